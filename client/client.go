@@ -0,0 +1,122 @@
+// Package client is a Go client of the content API for the services that
+// store their files in it. Uploads go straight to storage through direct
+// uploads: the client computes the SHA-256 of the data while streaming it,
+// retries failed attempts with a new presigned upload and confirms the
+// upload with the checksum, which the service verifies.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+)
+
+const (
+	defaultMaxAttempts = 3
+	defaultRetryDelay  = time.Second
+)
+
+// Config configures a Client
+type Config struct {
+	BaseURL     string        // Root of the service, e.g. "https://contents.example.com"
+	TenantID    string        // Sent as X-Tenant-ID if set
+	Principal   string        // Sent as X-Principal-ID if set
+	HTTPClient  *http.Client  // http.DefaultClient if nil
+	MaxAttempts int           // Attempts of an upload to storage, 3 if zero
+	RetryDelay  time.Duration // Wait before the first retry, doubled after each; 1s if zero
+}
+
+// Client calls the content API
+type Client struct {
+	config     Config
+	httpClient *http.Client
+}
+
+// New returns a client of the service at config.BaseURL
+func New(config Config) *Client {
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaultMaxAttempts
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = defaultRetryDelay
+	}
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{config: config, httpClient: httpClient}
+}
+
+// Error is an error response of the API
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("content API returned %d: %s", e.StatusCode, e.Message)
+}
+
+// GetContent retrieves a content item
+func (c *Client) GetContent(ctx context.Context, id uuid.UUID) (*model.Content, error) {
+	var content model.Content
+	if err := c.do(ctx, http.MethodGet, "/api/v1/contents/"+id.String(), nil, &content); err != nil {
+		return nil, err
+	}
+	return &content, nil
+}
+
+// do sends a request to the API with a JSON body, if not nil, and decodes
+// the JSON response into result
+func (c *Client) do(ctx context.Context, method, path string, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.config.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.config.TenantID != "" {
+		req.Header.Set("X-Tenant-ID", c.config.TenantID)
+	}
+	if c.config.Principal != "" {
+		req.Header.Set("X-Principal-ID", c.config.Principal)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		var response struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&response)
+		if response.Error == "" {
+			response.Error = http.StatusText(resp.StatusCode)
+		}
+		return &Error{StatusCode: resp.StatusCode, Message: response.Error}
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,185 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/storage"
+)
+
+// fakeService is the part of the content API and of storage uploads go through
+type fakeService struct {
+	t        *testing.T
+	server   *httptest.Server
+	failures []int // Statuses of the next PUTs to storage
+
+	mu       sync.Mutex
+	content  model.Content
+	stored   []byte
+	puts     int
+	renewals int
+}
+
+func newFakeService(t *testing.T, failures ...int) *fakeService {
+	f := &fakeService{t: t, failures: failures}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/contents", func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			FileSize int64 `json:"file_size"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		f.mu.Lock()
+		f.content = model.Content{ID: uuid.New(), Status: model.StatusCreated, FileSize: request.FileSize}
+		f.mu.Unlock()
+		f.respondUpload(w)
+	})
+	mux.HandleFunc("GET /api/v1/contents/{id}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if r.PathValue("id") != f.content.ID.String() {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Content not found"})
+			return
+		}
+		json.NewEncoder(w).Encode(f.content)
+	})
+	mux.HandleFunc("POST /api/v1/contents/{id}/upload", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.renewals++
+		f.mu.Unlock()
+		f.respondUpload(w)
+	})
+	mux.HandleFunc("PUT /bucket/{n}", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.puts++
+		if r.PathValue("n") != fmt.Sprint(f.renewals) {
+			w.WriteHeader(http.StatusForbidden) // Expired
+			return
+		}
+		if len(f.failures) > 0 {
+			status := f.failures[0]
+			f.failures = f.failures[1:]
+			w.WriteHeader(status)
+			return
+		}
+		f.stored = data
+	})
+	mux.HandleFunc("POST /api/v1/contents/{id}/uploaded", func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			SHA256 string `json:"sha256"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		sum := sha256.Sum256(f.stored)
+		if request.SHA256 != hex.EncodeToString(sum[:]) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(map[string]string{"error": "uploaded data does not match its checksum"})
+			return
+		}
+		f.content.Status = model.StatusUploaded
+		json.NewEncoder(w).Encode(f.content)
+	})
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// respondUpload returns the content with a presigned upload only valid until renewed
+func (f *fakeService) respondUpload(w http.ResponseWriter) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	json.NewEncoder(w).Encode(directUpload{
+		Content: f.content,
+		Upload:  &storage.PresignedUpload{Method: http.MethodPut, URL: fmt.Sprintf("%s/bucket/%d", f.server.URL, f.renewals)},
+	})
+}
+
+// onlyReader hides the io.Seeker of a reader
+type onlyReader struct{ io.Reader }
+
+func TestUpload(t *testing.T) {
+	data := []byte("%PDF-1.7 quarterly report")
+	tests := []struct {
+		name         string
+		failures     []int
+		data         io.Reader
+		wantErr      bool
+		wantPuts     int
+		wantRenewals int
+	}{
+		{name: "first attempt", wantPuts: 1},
+		{name: "retried with a new upload", failures: []int{http.StatusServiceUnavailable}, wantPuts: 2, wantRenewals: 1},
+		{name: "gives up", failures: []int{500, 500, 500}, wantErr: true, wantPuts: 3, wantRenewals: 2},
+		{name: "rejected", failures: []int{http.StatusBadRequest}, wantErr: true, wantPuts: 1},
+		{name: "not seekable", failures: []int{http.StatusServiceUnavailable}, data: onlyReader{bytes.NewReader(data)}, wantErr: true, wantPuts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeService(t, tt.failures...)
+			c := New(Config{BaseURL: f.server.URL, RetryDelay: 1})
+			input := tt.data
+			if input == nil {
+				input = bytes.NewReader(data)
+			}
+
+			content, err := c.Upload(context.Background(), UploadInput{FileName: "report.pdf", MIMEType: "application/pdf", Size: int64(len(data)), Data: input})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Upload() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && (content.Status != model.StatusUploaded || !bytes.Equal(f.stored, data)) {
+				t.Errorf("Upload() = status %q, stored %q", content.Status, f.stored)
+			}
+			if f.puts != tt.wantPuts || f.renewals != tt.wantRenewals {
+				t.Errorf("puts = %d, renewals = %d, want %d and %d", f.puts, f.renewals, tt.wantPuts, tt.wantRenewals)
+			}
+		})
+	}
+}
+
+func TestResumeUpload(t *testing.T) {
+	f := newFakeService(t, http.StatusServiceUnavailable)
+	c := New(Config{BaseURL: f.server.URL, MaxAttempts: 1})
+	ctx := context.Background()
+	data := "line 1\nline 2\n"
+
+	// The first process gives up after its only attempt
+	_, err := c.Upload(ctx, UploadInput{FileName: "log.txt", MIMEType: "text/plain", Size: int64(len(data)), Data: strings.NewReader(data)})
+	if err == nil {
+		t.Fatal("Upload() succeeded, want the storage failure")
+	}
+
+	content, err := c.ResumeUpload(ctx, f.content.ID, strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.Status != model.StatusUploaded || string(f.stored) != data {
+		t.Errorf("ResumeUpload() = status %q, stored %q", content.Status, f.stored)
+	}
+
+	// Resuming again doesn't send the data
+	puts := f.puts
+	if _, err := c.ResumeUpload(ctx, f.content.ID, strings.NewReader(data)); err != nil || f.puts != puts {
+		t.Errorf("ResumeUpload(uploaded) error = %v, puts %d, want no upload", err, f.puts-puts)
+	}
+
+	var apiErr *Error
+	if _, err := c.ResumeUpload(ctx, uuid.New(), strings.NewReader(data)); !errors.As(err, &apiErr) {
+		t.Errorf("ResumeUpload(unknown) error = %v, want an API error", err)
+	}
+}
@@ -0,0 +1,197 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/storage"
+)
+
+// UploadInput describes content to upload
+type UploadInput struct {
+	FileName    string
+	Description string // Markdown
+	MIMEType    string
+	Size        int64     // Exact size of Data in bytes
+	Data        io.Reader // Only retried if it is also an io.Seeker
+	EntityType  string
+	EntityID    string
+	Source      string
+	ExternalID  string
+	Metadata    model.Metadata
+}
+
+// directUpload is content created or renewed for a direct upload
+type directUpload struct {
+	model.Content
+	Upload *storage.PresignedUpload `json:"upload"`
+}
+
+// storageError is a failed response of storage to a presigned upload
+type storageError struct {
+	StatusCode int
+}
+
+func (e *storageError) Error() string {
+	return fmt.Sprintf("storage returned %d", e.StatusCode)
+}
+
+// Upload creates content and uploads its data straight to storage. The
+// SHA-256 of the data is computed while it streams and verified by the
+// service when the upload is confirmed. Failed attempts are retried with a
+// new presigned upload when Data can seek back to where it started.
+func (c *Client) Upload(ctx context.Context, input UploadInput) (*model.Content, error) {
+	if input.Data == nil || input.Size <= 0 {
+		return nil, errors.New("upload needs data of a positive size")
+	}
+
+	var created directUpload
+	err := c.do(ctx, http.MethodPost, "/api/v1/contents", map[string]interface{}{
+		"file_name":   input.FileName,
+		"description": input.Description,
+		"mime_type":   input.MIMEType,
+		"file_size":   input.Size,
+		"upload":      "put",
+		"entity_type": input.EntityType,
+		"entity_id":   input.EntityID,
+		"source":      input.Source,
+		"external_id": input.ExternalID,
+		"metadata":    input.Metadata,
+	}, &created)
+	if err != nil {
+		return nil, err
+	}
+	return c.send(ctx, &created.Content, created.Upload, input.Data)
+}
+
+// ResumeUpload uploads the data of content created by an earlier Upload
+// that didn't finish, e.g. because the process stopped, with a new
+// presigned upload. The service has no multipart sessions to pick up, so
+// the data is sent from its start. Content whose upload was already
+// confirmed is returned as it is.
+func (c *Client) ResumeUpload(ctx context.Context, id uuid.UUID, data io.Reader) (*model.Content, error) {
+	content, err := c.GetContent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if content.Status != model.StatusCreated {
+		return content, nil
+	}
+	renewed, err := c.renew(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return c.send(ctx, &renewed.Content, renewed.Upload, data)
+}
+
+// send uploads data with a presigned upload, retrying with a new one, and
+// confirms the upload with the SHA-256 of the data sent
+func (c *Client) send(ctx context.Context, content *model.Content, upload *storage.PresignedUpload, data io.Reader) (*model.Content, error) {
+	seeker, seekable := data.(io.Seeker)
+	var start int64
+	if seekable {
+		var err error
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			seekable = false
+		}
+	}
+
+	delay := c.config.RetryDelay
+	var checksum []byte
+	for attempt := 1; ; attempt++ {
+		hash := sha256.New()
+		err := c.put(ctx, upload, io.TeeReader(data, hash), content.FileSize)
+		if err == nil {
+			checksum = hash.Sum(nil)
+			break
+		}
+		if !seekable || attempt >= c.config.MaxAttempts || !retryable(ctx, err) {
+			return nil, fmt.Errorf("failed to upload the data of content %s after %d attempts: %w", content.ID, attempt, err)
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		delay *= 2
+		if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to rewind the data of content %s: %w", content.ID, err)
+		}
+		// The presigned upload may have expired, so every retry gets a new one
+		renewed, err := c.renew(ctx, content.ID)
+		if err != nil {
+			return nil, err
+		}
+		upload = renewed.Upload
+	}
+
+	var confirmed model.Content
+	path := "/api/v1/contents/" + content.ID.String() + "/uploaded"
+	if err := c.do(ctx, http.MethodPost, path, map[string]string{"sha256": hex.EncodeToString(checksum)}, &confirmed); err != nil {
+		return nil, err
+	}
+	return &confirmed, nil
+}
+
+// renew asks the service to presign the upload of content again
+func (c *Client) renew(ctx context.Context, id uuid.UUID) (*directUpload, error) {
+	var renewed directUpload
+	if err := c.do(ctx, http.MethodPost, "/api/v1/contents/"+id.String()+"/upload?upload=put", nil, &renewed); err != nil {
+		return nil, err
+	}
+	return &renewed, nil
+}
+
+// put sends data with a presigned PUT
+func (c *Client) put(ctx context.Context, upload *storage.PresignedUpload, data io.Reader, size int64) error {
+	if upload == nil || upload.Method != http.MethodPut {
+		return errors.New("the service didn't return a presigned PUT")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, upload.URL, data)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	for name, value := range upload.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return &storageError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+// retryable reports whether a failed upload to storage may succeed when
+// tried again: connection failures, expired signatures, throttling and
+// server errors
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var storageErr *storageError
+	if !errors.As(err, &storageErr) {
+		return true
+	}
+	switch code := storageErr.StatusCode; {
+	case code == http.StatusForbidden, code == http.StatusRequestTimeout, code == http.StatusTooManyRequests:
+		return true
+	default:
+		return code >= http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,95 @@
+// Package clock abstracts the current time and the generation of IDs, so
+// the service and repositories can be made deterministic in tests and can
+// create time-ordered UUIDv7 IDs, which keep the inserts of an index on the
+// ID close together, instead of random UUIDv4 ones.
+package clock
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Clock tells the current time
+type Clock interface {
+	Now() time.Time
+}
+
+// IDGenerator creates the IDs of new records
+type IDGenerator interface {
+	NewID() uuid.UUID
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// System is the clock of the system
+var System Clock = systemClock{}
+
+type randomIDs struct{}
+
+func (randomIDs) NewID() uuid.UUID { return uuid.New() }
+
+// RandomIDs creates random UUIDv4 IDs
+var RandomIDs IDGenerator = randomIDs{}
+
+type timeOrderedIDs struct{}
+
+func (timeOrderedIDs) NewID() uuid.UUID { return uuid.Must(uuid.NewV7()) }
+
+// TimeOrderedIDs creates UUIDv7 IDs, which start with the time they are
+// created at in milliseconds, so that IDs created later sort after. The
+// service and repositories create them by default.
+var TimeOrderedIDs IDGenerator = timeOrderedIDs{}
+
+// Manual is a clock that only moves when told to, for tests
+type Manual struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewManual returns a clock stopped at now
+func NewManual(now time.Time) *Manual {
+	return &Manual{now: now}
+}
+
+// Now returns the time the clock is at
+func (c *Manual) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set moves the clock to now
+func (c *Manual) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// Advance moves the clock forward by d
+func (c *Manual) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Sequence creates the IDs 00000000-0000-0000-0000-000000000001,
+// 00000000-0000-0000-0000-000000000002 and so on, for tests
+type Sequence struct {
+	mu   sync.Mutex
+	last uint64
+}
+
+// NewID returns the next ID of the sequence
+func (s *Sequence) NewID() uuid.UUID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last++
+	var id uuid.UUID
+	binary.BigEndian.PutUint64(id[8:], s.last)
+	return id
+}
@@ -0,0 +1,49 @@
+package clock
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestManual(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	c := NewManual(start)
+	if got := c.Now(); !got.Equal(start) {
+		t.Errorf("Now() = %v, want %v", got, start)
+	}
+	c.Advance(time.Hour)
+	if got, want := c.Now(), start.Add(time.Hour); !got.Equal(want) {
+		t.Errorf("Now() after Advance = %v, want %v", got, want)
+	}
+	c.Set(start)
+	if got := c.Now(); !got.Equal(start) {
+		t.Errorf("Now() after Set = %v, want %v", got, start)
+	}
+}
+
+func TestSequence(t *testing.T) {
+	var s Sequence
+	for _, want := range []string{
+		"00000000-0000-0000-0000-000000000001",
+		"00000000-0000-0000-0000-000000000002",
+	} {
+		if got := s.NewID().String(); got != want {
+			t.Errorf("NewID() = %s, want %s", got, want)
+		}
+	}
+}
+
+func TestTimeOrderedIDs(t *testing.T) {
+	previous := TimeOrderedIDs.NewID()
+	if previous.Version() != 7 {
+		t.Fatalf("NewID() version = %d, want 7", previous.Version())
+	}
+	for i := 0; i < 100; i++ {
+		id := TimeOrderedIDs.NewID()
+		if bytes.Compare(id[:], previous[:]) <= 0 {
+			t.Fatalf("NewID() = %s created after %s sorts before it", id, previous)
+		}
+		previous = id
+	}
+}
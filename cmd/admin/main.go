@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: admin [flags] <command>
+
+Commands:
+  export    Write all content metadata as NDJSON to stdout
+  import    Read NDJSON content metadata from stdin
+  backup    Write a backup archive of metadata and data to stdout
+  restore   Restore a backup archive from stdin
+  reconcile Check that uploaded content has its data in storage
+  purge     Permanently delete content last updated before -before
+  prune     Remove the records of content deleted before -before
+  prune-audit Remove the audit events that occurred before -before
+  reindex   Rebuild the search indexes, or those named by -index
+  transfer-ownership Make -to the creator of the content -from created in -tenant
+
+The admin token is read from the ADMIN_TOKEN environment variable.
+
+Flags:
+`)
+	flag.PrintDefaults()
+}
+
+func main() {
+	server := flag.String("server", "http://localhost:8080", "Base URL of the contents server")
+	conflict := flag.String("conflict", "skip", "Import/restore conflict policy: skip, overwrite or fail")
+	since := flag.String("since", "", "Only back up content updated at or after this RFC3339 time")
+	before := flag.String("before", "", "Purge content last updated, or prune content deleted or audit events, before this RFC3339 time")
+	status := flag.String("status", "", "Only purge content in this status")
+	repair := flag.Bool("repair", false, "Mark content with missing data as errored when reconciling")
+	dryRun := flag.Bool("dry-run", false, "Report what would be purged without deleting")
+	index := flag.String("index", "", "Comma-separated search indexes to rebuild, all of them if empty")
+	pause := flag.Duration("pause", 0, "Wait between rebuilding two search indexes")
+	tenant := flag.String("tenant", "", "Tenant whose content changes owner (empty = untenanted content)")
+	from := flag.String("from", "", "Principal whose content is transferred")
+	to := flag.String("to", "", "Principal the content is transferred to")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		usage()
+		os.Exit(2)
+	}
+
+	baseURL := strings.TrimRight(*server, "/") + "/admin/v1"
+
+	var err error
+	switch flag.Arg(0) {
+	case "export":
+		err = export(baseURL + "/export")
+	case "import":
+		err = upload(baseURL+"/import?conflict="+url.QueryEscape(*conflict), "application/x-ndjson")
+	case "backup":
+		err = export(baseURL + "/backup?since=" + url.QueryEscape(*since))
+	case "restore":
+		err = upload(baseURL+"/restore?conflict="+url.QueryEscape(*conflict), "application/zstd")
+	case "reconcile":
+		err = post(baseURL + "/reconcile?repair=" + strconv.FormatBool(*repair))
+	case "purge":
+		query := url.Values{
+			"before":  {*before},
+			"status":  {*status},
+			"dry_run": {strconv.FormatBool(*dryRun)},
+		}
+		err = post(baseURL + "/purge?" + query.Encode())
+	case "prune":
+		err = post(baseURL + "/prune-deleted?before=" + url.QueryEscape(*before))
+	case "prune-audit":
+		err = post(baseURL + "/prune-audit?before=" + url.QueryEscape(*before))
+	case "reindex":
+		query := url.Values{"pause": {pause.String()}}
+		for _, name := range strings.Split(*index, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				query.Add("index", name)
+			}
+		}
+		err = reindex(baseURL + "/reindex?" + query.Encode())
+	case "transfer-ownership":
+		err = postJSON(baseURL+"/ownership/transfer", map[string]string{"tenant_id": *tenant, "from": *from, "to": *to})
+	default:
+		usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// adminToken is sent as a bearer token with every request
+var adminToken = os.Getenv("ADMIN_TOKEN")
+
+// do sends an authenticated request to the admin API
+func do(method, endpoint, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if adminToken != "" {
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+	}
+	return http.DefaultClient.Do(req)
+}
+
+// export streams a server export to stdout
+func export(endpoint string) error {
+	resp, err := do(http.MethodGet, endpoint, "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(os.Stdout, resp.Body)
+	return err
+}
+
+// upload sends stdin to the server and prints the returned summary
+func upload(endpoint, contentType string) error {
+	resp, err := do(http.MethodPost, endpoint, contentType, os.Stdin)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(os.Stdout, resp.Body)
+	return err
+}
+
+// post triggers a server operation and prints the returned summary
+func post(endpoint string) error {
+	resp, err := do(http.MethodPost, endpoint, "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(os.Stdout, resp.Body)
+	return err
+}
+
+// postJSON sends a JSON body to the server and prints the response
+func postJSON(endpoint string, body interface{}) error {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	resp, err := do(http.MethodPost, endpoint, "application/json", bytes.NewReader(encoded))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(os.Stdout, resp.Body)
+	return err
+}
+
+// reindex triggers a rebuild of the search indexes and prints its progress
+// as it is reported, failing if the server reports an error midway
+func reindex(endpoint string) error {
+	resp, err := do(http.MethodPost, endpoint, "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		fmt.Println(scanner.Text())
+		var line struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &line) == nil && line.Error != "" {
+			return errors.New(line.Error)
+		}
+	}
+	return scanner.Err()
+}
+
+// checkResponse turns a non-2xx response into an error
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return fmt.Errorf("server returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
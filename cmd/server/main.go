@@ -8,48 +8,475 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	gofuse "github.com/hanwen/go-fuse/v2/fuse"
+	"github.com/livefire2015/simple-contents/clock"
+	"github.com/livefire2015/simple-contents/pkg/fuse"
+	"github.com/livefire2015/simple-contents/repository"
+	"github.com/livefire2015/simple-contents/repository/cache"
 	"github.com/livefire2015/simple-contents/repository/memory"
 	"github.com/livefire2015/simple-contents/service"
-	"github.com/livefire2015/simple-contents/storage/memorystorage"
+	"github.com/livefire2015/simple-contents/signature/docusign"
+	"github.com/livefire2015/simple-contents/signature/dropboxsign"
+	"github.com/livefire2015/simple-contents/storage/cachestorage"
+	"github.com/livefire2015/simple-contents/storage/cdn"
+	"github.com/livefire2015/simple-contents/storage/replicatedstorage"
+	"github.com/livefire2015/simple-contents/storage/retrystorage"
+	"github.com/livefire2015/simple-contents/storage/tenantstorage"
+	transportGrpc "github.com/livefire2015/simple-contents/transport/grpc"
 	transportHttp "github.com/livefire2015/simple-contents/transport/http"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 )
 
 func main() {
 	// Parse command line flags
 	port := flag.Int("port", 8080, "HTTP server port")
+	requestRate := flag.Int64("download-rate", 0, "Per-download bandwidth limit in bytes/sec (0 = unlimited)")
+	tenantRate := flag.Int64("tenant-download-rate", 0, "Per-tenant download bandwidth limit in bytes/sec (0 = unlimited)")
+	auditTransfers := flag.Bool("audit-transfers", false, "Record the bytes and duration of every upload and download as audit events")
+	verifyDownloads := flag.Bool("verify-downloads", false, "Check streamed content data against its stored MD5 checksum")
+	blockPIIURLs := flag.Bool("block-pii-urls", false, "Refuse presigned URLs for content flagged with PII unless the request has an elevated scope")
+	sanitizeSources := flag.String("sanitize-image-sources", "*", "Comma-separated sources whose images are stripped of EXIF/GPS data (* = all, empty = none)")
+	keepOriginals := flag.Bool("keep-original-images", false, "Keep unsanitized images for requests with an elevated scope")
+	ffmpegPath := flag.String("ffmpeg", "ffmpeg", "ffmpeg executable used for transcoding (empty = transcoding disabled)")
+	sofficePath := flag.String("soffice", "soffice", "LibreOffice executable used for PDF conversion (empty = conversion disabled)")
+	gotenbergURL := flag.String("gotenberg-url", "", "Gotenberg service used for PDF conversion instead of a local LibreOffice")
+	chromiumPath := flag.String("chromium", "chromium", "Headless Chromium used to render templates to PDF when no Gotenberg service is set (empty = PDF rendering disabled)")
+	deletionPolicy := flag.String("deletion-policy", "force", "Default policy for deleting linked content: force, unlink or last_reference")
+	adminPort := flag.Int("admin-port", 0, "Admin API port (0 = serve the admin API on the main port)")
+	webDAVTypes := flag.String("webdav-entity-types", "", "Comma-separated entity types served as WebDAV folders under /dav (empty = WebDAV disabled)")
+	webDAVWritable := flag.Bool("webdav-writable", false, "Allow uploading, renaming and deleting files over WebDAV")
+	ui := flag.Bool("ui", false, "Serve a web UI for browsing, previewing and uploading content under /ui")
+	uiTypes := flag.String("ui-entity-types", "", "Comma-separated entity types offered by the web UI")
+	uiUploads := flag.Bool("ui-uploads", false, "Allow uploading files to entities from the web UI")
+	fuseMount := flag.String("fuse-mount", "", "Directory the content catalogue is mounted on as a local filesystem (empty = not mounted)")
+	fuseTypes := flag.String("fuse-entity-types", "", "Comma-separated entity types listed at the root of the FUSE mount")
+	fuseWritable := flag.Bool("fuse-writable", false, "Allow saving, renaming and deleting files in the FUSE mount")
+	s3Port := flag.Int("s3-port", 0, "S3-compatible gateway port (0 = gateway disabled)")
+	s3Buckets := flag.String("s3-buckets", "", "Comma-separated gateway buckets as name=tenant:entity_type")
+	s3Region := flag.String("s3-region", "us-east-1", "Region the gateway's clients sign requests for")
+	requestTimeout := flag.Duration("request-timeout", transportHttp.DefaultRequestTimeoutConfig().Metadata, "Deadline of requests that don't transfer content data (0 = none)")
+	transferTimeout := flag.Duration("transfer-timeout", transportHttp.DefaultRequestTimeoutConfig().Transfer, "Deadline of uploads, downloads and long admin operations (0 = none)")
+	routeTimeouts := flag.String("route-timeouts", "", "Comma-separated deadlines of single routes as route=duration, e.g. \"POST /api/v1/contents/from-url=2m\" (0 = none)")
+	slowRequest := flag.Duration("slow-request", transportHttp.DefaultRequestTimeoutConfig().SlowRequest, "Requests taking longer are logged (0 = no slow request log)")
+	connectOrigins := flag.String("connect-origins", "", "Comma-separated origins whose pages may call the Connect API besides the service itself (* = any)")
+	wsOrigins := flag.String("websocket-origins", "", "Comma-separated origins whose pages may open WebSocket connections besides the service itself (* = any)")
+	previewAncestors := flag.String("preview-frame-ancestors", "", "Space-separated origins allowed to embed content previews (empty = same origin only)")
+	pdfViewerURL := flag.String("pdfjs-viewer", "", "PDF.js viewer.html URL used by content previews (empty = the browser's PDF viewer)")
+	publicURL := flag.String("public-url", "", "Public base URL of the service used in short download links, shares and upload requests (empty = the request host)")
+	cdnConfig := flag.String("cdn-config", "", "JSON file of CDNs that tenants can serve download URLs through")
+	entityTypes := flag.String("entity-types", "", "JSON file of the entity types content can be linked to and their rules (empty = any entity type)")
+	metadataSchemas := flag.String("metadata-schemas", "", "JSON file of the JSON Schemas content metadata must match, by source and entity type (empty = free-form metadata)")
+	metadataMaxBytes := flag.Int("metadata-max-bytes", service.DefaultMetadataLimits().MaxBytes, "Largest content metadata, encoded as JSON (0 = unlimited)")
+	metadataMaxKeys := flag.Int("metadata-max-keys", service.DefaultMetadataLimits().MaxKeys, "Maximum keys of content metadata, nested objects included (0 = unlimited)")
+	metadataMaxDepth := flag.Int("metadata-max-depth", service.DefaultMetadataLimits().MaxDepth, "Maximum nesting of objects and arrays in content metadata, 1 = flat (0 = unlimited)")
+	metadataValueTypes := flag.String("metadata-value-types", "", "Comma-separated JSON types metadata values may have: string, number, boolean, null, object, array (empty = any)")
+	maxAttachments := flag.Int("max-attachments", 0, "Maximum content linked to one entity (0 = unlimited)")
+	maxRoleAttachments := flag.String("max-attachments-per-role", "", "Comma-separated maximum content linked to one entity per association role, as role=limit")
+	storageSpec := flag.String("storage", "memory", "Storage backend: memory, s3://bucket?region=<region>, gs://bucket or minio://host:port/bucket")
+	storageAttempts := flag.Int("storage-attempts", 3, "Attempts for idempotent storage operations (1 = no retries)")
+	storageBreaker := flag.Int("storage-breaker-threshold", 5, "Consecutive storage failures that stop calls to the backend for a while (0 = no circuit breaker)")
+	storageHealthInterval := flag.Duration("storage-health-interval", service.DefaultStorageHealthConfig().Interval, "How often storage is checked, uploads are refused while it is down (0 = no health checks)")
+	storageHealthFailures := flag.Int("storage-health-failures", service.DefaultStorageHealthConfig().FailureThreshold, "Consecutive failed storage health checks before uploads are refused")
+	replicaSpec := flag.String("storage-replica", "", "Secondary storage backend every change is mirrored to, and reads fail over to (empty = no replica)")
+	replicaQueue := flag.Int("storage-replica-queue", 1024, "Mirror writes queued for the replica before changes are left to the repair job")
+	replicaRepair := flag.Duration("storage-replica-repair", 5*time.Minute, "How often changes that didn't reach the replica are retried")
+	cacheBytes := flag.Int64("storage-cache-bytes", 0, "Memory used to cache downloaded objects (0 = no cache)")
+	cacheMaxObject := flag.Int64("storage-cache-max-object", 8<<20, "Largest object kept in the download cache")
+	cacheDir := flag.String("storage-cache-dir", "", "Directory caching downloaded objects evicted from memory (empty = memory only)")
+	cacheDirBytes := flag.Int64("storage-cache-dir-bytes", 1<<30, "Disk space used by -storage-cache-dir")
+	repoCacheTTL := flag.Duration("repository-cache-ttl", 0, "How long content records are cached in memory (0 = no cache)")
+	repoListCacheTTL := flag.Duration("repository-list-cache-ttl", 0, "How long content listings are cached in memory (0 = listings not cached)")
+	urlExpiry := flag.Duration("url-expiry", service.DefaultURLExpiryPolicy().Default, "Lifetime of presigned content URLs requested without an expiry")
+	urlMaxExpiry := flag.Duration("url-max-expiry", service.DefaultURLExpiryPolicy().Max, "Longest lifetime of presigned content URLs, unless their tenant sets its own (0 = unlimited)")
+	urlElevatedMaxExpiry := flag.Duration("url-elevated-max-expiry", 0, "Longest lifetime of presigned content URLs requested with the elevated scope (0 = that of other callers)")
+	remoteMaxSize := flag.Int64("remote-fetch-max-size", service.DefaultRemoteFetchConfig().MaxSize, "Largest response accepted when creating content from a URL")
+	remoteTimeout := flag.Duration("remote-fetch-timeout", service.DefaultRemoteFetchConfig().Timeout, "Deadline for fetching content from a URL")
+	remotePrivate := flag.Bool("remote-fetch-allow-private", false, "Allow fetching content from loopback, private and other non-public addresses")
+	storageKeys := flag.String("storage-keys", "flat", "Layout of the storage keys of new content: flat ({id}/{file name}), or slash-separated prefixes of tenant, date and hash put in front, e.g. tenant/date")
+	keyMaxBytes := flag.Int("storage-key-max-bytes", service.DefaultKeyLimits().MaxKeyBytes, "Bytes a storage key may take; longer file names are shortened in the key")
+	keySegmentMaxBytes := flag.Int("storage-key-max-segment-bytes", service.DefaultKeyLimits().MaxSegmentBytes, "Bytes each slash-separated segment of a storage key may take")
+	timeOrderedIDs := flag.Bool("time-ordered-ids", true, "Create UUIDv7 IDs, which sort by creation time, for new records (false = random UUIDv4 IDs)")
+	metadataIndexes := flag.String("indexed-metadata-keys", "", "Comma-separated metadata keys looked up through an index when filtering")
+	exportBuckets := flag.String("export-buckets", "", "Comma-separated buckets of the storage backend content can be exported to (empty = exports disabled)")
+	scheduleConfig := flag.String("schedule", "", "JSON file of the cron schedules of retention sweeps, reconciliation, abandoned upload cleanup and usage recalculation (empty = no scheduled tasks)")
+	clamdAddress := flag.String("clamd", "", "clamd address, host:port or Unix socket path, used to scan uploads for viruses (empty = scanning disabled)")
 	flag.Parse()
 
 	// Create repository and storage implementations
-	// For this example, we'll use in-memory implementations
+	// For this example, we'll use an in-memory repository
 	repo := memory.NewMemoryRepository()
-	storage := memorystorage.NewMemoryStorage()
+	if *metadataIndexes != "" {
+		if err := repo.ConfigureMetadataIndexes(strings.Split(*metadataIndexes, ",")...); err != nil {
+			log.Fatalf("Invalid indexed metadata keys: %v", err)
+		}
+	}
+	ids := clock.RandomIDs
+	if *timeOrderedIDs {
+		ids = clock.TimeOrderedIDs
+	}
+	repo.ConfigureIDs(ids)
+	tenantService := service.NewTenantService(repo)
+
+	// Transient backend errors are retried, and a failing backend is given a rest
+	retryConfig := retrystorage.DefaultConfig()
+	retryConfig.MaxAttempts = *storageAttempts
+	retryConfig.FailureThreshold = *storageBreaker
+	backend, err := newStorageBackend(context.Background(), *storageSpec)
+	if err != nil {
+		log.Fatalf("Failed to create storage: %v", err)
+	}
+	backend = retrystorage.NewRetryStorage(backend, retryConfig)
+
+	var replicated *replicatedstorage.ReplicatedStorage
+	if *replicaSpec != "" {
+		replica, err := newStorageBackend(context.Background(), *replicaSpec)
+		if err != nil {
+			log.Fatalf("Failed to create storage replica: %v", err)
+		}
+		replicated = replicatedstorage.NewReplicatedStorage(backend, retrystorage.NewRetryStorage(replica, retryConfig), *replicaQueue)
+		backend = replicated
+	}
+	if *cacheBytes > 0 {
+		cacheConfig := cachestorage.Config{MaxBytes: *cacheBytes, MaxObjectSize: *cacheMaxObject}
+		if *cacheDir != "" {
+			if cacheConfig.Tier, err = cachestorage.NewDiskTier(*cacheDir, *cacheDirBytes); err != nil {
+				log.Fatalf("Failed to create storage cache directory: %v", err)
+			}
+		}
+		backend = cachestorage.NewCacheStorage(backend, cacheConfig)
+	}
+
+	// Tenants are isolated by key prefix, or by bucket through a named backend
+	storage := tenantstorage.NewTenantStorage(backend, tenantstorage.ResolverFunc(
+		func(ctx context.Context, tenantID string) (tenantstorage.Placement, error) {
+			tenant, err := tenantService.GetTenant(ctx, tenantID)
+			if err != nil {
+				return tenantstorage.Placement{}, err
+			}
+			return tenantstorage.Placement{Backend: tenant.StorageBackend, Prefix: tenant.StoragePrefix, CDN: tenant.CDN}, nil
+		}))
+	if *cdnConfig != "" {
+		signers, err := cdn.LoadConfig(*cdnConfig)
+		if err != nil {
+			log.Fatalf("Failed to load CDN configuration: %v", err)
+		}
+		for name, signer := range signers {
+			storage.RegisterCDN(name, signer)
+		}
+	}
+
+	// Content reads can be cached, writes through the content service evict them
+	var contentRepo repository.ContentRepository = repo
+	if *repoCacheTTL > 0 {
+		contentRepo = cache.NewCachedRepository(repo, cache.NewMemoryCache(), cache.Config{
+			ContentTTL: *repoCacheTTL,
+			ListTTL:    *repoListCacheTTL,
+		})
+	}
 
 	// Create content service
-	contentService := service.NewContentService(repo, storage)
+	contentService := service.NewContentService(contentRepo, storage)
+	contentService.ConfigureTenants(tenantService)
+	contentService.ConfigureIDs(ids)
+	keyStrategy, err := service.ParseKeyStrategy(*storageKeys)
+	if err != nil {
+		log.Fatalf("Invalid storage key layout: %v", err)
+	}
+	contentService.ConfigureKeyStrategy(keyStrategy)
+	if err := contentService.ConfigureKeyLimits(service.KeyLimits{MaxKeyBytes: *keyMaxBytes, MaxSegmentBytes: *keySegmentMaxBytes}); err != nil {
+		log.Fatalf("Invalid storage key limits: %v", err)
+	}
+	contentService.ConfigureRemoteFetch(service.RemoteFetchConfig{
+		MaxSize:              *remoteMaxSize,
+		Timeout:              *remoteTimeout,
+		AllowPrivateNetworks: *remotePrivate,
+	})
+	contentService.ConfigureClassifiers(service.NewRulesClassifier(service.DefaultClassificationRules()...))
+	contentService.EnablePIIDetection(service.PIIConfig{BlockPresignedURLs: *blockPIIURLs})
+	contentService.EnableAuditLog(repo)
+	contentService.ConfigureURLExpiry(service.URLExpiryPolicy{Default: *urlExpiry, Max: *urlMaxExpiry, ElevatedMax: *urlElevatedMaxExpiry})
+	if *auditTransfers {
+		contentService.EnableTransferAudit(nil)
+	}
+	contentService.ConfigureSearchIndexes(repo)
+	contentService.ConfigureJobs(repo)
+	if *exportBuckets != "" {
+		contentService.ConfigureExports(service.ExportConfig{Buckets: strings.Split(*exportBuckets, ",")})
+	}
+	if *sanitizeSources != "" {
+		sanitizeConfig := service.ImageSanitizationConfig{KeepOriginal: *keepOriginals}
+		if *sanitizeSources != "*" {
+			sanitizeConfig.Sources = strings.Split(*sanitizeSources, ",")
+		}
+		contentService.EnableImageSanitization(sanitizeConfig)
+	}
+	roleLimits, err := service.ParseRoleLimits(*maxRoleAttachments)
+	if err != nil {
+		log.Fatalf("Invalid attachment limits: %v", err)
+	}
+	if err := contentService.ConfigureAttachmentLimits(service.AttachmentLimits{MaxAttachments: *maxAttachments, MaxAttachmentsPerRole: roleLimits}); err != nil {
+		log.Fatalf("Invalid attachment limits: %v", err)
+	}
+	if *entityTypes != "" {
+		registry, err := service.LoadEntityTypes(*entityTypes)
+		if err != nil {
+			log.Fatalf("Failed to load entity types: %v", err)
+		}
+		contentService.ConfigureEntityTypes(registry)
+	}
+	metadataLimits := service.MetadataLimits{MaxBytes: *metadataMaxBytes, MaxKeys: *metadataMaxKeys, MaxDepth: *metadataMaxDepth}
+	if *metadataValueTypes != "" {
+		metadataLimits.ValueTypes = strings.Split(*metadataValueTypes, ",")
+	}
+	if err := contentService.ConfigureMetadataLimits(metadataLimits); err != nil {
+		log.Fatalf("Invalid metadata limits: %v", err)
+	}
+	if *metadataSchemas != "" {
+		schemas, err := service.LoadMetadataSchemas(*metadataSchemas)
+		if err != nil {
+			log.Fatalf("Failed to load metadata schemas: %v", err)
+		}
+		contentService.ConfigureMetadataSchemas(schemas)
+	}
+	if policy := service.DeletionPolicy(*deletionPolicy); policy.IsValid() {
+		contentService.ConfigureDeletionPolicy(policy)
+	} else {
+		log.Fatalf("Unknown deletion policy %q", *deletionPolicy)
+	}
+	if *storageHealthInterval > 0 {
+		contentService.EnableStorageHealthChecks(service.StorageHealthConfig{
+			Interval:         *storageHealthInterval,
+			FailureThreshold: *storageHealthFailures,
+		}, repo)
+	}
+	if *clamdAddress != "" {
+		contentService.EnableVirusScanning(service.NewClamdScanner(*clamdAddress), repo)
+	}
+	if *ffmpegPath != "" {
+		contentService.ConfigureTranscoder(service.NewFFmpegTranscoder(*ffmpegPath))
+	}
+	if *gotenbergURL != "" {
+		contentService.ConfigureConverter(service.NewGotenbergConverter(*gotenbergURL))
+	} else if *sofficePath != "" {
+		contentService.ConfigureConverter(service.NewLibreOfficeConverter(*sofficePath))
+	}
+	if secret := os.Getenv("WEBHOOK_SECRET"); secret != "" {
+		webhookConfig := service.DefaultWebhookConfig()
+		webhookConfig.Secret = []byte(secret)
+		contentService.ConfigureWebhooks(webhookConfig)
+	}
 
 	// Create HTTP handler
 	contentHandler := transportHttp.NewContentHandler(contentService)
+	// One throttle shared by every download path, so a tenant's limit covers them all
+	var throttle *transportHttp.DownloadThrottle
+	if *requestRate > 0 || *tenantRate > 0 {
+		throttle = transportHttp.NewDownloadThrottle(transportHttp.DownloadThrottleConfig{
+			PerRequestBytesPerSec: *requestRate,
+			PerTenantBytesPerSec:  *tenantRate,
+		})
+		contentHandler.EnableDownloadThrottle(throttle)
+	}
+	if *verifyDownloads {
+		contentHandler.EnableDownloadVerification()
+	}
+	if *wsOrigins != "" {
+		contentHandler.ConfigureWebSocket(transportHttp.WebSocketConfig{AllowedOrigins: strings.Split(*wsOrigins, ",")})
+	}
+	contentHandler.ConfigurePreview(transportHttp.PreviewConfig{
+		FrameAncestors: strings.Fields(*previewAncestors),
+		PDFViewerURL:   *pdfViewerURL,
+	})
+	contentHandler.EnableAnnotations(service.NewAnnotationService(repo, contentService))
+	contentHandler.EnableDocuments(service.NewDocumentService(repo, contentService))
+	contentHandler.EnableSavedSearches(service.NewSavedSearchService(repo, contentService))
+	contentHandler.EnableDownloadLinks(service.NewDownloadLinkService(repo, contentService), *publicURL)
+	contentHandler.EnableShares(service.NewShareService(repo, contentService), *publicURL)
+	contentHandler.EnableUploadRequests(service.NewUploadRequestService(repo, contentService), *publicURL)
+
+	// E-signature providers are enabled by their credentials
+	signatureService := service.NewSignatureService(repo, contentService)
+	if token := os.Getenv("DOCUSIGN_ACCESS_TOKEN"); token != "" {
+		signatureService.RegisterProvider(docusign.NewProvider(docusign.Config{
+			BaseURL:     os.Getenv("DOCUSIGN_BASE_URL"),
+			AccountID:   os.Getenv("DOCUSIGN_ACCOUNT_ID"),
+			AccessToken: token,
+			ConnectKey:  os.Getenv("DOCUSIGN_CONNECT_KEY"),
+		}))
+	}
+	if apiKey := os.Getenv("DROPBOX_SIGN_API_KEY"); apiKey != "" {
+		signatureService.RegisterProvider(dropboxsign.NewProvider(dropboxsign.Config{
+			BaseURL:  os.Getenv("DROPBOX_SIGN_BASE_URL"),
+			APIKey:   apiKey,
+			TestMode: os.Getenv("DROPBOX_SIGN_TEST_MODE") != "",
+		}))
+	}
+	contentHandler.EnableSignatures(signatureService)
+
+	templateService := service.NewTemplateService(repo, contentService)
+	if *gotenbergURL != "" {
+		templateService.ConfigureRenderer(service.NewGotenbergConverter(*gotenbergURL))
+	} else if *chromiumPath != "" {
+		templateService.ConfigureRenderer(service.NewChromiumRenderer(*chromiumPath))
+	}
+	contentHandler.EnableTemplates(templateService)
+	if token := os.Getenv("ELEVATED_TOKEN"); token != "" {
+		contentHandler.EnableElevatedScope(token)
+	}
+
+	// The admin API is disabled unless a token is configured
+	adminHandler := transportHttp.NewAdminHandler(contentService, tenantService, os.Getenv("ADMIN_TOKEN"))
+	adminTokens, err := transportHttp.ParseAdminTokens(os.Getenv("ADMIN_TOKENS"))
+	if err != nil {
+		log.Fatalf("Invalid ADMIN_TOKENS: %v", err)
+	}
+	for name, token := range adminTokens {
+		adminHandler.AddAdmin(name, token)
+	}
+
+	// The gRPC API, its generated JSON gateway and the Connect API share the main listener
+	grpcServer := grpc.NewServer()
+	contentServer := transportGrpc.NewContentServer(contentService)
+	contentServer.Register(grpcServer)
+	reflection.Register(grpcServer)
+	gateway, err := contentServer.Gateway(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to create API gateway: %v", err)
+	}
+	var connectConfig transportGrpc.ConnectConfig
+	if *connectOrigins != "" {
+		connectConfig.AllowedOrigins = strings.Split(*connectOrigins, ",")
+	}
+	connectPath, connectHandler := contentServer.Connect(connectConfig)
+
+	// Requests are given deadlines by route, transfers get longer ones
+	timeouts := transportHttp.RequestTimeoutConfig{
+		Metadata:    *requestTimeout,
+		Transfer:    *transferTimeout,
+		SlowRequest: *slowRequest,
+	}
+	if timeouts.Routes, err = transportHttp.ParseRouteTimeouts(*routeTimeouts); err != nil {
+		log.Fatalf("Invalid route timeouts: %v", err)
+	}
+	// Connect streams downloads and entity events
+	if _, ok := timeouts.Routes[connectPath+"*"]; !ok {
+		timeouts.Routes[connectPath+"*"] = *transferTimeout
+	}
 
 	// Create router and register routes
 	router := chi.NewRouter()
+	router.Use(transportHttp.RequestTimeouts(router, timeouts))
 	contentHandler.RegisterRoutes(router)
+	if *webDAVTypes != "" {
+		transportHttp.RegisterWebDAV(router, transportHttp.NewWebDAVHandler(contentService, transportHttp.WebDAVConfig{
+			EntityTypes: strings.Split(*webDAVTypes, ","),
+			Writable:    *webDAVWritable,
+			Throttle:    throttle,
+		}))
+	}
+	if *ui {
+		uiConfig := transportHttp.UIConfig{Uploads: *uiUploads}
+		if *uiTypes != "" {
+			uiConfig.EntityTypes = strings.Split(*uiTypes, ",")
+		}
+		transportHttp.NewUIHandler(contentService, uiConfig).RegisterRoutes(router)
+	}
+
+	router.Handle("/api/v2/*", gateway)
+	router.Handle(connectPath+"*", connectHandler)
 
-	// Create HTTP server
-	server := &http.Server{
+	// Create HTTP servers
+	servers := []*http.Server{{
 		Addr:    fmt.Sprintf(":%d", *port),
-		Handler: router,
+		Handler: transportGrpc.Handler(grpcServer, router),
+	}}
+	if *adminPort == 0 {
+		adminHandler.RegisterRoutes(router)
+	} else {
+		adminRouter := chi.NewRouter()
+		adminRouter.Use(transportHttp.RequestTimeouts(adminRouter, timeouts))
+		adminRouter.Use(middleware.Logger)
+		adminRouter.Use(middleware.Recoverer)
+		adminHandler.RegisterRoutes(adminRouter)
+		servers = append(servers, &http.Server{
+			Addr:    fmt.Sprintf(":%d", *adminPort),
+			Handler: adminRouter,
+		})
+	}
+
+	// The S3-compatible gateway needs its own listener, as S3 paths start with the bucket
+	if *s3Port != 0 {
+		buckets, err := service.ParseObjectBuckets(*s3Buckets)
+		if err != nil {
+			log.Fatalf("Invalid -s3-buckets: %v", err)
+		}
+		accessKey, secretKey := os.Getenv("S3_GATEWAY_ACCESS_KEY"), os.Getenv("S3_GATEWAY_SECRET_KEY")
+		if accessKey == "" || secretKey == "" {
+			log.Fatalf("S3_GATEWAY_ACCESS_KEY and S3_GATEWAY_SECRET_KEY are required for the S3 gateway")
+		}
+		s3Handler := transportHttp.NewS3GatewayHandler(contentService, transportHttp.S3GatewayConfig{
+			AccessKey: accessKey,
+			SecretKey: secretKey,
+			Region:    *s3Region,
+			Buckets:   buckets,
+			Throttle:  throttle,
+		})
+		s3Router := chi.NewRouter()
+		s3Router.Use(transportHttp.RequestTimeouts(s3Router, timeouts))
+		s3Handler.RegisterRoutes(s3Router)
+		servers = append(servers, &http.Server{
+			Addr:    fmt.Sprintf(":%d", *s3Port),
+			Handler: s3Router,
+		})
 	}
 
-	// Start server in a goroutine
-	serverErrors := make(chan error, 1)
-	go func() {
-		log.Printf("Starting server on port %d", *port)
-		serverErrors <- server.ListenAndServe()
-	}()
+	// Background jobs run until shutdown
+	background, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	if replicated != nil {
+		go replicated.RunRepair(background, *replicaRepair)
+	}
+	go contentService.RunStorageHealthChecks(background)
+	if *scheduleConfig != "" {
+		config, err := service.LoadScheduleConfig(*scheduleConfig)
+		if err != nil {
+			log.Fatalf("Failed to load schedule configuration: %v", err)
+		}
+		// Replicas sharing the repository elect one of them to run the tasks
+		scheduler, err := service.NewScheduler(contentService, repo, config)
+		if err != nil {
+			log.Fatalf("Invalid schedule configuration: %v", err)
+		}
+		go scheduler.Run(background)
+	}
+
+	var fuseServer *gofuse.Server
+	if *fuseMount != "" {
+		fuseServer, err = fuse.Mount(*fuseMount, contentService, fuse.Config{
+			EntityTypes: strings.Split(*fuseTypes, ","),
+			Writable:    *fuseWritable,
+		})
+		if err != nil {
+			log.Fatalf("Failed to mount %s: %v", *fuseMount, err)
+		}
+		log.Printf("Mounted content catalogue on %s", *fuseMount)
+	}
+
+	// Start servers in goroutines
+	serverErrors := make(chan error, len(servers))
+	for _, server := range servers {
+		go func(server *http.Server) {
+			log.Printf("Starting server on %s", server.Addr)
+			serverErrors <- server.ListenAndServe()
+		}(server)
+	}
 
 	// Wait for interrupt signal to gracefully shut down the server
 	shutdown := make(chan os.Signal, 1)
@@ -66,10 +493,24 @@ func main() {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		// Attempt to gracefully shut down the server
-		if err := server.Shutdown(ctx); err != nil {
-			log.Printf("Error during server shutdown: %v", err)
-			server.Close()
+		// Attempt to gracefully shut down the servers
+		for _, server := range servers {
+			if err := server.Shutdown(ctx); err != nil {
+				log.Printf("Error during server shutdown: %v", err)
+				server.Close()
+			}
+		}
+
+		if fuseServer != nil {
+			if err := fuseServer.Unmount(); err != nil {
+				log.Printf("Error unmounting %s: %v", *fuseMount, err)
+			}
+		}
+
+		// Queued mirror writes are applied before exiting
+		stopBackground()
+		if replicated != nil {
+			replicated.Close()
 		}
 	}
 }
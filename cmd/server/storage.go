@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+
+	gcpstorage "cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go-v2/config"
+	awss3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/livefire2015/simple-contents/storage"
+	"github.com/livefire2015/simple-contents/storage/gcp"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+	"github.com/livefire2015/simple-contents/storage/minio"
+	"github.com/livefire2015/simple-contents/storage/s3"
+	miniogo "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// newStorageBackend creates the storage backend described by spec:
+//
+//	memory
+//	s3://bucket?region=eu-west-1   credentials from the AWS default chain, large objects
+//	                               downloaded in parallel with ?download_concurrency=8&download_part_size=16777216
+//	gs://bucket                    credentials from Application Default Credentials
+//	minio://host:port/bucket       credentials from MINIO_ACCESS_KEY and MINIO_SECRET_KEY, ?insecure=true for plain HTTP
+func newStorageBackend(ctx context.Context, spec string) (storage.StorageService, error) {
+	if spec == "memory" {
+		return memorystorage.NewMemoryStorage(), nil
+	}
+
+	u, err := url.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage %q: %w", spec, err)
+	}
+	switch u.Scheme {
+	case "s3":
+		var options []func(*config.LoadOptions) error
+		if region := u.Query().Get("region"); region != "" {
+			options = append(options, config.WithRegion(region))
+		}
+		awsConfig, err := config.LoadDefaultConfig(ctx, options...)
+		if err != nil {
+			return nil, err
+		}
+		backend := s3.NewS3Storage(awss3.NewFromConfig(awsConfig), u.Host, awsConfig.Region)
+		if concurrency := u.Query().Get("download_concurrency"); concurrency != "" {
+			parallel := s3.ParallelDownloadConfig{PartSize: 16 << 20}
+			if parallel.Concurrency, err = strconv.Atoi(concurrency); err != nil {
+				return nil, fmt.Errorf("invalid download_concurrency %q", concurrency)
+			}
+			if partSize := u.Query().Get("download_part_size"); partSize != "" {
+				if parallel.PartSize, err = strconv.ParseInt(partSize, 10, 64); err != nil || parallel.PartSize <= 0 {
+					return nil, fmt.Errorf("invalid download_part_size %q", partSize)
+				}
+			}
+			backend.EnableParallelDownload(parallel)
+		}
+		return backend, nil
+	case "gs":
+		client, err := gcpstorage.NewClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return gcp.NewGCPStorage(client, u.Host), nil
+	case "minio":
+		bucket := u.Path
+		if len(bucket) > 0 && bucket[0] == '/' {
+			bucket = bucket[1:]
+		}
+		if bucket == "" {
+			return nil, fmt.Errorf("invalid storage %q: missing bucket", spec)
+		}
+		client, err := miniogo.New(u.Host, &miniogo.Options{
+			Creds:  credentials.NewStaticV4(os.Getenv("MINIO_ACCESS_KEY"), os.Getenv("MINIO_SECRET_KEY"), ""),
+			Secure: u.Query().Get("insecure") != "true",
+		})
+		if err != nil {
+			return nil, err
+		}
+		return minio.NewMinioStorage(client, bucket), nil
+	default:
+		return nil, fmt.Errorf("unknown storage %q, expected memory, s3://, gs:// or minio://", spec)
+	}
+}
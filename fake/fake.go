@@ -0,0 +1,54 @@
+// Package fake provides in-memory fakes of the content repository, the
+// storage service and the content service, for services using this module
+// to unit-test against it without a database or a bucket:
+//
+//	contents := fake.NewContentService()
+//	contents.Storage.Fail("Upload", errors.New("bucket unavailable"))
+//	_, err := contents.CreateContent(ctx, input) // Fails as an outage would
+//
+// The fakes behave like the real implementations, keeping their data in
+// memory. Any method can be made to fail with Fail, and Calls counts how
+// often a method was called.
+package fake
+
+import "sync"
+
+// Recorder counts the calls of the methods of a fake and holds the errors
+// they are made to fail with
+type Recorder struct {
+	mu       sync.Mutex
+	calls    map[string]int
+	failures map[string]error
+}
+
+// Fail makes a method fail with err from now on, or succeed again if err is nil
+func (r *Recorder) Fail(method string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failures == nil {
+		r.failures = make(map[string]error)
+	}
+	if err == nil {
+		delete(r.failures, method)
+		return
+	}
+	r.failures[method] = err
+}
+
+// Calls returns how often a method was called, failed calls included
+func (r *Recorder) Calls(method string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls[method]
+}
+
+// call records a call of a method and returns the error it is made to fail with
+func (r *Recorder) call(method string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.calls == nil {
+		r.calls = make(map[string]int)
+	}
+	r.calls[method]++
+	return r.failures[method]
+}
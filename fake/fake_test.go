@@ -0,0 +1,85 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/livefire2015/simple-contents/repository"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+func createInput() service.CreateContentInput {
+	return service.CreateContentInput{
+		FileName:   "invoice.txt",
+		MIMEType:   "text/plain",
+		FileSize:   5,
+		Data:       strings.NewReader("hello"),
+		EntityType: "order",
+		EntityID:   "42",
+	}
+}
+
+func TestContentService(t *testing.T) {
+	ctx := context.Background()
+	s := NewContentService()
+
+	content, err := s.CreateContent(ctx, createInput())
+	if err != nil {
+		t.Fatalf("CreateContent() error = %v", err)
+	}
+	data, _, err := s.GetContentData(ctx, content.ID)
+	if err != nil {
+		t.Fatalf("GetContentData() error = %v", err)
+	}
+	defer data.Close()
+	if got, _ := io.ReadAll(data); string(got) != "hello" {
+		t.Errorf("GetContentData() = %q, want %q", got, "hello")
+	}
+	contents, total, err := s.GetContentForEntity(ctx, "order", "42", repository.ListOptions{})
+	if err != nil || total != 1 || len(contents) != 1 || contents[0].ID != content.ID {
+		t.Errorf("GetContentForEntity() = %v, %d, %v, want the created content", contents, total, err)
+	}
+	if got := s.Calls("CreateContent"); got != 1 {
+		t.Errorf("Calls(CreateContent) = %d, want 1", got)
+	}
+	if got := s.Repository.Calls("CreateContent"); got != 1 {
+		t.Errorf("Repository.Calls(CreateContent) = %d, want 1", got)
+	}
+}
+
+func TestFail(t *testing.T) {
+	ctx := context.Background()
+	outage := errors.New("outage")
+
+	tests := []struct {
+		name string
+		fail func(s *ContentService)
+	}{
+		{name: "service", fail: func(s *ContentService) { s.Fail("CreateContent", outage) }},
+		{name: "repository", fail: func(s *ContentService) { s.Repository.Fail("CreateContent", outage) }},
+		{name: "repository transaction", fail: func(s *ContentService) { s.Repository.Fail("CreateAssociation", outage) }},
+		{name: "storage", fail: func(s *ContentService) { s.Storage.Fail("Upload", outage) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewContentService()
+			tt.fail(s)
+			if _, err := s.CreateContent(ctx, createInput()); !errors.Is(err, outage) {
+				t.Fatalf("CreateContent() error = %v, want %v", err, outage)
+			}
+
+			// Clearing the failure makes the method succeed again
+			s.Fail("CreateContent", nil)
+			s.Repository.Fail("CreateContent", nil)
+			s.Repository.Fail("CreateAssociation", nil)
+			s.Storage.Fail("Upload", nil)
+			if _, err := s.CreateContent(ctx, createInput()); err != nil {
+				t.Errorf("CreateContent() after clearing the failure: error = %v", err)
+			}
+		})
+	}
+}
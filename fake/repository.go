@@ -0,0 +1,295 @@
+package fake
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+	"github.com/livefire2015/simple-contents/repository/memory"
+)
+
+// Repository is a content repository kept in memory whose methods can be
+// made to fail. It embeds the memory repository, so it implements the
+// tenant, job and other repositories the service looks for too; only the
+// methods of repository.ContentRepository go through Fail and Calls.
+type Repository struct {
+	*memory.MemoryRepository
+	Recorder
+}
+
+// NewRepository returns an empty fake repository
+func NewRepository() *Repository {
+	return &Repository{MemoryRepository: memory.NewMemoryRepository()}
+}
+
+var _ repository.ContentRepository = (*Repository)(nil)
+
+// WithTx calls fn with the fake itself, so failures set by Fail apply
+// within transactions too
+func (r *Repository) WithTx(ctx context.Context, fn func(tx repository.ContentRepository) error) error {
+	if err := r.call("WithTx"); err != nil {
+		return err
+	}
+	return fn(r)
+}
+
+func (r *Repository) CreateContent(ctx context.Context, content *model.Content) error {
+	if err := r.call("CreateContent"); err != nil {
+		return err
+	}
+	return r.MemoryRepository.CreateContent(ctx, content)
+}
+
+func (r *Repository) CreateContentBatch(ctx context.Context, contents []*model.Content) error {
+	if err := r.call("CreateContentBatch"); err != nil {
+		return err
+	}
+	return r.MemoryRepository.CreateContentBatch(ctx, contents)
+}
+
+func (r *Repository) GetContentByID(ctx context.Context, id uuid.UUID) (*model.Content, error) {
+	if err := r.call("GetContentByID"); err != nil {
+		return nil, err
+	}
+	return r.MemoryRepository.GetContentByID(ctx, id)
+}
+
+func (r *Repository) GetContentByExternalID(ctx context.Context, tenantID, source, externalID string) (*model.Content, error) {
+	if err := r.call("GetContentByExternalID"); err != nil {
+		return nil, err
+	}
+	return r.MemoryRepository.GetContentByExternalID(ctx, tenantID, source, externalID)
+}
+
+func (r *Repository) GetContentsByIDs(ctx context.Context, ids []uuid.UUID) ([]*model.Content, error) {
+	if err := r.call("GetContentsByIDs"); err != nil {
+		return nil, err
+	}
+	return r.MemoryRepository.GetContentsByIDs(ctx, ids)
+}
+
+func (r *Repository) ListContent(ctx context.Context, filter model.ContentFilter, offset int, limit int) ([]*model.Content, int, error) {
+	if err := r.call("ListContent"); err != nil {
+		return nil, 0, err
+	}
+	return r.MemoryRepository.ListContent(ctx, filter, offset, limit)
+}
+
+func (r *Repository) ListContentStream(ctx context.Context, filter model.ContentFilter, fn func(*model.Content) error) error {
+	if err := r.call("ListContentStream"); err != nil {
+		return err
+	}
+	return r.MemoryRepository.ListContentStream(ctx, filter, fn)
+}
+
+func (r *Repository) ListContentPage(ctx context.Context, filter model.ContentFilter, options repository.ListOptions) (*repository.ContentPage, error) {
+	if err := r.call("ListContentPage"); err != nil {
+		return nil, err
+	}
+	return r.MemoryRepository.ListContentPage(ctx, filter, options)
+}
+
+func (r *Repository) ListContentByMetadataKey(ctx context.Context, filter model.ContentFilter, key, prefix, from string, limit int) ([]*model.Content, error) {
+	if err := r.call("ListContentByMetadataKey"); err != nil {
+		return nil, err
+	}
+	return r.MemoryRepository.ListContentByMetadataKey(ctx, filter, key, prefix, from, limit)
+}
+
+func (r *Repository) ContentStats(ctx context.Context, filter model.ContentFilter, groupBy model.ContentGroupBy) ([]*model.ContentStatsBucket, error) {
+	if err := r.call("ContentStats"); err != nil {
+		return nil, err
+	}
+	return r.MemoryRepository.ContentStats(ctx, filter, groupBy)
+}
+
+func (r *Repository) UpdateContent(ctx context.Context, content *model.Content) error {
+	if err := r.call("UpdateContent"); err != nil {
+		return err
+	}
+	return r.MemoryRepository.UpdateContent(ctx, content)
+}
+
+func (r *Repository) MarkCallbackSent(ctx context.Context, id uuid.UUID, sentAt time.Time) (bool, error) {
+	if err := r.call("MarkCallbackSent"); err != nil {
+		return false, err
+	}
+	return r.MemoryRepository.MarkCallbackSent(ctx, id, sentAt)
+}
+
+func (r *Repository) ClearCallbackSent(ctx context.Context, id uuid.UUID) error {
+	if err := r.call("ClearCallbackSent"); err != nil {
+		return err
+	}
+	return r.MemoryRepository.ClearCallbackSent(ctx, id)
+}
+
+func (r *Repository) DeleteContent(ctx context.Context, id uuid.UUID) error {
+	if err := r.call("DeleteContent"); err != nil {
+		return err
+	}
+	return r.MemoryRepository.DeleteContent(ctx, id)
+}
+
+func (r *Repository) DeleteContentIfUnreferenced(ctx context.Context, id uuid.UUID) error {
+	if err := r.call("DeleteContentIfUnreferenced"); err != nil {
+		return err
+	}
+	return r.MemoryRepository.DeleteContentIfUnreferenced(ctx, id)
+}
+
+func (r *Repository) DeleteContents(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error) {
+	if err := r.call("DeleteContents"); err != nil {
+		return nil, err
+	}
+	return r.MemoryRepository.DeleteContents(ctx, ids)
+}
+
+func (r *Repository) TransferOwnership(ctx context.Context, tenantID, from, to string, ids []uuid.UUID) ([]uuid.UUID, error) {
+	if err := r.call("TransferOwnership"); err != nil {
+		return nil, err
+	}
+	return r.MemoryRepository.TransferOwnership(ctx, tenantID, from, to, ids)
+}
+
+func (r *Repository) PruneDeletedContent(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	if err := r.call("PruneDeletedContent"); err != nil {
+		return 0, err
+	}
+	return r.MemoryRepository.PruneDeletedContent(ctx, deletedBefore)
+}
+
+func (r *Repository) CreateAssociation(ctx context.Context, association *model.ContentEntityAssociation) error {
+	if err := r.call("CreateAssociation"); err != nil {
+		return err
+	}
+	return r.MemoryRepository.CreateAssociation(ctx, association)
+}
+
+func (r *Repository) GetAssociationByID(ctx context.Context, associationID uuid.UUID) (*model.ContentEntityAssociation, error) {
+	if err := r.call("GetAssociationByID"); err != nil {
+		return nil, err
+	}
+	return r.MemoryRepository.GetAssociationByID(ctx, associationID)
+}
+
+func (r *Repository) GetAssociationByLink(ctx context.Context, contentID uuid.UUID, entityType, entityID string) (*model.ContentEntityAssociation, error) {
+	if err := r.call("GetAssociationByLink"); err != nil {
+		return nil, err
+	}
+	return r.MemoryRepository.GetAssociationByLink(ctx, contentID, entityType, entityID)
+}
+
+func (r *Repository) UpdateAssociation(ctx context.Context, association *model.ContentEntityAssociation) error {
+	if err := r.call("UpdateAssociation"); err != nil {
+		return err
+	}
+	return r.MemoryRepository.UpdateAssociation(ctx, association)
+}
+
+func (r *Repository) DeleteAssociation(ctx context.Context, associationID uuid.UUID) error {
+	if err := r.call("DeleteAssociation"); err != nil {
+		return err
+	}
+	return r.MemoryRepository.DeleteAssociation(ctx, associationID)
+}
+
+func (r *Repository) DeleteAssociationsByEntity(ctx context.Context, entityType, entityID string) ([]*model.ContentEntityAssociation, error) {
+	if err := r.call("DeleteAssociationsByEntity"); err != nil {
+		return nil, err
+	}
+	return r.MemoryRepository.DeleteAssociationsByEntity(ctx, entityType, entityID)
+}
+
+func (r *Repository) ReorderAssociations(ctx context.Context, entityType, entityID string, contentIDs []uuid.UUID) error {
+	if err := r.call("ReorderAssociations"); err != nil {
+		return err
+	}
+	return r.MemoryRepository.ReorderAssociations(ctx, entityType, entityID, contentIDs)
+}
+
+func (r *Repository) CreateRelation(ctx context.Context, relation *model.ContentRelation) error {
+	if err := r.call("CreateRelation"); err != nil {
+		return err
+	}
+	return r.MemoryRepository.CreateRelation(ctx, relation)
+}
+
+func (r *Repository) GetRelation(ctx context.Context, id uuid.UUID) (*model.ContentRelation, error) {
+	if err := r.call("GetRelation"); err != nil {
+		return nil, err
+	}
+	return r.MemoryRepository.GetRelation(ctx, id)
+}
+
+func (r *Repository) ListRelations(ctx context.Context, contentID uuid.UUID, direction model.RelationDirection, relationType model.RelationType) ([]*model.ContentRelation, error) {
+	if err := r.call("ListRelations"); err != nil {
+		return nil, err
+	}
+	return r.MemoryRepository.ListRelations(ctx, contentID, direction, relationType)
+}
+
+func (r *Repository) DeleteRelation(ctx context.Context, id uuid.UUID) error {
+	if err := r.call("DeleteRelation"); err != nil {
+		return err
+	}
+	return r.MemoryRepository.DeleteRelation(ctx, id)
+}
+
+func (r *Repository) PinContent(ctx context.Context, pin *model.Pin) error {
+	if err := r.call("PinContent"); err != nil {
+		return err
+	}
+	return r.MemoryRepository.PinContent(ctx, pin)
+}
+
+func (r *Repository) UnpinContent(ctx context.Context, principal string, contentID uuid.UUID) error {
+	if err := r.call("UnpinContent"); err != nil {
+		return err
+	}
+	return r.MemoryRepository.UnpinContent(ctx, principal, contentID)
+}
+
+func (r *Repository) ListContentByEntity(ctx context.Context, entityType string, entityID string, options repository.ListOptions) ([]*model.Content, int64, error) {
+	if err := r.call("ListContentByEntity"); err != nil {
+		return nil, 0, err
+	}
+	return r.MemoryRepository.ListContentByEntity(ctx, entityType, entityID, options)
+}
+
+func (r *Repository) ListEntityContents(ctx context.Context, entityType string, entityID string, options repository.ListOptions) ([]*model.EntityContent, int64, error) {
+	if err := r.call("ListEntityContents"); err != nil {
+		return nil, 0, err
+	}
+	return r.MemoryRepository.ListEntityContents(ctx, entityType, entityID, options)
+}
+
+func (r *Repository) ListAssociationsByEntity(ctx context.Context, entityType string, entityID string, options repository.ListOptions) ([]*model.ContentEntityAssociation, int64, error) {
+	if err := r.call("ListAssociationsByEntity"); err != nil {
+		return nil, 0, err
+	}
+	return r.MemoryRepository.ListAssociationsByEntity(ctx, entityType, entityID, options)
+}
+
+func (r *Repository) ListAssociationsByContent(ctx context.Context, contentID uuid.UUID, options repository.ListOptions) ([]*model.ContentEntityAssociation, int64, error) {
+	if err := r.call("ListAssociationsByContent"); err != nil {
+		return nil, 0, err
+	}
+	return r.MemoryRepository.ListAssociationsByContent(ctx, contentID, options)
+}
+
+func (r *Repository) ListEntityIDs(ctx context.Context, entityType string, options repository.ListOptions) ([]string, int64, error) {
+	if err := r.call("ListEntityIDs"); err != nil {
+		return nil, 0, err
+	}
+	return r.MemoryRepository.ListEntityIDs(ctx, entityType, options)
+}
+
+func (r *Repository) ListAssociationsByReviewState(ctx context.Context, entityType string, state model.ReviewState, options repository.ListOptions) ([]*model.ContentEntityAssociation, int64, error) {
+	if err := r.call("ListAssociationsByReviewState"); err != nil {
+		return nil, 0, err
+	}
+	return r.MemoryRepository.ListAssociationsByReviewState(ctx, entityType, state, options)
+}
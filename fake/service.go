@@ -0,0 +1,128 @@
+package fake
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// ContentService is a content service on a fake repository and storage.
+// The methods of service.ContentAPI can be made to fail; the others are
+// those of the service. Repository and Storage are exposed to seed data
+// or make the layers below the service fail.
+type ContentService struct {
+	*service.ContentService
+	Recorder
+	Repository *Repository
+	Storage    *Storage
+}
+
+// NewContentService returns a content service on an empty fake repository
+// and storage, configured as NewContentService configures a service
+func NewContentService() *ContentService {
+	repo := NewRepository()
+	store := NewStorage()
+	return &ContentService{
+		ContentService: service.NewContentService(repo, store),
+		Repository:     repo,
+		Storage:        store,
+	}
+}
+
+var _ service.ContentAPI = (*ContentService)(nil)
+
+func (s *ContentService) CreateContent(ctx context.Context, input service.CreateContentInput) (*model.Content, error) {
+	if err := s.call("CreateContent"); err != nil {
+		return nil, err
+	}
+	return s.ContentService.CreateContent(ctx, input)
+}
+
+func (s *ContentService) GetContent(ctx context.Context, id uuid.UUID) (*model.Content, error) {
+	if err := s.call("GetContent"); err != nil {
+		return nil, err
+	}
+	return s.ContentService.GetContent(ctx, id)
+}
+
+func (s *ContentService) GetContentData(ctx context.Context, id uuid.UUID) (io.ReadCloser, *model.Content, error) {
+	if err := s.call("GetContentData"); err != nil {
+		return nil, nil, err
+	}
+	return s.ContentService.GetContentData(ctx, id)
+}
+
+func (s *ContentService) GetContentURL(ctx context.Context, id uuid.UUID, expiry time.Duration) (*service.ContentURL, error) {
+	if err := s.call("GetContentURL"); err != nil {
+		return nil, err
+	}
+	return s.ContentService.GetContentURL(ctx, id, expiry)
+}
+
+func (s *ContentService) UpdateContent(ctx context.Context, input service.UpdateContentInput) (*model.Content, error) {
+	if err := s.call("UpdateContent"); err != nil {
+		return nil, err
+	}
+	return s.ContentService.UpdateContent(ctx, input)
+}
+
+func (s *ContentService) UpdateContentStatus(ctx context.Context, id uuid.UUID, status model.ContentStatus) (*model.Content, error) {
+	if err := s.call("UpdateContentStatus"); err != nil {
+		return nil, err
+	}
+	return s.ContentService.UpdateContentStatus(ctx, id, status)
+}
+
+func (s *ContentService) MarkContentAsUploaded(ctx context.Context, id uuid.UUID) (*model.Content, error) {
+	if err := s.call("MarkContentAsUploaded"); err != nil {
+		return nil, err
+	}
+	return s.ContentService.MarkContentAsUploaded(ctx, id)
+}
+
+func (s *ContentService) DeleteContent(ctx context.Context, id uuid.UUID, options service.DeleteContentOptions) (*service.DeleteContentResult, error) {
+	if err := s.call("DeleteContent"); err != nil {
+		return nil, err
+	}
+	return s.ContentService.DeleteContent(ctx, id, options)
+}
+
+func (s *ContentService) ListContent(ctx context.Context, input service.ListContentInput) (*service.ListContentResult, error) {
+	if err := s.call("ListContent"); err != nil {
+		return nil, err
+	}
+	return s.ContentService.ListContent(ctx, input)
+}
+
+func (s *ContentService) AssociateContent(ctx context.Context, input service.AssociateContentInput) (*model.ContentEntityAssociation, error) {
+	if err := s.call("AssociateContent"); err != nil {
+		return nil, err
+	}
+	return s.ContentService.AssociateContent(ctx, input)
+}
+
+func (s *ContentService) RemoveAssociation(ctx context.Context, associationID uuid.UUID) error {
+	if err := s.call("RemoveAssociation"); err != nil {
+		return err
+	}
+	return s.ContentService.RemoveAssociation(ctx, associationID)
+}
+
+func (s *ContentService) GetContentForEntity(ctx context.Context, entityType string, entityID string, options repository.ListOptions) ([]*model.Content, int64, error) {
+	if err := s.call("GetContentForEntity"); err != nil {
+		return nil, 0, err
+	}
+	return s.ContentService.GetContentForEntity(ctx, entityType, entityID, options)
+}
+
+func (s *ContentService) GetContentWithAssociationsForEntity(ctx context.Context, entityType string, entityID string, options repository.ListOptions) ([]*model.EntityContent, int64, error) {
+	if err := s.call("GetContentWithAssociationsForEntity"); err != nil {
+		return nil, 0, err
+	}
+	return s.ContentService.GetContentWithAssociationsForEntity(ctx, entityType, entityID, options)
+}
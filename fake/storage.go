@@ -0,0 +1,57 @@
+package fake
+
+import (
+	"context"
+	"io"
+
+	"github.com/livefire2015/simple-contents/storage"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+)
+
+// Storage is a storage service kept in memory whose methods can be made to fail
+type Storage struct {
+	*memorystorage.MemoryStorage
+	Recorder
+}
+
+// NewStorage returns an empty fake storage service
+func NewStorage() *Storage {
+	return &Storage{MemoryStorage: memorystorage.NewMemoryStorage()}
+}
+
+var _ storage.StorageService = (*Storage)(nil)
+
+func (s *Storage) Upload(ctx context.Context, key string, data io.Reader, size int64, contentType string) (string, error) {
+	if err := s.call("Upload"); err != nil {
+		return "", err
+	}
+	return s.MemoryStorage.Upload(ctx, key, data, size, contentType)
+}
+
+func (s *Storage) Download(ctx context.Context, path string) (io.ReadCloser, error) {
+	if err := s.call("Download"); err != nil {
+		return nil, err
+	}
+	return s.MemoryStorage.Download(ctx, path)
+}
+
+func (s *Storage) Stat(ctx context.Context, path string) (*storage.ObjectInfo, error) {
+	if err := s.call("Stat"); err != nil {
+		return nil, err
+	}
+	return s.MemoryStorage.Stat(ctx, path)
+}
+
+func (s *Storage) GetPresignedDownloadURL(ctx context.Context, path string, options storage.PresignedURLOptions) (string, error) {
+	if err := s.call("GetPresignedDownloadURL"); err != nil {
+		return "", err
+	}
+	return s.MemoryStorage.GetPresignedDownloadURL(ctx, path, options)
+}
+
+func (s *Storage) Delete(ctx context.Context, path string) error {
+	if err := s.call("Delete"); err != nil {
+		return err
+	}
+	return s.MemoryStorage.Delete(ctx, path)
+}
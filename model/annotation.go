@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Annotation is a reviewer's comment on a content item
+type Annotation struct {
+	ID        uuid.UUID         `json:"id"`
+	ContentID uuid.UUID         `json:"content_id"`
+	Author    string            `json:"author"`           // Identifier of the reviewer
+	Body      string            `json:"body"`             // Comment text
+	Anchor    *AnnotationAnchor `json:"anchor,omitempty"` // Where in the document the comment applies, nil for the whole document
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// AnnotationAnchor pins an annotation to a page and optionally a region of it
+type AnnotationAnchor struct {
+	Page   int     `json:"page,omitempty"`   // 1-based page number, 0 if not paged
+	Region *Region `json:"region,omitempty"` // Area of the page
+}
+
+// Region is a rectangle in coordinates relative to the page size, from 0 to 1
+type Region struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
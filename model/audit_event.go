@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEvent records an audited operation and who performed it
+type AuditEvent struct {
+	ID         uuid.UUID `json:"id"`
+	Action     string    `json:"action"`  // What was done, e.g. "quarantine.release"
+	Subject    string    `json:"subject"` // What it was done to, e.g. a content ID
+	Actor      string    `json:"actor"`
+	Detail     string    `json:"detail,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
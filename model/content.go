@@ -8,24 +8,75 @@ import (
 
 // Content represents a content item in the system
 type Content struct {
-	ID          uuid.UUID     `json:"id"`           // Unique identifier (e.g., UUID)
-	Status      ContentStatus `json:"status"`       // Processing status
-	FileName    string        `json:"file_name"`    // Original name of the file
-	MIMEType    string        `json:"mime_type"`    // MIME type of the file
-	FileSize    int64         `json:"file_size"`    // Size of the file in bytes
-	StoragePath string        `json:"storage_path"` // Path/key in the storage layer
-	CreatedBy   string        `json:"created_by"`   // Identifier of the content creator
-	CreatedAt   time.Time     `json:"created_at"`   // Timestamp of creation
-	UpdatedAt   time.Time     `json:"updated_at"`   // Timestamp of last update
-	DeletedAt   *time.Time    `json:"deleted_at,omitempty"`
+	ID                  uuid.UUID     `json:"id"`                              // Unique identifier (e.g., UUID)
+	TenantID            string        `json:"tenant_id,omitempty"`             // Owning tenant, empty for untenanted content
+	Status              ContentStatus `json:"status"`                          // Processing status
+	FileName            string        `json:"file_name"`                       // Original name of the file
+	Description         string        `json:"description,omitempty"`           // Rich-text description, in Markdown
+	MIMEType            string        `json:"mime_type"`                       // MIME type of the file
+	FileSize            int64         `json:"file_size"`                       // Size of the file in bytes
+	StoragePath         string        `json:"storage_path"`                    // Path/key in the storage layer
+	OriginalStoragePath string        `json:"original_storage_path,omitempty"` // Unsanitized upload, kept for privileged access
+	ETag                string        `json:"etag,omitempty"`                  // MD5-based entity tag of the stored data, as S3 computes it
+	DerivedFromID       *uuid.UUID    `json:"derived_from_id,omitempty"`       // Content this item was produced from, e.g. by transcoding
+	Derivation          string        `json:"derivation,omitempty"`            // How the item was derived, e.g. "transcode/mp4"
+	CreatedBy           string        `json:"created_by"`                      // Identifier of the content creator
+	CreatedAt           time.Time     `json:"created_at"`                      // Timestamp of creation
+	UpdatedAt           time.Time     `json:"updated_at"`                      // Timestamp of last update
+	DeletedAt           *time.Time    `json:"deleted_at,omitempty"`
 
 	// EntityType and EntityID are REMOVED from here
 	// as associations are now handled by ContentEntityAssociation.
 
-	Source   string   `json:"source"`             // e.g., "email_attachment", "direct_upload", "slack"
-	Metadata Metadata `json:"metadata,omitempty"` // Intrinsic metadata of the content itself
+	Source     string   `json:"source"`                // e.g., "email_attachment", "direct_upload", "slack"
+	ExternalID string   `json:"external_id,omitempty"` // Key of the content in the system it came from, unique per tenant and source
+	Metadata   Metadata `json:"metadata,omitempty"`    // Intrinsic metadata of the content itself
+
+	CallbackURL    string     `json:"callback_url,omitempty"`     // Invoked once when processing completes
+	CallbackSentAt *time.Time `json:"callback_sent_at,omitempty"` // When the callback was delivered
+
+	// References catalogue documents kept in another system instead of storing their data
+	Kind               ContentKind     `json:"kind,omitempty"`                 // file if empty
+	ReferenceURL       string          `json:"reference_url,omitempty"`        // Where the data of a reference lives
+	ReferenceStatus    ReferenceStatus `json:"reference_status,omitempty"`     // Outcome of the last reachability check
+	ReferenceCheckedAt *time.Time      `json:"reference_checked_at,omitempty"` // When the reference was last checked
+
+	DraftExpiresAt *time.Time `json:"draft_expires_at,omitempty"` // When an unfinalized draft is purged, nil once finalized
+}
+
+// IsDraft reports whether the content is a draft that hasn't been finalized
+func (c *Content) IsDraft() bool {
+	return c.DraftExpiresAt != nil
+}
+
+// IsReference reports whether the data of the content lives in another
+// system rather than in storage
+func (c *Content) IsReference() bool {
+	return c.Kind == KindReference
+}
+
+// ContentKind tells where the data of a content item lives
+type ContentKind string
+
+const (
+	KindFile      ContentKind = "file"      // Data in storage
+	KindReference ContentKind = "reference" // Data at a URL of another system
+)
+
+// IsValid reports whether k is a known kind
+func (k ContentKind) IsValid() bool {
+	return k == KindFile || k == KindReference
 }
 
+// ReferenceStatus is the outcome of checking that a reference can be reached
+type ReferenceStatus string
+
+const (
+	ReferenceUnchecked   ReferenceStatus = ""
+	ReferenceReachable   ReferenceStatus = "reachable"
+	ReferenceUnreachable ReferenceStatus = "unreachable"
+)
+
 // ContentStatus represents the status of a content item.
 type ContentStatus string
 
@@ -37,31 +88,134 @@ const (
 	// Add other statuses as needed
 )
 
+// IsTerminal reports whether processing of the content has finished
+func (s ContentStatus) IsTerminal() bool {
+	return s == StatusDone || s == StatusError
+}
+
+// IsValid reports whether s is a known status
+func (s ContentStatus) IsValid() bool {
+	switch s {
+	case StatusCreated, StatusUploaded, StatusDone, StatusError:
+		return true
+	}
+	return false
+}
+
 // Metadata contains additional information about the content
 type Metadata map[string]interface{}
 
 // ContentFilter represents filter criteria for content queries
 type ContentFilter struct {
-	FileName    string
-	MIMEType    string
-	MinSize     *int64
-	MaxSize     *int64
-	CreatedFrom *time.Time
-	CreatedTo   *time.Time
-	Metadata    map[string]interface{}
+	TenantID      string                 `json:"tenant_id,omitempty"`
+	Status        ContentStatus          `json:"status,omitempty"`
+	DerivedFromID *uuid.UUID             `json:"derived_from_id,omitempty"`
+	PinnedBy      string                 `json:"pinned_by,omitempty"`       // Only content pinned by this principal
+	CreatedBy     string                 `json:"created_by,omitempty"`      // Only content created by this principal
+	Kind          ContentKind            `json:"kind,omitempty"`            // Only content of this kind, files matching the empty kind too
+	DraftsExpired *time.Time             `json:"drafts_expired,omitempty"`  // Only drafts expiring by this time
+	FileName      string                 `json:"file_name,omitempty"`       // Matched as FileNameMatch says
+	FileNameMatch FileNameMatch          `json:"file_name_match,omitempty"` // substring if empty
+	Text          string                 `json:"text,omitempty"`            // Case-insensitive substring of the file name or description
+	MIMEType      string                 `json:"mime_type,omitempty"`
+	MinSize       *int64                 `json:"min_size,omitempty"`
+	MaxSize       *int64                 `json:"max_size,omitempty"`
+	CreatedFrom   *time.Time             `json:"created_from,omitempty"`
+	CreatedTo     *time.Time             `json:"created_to,omitempty"`
+	UpdatedFrom   *time.Time             `json:"updated_from,omitempty"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+
+	SortBy        ContentSortField `json:"sort_by,omitempty"`        // created_at if empty
+	SortAscending bool             `json:"sort_ascending,omitempty"` // Newest/largest first unless set
+}
+
+// FileNameMatch is how a filter's file name is matched, ignoring case
+type FileNameMatch string
+
+const (
+	MatchPrefix    FileNameMatch = "prefix"    // File names starting with it
+	MatchSubstring FileNameMatch = "substring" // File names containing it
+	// MatchFuzzy selects file names containing most of its trigrams, the
+	// closest first unless the filter sorts by a field
+	MatchFuzzy FileNameMatch = "fuzzy"
+)
+
+// IsValid reports whether m is a known file name match
+func (m FileNameMatch) IsValid() bool {
+	switch m {
+	case MatchPrefix, MatchSubstring, MatchFuzzy:
+		return true
+	}
+	return false
+}
+
+// ContentSortField names a field content listings can be ordered by
+type ContentSortField string
+
+const (
+	SortByCreatedAt ContentSortField = "created_at"
+	SortByUpdatedAt ContentSortField = "updated_at"
+	SortByFileName  ContentSortField = "file_name"
+	SortByFileSize  ContentSortField = "file_size"
+)
+
+// IsValid reports whether f is a known sort field
+func (f ContentSortField) IsValid() bool {
+	switch f {
+	case SortByCreatedAt, SortByUpdatedAt, SortByFileName, SortByFileSize:
+		return true
+	}
+	return false
 }
 
 // ContentEntityAssociation links a Content item to an external entity
 // and can store metadata specific to this particular link.
 type ContentEntityAssociation struct {
-	ID                  string                 `json:"id"`                   // Unique identifier for the association itself (e.g., UUID)
-	ContentID           string                 `json:"content_id"`           // Foreign key to the Content item
+	ID                  uuid.UUID              `json:"id"`                   // Unique identifier for the association itself
+	ContentID           uuid.UUID              `json:"content_id"`           // Foreign key to the Content item
 	EntityType          string                 `json:"entity_type"`          // Type of the associated entity
 	EntityID            string                 `json:"entity_id"`            // ID of the associated entity
 	AssociationMetadata map[string]interface{} `json:"association_metadata"` // Metadata specific to this link (e.g., role, version, context)
 	CreatedAt           time.Time              `json:"created_at"`           // Timestamp of association creation
 	UpdatedAt           time.Time              `json:"updated_at"`           // Timestamp of last update to the association
 	CreatedBy           string                 `json:"created_by"`           // Who created this specific association
+	Review              *AssociationReview     `json:"review,omitempty"`     // Approval workflow state, nil if the link needs no review
+	Position            int                    `json:"position"`             // User-defined order within the entity, 0 if not placed
+}
+
+// EntityContent is a content item listed for an entity, together with the
+// association linking them
+type EntityContent struct {
+	*Content
+	Association *ContentEntityAssociation `json:"association"`
+}
+
+// ReviewState is the state of an association in the approval workflow
+type ReviewState string
+
+const (
+	ReviewPending  ReviewState = "pending"
+	ReviewApproved ReviewState = "approved"
+	ReviewRejected ReviewState = "rejected"
+)
+
+// IsValid reports whether s is a known review state
+func (s ReviewState) IsValid() bool {
+	switch s {
+	case ReviewPending, ReviewApproved, ReviewRejected:
+		return true
+	}
+	return false
+}
+
+// AssociationReview records the review of a content item for an entity
+type AssociationReview struct {
+	State       ReviewState `json:"state"`
+	RequestedBy string      `json:"requested_by,omitempty"` // Who asked for the review
+	RequestedAt time.Time   `json:"requested_at"`
+	Reviewer    string      `json:"reviewer,omitempty"`   // Who approved or rejected the link
+	Reason      string      `json:"reason,omitempty"`     // Why, required when rejecting
+	DecidedAt   *time.Time  `json:"decided_at,omitempty"` // When the link was approved or rejected
 }
 
 // Example AssociationMetadata:
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Document groups the language variants of one logical document, e.g. the
+// terms of service in each language they are published in
+type Document struct {
+	ID              uuid.UUID         `json:"id"`
+	TenantID        string            `json:"tenant_id,omitempty"`
+	Name            string            `json:"name"`
+	DefaultLanguage string            `json:"default_language"`   // Variant served when none matches the request
+	Variants        []DocumentVariant `json:"variants,omitempty"` // Ordered by language, left out of listings
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+}
+
+// DocumentVariant is the content of a document in one language
+type DocumentVariant struct {
+	Language  string    `json:"language"` // BCP 47 language tag, e.g. "fr" or "pt-BR"
+	ContentID uuid.UUID `json:"content_id"`
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DownloadLinkMode is how a download link serves its content
+type DownloadLinkMode string
+
+const (
+	DownloadLinkRedirect DownloadLinkMode = "redirect" // Redirect to a short-lived presigned URL
+	DownloadLinkProxy    DownloadLinkMode = "proxy"    // Stream the data through the service
+)
+
+// IsValid reports whether m is a known mode
+func (m DownloadLinkMode) IsValid() bool {
+	return m == DownloadLinkRedirect || m == DownloadLinkProxy
+}
+
+// DownloadLink is a short URL token that downloads a content item until it expires
+type DownloadLink struct {
+	Token         string           `json:"token"`
+	ContentID     uuid.UUID        `json:"content_id"`
+	Mode          DownloadLinkMode `json:"mode"`
+	CreatedBy     string           `json:"created_by,omitempty"`
+	ExpiresAt     time.Time        `json:"expires_at"`
+	Clicks        int64            `json:"clicks"`                    // Number of times the link was redeemed
+	LastClickedAt *time.Time       `json:"last_clicked_at,omitempty"` // Time of the last redemption
+	CreatedAt     time.Time        `json:"created_at"`
+}
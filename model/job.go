@@ -0,0 +1,45 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobStatus is the state of an asynchronous job
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobDone      JobStatus = "done"
+	JobFailed    JobStatus = "failed"
+	JobCancelled JobStatus = "cancelled"
+)
+
+// Finished reports whether a job in this status has stopped for good
+func (s JobStatus) Finished() bool {
+	return s == JobDone || s == JobFailed || s == JobCancelled
+}
+
+// Job is a long-running operation, e.g. a transcode or an export, run in the
+// background and polled by its ID
+type Job struct {
+	ID        uuid.UUID  `json:"id"`
+	Kind      string     `json:"kind"` // e.g. "transcode/mp4", "export" or "bulk_delete"
+	TenantID  string     `json:"tenant_id,omitempty"`
+	ContentID *uuid.UUID `json:"content_id,omitempty"` // Content the job works on, if it works on one
+	Status    JobStatus  `json:"status"`
+	Progress  int        `json:"progress"`        // Percentage of the work done
+	Done      int64      `json:"done"`            // Units of work done, e.g. items copied
+	Total     int64      `json:"total,omitempty"` // Units of work, if known
+	// CancelRequested is set when cancelling a job another instance is running
+	CancelRequested bool            `json:"cancel_requested,omitempty"`
+	Input           json.RawMessage `json:"input,omitempty"`  // Parameters of the job
+	Result          json.RawMessage `json:"result,omitempty"` // Outcome, also kept when the job fails
+	Error           string          `json:"error,omitempty"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	FinishedAt      *time.Time      `json:"finished_at,omitempty"`
+}
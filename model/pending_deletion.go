@@ -0,0 +1,16 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PendingDeletion is a stored object that couldn't be deleted while storage
+// was unavailable, kept until the deletion is retried successfully
+type PendingDeletion struct {
+	ID          uuid.UUID `json:"id"`
+	TenantID    string    `json:"tenant_id,omitempty"` // Tenant whose storage holds the object
+	StoragePath string    `json:"storage_path"`
+	QueuedAt    time.Time `json:"queued_at"`
+}
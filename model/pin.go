@@ -0,0 +1,14 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Pin marks a content item as a favorite of a principal, e.g. a support agent
+type Pin struct {
+	Principal string    `json:"principal"`
+	ContentID uuid.UUID `json:"content_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RelationType is the meaning of a relation between two content items
+type RelationType string
+
+const (
+	RelationSupersedes      RelationType = "supersedes"        // The source replaces the target, e.g. a new revision
+	RelationTranslationOf   RelationType = "translation_of"    // The source is a translation of the target
+	RelationSignedVersionOf RelationType = "signed_version_of" // The source is the signed copy of the target
+)
+
+// IsValid reports whether t is a known relation type
+func (t RelationType) IsValid() bool {
+	switch t {
+	case RelationSupersedes, RelationTranslationOf, RelationSignedVersionOf:
+		return true
+	}
+	return false
+}
+
+// ContentRelation is a typed link from a source content item to a target,
+// e.g. a signed PDF that supersedes its draft
+type ContentRelation struct {
+	ID        uuid.UUID    `json:"id"`
+	Type      RelationType `json:"type"`
+	SourceID  uuid.UUID    `json:"source_id"`
+	TargetID  uuid.UUID    `json:"target_id"`
+	CreatedBy string       `json:"created_by"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// RelationDirection selects the relations of a content item by which end it is
+type RelationDirection string
+
+const (
+	RelationsOutgoing RelationDirection = "outgoing" // Relations the content is the source of
+	RelationsIncoming RelationDirection = "incoming" // Relations the content is the target of
+	RelationsBoth     RelationDirection = "both"
+)
+
+// IsValid reports whether d is a known relation direction
+func (d RelationDirection) IsValid() bool {
+	switch d {
+	case RelationsOutgoing, RelationsIncoming, RelationsBoth:
+		return true
+	}
+	return false
+}
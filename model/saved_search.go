@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SavedSearch is a content filter saved under a name by a principal
+type SavedSearch struct {
+	ID          uuid.UUID     `json:"id"`
+	Principal   string        `json:"principal"`           // Owner, the only principal who can see or run it
+	TenantID    string        `json:"tenant_id,omitempty"` // Tenant the search is run in
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Filter      ContentFilter `json:"filter"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
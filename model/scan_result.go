@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// ScanResult is the verdict of a virus scan of some data, identified by its
+// digest, under one version of the scanner's signature database
+type ScanResult struct {
+	Digest           string    `json:"digest"`            // Hex SHA-256 of the scanned data
+	SignatureVersion string    `json:"signature_version"` // Signature database the data was scanned with
+	Infected         bool      `json:"infected"`
+	Signature        string    `json:"signature,omitempty"` // Name of the detected virus
+	ScannedAt        time.Time `json:"scanned_at"`
+}
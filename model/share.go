@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Share is a public link through which anyone, without an account, can
+// download a content item until it expires, runs out of downloads or is
+// revoked
+type Share struct {
+	Token            string     `json:"token"`
+	ContentID        uuid.UUID  `json:"content_id"`
+	PasswordHash     string     `json:"-"` // bcrypt hash of the password, empty if none is needed
+	CreatedBy        string     `json:"created_by,omitempty"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"` // Nil for shares that work until revoked
+	MaxDownloads     int64      `json:"max_downloads"`        // 0 for unlimited downloads
+	Downloads        int64      `json:"downloads"`
+	LastDownloadedAt *time.Time `json:"last_downloaded_at,omitempty"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// HasPassword reports whether downloads must present the share's password
+func (s *Share) HasPassword() bool {
+	return s.PasswordHash != ""
+}
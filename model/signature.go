@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SignatureRequest tracks a content item sent to an e-signature provider
+type SignatureRequest struct {
+	ID                uuid.UUID  `json:"id"`
+	ContentID         uuid.UUID  `json:"content_id"`                  // Document sent for signature
+	Provider          string     `json:"provider"`                    // e.g. "docusign", "dropboxsign"
+	ProviderRequestID string     `json:"provider_request_id"`         // Envelope or signature request ID at the provider
+	Status            string     `json:"status"`                      // sent, completed, declined or voided
+	Signers           []Signer   `json:"signers"`                     // People asked to sign
+	Subject           string     `json:"subject,omitempty"`           // Email subject shown to signers
+	SignedContentID   *uuid.UUID `json:"signed_content_id,omitempty"` // Signed version, once completed
+	Error             string     `json:"error,omitempty"`             // Why the signed version could not be stored
+	CreatedBy         string     `json:"created_by"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	CompletedAt       *time.Time `json:"completed_at,omitempty"`
+}
+
+// Signer is a person asked to sign a document
+type Signer struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
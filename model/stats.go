@@ -0,0 +1,27 @@
+package model
+
+// ContentGroupBy names the field content statistics are grouped by
+type ContentGroupBy string
+
+const (
+	GroupByMIMEType ContentGroupBy = "mime_type"
+	GroupBySource   ContentGroupBy = "source"
+	GroupByStatus   ContentGroupBy = "status"
+	GroupByDay      ContentGroupBy = "day" // UTC creation date, formatted as 2006-01-02
+)
+
+// IsValid reports whether g is a known grouping
+func (g ContentGroupBy) IsValid() bool {
+	switch g {
+	case GroupByMIMEType, GroupBySource, GroupByStatus, GroupByDay:
+		return true
+	}
+	return false
+}
+
+// ContentStatsBucket holds the totals of the content sharing a group key
+type ContentStatsBucket struct {
+	Key        string `json:"key"`
+	Count      int64  `json:"count"`
+	TotalBytes int64  `json:"total_bytes"`
+}
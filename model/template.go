@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// TemplateFormat is the markup a template is written in
+type TemplateFormat string
+
+const (
+	TemplateHTML     TemplateFormat = "html"
+	TemplateMarkdown TemplateFormat = "markdown"
+)
+
+// IsValid reports whether f is a known template format
+func (f TemplateFormat) IsValid() bool {
+	return f == TemplateHTML || f == TemplateMarkdown
+}
+
+// Template is a document layout rendered with a JSON payload, e.g. a dispute letter
+type Template struct {
+	ID          string         `json:"id"`   // Short stable identifier, e.g. "dispute-letter"
+	Name        string         `json:"name"` // Display name
+	Format      TemplateFormat `json:"format"`
+	Body        string         `json:"body"`                 // Go template syntax, e.g. {{.customer.name}}
+	Stylesheet  string         `json:"stylesheet,omitempty"` // CSS applied to the rendered HTML
+	FileName    string         `json:"file_name,omitempty"`  // Default name of rendered documents, without extension
+	Description string         `json:"description,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// Tenant represents a business unit whose content is managed by the service
+type Tenant struct {
+	ID                  string          `json:"id"`                               // Short stable identifier, e.g. "billing"
+	Name                string          `json:"name"`                             // Display name
+	StorageBackend      string          `json:"storage_backend,omitempty"`        // Named storage backend overriding the default
+	StoragePrefix       string          `json:"storage_prefix,omitempty"`         // Key prefix of the tenant's objects, "<id>/" if empty
+	CDN                 string          `json:"cdn,omitempty"`                    // Named CDN signing download URLs instead of the storage backend
+	QuotaBytes          int64           `json:"quota_bytes"`                      // Maximum stored bytes, 0 for unlimited
+	QuotaWarnPercents   []int           `json:"quota_warn_percents,omitempty"`    // Usage percentages of the quota that trigger warnings
+	UsedBytes           int64           `json:"used_bytes"`                       // Bytes currently stored, maintained on upload and delete
+	Retention           RetentionPolicy `json:"retention"`                        // How long content is kept
+	EncryptionKeyRef    string          `json:"encryption_key_ref,omitempty"`     // Reference to the tenant's key in the key manager
+	MaxURLExpirySeconds int             `json:"max_url_expiry_seconds,omitempty"` // Longest lifetime of presigned URLs, 0 for the server's maximum
+	CreatedAt           time.Time       `json:"created_at"`
+	UpdatedAt           time.Time       `json:"updated_at"`
+}
+
+// RetentionPolicy controls how long a tenant's content is kept
+type RetentionPolicy struct {
+	MaxAgeDays int `json:"max_age_days" validate:"min=0"` // Content older than this may be purged, 0 to keep forever
+}
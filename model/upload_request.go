@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// UploadRequest is a link through which an external party, e.g. a
+// cardholder, uploads files attached to an entity without an account
+type UploadRequest struct {
+	Token       string     `json:"token"`
+	TenantID    string     `json:"tenant_id,omitempty"` // Tenant owning the uploaded content
+	EntityType  string     `json:"entity_type"`
+	EntityID    string     `json:"entity_id"`
+	Recipient   string     `json:"recipient,omitempty"` // Who the request was sent to, e.g. an email address
+	Message     string     `json:"message,omitempty"`   // Instructions shown on the upload page
+	CreatedBy   string     `json:"created_by,omitempty"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	MaxUploads  int64      `json:"max_uploads"`   // 0 for unlimited uploads
+	MaxFileSize int64      `json:"max_file_size"` // Largest file accepted, 0 for the service's limits only
+	Uploads     int64      `json:"uploads"`       // Files uploaded so far
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
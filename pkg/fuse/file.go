@@ -0,0 +1,273 @@
+package fuse
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"sync"
+	"syscall"
+
+	"github.com/google/uuid"
+	"github.com/hanwen/go-fuse/v2/fs"
+	gofuse "github.com/hanwen/go-fuse/v2/fuse"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// contentIno returns the inode number of a content item's file
+func contentIno(id uuid.UUID) uint64 {
+	// Automatic inode numbers start at 2^63, so content numbers stay below
+	return binary.BigEndian.Uint64(id[:8]) >> 1
+}
+
+// fileNode is a content item in an entity folder
+type fileNode struct {
+	fs.Inode
+	fsys       *fileSystem
+	entityType string
+	entityID   string
+
+	mu      sync.Mutex
+	name    string
+	content *model.Content // nil until a created file is first saved
+}
+
+var (
+	_ fs.NodeGetattrer = (*fileNode)(nil)
+	_ fs.NodeSetattrer = (*fileNode)(nil)
+	_ fs.NodeOpener    = (*fileNode)(nil)
+)
+
+// current returns the content the file holds
+func (n *fileNode) current() *model.Content {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.content
+}
+
+// setContent records the content the file holds after a lookup
+func (n *fileNode) setContent(name string, content *model.Content) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.name, n.content = name, content
+}
+
+// fileName returns the name the file is listed under
+func (n *fileNode) fileName() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.name
+}
+
+// replace records the content a save stored and returns the content it replaced
+func (n *fileNode) replace(content *model.Content) *model.Content {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	previous := n.content
+	n.content = content
+	return previous
+}
+
+// fillAttr describes the file as last looked up or saved
+func (n *fileNode) fillAttr(out *gofuse.Attr) {
+	out.Mode = syscall.S_IFREG | n.fsys.fileMode()
+	if content := n.current(); content != nil {
+		out.Size = uint64(content.FileSize)
+		out.SetTimes(nil, &content.UpdatedAt, &content.UpdatedAt)
+	}
+}
+
+func (n *fileNode) Getattr(ctx context.Context, f fs.FileHandle, out *gofuse.AttrOut) syscall.Errno {
+	n.fillAttr(&out.Attr)
+	// An open file may have been written to since it was saved
+	if h, ok := f.(*fileHandle); ok {
+		size, err := h.size()
+		if err != nil {
+			return syscall.EIO
+		}
+		out.Size = uint64(size)
+	}
+	return fs.OK
+}
+
+// Setattr truncates the file; other attributes can't be changed and are ignored
+func (n *fileNode) Setattr(ctx context.Context, f fs.FileHandle, in *gofuse.SetAttrIn, out *gofuse.AttrOut) syscall.Errno {
+	if size, ok := in.GetSize(); ok {
+		if !n.fsys.config.Writable {
+			return syscall.EROFS
+		}
+		h, ok := f.(*fileHandle)
+		if !ok {
+			// truncate(2) on a file that isn't open is saved at once
+			var err error
+			if h, err = n.open(ctx, size == 0); err != nil {
+				return errno(err)
+			}
+			defer h.Release(ctx)
+			defer h.Flush(ctx)
+		}
+		if err := h.truncate(int64(size)); err != nil {
+			return syscall.EIO
+		}
+	}
+	return n.Getattr(ctx, f, out)
+}
+
+// Open downloads the file to a local copy, unless it is truncated
+func (n *fileNode) Open(ctx context.Context, flags uint32) (fs.FileHandle, uint32, syscall.Errno) {
+	write := flags&(syscall.O_WRONLY|syscall.O_RDWR|syscall.O_TRUNC) != 0
+	if write && !n.fsys.config.Writable {
+		return nil, 0, syscall.EROFS
+	}
+	h, err := n.open(ctx, flags&syscall.O_TRUNC != 0)
+	if err != nil {
+		return nil, 0, errno(err)
+	}
+	return h, 0, fs.OK
+}
+
+// open creates a handle on a local copy of the file, empty and to be saved
+// if truncate is set
+func (n *fileNode) open(ctx context.Context, truncate bool) (*fileHandle, error) {
+	spool, err := os.CreateTemp(n.fsys.config.SpoolDir, "contents-*")
+	if err != nil {
+		return nil, err
+	}
+	// The copy is removed once closed
+	os.Remove(spool.Name())
+
+	if content := n.current(); content != nil && !truncate {
+		data, _, err := n.fsys.contentService.GetContentData(ctx, content.ID)
+		if err != nil {
+			spool.Close()
+			return nil, err
+		}
+		_, err = io.Copy(spool, data)
+		data.Close()
+		if err != nil {
+			spool.Close()
+			return nil, err
+		}
+	}
+	return &fileHandle{node: n, spool: spool, dirty: truncate}, nil
+}
+
+// fileHandle is an open file, read from and written to its local copy
+type fileHandle struct {
+	node  *fileNode
+	spool *os.File
+
+	mu    sync.Mutex
+	dirty bool // Written to since last saved
+}
+
+var (
+	_ fs.FileReader   = (*fileHandle)(nil)
+	_ fs.FileWriter   = (*fileHandle)(nil)
+	_ fs.FileFlusher  = (*fileHandle)(nil)
+	_ fs.FileFsyncer  = (*fileHandle)(nil)
+	_ fs.FileReleaser = (*fileHandle)(nil)
+)
+
+func (h *fileHandle) Read(ctx context.Context, dest []byte, off int64) (gofuse.ReadResult, syscall.Errno) {
+	n, err := h.spool.ReadAt(dest, off)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, syscall.EIO
+	}
+	return gofuse.ReadResultData(dest[:n]), fs.OK
+}
+
+func (h *fileHandle) Write(ctx context.Context, data []byte, off int64) (uint32, syscall.Errno) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n, err := h.spool.WriteAt(data, off)
+	h.dirty = true
+	if err != nil {
+		return uint32(n), syscall.EIO
+	}
+	return uint32(n), fs.OK
+}
+
+// Flush saves the file when a descriptor of it is closed
+func (h *fileHandle) Flush(ctx context.Context) syscall.Errno {
+	return errno(h.save(ctx))
+}
+
+func (h *fileHandle) Fsync(ctx context.Context, flags uint32) syscall.Errno {
+	return errno(h.save(ctx))
+}
+
+func (h *fileHandle) Release(ctx context.Context) syscall.Errno {
+	h.spool.Close()
+	return fs.OK
+}
+
+// size returns the size of the local copy
+func (h *fileHandle) size() (int64, error) {
+	info, err := h.spool.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// truncate resizes the local copy
+func (h *fileHandle) truncate(size int64) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.dirty = true
+	return h.spool.Truncate(size)
+}
+
+// save uploads the local copy as new content linked to the entity, if it
+// was written to, and detaches the content it replaces
+func (h *fileHandle) save(ctx context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.dirty {
+		return nil
+	}
+
+	size, err := h.size()
+	if err != nil {
+		return err
+	}
+	node := h.node
+	name := node.fileName()
+	mimeType := mime.TypeByExtension(path.Ext(name))
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+
+	content, err := node.fsys.contentService.CreateContent(ctx, service.CreateContentInput{
+		FileName:   name,
+		MIMEType:   mimeType,
+		FileSize:   size,
+		Data:       io.NewSectionReader(h.spool, 0, size),
+		EntityType: node.entityType,
+		EntityID:   node.entityID,
+		Source:     fuseSource,
+	})
+	if err != nil {
+		return err
+	}
+	h.dirty = false
+
+	previous := node.replace(content)
+	if previous == nil {
+		return nil
+	}
+	_, err = node.fsys.contentService.DeleteContent(ctx, previous.ID, service.DeleteContentOptions{
+		Policy:     service.DeleteLastReference,
+		EntityType: node.entityType,
+		EntityID:   node.entityID,
+	})
+	if errors.Is(err, service.ErrContentNotFound) {
+		return nil
+	}
+	return err
+}
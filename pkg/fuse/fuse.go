@@ -0,0 +1,361 @@
+// Package fuse mounts the content catalogue as a local filesystem, so tools
+// that expect files on disk, such as data-science notebooks, can read and
+// write content. The tree has the layout of the WebDAV facade:
+// /{entity type}/{entity ID}/{file name}.
+package fuse
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hanwen/go-fuse/v2/fs"
+	gofuse "github.com/hanwen/go-fuse/v2/fuse"
+	"github.com/livefire2015/simple-contents/repository"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+const (
+	// fuseSource is the source of content saved through the filesystem
+	fuseSource = "fuse"
+
+	// entityPageSize is the number of entities read per call when listing a type
+	entityPageSize = 500
+
+	// cacheTimeout is how long the kernel caches names and attributes
+	cacheTimeout = time.Second
+
+	// renameat2 flags passed on by the kernel
+	renameNoReplace = 0x1
+	renameExchange  = 0x2
+)
+
+// Config configures the filesystem
+type Config struct {
+	EntityTypes []string // Entity types listed at the root of the tree
+	Writable    bool     // Allow creating, replacing, renaming and deleting files
+
+	// SpoolDir holds the local copies of open files, the system temporary
+	// directory if empty
+	SpoolDir string
+}
+
+// Mount mounts the catalogue on dir and serves it until it is unmounted,
+// e.g. with the returned server's Unmount.
+//
+// Files are downloaded to a local copy when they are opened, not when they
+// are listed. Files opened for writing are uploaded as new content, replacing
+// the previous version in the folder, each time they are closed or synced.
+func Mount(dir string, contentService *service.ContentService, config Config) (*gofuse.Server, error) {
+	timeout := cacheTimeout
+	return fs.Mount(dir, newRoot(contentService, config), &fs.Options{
+		MountOptions: gofuse.MountOptions{
+			FsName:      "simple-contents",
+			Name:        "contents",
+			DirectMount: true,
+		},
+		EntryTimeout: &timeout,
+		AttrTimeout:  &timeout,
+		UID:          uint32(os.Getuid()),
+		GID:          uint32(os.Getgid()),
+	})
+}
+
+// fileSystem is shared by the nodes of a mount
+type fileSystem struct {
+	contentService *service.ContentService
+	config         Config
+}
+
+// newRoot returns the root node of the tree
+func newRoot(contentService *service.ContentService, config Config) *rootNode {
+	return &rootNode{fsys: &fileSystem{contentService: contentService, config: config}}
+}
+
+// fileMode returns the permissions of files
+func (fsys *fileSystem) fileMode() uint32 {
+	if fsys.config.Writable {
+		return 0o644
+	}
+	return 0o444
+}
+
+// dirMode returns the permissions of folders
+func (fsys *fileSystem) dirMode() uint32 {
+	if fsys.config.Writable {
+		return 0o755
+	}
+	return 0o555
+}
+
+// errno maps service errors to the error numbers returned to the kernel
+func errno(err error) syscall.Errno {
+	switch {
+	case err == nil:
+		return fs.OK
+	case errors.Is(err, service.ErrContentNotFound), errors.Is(err, service.ErrAssociationNotFound):
+		return syscall.ENOENT
+	case errors.Is(err, service.ErrContentQuarantined), errors.Is(err, service.ErrElevatedScopeRequired),
+		errors.Is(err, service.ErrContentRetained):
+		return syscall.EACCES
+	case errors.Is(err, service.ErrScanPending):
+		return syscall.EAGAIN
+	case errors.Is(err, service.ErrQuotaExceeded):
+		return syscall.ENOSPC
+	case errors.Is(err, service.ErrInvalidInput):
+		return syscall.EINVAL
+	}
+	return syscall.EIO
+}
+
+// dirChild returns the folder node named name under parent, reusing the
+// inode of an earlier lookup
+func dirChild(ctx context.Context, parent *fs.Inode, name string, node fs.InodeEmbedder) *fs.Inode {
+	if child := parent.GetChild(name); child != nil && child.IsDir() {
+		return child
+	}
+	return parent.NewInode(ctx, node, fs.StableAttr{Mode: syscall.S_IFDIR})
+}
+
+// rootNode lists the configured entity types
+type rootNode struct {
+	fs.Inode
+	fsys *fileSystem
+}
+
+var (
+	_ fs.NodeReaddirer = (*rootNode)(nil)
+	_ fs.NodeLookuper  = (*rootNode)(nil)
+	_ fs.NodeGetattrer = (*rootNode)(nil)
+)
+
+func (n *rootNode) Getattr(ctx context.Context, f fs.FileHandle, out *gofuse.AttrOut) syscall.Errno {
+	out.Mode = syscall.S_IFDIR | n.fsys.dirMode()
+	return fs.OK
+}
+
+func (n *rootNode) Readdir(ctx context.Context) (fs.DirStream, syscall.Errno) {
+	entries := make([]gofuse.DirEntry, 0, len(n.fsys.config.EntityTypes))
+	for _, entityType := range n.fsys.config.EntityTypes {
+		entries = append(entries, gofuse.DirEntry{Name: entityType, Mode: syscall.S_IFDIR})
+	}
+	return fs.NewListDirStream(entries), fs.OK
+}
+
+func (n *rootNode) Lookup(ctx context.Context, name string, out *gofuse.EntryOut) (*fs.Inode, syscall.Errno) {
+	if !slices.Contains(n.fsys.config.EntityTypes, name) {
+		return nil, syscall.ENOENT
+	}
+	out.Mode = syscall.S_IFDIR | n.fsys.dirMode()
+	return dirChild(ctx, &n.Inode, name, &typeNode{fsys: n.fsys, entityType: name}), fs.OK
+}
+
+// typeNode lists the entities of a type that have content. Every entity has
+// a folder, so content can be saved for an entity that has none yet.
+type typeNode struct {
+	fs.Inode
+	fsys       *fileSystem
+	entityType string
+}
+
+var (
+	_ fs.NodeReaddirer = (*typeNode)(nil)
+	_ fs.NodeLookuper  = (*typeNode)(nil)
+	_ fs.NodeMkdirer   = (*typeNode)(nil)
+	_ fs.NodeGetattrer = (*typeNode)(nil)
+)
+
+func (n *typeNode) Getattr(ctx context.Context, f fs.FileHandle, out *gofuse.AttrOut) syscall.Errno {
+	out.Mode = syscall.S_IFDIR | n.fsys.dirMode()
+	return fs.OK
+}
+
+func (n *typeNode) Readdir(ctx context.Context) (fs.DirStream, syscall.Errno) {
+	var entries []gofuse.DirEntry
+	for page := 1; ; page++ {
+		entityIDs, _, err := n.fsys.contentService.ListEntities(ctx, n.entityType, repository.ListOptions{Page: page, PageSize: entityPageSize})
+		if err != nil {
+			return nil, errno(err)
+		}
+		for _, entityID := range entityIDs {
+			entries = append(entries, gofuse.DirEntry{Name: entityID, Mode: syscall.S_IFDIR})
+		}
+		if len(entityIDs) < entityPageSize {
+			return fs.NewListDirStream(entries), fs.OK
+		}
+	}
+}
+
+func (n *typeNode) Lookup(ctx context.Context, name string, out *gofuse.EntryOut) (*fs.Inode, syscall.Errno) {
+	out.Mode = syscall.S_IFDIR | n.fsys.dirMode()
+	return dirChild(ctx, &n.Inode, name, &entityNode{fsys: n.fsys, entityType: n.entityType, entityID: name}), fs.OK
+}
+
+// Mkdir creates an entity folder, which always exists
+func (n *typeNode) Mkdir(ctx context.Context, name string, mode uint32, out *gofuse.EntryOut) (*fs.Inode, syscall.Errno) {
+	if !n.fsys.config.Writable {
+		return nil, syscall.EROFS
+	}
+	return n.Lookup(ctx, name, out)
+}
+
+// entityNode lists the content linked to an entity as files
+type entityNode struct {
+	fs.Inode
+	fsys       *fileSystem
+	entityType string
+	entityID   string
+}
+
+var (
+	_ fs.NodeReaddirer = (*entityNode)(nil)
+	_ fs.NodeLookuper  = (*entityNode)(nil)
+	_ fs.NodeCreater   = (*entityNode)(nil)
+	_ fs.NodeUnlinker  = (*entityNode)(nil)
+	_ fs.NodeRenamer   = (*entityNode)(nil)
+	_ fs.NodeGetattrer = (*entityNode)(nil)
+)
+
+func (n *entityNode) Getattr(ctx context.Context, f fs.FileHandle, out *gofuse.AttrOut) syscall.Errno {
+	out.Mode = syscall.S_IFDIR | n.fsys.dirMode()
+	return fs.OK
+}
+
+func (n *entityNode) Readdir(ctx context.Context) (fs.DirStream, syscall.Errno) {
+	files, names, err := n.fsys.contentService.EntityFiles(ctx, n.entityType, n.entityID)
+	if err != nil {
+		return nil, errno(err)
+	}
+	entries := make([]gofuse.DirEntry, 0, len(names))
+	for _, name := range names {
+		entries = append(entries, gofuse.DirEntry{Name: name, Mode: syscall.S_IFREG, Ino: contentIno(files[name].ID)})
+	}
+	return fs.NewListDirStream(entries), fs.OK
+}
+
+func (n *entityNode) Lookup(ctx context.Context, name string, out *gofuse.EntryOut) (*fs.Inode, syscall.Errno) {
+	files, _, err := n.fsys.contentService.EntityFiles(ctx, n.entityType, n.entityID)
+	if err != nil {
+		return nil, errno(err)
+	}
+
+	content, ok := files[name]
+	if !ok {
+		// A file created here is listed once it is first saved
+		if child := n.GetChild(name); child != nil {
+			if node, ok := child.Operations().(*fileNode); ok && node.current() == nil {
+				node.fillAttr(&out.Attr)
+				return child, fs.OK
+			}
+		}
+		return nil, syscall.ENOENT
+	}
+
+	node := &fileNode{fsys: n.fsys, entityType: n.entityType, entityID: n.entityID, name: name, content: content}
+	child := n.NewInode(ctx, node, fs.StableAttr{Mode: syscall.S_IFREG, Ino: contentIno(content.ID)})
+	// An inode of the same content found earlier is reused
+	child.Operations().(*fileNode).setContent(name, content)
+	child.Operations().(*fileNode).fillAttr(&out.Attr)
+	return child, fs.OK
+}
+
+// Create creates a file, stored as content once it is first saved. Hidden
+// files, such as editor swap files, are not worth cataloguing.
+func (n *entityNode) Create(ctx context.Context, name string, flags uint32, mode uint32, out *gofuse.EntryOut) (*fs.Inode, fs.FileHandle, uint32, syscall.Errno) {
+	if !n.fsys.config.Writable {
+		return nil, nil, 0, syscall.EROFS
+	}
+	if strings.HasPrefix(name, ".") {
+		return nil, nil, 0, syscall.EACCES
+	}
+
+	node := &fileNode{fsys: n.fsys, entityType: n.entityType, entityID: n.entityID, name: name}
+	handle, err := node.open(ctx, true)
+	if err != nil {
+		return nil, nil, 0, errno(err)
+	}
+	node.fillAttr(&out.Attr)
+	return n.NewInode(ctx, node, fs.StableAttr{Mode: syscall.S_IFREG}), handle, 0, fs.OK
+}
+
+// Unlink detaches a file from the entity, deleting the content once no
+// other entity uses it
+func (n *entityNode) Unlink(ctx context.Context, name string) syscall.Errno {
+	if !n.fsys.config.Writable {
+		return syscall.EROFS
+	}
+	files, _, err := n.fsys.contentService.EntityFiles(ctx, n.entityType, n.entityID)
+	if err != nil {
+		return errno(err)
+	}
+	content, ok := files[name]
+	if !ok {
+		return syscall.ENOENT
+	}
+	return errno(n.detach(ctx, content.ID))
+}
+
+// Rename renames a file within its entity folder, detaching the file it
+// replaces, as editors do when they save to a temporary file first
+func (n *entityNode) Rename(ctx context.Context, name string, newParent fs.InodeEmbedder, newName string, flags uint32) syscall.Errno {
+	if !n.fsys.config.Writable {
+		return syscall.EROFS
+	}
+	if newParent.EmbeddedInode() != n.EmbeddedInode() || strings.HasPrefix(newName, ".") {
+		return syscall.EACCES
+	}
+	if flags&renameExchange != 0 {
+		return syscall.ENOTSUP
+	}
+
+	files, _, err := n.fsys.contentService.EntityFiles(ctx, n.entityType, n.entityID)
+	if err != nil {
+		return errno(err)
+	}
+	replaced, exists := files[newName]
+	if exists && flags&renameNoReplace != 0 {
+		return syscall.EEXIST
+	}
+	child := n.GetChild(name)
+	content, ok := files[name]
+	if !ok {
+		// A created file that isn't saved yet is only renamed locally
+		if child != nil {
+			if node, ok := child.Operations().(*fileNode); ok && node.current() == nil {
+				node.setContent(newName, nil)
+				return fs.OK
+			}
+		}
+		return syscall.ENOENT
+	}
+
+	updated, err := n.fsys.contentService.UpdateContent(ctx, service.UpdateContentInput{ID: content.ID, FileName: newName})
+	if err != nil {
+		return errno(err)
+	}
+	// Later saves of an open file keep the new name
+	if child != nil {
+		if node, ok := child.Operations().(*fileNode); ok {
+			node.setContent(newName, updated)
+		}
+	}
+	if exists && replaced.ID != content.ID {
+		return errno(n.detach(ctx, replaced.ID))
+	}
+	return fs.OK
+}
+
+// detach removes content from the entity, deleting it once no other entity uses it
+func (n *entityNode) detach(ctx context.Context, id uuid.UUID) error {
+	_, err := n.fsys.contentService.DeleteContent(ctx, id, service.DeleteContentOptions{
+		Policy:     service.DeleteLastReference,
+		EntityType: n.entityType,
+		EntityID:   n.entityID,
+	})
+	return err
+}
@@ -0,0 +1,135 @@
+package fuse
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+	"github.com/livefire2015/simple-contents/repository/memory"
+	"github.com/livefire2015/simple-contents/service"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+)
+
+// mount mounts a catalogue holding one file of order 42, skipping the test
+// where FUSE isn't available
+func mount(t *testing.T, config Config) (string, *service.ContentService) {
+	t.Helper()
+	contentService := service.NewContentService(memory.NewMemoryRepository(), memorystorage.NewMemoryStorage())
+	_, err := contentService.CreateContent(context.Background(), service.CreateContentInput{
+		FileName:   "report.csv",
+		MIMEType:   "text/csv",
+		FileSize:   6,
+		Data:       bytes.NewReader([]byte("a,b\n1\n")),
+		EntityType: "order",
+		EntityID:   "42",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	server, err := Mount(dir, contentService, config)
+	if err != nil {
+		t.Skipf("FUSE is not available: %v", err)
+	}
+	t.Cleanup(func() { server.Unmount() })
+	return dir, contentService
+}
+
+// entityFiles returns the content of order 42 by file name
+func entityFiles(t *testing.T, contentService *service.ContentService) map[string]*model.Content {
+	t.Helper()
+	files, _, err := contentService.EntityFiles(context.Background(), "order", "42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestReadOnlyMount(t *testing.T) {
+	dir, _ := mount(t, Config{EntityTypes: []string{"order"}})
+
+	entries, err := os.ReadDir(filepath.Join(dir, "order"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "42" {
+		t.Errorf("order folder lists %v, want [42]", entries)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "order", "42", "report.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "a,b\n1\n" {
+		t.Errorf("read %q", data)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "order", "42", "new.txt"), []byte("x"), 0o644); err == nil {
+		t.Error("wrote a file to a read-only mount")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "invoice")); !os.IsNotExist(err) {
+		t.Errorf("unknown entity type: err = %v, want not exist", err)
+	}
+}
+
+func TestWriteThrough(t *testing.T) {
+	dir, contentService := mount(t, Config{EntityTypes: []string{"order"}, Writable: true})
+	folder := filepath.Join(dir, "order", "42")
+	previous := entityFiles(t, contentService)["report.csv"]
+
+	// Replacing a file stores new content and detaches the previous version
+	if err := os.WriteFile(filepath.Join(folder, "report.csv"), []byte("a,b\n2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	files := entityFiles(t, contentService)
+	replaced := files["report.csv"]
+	if replaced == nil || replaced.ID == previous.ID || replaced.FileSize != 6 {
+		t.Fatalf("report.csv = %+v after replacing it", replaced)
+	}
+	if _, err := contentService.GetContent(context.Background(), previous.ID); err == nil {
+		t.Error("previous version still exists")
+	}
+
+	// New files are linked to the entity of the folder
+	if err := os.WriteFile(filepath.Join(folder, "notes.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	notes := entityFiles(t, contentService)["notes.txt"]
+	if notes == nil || notes.Source != fuseSource || notes.MIMEType != "text/plain; charset=utf-8" {
+		t.Fatalf("notes.txt = %+v", notes)
+	}
+
+	// Renaming over a file replaces it
+	if err := os.Rename(filepath.Join(folder, "notes.txt"), filepath.Join(folder, "report.csv")); err != nil {
+		t.Fatal(err)
+	}
+	files = entityFiles(t, contentService)
+	if got := files["report.csv"]; got == nil || got.ID != notes.ID || len(files) != 1 {
+		t.Errorf("files after rename: %v", files)
+	}
+
+	if err := os.Remove(filepath.Join(folder, "report.csv")); err != nil {
+		t.Fatal(err)
+	}
+	if files := entityFiles(t, contentService); len(files) != 0 {
+		t.Errorf("files after remove: %v", files)
+	}
+
+	// Hidden files aren't catalogued
+	if err := os.WriteFile(filepath.Join(folder, ".swp"), []byte("x"), 0o644); err == nil {
+		t.Error("created a hidden file")
+	}
+	ids, _, err := contentService.ListEntities(context.Background(), "order", repository.ListOptions{Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(ids, "42") {
+		t.Errorf("entity 42 still has content")
+	}
+}
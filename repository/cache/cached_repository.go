@@ -0,0 +1,392 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// Cache is a key/value store with expiry, such as Redis
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+// Metrics receives cache hit and miss events
+type Metrics interface {
+	Hit(kind string)
+	Miss(kind string)
+}
+
+// Config controls cache TTLs
+type Config struct {
+	ContentTTL time.Duration // TTL for single content items
+	ListTTL    time.Duration // TTL for list results, 0 disables list caching
+	Metrics    Metrics       // Optional hit/miss metrics
+}
+
+// listGenerationKey holds a value that changes on every write so cached lists become unreachable
+const listGenerationKey = "contents:list:generation"
+
+// CachedRepository caches ContentRepository reads and invalidates them on writes
+type CachedRepository struct {
+	repository.ContentRepository
+	cache  Cache
+	config Config
+
+	// pending collects the invalidations of a transaction until it commits.
+	// It is only set on the repository passed to a WithTx callback.
+	pending *pendingInvalidations
+}
+
+// pendingInvalidations are the cache entries a transaction's writes make stale
+type pendingInvalidations struct {
+	mu       sync.Mutex
+	contents map[uuid.UUID]struct{}
+	lists    bool
+}
+
+// NewCachedRepository creates a new caching repository decorator
+func NewCachedRepository(repo repository.ContentRepository, cache Cache, config Config) *CachedRepository {
+	return &CachedRepository{
+		ContentRepository: repo,
+		cache:             cache,
+		config:            config,
+	}
+}
+
+// cachedList is the cached form of a ListContent result
+type cachedList struct {
+	Items      []*model.Content `json:"items"`
+	TotalCount int              `json:"total_count"`
+}
+
+func contentKey(id uuid.UUID) string {
+	return "contents:" + id.String()
+}
+
+// WithTx runs fn in a transaction of the underlying repository. The cache
+// entries its writes make stale are evicted once it commits: evicting them
+// earlier would let a concurrent read cache the rows as they were before the
+// commit. Reads in the transaction bypass the cache, as they may see
+// uncommitted writes.
+func (r *CachedRepository) WithTx(ctx context.Context, fn func(tx repository.ContentRepository) error) error {
+	if r.pending != nil {
+		// Nested transactions join the outer one, which evicts on commit
+		return r.ContentRepository.WithTx(ctx, func(tx repository.ContentRepository) error {
+			return fn(&CachedRepository{ContentRepository: tx, cache: r.cache, config: r.config, pending: r.pending})
+		})
+	}
+
+	pending := &pendingInvalidations{contents: make(map[uuid.UUID]struct{})}
+	err := r.ContentRepository.WithTx(ctx, func(tx repository.ContentRepository) error {
+		return fn(&CachedRepository{ContentRepository: tx, cache: r.cache, config: r.config, pending: pending})
+	})
+	if err != nil {
+		return err
+	}
+
+	for id := range pending.contents {
+		_ = r.cache.Delete(ctx, contentKey(id))
+	}
+	if pending.lists || len(pending.contents) > 0 {
+		r.invalidateLists(ctx)
+	}
+	return nil
+}
+
+// CreateContent stores a new content item and invalidates cached lists
+func (r *CachedRepository) CreateContent(ctx context.Context, content *model.Content) error {
+	if err := r.ContentRepository.CreateContent(ctx, content); err != nil {
+		return err
+	}
+
+	r.invalidateLists(ctx)
+	return nil
+}
+
+// CreateContentBatch stores several new content items and invalidates cached lists
+func (r *CachedRepository) CreateContentBatch(ctx context.Context, contents []*model.Content) error {
+	if err := r.ContentRepository.CreateContentBatch(ctx, contents); err != nil {
+		return err
+	}
+
+	r.invalidateLists(ctx)
+	return nil
+}
+
+// GetContentByID retrieves a content item from the cache or the underlying repository
+func (r *CachedRepository) GetContentByID(ctx context.Context, id uuid.UUID) (*model.Content, error) {
+	// Reads that must be current skip the cache as they skip replicas
+	if r.pending != nil || repository.PrimaryRequested(ctx) {
+		return r.ContentRepository.GetContentByID(ctx, id)
+	}
+
+	key := contentKey(id)
+	if data, ok, err := r.cache.Get(ctx, key); err == nil && ok {
+		var content model.Content
+		if err := json.Unmarshal(data, &content); err == nil {
+			r.hit("content")
+			return &content, nil
+		}
+	}
+	r.miss("content")
+
+	content, err := r.ContentRepository.GetContentByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err := json.Marshal(content); err == nil {
+		_ = r.cache.Set(ctx, key, data, r.config.ContentTTL)
+	}
+	return content, nil
+}
+
+// UpdateContent updates a content item and invalidates its cache entries
+func (r *CachedRepository) UpdateContent(ctx context.Context, content *model.Content) error {
+	err := r.ContentRepository.UpdateContent(ctx, content)
+	r.invalidate(ctx, content.ID)
+	return err
+}
+
+// MarkCallbackSent records a callback and invalidates the cached item
+func (r *CachedRepository) MarkCallbackSent(ctx context.Context, id uuid.UUID, sentAt time.Time) (bool, error) {
+	marked, err := r.ContentRepository.MarkCallbackSent(ctx, id, sentAt)
+	if marked {
+		r.invalidate(ctx, id)
+	}
+	return marked, err
+}
+
+// ClearCallbackSent forgets a callback and invalidates the cached item
+func (r *CachedRepository) ClearCallbackSent(ctx context.Context, id uuid.UUID) error {
+	err := r.ContentRepository.ClearCallbackSent(ctx, id)
+	r.invalidate(ctx, id)
+	return err
+}
+
+// DeleteContent deletes a content item and invalidates its cache entries
+func (r *CachedRepository) DeleteContent(ctx context.Context, id uuid.UUID) error {
+	err := r.ContentRepository.DeleteContent(ctx, id)
+	r.invalidate(ctx, id)
+	return err
+}
+
+// DeleteContentIfUnreferenced deletes an unreferenced content item and invalidates its cache entries
+func (r *CachedRepository) DeleteContentIfUnreferenced(ctx context.Context, id uuid.UUID) error {
+	err := r.ContentRepository.DeleteContentIfUnreferenced(ctx, id)
+	if err == nil {
+		r.invalidate(ctx, id)
+	}
+	return err
+}
+
+// DeleteContents deletes several content items and invalidates their cache entries
+func (r *CachedRepository) DeleteContents(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error) {
+	deleted, err := r.ContentRepository.DeleteContents(ctx, ids)
+	for _, id := range ids {
+		r.invalidateContent(ctx, id)
+	}
+	r.invalidateLists(ctx)
+	return deleted, err
+}
+
+// TransferOwnership hands content over to another creator and invalidates
+// the cache entries of the items transferred
+func (r *CachedRepository) TransferOwnership(ctx context.Context, tenantID, from, to string, ids []uuid.UUID) ([]uuid.UUID, error) {
+	transferred, err := r.ContentRepository.TransferOwnership(ctx, tenantID, from, to, ids)
+	for _, id := range transferred {
+		r.invalidateContent(ctx, id)
+	}
+	r.invalidateLists(ctx)
+	return transferred, err
+}
+
+// PruneDeletedContent removes deleted content and invalidates cached lists
+func (r *CachedRepository) PruneDeletedContent(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	pruned, err := r.ContentRepository.PruneDeletedContent(ctx, deletedBefore)
+	if pruned > 0 {
+		r.invalidateLists(ctx)
+	}
+	return pruned, err
+}
+
+// CreateAssociation links content to an entity and invalidates cached lists
+func (r *CachedRepository) CreateAssociation(ctx context.Context, association *model.ContentEntityAssociation) error {
+	if err := r.ContentRepository.CreateAssociation(ctx, association); err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, association.ContentID)
+	return nil
+}
+
+// UpdateAssociation updates an association and invalidates the cache entries of its content
+func (r *CachedRepository) UpdateAssociation(ctx context.Context, association *model.ContentEntityAssociation) error {
+	err := r.ContentRepository.UpdateAssociation(ctx, association)
+	r.invalidate(ctx, association.ContentID)
+	return err
+}
+
+// DeleteAssociation removes an association and invalidates the cache entries of its content
+func (r *CachedRepository) DeleteAssociation(ctx context.Context, associationID uuid.UUID) error {
+	association, lookupErr := r.ContentRepository.GetAssociationByID(ctx, associationID)
+	err := r.ContentRepository.DeleteAssociation(ctx, associationID)
+	if lookupErr == nil {
+		r.invalidateContent(ctx, association.ContentID)
+	}
+	r.invalidateLists(ctx)
+	return err
+}
+
+// DeleteAssociationsByEntity removes the associations of an entity and
+// invalidates the cache entries of the content they linked
+func (r *CachedRepository) DeleteAssociationsByEntity(ctx context.Context, entityType, entityID string) ([]*model.ContentEntityAssociation, error) {
+	deleted, err := r.ContentRepository.DeleteAssociationsByEntity(ctx, entityType, entityID)
+	for _, association := range deleted {
+		r.invalidateContent(ctx, association.ContentID)
+	}
+	r.invalidateLists(ctx)
+	return deleted, err
+}
+
+// ReorderAssociations reorders the content of an entity and invalidates the
+// cache entries of the reordered content
+func (r *CachedRepository) ReorderAssociations(ctx context.Context, entityType, entityID string, contentIDs []uuid.UUID) error {
+	if err := r.ContentRepository.ReorderAssociations(ctx, entityType, entityID, contentIDs); err != nil {
+		return err
+	}
+
+	for _, id := range contentIDs {
+		r.invalidateContent(ctx, id)
+	}
+	r.invalidateLists(ctx)
+	return nil
+}
+
+// PinContent pins a content item and invalidates cached lists, which may filter on pins
+func (r *CachedRepository) PinContent(ctx context.Context, pin *model.Pin) error {
+	if err := r.ContentRepository.PinContent(ctx, pin); err != nil {
+		return err
+	}
+
+	r.invalidateLists(ctx)
+	return nil
+}
+
+// UnpinContent removes a pin and invalidates cached lists
+func (r *CachedRepository) UnpinContent(ctx context.Context, principal string, contentID uuid.UUID) error {
+	if err := r.ContentRepository.UnpinContent(ctx, principal, contentID); err != nil {
+		return err
+	}
+
+	r.invalidateLists(ctx)
+	return nil
+}
+
+// ListContent retrieves content items from the cache or the underlying repository
+func (r *CachedRepository) ListContent(ctx context.Context, filter model.ContentFilter, offset int, limit int) ([]*model.Content, int, error) {
+	if r.config.ListTTL <= 0 || r.pending != nil || repository.PrimaryRequested(ctx) {
+		return r.ContentRepository.ListContent(ctx, filter, offset, limit)
+	}
+
+	key, ok := r.listKey(ctx, filter, offset, limit)
+	if ok {
+		if data, found, err := r.cache.Get(ctx, key); err == nil && found {
+			var list cachedList
+			if err := json.Unmarshal(data, &list); err == nil {
+				r.hit("list")
+				return list.Items, list.TotalCount, nil
+			}
+		}
+	}
+	r.miss("list")
+
+	items, totalCount, err := r.ContentRepository.ListContent(ctx, filter, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if ok {
+		if data, err := json.Marshal(cachedList{Items: items, TotalCount: totalCount}); err == nil {
+			_ = r.cache.Set(ctx, key, data, r.config.ListTTL)
+		}
+	}
+	return items, totalCount, nil
+}
+
+// listKey builds the cache key of a list query for the current list generation
+func (r *CachedRepository) listKey(ctx context.Context, filter model.ContentFilter, offset, limit int) (string, bool) {
+	generation, found, err := r.cache.Get(ctx, listGenerationKey)
+	if err != nil {
+		return "", false
+	}
+	if !found {
+		generation = []byte(uuid.NewString())
+		if err := r.cache.Set(ctx, listGenerationKey, generation, 0); err != nil {
+			return "", false
+		}
+	}
+
+	query, err := json.Marshal(filter)
+	if err != nil {
+		return "", false
+	}
+	hash := sha256.New()
+	hash.Write(generation)
+	hash.Write(query)
+	hash.Write([]byte(strconv.Itoa(offset) + ":" + strconv.Itoa(limit)))
+	return "contents:list:" + hex.EncodeToString(hash.Sum(nil)), true
+}
+
+// invalidate drops the cached item and all cached lists
+func (r *CachedRepository) invalidate(ctx context.Context, id uuid.UUID) {
+	r.invalidateContent(ctx, id)
+	r.invalidateLists(ctx)
+}
+
+// invalidateContent drops the cached item, or records it for eviction when
+// the transaction commits
+func (r *CachedRepository) invalidateContent(ctx context.Context, id uuid.UUID) {
+	if r.pending != nil {
+		r.pending.mu.Lock()
+		r.pending.contents[id] = struct{}{}
+		r.pending.mu.Unlock()
+		return
+	}
+	_ = r.cache.Delete(ctx, contentKey(id))
+}
+
+// invalidateLists rotates the list generation so previously cached lists are
+// never read again, or records that it must be rotated when the transaction commits
+func (r *CachedRepository) invalidateLists(ctx context.Context) {
+	if r.pending != nil {
+		r.pending.mu.Lock()
+		r.pending.lists = true
+		r.pending.mu.Unlock()
+		return
+	}
+	_ = r.cache.Set(ctx, listGenerationKey, []byte(uuid.NewString()), 0)
+}
+
+func (r *CachedRepository) hit(kind string) {
+	if r.config.Metrics != nil {
+		r.config.Metrics.Hit(kind)
+	}
+}
+
+func (r *CachedRepository) miss(kind string) {
+	if r.config.Metrics != nil {
+		r.config.Metrics.Miss(kind)
+	}
+}
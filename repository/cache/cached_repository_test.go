@@ -0,0 +1,183 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+	"github.com/livefire2015/simple-contents/repository/memory"
+)
+
+// stagedRepository applies the updates of a transaction only when it commits,
+// like a database would, so reads during the transaction see the old rows
+type stagedRepository struct {
+	*memory.MemoryRepository
+}
+
+type stagingTx struct {
+	repository.ContentRepository
+	updates []*model.Content
+}
+
+func (tx *stagingTx) UpdateContent(ctx context.Context, content *model.Content) error {
+	tx.updates = append(tx.updates, content)
+	return nil
+}
+
+func (r stagedRepository) WithTx(ctx context.Context, fn func(tx repository.ContentRepository) error) error {
+	tx := &stagingTx{ContentRepository: r.MemoryRepository}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	for _, content := range tx.updates {
+		if err := r.MemoryRepository.UpdateContent(ctx, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestWithTxEvictsAfterCommit(t *testing.T) {
+	errRollback := errors.New("rollback")
+
+	tests := []struct {
+		name     string
+		txErr    error
+		wantName string
+	}{
+		{name: "commit", txErr: nil, wantName: "renamed.pdf"},
+		{name: "rollback", txErr: errRollback, wantName: "original.pdf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := NewCachedRepository(stagedRepository{memory.NewMemoryRepository()}, NewMemoryCache(), Config{ContentTTL: time.Hour, ListTTL: time.Hour})
+
+			content := &model.Content{ID: uuid.New(), FileName: "original.pdf", Status: model.StatusDone, Metadata: model.Metadata{}}
+			if err := repo.CreateContent(ctx, content); err != nil {
+				t.Fatal(err)
+			}
+
+			err := repo.WithTx(ctx, func(tx repository.ContentRepository) error {
+				updated, err := tx.GetContentByID(ctx, content.ID)
+				if err != nil {
+					return err
+				}
+				updated.FileName = "renamed.pdf"
+				if err := tx.UpdateContent(ctx, updated); err != nil {
+					return err
+				}
+
+				// A concurrent read before the commit caches the old row
+				if _, err := repo.GetContentByID(ctx, content.ID); err != nil {
+					return err
+				}
+				return tt.txErr
+			})
+			if !errors.Is(err, tt.txErr) {
+				t.Fatalf("WithTx() error = %v, want %v", err, tt.txErr)
+			}
+
+			got, err := repo.GetContentByID(ctx, content.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.FileName != tt.wantName {
+				t.Errorf("file name after transaction = %q, want %q", got.FileName, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestWritesInvalidateLists(t *testing.T) {
+	const entityType, entityID = "project", "p1"
+
+	tests := []struct {
+		name  string
+		write func(ctx context.Context, repo *CachedRepository, content *model.Content, association *model.ContentEntityAssociation) error
+	}{
+		{
+			name: "create batch",
+			write: func(ctx context.Context, repo *CachedRepository, _ *model.Content, _ *model.ContentEntityAssociation) error {
+				return repo.CreateContentBatch(ctx, []*model.Content{{ID: uuid.New(), FileName: "new.pdf", Status: model.StatusDone, Metadata: model.Metadata{}}})
+			},
+		},
+		{
+			name: "prune deleted",
+			write: func(ctx context.Context, repo *CachedRepository, content *model.Content, _ *model.ContentEntityAssociation) error {
+				if err := repo.ContentRepository.DeleteContent(ctx, content.ID); err != nil {
+					return err
+				}
+				_, err := repo.PruneDeletedContent(ctx, time.Now().Add(time.Hour))
+				return err
+			},
+		},
+		{
+			name: "create association",
+			write: func(ctx context.Context, repo *CachedRepository, content *model.Content, _ *model.ContentEntityAssociation) error {
+				return repo.CreateAssociation(ctx, &model.ContentEntityAssociation{ContentID: content.ID, EntityType: "project", EntityID: "p2"})
+			},
+		},
+		{
+			name: "update association",
+			write: func(ctx context.Context, repo *CachedRepository, _ *model.Content, association *model.ContentEntityAssociation) error {
+				association.AssociationMetadata = map[string]interface{}{"role": "cover"}
+				return repo.UpdateAssociation(ctx, association)
+			},
+		},
+		{
+			name: "delete association",
+			write: func(ctx context.Context, repo *CachedRepository, _ *model.Content, association *model.ContentEntityAssociation) error {
+				return repo.DeleteAssociation(ctx, association.ID)
+			},
+		},
+		{
+			name: "delete associations by entity",
+			write: func(ctx context.Context, repo *CachedRepository, _ *model.Content, _ *model.ContentEntityAssociation) error {
+				_, err := repo.DeleteAssociationsByEntity(ctx, entityType, entityID)
+				return err
+			},
+		},
+		{
+			name: "reorder associations",
+			write: func(ctx context.Context, repo *CachedRepository, content *model.Content, _ *model.ContentEntityAssociation) error {
+				return repo.ReorderAssociations(ctx, entityType, entityID, []uuid.UUID{content.ID})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cache := NewMemoryCache()
+			repo := NewCachedRepository(memory.NewMemoryRepository(), cache, Config{ContentTTL: time.Hour, ListTTL: time.Hour})
+
+			content := &model.Content{ID: uuid.New(), FileName: "original.pdf", Status: model.StatusDone, Metadata: model.Metadata{}}
+			if err := repo.CreateContent(ctx, content); err != nil {
+				t.Fatal(err)
+			}
+			association := &model.ContentEntityAssociation{ContentID: content.ID, EntityType: entityType, EntityID: entityID}
+			if err := repo.ContentRepository.CreateAssociation(ctx, association); err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := repo.ListContent(ctx, model.ContentFilter{}, 0, 10); err != nil {
+				t.Fatal(err)
+			}
+			before, _, _ := cache.Get(ctx, listGenerationKey)
+
+			if err := tt.write(ctx, repo, content, association); err != nil {
+				t.Fatal(err)
+			}
+
+			after, _, _ := cache.Get(ctx, listGenerationKey)
+			if string(after) == string(before) {
+				t.Error("list generation unchanged after write, cached lists are stale")
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryCacheEntry is a value held by MemoryCache
+type memoryCacheEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// MemoryCache implements Cache in process memory.
+// It is intended for single-instance deployments and tests.
+type MemoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]memoryCacheEntry
+}
+
+// NewMemoryCache creates a new in-memory cache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		entries: make(map[string]memoryCacheEntry),
+	}
+}
+
+// Get returns the value stored under key if it has not expired
+func (c *MemoryCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, exists := c.entries[key]
+	if !exists || (!entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt)) {
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+// Set stores value under key for ttl, or forever if ttl is zero
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := memoryCacheEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	c.entries[key] = entry
+	return nil
+}
+
+// Delete removes the value stored under key
+func (c *MemoryCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+	return nil
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type writeTrackerKey struct{}
+
+type primaryReadsKey struct{}
+
+// TrackWrites returns a context recording whether writes are made through
+// it, so that backends with read replicas can send the reads that follow a
+// write to the primary
+func TrackWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, writeTrackerKey{}, new(atomic.Bool))
+}
+
+// NoteWrite records that a write was made through ctx
+func NoteWrite(ctx context.Context) {
+	if written, ok := ctx.Value(writeTrackerKey{}).(*atomic.Bool); ok {
+		written.Store(true)
+	}
+}
+
+// Written reports whether a write was made through a context returned by TrackWrites
+func Written(ctx context.Context) bool {
+	written, ok := ctx.Value(writeTrackerKey{}).(*atomic.Bool)
+	return ok && written.Load()
+}
+
+// ReadFromPrimary returns a context whose reads skip read replicas
+func ReadFromPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadsKey{}, true)
+}
+
+// PrimaryRequested reports whether reads through ctx must skip read replicas
+func PrimaryRequested(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryReadsKey{}).(bool)
+	return primary
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/livefire2015/simple-contents/model" // Adjust import path as needed
@@ -12,42 +13,365 @@ import (
 type ListOptions struct {
 	Page        int
 	PageSize    int
-	SortBy      string
-	ReturnTotal bool // Whether to calculate and return total count
+	SortBy      string // SortByPosition for entity content listings, creation time otherwise
+	PinnedBy    string // Only list content pinned by this principal, for content listings
+	CreatedBy   string // Only list content created by this principal, for content listings
+	// IncludeDrafts lists unfinalized drafts too; entity content listings
+	// leave them out otherwise
+	IncludeDrafts bool
+	ReturnTotal bool   // Whether to calculate and return total count
+	// EstimateTotal returns the query planner's estimate of the total instead
+	// of counting, for backends that have one. Only used with ReturnTotal.
+	EstimateTotal bool
+}
+
+// SortByPosition orders the content of an entity by the position of its
+// associations, placed content first and the rest newest first
+const SortByPosition = "position"
+
+// ContentPage is a page of a content listing
+type ContentPage struct {
+	Items          []*model.Content
+	Total          int64 // -1 if the total wasn't requested
+	TotalEstimated bool  // Whether Total is an estimate rather than a count
+	HasMore        bool  // Whether items follow this page
+}
+
+// Offset returns the number of rows to skip for the requested page
+func (o ListOptions) Offset() int {
+	if o.Page <= 1 || o.PageSize <= 0 {
+		return 0
+	}
+	return (o.Page - 1) * o.PageSize
 }
 
 // ContentRepository defines the interface for content and association persistence.
 type ContentRepository interface {
+	// WithTx calls fn with a repository whose writes are committed together
+	// if fn returns nil and rolled back otherwise. Backends without
+	// transactions call fn with themselves. fn may be called again if the
+	// transaction conflicts with a concurrent one.
+	WithTx(ctx context.Context, fn func(tx ContentRepository) error) error
+
 	// --- Content Specific Methods ---
+	// CreateContent returns ErrContentExists if a content item, even a
+	// deleted one, already has the ID, and ErrExternalIDExists if one of
+	// the tenant and source already has the external ID
 	CreateContent(ctx context.Context, content *model.Content) error
+	// CreateContentBatch stores several new content items at once; either all
+	// of them are stored or none, e.g. when one has the ID of existing content
+	CreateContentBatch(ctx context.Context, contents []*model.Content) error
 	GetContentByID(ctx context.Context, id uuid.UUID) (*model.Content, error)
+	// GetContentByExternalID retrieves the content a tenant created from a
+	// source under an external ID
+	GetContentByExternalID(ctx context.Context, tenantID, source, externalID string) (*model.Content, error)
+	// GetContentsByIDs retrieves the content items with the given IDs in no
+	// particular order, leaving out IDs that don't exist or were deleted
+	GetContentsByIDs(ctx context.Context, ids []uuid.UUID) ([]*model.Content, error)
 	ListContent(ctx context.Context, filter model.ContentFilter, offset int, limit int) ([]*model.Content, int, error)
-	UpdateContent(ctx context.Context, content *model.Content) error // For metadata, status, etc.
+	// ListContentStream calls fn for every content item matching a filter, in
+	// the order of ListContent, without counting them or holding them all in
+	// memory. It stops at the first error fn returns.
+	ListContentStream(ctx context.Context, filter model.ContentFilter, fn func(*model.Content) error) error
+	// ListContentPage retrieves a page of the content matching a filter. The
+	// total is only counted, or estimated, as options request; whether more
+	// items follow is found by reading one item past the page.
+	ListContentPage(ctx context.Context, filter model.ContentFilter, options ListOptions) (*ContentPage, error)
+	// ListContentByMetadataKey lists up to limit content items matching a
+	// filter whose metadata value under key is a string that starts with
+	// prefix and doesn't sort before from, in byte order of that value, so
+	// callers can page through them by value. Sorting fields of the filter
+	// are ignored.
+	ListContentByMetadataKey(ctx context.Context, filter model.ContentFilter, key, prefix, from string, limit int) ([]*model.Content, error)
+	// ContentStats counts the content matching a filter and sums its size per
+	// group, ordered by key. Sorting fields of the filter are ignored.
+	ContentStats(ctx context.Context, filter model.ContentFilter, groupBy model.ContentGroupBy) ([]*model.ContentStatsBucket, error)
+	UpdateContent(ctx context.Context, content *model.Content) error // For metadata, status, etc.; leaves CallbackSentAt unchanged
+	// MarkCallbackSent records that the completion callback of a content item
+	// is sent at sentAt unless one already is, and reports whether this call
+	// recorded it, so that only one caller sends the callback
+	MarkCallbackSent(ctx context.Context, id uuid.UUID, sentAt time.Time) (bool, error)
+	// ClearCallbackSent forgets a callback that couldn't be delivered, so it can be sent again
+	ClearCallbackSent(ctx context.Context, id uuid.UUID) error
 	DeleteContent(ctx context.Context, id uuid.UUID) error           // This would cascade to associations if DB constraints are set
+	// DeleteContentIfUnreferenced marks a content item as deleted unless an
+	// association still links it to an entity, in which case it returns ErrContentReferenced
+	DeleteContentIfUnreferenced(ctx context.Context, id uuid.UUID) error
+	// DeleteContents marks several content items as deleted and returns the IDs that existed
+	DeleteContents(ctx context.Context, ids []uuid.UUID) (deleted []uuid.UUID, err error)
+	// TransferOwnership makes to the creator of the content of a tenant that
+	// from created, of only the given IDs if any, and returns the IDs of the
+	// items transferred. Deleted content is left out.
+	TransferOwnership(ctx context.Context, tenantID, from, to string, ids []uuid.UUID) (transferred []uuid.UUID, err error)
+	// PruneDeletedContent permanently removes the content marked as deleted
+	// before a cutoff and returns the number of items removed
+	PruneDeletedContent(ctx context.Context, deletedBefore time.Time) (int64, error)
+
+	// --- Association Specific Methods ---
+	CreateAssociation(ctx context.Context, association *model.ContentEntityAssociation) error
+	GetAssociationByID(ctx context.Context, associationID uuid.UUID) (*model.ContentEntityAssociation, error)
+	// Get a specific association if its ID isn't known but the linked items are.
+	GetAssociationByLink(ctx context.Context, contentID uuid.UUID, entityType, entityID string) (*model.ContentEntityAssociation, error)
+	UpdateAssociation(ctx context.Context, association *model.ContentEntityAssociation) error // e.g., to update metadata or re-link (less common)
+	DeleteAssociation(ctx context.Context, associationID uuid.UUID) error
+	// DeleteAssociationsByEntity removes every association of an entity and returns them
+	DeleteAssociationsByEntity(ctx context.Context, entityType, entityID string) (deleted []*model.ContentEntityAssociation, err error)
+	// ReorderAssociations places the given content of an entity at positions
+	// 1..n and unplaces the rest. It fails with ErrAssociationNotFound, changing
+	// nothing, if a content item isn't linked to the entity.
+	ReorderAssociations(ctx context.Context, entityType, entityID string, contentIDs []uuid.UUID) error
+	// Alternative: DeleteAssociationByLink(ctx context.Context, contentID uuid.UUID, entityType, entityID string) error
+
+	// --- Relation Methods ---
+	// CreateRelation returns ErrRelationExists if the same relation links the two content items
+	CreateRelation(ctx context.Context, relation *model.ContentRelation) error
+	GetRelation(ctx context.Context, id uuid.UUID) (*model.ContentRelation, error)
+	// ListRelations returns the relations of a content item in a direction,
+	// optionally of one type, oldest first
+	ListRelations(ctx context.Context, contentID uuid.UUID, direction model.RelationDirection, relationType model.RelationType) ([]*model.ContentRelation, error)
+	DeleteRelation(ctx context.Context, id uuid.UUID) error
+
+	// --- Pin Methods ---
+	// PinContent pins a content item for a principal; pinning twice keeps the first pin
+	PinContent(ctx context.Context, pin *model.Pin) error
+	UnpinContent(ctx context.Context, principal string, contentID uuid.UUID) error
+
+	// --- Querying Methods (involving associations) ---
+
+	// List content associated with a specific entity.
+	// The implementation will join `contents` with `content_entity_associations`.
+	ListContentByEntity(ctx context.Context, entityType string, entityID string, options ListOptions) (contents []*model.Content, total int64, err error)
 
-	// // --- Association Specific Methods ---
-	// CreateAssociation(ctx context.Context, association *model.ContentEntityAssociation) error
-	// GetAssociationByID(ctx context.Context, associationID string) (*model.ContentEntityAssociation, error)
-	// // Get a specific association if its ID isn't known but the linked items are.
-	// GetAssociationByLink(ctx context.Context, contentID, entityType, entityID string) (*model.ContentEntityAssociation, error)
-	// UpdateAssociation(ctx context.Context, association *model.ContentEntityAssociation) error // e.g., to update metadata or re-link (less common)
-	// DeleteAssociation(ctx context.Context, associationID string) error
-	// // Alternative: DeleteAssociationByLink(ctx context.Context, contentID, entityType, entityID string) error
+	// List content associated with an entity along with the associations, read in the same query.
+	ListEntityContents(ctx context.Context, entityType string, entityID string, options ListOptions) (contents []*model.EntityContent, total int64, err error)
 
-	// // --- Querying Methods (involving associations) ---
+	// List associations for a given entity (useful if you want the association metadata too).
+	ListAssociationsByEntity(ctx context.Context, entityType string, entityID string, options ListOptions) (associations []*model.ContentEntityAssociation, total int64, err error)
 
-	// // List content associated with a specific entity.
-	// // The implementation will join `contents` with `content_entity_associations`.
-	// ListContentByEntity(ctx context.Context, entityType string, entityID string, options ListOptions) (contents []*model.Content, total int64, err error)
+	// List entities (via associations) linked to a specific content item.
+	ListAssociationsByContent(ctx context.Context, contentID uuid.UUID, options ListOptions) (associations []*model.ContentEntityAssociation, total int64, err error)
 
-	// // List associations for a given entity (useful if you want the association metadata too).
-	// ListAssociationsByEntity(ctx context.Context, entityType string, entityID string, options ListOptions) (associations []*model.ContentEntityAssociation, total int64, err error)
+	// List the distinct IDs of the entities of a type that have associations, in ID order.
+	ListEntityIDs(ctx context.Context, entityType string, options ListOptions) (entityIDs []string, total int64, err error)
 
-	// // List entities (via associations) linked to a specific content item.
-	// ListAssociationsByContent(ctx context.Context, contentID string, options ListOptions) (associations []*model.ContentEntityAssociation, total int64, err error)
+	// List associations in a review state, optionally restricted to one entity type.
+	ListAssociationsByReviewState(ctx context.Context, entityType string, state model.ReviewState, options ListOptions) (associations []*model.ContentEntityAssociation, total int64, err error)
 
 	// (Optional) Search content based on association metadata (more complex query)
 	// SearchContentByAssociationMetadata(ctx context.Context, entityType string, entityID string, metadataQuery map[string]interface{}, options ListOptions) ([]*model.Content, int64, error)
 }
 
-var ErrContentNotFound = errors.New("content not found")
+// TenantRepository defines the interface for tenant persistence.
+type TenantRepository interface {
+	CreateTenant(ctx context.Context, tenant *model.Tenant) error
+	GetTenant(ctx context.Context, id string) (*model.Tenant, error)
+	ListTenants(ctx context.Context, options ListOptions) (tenants []*model.Tenant, total int64, err error)
+	UpdateTenant(ctx context.Context, tenant *model.Tenant) error
+	DeleteTenant(ctx context.Context, id string) error
+	// AddTenantUsage atomically adds delta to the stored bytes of a tenant and returns the new total
+	AddTenantUsage(ctx context.Context, id string, delta int64) (usedBytes int64, err error)
+	// SetTenantUsage replaces the stored bytes of a tenant, e.g. with a recount
+	SetTenantUsage(ctx context.Context, id string, usedBytes int64) error
+}
+
+// AnnotationRepository defines the interface for annotation persistence.
+type AnnotationRepository interface {
+	CreateAnnotation(ctx context.Context, annotation *model.Annotation) error
+	GetAnnotation(ctx context.Context, id uuid.UUID) (*model.Annotation, error)
+	// ListAnnotationsByContent returns the annotations of a content item, oldest first
+	ListAnnotationsByContent(ctx context.Context, contentID uuid.UUID, options ListOptions) (annotations []*model.Annotation, total int64, err error)
+	UpdateAnnotation(ctx context.Context, annotation *model.Annotation) error
+	DeleteAnnotation(ctx context.Context, id uuid.UUID) error
+}
+
+// ScanResultRepository defines the interface for virus scan result persistence.
+type ScanResultRepository interface {
+	// GetScanResult returns the result of scanning data of a digest with a signature database version
+	GetScanResult(ctx context.Context, digest, signatureVersion string) (*model.ScanResult, error)
+	// SaveScanResult stores a scan result, replacing an earlier one for the same digest and version
+	SaveScanResult(ctx context.Context, result *model.ScanResult) error
+}
+
+// SearchIndexRepository defines the interface for rebuilding the indexes a
+// repository keeps to search content, after they are corrupted or the way
+// they are built changes.
+type SearchIndexRepository interface {
+	// SearchIndexes names the search indexes, in the order they are rebuilt
+	SearchIndexes(ctx context.Context) ([]string, error)
+	// RebuildSearchIndex rebuilds a search index from the stored content
+	RebuildSearchIndex(ctx context.Context, name string) error
+}
+
+// JobRepository defines the interface for asynchronous job persistence.
+type JobRepository interface {
+	CreateJob(ctx context.Context, job *model.Job) error
+	GetJob(ctx context.Context, id uuid.UUID) (*model.Job, error)
+	// UpdateJob records the state of a job, leaving a cancellation request in place
+	UpdateJob(ctx context.Context, job *model.Job) error
+	// RequestJobCancel flags a job to be cancelled by the instance running it
+	RequestJobCancel(ctx context.Context, id uuid.UUID) error
+	// FailStaleJobs fails the unfinished jobs last updated before a cutoff,
+	// whose instance stopped running them
+	FailStaleJobs(ctx context.Context, updatedBefore time.Time, reason string) (int64, error)
+	// PruneJobs removes the jobs that finished before a cutoff
+	PruneJobs(ctx context.Context, finishedBefore time.Time) (int64, error)
+}
+
+// PendingDeletionRepository queues the deletion of stored objects that
+// failed while storage was unavailable, until it is retried.
+type PendingDeletionRepository interface {
+	QueueDeletion(ctx context.Context, deletion *model.PendingDeletion) error
+	// ListPendingDeletions returns up to limit queued deletions, oldest first
+	ListPendingDeletions(ctx context.Context, limit int) ([]*model.PendingDeletion, error)
+	// RemovePendingDeletion removes a deletion that has been carried out
+	RemovePendingDeletion(ctx context.Context, id uuid.UUID) error
+}
+
+// LockRepository hands out named locks shared by every instance of the
+// service, e.g. so that only one of them runs scheduled tasks.
+type LockRepository interface {
+	// TryLock takes a lock, or returns ErrLockHeld if another holder has it
+	TryLock(ctx context.Context, name string) (Lock, error)
+}
+
+// Lock is a lock taken from a LockRepository
+type Lock interface {
+	// Held reports whether the lock is still held, which stops being the
+	// case when the session holding it is lost
+	Held(ctx context.Context) bool
+	Release(ctx context.Context) error
+}
+
+// DocumentRepository defines the interface for localized document persistence.
+type DocumentRepository interface {
+	CreateDocument(ctx context.Context, document *model.Document) error
+	// GetDocument returns a document with its variants ordered by language
+	GetDocument(ctx context.Context, id uuid.UUID) (*model.Document, error)
+	// ListDocuments returns the documents of a tenant ordered by name, without their variants
+	ListDocuments(ctx context.Context, tenantID string, options ListOptions) (documents []*model.Document, total int64, err error)
+	// UpdateDocument updates the name and default language of a document
+	UpdateDocument(ctx context.Context, document *model.Document) error
+	// DeleteDocument removes a document and its variants; their content is kept
+	DeleteDocument(ctx context.Context, id uuid.UUID) error
+	// SetDocumentVariant adds the variant of a language or replaces its content
+	SetDocumentVariant(ctx context.Context, documentID uuid.UUID, variant model.DocumentVariant) error
+	DeleteDocumentVariant(ctx context.Context, documentID uuid.UUID, language string) error
+}
+
+// SignatureRepository defines the interface for signature request persistence.
+type SignatureRepository interface {
+	CreateSignatureRequest(ctx context.Context, request *model.SignatureRequest) error
+	GetSignatureRequest(ctx context.Context, id uuid.UUID) (*model.SignatureRequest, error)
+	GetSignatureRequestByProviderID(ctx context.Context, provider, providerRequestID string) (*model.SignatureRequest, error)
+	// ListSignatureRequestsByContent returns the requests of a content item, newest first
+	ListSignatureRequestsByContent(ctx context.Context, contentID uuid.UUID, options ListOptions) (requests []*model.SignatureRequest, total int64, err error)
+	UpdateSignatureRequest(ctx context.Context, request *model.SignatureRequest) error
+}
+
+// TemplateRepository defines the interface for document template persistence.
+type TemplateRepository interface {
+	CreateTemplate(ctx context.Context, template *model.Template) error
+	GetTemplate(ctx context.Context, id string) (*model.Template, error)
+	ListTemplates(ctx context.Context, options ListOptions) (templates []*model.Template, total int64, err error)
+	UpdateTemplate(ctx context.Context, template *model.Template) error
+	DeleteTemplate(ctx context.Context, id string) error
+}
+
+// SavedSearchRepository defines the interface for saved search persistence.
+type SavedSearchRepository interface {
+	// CreateSavedSearch returns ErrSavedSearchExists if the principal already has a search with the name
+	CreateSavedSearch(ctx context.Context, search *model.SavedSearch) error
+	GetSavedSearch(ctx context.Context, id uuid.UUID) (*model.SavedSearch, error)
+	// ListSavedSearchesByPrincipal returns the searches of a principal ordered by name
+	ListSavedSearchesByPrincipal(ctx context.Context, principal string, options ListOptions) (searches []*model.SavedSearch, total int64, err error)
+	UpdateSavedSearch(ctx context.Context, search *model.SavedSearch) error
+	DeleteSavedSearch(ctx context.Context, id uuid.UUID) error
+}
+
+// AuditRepository defines the interface for audit log persistence.
+type AuditRepository interface {
+	RecordAuditEvent(ctx context.Context, event *model.AuditEvent) error
+	// PruneAuditEvents permanently removes the events that occurred before a
+	// cutoff and returns the number of events removed
+	PruneAuditEvents(ctx context.Context, before time.Time) (int64, error)
+}
+
+// DownloadLinkRepository defines the interface for download link persistence.
+type DownloadLinkRepository interface {
+	// CreateDownloadLink returns ErrDownloadLinkExists if the token is taken
+	CreateDownloadLink(ctx context.Context, link *model.DownloadLink) error
+	GetDownloadLink(ctx context.Context, token string) (*model.DownloadLink, error)
+	// ListDownloadLinksByContent returns the links of a content item, newest first
+	ListDownloadLinksByContent(ctx context.Context, contentID uuid.UUID, options ListOptions) (links []*model.DownloadLink, total int64, err error)
+	// RecordDownloadLinkClick atomically counts a redemption of a link made at clickedAt
+	RecordDownloadLinkClick(ctx context.Context, token string, clickedAt time.Time) error
+	DeleteDownloadLink(ctx context.Context, token string) error
+}
+
+// ShareRepository defines the interface for public share persistence.
+type ShareRepository interface {
+	// CreateShare returns ErrShareExists if the token is taken
+	CreateShare(ctx context.Context, share *model.Share) error
+	GetShare(ctx context.Context, token string) (*model.Share, error)
+	// ListSharesByContent returns the shares of a content item, revoked ones
+	// included, newest first
+	ListSharesByContent(ctx context.Context, contentID uuid.UUID, options ListOptions) (shares []*model.Share, total int64, err error)
+	// RecordShareDownload atomically counts a download made at downloadedAt,
+	// or returns ErrShareExhausted if the share has no downloads left
+	RecordShareDownload(ctx context.Context, token string, downloadedAt time.Time) error
+	// RevokeShare marks a share revoked at revokedAt; revoking it again keeps
+	// the first time
+	RevokeShare(ctx context.Context, token string, revokedAt time.Time) error
+}
+
+// UploadRequestRepository defines the interface for upload request persistence.
+type UploadRequestRepository interface {
+	// CreateUploadRequest returns ErrUploadRequestExists if the token is taken
+	CreateUploadRequest(ctx context.Context, request *model.UploadRequest) error
+	GetUploadRequest(ctx context.Context, token string) (*model.UploadRequest, error)
+	// ListUploadRequestsByEntity returns the upload requests of an entity,
+	// revoked ones included, newest first
+	ListUploadRequestsByEntity(ctx context.Context, entityType, entityID string, options ListOptions) (requests []*model.UploadRequest, total int64, err error)
+	// ReserveUpload atomically counts an upload through a request, or returns
+	// ErrUploadRequestExhausted if it has no uploads left
+	ReserveUpload(ctx context.Context, token string) error
+	// ReleaseUpload gives back an upload reserved for a file that failed
+	ReleaseUpload(ctx context.Context, token string) error
+	// RevokeUploadRequest marks a request revoked at revokedAt; revoking it
+	// again keeps the first time
+	RevokeUploadRequest(ctx context.Context, token string, revokedAt time.Time) error
+}
+
+var (
+	ErrContentNotFound        = errors.New("content not found")
+	ErrContentExists          = errors.New("content already exists")
+	ErrExternalIDExists       = errors.New("external ID already exists")
+	ErrContentReferenced      = errors.New("content is still associated with an entity")
+	ErrAssociationNotFound    = errors.New("association not found")
+	ErrAssociationExists      = errors.New("association already exists")
+	ErrTenantNotFound         = errors.New("tenant not found")
+	ErrTenantExists           = errors.New("tenant already exists")
+	ErrAnnotationNotFound     = errors.New("annotation not found")
+	ErrSignatureNotFound      = errors.New("signature request not found")
+	ErrTemplateNotFound       = errors.New("template not found")
+	ErrTemplateExists         = errors.New("template already exists")
+	ErrPinNotFound            = errors.New("pin not found")
+	ErrRelationNotFound       = errors.New("relation not found")
+	ErrRelationExists         = errors.New("relation already exists")
+	ErrDocumentNotFound       = errors.New("document not found")
+	ErrVariantNotFound        = errors.New("document variant not found")
+	ErrScanResultNotFound     = errors.New("scan result not found")
+	ErrSavedSearchNotFound    = errors.New("saved search not found")
+	ErrSavedSearchExists      = errors.New("saved search already exists")
+	ErrDownloadLinkNotFound   = errors.New("download link not found")
+	ErrDownloadLinkExists     = errors.New("download link already exists")
+	ErrShareNotFound          = errors.New("share not found")
+	ErrShareExists            = errors.New("share already exists")
+	ErrShareExhausted         = errors.New("share has no downloads left")
+	ErrUploadRequestNotFound  = errors.New("upload request not found")
+	ErrUploadRequestExists    = errors.New("upload request already exists")
+	ErrUploadRequestExhausted = errors.New("upload request has no uploads left")
+	ErrSearchIndexNotFound    = errors.New("search index not found")
+	ErrJobNotFound            = errors.New("job not found")
+	ErrLockHeld               = errors.New("lock held by another holder")
+)
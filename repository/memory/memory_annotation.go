@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// copyAnnotation returns a copy that shares no memory with the stored annotation
+func copyAnnotation(annotation *model.Annotation) *model.Annotation {
+	annotationCopy := *annotation
+	if annotation.Anchor != nil {
+		anchor := *annotation.Anchor
+		if anchor.Region != nil {
+			region := *anchor.Region
+			anchor.Region = &region
+		}
+		annotationCopy.Anchor = &anchor
+	}
+	return &annotationCopy
+}
+
+// CreateAnnotation stores a new annotation
+func (r *MemoryRepository) CreateAnnotation(ctx context.Context, annotation *model.Annotation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if annotation.ID == uuid.Nil {
+		annotation.ID = r.ids.NewID()
+	}
+
+	now := r.clock.Now()
+	annotation.CreatedAt = now
+	annotation.UpdatedAt = now
+
+	r.annotations[annotation.ID] = copyAnnotation(annotation)
+	return nil
+}
+
+// GetAnnotation retrieves an annotation by its ID
+func (r *MemoryRepository) GetAnnotation(ctx context.Context, id uuid.UUID) (*model.Annotation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	annotation, exists := r.annotations[id]
+	if !exists {
+		return nil, repository.ErrAnnotationNotFound
+	}
+
+	return copyAnnotation(annotation), nil
+}
+
+// ListAnnotationsByContent retrieves a page of the annotations of a content item, oldest first
+func (r *MemoryRepository) ListAnnotationsByContent(ctx context.Context, contentID uuid.UUID, options repository.ListOptions) ([]*model.Annotation, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var annotations []*model.Annotation
+	for _, annotation := range r.annotations {
+		if annotation.ContentID == contentID {
+			annotations = append(annotations, copyAnnotation(annotation))
+		}
+	}
+
+	sort.Slice(annotations, func(i, j int) bool {
+		return annotations[i].CreatedAt.Before(annotations[j].CreatedAt)
+	})
+
+	return paginate(annotations, options), int64(len(annotations)), nil
+}
+
+// UpdateAnnotation updates an existing annotation
+func (r *MemoryRepository) UpdateAnnotation(ctx context.Context, annotation *model.Annotation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.annotations[annotation.ID]
+	if !exists {
+		return repository.ErrAnnotationNotFound
+	}
+
+	annotation.CreatedAt = existing.CreatedAt
+	annotation.UpdatedAt = r.clock.Now()
+
+	r.annotations[annotation.ID] = copyAnnotation(annotation)
+	return nil
+}
+
+// DeleteAnnotation removes an annotation
+func (r *MemoryRepository) DeleteAnnotation(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.annotations[id]; !exists {
+		return repository.ErrAnnotationNotFound
+	}
+
+	delete(r.annotations, id)
+	return nil
+}
@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+)
+
+// RecordAuditEvent stores an audit event
+func (r *MemoryRepository) RecordAuditEvent(ctx context.Context, event *model.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if event.ID == uuid.Nil {
+		event.ID = r.ids.NewID()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = r.clock.Now()
+	}
+	eventCopy := *event
+	r.auditEvents = append(r.auditEvents, &eventCopy)
+	return nil
+}
+
+// PruneAuditEvents removes the audit events that occurred before a cutoff
+func (r *MemoryRepository) PruneAuditEvents(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.auditEvents[:0]
+	for _, event := range r.auditEvents {
+		if !event.OccurredAt.Before(before) {
+			kept = append(kept, event)
+		}
+	}
+	pruned := int64(len(r.auditEvents) - len(kept))
+	clear(r.auditEvents[len(kept):])
+	r.auditEvents = kept
+	return pruned, nil
+}
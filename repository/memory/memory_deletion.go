@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+)
+
+// QueueDeletion stores a deletion to retry
+func (r *MemoryRepository) QueueDeletion(ctx context.Context, deletion *model.PendingDeletion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if deletion.ID == uuid.Nil {
+		deletion.ID = r.ids.NewID()
+	}
+	if deletion.QueuedAt.IsZero() {
+		deletion.QueuedAt = r.clock.Now()
+	}
+
+	deletionCopy := *deletion
+	r.deletions[deletion.ID] = &deletionCopy
+	return nil
+}
+
+// ListPendingDeletions returns up to limit queued deletions, oldest first
+func (r *MemoryRepository) ListPendingDeletions(ctx context.Context, limit int) ([]*model.PendingDeletion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deletions := make([]*model.PendingDeletion, 0, len(r.deletions))
+	for _, deletion := range r.deletions {
+		deletionCopy := *deletion
+		deletions = append(deletions, &deletionCopy)
+	}
+	sort.Slice(deletions, func(i, j int) bool {
+		if !deletions[i].QueuedAt.Equal(deletions[j].QueuedAt) {
+			return deletions[i].QueuedAt.Before(deletions[j].QueuedAt)
+		}
+		return deletions[i].ID.String() < deletions[j].ID.String()
+	})
+	if limit > 0 && len(deletions) > limit {
+		deletions = deletions[:limit]
+	}
+	return deletions, nil
+}
+
+// RemovePendingDeletion removes a deletion that has been carried out
+func (r *MemoryRepository) RemovePendingDeletion(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.deletions, id)
+	return nil
+}
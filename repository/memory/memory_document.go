@@ -0,0 +1,148 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// copyDocument returns a copy that shares no memory with the stored document
+func copyDocument(document *model.Document) *model.Document {
+	documentCopy := *document
+	documentCopy.Variants = append([]model.DocumentVariant{}, document.Variants...)
+	return &documentCopy
+}
+
+// CreateDocument stores a new document
+func (r *MemoryRepository) CreateDocument(ctx context.Context, document *model.Document) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if document.ID == uuid.Nil {
+		document.ID = r.ids.NewID()
+	}
+
+	now := r.clock.Now()
+	document.CreatedAt = now
+	document.UpdatedAt = now
+	if document.Variants == nil {
+		document.Variants = []model.DocumentVariant{}
+	}
+
+	r.documents[document.ID] = copyDocument(document)
+	return nil
+}
+
+// GetDocument retrieves a document with its variants
+func (r *MemoryRepository) GetDocument(ctx context.Context, id uuid.UUID) (*model.Document, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	document, exists := r.documents[id]
+	if !exists {
+		return nil, repository.ErrDocumentNotFound
+	}
+
+	return copyDocument(document), nil
+}
+
+// ListDocuments retrieves a page of the documents of a tenant ordered by name
+func (r *MemoryRepository) ListDocuments(ctx context.Context, tenantID string, options repository.ListOptions) ([]*model.Document, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var documents []*model.Document
+	for _, document := range r.documents {
+		if document.TenantID == tenantID {
+			documentCopy := *document
+			documentCopy.Variants = nil
+			documents = append(documents, &documentCopy)
+		}
+	}
+
+	sort.Slice(documents, func(i, j int) bool {
+		if documents[i].Name == documents[j].Name {
+			return documents[i].ID.String() < documents[j].ID.String()
+		}
+		return documents[i].Name < documents[j].Name
+	})
+
+	return paginate(documents, options), int64(len(documents)), nil
+}
+
+// UpdateDocument updates the name and default language of a document
+func (r *MemoryRepository) UpdateDocument(ctx context.Context, document *model.Document) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.documents[document.ID]
+	if !exists {
+		return repository.ErrDocumentNotFound
+	}
+
+	existing.Name = document.Name
+	existing.DefaultLanguage = document.DefaultLanguage
+	existing.UpdatedAt = r.clock.Now()
+	*document = *copyDocument(existing)
+	return nil
+}
+
+// DeleteDocument removes a document and its variants
+func (r *MemoryRepository) DeleteDocument(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.documents[id]; !exists {
+		return repository.ErrDocumentNotFound
+	}
+
+	delete(r.documents, id)
+	return nil
+}
+
+// SetDocumentVariant adds the variant of a language or replaces its content
+func (r *MemoryRepository) SetDocumentVariant(ctx context.Context, documentID uuid.UUID, variant model.DocumentVariant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	document, exists := r.documents[documentID]
+	if !exists {
+		return repository.ErrDocumentNotFound
+	}
+
+	document.UpdatedAt = r.clock.Now()
+	for i := range document.Variants {
+		if document.Variants[i].Language == variant.Language {
+			document.Variants[i] = variant
+			return nil
+		}
+	}
+	document.Variants = append(document.Variants, variant)
+	sort.Slice(document.Variants, func(i, j int) bool {
+		return document.Variants[i].Language < document.Variants[j].Language
+	})
+	return nil
+}
+
+// DeleteDocumentVariant removes the variant of a language
+func (r *MemoryRepository) DeleteDocumentVariant(ctx context.Context, documentID uuid.UUID, language string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	document, exists := r.documents[documentID]
+	if !exists {
+		return repository.ErrDocumentNotFound
+	}
+
+	for i := range document.Variants {
+		if document.Variants[i].Language == language {
+			document.Variants = append(document.Variants[:i], document.Variants[i+1:]...)
+			document.UpdatedAt = r.clock.Now()
+			return nil
+		}
+	}
+	return repository.ErrVariantNotFound
+}
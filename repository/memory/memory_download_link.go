@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// copyDownloadLink returns a copy that shares no state with the stored link
+func copyDownloadLink(link *model.DownloadLink) *model.DownloadLink {
+	linkCopy := *link
+	if link.LastClickedAt != nil {
+		clickedAt := *link.LastClickedAt
+		linkCopy.LastClickedAt = &clickedAt
+	}
+	return &linkCopy
+}
+
+// CreateDownloadLink stores a new download link
+func (r *MemoryRepository) CreateDownloadLink(ctx context.Context, link *model.DownloadLink) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.downloadLinks[link.Token]; exists {
+		return repository.ErrDownloadLinkExists
+	}
+	link.CreatedAt = r.clock.Now()
+
+	r.downloadLinks[link.Token] = copyDownloadLink(link)
+	return nil
+}
+
+// GetDownloadLink retrieves a download link by its token
+func (r *MemoryRepository) GetDownloadLink(ctx context.Context, token string) (*model.DownloadLink, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	link, exists := r.downloadLinks[token]
+	if !exists {
+		return nil, repository.ErrDownloadLinkNotFound
+	}
+
+	return copyDownloadLink(link), nil
+}
+
+// ListDownloadLinksByContent retrieves a page of the links of a content item, newest first
+func (r *MemoryRepository) ListDownloadLinksByContent(ctx context.Context, contentID uuid.UUID, options repository.ListOptions) ([]*model.DownloadLink, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var links []*model.DownloadLink
+	for _, link := range r.downloadLinks {
+		if link.ContentID == contentID {
+			links = append(links, copyDownloadLink(link))
+		}
+	}
+
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].CreatedAt.After(links[j].CreatedAt)
+	})
+
+	return paginate(links, options), int64(len(links)), nil
+}
+
+// RecordDownloadLinkClick counts a redemption of a download link
+func (r *MemoryRepository) RecordDownloadLinkClick(ctx context.Context, token string, clickedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	link, exists := r.downloadLinks[token]
+	if !exists {
+		return repository.ErrDownloadLinkNotFound
+	}
+
+	link.Clicks++
+	link.LastClickedAt = &clickedAt
+	return nil
+}
+
+// DeleteDownloadLink removes a download link
+func (r *MemoryRepository) DeleteDownloadLink(ctx context.Context, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.downloadLinks[token]; !exists {
+		return repository.ErrDownloadLinkNotFound
+	}
+
+	delete(r.downloadLinks, token)
+	return nil
+}
@@ -0,0 +1,39 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/livefire2015/simple-contents/model"
+)
+
+// externalIDKey identifies content by the key a source system gave it
+type externalIDKey struct {
+	tenantID   string
+	source     string
+	externalID string
+}
+
+// externalIDKeyOf returns the external ID key of content, if it has one
+func externalIDKeyOf(content *model.Content) (externalIDKey, bool) {
+	if content.ExternalID == "" {
+		return externalIDKey{}, false
+	}
+	return externalIDKey{tenantID: content.TenantID, source: content.Source, externalID: content.ExternalID}, true
+}
+
+// GetContentByExternalID retrieves the content a tenant created from a
+// source under an external ID
+func (r *MemoryRepository) GetContentByExternalID(ctx context.Context, tenantID, source, externalID string) (*model.Content, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, exists := r.contentsByExternalID[externalIDKey{tenantID: tenantID, source: source, externalID: externalID}]
+	if !exists {
+		return nil, ErrContentNotFound
+	}
+	content := r.contents[id]
+	if content.DeletedAt != nil {
+		return nil, ErrContentNotFound
+	}
+	return copyContent(content), nil
+}
@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/livefire2015/simple-contents/model"
+)
+
+// fuzzyThreshold is the share of the trigrams of a fuzzy search a file name
+// must contain, like the word similarity threshold of pg_trgm
+const fuzzyThreshold = 0.6
+
+// trigrams returns the trigrams of the words of s the way pg_trgm extracts
+// them: lowercased, with each alphanumeric word padded by two spaces in
+// front and one behind
+func trigrams(s string) map[string]struct{} {
+	set := make(map[string]struct{})
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = struct{}{}
+		}
+	}
+	return set
+}
+
+// fileNameScore is the share of the trigrams of search found in a file name
+func fileNameScore(fileName, search string) float64 {
+	wanted := trigrams(search)
+	if len(wanted) == 0 {
+		return 0
+	}
+	found := trigrams(fileName)
+	matched := 0
+	for trigram := range wanted {
+		if _, ok := found[trigram]; ok {
+			matched++
+		}
+	}
+	return float64(matched) / float64(len(wanted))
+}
+
+// matchesFileName reports whether a file name is selected by the file name of a filter
+func matchesFileName(fileName string, filter model.ContentFilter) bool {
+	if filter.FileName == "" {
+		return true
+	}
+	switch filter.FileNameMatch {
+	case model.MatchPrefix:
+		return strings.HasPrefix(strings.ToLower(fileName), strings.ToLower(filter.FileName))
+	case model.MatchFuzzy:
+		return fileNameScore(fileName, filter.FileName) >= fuzzyThreshold
+	default:
+		return containsFold(fileName, filter.FileName)
+	}
+}
+
+// containsFold reports whether substr is within s, ignoring case
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
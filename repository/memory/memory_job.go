@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// copyJob returns a copy that shares no memory with the stored job
+func copyJob(job *model.Job) *model.Job {
+	jobCopy := *job
+	if job.ContentID != nil {
+		contentID := *job.ContentID
+		jobCopy.ContentID = &contentID
+	}
+	if job.FinishedAt != nil {
+		finishedAt := *job.FinishedAt
+		jobCopy.FinishedAt = &finishedAt
+	}
+	jobCopy.Input = bytes.Clone(job.Input)
+	jobCopy.Result = bytes.Clone(job.Result)
+	return &jobCopy
+}
+
+// CreateJob stores a new job
+func (r *MemoryRepository) CreateJob(ctx context.Context, job *model.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if job.ID == uuid.Nil {
+		job.ID = r.ids.NewID()
+	}
+	now := r.clock.Now()
+	job.CreatedAt = now
+	job.UpdatedAt = now
+
+	r.jobs[job.ID] = copyJob(job)
+	return nil
+}
+
+// GetJob retrieves a job by its ID
+func (r *MemoryRepository) GetJob(ctx context.Context, id uuid.UUID) (*model.Job, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	job, exists := r.jobs[id]
+	if !exists {
+		return nil, repository.ErrJobNotFound
+	}
+	return copyJob(job), nil
+}
+
+// UpdateJob records the state of a job, leaving a cancellation request in place
+func (r *MemoryRepository) UpdateJob(ctx context.Context, job *model.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, exists := r.jobs[job.ID]
+	if !exists {
+		return repository.ErrJobNotFound
+	}
+	job.UpdatedAt = r.clock.Now()
+	job.CancelRequested = job.CancelRequested || stored.CancelRequested
+	r.jobs[job.ID] = copyJob(job)
+	return nil
+}
+
+// RequestJobCancel flags a job to be cancelled by the instance running it
+func (r *MemoryRepository) RequestJobCancel(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, exists := r.jobs[id]
+	if !exists {
+		return repository.ErrJobNotFound
+	}
+	job.CancelRequested = true
+	job.UpdatedAt = r.clock.Now()
+	return nil
+}
+
+// FailStaleJobs fails the unfinished jobs last updated before a cutoff
+func (r *MemoryRepository) FailStaleJobs(ctx context.Context, updatedBefore time.Time, reason string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var failed int64
+	now := r.clock.Now()
+	for _, job := range r.jobs {
+		if job.FinishedAt == nil && job.UpdatedAt.Before(updatedBefore) {
+			job.Status = model.JobFailed
+			job.Error = reason
+			job.UpdatedAt = now
+			finishedAt := now.UTC()
+			job.FinishedAt = &finishedAt
+			failed++
+		}
+	}
+	return failed, nil
+}
+
+// PruneJobs removes the jobs that finished before a cutoff
+func (r *MemoryRepository) PruneJobs(ctx context.Context, finishedBefore time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var pruned int64
+	for id, job := range r.jobs {
+		if job.FinishedAt != nil && job.FinishedAt.Before(finishedBefore) {
+			delete(r.jobs, id)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
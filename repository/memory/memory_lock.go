@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// memoryLock is a lock of a memory repository, only shared by the services
+// of one process
+type memoryLock struct {
+	repo *MemoryRepository
+	name string
+}
+
+// TryLock takes a lock, or returns ErrLockHeld if another holder has it
+func (r *MemoryRepository) TryLock(ctx context.Context, name string) (repository.Lock, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.locks[name] {
+		return nil, repository.ErrLockHeld
+	}
+	r.locks[name] = true
+	return &memoryLock{repo: r, name: name}, nil
+}
+
+// Held reports whether the lock is still held
+func (l *memoryLock) Held(ctx context.Context) bool {
+	l.repo.mu.RLock()
+	defer l.repo.mu.RUnlock()
+	return l.repo.locks[l.name]
+}
+
+// Release gives the lock up
+func (l *memoryLock) Release(ctx context.Context) error {
+	l.repo.mu.Lock()
+	defer l.repo.mu.Unlock()
+	delete(l.repo.locks, l.name)
+	return nil
+}
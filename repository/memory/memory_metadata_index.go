@@ -0,0 +1,47 @@
+package memory
+
+import "github.com/livefire2015/simple-contents/model"
+
+// metadataValue is a string value of an indexed metadata key
+type metadataValue struct {
+	key   string
+	value string
+}
+
+// ConfigureMetadataIndexes maintains lookup maps of the content with each
+// string value of the given metadata keys, so filters on them don't scan
+// every content item
+func (r *MemoryRepository) ConfigureMetadataIndexes(keys ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.indexedMetadata = make(map[string]bool, len(keys))
+	for _, key := range keys {
+		r.indexedMetadata[key] = true
+	}
+	r.contentsByMetadata = make(map[metadataValue]idSet)
+	for _, content := range r.contents {
+		r.indexMetadata(content)
+	}
+	return nil
+}
+
+// indexMetadata adds content to the index of each of its indexed metadata
+// values. The caller must hold the lock.
+func (r *MemoryRepository) indexMetadata(content *model.Content) {
+	for key := range r.indexedMetadata {
+		if value, ok := content.Metadata[key].(string); ok {
+			addToIndex(r.contentsByMetadata, metadataValue{key, value}, content.ID)
+		}
+	}
+}
+
+// unindexMetadata removes content from the index of each of its indexed
+// metadata values. The caller must hold the lock.
+func (r *MemoryRepository) unindexMetadata(content *model.Content) {
+	for key := range r.indexedMetadata {
+		if value, ok := content.Metadata[key].(string); ok {
+			removeFromIndex(r.contentsByMetadata, metadataValue{key, value}, content.ID)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// pinKey identifies the pin of a content item by a principal
+type pinKey struct {
+	principal string
+	contentID uuid.UUID
+}
+
+// PinContent pins a content item for a principal
+func (r *MemoryRepository) PinContent(ctx context.Context, pin *model.Pin) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := pinKey{principal: pin.Principal, contentID: pin.ContentID}
+	if existing, exists := r.pins[key]; exists {
+		*pin = *existing
+		return nil
+	}
+
+	if pin.CreatedAt.IsZero() {
+		pin.CreatedAt = r.clock.Now()
+	}
+	pinCopy := *pin
+	r.pins[key] = &pinCopy
+	return nil
+}
+
+// UnpinContent removes the pin of a content item by a principal
+func (r *MemoryRepository) UnpinContent(ctx context.Context, principal string, contentID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := pinKey{principal: principal, contentID: contentID}
+	if _, exists := r.pins[key]; !exists {
+		return repository.ErrPinNotFound
+	}
+
+	delete(r.pins, key)
+	return nil
+}
+
+// isPinned reports whether principal pinned a content item; the caller holds the lock
+func (r *MemoryRepository) isPinned(principal string, contentID uuid.UUID) bool {
+	_, exists := r.pins[pinKey{principal: principal, contentID: contentID}]
+	return exists
+}
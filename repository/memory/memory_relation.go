@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// CreateRelation stores a new relation between two content items
+func (r *MemoryRepository) CreateRelation(ctx context.Context, relation *model.ContentRelation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.relations {
+		if existing.SourceID == relation.SourceID && existing.TargetID == relation.TargetID && existing.Type == relation.Type {
+			return repository.ErrRelationExists
+		}
+	}
+
+	if relation.ID == uuid.Nil {
+		relation.ID = r.ids.NewID()
+	}
+	if relation.CreatedAt.IsZero() {
+		relation.CreatedAt = r.clock.Now()
+	}
+
+	relationCopy := *relation
+	r.relations[relation.ID] = &relationCopy
+	return nil
+}
+
+// GetRelation retrieves a relation by its ID
+func (r *MemoryRepository) GetRelation(ctx context.Context, id uuid.UUID) (*model.ContentRelation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	relation, exists := r.relations[id]
+	if !exists {
+		return nil, repository.ErrRelationNotFound
+	}
+
+	relationCopy := *relation
+	return &relationCopy, nil
+}
+
+// ListRelations retrieves the relations of a content item, oldest first
+func (r *MemoryRepository) ListRelations(ctx context.Context, contentID uuid.UUID, direction model.RelationDirection, relationType model.RelationType) ([]*model.ContentRelation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	relations := []*model.ContentRelation{}
+	for _, relation := range r.relations {
+		if relationType != "" && relation.Type != relationType {
+			continue
+		}
+		outgoing := relation.SourceID == contentID && direction != model.RelationsIncoming
+		incoming := relation.TargetID == contentID && direction != model.RelationsOutgoing
+		if outgoing || incoming {
+			relationCopy := *relation
+			relations = append(relations, &relationCopy)
+		}
+	}
+
+	sort.Slice(relations, func(i, j int) bool {
+		if relations[i].CreatedAt.Equal(relations[j].CreatedAt) {
+			return relations[i].ID.String() < relations[j].ID.String()
+		}
+		return relations[i].CreatedAt.Before(relations[j].CreatedAt)
+	})
+	return relations, nil
+}
+
+// DeleteRelation removes a relation
+func (r *MemoryRepository) DeleteRelation(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.relations[id]; !exists {
+		return repository.ErrRelationNotFound
+	}
+
+	delete(r.relations, id)
+	return nil
+}
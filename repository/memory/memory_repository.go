@@ -1,49 +1,263 @@
 package memory
 
 import (
+	"cmp"
 	"context"
-	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/clock"
 	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
 )
 
 var (
-	ErrContentNotFound = errors.New("content not found")
+	ErrContentNotFound = repository.ErrContentNotFound
 )
 
-// MemoryRepository implements ContentRepository using in-memory storage
+// MemoryRepository implements ContentRepository, TenantRepository,
+// AnnotationRepository, SignatureRepository, TemplateRepository,
+// SavedSearchRepository, DownloadLinkRepository, ShareRepository,
+// UploadRequestRepository and AuditRepository using in-memory storage
 type MemoryRepository struct {
-	mu       sync.RWMutex
-	contents map[uuid.UUID]*model.Content
+	mu           sync.RWMutex
+	contents     map[uuid.UUID]*model.Content
+	associations map[uuid.UUID]*model.ContentEntityAssociation
+	tenants      map[string]*model.Tenant
+	annotations  map[uuid.UUID]*model.Annotation
+	signatures   map[uuid.UUID]*model.SignatureRequest
+	templates    map[string]*model.Template
+	pins         map[pinKey]*model.Pin
+	relations    map[uuid.UUID]*model.ContentRelation
+
+	savedSearches  map[uuid.UUID]*model.SavedSearch
+	downloadLinks  map[string]*model.DownloadLink
+	shares         map[string]*model.Share
+	uploadRequests map[string]*model.UploadRequest
+	documents      map[uuid.UUID]*model.Document
+	scanResults    map[scanResultKey]*model.ScanResult
+	jobs           map[uuid.UUID]*model.Job
+	deletions      map[uuid.UUID]*model.PendingDeletion
+	locks          map[string]bool
+	auditEvents    []*model.AuditEvent
+
+	// Indexes of the content IDs in each status and the association IDs of each entity
+	contentsByStatus     map[model.ContentStatus]idSet
+	associationsByEntity map[entityKey]idSet
+	contentsByExternalID map[externalIDKey]uuid.UUID
+
+	// Index of the content IDs by the string values of the indexed metadata keys
+	indexedMetadata    map[string]bool
+	contentsByMetadata map[metadataValue]idSet
+
+	clock clock.Clock
+	ids   clock.IDGenerator
+}
+
+// idSet is a set of IDs
+type idSet map[uuid.UUID]struct{}
+
+// entityKey identifies an entity content is associated with
+type entityKey struct {
+	entityType string
+	entityID   string
+}
+
+// addToIndex adds an ID to the set of key, creating the set if needed
+func addToIndex[K comparable](index map[K]idSet, key K, id uuid.UUID) {
+	set, ok := index[key]
+	if !ok {
+		set = make(idSet)
+		index[key] = set
+	}
+	set[id] = struct{}{}
+}
+
+// removeFromIndex removes an ID from the set of key, dropping the set once empty
+func removeFromIndex[K comparable](index map[K]idSet, key K, id uuid.UUID) {
+	set := index[key]
+	delete(set, id)
+	if len(set) == 0 {
+		delete(index, key)
+	}
 }
 
 // NewMemoryRepository creates a new in-memory repository
 func NewMemoryRepository() *MemoryRepository {
 	return &MemoryRepository{
-		contents: make(map[uuid.UUID]*model.Content),
+		contents:     make(map[uuid.UUID]*model.Content),
+		associations: make(map[uuid.UUID]*model.ContentEntityAssociation),
+		tenants:      make(map[string]*model.Tenant),
+		annotations:  make(map[uuid.UUID]*model.Annotation),
+		signatures:   make(map[uuid.UUID]*model.SignatureRequest),
+		templates:    make(map[string]*model.Template),
+		pins:         make(map[pinKey]*model.Pin),
+		relations:    make(map[uuid.UUID]*model.ContentRelation),
+
+		savedSearches:  make(map[uuid.UUID]*model.SavedSearch),
+		downloadLinks:  make(map[string]*model.DownloadLink),
+		shares:         make(map[string]*model.Share),
+		uploadRequests: make(map[string]*model.UploadRequest),
+		documents:      make(map[uuid.UUID]*model.Document),
+		scanResults:    make(map[scanResultKey]*model.ScanResult),
+		jobs:           make(map[uuid.UUID]*model.Job),
+		deletions:      make(map[uuid.UUID]*model.PendingDeletion),
+		locks:          make(map[string]bool),
+
+		contentsByStatus:     make(map[model.ContentStatus]idSet),
+		associationsByEntity: make(map[entityKey]idSet),
+		contentsByExternalID: make(map[externalIDKey]uuid.UUID),
+
+		clock: clock.System,
+		ids:   clock.TimeOrderedIDs,
 	}
 }
 
+// ConfigureClock sets the clock the timestamps of records are taken from
+func (r *MemoryRepository) ConfigureClock(c clock.Clock) {
+	r.clock = c
+}
+
+// ConfigureIDs sets the generator of the IDs of new records, time-ordered
+// UUIDv7 by default
+func (r *MemoryRepository) ConfigureIDs(ids clock.IDGenerator) {
+	r.ids = ids
+}
+
+// WithTx calls fn with the repository itself; writes made before fn fails
+// are not rolled back
+func (r *MemoryRepository) WithTx(ctx context.Context, fn func(tx repository.ContentRepository) error) error {
+	return fn(r)
+}
+
+// copyContent returns a copy that shares no memory with the stored content,
+// including its metadata
+func copyContent(content *model.Content) *model.Content {
+	contentCopy := *content
+	if content.DerivedFromID != nil {
+		derivedFromID := *content.DerivedFromID
+		contentCopy.DerivedFromID = &derivedFromID
+	}
+	if content.DeletedAt != nil {
+		deletedAt := *content.DeletedAt
+		contentCopy.DeletedAt = &deletedAt
+	}
+	if content.CallbackSentAt != nil {
+		callbackSentAt := *content.CallbackSentAt
+		contentCopy.CallbackSentAt = &callbackSentAt
+	}
+	if content.ReferenceCheckedAt != nil {
+		referenceCheckedAt := *content.ReferenceCheckedAt
+		contentCopy.ReferenceCheckedAt = &referenceCheckedAt
+	}
+	if content.DraftExpiresAt != nil {
+		draftExpiresAt := *content.DraftExpiresAt
+		contentCopy.DraftExpiresAt = &draftExpiresAt
+	}
+	if content.Metadata != nil {
+		contentCopy.Metadata = copyMap(content.Metadata)
+	}
+	return &contentCopy
+}
+
+// copyMap deep copies a JSON-like map, as a round trip through a JSONB column would
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	mapCopy := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		mapCopy[k] = copyValue(v)
+	}
+	return mapCopy
+}
+
+// copyValue deep copies the maps and slices of a JSON-like value
+func copyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return copyMap(v)
+	case model.Metadata:
+		return model.Metadata(copyMap(v))
+	case []interface{}:
+		sliceCopy := make([]interface{}, len(v))
+		for i, item := range v {
+			sliceCopy[i] = copyValue(item)
+		}
+		return sliceCopy
+	case []string:
+		return append([]string(nil), v...)
+	}
+	return v
+}
+
 // Create stores a new content item
 func (r *MemoryRepository) CreateContent(ctx context.Context, content *model.Content) error {
+	return r.CreateContentBatch(ctx, []*model.Content{content})
+}
+
+// CreateContentBatch stores several new content items, none of them if an
+// ID or external ID is taken
+func (r *MemoryRepository) CreateContentBatch(ctx context.Context, contents []*model.Content) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if content.ID == uuid.Nil {
-		content.ID = uuid.New()
+	// Imported rows keep their original timestamps
+	now := r.clock.Now()
+	ids := make(map[uuid.UUID]bool, len(contents))
+	externalIDs := make(map[externalIDKey]bool)
+	for _, content := range contents {
+		if content.ID == uuid.Nil {
+			content.ID = r.ids.NewID()
+		}
+		if _, exists := r.contents[content.ID]; exists || ids[content.ID] {
+			return fmt.Errorf("%w: %s", repository.ErrContentExists, content.ID)
+		}
+		ids[content.ID] = true
+		if key, ok := externalIDKeyOf(content); ok {
+			if _, exists := r.contentsByExternalID[key]; exists || externalIDs[key] {
+				return fmt.Errorf("%w: %s", repository.ErrExternalIDExists, content.ExternalID)
+			}
+			externalIDs[key] = true
+		}
+		if content.CreatedAt.IsZero() {
+			content.CreatedAt = now
+		}
+		if content.UpdatedAt.IsZero() {
+			content.UpdatedAt = now
+		}
 	}
 
-	now := time.Now()
-	content.CreatedAt = now
-	content.UpdatedAt = now
-
-	r.contents[content.ID] = content
+	for _, content := range contents {
+		r.contents[content.ID] = copyContent(content)
+		addToIndex(r.contentsByStatus, content.Status, content.ID)
+		r.indexMetadata(content)
+		if key, ok := externalIDKeyOf(content); ok {
+			r.contentsByExternalID[key] = content.ID
+		}
+	}
 	return nil
 }
 
+// GetContentsByIDs retrieves the content items with the given IDs
+func (r *MemoryRepository) GetContentsByIDs(ctx context.Context, ids []uuid.UUID) ([]*model.Content, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	contents := make([]*model.Content, 0, len(ids))
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		content, exists := r.contents[id]
+		if !exists || content.DeletedAt != nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		contents = append(contents, copyContent(content))
+	}
+	return contents, nil
+}
+
 // GetByID retrieves a content item by its ID
 func (r *MemoryRepository) GetContentByID(ctx context.Context, id uuid.UUID) (*model.Content, error) {
 	r.mu.RLock()
@@ -55,8 +269,7 @@ func (r *MemoryRepository) GetContentByID(ctx context.Context, id uuid.UUID) (*m
 	}
 
 	// Return a copy to prevent modification of the stored data
-	contentCopy := *content
-	return &contentCopy, nil
+	return copyContent(content), nil
 }
 
 // Update updates an existing content item
@@ -70,9 +283,43 @@ func (r *MemoryRepository) UpdateContent(ctx context.Context, content *model.Con
 	}
 
 	content.CreatedAt = existing.CreatedAt
-	content.UpdatedAt = time.Now()
+	content.UpdatedAt = r.clock.Now()
+	content.CallbackSentAt = existing.CallbackSentAt
+
+	removeFromIndex(r.contentsByStatus, existing.Status, existing.ID)
+	r.unindexMetadata(existing)
+	r.contents[content.ID] = copyContent(content)
+	addToIndex(r.contentsByStatus, content.Status, content.ID)
+	r.indexMetadata(content)
+	return nil
+}
+
+// MarkCallbackSent records the callback of a content item unless one already is
+func (r *MemoryRepository) MarkCallbackSent(ctx context.Context, id uuid.UUID, sentAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	content, exists := r.contents[id]
+	if !exists || content.DeletedAt != nil {
+		return false, ErrContentNotFound
+	}
+	if content.CallbackSentAt != nil {
+		return false, nil
+	}
+	content.CallbackSentAt = &sentAt
+	return true, nil
+}
+
+// ClearCallbackSent forgets the recorded callback of a content item
+func (r *MemoryRepository) ClearCallbackSent(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	r.contents[content.ID] = content
+	content, exists := r.contents[id]
+	if !exists || content.DeletedAt != nil {
+		return ErrContentNotFound
+	}
+	content.CallbackSentAt = nil
 	return nil
 }
 
@@ -86,62 +333,114 @@ func (r *MemoryRepository) DeleteContent(ctx context.Context, id uuid.UUID) erro
 		return ErrContentNotFound
 	}
 
-	now := time.Now()
+	now := r.clock.Now()
 	content.DeletedAt = &now
 	return nil
 }
 
-// List retrieves content items based on filter criteria
-func (r *MemoryRepository) ListContent(ctx context.Context, filter model.ContentFilter, offset, limit int) ([]*model.Content, int, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	var filteredContents []*model.Content
+// DeleteContentIfUnreferenced marks a content item as deleted if no association links it
+func (r *MemoryRepository) DeleteContentIfUnreferenced(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	// Apply filters
-	for _, content := range r.contents {
-		if content.DeletedAt != nil {
-			continue
+	content, exists := r.contents[id]
+	if !exists || content.DeletedAt != nil {
+		return ErrContentNotFound
+	}
+	for _, association := range r.associations {
+		if association.ContentID == id {
+			return repository.ErrContentReferenced
 		}
+	}
 
-		if filter.MIMEType != "" && content.MIMEType != filter.MIMEType {
-			continue
-		}
+	now := r.clock.Now()
+	content.DeletedAt = &now
+	return nil
+}
 
-		if filter.MinSize != nil && content.FileSize < *filter.MinSize {
-			continue
-		}
+// DeleteContents marks several content items as deleted
+func (r *MemoryRepository) DeleteContents(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-		if filter.MaxSize != nil && content.FileSize > *filter.MaxSize {
+	now := r.clock.Now()
+	deleted := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		content, exists := r.contents[id]
+		if !exists || content.DeletedAt != nil {
 			continue
 		}
+		content.DeletedAt = &now
+		deleted = append(deleted, id)
+	}
+	return deleted, nil
+}
 
-		if filter.CreatedFrom != nil && content.CreatedAt.Before(*filter.CreatedFrom) {
-			continue
-		}
+// TransferOwnership hands the content of a tenant over from one creator to another
+func (r *MemoryRepository) TransferOwnership(ctx context.Context, tenantID, from, to string, ids []uuid.UUID) ([]uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-		if filter.CreatedTo != nil && content.CreatedAt.After(*filter.CreatedTo) {
+	candidates := ids
+	if len(candidates) == 0 {
+		for id := range r.contents {
+			candidates = append(candidates, id)
+		}
+	}
+	now := r.clock.Now()
+	transferred := []uuid.UUID{}
+	for _, id := range candidates {
+		content, exists := r.contents[id]
+		if !exists || content.DeletedAt != nil || content.TenantID != tenantID || content.CreatedBy != from {
 			continue
 		}
+		content.CreatedBy = to
+		content.UpdatedAt = now
+		transferred = append(transferred, id)
+	}
+	return transferred, nil
+}
 
-		// Check metadata filters if any
-		if len(filter.Metadata) > 0 {
-			match := true
-			for k, v := range filter.Metadata {
-				if contentValue, exists := content.Metadata[k]; !exists || contentValue != v {
-					match = false
-					break
-				}
-			}
-			if !match {
-				continue
+// PruneDeletedContent permanently removes the content deleted before a cutoff
+func (r *MemoryRepository) PruneDeletedContent(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var pruned int64
+	for id, content := range r.contents {
+		if content.DeletedAt != nil && content.DeletedAt.Before(deletedBefore) {
+			removeFromIndex(r.contentsByStatus, content.Status, id)
+			r.unindexMetadata(content)
+			if key, ok := externalIDKeyOf(content); ok {
+				delete(r.contentsByExternalID, key)
 			}
+			delete(r.contents, id)
+			pruned++
 		}
+	}
+	return pruned, nil
+}
 
-		// Create a copy to prevent modification of the stored data
-		contentCopy := *content
-		filteredContents = append(filteredContents, &contentCopy)
+// compareContents orders two content items by a sort field
+func compareContents(a, b *model.Content, field model.ContentSortField) int {
+	switch field {
+	case model.SortByUpdatedAt:
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	case model.SortByFileName:
+		return strings.Compare(a.FileName, b.FileName)
+	case model.SortByFileSize:
+		return cmp.Compare(a.FileSize, b.FileSize)
+	default:
+		return a.CreatedAt.Compare(b.CreatedAt)
 	}
+}
+
+// List retrieves content items based on filter criteria
+func (r *MemoryRepository) ListContent(ctx context.Context, filter model.ContentFilter, offset, limit int) ([]*model.Content, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	filteredContents := r.filterContents(filter)
 
 	// Calculate total count
 	totalCount := len(filteredContents)
@@ -156,5 +455,578 @@ func (r *MemoryRepository) ListContent(ctx context.Context, filter model.Content
 		end = len(filteredContents)
 	}
 
-	return filteredContents[offset:end], totalCount, nil
+	// Copy only the page to prevent modification of the stored data
+	page := make([]*model.Content, 0, end-offset)
+	for _, content := range filteredContents[offset:end] {
+		page = append(page, copyContent(content))
+	}
+	return page, totalCount, nil
+}
+
+// ListContentPage retrieves a page of content items. Counting is free in
+// memory, so estimated totals are exact.
+func (r *MemoryRepository) ListContentPage(ctx context.Context, filter model.ContentFilter, options repository.ListOptions) (*repository.ContentPage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	filteredContents := r.filterContents(filter)
+	items := paginate(filteredContents, options)
+	page := &repository.ContentPage{
+		Items:   make([]*model.Content, len(items)),
+		Total:   -1,
+		HasMore: options.PageSize > 0 && options.Offset()+len(items) < len(filteredContents),
+	}
+	for i, content := range items {
+		page.Items[i] = copyContent(content)
+	}
+	if options.ReturnTotal {
+		page.Total = int64(len(filteredContents))
+	}
+	return page, nil
+}
+
+// ListContentByMetadataKey lists content in byte order of a string metadata value
+func (r *MemoryRepository) ListContentByMetadataKey(ctx context.Context, filter model.ContentFilter, key, prefix, from string, limit int) ([]*model.Content, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var contents []*model.Content
+	r.eachCandidate(filter, func(content *model.Content) {
+		value, ok := content.Metadata[key].(string)
+		if ok && strings.HasPrefix(value, prefix) && value >= from && r.matchesFilter(content, filter) {
+			contents = append(contents, content)
+		}
+	})
+	sort.Slice(contents, func(i, j int) bool {
+		a, b := contents[i].Metadata[key].(string), contents[j].Metadata[key].(string)
+		if a != b {
+			return a < b
+		}
+		return contents[i].ID.String() < contents[j].ID.String()
+	})
+
+	contents = contents[:min(limit, len(contents))]
+	for i, content := range contents {
+		contents[i] = copyContent(content)
+	}
+	return contents, nil
+}
+
+// ListContentStream calls fn for every content item matching a filter. The
+// items are copied up front so fn runs without the lock held.
+func (r *MemoryRepository) ListContentStream(ctx context.Context, filter model.ContentFilter, fn func(*model.Content) error) error {
+	r.mu.RLock()
+	contents := r.filterContents(filter)
+	for i, content := range contents {
+		contents[i] = copyContent(content)
+	}
+	r.mu.RUnlock()
+
+	for _, content := range contents {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filterContents returns the stored content matching a filter in listing
+// order. The caller must hold the lock.
+func (r *MemoryRepository) filterContents(filter model.ContentFilter) []*model.Content {
+	var filteredContents []*model.Content
+	r.eachCandidate(filter, func(content *model.Content) {
+		if r.matchesFilter(content, filter) {
+			filteredContents = append(filteredContents, content)
+		}
+	})
+
+	// Newest first by default, matching the Postgres repository, with ties
+	// broken by ID so pages are stable. Fuzzy file name searches put the
+	// closest names first instead.
+	var scores map[*model.Content]float64
+	if filter.FileName != "" && filter.FileNameMatch == model.MatchFuzzy && filter.SortBy == "" {
+		scores = make(map[*model.Content]float64, len(filteredContents))
+		for _, content := range filteredContents {
+			scores[content] = fileNameScore(content.FileName, filter.FileName)
+		}
+	}
+	sort.Slice(filteredContents, func(i, j int) bool {
+		a, b := filteredContents[i], filteredContents[j]
+		if scores != nil && scores[a] != scores[b] {
+			return scores[a] > scores[b]
+		}
+		if c := compareContents(a, b, filter.SortBy); c != 0 {
+			return (c < 0) == filter.SortAscending
+		}
+		return a.ID.String() < b.ID.String()
+	})
+	return filteredContents
+}
+
+// eachCandidate calls fn with the stored content that may match a filter,
+// narrowed down by the smallest of the indexes the filter can use: the
+// status index and those of indexed metadata keys. The caller must hold the
+// lock.
+func (r *MemoryRepository) eachCandidate(filter model.ContentFilter, fn func(*model.Content)) {
+	var candidates idSet
+	narrowed := false
+	if filter.Status != "" {
+		candidates, narrowed = r.contentsByStatus[filter.Status], true
+	}
+	for key, value := range filter.Metadata {
+		s, ok := value.(string)
+		if !ok || !r.indexedMetadata[key] {
+			continue
+		}
+		if set := r.contentsByMetadata[metadataValue{key, s}]; !narrowed || len(set) < len(candidates) {
+			candidates, narrowed = set, true
+		}
+	}
+
+	if !narrowed {
+		for _, content := range r.contents {
+			fn(content)
+		}
+		return
+	}
+	for id := range candidates {
+		fn(r.contents[id])
+	}
+}
+
+// ContentStats counts the content matching a filter and sums its size per group
+func (r *MemoryRepository) ContentStats(ctx context.Context, filter model.ContentFilter, groupBy model.ContentGroupBy) ([]*model.ContentStatsBucket, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	buckets := make(map[string]*model.ContentStatsBucket)
+	var err error
+	r.eachCandidate(filter, func(content *model.Content) {
+		if err != nil || !r.matchesFilter(content, filter) {
+			return
+		}
+
+		var key string
+		switch groupBy {
+		case model.GroupByMIMEType:
+			key = content.MIMEType
+		case model.GroupBySource:
+			key = content.Source
+		case model.GroupByStatus:
+			key = string(content.Status)
+		case model.GroupByDay:
+			key = content.CreatedAt.UTC().Format(time.DateOnly)
+		default:
+			err = fmt.Errorf("unknown grouping %q", groupBy)
+			return
+		}
+
+		bucket, ok := buckets[key]
+		if !ok {
+			bucket = &model.ContentStatsBucket{Key: key}
+			buckets[key] = bucket
+		}
+		bucket.Count++
+		bucket.TotalBytes += content.FileSize
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]*model.ContentStatsBucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		stats = append(stats, bucket)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Key < stats[j].Key
+	})
+	return stats, nil
+}
+
+// matchesFilter reports whether a stored content item is selected by filter.
+// The caller must hold the lock.
+func (r *MemoryRepository) matchesFilter(content *model.Content, filter model.ContentFilter) bool {
+	if content.DeletedAt != nil {
+		return false
+	}
+
+	if filter.TenantID != "" && content.TenantID != filter.TenantID {
+		return false
+	}
+
+	if filter.Status != "" && content.Status != filter.Status {
+		return false
+	}
+
+	if filter.DerivedFromID != nil && (content.DerivedFromID == nil || *content.DerivedFromID != *filter.DerivedFromID) {
+		return false
+	}
+
+	if filter.PinnedBy != "" && !r.isPinned(filter.PinnedBy, content.ID) {
+		return false
+	}
+
+	if filter.CreatedBy != "" && content.CreatedBy != filter.CreatedBy {
+		return false
+	}
+
+	if filter.Kind != "" && content.IsReference() != (filter.Kind == model.KindReference) {
+		return false
+	}
+
+	if filter.DraftsExpired != nil && (!content.IsDraft() || content.DraftExpiresAt.After(*filter.DraftsExpired)) {
+		return false
+	}
+
+	if !matchesFileName(content.FileName, filter) {
+		return false
+	}
+
+	if filter.Text != "" && !containsFold(content.FileName, filter.Text) && !containsFold(content.Description, filter.Text) {
+		return false
+	}
+
+	if filter.MIMEType != "" && content.MIMEType != filter.MIMEType {
+		return false
+	}
+
+	if filter.MinSize != nil && content.FileSize < *filter.MinSize {
+		return false
+	}
+
+	if filter.MaxSize != nil && content.FileSize > *filter.MaxSize {
+		return false
+	}
+
+	if filter.CreatedFrom != nil && content.CreatedAt.Before(*filter.CreatedFrom) {
+		return false
+	}
+
+	if filter.CreatedTo != nil && content.CreatedAt.After(*filter.CreatedTo) {
+		return false
+	}
+
+	if filter.UpdatedFrom != nil && content.UpdatedAt.Before(*filter.UpdatedFrom) {
+		return false
+	}
+
+	// Check metadata filters if any
+	for k, v := range filter.Metadata {
+		if contentValue, exists := content.Metadata[k]; !exists || contentValue != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// copyAssociation returns a copy that shares no metadata or review with the stored association
+func copyAssociation(association *model.ContentEntityAssociation) *model.ContentEntityAssociation {
+	associationCopy := *association
+	if association.AssociationMetadata != nil {
+		associationCopy.AssociationMetadata = copyMap(association.AssociationMetadata)
+	}
+	if association.Review != nil {
+		review := *association.Review
+		if review.DecidedAt != nil {
+			decidedAt := *review.DecidedAt
+			review.DecidedAt = &decidedAt
+		}
+		associationCopy.Review = &review
+	}
+	return &associationCopy
+}
+
+// entityAssociations returns the stored associations of an entity, newest
+// first. The caller must hold the lock.
+func (r *MemoryRepository) entityAssociations(entityType, entityID string) []*model.ContentEntityAssociation {
+	var associations []*model.ContentEntityAssociation
+	for id := range r.associationsByEntity[entityKey{entityType, entityID}] {
+		associations = append(associations, r.associations[id])
+	}
+	sortAssociations(associations)
+	return associations
+}
+
+// sortAssociations orders associations newest first, with ties broken by ID
+func sortAssociations(associations []*model.ContentEntityAssociation) {
+	sort.Slice(associations, func(i, j int) bool {
+		if associations[i].CreatedAt.Equal(associations[j].CreatedAt) {
+			return associations[i].ID.String() < associations[j].ID.String()
+		}
+		return associations[i].CreatedAt.After(associations[j].CreatedAt)
+	})
+}
+
+// CreateAssociation stores a new association
+func (r *MemoryRepository) CreateAssociation(ctx context.Context, association *model.ContentEntityAssociation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.entityAssociations(association.EntityType, association.EntityID) {
+		if existing.ContentID == association.ContentID {
+			return repository.ErrAssociationExists
+		}
+	}
+
+	if association.ID == uuid.Nil {
+		association.ID = r.ids.NewID()
+	}
+
+	now := r.clock.Now()
+	if association.CreatedAt.IsZero() {
+		association.CreatedAt = now
+	}
+	if association.UpdatedAt.IsZero() {
+		association.UpdatedAt = now
+	}
+
+	r.associations[association.ID] = copyAssociation(association)
+	addToIndex(r.associationsByEntity, entityKey{association.EntityType, association.EntityID}, association.ID)
+	return nil
+}
+
+// GetAssociationByID retrieves an association by its ID
+func (r *MemoryRepository) GetAssociationByID(ctx context.Context, associationID uuid.UUID) (*model.ContentEntityAssociation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	association, exists := r.associations[associationID]
+	if !exists {
+		return nil, repository.ErrAssociationNotFound
+	}
+
+	return copyAssociation(association), nil
+}
+
+// GetAssociationByLink retrieves the association between a content item and an entity
+func (r *MemoryRepository) GetAssociationByLink(ctx context.Context, contentID uuid.UUID, entityType, entityID string) (*model.ContentEntityAssociation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, association := range r.entityAssociations(entityType, entityID) {
+		if association.ContentID == contentID {
+			return copyAssociation(association), nil
+		}
+	}
+
+	return nil, repository.ErrAssociationNotFound
+}
+
+// UpdateAssociation updates an existing association
+func (r *MemoryRepository) UpdateAssociation(ctx context.Context, association *model.ContentEntityAssociation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.associations[association.ID]
+	if !exists {
+		return repository.ErrAssociationNotFound
+	}
+
+	association.CreatedAt = existing.CreatedAt
+	association.UpdatedAt = r.clock.Now()
+
+	removeFromIndex(r.associationsByEntity, entityKey{existing.EntityType, existing.EntityID}, existing.ID)
+	r.associations[association.ID] = copyAssociation(association)
+	addToIndex(r.associationsByEntity, entityKey{association.EntityType, association.EntityID}, association.ID)
+	return nil
+}
+
+// DeleteAssociation removes an association
+func (r *MemoryRepository) DeleteAssociation(ctx context.Context, associationID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	association, exists := r.associations[associationID]
+	if !exists {
+		return repository.ErrAssociationNotFound
+	}
+
+	removeFromIndex(r.associationsByEntity, entityKey{association.EntityType, association.EntityID}, associationID)
+	delete(r.associations, associationID)
+	return nil
+}
+
+// ReorderAssociations places the given content of an entity in order
+func (r *MemoryRepository) ReorderAssociations(ctx context.Context, entityType, entityID string, contentIDs []uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	associations := r.entityAssociations(entityType, entityID)
+	byContent := make(map[uuid.UUID]*model.ContentEntityAssociation, len(associations))
+	for _, association := range associations {
+		byContent[association.ContentID] = association
+	}
+	for _, contentID := range contentIDs {
+		if byContent[contentID] == nil {
+			return repository.ErrAssociationNotFound
+		}
+	}
+
+	now := r.clock.Now()
+	positions := make(map[uuid.UUID]int, len(contentIDs))
+	for i, contentID := range contentIDs {
+		positions[contentID] = i + 1
+	}
+	for _, association := range associations {
+		if position := positions[association.ContentID]; association.Position != position {
+			association.Position = position
+			association.UpdatedAt = now
+		}
+	}
+	return nil
+}
+
+// DeleteAssociationsByEntity removes every association of an entity
+func (r *MemoryRepository) DeleteAssociationsByEntity(ctx context.Context, entityType, entityID string) ([]*model.ContentEntityAssociation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := r.entityAssociations(entityType, entityID)
+	for _, association := range deleted {
+		delete(r.associations, association.ID)
+	}
+	delete(r.associationsByEntity, entityKey{entityType, entityID})
+	if deleted == nil {
+		deleted = []*model.ContentEntityAssociation{}
+	}
+	return deleted, nil
+}
+
+// ListContentByEntity retrieves the content items associated with an entity
+func (r *MemoryRepository) ListContentByEntity(ctx context.Context, entityType string, entityID string, options repository.ListOptions) ([]*model.Content, int64, error) {
+	entityContents, total, err := r.ListEntityContents(ctx, entityType, entityID, options)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	contents := make([]*model.Content, len(entityContents))
+	for i, entityContent := range entityContents {
+		contents[i] = entityContent.Content
+	}
+	return contents, total, nil
+}
+
+// ListEntityContents retrieves the content items associated with an entity
+// along with their associations
+func (r *MemoryRepository) ListEntityContents(ctx context.Context, entityType string, entityID string, options repository.ListOptions) ([]*model.EntityContent, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var contents []*model.EntityContent
+	for id := range r.associationsByEntity[entityKey{entityType, entityID}] {
+		association := r.associations[id]
+		content, exists := r.contents[association.ContentID]
+		if !exists || content.DeletedAt != nil {
+			continue
+		}
+		if options.PinnedBy != "" && !r.isPinned(options.PinnedBy, content.ID) {
+			continue
+		}
+		if options.CreatedBy != "" && content.CreatedBy != options.CreatedBy {
+			continue
+		}
+		if content.IsDraft() && !options.IncludeDrafts {
+			continue
+		}
+		contents = append(contents, &model.EntityContent{Content: content, Association: association})
+	}
+
+	byPosition := options.SortBy == repository.SortByPosition
+	sort.Slice(contents, func(i, j int) bool {
+		if a, b := contents[i].Association.Position, contents[j].Association.Position; byPosition && a != b {
+			// Content that was never placed goes after the placed content
+			return b == 0 || (a != 0 && a < b)
+		}
+		if contents[i].CreatedAt.Equal(contents[j].CreatedAt) {
+			return contents[i].ID.String() < contents[j].ID.String()
+		}
+		return contents[i].CreatedAt.After(contents[j].CreatedAt)
+	})
+
+	page := paginate(contents, options)
+	for i, content := range page {
+		page[i] = &model.EntityContent{Content: copyContent(content.Content), Association: copyAssociation(content.Association)}
+	}
+	return page, int64(len(contents)), nil
+}
+
+// ListAssociationsByEntity retrieves the associations of an entity
+func (r *MemoryRepository) ListAssociationsByEntity(ctx context.Context, entityType string, entityID string, options repository.ListOptions) ([]*model.ContentEntityAssociation, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	associations := r.entityAssociations(entityType, entityID)
+	page := paginate(associations, options)
+	for i, association := range page {
+		page[i] = copyAssociation(association)
+	}
+	return page, int64(len(associations)), nil
+}
+
+// ListAssociationsByContent retrieves the associations of a content item
+func (r *MemoryRepository) ListAssociationsByContent(ctx context.Context, contentID uuid.UUID, options repository.ListOptions) ([]*model.ContentEntityAssociation, int64, error) {
+	return r.listAssociations(options, func(association *model.ContentEntityAssociation) bool {
+		return association.ContentID == contentID
+	})
+}
+
+// ListEntityIDs retrieves the distinct IDs of the linked entities of a type
+func (r *MemoryRepository) ListEntityIDs(ctx context.Context, entityType string, options repository.ListOptions) ([]string, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entityIDs []string
+	for key := range r.associationsByEntity {
+		if key.entityType == entityType {
+			entityIDs = append(entityIDs, key.entityID)
+		}
+	}
+	sort.Strings(entityIDs)
+
+	return paginate(entityIDs, options), int64(len(entityIDs)), nil
+}
+
+// ListAssociationsByReviewState retrieves the associations in a review state
+func (r *MemoryRepository) ListAssociationsByReviewState(ctx context.Context, entityType string, state model.ReviewState, options repository.ListOptions) ([]*model.ContentEntityAssociation, int64, error) {
+	return r.listAssociations(options, func(association *model.ContentEntityAssociation) bool {
+		return association.Review != nil && association.Review.State == state &&
+			(entityType == "" || association.EntityType == entityType)
+	})
+}
+
+// listAssociations returns a page of the associations accepted by match, newest first
+func (r *MemoryRepository) listAssociations(options repository.ListOptions, match func(*model.ContentEntityAssociation) bool) ([]*model.ContentEntityAssociation, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var associations []*model.ContentEntityAssociation
+	for _, association := range r.associations {
+		if match(association) {
+			associations = append(associations, association)
+		}
+	}
+	sortAssociations(associations)
+
+	page := paginate(associations, options)
+	for i, association := range page {
+		page[i] = copyAssociation(association)
+	}
+	return page, int64(len(associations)), nil
+}
+
+// paginate returns the page of items selected by options, or all items if no page size is set
+func paginate[T any](items []T, options repository.ListOptions) []T {
+	if options.PageSize <= 0 {
+		return items
+	}
+
+	offset := options.Offset()
+	if offset >= len(items) {
+		return []T{}
+	}
+	return items[offset:min(offset+options.PageSize, len(items))]
 }
@@ -0,0 +1,305 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+func TestCreateContentRejectsExistingID(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	existing := &model.Content{FileName: "a.txt", Status: model.StatusCreated}
+	if err := repo.CreateContent(ctx, existing); err != nil {
+		t.Fatalf("CreateContent() error = %v", err)
+	}
+
+	fresh := &model.Content{ID: uuid.New(), FileName: "b.txt"}
+	tests := []struct {
+		name     string
+		create   func() error
+		wantGone uuid.UUID
+	}{
+		{
+			name:   "single",
+			create: func() error { return repo.CreateContent(ctx, &model.Content{ID: existing.ID, FileName: "other.txt"}) },
+		},
+		{
+			name: "batch",
+			create: func() error {
+				return repo.CreateContentBatch(ctx, []*model.Content{fresh, {ID: existing.ID, FileName: "other.txt"}})
+			},
+			wantGone: fresh.ID,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.create(); !errors.Is(err, repository.ErrContentExists) {
+				t.Fatalf("error = %v, want ErrContentExists", err)
+			}
+			got, err := repo.GetContentByID(ctx, existing.ID)
+			if err != nil || got.FileName != "a.txt" {
+				t.Errorf("existing content = %+v, %v, want it unchanged", got, err)
+			}
+			if tt.wantGone != uuid.Nil {
+				if _, err := repo.GetContentByID(ctx, tt.wantGone); !errors.Is(err, repository.ErrContentNotFound) {
+					t.Errorf("rest of the batch stored, error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestMetadataIndexes(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	// Content stored before the keys are declared is indexed too
+	early := &model.Content{FileName: "a.pdf", Status: model.StatusUploaded, Metadata: model.Metadata{"invoice_number": "INV-1"}}
+	if err := repo.CreateContent(ctx, early); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.ConfigureMetadataIndexes("invoice_number"); err != nil {
+		t.Fatal(err)
+	}
+	late := &model.Content{FileName: "b.pdf", Status: model.StatusUploaded, Metadata: model.Metadata{"invoice_number": "INV-2", "amount": 3.0}}
+	if err := repo.CreateContent(ctx, late); err != nil {
+		t.Fatal(err)
+	}
+	moved := &model.Content{FileName: "c.pdf", Status: model.StatusUploaded, Metadata: model.Metadata{"invoice_number": "INV-1"}}
+	if err := repo.CreateContent(ctx, moved); err != nil {
+		t.Fatal(err)
+	}
+	moved.Metadata = model.Metadata{"invoice_number": "INV-3"}
+	if err := repo.UpdateContent(ctx, moved); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+		status   model.ContentStatus
+		want     []uuid.UUID
+	}{
+		{name: "indexed before", metadata: map[string]interface{}{"invoice_number": "INV-1"}, want: []uuid.UUID{early.ID}},
+		{name: "indexed after", metadata: map[string]interface{}{"invoice_number": "INV-2"}, want: []uuid.UUID{late.ID}},
+		{name: "reindexed on update", metadata: map[string]interface{}{"invoice_number": "INV-3"}, want: []uuid.UUID{moved.ID}},
+		{name: "with other conditions", metadata: map[string]interface{}{"invoice_number": "INV-2", "amount": 3.0}, status: model.StatusUploaded, want: []uuid.UUID{late.ID}},
+		{name: "unknown value", metadata: map[string]interface{}{"invoice_number": "INV-9"}, want: nil},
+		{name: "not indexed", metadata: map[string]interface{}{"amount": 3.0}, want: []uuid.UUID{late.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, total, err := repo.ListContent(ctx, model.ContentFilter{Metadata: tt.metadata, Status: tt.status}, 0, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != len(tt.want) || len(items) != len(tt.want) {
+				t.Fatalf("ListContent() returned %d items, total %d, want %d", len(items), total, len(tt.want))
+			}
+			for i, id := range tt.want {
+				if items[i].ID != id {
+					t.Errorf("item %d = %s, want %s", i, items[i].ID, id)
+				}
+			}
+		})
+	}
+}
+
+func TestFileNameSearch(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	var ids []uuid.UUID
+	for _, name := range []string{"Invoice_2024.pdf", "invoices.zip", "report-invoice.pdf", "Receipt.png"} {
+		content := &model.Content{FileName: name, Status: model.StatusUploaded}
+		if err := repo.CreateContent(ctx, content); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, content.ID)
+	}
+
+	tests := []struct {
+		name     string
+		fileName string
+		match    model.FileNameMatch
+		want     []uuid.UUID
+	}{
+		{name: "prefix ignores case", fileName: "invoice", match: model.MatchPrefix, want: []uuid.UUID{ids[1], ids[0]}},
+		{name: "substring", fileName: "INVOICE", want: []uuid.UUID{ids[2], ids[1], ids[0]}},
+		{name: "substring with separator", fileName: "t-inv", match: model.MatchSubstring, want: []uuid.UUID{ids[2]}},
+		{name: "fuzzy tolerates typos", fileName: "invoce", match: model.MatchFuzzy, want: []uuid.UUID{ids[2], ids[0]}},
+		{name: "fuzzy closest first", fileName: "invoices", match: model.MatchFuzzy, want: []uuid.UUID{ids[1], ids[2], ids[0]}},
+		{name: "no match", fileName: "contract", match: model.MatchFuzzy, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, total, err := repo.ListContent(ctx, model.ContentFilter{FileName: tt.fileName, FileNameMatch: tt.match}, 0, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != len(tt.want) || len(items) != len(tt.want) {
+				t.Fatalf("ListContent() returned %d items, total %d, want %d", len(items), total, len(tt.want))
+			}
+			for i, id := range tt.want {
+				if items[i].ID != id {
+					t.Errorf("item %d = %s, want %s", i, items[i].FileName, id)
+				}
+			}
+		})
+	}
+}
+
+func TestTextSearch(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	var ids []uuid.UUID
+	for _, content := range []*model.Content{
+		{FileName: "q3.pdf", Description: "The **Quarterly** report"},
+		{FileName: "quarterly-plan.docx"},
+		{FileName: "notes.txt", Description: "Nothing to see"},
+	} {
+		if err := repo.CreateContent(ctx, content); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, content.ID)
+	}
+
+	items, total, err := repo.ListContent(ctx, model.ContentFilter{Text: "QUARTERLY", SortBy: model.SortByFileName, SortAscending: true}, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(items) != 2 || items[0].ID != ids[0] || items[1].ID != ids[1] {
+		t.Errorf("ListContent() = %d items, total %d, want the file name and description matches", len(items), total)
+	}
+}
+
+func TestRebuildSearchIndex(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	if err := repo.ConfigureMetadataIndexes("invoice_number"); err != nil {
+		t.Fatal(err)
+	}
+	content := &model.Content{FileName: "a.pdf", Status: model.StatusUploaded, Metadata: model.Metadata{"invoice_number": "INV-1"}}
+	if err := repo.CreateContent(ctx, content); err != nil {
+		t.Fatal(err)
+	}
+
+	filters := map[string]model.ContentFilter{
+		"contents_by_status":   {Status: model.StatusUploaded},
+		"contents_by_metadata": {Metadata: map[string]interface{}{"invoice_number": "INV-1"}},
+	}
+	for index, filter := range filters {
+		t.Run(index, func(t *testing.T) {
+			// Lose the index, as if it were corrupted
+			repo.mu.Lock()
+			repo.contentsByStatus = make(map[model.ContentStatus]idSet)
+			repo.contentsByMetadata = make(map[metadataValue]idSet)
+			repo.mu.Unlock()
+			if _, total, _ := repo.ListContent(ctx, filter, 0, 10); total != 0 {
+				t.Fatalf("ListContent() found %d items without the index", total)
+			}
+
+			if err := repo.RebuildSearchIndex(ctx, index); err != nil {
+				t.Fatal(err)
+			}
+			items, total, err := repo.ListContent(ctx, filter, 0, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != 1 || items[0].ID != content.ID {
+				t.Errorf("ListContent() after the rebuild returned %d items, want the content", total)
+			}
+		})
+	}
+
+	if err := repo.RebuildSearchIndex(ctx, "contents_by_name"); !errors.Is(err, repository.ErrSearchIndexNotFound) {
+		t.Errorf("RebuildSearchIndex() of an unknown index error = %v, want ErrSearchIndexNotFound", err)
+	}
+}
+
+func TestJobs(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	job := &model.Job{Kind: "export", Status: model.JobRunning}
+	if err := repo.CreateJob(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.RequestJobCancel(ctx, job.ID); err != nil {
+		t.Fatal(err)
+	}
+	// The runner saving its progress doesn't clear the request, and learns of it
+	job.Done = 3
+	if err := repo.UpdateJob(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+	if !job.CancelRequested {
+		t.Error("UpdateJob() did not return the cancellation request")
+	}
+	got, err := repo.GetJob(ctx, job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Done != 3 || !got.CancelRequested {
+		t.Errorf("GetJob() = %d done, cancel requested %v, want 3 done with the request", got.Done, got.CancelRequested)
+	}
+
+	// The unfinished jobs of a stopped instance are failed
+	stale := &model.Job{Kind: "export", Status: model.JobRunning}
+	if err := repo.CreateJob(ctx, stale); err != nil {
+		t.Fatal(err)
+	}
+	if failed, err := repo.FailStaleJobs(ctx, time.Now().Add(time.Second), "gone"); err != nil || failed != 2 {
+		t.Fatalf("FailStaleJobs() = %d, %v, want 2", failed, err)
+	}
+	if got, _ := repo.GetJob(ctx, stale.ID); got.Status != model.JobFailed || got.Error != "gone" || got.FinishedAt == nil {
+		t.Errorf("stale job = %s (%q), want failed", got.Status, got.Error)
+	}
+
+	finishedAt := time.Now().Add(-time.Hour)
+	job.Status, job.FinishedAt = model.JobCancelled, &finishedAt
+	if err := repo.UpdateJob(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+	if pruned, err := repo.PruneJobs(ctx, time.Now().Add(-time.Minute)); err != nil || pruned != 1 {
+		t.Fatalf("PruneJobs() = %d, %v, want 1", pruned, err)
+	}
+	if _, err := repo.GetJob(ctx, job.ID); !errors.Is(err, repository.ErrJobNotFound) {
+		t.Errorf("GetJob() of a pruned job error = %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestPendingDeletions(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	now := time.Now()
+	for i, path := range []string{"c", "a", "b"} {
+		deletion := &model.PendingDeletion{StoragePath: path, QueuedAt: now.Add(time.Duration(i) * time.Second)}
+		if err := repo.QueueDeletion(ctx, deletion); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deletions, err := repo.ListPendingDeletions(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deletions) != 2 || deletions[0].StoragePath != "c" || deletions[1].StoragePath != "a" {
+		t.Fatalf("ListPendingDeletions() = %v, want c and a, oldest first", deletions)
+	}
+
+	if err := repo.RemovePendingDeletion(ctx, deletions[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if deletions, _ = repo.ListPendingDeletions(ctx, 10); len(deletions) != 2 || deletions[0].StoragePath != "a" {
+		t.Errorf("ListPendingDeletions() after removing c = %v, want a and b", deletions)
+	}
+}
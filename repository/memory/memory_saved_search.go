@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// copySavedSearch returns a copy that shares no filter state with the stored search
+func copySavedSearch(search *model.SavedSearch) *model.SavedSearch {
+	searchCopy := *search
+	searchCopy.Filter.Metadata = maps.Clone(search.Filter.Metadata)
+	return &searchCopy
+}
+
+// CreateSavedSearch stores a new saved search
+func (r *MemoryRepository) CreateSavedSearch(ctx context.Context, search *model.SavedSearch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.savedSearches {
+		if existing.Principal == search.Principal && existing.Name == search.Name {
+			return repository.ErrSavedSearchExists
+		}
+	}
+
+	if search.ID == uuid.Nil {
+		search.ID = r.ids.NewID()
+	}
+	now := r.clock.Now()
+	search.CreatedAt = now
+	search.UpdatedAt = now
+
+	r.savedSearches[search.ID] = copySavedSearch(search)
+	return nil
+}
+
+// GetSavedSearch retrieves a saved search by its ID
+func (r *MemoryRepository) GetSavedSearch(ctx context.Context, id uuid.UUID) (*model.SavedSearch, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	search, exists := r.savedSearches[id]
+	if !exists {
+		return nil, repository.ErrSavedSearchNotFound
+	}
+
+	return copySavedSearch(search), nil
+}
+
+// ListSavedSearchesByPrincipal retrieves a page of the searches of a principal ordered by name
+func (r *MemoryRepository) ListSavedSearchesByPrincipal(ctx context.Context, principal string, options repository.ListOptions) ([]*model.SavedSearch, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var searches []*model.SavedSearch
+	for _, search := range r.savedSearches {
+		if search.Principal == principal {
+			searches = append(searches, copySavedSearch(search))
+		}
+	}
+
+	sort.Slice(searches, func(i, j int) bool {
+		return searches[i].Name < searches[j].Name
+	})
+
+	return paginate(searches, options), int64(len(searches)), nil
+}
+
+// UpdateSavedSearch updates an existing saved search
+func (r *MemoryRepository) UpdateSavedSearch(ctx context.Context, search *model.SavedSearch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.savedSearches[search.ID]
+	if !exists {
+		return repository.ErrSavedSearchNotFound
+	}
+	for _, other := range r.savedSearches {
+		if other.ID != search.ID && other.Principal == search.Principal && other.Name == search.Name {
+			return repository.ErrSavedSearchExists
+		}
+	}
+
+	search.CreatedAt = existing.CreatedAt
+	search.UpdatedAt = r.clock.Now()
+
+	r.savedSearches[search.ID] = copySavedSearch(search)
+	return nil
+}
+
+// DeleteSavedSearch removes a saved search
+func (r *MemoryRepository) DeleteSavedSearch(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.savedSearches[id]; !exists {
+		return repository.ErrSavedSearchNotFound
+	}
+
+	delete(r.savedSearches, id)
+	return nil
+}
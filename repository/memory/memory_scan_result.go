@@ -0,0 +1,38 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// scanResultKey identifies the scan of a digest with a signature database version
+type scanResultKey struct {
+	digest           string
+	signatureVersion string
+}
+
+// GetScanResult retrieves the result of scanning a digest with a signature database version
+func (r *MemoryRepository) GetScanResult(ctx context.Context, digest, signatureVersion string) (*model.ScanResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result, exists := r.scanResults[scanResultKey{digest, signatureVersion}]
+	if !exists {
+		return nil, repository.ErrScanResultNotFound
+	}
+
+	resultCopy := *result
+	return &resultCopy, nil
+}
+
+// SaveScanResult stores a scan result
+func (r *MemoryRepository) SaveScanResult(ctx context.Context, result *model.ScanResult) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	resultCopy := *result
+	r.scanResults[scanResultKey{result.Digest, result.SignatureVersion}] = &resultCopy
+	return nil
+}
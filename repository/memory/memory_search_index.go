@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// Names of the indexes listings search
+const (
+	indexContentsByStatus   = "contents_by_status"
+	indexContentsByMetadata = "contents_by_metadata"
+)
+
+// SearchIndexes names the indexes of content listings
+func (r *MemoryRepository) SearchIndexes(ctx context.Context) ([]string, error) {
+	return []string{indexContentsByStatus, indexContentsByMetadata}, nil
+}
+
+// RebuildSearchIndex rebuilds an index of content listings from the stored content
+func (r *MemoryRepository) RebuildSearchIndex(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch name {
+	case indexContentsByStatus:
+		r.contentsByStatus = make(map[model.ContentStatus]idSet)
+		for _, content := range r.contents {
+			addToIndex(r.contentsByStatus, content.Status, content.ID)
+		}
+	case indexContentsByMetadata:
+		r.contentsByMetadata = make(map[metadataValue]idSet)
+		for _, content := range r.contents {
+			r.indexMetadata(content)
+		}
+	default:
+		return fmt.Errorf("%w: %s", repository.ErrSearchIndexNotFound, name)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// copyShare returns a copy that shares no state with the stored share
+func copyShare(share *model.Share) *model.Share {
+	shareCopy := *share
+	for _, t := range []**time.Time{&shareCopy.ExpiresAt, &shareCopy.LastDownloadedAt, &shareCopy.RevokedAt} {
+		if *t != nil {
+			value := **t
+			*t = &value
+		}
+	}
+	return &shareCopy
+}
+
+// CreateShare stores a new share
+func (r *MemoryRepository) CreateShare(ctx context.Context, share *model.Share) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.shares[share.Token]; exists {
+		return repository.ErrShareExists
+	}
+	share.CreatedAt = r.clock.Now()
+
+	r.shares[share.Token] = copyShare(share)
+	return nil
+}
+
+// GetShare retrieves a share by its token
+func (r *MemoryRepository) GetShare(ctx context.Context, token string) (*model.Share, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	share, exists := r.shares[token]
+	if !exists {
+		return nil, repository.ErrShareNotFound
+	}
+
+	return copyShare(share), nil
+}
+
+// ListSharesByContent retrieves a page of the shares of a content item, newest first
+func (r *MemoryRepository) ListSharesByContent(ctx context.Context, contentID uuid.UUID, options repository.ListOptions) ([]*model.Share, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var shares []*model.Share
+	for _, share := range r.shares {
+		if share.ContentID == contentID {
+			shares = append(shares, copyShare(share))
+		}
+	}
+
+	sort.Slice(shares, func(i, j int) bool {
+		return shares[i].CreatedAt.After(shares[j].CreatedAt)
+	})
+
+	return paginate(shares, options), int64(len(shares)), nil
+}
+
+// RecordShareDownload counts a download through a share, unless it has none left
+func (r *MemoryRepository) RecordShareDownload(ctx context.Context, token string, downloadedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	share, exists := r.shares[token]
+	if !exists {
+		return repository.ErrShareNotFound
+	}
+	if share.MaxDownloads > 0 && share.Downloads >= share.MaxDownloads {
+		return repository.ErrShareExhausted
+	}
+
+	share.Downloads++
+	share.LastDownloadedAt = &downloadedAt
+	return nil
+}
+
+// RevokeShare marks a share revoked
+func (r *MemoryRepository) RevokeShare(ctx context.Context, token string, revokedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	share, exists := r.shares[token]
+	if !exists {
+		return repository.ErrShareNotFound
+	}
+
+	if share.RevokedAt == nil {
+		share.RevokedAt = &revokedAt
+	}
+	return nil
+}
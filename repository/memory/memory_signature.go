@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// copySignatureRequest returns a copy that shares no memory with the stored request
+func copySignatureRequest(request *model.SignatureRequest) *model.SignatureRequest {
+	requestCopy := *request
+	requestCopy.Signers = append([]model.Signer(nil), request.Signers...)
+	if request.SignedContentID != nil {
+		id := *request.SignedContentID
+		requestCopy.SignedContentID = &id
+	}
+	if request.CompletedAt != nil {
+		completedAt := *request.CompletedAt
+		requestCopy.CompletedAt = &completedAt
+	}
+	return &requestCopy
+}
+
+// CreateSignatureRequest stores a new signature request
+func (r *MemoryRepository) CreateSignatureRequest(ctx context.Context, request *model.SignatureRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if request.ID == uuid.Nil {
+		request.ID = r.ids.NewID()
+	}
+
+	now := r.clock.Now()
+	request.CreatedAt = now
+	request.UpdatedAt = now
+
+	r.signatures[request.ID] = copySignatureRequest(request)
+	return nil
+}
+
+// GetSignatureRequest retrieves a signature request by its ID
+func (r *MemoryRepository) GetSignatureRequest(ctx context.Context, id uuid.UUID) (*model.SignatureRequest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	request, exists := r.signatures[id]
+	if !exists {
+		return nil, repository.ErrSignatureNotFound
+	}
+
+	return copySignatureRequest(request), nil
+}
+
+// GetSignatureRequestByProviderID retrieves a signature request by the provider's identifier
+func (r *MemoryRepository) GetSignatureRequestByProviderID(ctx context.Context, provider, providerRequestID string) (*model.SignatureRequest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, request := range r.signatures {
+		if request.Provider == provider && request.ProviderRequestID == providerRequestID {
+			return copySignatureRequest(request), nil
+		}
+	}
+
+	return nil, repository.ErrSignatureNotFound
+}
+
+// ListSignatureRequestsByContent retrieves a page of the signature requests of a content item, newest first
+func (r *MemoryRepository) ListSignatureRequestsByContent(ctx context.Context, contentID uuid.UUID, options repository.ListOptions) ([]*model.SignatureRequest, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var requests []*model.SignatureRequest
+	for _, request := range r.signatures {
+		if request.ContentID == contentID {
+			requests = append(requests, copySignatureRequest(request))
+		}
+	}
+
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].CreatedAt.After(requests[j].CreatedAt)
+	})
+
+	return paginate(requests, options), int64(len(requests)), nil
+}
+
+// UpdateSignatureRequest updates an existing signature request
+func (r *MemoryRepository) UpdateSignatureRequest(ctx context.Context, request *model.SignatureRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.signatures[request.ID]
+	if !exists {
+		return repository.ErrSignatureNotFound
+	}
+
+	request.CreatedAt = existing.CreatedAt
+	request.UpdatedAt = r.clock.Now()
+
+	r.signatures[request.ID] = copySignatureRequest(request)
+	return nil
+}
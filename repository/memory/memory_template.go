@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// CreateTemplate stores a new template
+func (r *MemoryRepository) CreateTemplate(ctx context.Context, template *model.Template) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.templates[template.ID]; exists {
+		return repository.ErrTemplateExists
+	}
+
+	now := r.clock.Now()
+	template.CreatedAt = now
+	template.UpdatedAt = now
+
+	templateCopy := *template
+	r.templates[template.ID] = &templateCopy
+	return nil
+}
+
+// GetTemplate retrieves a template by its ID
+func (r *MemoryRepository) GetTemplate(ctx context.Context, id string) (*model.Template, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	template, exists := r.templates[id]
+	if !exists {
+		return nil, repository.ErrTemplateNotFound
+	}
+
+	templateCopy := *template
+	return &templateCopy, nil
+}
+
+// ListTemplates retrieves a page of templates ordered by ID
+func (r *MemoryRepository) ListTemplates(ctx context.Context, options repository.ListOptions) ([]*model.Template, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	templates := make([]*model.Template, 0, len(r.templates))
+	for _, template := range r.templates {
+		templateCopy := *template
+		templates = append(templates, &templateCopy)
+	}
+
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].ID < templates[j].ID
+	})
+
+	return paginate(templates, options), int64(len(templates)), nil
+}
+
+// UpdateTemplate updates an existing template
+func (r *MemoryRepository) UpdateTemplate(ctx context.Context, template *model.Template) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.templates[template.ID]
+	if !exists {
+		return repository.ErrTemplateNotFound
+	}
+
+	template.CreatedAt = existing.CreatedAt
+	template.UpdatedAt = r.clock.Now()
+
+	templateCopy := *template
+	r.templates[template.ID] = &templateCopy
+	return nil
+}
+
+// DeleteTemplate removes a template
+func (r *MemoryRepository) DeleteTemplate(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.templates[id]; !exists {
+		return repository.ErrTemplateNotFound
+	}
+
+	delete(r.templates, id)
+	return nil
+}
@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// CreateTenant stores a new tenant
+func (r *MemoryRepository) CreateTenant(ctx context.Context, tenant *model.Tenant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tenants[tenant.ID]; exists {
+		return repository.ErrTenantExists
+	}
+
+	now := r.clock.Now()
+	if tenant.CreatedAt.IsZero() {
+		tenant.CreatedAt = now
+	}
+	if tenant.UpdatedAt.IsZero() {
+		tenant.UpdatedAt = now
+	}
+
+	tenantCopy := *tenant
+	r.tenants[tenant.ID] = &tenantCopy
+	return nil
+}
+
+// GetTenant retrieves a tenant by its ID
+func (r *MemoryRepository) GetTenant(ctx context.Context, id string) (*model.Tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenant, exists := r.tenants[id]
+	if !exists {
+		return nil, repository.ErrTenantNotFound
+	}
+
+	tenantCopy := *tenant
+	return &tenantCopy, nil
+}
+
+// ListTenants retrieves a page of tenants ordered by ID
+func (r *MemoryRepository) ListTenants(ctx context.Context, options repository.ListOptions) ([]*model.Tenant, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenants := make([]*model.Tenant, 0, len(r.tenants))
+	for _, tenant := range r.tenants {
+		tenantCopy := *tenant
+		tenants = append(tenants, &tenantCopy)
+	}
+
+	sort.Slice(tenants, func(i, j int) bool {
+		return tenants[i].ID < tenants[j].ID
+	})
+
+	return paginate(tenants, options), int64(len(tenants)), nil
+}
+
+// UpdateTenant updates an existing tenant
+func (r *MemoryRepository) UpdateTenant(ctx context.Context, tenant *model.Tenant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.tenants[tenant.ID]
+	if !exists {
+		return repository.ErrTenantNotFound
+	}
+
+	// Usage is only changed through AddTenantUsage
+	tenant.CreatedAt = existing.CreatedAt
+	tenant.UsedBytes = existing.UsedBytes
+	tenant.UpdatedAt = r.clock.Now()
+
+	tenantCopy := *tenant
+	r.tenants[tenant.ID] = &tenantCopy
+	return nil
+}
+
+// DeleteTenant removes a tenant
+func (r *MemoryRepository) DeleteTenant(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tenants[id]; !exists {
+		return repository.ErrTenantNotFound
+	}
+
+	delete(r.tenants, id)
+	return nil
+}
+
+// AddTenantUsage adds delta to the stored bytes of a tenant
+func (r *MemoryRepository) AddTenantUsage(ctx context.Context, id string, delta int64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tenant, exists := r.tenants[id]
+	if !exists {
+		return 0, repository.ErrTenantNotFound
+	}
+
+	tenant.UsedBytes += delta
+	return tenant.UsedBytes, nil
+}
+
+// SetTenantUsage replaces the stored bytes of a tenant
+func (r *MemoryRepository) SetTenantUsage(ctx context.Context, id string, usedBytes int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tenant, exists := r.tenants[id]
+	if !exists {
+		return repository.ErrTenantNotFound
+	}
+
+	tenant.UsedBytes = usedBytes
+	return nil
+}
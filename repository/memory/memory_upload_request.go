@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// copyUploadRequest returns a copy that shares no state with the stored request
+func copyUploadRequest(request *model.UploadRequest) *model.UploadRequest {
+	requestCopy := *request
+	if request.RevokedAt != nil {
+		revokedAt := *request.RevokedAt
+		requestCopy.RevokedAt = &revokedAt
+	}
+	return &requestCopy
+}
+
+// CreateUploadRequest stores a new upload request
+func (r *MemoryRepository) CreateUploadRequest(ctx context.Context, request *model.UploadRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.uploadRequests[request.Token]; exists {
+		return repository.ErrUploadRequestExists
+	}
+	request.CreatedAt = r.clock.Now()
+
+	r.uploadRequests[request.Token] = copyUploadRequest(request)
+	return nil
+}
+
+// GetUploadRequest retrieves an upload request by its token
+func (r *MemoryRepository) GetUploadRequest(ctx context.Context, token string) (*model.UploadRequest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	request, exists := r.uploadRequests[token]
+	if !exists {
+		return nil, repository.ErrUploadRequestNotFound
+	}
+
+	return copyUploadRequest(request), nil
+}
+
+// ListUploadRequestsByEntity retrieves a page of the upload requests of an entity, newest first
+func (r *MemoryRepository) ListUploadRequestsByEntity(ctx context.Context, entityType, entityID string, options repository.ListOptions) ([]*model.UploadRequest, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var requests []*model.UploadRequest
+	for _, request := range r.uploadRequests {
+		if request.EntityType == entityType && request.EntityID == entityID {
+			requests = append(requests, copyUploadRequest(request))
+		}
+	}
+
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].CreatedAt.After(requests[j].CreatedAt)
+	})
+
+	return paginate(requests, options), int64(len(requests)), nil
+}
+
+// ReserveUpload counts an upload through a request, unless it has none left
+func (r *MemoryRepository) ReserveUpload(ctx context.Context, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	request, exists := r.uploadRequests[token]
+	if !exists {
+		return repository.ErrUploadRequestNotFound
+	}
+	if request.MaxUploads > 0 && request.Uploads >= request.MaxUploads {
+		return repository.ErrUploadRequestExhausted
+	}
+
+	request.Uploads++
+	return nil
+}
+
+// ReleaseUpload gives back a reserved upload
+func (r *MemoryRepository) ReleaseUpload(ctx context.Context, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	request, exists := r.uploadRequests[token]
+	if !exists {
+		return repository.ErrUploadRequestNotFound
+	}
+
+	if request.Uploads > 0 {
+		request.Uploads--
+	}
+	return nil
+}
+
+// RevokeUploadRequest marks an upload request revoked
+func (r *MemoryRepository) RevokeUploadRequest(ctx context.Context, token string, revokedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	request, exists := r.uploadRequests[token]
+	if !exists {
+		return repository.ErrUploadRequestNotFound
+	}
+
+	if request.RevokedAt == nil {
+		request.RevokedAt = &revokedAt
+	}
+	return nil
+}
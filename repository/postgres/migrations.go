@@ -0,0 +1,10 @@
+package postgres
+
+import "embed"
+
+// Migrations holds the schema migrations of the repository, numbered in the
+// order they apply, with an .up.sql and a .down.sql file each, as migration
+// tools such as golang-migrate read them
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
@@ -0,0 +1,12 @@
+-- Turns the partitioned contents table back into a plain one. The rows of
+-- the monthly partitions are moved into the legacy table first.
+BEGIN;
+
+ALTER TABLE contents DETACH PARTITION contents_legacy;
+INSERT INTO contents_legacy SELECT * FROM contents;
+DROP TABLE contents;
+
+ALTER TABLE contents_legacy RENAME CONSTRAINT contents_legacy_pkey TO contents_pkey;
+ALTER TABLE contents_legacy RENAME TO contents;
+
+COMMIT;
@@ -0,0 +1,29 @@
+-- Partitions the contents table by month of created_at (PostgreSQL 14 or
+-- later). The existing table becomes the partition of everything created
+-- before next month, so its rows stay in place; later months get partitions
+-- of their own from EnsureContentPartitions, which must first be run for
+-- next month before it starts.
+--
+-- A partitioned table's primary key must include the partition key, so it
+-- becomes (id, created_at). Foreign keys referencing contents(id) can't
+-- reference it any more and must be dropped before running this migration.
+-- The table is given no default partition: pruning detaches partitions
+-- concurrently, which Postgres doesn't allow alongside one.
+--
+-- Attaching the existing table builds the new indexes on it, which locks
+-- it for as long; run this migration during a maintenance window.
+BEGIN;
+
+ALTER TABLE contents RENAME TO contents_legacy;
+ALTER TABLE contents_legacy RENAME CONSTRAINT contents_pkey TO contents_legacy_pkey;
+
+CREATE TABLE contents (LIKE contents_legacy INCLUDING DEFAULTS INCLUDING CONSTRAINTS INCLUDING GENERATED)
+	PARTITION BY RANGE (created_at);
+ALTER TABLE contents ADD PRIMARY KEY (id, created_at);
+CREATE INDEX contents_tenant_created_idx ON contents (tenant_id, created_at DESC);
+CREATE INDEX contents_deleted_idx ON contents (deleted_at) WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE contents ATTACH PARTITION contents_legacy
+	FOR VALUES FROM (MINVALUE) TO ((date_trunc('month', now() AT TIME ZONE 'UTC') + interval '1 month') AT TIME ZONE 'UTC');
+
+COMMIT;
@@ -0,0 +1,15 @@
+-- Audit events, partitioned by month of occurred_at so that retention drops
+-- whole partitions. EnsureAuditPartitions creates the monthly partitions;
+-- RecordAuditEvent also creates the partition of an event's month if it's
+-- missing.
+CREATE TABLE audit_events (
+	id          UUID        NOT NULL,
+	action      TEXT        NOT NULL,
+	subject     TEXT        NOT NULL,
+	actor       TEXT        NOT NULL,
+	detail      TEXT        NOT NULL DEFAULT '',
+	occurred_at TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (id, occurred_at)
+) PARTITION BY RANGE (occurred_at);
+
+CREATE INDEX audit_events_subject_idx ON audit_events (subject, occurred_at DESC);
@@ -0,0 +1 @@
+DROP TABLE scan_results;
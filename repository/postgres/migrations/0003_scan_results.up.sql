@@ -0,0 +1,11 @@
+-- Virus scan verdicts by digest of the scanned data and version of the
+-- signature database they were given with, so identical data isn't scanned
+-- again until the signatures change.
+CREATE TABLE scan_results (
+	digest            TEXT        NOT NULL,
+	signature_version TEXT        NOT NULL,
+	infected          BOOLEAN     NOT NULL,
+	signature         TEXT        NOT NULL DEFAULT '',
+	scanned_at        TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (digest, signature_version)
+);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS contents_name_trgm_idx;
@@ -0,0 +1,4 @@
+-- Trigram index of file names, serving substring searches as well as the
+-- fuzzy ones of pg_trgm's word similarity operator.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS contents_name_trgm_idx ON contents USING gin (name gin_trgm_ops);
@@ -0,0 +1,20 @@
+-- Asynchronous jobs, e.g. transcodes and exports, kept so their status can be
+-- polled from any instance until they are pruned some time after finishing.
+CREATE TABLE jobs (
+	id               UUID        PRIMARY KEY,
+	kind             TEXT        NOT NULL,
+	tenant_id        TEXT        NOT NULL DEFAULT '',
+	content_id       UUID,
+	status           TEXT        NOT NULL,
+	progress         INTEGER     NOT NULL DEFAULT 0,
+	done             BIGINT      NOT NULL DEFAULT 0,
+	total            BIGINT      NOT NULL DEFAULT 0,
+	cancel_requested BOOLEAN     NOT NULL DEFAULT FALSE,
+	input            JSONB,
+	result           JSONB,
+	error            TEXT        NOT NULL DEFAULT '',
+	created_at       TIMESTAMPTZ NOT NULL,
+	updated_at       TIMESTAMPTZ NOT NULL,
+	finished_at      TIMESTAMPTZ
+);
+CREATE INDEX jobs_finished_idx ON jobs (finished_at) WHERE finished_at IS NOT NULL;
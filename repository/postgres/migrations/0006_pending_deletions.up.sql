@@ -0,0 +1,9 @@
+-- Stored objects of deleted content that storage failed to delete, retried
+-- once it is healthy again instead of being left behind.
+CREATE TABLE pending_deletions (
+	id           UUID        PRIMARY KEY,
+	tenant_id    TEXT        NOT NULL DEFAULT '',
+	storage_path TEXT        NOT NULL,
+	queued_at    TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX pending_deletions_queued_idx ON pending_deletions (queued_at);
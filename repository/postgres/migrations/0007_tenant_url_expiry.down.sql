@@ -0,0 +1 @@
+ALTER TABLE tenants DROP COLUMN max_url_expiry_seconds;
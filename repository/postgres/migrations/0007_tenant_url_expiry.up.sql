@@ -0,0 +1,3 @@
+-- Per-tenant cap on the lifetime of presigned download URLs, 0 for the
+-- server's maximum.
+ALTER TABLE tenants ADD COLUMN max_url_expiry_seconds INTEGER NOT NULL DEFAULT 0;
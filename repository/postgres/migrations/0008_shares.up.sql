@@ -0,0 +1,15 @@
+-- Public share links of content, kept after they are revoked so that their
+-- downloads can still be listed.
+CREATE TABLE shares (
+	token              TEXT        PRIMARY KEY,
+	content_id         UUID        NOT NULL,
+	password_hash      TEXT        NOT NULL DEFAULT '',
+	created_by         TEXT        NOT NULL DEFAULT '',
+	expires_at         TIMESTAMPTZ,
+	max_downloads      BIGINT      NOT NULL DEFAULT 0,
+	downloads          BIGINT      NOT NULL DEFAULT 0,
+	last_downloaded_at TIMESTAMPTZ,
+	revoked_at         TIMESTAMPTZ,
+	created_at         TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX shares_content_idx ON shares (content_id, created_at DESC);
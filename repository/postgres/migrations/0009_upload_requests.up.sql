@@ -0,0 +1,18 @@
+-- Links through which external parties upload files attached to an entity
+-- without an account, kept after they are revoked.
+CREATE TABLE upload_requests (
+	token         TEXT        PRIMARY KEY,
+	tenant_id     TEXT        NOT NULL DEFAULT '',
+	entity_type   TEXT        NOT NULL,
+	entity_id     TEXT        NOT NULL,
+	recipient     TEXT        NOT NULL DEFAULT '',
+	message       TEXT        NOT NULL DEFAULT '',
+	created_by    TEXT        NOT NULL DEFAULT '',
+	expires_at    TIMESTAMPTZ NOT NULL,
+	max_uploads   BIGINT      NOT NULL DEFAULT 0,
+	max_file_size BIGINT      NOT NULL DEFAULT 0,
+	uploads       BIGINT      NOT NULL DEFAULT 0,
+	revoked_at    TIMESTAMPTZ,
+	created_at    TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX upload_requests_entity_idx ON upload_requests (entity_type, entity_id, created_at DESC);
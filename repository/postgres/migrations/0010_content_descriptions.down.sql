@@ -0,0 +1,2 @@
+-- The column stays text: longer descriptions may have been stored since.
+DROP INDEX IF EXISTS contents_description_trgm_idx;
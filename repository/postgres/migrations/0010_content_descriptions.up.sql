@@ -0,0 +1,6 @@
+-- Descriptions hold Markdown of up to 20,000 characters, so the column
+-- becomes unbounded text, a change that doesn't rewrite the table. A
+-- trigram index serves the substring searches of the q parameter.
+ALTER TABLE contents ALTER COLUMN description TYPE TEXT;
+ALTER TABLE contents ALTER COLUMN description SET DEFAULT '';
+CREATE INDEX IF NOT EXISTS contents_description_trgm_idx ON contents USING gin (description gin_trgm_ops);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS contents_created_by_idx;
+ALTER TABLE contents DROP COLUMN created_by;
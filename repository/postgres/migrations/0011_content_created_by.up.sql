@@ -0,0 +1,4 @@
+-- The principal who created each content item, filtered on by
+-- created_by=me listings.
+ALTER TABLE contents ADD COLUMN IF NOT EXISTS created_by TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS contents_created_by_idx ON contents (tenant_id, created_by, created_at DESC);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS content_external_ids;
+ALTER TABLE contents DROP COLUMN external_id;
@@ -0,0 +1,14 @@
+-- The keys upstream systems give the content they create, unique per tenant
+-- and source. A unique index on the partitioned contents table would have to
+-- include created_at, so uniqueness is kept by a table of its own, written
+-- in the transaction storing the content. Rows of pruned content are
+-- removed with it.
+ALTER TABLE contents ADD COLUMN IF NOT EXISTS external_id TEXT NOT NULL DEFAULT '';
+CREATE TABLE content_external_ids (
+	tenant_id   TEXT NOT NULL,
+	source      TEXT NOT NULL,
+	external_id TEXT NOT NULL,
+	content_id  UUID NOT NULL,
+	PRIMARY KEY (tenant_id, source, external_id)
+);
+CREATE INDEX content_external_ids_content_idx ON content_external_ids (content_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS contents_references_idx;
+ALTER TABLE contents DROP COLUMN reference_checked_at;
+ALTER TABLE contents DROP COLUMN reference_status;
+ALTER TABLE contents DROP COLUMN reference_url;
+ALTER TABLE contents DROP COLUMN kind;
@@ -0,0 +1,8 @@
+-- References catalogue documents whose data stays in another system: a URL
+-- instead of a stored object, checked for reachability by the references
+-- task. The partial index serves kind=reference listings and that task.
+ALTER TABLE contents ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT '';
+ALTER TABLE contents ADD COLUMN IF NOT EXISTS reference_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE contents ADD COLUMN IF NOT EXISTS reference_status TEXT NOT NULL DEFAULT '';
+ALTER TABLE contents ADD COLUMN IF NOT EXISTS reference_checked_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS contents_references_idx ON contents (created_at DESC) WHERE kind = 'reference' AND deleted_at IS NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS contents_drafts_idx;
+ALTER TABLE contents DROP COLUMN draft_expires_at;
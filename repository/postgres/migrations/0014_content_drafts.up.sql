@@ -0,0 +1,5 @@
+-- Drafts are content created with a TTL and left out of entity listings
+-- until finalized, which clears draft_expires_at. The partial index serves
+-- the drafts task purging the ones never finalized.
+ALTER TABLE contents ADD COLUMN IF NOT EXISTS draft_expires_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS contents_drafts_idx ON contents (draft_expires_at) WHERE draft_expires_at IS NOT NULL AND deleted_at IS NULL;
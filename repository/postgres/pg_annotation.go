@@ -0,0 +1,194 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// annotationDB is a database model for an annotation
+type annotationDB struct {
+	ID        uuid.UUID      `db:"id"`
+	ContentID uuid.UUID      `db:"content_id"`
+	Author    string         `db:"author"`
+	Body      string         `db:"body"`
+	Anchor    sql.NullString `db:"anchor"` // JSON stored as string
+	CreatedAt time.Time      `db:"created_at"`
+	UpdatedAt time.Time      `db:"updated_at"`
+}
+
+// toModel converts a database model to a domain model
+func (a *annotationDB) toModel() (*model.Annotation, error) {
+	annotation := &model.Annotation{
+		ID:        a.ID,
+		ContentID: a.ContentID,
+		Author:    a.Author,
+		Body:      a.Body,
+		CreatedAt: a.CreatedAt,
+		UpdatedAt: a.UpdatedAt,
+	}
+
+	if a.Anchor.Valid {
+		if err := json.Unmarshal([]byte(a.Anchor.String), &annotation.Anchor); err != nil {
+			return nil, err
+		}
+	}
+
+	return annotation, nil
+}
+
+// annotationFromModel converts a domain model to a database model
+func annotationFromModel(annotation *model.Annotation) (*annotationDB, error) {
+	dbAnnotation := &annotationDB{
+		ID:        annotation.ID,
+		ContentID: annotation.ContentID,
+		Author:    annotation.Author,
+		Body:      annotation.Body,
+		CreatedAt: annotation.CreatedAt,
+		UpdatedAt: annotation.UpdatedAt,
+	}
+
+	if annotation.Anchor != nil {
+		anchorBytes, err := json.Marshal(annotation.Anchor)
+		if err != nil {
+			return nil, err
+		}
+		dbAnnotation.Anchor = sql.NullString{
+			String: string(anchorBytes),
+			Valid:  true,
+		}
+	}
+
+	return dbAnnotation, nil
+}
+
+// CreateAnnotation stores a new annotation
+func (r *PostgresRepository) CreateAnnotation(ctx context.Context, annotation *model.Annotation) error {
+	if annotation.ID == uuid.Nil {
+		annotation.ID = r.ids.NewID()
+	}
+
+	now := r.clock.Now()
+	annotation.CreatedAt = now
+	annotation.UpdatedAt = now
+
+	dbAnnotation, err := annotationFromModel(annotation)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO annotations (
+			id, content_id, author, body, anchor, created_at, updated_at
+		) VALUES (
+			:id, :content_id, :author, :body, :anchor, :created_at, :updated_at
+		)
+	`
+
+	_, err = r.db.NamedExecContext(ctx, query, dbAnnotation)
+	return err
+}
+
+// GetAnnotation retrieves an annotation by its ID
+func (r *PostgresRepository) GetAnnotation(ctx context.Context, id uuid.UUID) (*model.Annotation, error) {
+	var dbAnnotation annotationDB
+	if err := r.db.GetContext(ctx, &dbAnnotation, `SELECT * FROM annotations WHERE id = $1`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrAnnotationNotFound
+		}
+		return nil, err
+	}
+
+	return dbAnnotation.toModel()
+}
+
+// ListAnnotationsByContent retrieves a page of the annotations of a content item, oldest first
+func (r *PostgresRepository) ListAnnotationsByContent(ctx context.Context, contentID uuid.UUID, options repository.ListOptions) ([]*model.Annotation, int64, error) {
+	var total int64
+	if options.ReturnTotal {
+		if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM annotations WHERE content_id = $1`, contentID); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	query := `SELECT * FROM annotations WHERE content_id = $1 ORDER BY created_at`
+	args := queryArgs{contentID}
+	if options.PageSize > 0 {
+		query += " LIMIT " + args.add(options.PageSize) + " OFFSET " + args.add(options.Offset())
+	}
+
+	var dbAnnotations []annotationDB
+	if err := r.db.SelectContext(ctx, &dbAnnotations, query, args...); err != nil {
+		return nil, 0, err
+	}
+
+	annotations := make([]*model.Annotation, len(dbAnnotations))
+	for i := range dbAnnotations {
+		annotation, err := dbAnnotations[i].toModel()
+		if err != nil {
+			return nil, 0, err
+		}
+		annotations[i] = annotation
+	}
+
+	return annotations, total, nil
+}
+
+// UpdateAnnotation updates the body and anchor of an existing annotation
+func (r *PostgresRepository) UpdateAnnotation(ctx context.Context, annotation *model.Annotation) error {
+	annotation.UpdatedAt = r.clock.Now()
+
+	dbAnnotation, err := annotationFromModel(annotation)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE annotations SET
+			body = :body,
+			anchor = :anchor,
+			updated_at = :updated_at
+		WHERE id = :id
+	`
+
+	result, err := r.db.NamedExecContext(ctx, query, dbAnnotation)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrAnnotationNotFound
+	}
+
+	return nil
+}
+
+// DeleteAnnotation removes an annotation
+func (r *PostgresRepository) DeleteAnnotation(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM annotations WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrAnnotationNotFound
+	}
+
+	return nil
+}
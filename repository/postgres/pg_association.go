@@ -0,0 +1,489 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// associationDB is a database model for a content-entity association
+type associationDB struct {
+	ID                  uuid.UUID      `db:"id"`
+	ContentID           uuid.UUID      `db:"content_id"`
+	EntityType          string         `db:"entity_type"`
+	EntityID            string         `db:"entity_id"`
+	AssociationMetadata sql.NullString `db:"association_metadata"` // JSON stored as string
+	CreatedAt           time.Time      `db:"created_at"`
+	UpdatedAt           time.Time      `db:"updated_at"`
+	CreatedBy           string         `db:"created_by"`
+
+	ReviewState       string       `db:"review_state"` // Empty if the link needs no review
+	ReviewRequestedBy string       `db:"review_requested_by"`
+	ReviewRequestedAt sql.NullTime `db:"review_requested_at"`
+	Reviewer          string       `db:"reviewer"`
+	ReviewReason      string       `db:"review_reason"`
+	ReviewedAt        sql.NullTime `db:"reviewed_at"`
+
+	Position int `db:"position"`
+}
+
+// toModel converts a database model to a domain model
+func (a *associationDB) toModel() (*model.ContentEntityAssociation, error) {
+	association := &model.ContentEntityAssociation{
+		ID:         a.ID,
+		ContentID:  a.ContentID,
+		EntityType: a.EntityType,
+		EntityID:   a.EntityID,
+		CreatedAt:  a.CreatedAt,
+		UpdatedAt:  a.UpdatedAt,
+		CreatedBy:  a.CreatedBy,
+		Position:   a.Position,
+	}
+
+	if a.ReviewState != "" {
+		association.Review = &model.AssociationReview{
+			State:       model.ReviewState(a.ReviewState),
+			RequestedBy: a.ReviewRequestedBy,
+			RequestedAt: a.ReviewRequestedAt.Time,
+			Reviewer:    a.Reviewer,
+			Reason:      a.ReviewReason,
+		}
+		if a.ReviewedAt.Valid {
+			association.Review.DecidedAt = &a.ReviewedAt.Time
+		}
+	}
+
+	if a.AssociationMetadata.Valid {
+		if err := json.Unmarshal([]byte(a.AssociationMetadata.String), &association.AssociationMetadata); err != nil {
+			return nil, err
+		}
+	}
+
+	return association, nil
+}
+
+// associationFromModel converts a domain model to a database model
+func associationFromModel(association *model.ContentEntityAssociation) (*associationDB, error) {
+	dbAssociation := &associationDB{
+		ID:         association.ID,
+		ContentID:  association.ContentID,
+		EntityType: association.EntityType,
+		EntityID:   association.EntityID,
+		CreatedAt:  association.CreatedAt,
+		UpdatedAt:  association.UpdatedAt,
+		CreatedBy:  association.CreatedBy,
+		Position:   association.Position,
+	}
+
+	if review := association.Review; review != nil {
+		dbAssociation.ReviewState = string(review.State)
+		dbAssociation.ReviewRequestedBy = review.RequestedBy
+		dbAssociation.ReviewRequestedAt = sql.NullTime{Time: review.RequestedAt, Valid: true}
+		dbAssociation.Reviewer = review.Reviewer
+		dbAssociation.ReviewReason = review.Reason
+		if review.DecidedAt != nil {
+			dbAssociation.ReviewedAt = sql.NullTime{Time: *review.DecidedAt, Valid: true}
+		}
+	}
+
+	if len(association.AssociationMetadata) > 0 {
+		metadataBytes, err := json.Marshal(association.AssociationMetadata)
+		if err != nil {
+			return nil, err
+		}
+		dbAssociation.AssociationMetadata = sql.NullString{
+			String: string(metadataBytes),
+			Valid:  true,
+		}
+	}
+
+	return dbAssociation, nil
+}
+
+// CreateAssociation stores a new association
+func (r *PostgresRepository) CreateAssociation(ctx context.Context, association *model.ContentEntityAssociation) error {
+	if association.ID == uuid.Nil {
+		association.ID = r.ids.NewID()
+	}
+
+	now := r.clock.Now()
+	if association.CreatedAt.IsZero() {
+		association.CreatedAt = now
+	}
+	if association.UpdatedAt.IsZero() {
+		association.UpdatedAt = now
+	}
+
+	dbAssociation, err := associationFromModel(association)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO content_entity_associations (
+			id, content_id, entity_type, entity_id, association_metadata, created_at, updated_at, created_by,
+			review_state, review_requested_by, review_requested_at, reviewer, review_reason, reviewed_at, position
+		) VALUES (
+			:id, :content_id, :entity_type, :entity_id, :association_metadata, :created_at, :updated_at, :created_by,
+			:review_state, :review_requested_by, :review_requested_at, :reviewer, :review_reason, :reviewed_at, :position
+		)
+		ON CONFLICT (content_id, entity_type, entity_id) DO NOTHING
+	`
+
+	result, err := r.db.NamedExecContext(ctx, query, dbAssociation)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrAssociationExists
+	}
+
+	return nil
+}
+
+// getAssociation runs a query that selects a single association
+func (r *PostgresRepository) getAssociation(ctx context.Context, query string, args ...interface{}) (*model.ContentEntityAssociation, error) {
+	var dbAssociation associationDB
+	if err := r.db.GetContext(ctx, &dbAssociation, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrAssociationNotFound
+		}
+		return nil, err
+	}
+
+	return dbAssociation.toModel()
+}
+
+// GetAssociationByID retrieves an association by its ID
+func (r *PostgresRepository) GetAssociationByID(ctx context.Context, associationID uuid.UUID) (*model.ContentEntityAssociation, error) {
+	query := `
+		SELECT * FROM content_entity_associations
+		WHERE id = $1
+	`
+
+	return r.getAssociation(ctx, query, associationID)
+}
+
+// GetAssociationByLink retrieves the association between a content item and an entity
+func (r *PostgresRepository) GetAssociationByLink(ctx context.Context, contentID uuid.UUID, entityType, entityID string) (*model.ContentEntityAssociation, error) {
+	query := `
+		SELECT * FROM content_entity_associations
+		WHERE content_id = $1 AND entity_type = $2 AND entity_id = $3
+	`
+
+	return r.getAssociation(ctx, query, contentID, entityType, entityID)
+}
+
+// UpdateAssociation updates an existing association
+func (r *PostgresRepository) UpdateAssociation(ctx context.Context, association *model.ContentEntityAssociation) error {
+	association.UpdatedAt = r.clock.Now()
+
+	dbAssociation, err := associationFromModel(association)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE content_entity_associations SET
+			content_id = :content_id,
+			entity_type = :entity_type,
+			entity_id = :entity_id,
+			association_metadata = :association_metadata,
+			review_state = :review_state,
+			review_requested_by = :review_requested_by,
+			review_requested_at = :review_requested_at,
+			reviewer = :reviewer,
+			review_reason = :review_reason,
+			reviewed_at = :reviewed_at,
+			position = :position,
+			updated_at = :updated_at
+		WHERE id = :id
+	`
+
+	result, err := r.db.NamedExecContext(ctx, query, dbAssociation)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrAssociationNotFound
+	}
+
+	return nil
+}
+
+// DeleteAssociation removes an association
+func (r *PostgresRepository) DeleteAssociation(ctx context.Context, associationID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM content_entity_associations WHERE id = $1`, associationID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrAssociationNotFound
+	}
+
+	return nil
+}
+
+// DeleteAssociationsByEntity removes every association of an entity
+func (r *PostgresRepository) DeleteAssociationsByEntity(ctx context.Context, entityType, entityID string) ([]*model.ContentEntityAssociation, error) {
+	query := `
+		DELETE FROM content_entity_associations
+		WHERE entity_type = $1 AND entity_id = $2
+		RETURNING *
+	`
+
+	var dbAssociations []associationDB
+	if err := r.db.SelectContext(ctx, &dbAssociations, query, entityType, entityID); err != nil {
+		return nil, err
+	}
+
+	deleted := make([]*model.ContentEntityAssociation, len(dbAssociations))
+	for i, dbAssociation := range dbAssociations {
+		association, err := dbAssociation.toModel()
+		if err != nil {
+			return nil, err
+		}
+		deleted[i] = association
+	}
+	return deleted, nil
+}
+
+// entityContentFrom returns the FROM clause joining the content of an entity
+// to its associations, with its arguments
+func entityContentFrom(entityType string, entityID string, options repository.ListOptions) (string, queryArgs) {
+	from := `
+		FROM contents c
+		JOIN content_entity_associations a ON a.content_id = c.id::text
+		WHERE a.entity_type = $1 AND a.entity_id = $2 AND c.deleted_at IS NULL
+	`
+	args := queryArgs{entityType, entityID}
+	if options.CreatedBy != "" {
+		from += " AND c.created_by = " + args.add(options.CreatedBy)
+	}
+	if !options.IncludeDrafts {
+		from += " AND c.draft_expires_at IS NULL"
+	}
+	if options.PinnedBy != "" {
+		from += " AND EXISTS (SELECT 1 FROM content_pins p WHERE p.content_id = c.id AND p.principal = " + args.add(options.PinnedBy) + ")"
+	}
+	return from, args
+}
+
+// selectEntityContent runs a listing of the content of an entity into dest,
+// counting the matching rows if requested
+func (r *PostgresRepository) selectEntityContent(ctx context.Context, dest interface{}, columns string, entityType string, entityID string, options repository.ListOptions) (int64, error) {
+	from, args := entityContentFrom(entityType, entityID, options)
+
+	var total int64
+	if options.ReturnTotal {
+		if err := r.db.GetContext(ctx, &total, "SELECT COUNT(*) "+from, args...); err != nil {
+			return 0, err
+		}
+	}
+
+	query := "SELECT " + columns + from + " ORDER BY c.created_at DESC"
+	if options.SortBy == repository.SortByPosition {
+		// Content that was never placed goes after the placed content
+		query = "SELECT " + columns + from + " ORDER BY a.position = 0, a.position, c.created_at DESC"
+	}
+	if options.PageSize > 0 {
+		query += " LIMIT " + args.add(options.PageSize) + " OFFSET " + args.add(options.Offset())
+	}
+	return total, r.db.SelectContext(ctx, dest, query, args...)
+}
+
+// ReorderAssociations places the given content of an entity in order in a
+// single transaction
+func (r *PostgresRepository) ReorderAssociations(ctx context.Context, entityType, entityID string, contentIDs []uuid.UUID) error {
+	ids := make([]string, len(contentIDs))
+	for i, id := range contentIDs {
+		ids[i] = id.String()
+	}
+
+	return r.WithTx(ctx, func(tx repository.ContentRepository) error {
+		pg := tx.(*PostgresRepository)
+		now := r.clock.Now()
+
+		if len(ids) > 0 {
+			// The ordinality of each ID in the array is its position
+			result, err := pg.db.ExecContext(ctx, `
+				UPDATE content_entity_associations a SET position = o.position, updated_at = $4
+				FROM unnest($3::text[]) WITH ORDINALITY AS o(content_id, position)
+				WHERE a.entity_type = $1 AND a.entity_id = $2 AND a.content_id = o.content_id
+			`, entityType, entityID, "{"+strings.Join(ids, ",")+"}", now)
+			if err != nil {
+				return err
+			}
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if rowsAffected != int64(len(ids)) {
+				return repository.ErrAssociationNotFound
+			}
+		}
+
+		_, err := pg.db.ExecContext(ctx, `
+			UPDATE content_entity_associations SET position = 0, updated_at = $4
+			WHERE entity_type = $1 AND entity_id = $2 AND position <> 0 AND content_id <> ALL($3::text[])
+		`, entityType, entityID, "{"+strings.Join(ids, ",")+"}", now)
+		return err
+	})
+}
+
+// ListContentByEntity retrieves the content items associated with an entity
+func (r *PostgresRepository) ListContentByEntity(ctx context.Context, entityType string, entityID string, options repository.ListOptions) ([]*model.Content, int64, error) {
+	var dbContents []contentDB
+	total, err := r.selectEntityContent(ctx, &dbContents, "c.*", entityType, entityID, options)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	contents := make([]*model.Content, len(dbContents))
+	for i, dbContent := range dbContents {
+		content, err := dbContent.toModel()
+		if err != nil {
+			return nil, 0, err
+		}
+		contents[i] = content
+	}
+
+	return contents, total, nil
+}
+
+// entityContentDB is a content row joined with the association to its entity
+type entityContentDB struct {
+	contentDB
+	Association associationDB `db:"a"`
+}
+
+// entityContentColumns selects the content columns, and the association
+// columns under the "a." prefix sqlx maps to entityContentDB.Association
+const entityContentColumns = `c.*,
+	a.id AS "a.id", a.content_id AS "a.content_id", a.entity_type AS "a.entity_type", a.entity_id AS "a.entity_id",
+	a.association_metadata AS "a.association_metadata", a.created_at AS "a.created_at", a.updated_at AS "a.updated_at",
+	a.created_by AS "a.created_by", a.review_state AS "a.review_state", a.review_requested_by AS "a.review_requested_by",
+	a.review_requested_at AS "a.review_requested_at", a.reviewer AS "a.reviewer", a.review_reason AS "a.review_reason",
+	a.reviewed_at AS "a.reviewed_at", a.position AS "a.position"
+`
+
+// ListEntityContents retrieves the content items associated with an entity
+// along with their associations in a single joined query
+func (r *PostgresRepository) ListEntityContents(ctx context.Context, entityType string, entityID string, options repository.ListOptions) ([]*model.EntityContent, int64, error) {
+	var rows []entityContentDB
+	total, err := r.selectEntityContent(ctx, &rows, entityContentColumns, entityType, entityID, options)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	contents := make([]*model.EntityContent, len(rows))
+	for i, row := range rows {
+		content, err := row.contentDB.toModel()
+		if err != nil {
+			return nil, 0, err
+		}
+		association, err := row.Association.toModel()
+		if err != nil {
+			return nil, 0, err
+		}
+		contents[i] = &model.EntityContent{Content: content, Association: association}
+	}
+
+	return contents, total, nil
+}
+
+// ListAssociationsByEntity retrieves the associations of an entity
+func (r *PostgresRepository) ListAssociationsByEntity(ctx context.Context, entityType string, entityID string, options repository.ListOptions) ([]*model.ContentEntityAssociation, int64, error) {
+	return r.listAssociations(ctx, "entity_type = $1 AND entity_id = $2", queryArgs{entityType, entityID}, options)
+}
+
+// ListAssociationsByContent retrieves the associations of a content item
+func (r *PostgresRepository) ListAssociationsByContent(ctx context.Context, contentID uuid.UUID, options repository.ListOptions) ([]*model.ContentEntityAssociation, int64, error) {
+	return r.listAssociations(ctx, "content_id = $1", queryArgs{contentID}, options)
+}
+
+// ListEntityIDs retrieves the distinct IDs of the linked entities of a type
+func (r *PostgresRepository) ListEntityIDs(ctx context.Context, entityType string, options repository.ListOptions) ([]string, int64, error) {
+	var total int64
+	if options.ReturnTotal {
+		if err := r.db.GetContext(ctx, &total, "SELECT COUNT(DISTINCT entity_id) FROM content_entity_associations WHERE entity_type = $1", entityType); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	query := "SELECT DISTINCT entity_id FROM content_entity_associations WHERE entity_type = $1 ORDER BY entity_id"
+	args := queryArgs{entityType}
+	if options.PageSize > 0 {
+		query += " LIMIT " + args.add(options.PageSize) + " OFFSET " + args.add(options.Offset())
+	}
+
+	var entityIDs []string
+	if err := r.db.SelectContext(ctx, &entityIDs, query, args...); err != nil {
+		return nil, 0, err
+	}
+	return entityIDs, total, nil
+}
+
+// ListAssociationsByReviewState retrieves the associations in a review state
+func (r *PostgresRepository) ListAssociationsByReviewState(ctx context.Context, entityType string, state model.ReviewState, options repository.ListOptions) ([]*model.ContentEntityAssociation, int64, error) {
+	if entityType == "" {
+		return r.listAssociations(ctx, "review_state = $1", queryArgs{string(state)}, options)
+	}
+	return r.listAssociations(ctx, "review_state = $1 AND entity_type = $2", queryArgs{string(state), entityType}, options)
+}
+
+// listAssociations returns a page of the associations matching where, newest first
+func (r *PostgresRepository) listAssociations(ctx context.Context, where string, args queryArgs, options repository.ListOptions) ([]*model.ContentEntityAssociation, int64, error) {
+	var total int64
+	if options.ReturnTotal {
+		if err := r.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM content_entity_associations WHERE "+where, args...); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	query := "SELECT * FROM content_entity_associations WHERE " + where + " ORDER BY created_at DESC"
+	if options.PageSize > 0 {
+		query += " LIMIT " + args.add(options.PageSize) + " OFFSET " + args.add(options.Offset())
+	}
+
+	var dbAssociations []associationDB
+	if err := r.db.SelectContext(ctx, &dbAssociations, query, args...); err != nil {
+		return nil, 0, err
+	}
+
+	associations := make([]*model.ContentEntityAssociation, len(dbAssociations))
+	for i, dbAssociation := range dbAssociations {
+		association, err := dbAssociation.toModel()
+		if err != nil {
+			return nil, 0, err
+		}
+		associations[i] = association
+	}
+
+	return associations, total, nil
+}
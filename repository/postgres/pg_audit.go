@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+)
+
+// RecordAuditEvent stores an audit event. If the partition of its month is
+// missing, e.g. because EnsureAuditPartitions didn't run in time, the
+// partition is created and the event stored again.
+func (r *PostgresRepository) RecordAuditEvent(ctx context.Context, event *model.AuditEvent) error {
+	if event.ID == uuid.Nil {
+		event.ID = r.ids.NewID()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = r.clock.Now()
+	}
+
+	query := `
+		INSERT INTO audit_events (id, action, subject, actor, detail, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	insert := func() error {
+		_, err := r.db.ExecContext(ctx, query, event.ID, event.Action, event.Subject, event.Actor, event.Detail, event.OccurredAt)
+		return err
+	}
+	err := insert()
+	if isNoPartition(err) {
+		if err := r.EnsureAuditPartitions(ctx, event.OccurredAt, event.OccurredAt); err != nil {
+			return err
+		}
+		err = insert()
+	}
+	return err
+}
+
+// PruneAuditEvents removes the audit events that occurred before a cutoff,
+// dropping the monthly partitions that only hold such events
+func (r *PostgresRepository) PruneAuditEvents(ctx context.Context, before time.Time) (int64, error) {
+	pruned, err := r.pruneMonthlyPartitions(ctx, auditTable, before)
+	if err != nil {
+		return pruned, err
+	}
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM audit_events WHERE occurred_at < $1", before)
+	if err != nil {
+		return pruned, err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return pruned, err
+	}
+	return pruned + deleted, nil
+}
+
+// isNoPartition reports whether an insert failed because no partition
+// holds the row
+func isNoPartition(err error) bool {
+	var pgErr interface {
+		SQLState() string
+		Error() string
+	}
+	return errors.As(err, &pgErr) && pgErr.SQLState() == "23514" && strings.Contains(pgErr.Error(), "no partition")
+}
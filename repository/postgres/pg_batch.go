@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// insertBatchSize is the number of rows per INSERT of a batch, keeping the
+// parameters of a statement well under the Postgres limit of 65535
+const insertBatchSize = 500
+
+// CreateContentBatch stores several new content items with multi-row
+// INSERTs in a single transaction
+func (r *PostgresRepository) CreateContentBatch(ctx context.Context, contents []*model.Content) error {
+	now := r.clock.Now()
+	rows := make([]*contentDB, len(contents))
+	for i, content := range contents {
+		if content.ID == uuid.Nil {
+			content.ID = r.ids.NewID()
+		}
+		if content.CreatedAt.IsZero() {
+			content.CreatedAt = now
+		}
+		if content.UpdatedAt.IsZero() {
+			content.UpdatedAt = now
+		}
+
+		dbContent, err := fromModel(content)
+		if err != nil {
+			return err
+		}
+		rows[i] = dbContent
+	}
+
+	return r.WithTx(ctx, func(tx repository.ContentRepository) error {
+		pg := tx.(*PostgresRepository)
+		for start := 0; start < len(rows); start += insertBatchSize {
+			batch := rows[start:min(start+insertBatchSize, len(rows))]
+			if err := pg.insertExternalIDs(ctx, batch); err != nil {
+				return err
+			}
+			if err := pg.insertContents(ctx, batch); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// insertContents inserts rows with a single multi-row INSERT
+func (r *PostgresRepository) insertContents(ctx context.Context, rows []*contentDB) error {
+	var args queryArgs
+	values := make([]string, len(rows))
+	for i, row := range rows {
+		values[i] = "(" + strings.Join([]string{
+			args.add(row.ID), args.add(row.TenantID), args.add(row.Status), args.add(row.Name), args.add(row.Description),
+			args.add(row.MIMEType), args.add(row.FileSize), args.add(row.Path), args.add(row.OriginalPath), args.add(row.ETag),
+			args.add(row.DerivedFromID), args.add(row.Derivation), args.add(row.Metadata), args.add(row.CreatedAt), args.add(row.UpdatedAt),
+			args.add(row.CallbackURL), args.add(row.CallbackSentAt), args.add(row.CreatedBy), args.add(row.Source), args.add(row.ExternalID),
+			args.add(row.Kind), args.add(row.ReferenceURL), args.add(row.ReferenceStatus), args.add(row.ReferenceCheckedAt),
+			args.add(row.DraftExpiresAt),
+		}, ", ") + ")"
+	}
+
+	query := `
+		INSERT INTO contents (
+			id, tenant_id, status, name, description, content_type, size, path, original_path, etag, derived_from_id, derivation, metadata, created_at, updated_at,
+			callback_url, callback_sent_at, created_by, source, external_id, kind, reference_url, reference_status, reference_checked_at, draft_expires_at
+		) VALUES ` + strings.Join(values, ", ")
+
+	_, err := r.db.ExecContext(ctx, query, args...)
+	if isUniqueViolation(err) {
+		return repository.ErrContentExists
+	}
+	return err
+}
+
+// GetContentsByIDs retrieves the content items with the given IDs in one query
+func (r *PostgresRepository) GetContentsByIDs(ctx context.Context, ids []uuid.UUID) ([]*model.Content, error) {
+	if len(ids) == 0 {
+		return []*model.Content{}, nil
+	}
+
+	// A single array parameter keeps the query text, and its prepared
+	// statement, the same for any number of IDs
+	elements := make([]string, len(ids))
+	for i, id := range ids {
+		elements[i] = id.String()
+	}
+	query := "SELECT * FROM contents WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL"
+
+	var dbContents []contentDB
+	if err := r.reader(ctx).SelectContext(ctx, &dbContents, query, "{"+strings.Join(elements, ",")+"}"); err != nil {
+		return nil, err
+	}
+
+	contents := make([]*model.Content, len(dbContents))
+	for i, dbContent := range dbContents {
+		content, err := dbContent.toModel()
+		if err != nil {
+			return nil, err
+		}
+		contents[i] = content
+	}
+	return contents, nil
+}
@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+)
+
+// pendingDeletionDB is a database model for a pending deletion
+type pendingDeletionDB struct {
+	ID          uuid.UUID `db:"id"`
+	TenantID    string    `db:"tenant_id"`
+	StoragePath string    `db:"storage_path"`
+	QueuedAt    time.Time `db:"queued_at"`
+}
+
+// QueueDeletion stores a deletion to retry
+func (r *PostgresRepository) QueueDeletion(ctx context.Context, deletion *model.PendingDeletion) error {
+	if deletion.ID == uuid.Nil {
+		deletion.ID = r.ids.NewID()
+	}
+	if deletion.QueuedAt.IsZero() {
+		deletion.QueuedAt = r.clock.Now().UTC()
+	}
+
+	query := `
+		INSERT INTO pending_deletions (id, tenant_id, storage_path, queued_at)
+		VALUES ($1, $2, $3, $4)
+	`
+	_, err := r.db.ExecContext(ctx, query, deletion.ID, deletion.TenantID, deletion.StoragePath, deletion.QueuedAt)
+	return err
+}
+
+// ListPendingDeletions returns up to limit queued deletions, oldest first
+func (r *PostgresRepository) ListPendingDeletions(ctx context.Context, limit int) ([]*model.PendingDeletion, error) {
+	var dbDeletions []pendingDeletionDB
+	query := `SELECT * FROM pending_deletions ORDER BY queued_at, id LIMIT $1`
+	if err := r.db.SelectContext(ctx, &dbDeletions, query, limit); err != nil {
+		return nil, err
+	}
+
+	deletions := make([]*model.PendingDeletion, len(dbDeletions))
+	for i, d := range dbDeletions {
+		deletions[i] = &model.PendingDeletion{
+			ID:          d.ID,
+			TenantID:    d.TenantID,
+			StoragePath: d.StoragePath,
+			QueuedAt:    d.QueuedAt,
+		}
+	}
+	return deletions, nil
+}
+
+// RemovePendingDeletion removes a deletion that has been carried out
+func (r *PostgresRepository) RemovePendingDeletion(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM pending_deletions WHERE id = $1`, id)
+	return err
+}
@@ -0,0 +1,212 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// documentDB is a database model for a localized document
+type documentDB struct {
+	ID              uuid.UUID `db:"id"`
+	TenantID        string    `db:"tenant_id"`
+	Name            string    `db:"name"`
+	DefaultLanguage string    `db:"default_language"`
+	CreatedAt       time.Time `db:"created_at"`
+	UpdatedAt       time.Time `db:"updated_at"`
+}
+
+// toModel converts a database model to a domain model
+func (d *documentDB) toModel() *model.Document {
+	return &model.Document{
+		ID:              d.ID,
+		TenantID:        d.TenantID,
+		Name:            d.Name,
+		DefaultLanguage: d.DefaultLanguage,
+		CreatedAt:       d.CreatedAt,
+		UpdatedAt:       d.UpdatedAt,
+	}
+}
+
+// documentVariantDB is a database model for the variant of a document in a language
+type documentVariantDB struct {
+	Language  string    `db:"language"`
+	ContentID uuid.UUID `db:"content_id"`
+}
+
+// CreateDocument stores a new document
+func (r *PostgresRepository) CreateDocument(ctx context.Context, document *model.Document) error {
+	if document.ID == uuid.Nil {
+		document.ID = r.ids.NewID()
+	}
+
+	now := r.clock.Now()
+	document.CreatedAt = now
+	document.UpdatedAt = now
+	if document.Variants == nil {
+		document.Variants = []model.DocumentVariant{}
+	}
+
+	query := `
+		INSERT INTO documents (id, tenant_id, name, default_language, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := r.db.ExecContext(ctx, query, document.ID, document.TenantID, document.Name, document.DefaultLanguage, document.CreatedAt, document.UpdatedAt)
+	return err
+}
+
+// GetDocument retrieves a document with its variants
+func (r *PostgresRepository) GetDocument(ctx context.Context, id uuid.UUID) (*model.Document, error) {
+	var dbDocument documentDB
+	if err := r.db.GetContext(ctx, &dbDocument, `SELECT * FROM documents WHERE id = $1`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrDocumentNotFound
+		}
+		return nil, err
+	}
+
+	var dbVariants []documentVariantDB
+	query := `SELECT language, content_id FROM document_variants WHERE document_id = $1 ORDER BY language`
+	if err := r.db.SelectContext(ctx, &dbVariants, query, id); err != nil {
+		return nil, err
+	}
+
+	document := dbDocument.toModel()
+	document.Variants = make([]model.DocumentVariant, len(dbVariants))
+	for i, dbVariant := range dbVariants {
+		document.Variants[i] = model.DocumentVariant{Language: dbVariant.Language, ContentID: dbVariant.ContentID}
+	}
+	return document, nil
+}
+
+// ListDocuments retrieves a page of the documents of a tenant ordered by name
+func (r *PostgresRepository) ListDocuments(ctx context.Context, tenantID string, options repository.ListOptions) ([]*model.Document, int64, error) {
+	var total int64
+	if options.ReturnTotal {
+		if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM documents WHERE tenant_id = $1`, tenantID); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	query := `SELECT * FROM documents WHERE tenant_id = $1 ORDER BY name, id`
+	args := queryArgs{tenantID}
+	if options.PageSize > 0 {
+		query += " LIMIT " + args.add(options.PageSize) + " OFFSET " + args.add(options.Offset())
+	}
+
+	var dbDocuments []documentDB
+	if err := r.db.SelectContext(ctx, &dbDocuments, query, args...); err != nil {
+		return nil, 0, err
+	}
+
+	documents := make([]*model.Document, len(dbDocuments))
+	for i, dbDocument := range dbDocuments {
+		documents[i] = dbDocument.toModel()
+	}
+	return documents, total, nil
+}
+
+// UpdateDocument updates the name and default language of a document
+func (r *PostgresRepository) UpdateDocument(ctx context.Context, document *model.Document) error {
+	document.UpdatedAt = r.clock.Now()
+
+	query := `UPDATE documents SET name = $2, default_language = $3, updated_at = $4 WHERE id = $1`
+	result, err := r.db.ExecContext(ctx, query, document.ID, document.Name, document.DefaultLanguage, document.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrDocumentNotFound
+	}
+
+	return nil
+}
+
+// DeleteDocument removes a document and its variants
+func (r *PostgresRepository) DeleteDocument(ctx context.Context, id uuid.UUID) error {
+	return r.WithTx(ctx, func(tx repository.ContentRepository) error {
+		pg := tx.(*PostgresRepository)
+		if _, err := pg.db.ExecContext(ctx, `DELETE FROM document_variants WHERE document_id = $1`, id); err != nil {
+			return err
+		}
+
+		result, err := pg.db.ExecContext(ctx, `DELETE FROM documents WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return repository.ErrDocumentNotFound
+		}
+
+		return nil
+	})
+}
+
+// SetDocumentVariant adds the variant of a language or replaces its content
+func (r *PostgresRepository) SetDocumentVariant(ctx context.Context, documentID uuid.UUID, variant model.DocumentVariant) error {
+	return r.WithTx(ctx, func(tx repository.ContentRepository) error {
+		pg := tx.(*PostgresRepository)
+
+		// Touching the document first also checks that it exists
+		result, err := pg.db.ExecContext(ctx, `UPDATE documents SET updated_at = $2 WHERE id = $1`, documentID, r.clock.Now())
+		if err != nil {
+			return err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return repository.ErrDocumentNotFound
+		}
+
+		query := `
+			INSERT INTO document_variants (document_id, language, content_id)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (document_id, language) DO UPDATE SET content_id = EXCLUDED.content_id
+		`
+		_, err = pg.db.ExecContext(ctx, query, documentID, variant.Language, variant.ContentID)
+		return err
+	})
+}
+
+// DeleteDocumentVariant removes the variant of a language
+func (r *PostgresRepository) DeleteDocumentVariant(ctx context.Context, documentID uuid.UUID, language string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM document_variants WHERE document_id = $1 AND language = $2`, documentID, language)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		// Tell a missing document from a missing variant
+		if _, err := r.GetDocument(ctx, documentID); err != nil {
+			return err
+		}
+		return repository.ErrVariantNotFound
+	}
+
+	_, err = r.db.ExecContext(ctx, `UPDATE documents SET updated_at = $2 WHERE id = $1`, documentID, r.clock.Now())
+	return err
+}
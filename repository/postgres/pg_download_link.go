@@ -0,0 +1,151 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// downloadLinkDB is a database model for a download link
+type downloadLinkDB struct {
+	Token         string       `db:"token"`
+	ContentID     uuid.UUID    `db:"content_id"`
+	Mode          string       `db:"mode"`
+	CreatedBy     string       `db:"created_by"`
+	ExpiresAt     time.Time    `db:"expires_at"`
+	Clicks        int64        `db:"clicks"`
+	LastClickedAt sql.NullTime `db:"last_clicked_at"`
+	CreatedAt     time.Time    `db:"created_at"`
+}
+
+// toModel converts a database model to a domain model
+func (l *downloadLinkDB) toModel() *model.DownloadLink {
+	link := &model.DownloadLink{
+		Token:     l.Token,
+		ContentID: l.ContentID,
+		Mode:      model.DownloadLinkMode(l.Mode),
+		CreatedBy: l.CreatedBy,
+		ExpiresAt: l.ExpiresAt,
+		Clicks:    l.Clicks,
+		CreatedAt: l.CreatedAt,
+	}
+	if l.LastClickedAt.Valid {
+		link.LastClickedAt = &l.LastClickedAt.Time
+	}
+	return link
+}
+
+// CreateDownloadLink stores a new download link
+func (r *PostgresRepository) CreateDownloadLink(ctx context.Context, link *model.DownloadLink) error {
+	link.CreatedAt = r.clock.Now()
+
+	query := `
+		INSERT INTO download_links (
+			token, content_id, mode, created_by, expires_at, clicks, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, 0, $6
+		)
+		ON CONFLICT (token) DO NOTHING
+	`
+
+	result, err := r.db.ExecContext(ctx, query, link.Token, link.ContentID, string(link.Mode), link.CreatedBy, link.ExpiresAt, link.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrDownloadLinkExists
+	}
+
+	return nil
+}
+
+// GetDownloadLink retrieves a download link by its token
+func (r *PostgresRepository) GetDownloadLink(ctx context.Context, token string) (*model.DownloadLink, error) {
+	var dbLink downloadLinkDB
+	if err := r.db.GetContext(ctx, &dbLink, `SELECT * FROM download_links WHERE token = $1`, token); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrDownloadLinkNotFound
+		}
+		return nil, err
+	}
+
+	return dbLink.toModel(), nil
+}
+
+// ListDownloadLinksByContent retrieves a page of the links of a content item, newest first
+func (r *PostgresRepository) ListDownloadLinksByContent(ctx context.Context, contentID uuid.UUID, options repository.ListOptions) ([]*model.DownloadLink, int64, error) {
+	var total int64
+	if options.ReturnTotal {
+		if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM download_links WHERE content_id = $1`, contentID); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	query := `SELECT * FROM download_links WHERE content_id = $1 ORDER BY created_at DESC`
+	args := queryArgs{contentID}
+	if options.PageSize > 0 {
+		query += " LIMIT " + args.add(options.PageSize) + " OFFSET " + args.add(options.Offset())
+	}
+
+	var dbLinks []downloadLinkDB
+	if err := r.db.SelectContext(ctx, &dbLinks, query, args...); err != nil {
+		return nil, 0, err
+	}
+
+	links := make([]*model.DownloadLink, len(dbLinks))
+	for i := range dbLinks {
+		links[i] = dbLinks[i].toModel()
+	}
+
+	return links, total, nil
+}
+
+// RecordDownloadLinkClick atomically counts a redemption of a download link
+func (r *PostgresRepository) RecordDownloadLinkClick(ctx context.Context, token string, clickedAt time.Time) error {
+	query := `UPDATE download_links SET clicks = clicks + 1, last_clicked_at = $2 WHERE token = $1`
+	result, err := r.db.ExecContext(ctx, query, token, clickedAt)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrDownloadLinkNotFound
+	}
+
+	return nil
+}
+
+// DeleteDownloadLink removes a download link
+func (r *PostgresRepository) DeleteDownloadLink(ctx context.Context, token string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM download_links WHERE token = $1`, token)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrDownloadLinkNotFound
+	}
+
+	return nil
+}
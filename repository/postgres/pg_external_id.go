@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// GetContentByExternalID retrieves the content a tenant created from a
+// source under an external ID
+func (r *PostgresRepository) GetContentByExternalID(ctx context.Context, tenantID, source, externalID string) (*model.Content, error) {
+	query := `
+		SELECT c.* FROM content_external_ids e
+		JOIN contents c ON c.id = e.content_id
+		WHERE e.tenant_id = $1 AND e.source = $2 AND e.external_id = $3 AND c.deleted_at IS NULL
+	`
+
+	var dbContent contentDB
+	if err := r.reader(ctx).GetContext(ctx, &dbContent, query, tenantID, source, externalID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrContentNotFound
+		}
+		return nil, err
+	}
+
+	return dbContent.toModel()
+}
+
+// insertExternalIDs reserves the external IDs of the rows that have one,
+// failing with ErrExternalIDExists if any is taken. It must run in the
+// transaction inserting the rows.
+func (r *PostgresRepository) insertExternalIDs(ctx context.Context, rows []*contentDB) error {
+	var args queryArgs
+	var values []string
+	for _, row := range rows {
+		if row.ExternalID == "" {
+			continue
+		}
+		values = append(values, "("+strings.Join([]string{
+			args.add(row.TenantID), args.add(row.Source), args.add(row.ExternalID), args.add(row.ID),
+		}, ", ")+")")
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	query := "INSERT INTO content_external_ids (tenant_id, source, external_id, content_id) VALUES " + strings.Join(values, ", ")
+	_, err := r.db.ExecContext(ctx, query, args...)
+	if isUniqueViolation(err) {
+		return repository.ErrExternalIDExists
+	}
+	return err
+}
+
+// pruneExternalIDs removes the external IDs of content that no longer exists
+func (r *PostgresRepository) pruneExternalIDs(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM content_external_ids e
+		WHERE NOT EXISTS (SELECT 1 FROM contents c WHERE c.id = e.content_id)
+	`)
+	return err
+}
@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// jobDB is a database model for a job
+type jobDB struct {
+	ID              uuid.UUID      `db:"id"`
+	Kind            string         `db:"kind"`
+	TenantID        string         `db:"tenant_id"`
+	ContentID       *uuid.UUID     `db:"content_id"`
+	Status          string         `db:"status"`
+	Progress        int            `db:"progress"`
+	Done            int64          `db:"done"`
+	Total           int64          `db:"total"`
+	CancelRequested bool           `db:"cancel_requested"`
+	Input           sql.NullString `db:"input"`  // JSON stored as string
+	Result          sql.NullString `db:"result"` // JSON stored as string
+	Error           string         `db:"error"`
+	CreatedAt       time.Time      `db:"created_at"`
+	UpdatedAt       time.Time      `db:"updated_at"`
+	FinishedAt      *time.Time     `db:"finished_at"`
+}
+
+// toModel converts a database model to a domain model
+func (j *jobDB) toModel() *model.Job {
+	job := &model.Job{
+		ID:              j.ID,
+		Kind:            j.Kind,
+		TenantID:        j.TenantID,
+		ContentID:       j.ContentID,
+		Status:          model.JobStatus(j.Status),
+		Progress:        j.Progress,
+		Done:            j.Done,
+		Total:           j.Total,
+		CancelRequested: j.CancelRequested,
+		Error:           j.Error,
+		CreatedAt:       j.CreatedAt,
+		UpdatedAt:       j.UpdatedAt,
+		FinishedAt:      j.FinishedAt,
+	}
+	if j.Input.Valid {
+		job.Input = json.RawMessage(j.Input.String)
+	}
+	if j.Result.Valid {
+		job.Result = json.RawMessage(j.Result.String)
+	}
+	return job
+}
+
+// jobFromModel converts a domain model to a database model
+func jobFromModel(job *model.Job) *jobDB {
+	return &jobDB{
+		ID:              job.ID,
+		Kind:            job.Kind,
+		TenantID:        job.TenantID,
+		ContentID:       job.ContentID,
+		Status:          string(job.Status),
+		Progress:        job.Progress,
+		Done:            job.Done,
+		Total:           job.Total,
+		CancelRequested: job.CancelRequested,
+		Input:           sql.NullString{String: string(job.Input), Valid: len(job.Input) > 0},
+		Result:          sql.NullString{String: string(job.Result), Valid: len(job.Result) > 0},
+		Error:           job.Error,
+		CreatedAt:       job.CreatedAt,
+		UpdatedAt:       job.UpdatedAt,
+		FinishedAt:      job.FinishedAt,
+	}
+}
+
+// CreateJob stores a new job
+func (r *PostgresRepository) CreateJob(ctx context.Context, job *model.Job) error {
+	if job.ID == uuid.Nil {
+		job.ID = r.ids.NewID()
+	}
+	now := r.clock.Now()
+	job.CreatedAt = now
+	job.UpdatedAt = now
+
+	query := `
+		INSERT INTO jobs (
+			id, kind, tenant_id, content_id, status, progress, done, total, cancel_requested,
+			input, result, error, created_at, updated_at, finished_at
+		) VALUES (
+			:id, :kind, :tenant_id, :content_id, :status, :progress, :done, :total, :cancel_requested,
+			:input, :result, :error, :created_at, :updated_at, :finished_at
+		)
+	`
+
+	_, err := r.db.NamedExecContext(ctx, query, jobFromModel(job))
+	return err
+}
+
+// GetJob retrieves a job by its ID
+func (r *PostgresRepository) GetJob(ctx context.Context, id uuid.UUID) (*model.Job, error) {
+	var dbJob jobDB
+	if err := r.db.GetContext(ctx, &dbJob, `SELECT * FROM jobs WHERE id = $1`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrJobNotFound
+		}
+		return nil, err
+	}
+
+	return dbJob.toModel(), nil
+}
+
+// UpdateJob records the state of a job, leaving a cancellation request in
+// place. The job gets the stored request back so its runner sees it.
+func (r *PostgresRepository) UpdateJob(ctx context.Context, job *model.Job) error {
+	job.UpdatedAt = r.clock.Now()
+
+	dbJob := jobFromModel(job)
+	query := `
+		UPDATE jobs SET
+			status = $2, progress = $3, done = $4, total = $5,
+			cancel_requested = cancel_requested OR $6,
+			result = $7, error = $8, updated_at = $9, finished_at = $10
+		WHERE id = $1
+		RETURNING cancel_requested
+	`
+
+	err := r.db.GetContext(ctx, &job.CancelRequested, query,
+		dbJob.ID, dbJob.Status, dbJob.Progress, dbJob.Done, dbJob.Total,
+		dbJob.CancelRequested, dbJob.Result, dbJob.Error, dbJob.UpdatedAt, dbJob.FinishedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.ErrJobNotFound
+	}
+	return err
+}
+
+// RequestJobCancel flags a job to be cancelled by the instance running it
+func (r *PostgresRepository) RequestJobCancel(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE jobs SET cancel_requested = TRUE, updated_at = $2 WHERE id = $1`, id, r.clock.Now())
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrJobNotFound
+	}
+
+	return nil
+}
+
+// FailStaleJobs fails the unfinished jobs last updated before a cutoff
+func (r *PostgresRepository) FailStaleJobs(ctx context.Context, updatedBefore time.Time, reason string) (int64, error) {
+	now := r.clock.Now().UTC()
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE jobs SET status = $2, error = $3, updated_at = $4, finished_at = $4
+		WHERE finished_at IS NULL AND updated_at < $1
+	`, updatedBefore, string(model.JobFailed), reason, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// PruneJobs removes the jobs that finished before a cutoff
+func (r *PostgresRepository) PruneJobs(ctx context.Context, finishedBefore time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM jobs WHERE finished_at < $1`, finishedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// pgLock is a session-level advisory lock, held by a connection taken out
+// of the pool until the lock is released
+type pgLock struct {
+	conn *sqlx.Conn
+	name string
+}
+
+// TryLock takes the advisory lock keyed by the hash of a name, or returns
+// ErrLockHeld if another session has it
+func (r *PostgresRepository) TryLock(ctx context.Context, name string) (repository.Lock, error) {
+	if r.conn == nil {
+		return nil, errors.New("locks can't be taken within a transaction")
+	}
+	conn, err := r.conn.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var acquired bool
+	if err := conn.GetContext(ctx, &acquired, `SELECT pg_try_advisory_lock(hashtextextended($1, 0))`, name); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !acquired {
+		conn.Close()
+		return nil, repository.ErrLockHeld
+	}
+	return &pgLock{conn: conn, name: name}, nil
+}
+
+// Held reports whether the session holding the lock is still alive
+func (l *pgLock) Held(ctx context.Context) bool {
+	return l.conn.PingContext(ctx) == nil
+}
+
+// Release unlocks the lock and returns its connection to the pool
+func (l *pgLock) Release(ctx context.Context) error {
+	defer l.conn.Close()
+	_, err := l.conn.ExecContext(ctx, `SELECT pg_advisory_unlock(hashtextextended($1, 0))`, l.name)
+	return err
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// metadataIndexKey matches the metadata keys that can be indexed. The key is
+// written into the index name and expression, so it must be a plain
+// identifier short enough for the name to fit in 63 bytes.
+var metadataIndexKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,39}$`)
+
+// validateMetadataIndexKeys checks that keys can be indexed
+func validateMetadataIndexKeys(keys []string) error {
+	for _, key := range keys {
+		if !metadataIndexKey.MatchString(key) {
+			return fmt.Errorf("metadata key %q can't be indexed: keys must be identifiers of at most 40 characters", key)
+		}
+	}
+	return nil
+}
+
+// ConfigureMetadataIndexes declares the metadata keys that have an
+// expression index, created by the migration MetadataIndexMigration
+// generates. Filters on the string values of those keys, and listings in
+// their order, are written to use the index.
+func (r *PostgresRepository) ConfigureMetadataIndexes(keys ...string) error {
+	if err := validateMetadataIndexKeys(keys); err != nil {
+		return err
+	}
+	r.metadataIndexes = make(map[string]bool, len(keys))
+	for _, key := range keys {
+		r.metadataIndexes[key] = true
+	}
+	return nil
+}
+
+// metadataIndexExpression is the indexed expression of a metadata key, the
+// string value compared with the C collation as ListContentByMetadataKey
+// orders by it
+func metadataIndexExpression(key string) string {
+	return `(metadata->>'` + key + `') COLLATE "C"`
+}
+
+// metadataIndexName names the index of a metadata key
+func metadataIndexName(key string) string {
+	return `"contents_metadata_` + key + `_idx"`
+}
+
+// MetadataIndexMigration returns the up and down statements of a migration
+// creating the expression indexes of metadata keys, to be saved as the next
+// NNNN_metadata_indexes.up.sql and .down.sql migration
+func MetadataIndexMigration(keys ...string) (up, down string, err error) {
+	if err := validateMetadataIndexKeys(keys); err != nil {
+		return "", "", err
+	}
+
+	var upSQL, downSQL strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&upSQL, "CREATE INDEX IF NOT EXISTS %s ON contents ((%s));\n", metadataIndexName(key), metadataIndexExpression(key))
+		fmt.Fprintf(&downSQL, "DROP INDEX IF EXISTS %s;\n", metadataIndexName(key))
+	}
+	return upSQL.String(), downSQL.String(), nil
+}
@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/livefire2015/simple-contents/model"
+)
+
+func TestBuildWhereClauseMetadataIndexes(t *testing.T) {
+	indexes := map[string]bool{"invoice_number": true}
+
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+		want     string
+	}{
+		{
+			name:     "indexed string",
+			metadata: map[string]interface{}{"invoice_number": "INV-1"},
+			want:     `deleted_at IS NULL AND (metadata->>'invoice_number') COLLATE "C" = $1 AND jsonb_typeof(metadata->'invoice_number') = 'string'`,
+		},
+		{
+			name:     "indexed key with another type",
+			metadata: map[string]interface{}{"invoice_number": 7.0},
+			want:     `deleted_at IS NULL AND metadata->$1 = $2`,
+		},
+		{
+			name:     "not indexed",
+			metadata: map[string]interface{}{"order": "A"},
+			want:     `deleted_at IS NULL AND metadata->$1 = $2`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, _ := buildWhereClause(model.ContentFilter{Metadata: tt.metadata}, indexes)
+			if where != tt.want {
+				t.Errorf("buildWhereClause() = %s, want %s", where, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetadataIndexMigration(t *testing.T) {
+	up, down, err := MetadataIndexMigration("invoice_number", "orderId")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantUp := `CREATE INDEX IF NOT EXISTS "contents_metadata_invoice_number_idx" ON contents (((metadata->>'invoice_number') COLLATE "C"));` + "\n" +
+		`CREATE INDEX IF NOT EXISTS "contents_metadata_orderId_idx" ON contents (((metadata->>'orderId') COLLATE "C"));` + "\n"
+	if up != wantUp {
+		t.Errorf("up =\n%s\nwant\n%s", up, wantUp)
+	}
+	if !strings.Contains(down, `DROP INDEX IF EXISTS "contents_metadata_orderId_idx";`) {
+		t.Errorf("down = %s, want the indexes dropped", down)
+	}
+
+	for _, key := range []string{"", "a'b", "a b", "1st", strings.Repeat("k", 41)} {
+		if _, _, err := MetadataIndexMigration(key); err == nil {
+			t.Errorf("MetadataIndexMigration(%q) succeeded, want an error", key)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// partitionedTable is a table partitioned by range of the month of a
+// timestamp column, with partitions named after the table and the month,
+// e.g. contents_2026_10
+type partitionedTable struct {
+	name   string
+	column string
+	// keep is the condition, given the pruning cutoff as $1, of the rows
+	// that pruning keeps
+	keep string
+}
+
+var (
+	contentsTable = partitionedTable{name: "contents", column: "created_at", keep: "deleted_at IS NULL OR deleted_at >= $1"}
+	auditTable    = partitionedTable{name: "audit_events", column: "occurred_at", keep: "occurred_at >= $1"}
+)
+
+// partitionName returns the name of the partition holding the rows of the month of t
+func (t partitionedTable) partitionName(month time.Time) string {
+	return t.name + "_" + month.UTC().Format("2006_01")
+}
+
+// monthStart returns the start of the UTC month of t
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// EnsureContentPartitions creates the monthly partitions of a contents table
+// partitioned by range of created_at, from the month of from through the
+// month of through. Existing partitions are left alone. It is meant to be
+// run ahead of time, e.g. daily for the next few months.
+func (r *PostgresRepository) EnsureContentPartitions(ctx context.Context, from, through time.Time) error {
+	return r.ensurePartitions(ctx, contentsTable, from, through)
+}
+
+// EnsureAuditPartitions creates the monthly partitions of the audit_events
+// table from the month of from through the month of through, as
+// EnsureContentPartitions does for contents
+func (r *PostgresRepository) EnsureAuditPartitions(ctx context.Context, from, through time.Time) error {
+	return r.ensurePartitions(ctx, auditTable, from, through)
+}
+
+func (r *PostgresRepository) ensurePartitions(ctx context.Context, t partitionedTable, from, through time.Time) error {
+	for month := monthStart(from); !month.After(through); month = month.AddDate(0, 1, 0) {
+		// DDL takes no parameters; the bounds are formatted timestamps
+		query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s %s",
+			t.partitionName(month), t.name, partitionBounds(month))
+		if _, err := r.conn.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to create partition %s: %w", t.partitionName(month), err)
+		}
+	}
+	return nil
+}
+
+// partitionBounds returns the bounds clause of the partition of a month
+func partitionBounds(month time.Time) string {
+	return fmt.Sprintf("FOR VALUES FROM ('%s') TO ('%s')", month.Format(time.RFC3339), month.AddDate(0, 1, 0).Format(time.RFC3339))
+}
+
+// monthlyPartition is a monthly partition of a table, or a table that was
+// detached from it by a pruning run that didn't finish
+type monthlyPartition struct {
+	name     string
+	month    time.Time
+	attached bool
+	pending  bool // Detached concurrently, but the detach wasn't finalized
+}
+
+// monthlyPartitions returns the monthly partitions of a table. It is empty
+// if the table isn't partitioned.
+func (r *PostgresRepository) monthlyPartitions(ctx context.Context, t partitionedTable) ([]monthlyPartition, error) {
+	var rows []struct {
+		Name     string `db:"relname"`
+		Attached bool   `db:"attached"`
+		Pending  bool   `db:"pending"`
+	}
+	query := `
+		SELECT c.relname, i.inhrelid IS NOT NULL AS attached, COALESCE(i.inhdetachpending, false) AS pending
+		FROM pg_class c
+		LEFT JOIN pg_inherits i ON i.inhrelid = c.oid AND i.inhparent = $1::regclass
+		WHERE c.relkind = 'r' AND c.relname LIKE $2
+			AND c.relnamespace = (SELECT relnamespace FROM pg_class WHERE oid = $1::regclass)
+	`
+	if err := r.conn.SelectContext(ctx, &rows, query, t.name, t.name+`\_%`); err != nil {
+		return nil, err
+	}
+
+	var partitions []monthlyPartition
+	for _, row := range rows {
+		month, err := time.Parse("2006_01", strings.TrimPrefix(row.Name, t.name+"_"))
+		if err != nil {
+			continue // Not one of ours, e.g. a default partition
+		}
+		partitions = append(partitions, monthlyPartition{name: row.Name, month: month, attached: row.Attached, pending: row.Pending})
+	}
+	return partitions, nil
+}
+
+// pruneMonthlyPartitions drops the monthly partitions of a table whose rows
+// pruning at a cutoff removes, and returns the number of rows they held.
+// A partition is first detached concurrently, which doesn't block queries
+// on the table; rows may still be written or restored until the detach
+// completes, so the partition is checked again and put back if it must be
+// kept. A partition a previous run left detached is finished the same way.
+func (r *PostgresRepository) pruneMonthlyPartitions(ctx context.Context, t partitionedTable, cutoff time.Time) (int64, error) {
+	partitions, err := r.monthlyPartitions(ctx, t)
+	if err != nil {
+		return 0, err
+	}
+
+	var pruned int64
+	for _, p := range partitions {
+		// Rows are pruned some time after they are created, so newer partitions always keep rows
+		if p.month.AddDate(0, 1, 0).After(cutoff) {
+			continue
+		}
+
+		if p.attached && !p.pending {
+			if _, keep, err := r.partitionRows(ctx, t, p.name, cutoff); err != nil || keep {
+				if err != nil {
+					return pruned, err
+				}
+				continue
+			}
+			if _, err := r.conn.ExecContext(ctx, "ALTER TABLE "+t.name+" DETACH PARTITION "+p.name+" CONCURRENTLY"); err != nil {
+				return pruned, fmt.Errorf("failed to detach partition %s: %w", p.name, err)
+			}
+		} else if p.pending {
+			if _, err := r.conn.ExecContext(ctx, "ALTER TABLE "+t.name+" DETACH PARTITION "+p.name+" FINALIZE"); err != nil {
+				return pruned, fmt.Errorf("failed to finalize the detach of partition %s: %w", p.name, err)
+			}
+		}
+
+		rows, keep, err := r.partitionRows(ctx, t, p.name, cutoff)
+		if err != nil {
+			return pruned, err
+		}
+		if keep {
+			query := "ALTER TABLE " + t.name + " ATTACH PARTITION " + p.name + " " + partitionBounds(p.month)
+			if _, err := r.conn.ExecContext(ctx, query); err != nil {
+				return pruned, fmt.Errorf("failed to reattach partition %s: %w", p.name, err)
+			}
+			continue
+		}
+		if _, err := r.conn.ExecContext(ctx, "DROP TABLE "+p.name); err != nil {
+			return pruned, fmt.Errorf("failed to drop partition %s: %w", p.name, err)
+		}
+		pruned += rows
+	}
+	return pruned, nil
+}
+
+// partitionRows counts the rows of a partition and reports whether pruning
+// at a cutoff keeps any of them
+func (r *PostgresRepository) partitionRows(ctx context.Context, t partitionedTable, name string, cutoff time.Time) (rows int64, keep bool, err error) {
+	query := "SELECT COUNT(*), COALESCE(bool_or(" + t.keep + "), false) FROM " + name
+	err = r.conn.QueryRowContext(ctx, query, cutoff).Scan(&rows, &keep)
+	return rows, keep, err
+}
+
+// PruneDeletedContent permanently removes the content rows deleted before a
+// cutoff. On a partitioned table, monthly partitions that only hold such rows
+// are dropped whole instead of deleted from row by row.
+func (r *PostgresRepository) PruneDeletedContent(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	pruned, err := r.pruneMonthlyPartitions(ctx, contentsTable, deletedBefore)
+	if err != nil {
+		return pruned, err
+	}
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM contents WHERE deleted_at < $1", deletedBefore)
+	if err != nil {
+		return pruned, err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return pruned, err
+	}
+	return pruned + deleted, r.pruneExternalIDs(ctx)
+}
@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+)
+
+func TestPartitionBounds(t *testing.T) {
+	month := monthStart(time.Date(2026, 12, 17, 23, 0, 0, 0, time.FixedZone("PST", -8*3600)))
+	if got, want := contentsTable.partitionName(month), "contents_2026_12"; got != want {
+		t.Errorf("partitionName() = %q, want %q", got, want)
+	}
+	if got, want := partitionBounds(month), "FOR VALUES FROM ('2026-12-01T00:00:00Z') TO ('2027-01-01T00:00:00Z')"; got != want {
+		t.Errorf("partitionBounds() = %q, want %q", got, want)
+	}
+}
+
+// TestPruneAuditPartitions runs against the database of POSTGRES_TEST_DSN,
+// in a schema of its own
+func TestPruneAuditPartitions(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+	ctx := context.Background()
+
+	admin, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+	schema := "partition_test_" + uuid.NewString()[:8]
+	if _, err := admin.ExecContext(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatal(err)
+	}
+	defer admin.ExecContext(ctx, "DROP SCHEMA "+schema+" CASCADE")
+
+	schemaDSN, err := pgxConnString(dsn, "search_path", schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := OpenPgx(ctx, schemaDSN, PgxConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	migration, err := Migrations.ReadFile("migrations/0002_audit_events.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.conn.ExecContext(ctx, string(migration)); err != nil {
+		t.Fatal(err)
+	}
+
+	january := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := r.EnsureAuditPartitions(ctx, january, january.AddDate(0, 1, 0)); err != nil {
+		t.Fatal(err)
+	}
+	// March has no partition yet and gets one when its event is recorded
+	for _, month := range []time.Time{january, january.AddDate(0, 1, 0), january.AddDate(0, 2, 0)} {
+		event := &model.AuditEvent{Action: "test", Subject: "s", Actor: "a", OccurredAt: month.Add(time.Hour)}
+		if err := r.RecordAuditEvent(ctx, event); err != nil {
+			t.Fatalf("RecordAuditEvent(%s) error = %v", month.Format("2006-01"), err)
+		}
+	}
+
+	pruned, err := r.PruneAuditEvents(ctx, january.AddDate(0, 1, 15))
+	if err != nil {
+		t.Fatalf("PruneAuditEvents() error = %v", err)
+	}
+	if pruned != 2 {
+		t.Errorf("pruned %d events, want 2", pruned)
+	}
+
+	partitions, err := r.monthlyPartitions(ctx, auditTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range partitions {
+		names = append(names, fmt.Sprintf("%s attached=%v", p.name, p.attached))
+	}
+	// January was dropped; February still holds the partition of its rows
+	if len(partitions) != 2 {
+		t.Errorf("partitions = %v, want February and March", names)
+	}
+}
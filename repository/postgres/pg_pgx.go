@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+)
+
+// PgxConfig configures a repository opened with OpenPgx
+type PgxConfig struct {
+	Pool PoolConfig
+
+	// ReplicaDSN, if set, connects to a read replica with a pool of its own,
+	// sized like the primary's, and routes reads to it as EnableReadReplica does
+	ReplicaDSN string
+	Replica    ReplicaConfig
+}
+
+// OpenPgx opens a repository on pgxpool connection pools to the database of
+// a connection string and, if configured, its read replica. Queries run
+// through pgx's database/sql adapter; pgx prepares and caches the statement
+// of each query on every connection, so the repository doesn't cache them
+// itself. Close closes the pools.
+func OpenPgx(ctx context.Context, dsn string, config PgxConfig) (*PostgresRepository, error) {
+	primary, err := openPgxPool(ctx, dsn, config.Pool)
+	if err != nil {
+		return nil, err
+	}
+	r := NewPostgresRepository(pgxDB(primary))
+	r.stmts.driverCached = true
+	r.pgxPools = []*pgxpool.Pool{primary}
+
+	if config.ReplicaDSN != "" {
+		replica, err := openPgxPool(ctx, config.ReplicaDSN, config.Pool)
+		if err != nil {
+			primary.Close()
+			return nil, err
+		}
+		r.pgxPools = append(r.pgxPools, replica)
+		r.EnableReadReplica(pgxDB(replica), config.Replica)
+		r.replicaStmts.driverCached = true
+	}
+
+	r.ConfigurePool(config.Pool)
+	return r, nil
+}
+
+// openPgxPool connects a pool sized by config and checks that the database is reachable
+func openPgxPool(ctx context.Context, dsn string, config PoolConfig) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if config.MaxOpenConns > 0 {
+		poolConfig.MaxConns = int32(config.MaxOpenConns)
+	}
+	if config.MinConns > 0 {
+		poolConfig.MinConns = int32(config.MinConns)
+	}
+	if config.ConnMaxLifetime > 0 {
+		poolConfig.MaxConnLifetime = config.ConnMaxLifetime
+	}
+	if config.ConnMaxIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = config.ConnMaxIdleTime
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return pool, nil
+}
+
+// pgxDB wraps a pgx pool as a *sqlx.DB; connections are pooled by pgx only
+func pgxDB(pool *pgxpool.Pool) *sqlx.DB {
+	return sqlx.NewDb(stdlib.OpenDBFromPool(pool), "pgx")
+}
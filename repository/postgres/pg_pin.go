@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// pinDB is a database model for a pinned content item
+type pinDB struct {
+	Principal string    `db:"principal"`
+	ContentID uuid.UUID `db:"content_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// PinContent pins a content item for a principal
+func (r *PostgresRepository) PinContent(ctx context.Context, pin *model.Pin) error {
+	if pin.CreatedAt.IsZero() {
+		pin.CreatedAt = r.clock.Now()
+	}
+
+	// The no-op update makes RETURNING report the existing pin
+	query := `
+		INSERT INTO content_pins (principal, content_id, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (principal, content_id) DO UPDATE SET principal = EXCLUDED.principal
+		RETURNING principal, content_id, created_at
+	`
+
+	var dbPin pinDB
+	if err := r.db.GetContext(ctx, &dbPin, query, pin.Principal, pin.ContentID, pin.CreatedAt); err != nil {
+		return err
+	}
+
+	pin.CreatedAt = dbPin.CreatedAt
+	return nil
+}
+
+// UnpinContent removes the pin of a content item by a principal
+func (r *PostgresRepository) UnpinContent(ctx context.Context, principal string, contentID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM content_pins WHERE principal = $1 AND content_id = $2`, principal, contentID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrPinNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PoolConfig tunes the connection pools of a repository and how its queries
+// and transactions are run. Zero values keep the defaults of the pool.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int // Not used by pgx pools, which close idle connections after ConnMaxIdleTime
+	MinConns        int // Connections a pgx pool keeps open; not used by database/sql pools
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// StatementTimeout bounds each query; streamed listings are not bounded
+	StatementTimeout time.Duration
+	// MaxTxRetries is how many times a transaction that fails with a
+	// serialization failure or a deadlock is run again
+	MaxTxRetries int
+}
+
+// txRetryBackoff is the wait before the first retry of a transaction,
+// doubled on each further retry
+const txRetryBackoff = 20 * time.Millisecond
+
+// ConfigurePool applies config to the connection pools of the repository,
+// the primary's and, whether it's enabled before or after, the read
+// replica's. It must be called before the repository is used. The pools of
+// a repository from OpenPgx are sized when it's opened, so only the
+// timeouts and retries of config apply to them.
+func (r *PostgresRepository) ConfigurePool(config PoolConfig) {
+	r.pool = config
+	r.applyPool()
+}
+
+// applyPool tunes the database/sql pools of the repository and sets the
+// statement timeout of its queryers
+func (r *PostgresRepository) applyPool() {
+	r.db = &preparedQueryer{cache: r.stmts, timeout: r.pool.StatementTimeout}
+	if r.replica != nil {
+		r.replica = &preparedQueryer{cache: r.replicaStmts, timeout: r.pool.StatementTimeout}
+	}
+	if r.pgxPools != nil {
+		return
+	}
+
+	dbs := []*sqlx.DB{r.conn}
+	if r.replicaStmts != nil {
+		dbs = append(dbs, r.replicaStmts.db)
+	}
+	for _, db := range dbs {
+		if r.pool.MaxOpenConns > 0 {
+			db.SetMaxOpenConns(r.pool.MaxOpenConns)
+		}
+		if r.pool.MaxIdleConns > 0 {
+			db.SetMaxIdleConns(r.pool.MaxIdleConns)
+		}
+		if r.pool.ConnMaxLifetime > 0 {
+			db.SetConnMaxLifetime(r.pool.ConnMaxLifetime)
+		}
+		if r.pool.ConnMaxIdleTime > 0 {
+			db.SetConnMaxIdleTime(r.pool.ConnMaxIdleTime)
+		}
+	}
+}
+
+// isRetryable reports whether a transaction failed because of a conflict
+// with a concurrent one and may succeed if run again. Both lib/pq and pgx
+// errors expose their SQLSTATE through SQLState.
+func isRetryable(err error) bool {
+	var pgErr interface{ SQLState() string }
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	switch pgErr.SQLState() {
+	case "40001", // serialization_failure
+		"40P01": // deadlock_detected
+		return true
+	}
+	return false
+}
+
+// isUniqueViolation reports whether a statement failed on a unique constraint
+func isUniqueViolation(err error) bool {
+	var pgErr interface{ SQLState() string }
+	return errors.As(err, &pgErr) && pgErr.SQLState() == "23505"
+}
+
+// waitRetry waits before the given retry of a transaction, returning false
+// if the context ends first
+func waitRetry(ctx context.Context, retry int) bool {
+	timer := time.NewTimer(txRetryBackoff << (retry - 1))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+func TestConfigurePoolTunesReplicaInAnyOrder(t *testing.T) {
+	config := PoolConfig{MaxOpenConns: 7, StatementTimeout: time.Second}
+	tests := []struct {
+		name  string
+		setup func(r *PostgresRepository, replica *sqlx.DB)
+	}{
+		{
+			name: "pool first",
+			setup: func(r *PostgresRepository, replica *sqlx.DB) {
+				r.ConfigurePool(config)
+				r.EnableReadReplica(replica, ReplicaConfig{})
+			},
+		},
+		{
+			name: "replica first",
+			setup: func(r *PostgresRepository, replica *sqlx.DB) {
+				r.EnableReadReplica(replica, ReplicaConfig{})
+				r.ConfigurePool(config)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Opening doesn't connect
+			primary := sqlx.MustOpen("pgx", "postgres://localhost/primary")
+			replica := sqlx.MustOpen("pgx", "postgres://localhost/replica")
+			defer primary.Close()
+			defer replica.Close()
+
+			r := NewPostgresRepository(primary)
+			tt.setup(r, replica)
+
+			for name, db := range map[string]*sqlx.DB{"primary": primary, "replica": replica} {
+				if got := db.Stats().MaxOpenConnections; got != config.MaxOpenConns {
+					t.Errorf("%s max open connections = %d, want %d", name, got, config.MaxOpenConns)
+				}
+			}
+			for name, q := range map[string]queryer{"primary": r.db, "replica": r.replica} {
+				if got := q.(*preparedQueryer).timeout; got != config.StatementTimeout {
+					t.Errorf("%s statement timeout = %v, want %v", name, got, config.StatementTimeout)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenPgx(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+	ctx := context.Background()
+	r, err := OpenPgx(ctx, dsn, PgxConfig{Pool: PoolConfig{MaxOpenConns: 4, MinConns: 1, MaxTxRetries: 2}, ReplicaDSN: dsn})
+	if err != nil {
+		t.Fatalf("OpenPgx() error = %v", err)
+	}
+	defer r.Close()
+
+	if got := r.pgxPools[0].Config().MaxConns; got != 4 {
+		t.Errorf("pool max connections = %d, want 4", got)
+	}
+	err = r.WithTx(ctx, func(tx repository.ContentRepository) error {
+		_, err := tx.GetContentByID(ctx, uuid.New())
+		return err
+	})
+	if !errors.Is(err, repository.ErrContentNotFound) {
+		t.Errorf("GetContentByID() error = %v, want ErrContentNotFound", err)
+	}
+}
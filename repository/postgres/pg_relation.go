@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// relationDB is a database model for a relation between two content items
+type relationDB struct {
+	ID        uuid.UUID `db:"id"`
+	Type      string    `db:"type"`
+	SourceID  uuid.UUID `db:"source_id"`
+	TargetID  uuid.UUID `db:"target_id"`
+	CreatedBy string    `db:"created_by"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// toModel converts a database model to a domain model
+func (r *relationDB) toModel() *model.ContentRelation {
+	return &model.ContentRelation{
+		ID:        r.ID,
+		Type:      model.RelationType(r.Type),
+		SourceID:  r.SourceID,
+		TargetID:  r.TargetID,
+		CreatedBy: r.CreatedBy,
+		CreatedAt: r.CreatedAt,
+	}
+}
+
+// CreateRelation stores a new relation between two content items
+func (r *PostgresRepository) CreateRelation(ctx context.Context, relation *model.ContentRelation) error {
+	if relation.ID == uuid.Nil {
+		relation.ID = r.ids.NewID()
+	}
+	if relation.CreatedAt.IsZero() {
+		relation.CreatedAt = r.clock.Now()
+	}
+
+	query := `
+		INSERT INTO content_relations (id, type, source_id, target_id, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (source_id, target_id, type) DO NOTHING
+	`
+
+	result, err := r.db.ExecContext(ctx, query, relation.ID, string(relation.Type), relation.SourceID, relation.TargetID, relation.CreatedBy, relation.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrRelationExists
+	}
+
+	return nil
+}
+
+// GetRelation retrieves a relation by its ID
+func (r *PostgresRepository) GetRelation(ctx context.Context, id uuid.UUID) (*model.ContentRelation, error) {
+	var dbRelation relationDB
+	if err := r.db.GetContext(ctx, &dbRelation, `SELECT * FROM content_relations WHERE id = $1`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrRelationNotFound
+		}
+		return nil, err
+	}
+
+	return dbRelation.toModel(), nil
+}
+
+// ListRelations retrieves the relations of a content item, oldest first
+func (r *PostgresRepository) ListRelations(ctx context.Context, contentID uuid.UUID, direction model.RelationDirection, relationType model.RelationType) ([]*model.ContentRelation, error) {
+	var where string
+	switch direction {
+	case model.RelationsOutgoing:
+		where = "source_id = $1"
+	case model.RelationsIncoming:
+		where = "target_id = $1"
+	default:
+		where = "(source_id = $1 OR target_id = $1)"
+	}
+	args := queryArgs{contentID}
+	if relationType != "" {
+		where += " AND type = " + args.add(string(relationType))
+	}
+
+	var dbRelations []relationDB
+	if err := r.db.SelectContext(ctx, &dbRelations, "SELECT * FROM content_relations WHERE "+where+" ORDER BY created_at, id", args...); err != nil {
+		return nil, err
+	}
+
+	relations := make([]*model.ContentRelation, len(dbRelations))
+	for i, dbRelation := range dbRelations {
+		relations[i] = dbRelation.toModel()
+	}
+	return relations, nil
+}
+
+// DeleteRelation removes a relation
+func (r *PostgresRepository) DeleteRelation(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM content_relations WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrRelationNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// ReplicaConfig configures the routing of reads to a read replica
+type ReplicaConfig struct {
+	// ReadYourWrites sends the reads made after a write through a context
+	// from repository.TrackWrites to the primary, so a request sees its own
+	// writes despite replication lag
+	ReadYourWrites bool
+}
+
+// EnableReadReplica sends content reads (GetContentByID, the content
+// listings and statistics) to a replica. Writes, transactions and other
+// reads stay on the primary. The replica's pool is tuned like the primary's.
+func (r *PostgresRepository) EnableReadReplica(replica *sqlx.DB, config ReplicaConfig) {
+	r.replicaStmts = newStmtCache(replica)
+	r.replica = &preparedQueryer{cache: r.replicaStmts}
+	r.replicaConfig = config
+	r.applyPool()
+}
+
+// reader returns the queryer content reads through ctx go to
+func (r *PostgresRepository) reader(ctx context.Context) queryer {
+	switch {
+	case r.replica == nil, repository.PrimaryRequested(ctx):
+		return r.db
+	case r.replicaConfig.ReadYourWrites && repository.Written(ctx):
+		return r.db
+	}
+	return r.replica
+}
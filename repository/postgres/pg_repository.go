@@ -5,59 +5,204 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jmoiron/sqlx"
+	"github.com/livefire2015/simple-contents/clock"
 	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
 )
 
 var (
-	ErrContentNotFound = errors.New("content not found")
+	ErrContentNotFound = repository.ErrContentNotFound
 )
 
+// queryer runs queries on a database or inside a transaction
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error)
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error)
+}
+
 // PostgresRepository implements ContentRepository using PostgreSQL
 type PostgresRepository struct {
-	db *sqlx.DB
+	db    queryer
+	conn  *sqlx.DB // nil for a repository bound to a transaction
+	stmts *stmtCache
+	pool  PoolConfig
+
+	// Content reads go to the replica if set
+	replica       queryer
+	replicaStmts  *stmtCache
+	replicaConfig ReplicaConfig
+
+	// Pools opened by OpenPgx, closed with the repository
+	pgxPools []*pgxpool.Pool
+
+	// Metadata keys with an expression index
+	metadataIndexes map[string]bool
+
+	clock clock.Clock
+	ids   clock.IDGenerator
 }
 
 // NewPostgresRepository creates a new PostgreSQL repository
 func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	stmts := newStmtCache(db)
 	return &PostgresRepository{
-		db: db,
+		db:    &preparedQueryer{cache: stmts},
+		conn:  db,
+		stmts: stmts,
+		clock: clock.System,
+		ids:   clock.TimeOrderedIDs,
+	}
+}
+
+// ConfigureClock sets the clock the timestamps of records are taken from
+func (r *PostgresRepository) ConfigureClock(c clock.Clock) {
+	r.clock = c
+}
+
+// ConfigureIDs sets the generator of the IDs of new records, time-ordered
+// UUIDv7 by default
+func (r *PostgresRepository) ConfigureIDs(ids clock.IDGenerator) {
+	r.ids = ids
+}
+
+// Close closes the prepared statements of the repository. The databases are
+// left open, except for the pools of a repository from OpenPgx.
+func (r *PostgresRepository) Close() error {
+	if r.replicaStmts != nil {
+		if err := r.replicaStmts.Close(); err != nil {
+			return err
+		}
+	}
+	if err := r.stmts.Close(); err != nil {
+		return err
+	}
+	for _, pool := range r.pgxPools {
+		pool.Close()
+	}
+	return nil
+}
+
+// WithTx runs fn in a transaction, committing it if fn returns nil. Calls
+// nested in a transaction join it. Transactions failing on a conflict with
+// a concurrent one are run again, up to PoolConfig.MaxTxRetries times.
+func (r *PostgresRepository) WithTx(ctx context.Context, fn func(tx repository.ContentRepository) error) error {
+	if r.conn == nil {
+		return fn(r)
+	}
+
+	for retry := 0; ; retry++ {
+		err := r.runTx(ctx, fn)
+		if err == nil || retry >= r.pool.MaxTxRetries || !isRetryable(err) || !waitRetry(ctx, retry+1) {
+			return err
+		}
+	}
+}
+
+// runTx runs fn in a single transaction
+func (r *PostgresRepository) runTx(ctx context.Context, fn func(tx repository.ContentRepository) error) error {
+	tx, err := r.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	repository.NoteWrite(ctx)
+	queries := &preparedQueryer{cache: r.stmts, tx: tx, timeout: r.pool.StatementTimeout}
+	if err := fn(&PostgresRepository{db: queries, metadataIndexes: r.metadataIndexes, clock: r.clock, ids: r.ids}); err != nil {
+		_ = tx.Rollback()
+		return err
 	}
+	return tx.Commit()
 }
 
 // contentDB is a database model for content
 type contentDB struct {
-	ID          uuid.UUID      `db:"id"`
-	Name        string         `db:"name"`
-	Description string         `db:"description"`
-	MIMEType    string         `db:"mime_type"`
-	FileSize    int64          `db:"file_size"`
-	Path        string         `db:"path"`
-	Metadata    sql.NullString `db:"metadata"` // JSON stored as string
-	CreatedAt   time.Time      `db:"created_at"`
-	UpdatedAt   time.Time      `db:"updated_at"`
-	DeletedAt   sql.NullTime   `db:"deleted_at"`
+	ID            uuid.UUID      `db:"id"`
+	TenantID      string         `db:"tenant_id"`
+	Status        string         `db:"status"`
+	Name          string         `db:"name"`
+	Description   string         `db:"description"`
+	MIMEType      string         `db:"mime_type"`
+	FileSize      int64          `db:"file_size"`
+	Path          string         `db:"path"`
+	OriginalPath  string         `db:"original_path"`
+	ETag          string         `db:"etag"`
+	DerivedFromID uuid.NullUUID  `db:"derived_from_id"`
+	Derivation    string         `db:"derivation"`
+	Source        string         `db:"source"`
+	ExternalID    string         `db:"external_id"`
+	Kind          string         `db:"kind"`
+	ReferenceURL  string         `db:"reference_url"`
+	Metadata      sql.NullString `db:"metadata"` // JSON stored as string
+	CreatedBy     string         `db:"created_by"`
+	CreatedAt     time.Time      `db:"created_at"`
+	UpdatedAt     time.Time      `db:"updated_at"`
+	DeletedAt     sql.NullTime   `db:"deleted_at"`
+
+	CallbackURL    string       `db:"callback_url"`
+	CallbackSentAt sql.NullTime `db:"callback_sent_at"`
+
+	ReferenceStatus    string       `db:"reference_status"`
+	ReferenceCheckedAt sql.NullTime `db:"reference_checked_at"`
+
+	DraftExpiresAt sql.NullTime `db:"draft_expires_at"`
 }
 
 // toModel converts a database model to a domain model
 func (c *contentDB) toModel() (*model.Content, error) {
 	content := &model.Content{
-		ID:          c.ID,
-		FileName:    c.Name,
-		MIMEType:    c.MIMEType,
-		FileSize:    c.FileSize,
-		StoragePath: c.Path,
-		CreatedAt:   c.CreatedAt,
-		UpdatedAt:   c.UpdatedAt,
+		ID:                  c.ID,
+		TenantID:            c.TenantID,
+		Status:              model.ContentStatus(c.Status),
+		FileName:            c.Name,
+		Description:         c.Description,
+		MIMEType:            c.MIMEType,
+		FileSize:            c.FileSize,
+		StoragePath:         c.Path,
+		OriginalStoragePath: c.OriginalPath,
+		ETag:                c.ETag,
+		Derivation:          c.Derivation,
+		Source:              c.Source,
+		ExternalID:          c.ExternalID,
+		Kind:                model.ContentKind(c.Kind),
+		ReferenceURL:        c.ReferenceURL,
+		ReferenceStatus:     model.ReferenceStatus(c.ReferenceStatus),
+		CreatedBy:           c.CreatedBy,
+		CreatedAt:           c.CreatedAt,
+		UpdatedAt:           c.UpdatedAt,
+		CallbackURL:         c.CallbackURL,
 	}
 
 	if c.DeletedAt.Valid {
 		content.DeletedAt = &c.DeletedAt.Time
 	}
 
+	if c.DerivedFromID.Valid {
+		content.DerivedFromID = &c.DerivedFromID.UUID
+	}
+
+	if c.CallbackSentAt.Valid {
+		content.CallbackSentAt = &c.CallbackSentAt.Time
+	}
+
+	if c.ReferenceCheckedAt.Valid {
+		content.ReferenceCheckedAt = &c.ReferenceCheckedAt.Time
+	}
+
+	if c.DraftExpiresAt.Valid {
+		content.DraftExpiresAt = &c.DraftExpiresAt.Time
+	}
+
 	// Parse metadata JSON
 	if c.Metadata.Valid {
 		var metadata model.Metadata
@@ -75,13 +220,29 @@ func (c *contentDB) toModel() (*model.Content, error) {
 // fromModel converts a domain model to a database model
 func fromModel(content *model.Content) (*contentDB, error) {
 	dbContent := &contentDB{
-		ID:        content.ID,
-		Name:      content.FileName,
-		MIMEType:  content.MIMEType,
-		FileSize:  content.FileSize,
-		Path:      content.StoragePath,
-		CreatedAt: content.CreatedAt,
-		UpdatedAt: content.UpdatedAt,
+		ID:           content.ID,
+		TenantID:     content.TenantID,
+		Status:       string(content.Status),
+		Name:         content.FileName,
+		Description:  content.Description,
+		MIMEType:     content.MIMEType,
+		FileSize:     content.FileSize,
+		Path:         content.StoragePath,
+		OriginalPath: content.OriginalStoragePath,
+		ETag:         content.ETag,
+		Derivation:   content.Derivation,
+		Source:       content.Source,
+		ExternalID:   content.ExternalID,
+		Kind:         string(content.Kind),
+		ReferenceURL: content.ReferenceURL,
+		CreatedBy:    content.CreatedBy,
+		CreatedAt:    content.CreatedAt,
+		UpdatedAt:    content.UpdatedAt,
+		CallbackURL:  content.CallbackURL,
+	}
+
+	if content.DerivedFromID != nil {
+		dbContent.DerivedFromID = uuid.NullUUID{UUID: *content.DerivedFromID, Valid: true}
 	}
 
 	if content.DeletedAt != nil {
@@ -91,6 +252,28 @@ func fromModel(content *model.Content) (*contentDB, error) {
 		}
 	}
 
+	if content.CallbackSentAt != nil {
+		dbContent.CallbackSentAt = sql.NullTime{
+			Time:  *content.CallbackSentAt,
+			Valid: true,
+		}
+	}
+
+	dbContent.ReferenceStatus = string(content.ReferenceStatus)
+	if content.ReferenceCheckedAt != nil {
+		dbContent.ReferenceCheckedAt = sql.NullTime{
+			Time:  *content.ReferenceCheckedAt,
+			Valid: true,
+		}
+	}
+
+	if content.DraftExpiresAt != nil {
+		dbContent.DraftExpiresAt = sql.NullTime{
+			Time:  *content.DraftExpiresAt,
+			Valid: true,
+		}
+	}
+
 	// Convert metadata to JSON
 	if len(content.Metadata) > 0 {
 		metadataBytes, err := json.Marshal(content.Metadata)
@@ -109,27 +292,52 @@ func fromModel(content *model.Content) (*contentDB, error) {
 // Create stores a new content item
 func (r *PostgresRepository) CreateContent(ctx context.Context, content *model.Content) error {
 	if content.ID == uuid.Nil {
-		content.ID = uuid.New()
+		content.ID = r.ids.NewID()
 	}
 
-	now := time.Now()
-	content.CreatedAt = now
-	content.UpdatedAt = now
+	// Imported rows keep their original timestamps
+	now := r.clock.Now()
+	if content.CreatedAt.IsZero() {
+		content.CreatedAt = now
+	}
+	if content.UpdatedAt.IsZero() {
+		content.UpdatedAt = now
+	}
 
 	dbContent, err := fromModel(content)
 	if err != nil {
 		return err
 	}
+	if dbContent.ExternalID == "" {
+		return r.insertContent(ctx, dbContent)
+	}
 
+	// The external ID is reserved in the transaction storing the content
+	return r.WithTx(ctx, func(tx repository.ContentRepository) error {
+		pg := tx.(*PostgresRepository)
+		if err := pg.insertExternalIDs(ctx, []*contentDB{dbContent}); err != nil {
+			return fmt.Errorf("%w: %s", err, content.ExternalID)
+		}
+		return pg.insertContent(ctx, dbContent)
+	})
+}
+
+// insertContent inserts a single row
+func (r *PostgresRepository) insertContent(ctx context.Context, dbContent *contentDB) error {
 	query := `
 		INSERT INTO contents (
-			id, name, description, content_type, size, path, metadata, created_at, updated_at
+			id, tenant_id, status, name, description, content_type, size, path, original_path, etag, derived_from_id, derivation, metadata, created_at, updated_at,
+			callback_url, callback_sent_at, created_by, source, external_id, kind, reference_url, reference_status, reference_checked_at, draft_expires_at
 		) VALUES (
-			:id, :name, :description, :content_type, :size, :path, :metadata, :created_at, :updated_at
+			:id, :tenant_id, :status, :name, :description, :content_type, :size, :path, :original_path, :etag, :derived_from_id, :derivation, :metadata, :created_at, :updated_at,
+			:callback_url, :callback_sent_at, :created_by, :source, :external_id, :kind, :reference_url, :reference_status, :reference_checked_at, :draft_expires_at
 		)
 	`
 
-	_, err = r.db.NamedExecContext(ctx, query, dbContent)
+	_, err := r.db.NamedExecContext(ctx, query, dbContent)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: %s", repository.ErrContentExists, dbContent.ID)
+	}
 	return err
 }
 
@@ -141,7 +349,7 @@ func (r *PostgresRepository) GetContentByID(ctx context.Context, id uuid.UUID) (
 	`
 
 	var dbContent contentDB
-	if err := r.db.GetContext(ctx, &dbContent, query, id); err != nil {
+	if err := r.reader(ctx).GetContext(ctx, &dbContent, query, id); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrContentNotFound
 		}
@@ -153,7 +361,7 @@ func (r *PostgresRepository) GetContentByID(ctx context.Context, id uuid.UUID) (
 
 // Update updates an existing content item
 func (r *PostgresRepository) UpdateContent(ctx context.Context, content *model.Content) error {
-	content.UpdatedAt = time.Now()
+	content.UpdatedAt = r.clock.Now()
 
 	dbContent, err := fromModel(content)
 	if err != nil {
@@ -162,13 +370,20 @@ func (r *PostgresRepository) UpdateContent(ctx context.Context, content *model.C
 
 	query := `
 		UPDATE contents SET
+			status = :status,
 			name = :name,
 			description = :description,
 			content_type = :content_type,
 			size = :size,
 			path = :path,
+			original_path = :original_path,
+			etag = :etag,
 			metadata = :metadata,
-			updated_at = :updated_at
+			updated_at = :updated_at,
+			callback_url = :callback_url,
+			reference_status = :reference_status,
+			reference_checked_at = :reference_checked_at,
+			draft_expires_at = :draft_expires_at
 		WHERE id = :id AND deleted_at IS NULL
 	`
 
@@ -189,6 +404,42 @@ func (r *PostgresRepository) UpdateContent(ctx context.Context, content *model.C
 	return nil
 }
 
+// MarkCallbackSent records the callback of a content item unless one already
+// is. The condition is checked by the update itself, so of several instances
+// finishing the same content only one records, and sends, the callback.
+func (r *PostgresRepository) MarkCallbackSent(ctx context.Context, id uuid.UUID, sentAt time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE contents SET callback_sent_at = $2 WHERE id = $1 AND deleted_at IS NULL AND callback_sent_at IS NULL`,
+		id, sentAt)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// ClearCallbackSent forgets the recorded callback of a content item
+func (r *PostgresRepository) ClearCallbackSent(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE contents SET callback_sent_at = NULL WHERE id = $1 AND deleted_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrContentNotFound
+	}
+	return nil
+}
+
 // Delete marks a content item as deleted
 func (r *PostgresRepository) DeleteContent(ctx context.Context, id uuid.UUID) error {
 	query := `
@@ -197,7 +448,7 @@ func (r *PostgresRepository) DeleteContent(ctx context.Context, id uuid.UUID) er
 		WHERE id = $2 AND deleted_at IS NULL
 	`
 
-	result, err := r.db.ExecContext(ctx, query, time.Now(), id)
+	result, err := r.db.ExecContext(ctx, query, r.clock.Now(), id)
 	if err != nil {
 		return err
 	}
@@ -214,71 +465,258 @@ func (r *PostgresRepository) DeleteContent(ctx context.Context, id uuid.UUID) er
 	return nil
 }
 
-// buildWhereClause constructs the WHERE clause for filtering
-func buildWhereClause(filter model.ContentFilter) (string, []interface{}) {
-	where := "deleted_at IS NULL"
-	var params []interface{}
-	paramCount := 1
+// DeleteContentIfUnreferenced marks a content item as deleted if no association
+// links it, checking the references in the same statement
+func (r *PostgresRepository) DeleteContentIfUnreferenced(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE contents SET
+			deleted_at = $1
+		WHERE id = $2 AND deleted_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM content_entity_associations WHERE content_id = $3)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, r.clock.Now(), id, id.String())
+	if err != nil {
+		return err
+	}
 
-	if filter.MIMEType != "" {
-		where += " AND mime_type = $" + string(paramCount)
-		params = append(params, filter.MIMEType)
-		paramCount++
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
 	}
 
-	if filter.MinSize != nil {
-		where += " AND size >= $" + string(paramCount)
-		params = append(params, *filter.MinSize)
-		paramCount++
+	if rowsAffected == 0 {
+		// Tell a missing row from a referenced one
+		if _, err := r.GetContentByID(ctx, id); err != nil {
+			return err
+		}
+		return repository.ErrContentReferenced
 	}
 
-	if filter.MaxSize != nil {
-		where += " AND size <= $" + string(paramCount)
-		params = append(params, *filter.MaxSize)
-		paramCount++
+	return nil
+}
+
+// DeleteContents marks several content items as deleted in one statement
+func (r *PostgresRepository) DeleteContents(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error) {
+	deleted := []uuid.UUID{}
+	if len(ids) == 0 {
+		return deleted, nil
 	}
 
-	if filter.CreatedFrom != nil {
-		where += " AND created_at >= $" + string(paramCount)
-		params = append(params, *filter.CreatedFrom)
-		paramCount++
+	query, args, err := sqlx.In(`
+		UPDATE contents SET
+			deleted_at = ?
+		WHERE id IN (?) AND deleted_at IS NULL
+		RETURNING id
+	`, r.clock.Now(), ids)
+	if err != nil {
+		return nil, err
 	}
 
+	if err := r.db.SelectContext(ctx, &deleted, sqlx.Rebind(sqlx.DOLLAR, query), args...); err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
+
+// TransferOwnership hands the content of a tenant over from one creator to
+// another in one statement
+func (r *PostgresRepository) TransferOwnership(ctx context.Context, tenantID, from, to string, ids []uuid.UUID) ([]uuid.UUID, error) {
+	args := queryArgs{to, r.clock.Now(), tenantID, from}
+	query := `
+		UPDATE contents SET
+			created_by = $1,
+			updated_at = $2
+		WHERE tenant_id = $3 AND created_by = $4 AND deleted_at IS NULL`
+	if len(ids) > 0 {
+		placeholders := make([]string, len(ids))
+		for i, id := range ids {
+			placeholders[i] = args.add(id)
+		}
+		query += " AND id IN (" + strings.Join(placeholders, ", ") + ")"
+	}
+
+	transferred := []uuid.UUID{}
+	if err := r.db.SelectContext(ctx, &transferred, query+" RETURNING id", args...); err != nil {
+		return nil, err
+	}
+	return transferred, nil
+}
+
+// queryArgs holds the arguments of a query, numbering their placeholders as
+// they are added
+type queryArgs []interface{}
+
+// add appends an argument and returns its placeholder
+func (a *queryArgs) add(value interface{}) string {
+	*a = append(*a, value)
+	return "$" + strconv.Itoa(len(*a))
+}
+
+// buildWhereClause constructs the WHERE clause for filtering. Conditions are
+// added in a fixed order, so equal filters give the same query text and
+// share a prepared statement. String values of indexed metadata keys are
+// compared through the indexed expression.
+func buildWhereClause(filter model.ContentFilter, metadataIndexes map[string]bool) (string, queryArgs) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args queryArgs
+
+	if filter.TenantID != "" {
+		conditions = append(conditions, "tenant_id = "+args.add(filter.TenantID))
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = "+args.add(filter.Status))
+	}
+	if filter.DerivedFromID != nil {
+		conditions = append(conditions, "derived_from_id = "+args.add(*filter.DerivedFromID))
+	}
+	if filter.CreatedBy != "" {
+		conditions = append(conditions, "created_by = "+args.add(filter.CreatedBy))
+	}
+	switch filter.Kind {
+	case model.KindReference:
+		conditions = append(conditions, "kind = 'reference'")
+	case model.KindFile:
+		conditions = append(conditions, "kind <> 'reference'")
+	}
+	if filter.DraftsExpired != nil {
+		conditions = append(conditions, "draft_expires_at <= "+args.add(*filter.DraftsExpired))
+	}
+	if filter.PinnedBy != "" {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM content_pins p WHERE p.content_id = contents.id AND p.principal = "+args.add(filter.PinnedBy)+")")
+	}
+	if filter.FileName != "" {
+		switch filter.FileNameMatch {
+		case model.MatchPrefix:
+			conditions = append(conditions, "name ILIKE "+args.add(escapeLike(filter.FileName)+"%"))
+		case model.MatchFuzzy:
+			conditions = append(conditions, args.add(filter.FileName)+" <% name")
+		default:
+			conditions = append(conditions, "name ILIKE "+args.add("%"+escapeLike(filter.FileName)+"%"))
+		}
+	}
+	if filter.Text != "" {
+		pattern := args.add("%" + escapeLike(filter.Text) + "%")
+		conditions = append(conditions, "(name ILIKE "+pattern+" OR description ILIKE "+pattern+")")
+	}
+	if filter.MIMEType != "" {
+		conditions = append(conditions, "mime_type = "+args.add(filter.MIMEType))
+	}
+	if filter.MinSize != nil {
+		conditions = append(conditions, "size >= "+args.add(*filter.MinSize))
+	}
+	if filter.MaxSize != nil {
+		conditions = append(conditions, "size <= "+args.add(*filter.MaxSize))
+	}
+	if filter.CreatedFrom != nil {
+		conditions = append(conditions, "created_at >= "+args.add(*filter.CreatedFrom))
+	}
 	if filter.CreatedTo != nil {
-		where += " AND created_at <= $" + string(paramCount)
-		params = append(params, *filter.CreatedTo)
-		paramCount++
+		conditions = append(conditions, "created_at <= "+args.add(*filter.CreatedTo))
+	}
+	if filter.UpdatedFrom != nil {
+		conditions = append(conditions, "updated_at >= "+args.add(*filter.UpdatedFrom))
 	}
 
 	// Metadata filtering is more complex with JSON
-	if len(filter.Metadata) > 0 {
-		for key, value := range filter.Metadata {
-			where += " AND metadata->$" + string(paramCount) + " = $" + string(paramCount+1)
-			params = append(params, key, value)
-			paramCount += 2
+	keys := make([]string, 0, len(filter.Metadata))
+	for key := range filter.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if value, ok := filter.Metadata[key].(string); ok && metadataIndexes[key] {
+			conditions = append(conditions, metadataIndexExpression(key)+" = "+args.add(value),
+				"jsonb_typeof(metadata->'"+key+"') = 'string'")
+			continue
 		}
+		conditions = append(conditions, "metadata->"+args.add(key)+" = "+args.add(filter.Metadata[key]))
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// sortColumns maps sort fields to their columns
+var sortColumns = map[model.ContentSortField]string{
+	model.SortByCreatedAt: "created_at",
+	model.SortByUpdatedAt: "updated_at",
+	model.SortByFileName:  "name",
+	model.SortByFileSize:  "size",
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// orderClause constructs the ORDER BY clause of a listing, newest first by
+// default, or closest file names first for fuzzy file name searches
+func orderClause(filter model.ContentFilter, params *queryArgs) string {
+	if filter.FileName != "" && filter.FileNameMatch == model.MatchFuzzy && filter.SortBy == "" {
+		return "word_similarity(" + params.add(filter.FileName) + ", name) DESC, id"
+	}
+	column, ok := sortColumns[filter.SortBy]
+	if !ok {
+		column = "created_at"
+	}
+	direction := " DESC"
+	if filter.SortAscending {
+		direction = " ASC"
+	}
+	return column + direction + ", id"
+}
+
+// groupKeys maps content groupings to the SQL expression of their key
+var groupKeys = map[model.ContentGroupBy]string{
+	model.GroupByMIMEType: "mime_type",
+	model.GroupBySource:   "source",
+	model.GroupByStatus:   "status",
+	model.GroupByDay:      "to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD')",
+}
+
+// ContentStats counts the content matching a filter and sums its size per group
+func (r *PostgresRepository) ContentStats(ctx context.Context, filter model.ContentFilter, groupBy model.ContentGroupBy) ([]*model.ContentStatsBucket, error) {
+	key, ok := groupKeys[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown grouping %q", groupBy)
+	}
+	whereClause, params := buildWhereClause(filter, r.metadataIndexes)
+
+	query := "SELECT " + key + " AS key, COUNT(*) AS count, COALESCE(SUM(file_size), 0) AS total_bytes" +
+		" FROM contents WHERE " + whereClause + " GROUP BY 1 ORDER BY 1"
+
+	var rows []struct {
+		Key        sql.NullString `db:"key"`
+		Count      int64          `db:"count"`
+		TotalBytes int64          `db:"total_bytes"`
+	}
+	if err := r.reader(ctx).SelectContext(ctx, &rows, query, params...); err != nil {
+		return nil, err
 	}
 
-	return where, params
+	stats := make([]*model.ContentStatsBucket, len(rows))
+	for i, row := range rows {
+		stats[i] = &model.ContentStatsBucket{Key: row.Key.String, Count: row.Count, TotalBytes: row.TotalBytes}
+	}
+	return stats, nil
 }
 
 // List retrieves content items based on filter criteria
 func (r *PostgresRepository) ListContent(ctx context.Context, filter model.ContentFilter, offset, limit int) ([]*model.Content, int, error) {
-	whereClause, params := buildWhereClause(filter)
+	whereClause, params := buildWhereClause(filter, r.metadataIndexes)
 
 	// Count total matching records
 	countQuery := "SELECT COUNT(*) FROM contents WHERE " + whereClause
 	var totalCount int
-	if err := r.db.GetContext(ctx, &totalCount, countQuery, params...); err != nil {
+	if err := r.reader(ctx).GetContext(ctx, &totalCount, countQuery, params...); err != nil {
 		return nil, 0, err
 	}
 
 	// Get paginated results
-	query := "SELECT * FROM contents WHERE " + whereClause + " ORDER BY created_at DESC LIMIT $" + string(len(params)+1) + " OFFSET $" + string(len(params)+2)
-	params = append(params, limit, offset)
+	query := "SELECT * FROM contents WHERE " + whereClause + " ORDER BY " + orderClause(filter, &params) + " LIMIT " + params.add(limit) + " OFFSET " + params.add(offset)
 
 	var dbContents []contentDB
-	if err := r.db.SelectContext(ctx, &dbContents, query, params...); err != nil {
+	if err := r.reader(ctx).SelectContext(ctx, &dbContents, query, params...); err != nil {
 		return nil, 0, err
 	}
 
@@ -294,3 +732,129 @@ func (r *PostgresRepository) ListContent(ctx context.Context, filter model.Conte
 
 	return contents, totalCount, nil
 }
+
+// ListContentPage retrieves a page of content items, fetching one row past
+// the page to tell whether more follow
+func (r *PostgresRepository) ListContentPage(ctx context.Context, filter model.ContentFilter, options repository.ListOptions) (*repository.ContentPage, error) {
+	whereClause, params := buildWhereClause(filter, r.metadataIndexes)
+	page := &repository.ContentPage{Total: -1}
+
+	switch {
+	case options.ReturnTotal && options.EstimateTotal:
+		total, err := r.estimateRows(ctx, "SELECT 1 FROM contents WHERE "+whereClause, params)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = total
+		page.TotalEstimated = true
+	case options.ReturnTotal:
+		if err := r.reader(ctx).GetContext(ctx, &page.Total, "SELECT COUNT(*) FROM contents WHERE "+whereClause, params...); err != nil {
+			return nil, err
+		}
+	}
+
+	query := "SELECT * FROM contents WHERE " + whereClause + " ORDER BY " + orderClause(filter, &params)
+	if options.PageSize > 0 {
+		query += " LIMIT " + params.add(options.PageSize+1) + " OFFSET " + params.add(options.Offset())
+	}
+
+	var dbContents []contentDB
+	if err := r.reader(ctx).SelectContext(ctx, &dbContents, query, params...); err != nil {
+		return nil, err
+	}
+	if options.PageSize > 0 && len(dbContents) > options.PageSize {
+		dbContents = dbContents[:options.PageSize]
+		page.HasMore = true
+	}
+
+	page.Items = make([]*model.Content, len(dbContents))
+	for i, dbContent := range dbContents {
+		content, err := dbContent.toModel()
+		if err != nil {
+			return nil, err
+		}
+		page.Items[i] = content
+	}
+	return page, nil
+}
+
+// ListContentByMetadataKey lists content in byte order of a string metadata
+// value, compared with the C collation so the order doesn't depend on the
+// locale and the expression index of the key, if it has one, can be used
+func (r *PostgresRepository) ListContentByMetadataKey(ctx context.Context, filter model.ContentFilter, key, prefix, from string, limit int) ([]*model.Content, error) {
+	whereClause, params := buildWhereClause(filter, r.metadataIndexes)
+	var value string
+	if r.metadataIndexes[key] {
+		value = metadataIndexExpression(key)
+	} else {
+		value = "(metadata->>" + params.add(key) + ") COLLATE \"C\""
+	}
+	query := "SELECT * FROM contents WHERE " + whereClause +
+		" AND jsonb_typeof(metadata->" + params.add(key) + ") = 'string'" +
+		" AND starts_with(metadata->>" + params.add(key) + ", " + params.add(prefix) + ")" +
+		" AND " + value + " >= " + params.add(from) +
+		" ORDER BY " + value + ", id LIMIT " + params.add(limit)
+
+	var dbContents []contentDB
+	if err := r.reader(ctx).SelectContext(ctx, &dbContents, query, params...); err != nil {
+		return nil, err
+	}
+	contents := make([]*model.Content, len(dbContents))
+	for i, dbContent := range dbContents {
+		content, err := dbContent.toModel()
+		if err != nil {
+			return nil, err
+		}
+		contents[i] = content
+	}
+	return contents, nil
+}
+
+// estimateRows returns the planner's estimate of the number of rows a query returns
+func (r *PostgresRepository) estimateRows(ctx context.Context, query string, params queryArgs) (int64, error) {
+	var plan []byte
+	if err := r.reader(ctx).GetContext(ctx, &plan, "EXPLAIN (FORMAT JSON) "+query, params...); err != nil {
+		return 0, err
+	}
+
+	var explained []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(plan, &explained); err != nil {
+		return 0, fmt.Errorf("failed to parse query plan: %w", err)
+	}
+	if len(explained) == 0 {
+		return 0, errors.New("empty query plan")
+	}
+	return int64(explained[0].Plan.Rows), nil
+}
+
+// ListContentStream calls fn for every content item matching a filter as
+// rows are read from the database
+func (r *PostgresRepository) ListContentStream(ctx context.Context, filter model.ContentFilter, fn func(*model.Content) error) error {
+	whereClause, params := buildWhereClause(filter, r.metadataIndexes)
+	query := "SELECT * FROM contents WHERE " + whereClause + " ORDER BY " + orderClause(filter, &params)
+
+	rows, err := r.reader(ctx).QueryxContext(ctx, query, params...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var dbContent contentDB
+		if err := rows.StructScan(&dbContent); err != nil {
+			return err
+		}
+		content, err := dbContent.toModel()
+		if err != nil {
+			return err
+		}
+		if err := fn(content); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
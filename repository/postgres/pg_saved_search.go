@@ -0,0 +1,207 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// savedSearchDB is a database model for a saved search
+type savedSearchDB struct {
+	ID          uuid.UUID `db:"id"`
+	Principal   string    `db:"principal"`
+	TenantID    string    `db:"tenant_id"`
+	Name        string    `db:"name"`
+	Description string    `db:"description"`
+	Filter      string    `db:"filter"` // JSON stored as string
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+// toModel converts a database model to a domain model
+func (s *savedSearchDB) toModel() (*model.SavedSearch, error) {
+	search := &model.SavedSearch{
+		ID:          s.ID,
+		Principal:   s.Principal,
+		TenantID:    s.TenantID,
+		Name:        s.Name,
+		Description: s.Description,
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+	}
+	if err := json.Unmarshal([]byte(s.Filter), &search.Filter); err != nil {
+		return nil, err
+	}
+	return search, nil
+}
+
+// savedSearchFromModel converts a domain model to a database model
+func savedSearchFromModel(search *model.SavedSearch) (*savedSearchDB, error) {
+	filter, err := json.Marshal(search.Filter)
+	if err != nil {
+		return nil, err
+	}
+	return &savedSearchDB{
+		ID:          search.ID,
+		Principal:   search.Principal,
+		TenantID:    search.TenantID,
+		Name:        search.Name,
+		Description: search.Description,
+		Filter:      string(filter),
+		CreatedAt:   search.CreatedAt,
+		UpdatedAt:   search.UpdatedAt,
+	}, nil
+}
+
+// CreateSavedSearch stores a new saved search
+func (r *PostgresRepository) CreateSavedSearch(ctx context.Context, search *model.SavedSearch) error {
+	if search.ID == uuid.Nil {
+		search.ID = r.ids.NewID()
+	}
+	now := r.clock.Now()
+	search.CreatedAt = now
+	search.UpdatedAt = now
+
+	dbSearch, err := savedSearchFromModel(search)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO saved_searches (
+			id, principal, tenant_id, name, description, filter, created_at, updated_at
+		) VALUES (
+			:id, :principal, :tenant_id, :name, :description, :filter, :created_at, :updated_at
+		)
+		ON CONFLICT (principal, name) DO NOTHING
+	`
+
+	result, err := r.db.NamedExecContext(ctx, query, dbSearch)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrSavedSearchExists
+	}
+
+	return nil
+}
+
+// GetSavedSearch retrieves a saved search by its ID
+func (r *PostgresRepository) GetSavedSearch(ctx context.Context, id uuid.UUID) (*model.SavedSearch, error) {
+	var dbSearch savedSearchDB
+	if err := r.db.GetContext(ctx, &dbSearch, `SELECT * FROM saved_searches WHERE id = $1`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrSavedSearchNotFound
+		}
+		return nil, err
+	}
+
+	return dbSearch.toModel()
+}
+
+// ListSavedSearchesByPrincipal retrieves a page of the searches of a principal ordered by name
+func (r *PostgresRepository) ListSavedSearchesByPrincipal(ctx context.Context, principal string, options repository.ListOptions) ([]*model.SavedSearch, int64, error) {
+	var total int64
+	if options.ReturnTotal {
+		if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM saved_searches WHERE principal = $1`, principal); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	query := `SELECT * FROM saved_searches WHERE principal = $1 ORDER BY name`
+	args := queryArgs{principal}
+	if options.PageSize > 0 {
+		query += " LIMIT " + args.add(options.PageSize) + " OFFSET " + args.add(options.Offset())
+	}
+
+	var dbSearches []savedSearchDB
+	if err := r.db.SelectContext(ctx, &dbSearches, query, args...); err != nil {
+		return nil, 0, err
+	}
+
+	searches := make([]*model.SavedSearch, len(dbSearches))
+	for i := range dbSearches {
+		search, err := dbSearches[i].toModel()
+		if err != nil {
+			return nil, 0, err
+		}
+		searches[i] = search
+	}
+
+	return searches, total, nil
+}
+
+// UpdateSavedSearch updates an existing saved search
+func (r *PostgresRepository) UpdateSavedSearch(ctx context.Context, search *model.SavedSearch) error {
+	search.UpdatedAt = r.clock.Now()
+
+	dbSearch, err := savedSearchFromModel(search)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE saved_searches SET
+			name = :name,
+			description = :description,
+			filter = :filter,
+			updated_at = :updated_at
+		WHERE id = :id
+			AND NOT EXISTS (
+				SELECT 1 FROM saved_searches other
+				WHERE other.principal = :principal AND other.name = :name AND other.id <> :id
+			)
+	`
+
+	result, err := r.db.NamedExecContext(ctx, query, dbSearch)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		// Tell a missing search from a name clash
+		if _, err := r.GetSavedSearch(ctx, search.ID); err != nil {
+			return err
+		}
+		return repository.ErrSavedSearchExists
+	}
+
+	return nil
+}
+
+// DeleteSavedSearch removes a saved search
+func (r *PostgresRepository) DeleteSavedSearch(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM saved_searches WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrSavedSearchNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// scanResultDB is a database model for a virus scan result
+type scanResultDB struct {
+	Digest           string    `db:"digest"`
+	SignatureVersion string    `db:"signature_version"`
+	Infected         bool      `db:"infected"`
+	Signature        string    `db:"signature"`
+	ScannedAt        time.Time `db:"scanned_at"`
+}
+
+// GetScanResult retrieves the result of scanning a digest with a signature database version
+func (r *PostgresRepository) GetScanResult(ctx context.Context, digest, signatureVersion string) (*model.ScanResult, error) {
+	var dbResult scanResultDB
+	query := `SELECT * FROM scan_results WHERE digest = $1 AND signature_version = $2`
+	if err := r.reader(ctx).GetContext(ctx, &dbResult, query, digest, signatureVersion); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrScanResultNotFound
+		}
+		return nil, err
+	}
+
+	return &model.ScanResult{
+		Digest:           dbResult.Digest,
+		SignatureVersion: dbResult.SignatureVersion,
+		Infected:         dbResult.Infected,
+		Signature:        dbResult.Signature,
+		ScannedAt:        dbResult.ScannedAt,
+	}, nil
+}
+
+// SaveScanResult stores a scan result
+func (r *PostgresRepository) SaveScanResult(ctx context.Context, result *model.ScanResult) error {
+	query := `
+		INSERT INTO scan_results (digest, signature_version, infected, signature, scanned_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (digest, signature_version) DO UPDATE
+		SET infected = EXCLUDED.infected, signature = EXCLUDED.signature, scanned_at = EXCLUDED.scanned_at
+	`
+	_, err := r.db.ExecContext(ctx, query, result.Digest, result.SignatureVersion, result.Infected, result.Signature, result.ScannedAt)
+	return err
+}
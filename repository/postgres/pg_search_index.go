@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// SearchIndexes names the indexes content is searched with: the trigram
+// index of file names and the expression indexes of metadata keys, as far
+// as their migrations were applied
+func (r *PostgresRepository) SearchIndexes(ctx context.Context) ([]string, error) {
+	if r.conn == nil {
+		return nil, errors.New("search indexes can't be rebuilt in a transaction")
+	}
+	names := []string{}
+	query := `
+		SELECT indexname FROM pg_indexes
+		WHERE schemaname = current_schema() AND tablename = 'contents'
+			AND (indexname = 'contents_name_trgm_idx' OR indexname LIKE 'contents\_metadata\_%\_idx')
+		ORDER BY indexname
+	`
+	if err := r.conn.SelectContext(ctx, &names, query); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// RebuildSearchIndex rebuilds a search index with REINDEX CONCURRENTLY,
+// which doesn't block writes to the contents table meanwhile
+func (r *PostgresRepository) RebuildSearchIndex(ctx context.Context, name string) error {
+	// The name is written into the statement, so it must be one of ours
+	names, err := r.SearchIndexes(ctx)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, n := range names {
+		found = found || n == name
+	}
+	if !found {
+		return fmt.Errorf("%w: %s", repository.ErrSearchIndexNotFound, name)
+	}
+
+	if _, err := r.conn.ExecContext(ctx, `REINDEX INDEX CONCURRENTLY "`+name+`"`); err != nil {
+		return fmt.Errorf("failed to rebuild index %s: %w", name, err)
+	}
+	return nil
+}
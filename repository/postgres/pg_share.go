@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// shareDB is a database model for a share
+type shareDB struct {
+	Token            string       `db:"token"`
+	ContentID        uuid.UUID    `db:"content_id"`
+	PasswordHash     string       `db:"password_hash"`
+	CreatedBy        string       `db:"created_by"`
+	ExpiresAt        sql.NullTime `db:"expires_at"`
+	MaxDownloads     int64        `db:"max_downloads"`
+	Downloads        int64        `db:"downloads"`
+	LastDownloadedAt sql.NullTime `db:"last_downloaded_at"`
+	RevokedAt        sql.NullTime `db:"revoked_at"`
+	CreatedAt        time.Time    `db:"created_at"`
+}
+
+// toModel converts a database model to a domain model
+func (s *shareDB) toModel() *model.Share {
+	share := &model.Share{
+		Token:        s.Token,
+		ContentID:    s.ContentID,
+		PasswordHash: s.PasswordHash,
+		CreatedBy:    s.CreatedBy,
+		MaxDownloads: s.MaxDownloads,
+		Downloads:    s.Downloads,
+		CreatedAt:    s.CreatedAt,
+	}
+	if s.ExpiresAt.Valid {
+		share.ExpiresAt = &s.ExpiresAt.Time
+	}
+	if s.LastDownloadedAt.Valid {
+		share.LastDownloadedAt = &s.LastDownloadedAt.Time
+	}
+	if s.RevokedAt.Valid {
+		share.RevokedAt = &s.RevokedAt.Time
+	}
+	return share
+}
+
+// CreateShare stores a new share
+func (r *PostgresRepository) CreateShare(ctx context.Context, share *model.Share) error {
+	share.CreatedAt = r.clock.Now()
+
+	query := `
+		INSERT INTO shares (
+			token, content_id, password_hash, created_by, expires_at, max_downloads, downloads, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, 0, $7
+		)
+		ON CONFLICT (token) DO NOTHING
+	`
+
+	result, err := r.db.ExecContext(ctx, query, share.Token, share.ContentID, share.PasswordHash, share.CreatedBy, share.ExpiresAt, share.MaxDownloads, share.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrShareExists
+	}
+
+	return nil
+}
+
+// GetShare retrieves a share by its token
+func (r *PostgresRepository) GetShare(ctx context.Context, token string) (*model.Share, error) {
+	var dbShare shareDB
+	if err := r.db.GetContext(ctx, &dbShare, `SELECT * FROM shares WHERE token = $1`, token); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrShareNotFound
+		}
+		return nil, err
+	}
+
+	return dbShare.toModel(), nil
+}
+
+// ListSharesByContent retrieves a page of the shares of a content item, newest first
+func (r *PostgresRepository) ListSharesByContent(ctx context.Context, contentID uuid.UUID, options repository.ListOptions) ([]*model.Share, int64, error) {
+	var total int64
+	if options.ReturnTotal {
+		if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM shares WHERE content_id = $1`, contentID); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	query := `SELECT * FROM shares WHERE content_id = $1 ORDER BY created_at DESC`
+	args := queryArgs{contentID}
+	if options.PageSize > 0 {
+		query += " LIMIT " + args.add(options.PageSize) + " OFFSET " + args.add(options.Offset())
+	}
+
+	var dbShares []shareDB
+	if err := r.db.SelectContext(ctx, &dbShares, query, args...); err != nil {
+		return nil, 0, err
+	}
+
+	shares := make([]*model.Share, len(dbShares))
+	for i := range dbShares {
+		shares[i] = dbShares[i].toModel()
+	}
+
+	return shares, total, nil
+}
+
+// RecordShareDownload atomically counts a download through a share, unless
+// it has none left
+func (r *PostgresRepository) RecordShareDownload(ctx context.Context, token string, downloadedAt time.Time) error {
+	query := `
+		UPDATE shares SET downloads = downloads + 1, last_downloaded_at = $2
+		WHERE token = $1 AND (max_downloads = 0 OR downloads < max_downloads)
+	`
+	result, err := r.db.ExecContext(ctx, query, token, downloadedAt)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		// Tell a missing share from one used up
+		if _, err := r.GetShare(ctx, token); err != nil {
+			return err
+		}
+		return repository.ErrShareExhausted
+	}
+
+	return nil
+}
+
+// RevokeShare marks a share revoked
+func (r *PostgresRepository) RevokeShare(ctx context.Context, token string, revokedAt time.Time) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE shares SET revoked_at = COALESCE(revoked_at, $2) WHERE token = $1`, token, revokedAt)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrShareNotFound
+	}
+
+	return nil
+}
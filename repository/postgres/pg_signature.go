@@ -0,0 +1,207 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// signatureDB is a database model for a signature request
+type signatureDB struct {
+	ID                uuid.UUID     `db:"id"`
+	ContentID         uuid.UUID     `db:"content_id"`
+	Provider          string        `db:"provider"`
+	ProviderRequestID string        `db:"provider_request_id"`
+	Status            string        `db:"status"`
+	Signers           string        `db:"signers"` // JSON array stored as string
+	Subject           string        `db:"subject"`
+	SignedContentID   uuid.NullUUID `db:"signed_content_id"`
+	Error             string        `db:"error"`
+	CreatedBy         string        `db:"created_by"`
+	CreatedAt         time.Time     `db:"created_at"`
+	UpdatedAt         time.Time     `db:"updated_at"`
+	CompletedAt       sql.NullTime  `db:"completed_at"`
+}
+
+// toModel converts a database model to a domain model
+func (s *signatureDB) toModel() (*model.SignatureRequest, error) {
+	request := &model.SignatureRequest{
+		ID:                s.ID,
+		ContentID:         s.ContentID,
+		Provider:          s.Provider,
+		ProviderRequestID: s.ProviderRequestID,
+		Status:            s.Status,
+		Subject:           s.Subject,
+		Error:             s.Error,
+		CreatedBy:         s.CreatedBy,
+		CreatedAt:         s.CreatedAt,
+		UpdatedAt:         s.UpdatedAt,
+	}
+
+	if err := json.Unmarshal([]byte(s.Signers), &request.Signers); err != nil {
+		return nil, err
+	}
+	if s.SignedContentID.Valid {
+		request.SignedContentID = &s.SignedContentID.UUID
+	}
+	if s.CompletedAt.Valid {
+		request.CompletedAt = &s.CompletedAt.Time
+	}
+
+	return request, nil
+}
+
+// signatureFromModel converts a domain model to a database model
+func signatureFromModel(request *model.SignatureRequest) (*signatureDB, error) {
+	signers, err := json.Marshal(request.Signers)
+	if err != nil {
+		return nil, err
+	}
+
+	dbRequest := &signatureDB{
+		ID:                request.ID,
+		ContentID:         request.ContentID,
+		Provider:          request.Provider,
+		ProviderRequestID: request.ProviderRequestID,
+		Status:            request.Status,
+		Signers:           string(signers),
+		Subject:           request.Subject,
+		Error:             request.Error,
+		CreatedBy:         request.CreatedBy,
+		CreatedAt:         request.CreatedAt,
+		UpdatedAt:         request.UpdatedAt,
+	}
+
+	if request.SignedContentID != nil {
+		dbRequest.SignedContentID = uuid.NullUUID{UUID: *request.SignedContentID, Valid: true}
+	}
+	if request.CompletedAt != nil {
+		dbRequest.CompletedAt = sql.NullTime{Time: *request.CompletedAt, Valid: true}
+	}
+
+	return dbRequest, nil
+}
+
+// CreateSignatureRequest stores a new signature request
+func (r *PostgresRepository) CreateSignatureRequest(ctx context.Context, request *model.SignatureRequest) error {
+	if request.ID == uuid.Nil {
+		request.ID = r.ids.NewID()
+	}
+
+	now := r.clock.Now()
+	request.CreatedAt = now
+	request.UpdatedAt = now
+
+	dbRequest, err := signatureFromModel(request)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO signature_requests (
+			id, content_id, provider, provider_request_id, status, signers, subject, signed_content_id, error, created_by, created_at, updated_at, completed_at
+		) VALUES (
+			:id, :content_id, :provider, :provider_request_id, :status, :signers, :subject, :signed_content_id, :error, :created_by, :created_at, :updated_at, :completed_at
+		)
+	`
+
+	_, err = r.db.NamedExecContext(ctx, query, dbRequest)
+	return err
+}
+
+// getSignatureRequest runs a query that selects a single signature request
+func (r *PostgresRepository) getSignatureRequest(ctx context.Context, query string, args ...interface{}) (*model.SignatureRequest, error) {
+	var dbRequest signatureDB
+	if err := r.db.GetContext(ctx, &dbRequest, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrSignatureNotFound
+		}
+		return nil, err
+	}
+
+	return dbRequest.toModel()
+}
+
+// GetSignatureRequest retrieves a signature request by its ID
+func (r *PostgresRepository) GetSignatureRequest(ctx context.Context, id uuid.UUID) (*model.SignatureRequest, error) {
+	return r.getSignatureRequest(ctx, `SELECT * FROM signature_requests WHERE id = $1`, id)
+}
+
+// GetSignatureRequestByProviderID retrieves a signature request by the provider's identifier
+func (r *PostgresRepository) GetSignatureRequestByProviderID(ctx context.Context, provider, providerRequestID string) (*model.SignatureRequest, error) {
+	return r.getSignatureRequest(ctx, `SELECT * FROM signature_requests WHERE provider = $1 AND provider_request_id = $2`, provider, providerRequestID)
+}
+
+// ListSignatureRequestsByContent retrieves a page of the signature requests of a content item, newest first
+func (r *PostgresRepository) ListSignatureRequestsByContent(ctx context.Context, contentID uuid.UUID, options repository.ListOptions) ([]*model.SignatureRequest, int64, error) {
+	var total int64
+	if options.ReturnTotal {
+		if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM signature_requests WHERE content_id = $1`, contentID); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	query := `SELECT * FROM signature_requests WHERE content_id = $1 ORDER BY created_at DESC`
+	args := queryArgs{contentID}
+	if options.PageSize > 0 {
+		query += " LIMIT " + args.add(options.PageSize) + " OFFSET " + args.add(options.Offset())
+	}
+
+	var dbRequests []signatureDB
+	if err := r.db.SelectContext(ctx, &dbRequests, query, args...); err != nil {
+		return nil, 0, err
+	}
+
+	requests := make([]*model.SignatureRequest, len(dbRequests))
+	for i := range dbRequests {
+		request, err := dbRequests[i].toModel()
+		if err != nil {
+			return nil, 0, err
+		}
+		requests[i] = request
+	}
+
+	return requests, total, nil
+}
+
+// UpdateSignatureRequest updates the status and outcome of a signature request
+func (r *PostgresRepository) UpdateSignatureRequest(ctx context.Context, request *model.SignatureRequest) error {
+	request.UpdatedAt = r.clock.Now()
+
+	dbRequest, err := signatureFromModel(request)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE signature_requests SET
+			status = :status,
+			signed_content_id = :signed_content_id,
+			error = :error,
+			updated_at = :updated_at,
+			completed_at = :completed_at
+		WHERE id = :id
+	`
+
+	result, err := r.db.NamedExecContext(ctx, query, dbRequest)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrSignatureNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,227 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// maxCachedStatements bounds the prepared statements kept open. Listings
+// build their queries from the filter, so there is no fixed set of query
+// texts; queries past the limit run unprepared.
+const maxCachedStatements = 256
+
+// stmtCache prepares each query text once and reuses the statement, so the
+// database parses and plans it once instead of on every call
+type stmtCache struct {
+	db    *sqlx.DB
+	mu    sync.Mutex
+	stmts map[string]*sqlx.Stmt
+	named map[string]*sqlx.NamedStmt
+
+	// driverCached is set for drivers that prepare and cache statements
+	// themselves, like pgx; nothing is prepared here then
+	driverCached bool
+}
+
+func newStmtCache(db *sqlx.DB) *stmtCache {
+	return &stmtCache{
+		db:    db,
+		stmts: make(map[string]*sqlx.Stmt),
+		named: make(map[string]*sqlx.NamedStmt),
+	}
+}
+
+// full reports whether no more statements can be cached. The caller must hold the lock.
+func (c *stmtCache) full() bool {
+	return len(c.stmts)+len(c.named) >= maxCachedStatements
+}
+
+// stmt returns the prepared statement of a query, preparing it unless
+// prepare is false. It returns nil if the query isn't prepared.
+func (c *stmtCache) stmt(ctx context.Context, query string, prepare bool) (*sqlx.Stmt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if stmt, ok := c.stmts[query]; ok || !prepare || c.driverCached || c.full() {
+		return stmt, nil
+	}
+	stmt, err := c.db.PreparexContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	c.stmts[query] = stmt
+	return stmt, nil
+}
+
+// namedStmt is stmt for queries with named parameters
+func (c *stmtCache) namedStmt(ctx context.Context, query string, prepare bool) (*sqlx.NamedStmt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if stmt, ok := c.named[query]; ok || !prepare || c.driverCached || c.full() {
+		return stmt, nil
+	}
+	stmt, err := c.db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	c.named[query] = stmt
+	return stmt, nil
+}
+
+// Close closes the cached statements
+func (c *stmtCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var firstErr error
+	for query, stmt := range c.stmts {
+		if err := stmt.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(c.stmts, query)
+	}
+	for query, stmt := range c.named {
+		if err := stmt.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(c.named, query)
+	}
+	return firstErr
+}
+
+// preparedQueryer runs queries through the statement cache, on the pool or
+// inside a transaction
+type preparedQueryer struct {
+	cache   *stmtCache
+	tx      *sqlx.Tx      // nil outside a transaction
+	timeout time.Duration // Bounds each query if set
+}
+
+// isWrite reports whether a query changes data
+func isWrite(query string) bool {
+	verb := strings.TrimSpace(query)
+	if end := strings.IndexFunc(verb, unicode.IsSpace); end >= 0 {
+		verb = verb[:end]
+	}
+	switch strings.ToUpper(verb) {
+	case "INSERT", "UPDATE", "DELETE":
+		return true
+	}
+	return false
+}
+
+// withTimeout bounds a query by the statement timeout
+func (q *preparedQueryer) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if q.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, q.timeout)
+}
+
+// raw returns the queryer to run unprepared queries on
+func (q *preparedQueryer) raw() queryer {
+	if q.tx != nil {
+		return q.tx
+	}
+	return q.cache.db
+}
+
+// stmt returns the statement to run a query with, nil to run it unprepared.
+// Inside a transaction only statements already prepared are used, since
+// preparing would need a second connection from the pool.
+func (q *preparedQueryer) stmt(ctx context.Context, query string) (*sqlx.Stmt, error) {
+	stmt, err := q.cache.stmt(ctx, query, q.tx == nil)
+	if err != nil || stmt == nil || q.tx == nil {
+		return stmt, err
+	}
+	return q.tx.StmtxContext(ctx, stmt), nil
+}
+
+func (q *preparedQueryer) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	repository.NoteWrite(ctx)
+	ctx, cancel := q.withTimeout(ctx)
+	defer cancel()
+
+	stmt, err := q.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if stmt == nil {
+		return q.raw().ExecContext(ctx, query, args...)
+	}
+	return stmt.ExecContext(ctx, args...)
+}
+
+func (q *preparedQueryer) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	repository.NoteWrite(ctx)
+	ctx, cancel := q.withTimeout(ctx)
+	defer cancel()
+
+	stmt, err := q.cache.namedStmt(ctx, query, q.tx == nil)
+	if err != nil {
+		return nil, err
+	}
+	if stmt == nil {
+		return q.raw().NamedExecContext(ctx, query, arg)
+	}
+	if q.tx != nil {
+		stmt = q.tx.NamedStmtContext(ctx, stmt)
+	}
+	return stmt.ExecContext(ctx, arg)
+}
+
+func (q *preparedQueryer) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	if isWrite(query) {
+		repository.NoteWrite(ctx)
+	}
+	ctx, cancel := q.withTimeout(ctx)
+	defer cancel()
+
+	stmt, err := q.stmt(ctx, query)
+	if err != nil {
+		return err
+	}
+	if stmt == nil {
+		return q.raw().GetContext(ctx, dest, query, args...)
+	}
+	return stmt.GetContext(ctx, dest, args...)
+}
+
+func (q *preparedQueryer) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	if isWrite(query) {
+		repository.NoteWrite(ctx)
+	}
+	ctx, cancel := q.withTimeout(ctx)
+	defer cancel()
+
+	stmt, err := q.stmt(ctx, query)
+	if err != nil {
+		return err
+	}
+	if stmt == nil {
+		return q.raw().SelectContext(ctx, dest, query, args...)
+	}
+	return stmt.SelectContext(ctx, dest, args...)
+}
+
+func (q *preparedQueryer) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	if isWrite(query) {
+		repository.NoteWrite(ctx)
+	}
+	stmt, err := q.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if stmt == nil {
+		return q.raw().QueryxContext(ctx, query, args...)
+	}
+	return stmt.QueryxContext(ctx, args...)
+}
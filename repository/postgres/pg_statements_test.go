@@ -0,0 +1,163 @@
+package postgres
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+	"github.com/livefire2015/simple-contents/model"
+)
+
+func TestBuildWhereClausePlaceholders(t *testing.T) {
+	minSize, maxSize := int64(1), int64(1<<20)
+	now := time.Now()
+	derivedFrom := uuid.New()
+	filter := model.ContentFilter{
+		TenantID:      "acme",
+		Status:        model.StatusDone,
+		DerivedFromID: &derivedFrom,
+		PinnedBy:      "alice",
+		FileName:      "invoice",
+		MIMEType:      "application/pdf",
+		MinSize:       &minSize,
+		MaxSize:       &maxSize,
+		CreatedFrom:   &now,
+		CreatedTo:     &now,
+		UpdatedFrom:   &now,
+		Metadata:      map[string]interface{}{"a": "1", "b": "2", "c": "3"},
+	}
+
+	where, args := buildWhereClause(filter, nil)
+	placeholders := regexp.MustCompile(`\$(\d+)`).FindAllStringSubmatch(where, -1)
+	if len(placeholders) != len(args) {
+		t.Fatalf("%d placeholders for %d arguments in %q", len(placeholders), len(args), where)
+	}
+	// Past $9 a placeholder made with string(rune) would be a letter
+	for i, placeholder := range placeholders {
+		if placeholder[1] != strconv.Itoa(i+1) {
+			t.Errorf("placeholder %d is $%s in %q", i+1, placeholder[1], where)
+		}
+	}
+}
+
+func TestFileNameConditions(t *testing.T) {
+	tests := []struct {
+		name      string
+		match     model.FileNameMatch
+		fileName  string
+		wantWhere string
+		wantArg   string
+		wantOrder string
+	}{
+		{name: "substring", fileName: "invoice", wantWhere: "name ILIKE $1", wantArg: "%invoice%", wantOrder: "created_at DESC, id"},
+		{name: "prefix", match: model.MatchPrefix, fileName: "invoice", wantWhere: "name ILIKE $1", wantArg: "invoice%", wantOrder: "created_at DESC, id"},
+		{name: "wildcards escaped", match: model.MatchPrefix, fileName: `50%_off\`, wantWhere: "name ILIKE $1", wantArg: `50\%\_off\\%`, wantOrder: "created_at DESC, id"},
+		{name: "fuzzy", match: model.MatchFuzzy, fileName: "invoce", wantWhere: "$1 <% name", wantArg: "invoce", wantOrder: "word_similarity($2, name) DESC, id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := model.ContentFilter{FileName: tt.fileName, FileNameMatch: tt.match}
+			where, args := buildWhereClause(filter, nil)
+			if want := "deleted_at IS NULL AND " + tt.wantWhere; where != want {
+				t.Errorf("buildWhereClause() = %s, want %s", where, want)
+			}
+			if len(args) != 1 || args[0] != tt.wantArg {
+				t.Errorf("buildWhereClause() arguments = %v, want [%s]", args, tt.wantArg)
+			}
+			if order := orderClause(filter, &args); order != tt.wantOrder {
+				t.Errorf("orderClause() = %s, want %s", order, tt.wantOrder)
+			}
+		})
+	}
+}
+
+// The benchmarks below run against the database of POSTGRES_TEST_DSN, which
+// must have the contents table, e.g.
+//
+//	POSTGRES_TEST_DSN=postgres://localhost/contents_test go test -run XXX -bench . -cpu 1,8,32 ./repository/postgres
+//
+// pgx's own statement cache is turned off so the unprepared runs parse and
+// plan every query, as drivers without one do.
+func benchmarkDB(b *testing.B) *sqlx.DB {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		b.Skip("POSTGRES_TEST_DSN not set")
+	}
+	config, err := pgxConnString(dsn, "default_query_exec_mode", "exec")
+	if err != nil {
+		b.Fatal(err)
+	}
+	db, err := sqlx.Open("pgx", config)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+	return db
+}
+
+// pgxConnString adds a runtime parameter to a connection URL
+func pgxConnString(dsn, name, value string) (string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set(name, value)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// benchmarkRepositories runs fn in parallel on a repository with and without
+// the prepared statement cache
+func benchmarkRepositories(b *testing.B, db *sqlx.DB, fn func(ctx context.Context, repo *PostgresRepository) error) {
+	repositories := map[string]*PostgresRepository{
+		"prepared":   NewPostgresRepository(db),
+		"unprepared": {db: db, conn: db},
+	}
+	for _, name := range []string{"unprepared", "prepared"} {
+		repo := repositories[name]
+		b.Run(name, func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				ctx := context.Background()
+				for pb.Next() {
+					if err := fn(ctx, repo); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+		if repo.stmts != nil {
+			repo.Close()
+		}
+	}
+}
+
+func BenchmarkGetContentByID(b *testing.B) {
+	db := benchmarkDB(b)
+	content := &model.Content{TenantID: "bench", Status: model.StatusDone, FileName: "bench.txt", MIMEType: "text/plain", StoragePath: "bench/" + uuid.NewString()}
+	if err := (&PostgresRepository{db: db, conn: db}).CreateContent(context.Background(), content); err != nil {
+		b.Fatal(err)
+	}
+
+	benchmarkRepositories(b, db, func(ctx context.Context, repo *PostgresRepository) error {
+		_, err := repo.GetContentByID(ctx, content.ID)
+		return err
+	})
+}
+
+func BenchmarkListContent(b *testing.B) {
+	filter := model.ContentFilter{TenantID: "bench", Status: model.StatusDone, MIMEType: "text/plain"}
+	benchmarkRepositories(b, benchmarkDB(b), func(ctx context.Context, repo *PostgresRepository) error {
+		_, _, err := repo.ListContent(ctx, filter, 0, 20)
+		return err
+	})
+}
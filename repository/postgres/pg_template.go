@@ -0,0 +1,179 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// templateDB is a database model for a document template
+type templateDB struct {
+	ID          string    `db:"id"`
+	Name        string    `db:"name"`
+	Format      string    `db:"format"`
+	Body        string    `db:"body"`
+	Stylesheet  string    `db:"stylesheet"`
+	FileName    string    `db:"file_name"`
+	Description string    `db:"description"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+// toModel converts a database model to a domain model
+func (t *templateDB) toModel() *model.Template {
+	return &model.Template{
+		ID:          t.ID,
+		Name:        t.Name,
+		Format:      model.TemplateFormat(t.Format),
+		Body:        t.Body,
+		Stylesheet:  t.Stylesheet,
+		FileName:    t.FileName,
+		Description: t.Description,
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+	}
+}
+
+// templateFromModel converts a domain model to a database model
+func templateFromModel(template *model.Template) *templateDB {
+	return &templateDB{
+		ID:          template.ID,
+		Name:        template.Name,
+		Format:      string(template.Format),
+		Body:        template.Body,
+		Stylesheet:  template.Stylesheet,
+		FileName:    template.FileName,
+		Description: template.Description,
+		CreatedAt:   template.CreatedAt,
+		UpdatedAt:   template.UpdatedAt,
+	}
+}
+
+// CreateTemplate stores a new template
+func (r *PostgresRepository) CreateTemplate(ctx context.Context, template *model.Template) error {
+	now := r.clock.Now()
+	template.CreatedAt = now
+	template.UpdatedAt = now
+
+	query := `
+		INSERT INTO templates (
+			id, name, format, body, stylesheet, file_name, description, created_at, updated_at
+		) VALUES (
+			:id, :name, :format, :body, :stylesheet, :file_name, :description, :created_at, :updated_at
+		)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	result, err := r.db.NamedExecContext(ctx, query, templateFromModel(template))
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrTemplateExists
+	}
+
+	return nil
+}
+
+// GetTemplate retrieves a template by its ID
+func (r *PostgresRepository) GetTemplate(ctx context.Context, id string) (*model.Template, error) {
+	var dbTemplate templateDB
+	if err := r.db.GetContext(ctx, &dbTemplate, `SELECT * FROM templates WHERE id = $1`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrTemplateNotFound
+		}
+		return nil, err
+	}
+
+	return dbTemplate.toModel(), nil
+}
+
+// ListTemplates retrieves a page of templates ordered by ID
+func (r *PostgresRepository) ListTemplates(ctx context.Context, options repository.ListOptions) ([]*model.Template, int64, error) {
+	var total int64
+	if options.ReturnTotal {
+		if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM templates`); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	query := `SELECT * FROM templates ORDER BY id`
+	var args queryArgs
+	if options.PageSize > 0 {
+		query += " LIMIT " + args.add(options.PageSize) + " OFFSET " + args.add(options.Offset())
+	}
+
+	var dbTemplates []templateDB
+	if err := r.db.SelectContext(ctx, &dbTemplates, query, args...); err != nil {
+		return nil, 0, err
+	}
+
+	templates := make([]*model.Template, len(dbTemplates))
+	for i := range dbTemplates {
+		templates[i] = dbTemplates[i].toModel()
+	}
+
+	return templates, total, nil
+}
+
+// UpdateTemplate updates an existing template
+func (r *PostgresRepository) UpdateTemplate(ctx context.Context, template *model.Template) error {
+	template.UpdatedAt = r.clock.Now()
+
+	query := `
+		UPDATE templates SET
+			name = :name,
+			format = :format,
+			body = :body,
+			stylesheet = :stylesheet,
+			file_name = :file_name,
+			description = :description,
+			updated_at = :updated_at
+		WHERE id = :id
+	`
+
+	result, err := r.db.NamedExecContext(ctx, query, templateFromModel(template))
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrTemplateNotFound
+	}
+
+	return nil
+}
+
+// DeleteTemplate removes a template
+func (r *PostgresRepository) DeleteTemplate(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM templates WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrTemplateNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,262 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// tenantDB is a database model for a tenant
+type tenantDB struct {
+	ID                  string         `db:"id"`
+	Name                string         `db:"name"`
+	StorageBackend      string         `db:"storage_backend"`
+	StoragePrefix       string         `db:"storage_prefix"`
+	CDN                 string         `db:"cdn"`
+	QuotaBytes          int64          `db:"quota_bytes"`
+	QuotaWarnPercents   sql.NullString `db:"quota_warn_percents"` // JSON array stored as string
+	UsedBytes           int64          `db:"used_bytes"`
+	RetentionMaxAgeDays int            `db:"retention_max_age_days"`
+	EncryptionKeyRef    string         `db:"encryption_key_ref"`
+	MaxURLExpirySeconds int            `db:"max_url_expiry_seconds"`
+	CreatedAt           time.Time      `db:"created_at"`
+	UpdatedAt           time.Time      `db:"updated_at"`
+}
+
+// toModel converts a database model to a domain model
+func (t *tenantDB) toModel() (*model.Tenant, error) {
+	tenant := &model.Tenant{
+		ID:                  t.ID,
+		Name:                t.Name,
+		StorageBackend:      t.StorageBackend,
+		StoragePrefix:       t.StoragePrefix,
+		CDN:                 t.CDN,
+		QuotaBytes:          t.QuotaBytes,
+		UsedBytes:           t.UsedBytes,
+		Retention:           model.RetentionPolicy{MaxAgeDays: t.RetentionMaxAgeDays},
+		EncryptionKeyRef:    t.EncryptionKeyRef,
+		MaxURLExpirySeconds: t.MaxURLExpirySeconds,
+		CreatedAt:           t.CreatedAt,
+		UpdatedAt:           t.UpdatedAt,
+	}
+
+	if t.QuotaWarnPercents.Valid {
+		if err := json.Unmarshal([]byte(t.QuotaWarnPercents.String), &tenant.QuotaWarnPercents); err != nil {
+			return nil, err
+		}
+	}
+
+	return tenant, nil
+}
+
+// tenantFromModel converts a domain model to a database model
+func tenantFromModel(tenant *model.Tenant) (*tenantDB, error) {
+	dbTenant := &tenantDB{
+		ID:                  tenant.ID,
+		Name:                tenant.Name,
+		StorageBackend:      tenant.StorageBackend,
+		StoragePrefix:       tenant.StoragePrefix,
+		CDN:                 tenant.CDN,
+		QuotaBytes:          tenant.QuotaBytes,
+		RetentionMaxAgeDays: tenant.Retention.MaxAgeDays,
+		EncryptionKeyRef:    tenant.EncryptionKeyRef,
+		MaxURLExpirySeconds: tenant.MaxURLExpirySeconds,
+		CreatedAt:           tenant.CreatedAt,
+		UpdatedAt:           tenant.UpdatedAt,
+	}
+
+	if len(tenant.QuotaWarnPercents) > 0 {
+		percentsBytes, err := json.Marshal(tenant.QuotaWarnPercents)
+		if err != nil {
+			return nil, err
+		}
+		dbTenant.QuotaWarnPercents = sql.NullString{
+			String: string(percentsBytes),
+			Valid:  true,
+		}
+	}
+
+	return dbTenant, nil
+}
+
+// CreateTenant stores a new tenant
+func (r *PostgresRepository) CreateTenant(ctx context.Context, tenant *model.Tenant) error {
+	now := r.clock.Now()
+	if tenant.CreatedAt.IsZero() {
+		tenant.CreatedAt = now
+	}
+	if tenant.UpdatedAt.IsZero() {
+		tenant.UpdatedAt = now
+	}
+
+	query := `
+		INSERT INTO tenants (
+			id, name, storage_backend, storage_prefix, cdn, quota_bytes, quota_warn_percents, retention_max_age_days, encryption_key_ref, max_url_expiry_seconds, created_at, updated_at
+		) VALUES (
+			:id, :name, :storage_backend, :storage_prefix, :cdn, :quota_bytes, :quota_warn_percents, :retention_max_age_days, :encryption_key_ref, :max_url_expiry_seconds, :created_at, :updated_at
+		)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	dbTenant, err := tenantFromModel(tenant)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.NamedExecContext(ctx, query, dbTenant)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrTenantExists
+	}
+
+	return nil
+}
+
+// GetTenant retrieves a tenant by its ID
+func (r *PostgresRepository) GetTenant(ctx context.Context, id string) (*model.Tenant, error) {
+	var dbTenant tenantDB
+	if err := r.db.GetContext(ctx, &dbTenant, `SELECT * FROM tenants WHERE id = $1`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrTenantNotFound
+		}
+		return nil, err
+	}
+
+	return dbTenant.toModel()
+}
+
+// ListTenants retrieves a page of tenants ordered by ID
+func (r *PostgresRepository) ListTenants(ctx context.Context, options repository.ListOptions) ([]*model.Tenant, int64, error) {
+	var total int64
+	if options.ReturnTotal {
+		if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM tenants`); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	query := `SELECT * FROM tenants ORDER BY id`
+	var args queryArgs
+	if options.PageSize > 0 {
+		query += " LIMIT " + args.add(options.PageSize) + " OFFSET " + args.add(options.Offset())
+	}
+
+	var dbTenants []tenantDB
+	if err := r.db.SelectContext(ctx, &dbTenants, query, args...); err != nil {
+		return nil, 0, err
+	}
+
+	tenants := make([]*model.Tenant, len(dbTenants))
+	for i := range dbTenants {
+		tenant, err := dbTenants[i].toModel()
+		if err != nil {
+			return nil, 0, err
+		}
+		tenants[i] = tenant
+	}
+
+	return tenants, total, nil
+}
+
+// UpdateTenant updates an existing tenant
+func (r *PostgresRepository) UpdateTenant(ctx context.Context, tenant *model.Tenant) error {
+	tenant.UpdatedAt = r.clock.Now()
+
+	query := `
+		UPDATE tenants SET
+			name = :name,
+			storage_backend = :storage_backend,
+			storage_prefix = :storage_prefix,
+			cdn = :cdn,
+			quota_bytes = :quota_bytes,
+			quota_warn_percents = :quota_warn_percents,
+			retention_max_age_days = :retention_max_age_days,
+			encryption_key_ref = :encryption_key_ref,
+			max_url_expiry_seconds = :max_url_expiry_seconds,
+			updated_at = :updated_at
+		WHERE id = :id
+	`
+
+	dbTenant, err := tenantFromModel(tenant)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.NamedExecContext(ctx, query, dbTenant)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrTenantNotFound
+	}
+
+	return nil
+}
+
+// DeleteTenant removes a tenant
+func (r *PostgresRepository) DeleteTenant(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM tenants WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrTenantNotFound
+	}
+
+	return nil
+}
+
+// AddTenantUsage atomically adds delta to the stored bytes of a tenant
+func (r *PostgresRepository) AddTenantUsage(ctx context.Context, id string, delta int64) (int64, error) {
+	var usedBytes int64
+	query := `UPDATE tenants SET used_bytes = used_bytes + $2 WHERE id = $1 RETURNING used_bytes`
+	if err := r.db.GetContext(ctx, &usedBytes, query, id, delta); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, repository.ErrTenantNotFound
+		}
+		return 0, err
+	}
+
+	return usedBytes, nil
+}
+
+// SetTenantUsage replaces the stored bytes of a tenant
+func (r *PostgresRepository) SetTenantUsage(ctx context.Context, id string, usedBytes int64) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE tenants SET used_bytes = $2 WHERE id = $1`, id, usedBytes)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return repository.ErrTenantNotFound
+	}
+	return nil
+}
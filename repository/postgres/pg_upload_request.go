@@ -0,0 +1,188 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// uploadRequestDB is a database model for an upload request
+type uploadRequestDB struct {
+	Token       string       `db:"token"`
+	TenantID    string       `db:"tenant_id"`
+	EntityType  string       `db:"entity_type"`
+	EntityID    string       `db:"entity_id"`
+	Recipient   string       `db:"recipient"`
+	Message     string       `db:"message"`
+	CreatedBy   string       `db:"created_by"`
+	ExpiresAt   time.Time    `db:"expires_at"`
+	MaxUploads  int64        `db:"max_uploads"`
+	MaxFileSize int64        `db:"max_file_size"`
+	Uploads     int64        `db:"uploads"`
+	RevokedAt   sql.NullTime `db:"revoked_at"`
+	CreatedAt   time.Time    `db:"created_at"`
+}
+
+// toModel converts a database model to a domain model
+func (u *uploadRequestDB) toModel() *model.UploadRequest {
+	request := &model.UploadRequest{
+		Token:       u.Token,
+		TenantID:    u.TenantID,
+		EntityType:  u.EntityType,
+		EntityID:    u.EntityID,
+		Recipient:   u.Recipient,
+		Message:     u.Message,
+		CreatedBy:   u.CreatedBy,
+		ExpiresAt:   u.ExpiresAt,
+		MaxUploads:  u.MaxUploads,
+		MaxFileSize: u.MaxFileSize,
+		Uploads:     u.Uploads,
+		CreatedAt:   u.CreatedAt,
+	}
+	if u.RevokedAt.Valid {
+		request.RevokedAt = &u.RevokedAt.Time
+	}
+	return request
+}
+
+// CreateUploadRequest stores a new upload request
+func (r *PostgresRepository) CreateUploadRequest(ctx context.Context, request *model.UploadRequest) error {
+	request.CreatedAt = r.clock.Now()
+
+	query := `
+		INSERT INTO upload_requests (
+			token, tenant_id, entity_type, entity_id, recipient, message, created_by, expires_at, max_uploads, max_file_size, uploads, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, 0, $11
+		)
+		ON CONFLICT (token) DO NOTHING
+	`
+
+	result, err := r.db.ExecContext(ctx, query, request.Token, request.TenantID, request.EntityType, request.EntityID, request.Recipient, request.Message,
+		request.CreatedBy, request.ExpiresAt, request.MaxUploads, request.MaxFileSize, request.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrUploadRequestExists
+	}
+
+	return nil
+}
+
+// GetUploadRequest retrieves an upload request by its token
+func (r *PostgresRepository) GetUploadRequest(ctx context.Context, token string) (*model.UploadRequest, error) {
+	var dbRequest uploadRequestDB
+	if err := r.db.GetContext(ctx, &dbRequest, `SELECT * FROM upload_requests WHERE token = $1`, token); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrUploadRequestNotFound
+		}
+		return nil, err
+	}
+
+	return dbRequest.toModel(), nil
+}
+
+// ListUploadRequestsByEntity retrieves a page of the upload requests of an entity, newest first
+func (r *PostgresRepository) ListUploadRequestsByEntity(ctx context.Context, entityType, entityID string, options repository.ListOptions) ([]*model.UploadRequest, int64, error) {
+	var total int64
+	if options.ReturnTotal {
+		if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM upload_requests WHERE entity_type = $1 AND entity_id = $2`, entityType, entityID); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	query := `SELECT * FROM upload_requests WHERE entity_type = $1 AND entity_id = $2 ORDER BY created_at DESC`
+	args := queryArgs{entityType, entityID}
+	if options.PageSize > 0 {
+		query += " LIMIT " + args.add(options.PageSize) + " OFFSET " + args.add(options.Offset())
+	}
+
+	var dbRequests []uploadRequestDB
+	if err := r.db.SelectContext(ctx, &dbRequests, query, args...); err != nil {
+		return nil, 0, err
+	}
+
+	requests := make([]*model.UploadRequest, len(dbRequests))
+	for i := range dbRequests {
+		requests[i] = dbRequests[i].toModel()
+	}
+
+	return requests, total, nil
+}
+
+// ReserveUpload atomically counts an upload through a request, unless it
+// has none left
+func (r *PostgresRepository) ReserveUpload(ctx context.Context, token string) error {
+	query := `
+		UPDATE upload_requests SET uploads = uploads + 1
+		WHERE token = $1 AND (max_uploads = 0 OR uploads < max_uploads)
+	`
+	result, err := r.db.ExecContext(ctx, query, token)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		// Tell a missing request from one used up
+		if _, err := r.GetUploadRequest(ctx, token); err != nil {
+			return err
+		}
+		return repository.ErrUploadRequestExhausted
+	}
+
+	return nil
+}
+
+// ReleaseUpload gives back a reserved upload
+func (r *PostgresRepository) ReleaseUpload(ctx context.Context, token string) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE upload_requests SET uploads = GREATEST(uploads - 1, 0) WHERE token = $1`, token)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrUploadRequestNotFound
+	}
+
+	return nil
+}
+
+// RevokeUploadRequest marks an upload request revoked
+func (r *PostgresRepository) RevokeUploadRequest(ctx context.Context, token string, revokedAt time.Time) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE upload_requests SET revoked_at = COALESCE(revoked_at, $2) WHERE token = $1`, token, revokedAt)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrUploadRequestNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+var ErrAnnotationNotFound = errors.New("annotation not found")
+
+// maxAnnotationBodyLength bounds the size of a single comment
+const maxAnnotationBodyLength = 10000
+
+// AnnotationService handles business logic for comments on content
+type AnnotationService struct {
+	repo     repository.AnnotationRepository
+	contents *ContentService
+}
+
+// NewAnnotationService creates a new annotation service
+func NewAnnotationService(repo repository.AnnotationRepository, contents *ContentService) *AnnotationService {
+	return &AnnotationService{
+		repo:     repo,
+		contents: contents,
+	}
+}
+
+// AnnotationInput represents the editable fields of an annotation
+type AnnotationInput struct {
+	Author string                  `json:"author" validate:"max=255"`
+	Body   string                  `json:"body" validate:"required"`
+	Anchor *model.AnnotationAnchor `json:"anchor"`
+}
+
+// validate checks the fields shared by create and update
+func (input AnnotationInput) validate() error {
+	if err := validateInput(input); err != nil {
+		return err
+	}
+	if len(input.Body) > maxAnnotationBodyLength {
+		return fmt.Errorf("%w: annotation body must be at most %d bytes", ErrInvalidInput, maxAnnotationBodyLength)
+	}
+	if input.Anchor == nil {
+		return nil
+	}
+	if input.Anchor.Page < 0 {
+		return fmt.Errorf("%w: anchor page must not be negative", ErrInvalidInput)
+	}
+	if region := input.Anchor.Region; region != nil {
+		if region.X < 0 || region.Y < 0 || region.Width <= 0 || region.Height <= 0 ||
+			region.X+region.Width > 1 || region.Y+region.Height > 1 {
+			return fmt.Errorf("%w: anchor region must lie within the page, in coordinates from 0 to 1", ErrInvalidInput)
+		}
+	}
+	return nil
+}
+
+// CreateAnnotation adds a comment to a content item
+func (s *AnnotationService) CreateAnnotation(ctx context.Context, contentID uuid.UUID, input AnnotationInput) (*model.Annotation, error) {
+	if input.Author == "" {
+		return nil, fmt.Errorf("%w: annotation author is required", ErrInvalidInput)
+	}
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+	if _, err := s.contents.GetContent(ctx, contentID); err != nil {
+		return nil, err
+	}
+
+	annotation := &model.Annotation{
+		ContentID: contentID,
+		Author:    input.Author,
+		Body:      input.Body,
+		Anchor:    input.Anchor,
+	}
+	if err := s.repo.CreateAnnotation(ctx, annotation); err != nil {
+		return nil, fmt.Errorf("failed to create annotation: %w", err)
+	}
+
+	return annotation, nil
+}
+
+// GetAnnotation retrieves an annotation of a content item
+func (s *AnnotationService) GetAnnotation(ctx context.Context, contentID, id uuid.UUID) (*model.Annotation, error) {
+	annotation, err := s.repo.GetAnnotation(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrAnnotationNotFound) {
+			return nil, ErrAnnotationNotFound
+		}
+		return nil, err
+	}
+
+	// Annotations are only reachable through the content they belong to
+	if annotation.ContentID != contentID {
+		return nil, ErrAnnotationNotFound
+	}
+	return annotation, nil
+}
+
+// ListAnnotations retrieves a page of the annotations of a content item, oldest first
+func (s *AnnotationService) ListAnnotations(ctx context.Context, contentID uuid.UUID, options repository.ListOptions) ([]*model.Annotation, int64, error) {
+	if _, err := s.contents.GetContent(ctx, contentID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListAnnotationsByContent(ctx, contentID, options)
+}
+
+// UpdateAnnotation replaces the body and anchor of an annotation. The author
+// cannot be changed.
+func (s *AnnotationService) UpdateAnnotation(ctx context.Context, contentID, id uuid.UUID, input AnnotationInput) (*model.Annotation, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	annotation, err := s.GetAnnotation(ctx, contentID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	annotation.Body = input.Body
+	annotation.Anchor = input.Anchor
+
+	if err := s.repo.UpdateAnnotation(ctx, annotation); err != nil {
+		if errors.Is(err, repository.ErrAnnotationNotFound) {
+			return nil, ErrAnnotationNotFound
+		}
+		return nil, fmt.Errorf("failed to update annotation: %w", err)
+	}
+
+	return annotation, nil
+}
+
+// DeleteAnnotation removes an annotation of a content item
+func (s *AnnotationService) DeleteAnnotation(ctx context.Context, contentID, id uuid.UUID) error {
+	if _, err := s.GetAnnotation(ctx, contentID, id); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteAnnotation(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrAnnotationNotFound) {
+			return ErrAnnotationNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+var (
+	ErrAssociationNotFound = errors.New("association not found")
+	ErrAssociationExists   = errors.New("association already exists")
+)
+
+// AssociateContentInput defines the input for associating content with an entity
+type AssociateContentInput struct {
+	ContentID           uuid.UUID              `json:"content_id" validate:"required"`
+	EntityType          string                 `json:"entity_type" validate:"required,max=100"`
+	EntityID            string                 `json:"entity_id" validate:"required,max=255"`
+	AssociationMetadata map[string]interface{} `json:"association_metadata" validate:"max=1000"`
+	AssociatedBy        string                 `json:"associated_by" validate:"max=255"` // User/service performing the association, unless the context carries a principal
+	RequireReview       bool                   `json:"require_review"`                   // Start the link in the pending review state
+}
+
+// AssociateContent links an existing content item to an entity.
+func (s *ContentService) AssociateContent(ctx context.Context, input AssociateContentInput) (*model.ContentEntityAssociation, error) {
+	if err := validateInput(input); err != nil {
+		return nil, err
+	}
+	input.AssociatedBy = principalOr(ctx, input.AssociatedBy)
+
+	// Validate that the content item exists
+	content, err := s.GetContent(ctx, input.ContentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAssociation(ctx, input.EntityType, input.EntityID, associationRole(input.AssociationMetadata), content.MIMEType); err != nil {
+		return nil, err
+	}
+
+	association := s.newAssociation(input)
+	if err := createAssociation(ctx, s.repo, association); err != nil {
+		return nil, err
+	}
+
+	s.publishEntityEvent(EventContentAdded, association, content)
+	if association.Review != nil {
+		s.publishEntityEvent(EventReviewRequested, association, content)
+	}
+	return association, nil
+}
+
+// newAssociation builds the association requested by input
+func (s *ContentService) newAssociation(input AssociateContentInput) *model.ContentEntityAssociation {
+	now := s.now()
+	association := &model.ContentEntityAssociation{
+		ID:                  s.ids.NewID(),
+		ContentID:           input.ContentID,
+		EntityType:          input.EntityType,
+		EntityID:            input.EntityID,
+		AssociationMetadata: input.AssociationMetadata,
+		CreatedBy:           input.AssociatedBy,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	if input.RequireReview {
+		association.Review = &model.AssociationReview{
+			State:       model.ReviewPending,
+			RequestedBy: input.AssociatedBy,
+			RequestedAt: now,
+		}
+	}
+	return association
+}
+
+// createAssociation stores an association in repo, which may be bound to a transaction
+func createAssociation(ctx context.Context, repo repository.ContentRepository, association *model.ContentEntityAssociation) error {
+	if err := repo.CreateAssociation(ctx, association); err != nil {
+		if errors.Is(err, repository.ErrAssociationExists) {
+			return fmt.Errorf("%w: content %s is already associated with entity %s/%s",
+				ErrAssociationExists, association.ContentID, association.EntityType, association.EntityID)
+		}
+		return fmt.Errorf("failed to create association: %w", err)
+	}
+	return nil
+}
+
+// RemoveAssociation unlinks a content item from an entity
+func (s *ContentService) RemoveAssociation(ctx context.Context, associationID uuid.UUID) error {
+	association, err := s.repo.GetAssociationByID(ctx, associationID)
+	if err != nil {
+		if errors.Is(err, repository.ErrAssociationNotFound) {
+			return ErrAssociationNotFound
+		}
+		return err
+	}
+
+	if err := s.repo.DeleteAssociation(ctx, associationID); err != nil {
+		if errors.Is(err, repository.ErrAssociationNotFound) {
+			return ErrAssociationNotFound
+		}
+		return err
+	}
+
+	s.publishEntityEvent(EventContentRemoved, association, nil)
+	return nil
+}
+
+// DeleteEntityInput describes an entity deleted upstream
+type DeleteEntityInput struct {
+	EntityType string
+	EntityID   string
+	Purge      bool   // Also delete content left without any association
+	DeletedBy  string // Recorded in the audit log
+}
+
+// DeleteEntityResult reports what was removed with an entity
+type DeleteEntityResult struct {
+	EntityType          string           `json:"entity_type"`
+	EntityID            string           `json:"entity_id"`
+	RemovedAssociations []uuid.UUID      `json:"removed_associations"`
+	Purged              []BulkDeleteItem `json:"purged,omitempty"`
+}
+
+// DeleteEntity unlinks all content from an entity and, with Purge, deletes the
+// content no other entity is linked to
+func (s *ContentService) DeleteEntity(ctx context.Context, input DeleteEntityInput) (*DeleteEntityResult, error) {
+	if input.EntityType == "" || input.EntityID == "" {
+		return nil, ErrInvalidInput
+	}
+
+	// Orphans are found in the same transaction, so a link created concurrently
+	// either survives the delete or keeps its content from being purged
+	var removed []*model.ContentEntityAssociation
+	var orphans []uuid.UUID
+	err := s.repo.WithTx(ctx, func(tx repository.ContentRepository) error {
+		orphans = nil // The transaction may be run again
+		var err error
+		removed, err = tx.DeleteAssociationsByEntity(ctx, input.EntityType, input.EntityID)
+		if err != nil || !input.Purge {
+			return err
+		}
+
+		for _, association := range removed {
+			_, remaining, err := tx.ListAssociationsByContent(ctx, association.ContentID, repository.ListOptions{PageSize: 1, ReturnTotal: true})
+			if err != nil {
+				return err
+			}
+			if remaining == 0 {
+				orphans = append(orphans, association.ContentID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DeleteEntityResult{
+		EntityType:          input.EntityType,
+		EntityID:            input.EntityID,
+		RemovedAssociations: make([]uuid.UUID, len(removed)),
+	}
+	for i, association := range removed {
+		result.RemovedAssociations[i] = association.ID
+		s.publishEntityEvent(EventContentRemoved, association, nil)
+	}
+
+	if len(orphans) > 0 {
+		purged, err := s.deleteContents(ctx, orphans, removed)
+		if err != nil {
+			return result, fmt.Errorf("failed to purge content of entity %s/%s: %w", input.EntityType, input.EntityID, err)
+		}
+		result.Purged = purged.Items
+	}
+
+	detail := fmt.Sprintf("%d associations removed, %d contents purged", len(removed), len(orphans))
+	s.audit(ctx, model.AuditEvent{Action: "entity.delete", Subject: input.EntityType + "/" + input.EntityID, Actor: input.DeletedBy, Detail: detail},
+		"entity %s/%s deleted by %q: %s", input.EntityType, input.EntityID, input.DeletedBy, detail)
+	return result, nil
+}
+
+// GetContentForEntity retrieves content items linked to a specific entity.
+func (s *ContentService) GetContentForEntity(ctx context.Context, entityType string, entityID string, options repository.ListOptions) ([]*model.Content, int64, error) {
+	if entityType == "" || entityID == "" {
+		return nil, 0, ErrInvalidInput
+	}
+	// This service method now calls the repository method that handles the join
+	return s.repo.ListContentByEntity(ctx, entityType, entityID, options)
+}
+
+// ReorderEntityContents puts the given content of an entity first, in order.
+// Content left out keeps its place after them, newest first.
+func (s *ContentService) ReorderEntityContents(ctx context.Context, entityType, entityID string, contentIDs []uuid.UUID) error {
+	if entityType == "" || entityID == "" {
+		return ErrInvalidInput
+	}
+	seen := make(map[uuid.UUID]bool, len(contentIDs))
+	for _, id := range contentIDs {
+		if seen[id] {
+			return fmt.Errorf("%w: content %s is listed twice", ErrInvalidInput, id)
+		}
+		seen[id] = true
+	}
+
+	if err := s.repo.ReorderAssociations(ctx, entityType, entityID, contentIDs); err != nil {
+		if errors.Is(err, repository.ErrAssociationNotFound) {
+			return ErrAssociationNotFound
+		}
+		return err
+	}
+
+	s.events.Publish(Event{
+		Type:       EventReordered,
+		Timestamp:  s.now(),
+		EntityType: entityType,
+		EntityID:   entityID,
+		Order:      contentIDs,
+	})
+	return nil
+}
+
+// GetContentWithAssociationsForEntity retrieves content items linked to an
+// entity together with the associations linking them
+func (s *ContentService) GetContentWithAssociationsForEntity(ctx context.Context, entityType string, entityID string, options repository.ListOptions) ([]*model.EntityContent, int64, error) {
+	if entityType == "" || entityID == "" {
+		return nil, 0, ErrInvalidInput
+	}
+	return s.repo.ListEntityContents(ctx, entityType, entityID, options)
+}
+
+// ListEntities retrieves the IDs of the entities of a type that have content
+func (s *ContentService) ListEntities(ctx context.Context, entityType string, options repository.ListOptions) ([]string, int64, error) {
+	if entityType == "" {
+		return nil, 0, ErrInvalidInput
+	}
+	return s.repo.ListEntityIDs(ctx, entityType, options)
+}
+
+// ListAssociationsForContent retrieves the entities a content item is linked to
+func (s *ContentService) ListAssociationsForContent(ctx context.Context, contentID uuid.UUID, options repository.ListOptions) ([]*model.ContentEntityAssociation, int64, error) {
+	return s.repo.ListAssociationsByContent(ctx, contentID, options)
+}
+
+// contentAssociations returns every association of a content item
+func (s *ContentService) contentAssociations(ctx context.Context, contentID uuid.UUID) []*model.ContentEntityAssociation {
+	associations, _, err := s.repo.ListAssociationsByContent(ctx, contentID, repository.ListOptions{})
+	if err != nil {
+		return nil
+	}
+	return associations
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// MetadataAssociationRole is the association metadata key holding the role of
+// the content for its entity, e.g. "photo" or "receipt"
+const MetadataAssociationRole = "role"
+
+// ErrAttachmentLimit is matched by every LimitExceededError
+var ErrAttachmentLimit = errors.New("attachment limit exceeded")
+
+// LimitExceededError is returned when linking content would take an entity
+// over one of its attachment limits
+type LimitExceededError struct {
+	EntityType string
+	EntityID   string
+	Role       string // Empty for the limit on all attachments
+	Limit      int
+	Count      int64 // Attachments the entity already has, in the role if set
+}
+
+func (e *LimitExceededError) Error() string {
+	if e.Role != "" {
+		return fmt.Sprintf("attachment limit exceeded for %s %s: %d of %d %q attachments used",
+			e.EntityType, e.EntityID, e.Count, e.Limit, e.Role)
+	}
+	return fmt.Sprintf("attachment limit exceeded for %s %s: %d of %d attachments used",
+		e.EntityType, e.EntityID, e.Count, e.Limit)
+}
+
+// Unwrap makes errors.Is(err, ErrAttachmentLimit) match
+func (e *LimitExceededError) Unwrap() error {
+	return ErrAttachmentLimit
+}
+
+// AttachmentLimits caps the content linked to a single entity
+type AttachmentLimits struct {
+	MaxAttachments        int            `json:"max_attachments,omitempty"`          // All links of an entity, 0 = unlimited
+	MaxAttachmentsPerRole map[string]int `json:"max_attachments_per_role,omitempty"` // Links by association role
+}
+
+// validate checks that no limit is negative
+func (l AttachmentLimits) validate() error {
+	if l.MaxAttachments < 0 {
+		return fmt.Errorf("%w: max_attachments must not be negative", ErrInvalidInput)
+	}
+	for role, limit := range l.MaxAttachmentsPerRole {
+		if role == "" || limit < 0 {
+			return fmt.Errorf("%w: invalid attachment limit %d for role %q", ErrInvalidInput, limit, role)
+		}
+	}
+	return nil
+}
+
+// orDefaults returns the limits with the ones that aren't set taken from defaults
+func (l AttachmentLimits) orDefaults(defaults AttachmentLimits) AttachmentLimits {
+	if l.MaxAttachments == 0 {
+		l.MaxAttachments = defaults.MaxAttachments
+	}
+	if len(defaults.MaxAttachmentsPerRole) > 0 {
+		perRole := make(map[string]int, len(defaults.MaxAttachmentsPerRole)+len(l.MaxAttachmentsPerRole))
+		for role, limit := range defaults.MaxAttachmentsPerRole {
+			perRole[role] = limit
+		}
+		for role, limit := range l.MaxAttachmentsPerRole {
+			perRole[role] = limit
+		}
+		l.MaxAttachmentsPerRole = perRole
+	}
+	return l
+}
+
+// ParseRoleLimits parses a comma-separated list of role=limit pairs
+func ParseRoleLimits(spec string) (map[string]int, error) {
+	limits := make(map[string]int)
+	for _, entry := range strings.Split(spec, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		role, value, _ := strings.Cut(entry, "=")
+		limit, err := strconv.Atoi(value)
+		if err != nil || role == "" || limit < 0 {
+			return nil, fmt.Errorf("%w: invalid role limit %q", ErrInvalidInput, entry)
+		}
+		limits[role] = limit
+	}
+	return limits, nil
+}
+
+// ConfigureAttachmentLimits sets the attachment limits of every entity.
+// Entity types of the registry can override them.
+func (s *ContentService) ConfigureAttachmentLimits(limits AttachmentLimits) error {
+	if err := limits.validate(); err != nil {
+		return err
+	}
+	s.attachmentLimits = limits
+	return nil
+}
+
+// associationRole returns the role of an association from its metadata
+func associationRole(metadata map[string]interface{}) string {
+	role, _ := metadata[MetadataAssociationRole].(string)
+	return role
+}
+
+// checkAttachmentLimits fails with a LimitExceededError if an entity can't
+// take another link in a role
+func (s *ContentService) checkAttachmentLimits(ctx context.Context, limits AttachmentLimits, entityType, entityID, role string) error {
+	roleLimit := limits.MaxAttachmentsPerRole[role]
+	if role == "" {
+		roleLimit = 0
+	}
+	if limits.MaxAttachments == 0 && roleLimit == 0 {
+		return nil
+	}
+
+	// Only the total is needed unless the role is limited
+	options := repository.ListOptions{Page: 1, PageSize: 1, ReturnTotal: true}
+	if roleLimit > 0 {
+		options = repository.ListOptions{ReturnTotal: true}
+	}
+	associations, total, err := s.repo.ListAssociationsByEntity(ctx, entityType, entityID, options)
+	if err != nil {
+		return err
+	}
+	if limits.MaxAttachments > 0 && total >= int64(limits.MaxAttachments) {
+		return &LimitExceededError{EntityType: entityType, EntityID: entityID, Limit: limits.MaxAttachments, Count: total}
+	}
+
+	if roleLimit > 0 {
+		var count int64
+		for _, association := range associations {
+			if associationRole(association.AssociationMetadata) == role {
+				count++
+			}
+		}
+		if count >= int64(roleLimit) {
+			return &LimitExceededError{EntityType: entityType, EntityID: entityID, Role: role, Limit: roleLimit, Count: count}
+		}
+	}
+	return nil
+}
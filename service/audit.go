@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// EnableAuditLog records audited operations in repo as well as in the log
+func (s *ContentService) EnableAuditLog(repo repository.AuditRepository) {
+	s.auditLog = repo
+}
+
+// audit logs an audited operation and records it if the audit log is
+// enabled. Failing to record it doesn't fail the operation, which is done.
+func (s *ContentService) audit(ctx context.Context, event model.AuditEvent, format string, args ...any) {
+	log.Printf("audit: "+format, args...)
+	if s.auditLog == nil {
+		return
+	}
+	if err := s.auditLog.RecordAuditEvent(ctx, &event); err != nil {
+		log.Printf("Failed to record audit event %s on %s: %v", event.Action, event.Subject, err)
+	}
+}
+
+// PruneAuditLog permanently removes the audit events that occurred before a cutoff
+func (s *ContentService) PruneAuditLog(ctx context.Context, before time.Time) (int64, error) {
+	if before.IsZero() {
+		return 0, fmt.Errorf("%w: pruning requires a cutoff time", ErrInvalidInput)
+	}
+	if s.auditLog == nil {
+		return 0, nil
+	}
+	return s.auditLog.PruneAuditEvents(ctx, before)
+}
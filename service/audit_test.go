@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository/memory"
+)
+
+func TestAuditLogRecordsAndPrunes(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepository()
+	s := newTestInstance(repo)
+	s.EnableAuditLog(repo)
+
+	content := &model.Content{FileName: "invoice.pdf", Metadata: model.Metadata{MetadataVirusScan: "infected", MetadataVirusSignature: "Eicar-Test-Signature"}}
+	if err := repo.CreateContent(ctx, content); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ReleaseQuarantined(ctx, content.ID, "alice"); err != nil {
+		t.Fatalf("ReleaseQuarantined() error = %v", err)
+	}
+
+	if pruned, err := s.PruneAuditLog(ctx, time.Now().Add(-time.Hour)); err != nil || pruned != 0 {
+		t.Errorf("PruneAuditLog(an hour ago) = %d, %v, want 0", pruned, err)
+	}
+	if pruned, err := s.PruneAuditLog(ctx, time.Now().Add(time.Hour)); err != nil || pruned != 1 {
+		t.Errorf("PruneAuditLog(in an hour) = %d, %v, want the release event", pruned, err)
+	}
+}
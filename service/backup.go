@@ -0,0 +1,300 @@
+package service
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/klauspost/compress/zstd"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+	"github.com/livefire2015/simple-contents/storage"
+)
+
+// backupFormatVersion is written to the manifest of every backup archive
+const backupFormatVersion = 1
+
+// BackupOptions controls what a backup contains
+type BackupOptions struct {
+	// Since limits the backup to content updated at or after this time.
+	// A nil value produces a full backup.
+	Since *time.Time
+}
+
+// BackupManifest is stored as the first entry of a backup archive
+type BackupManifest struct {
+	Version   int        `json:"version"`
+	CreatedAt time.Time  `json:"created_at"`
+	Since     *time.Time `json:"since,omitempty"`
+}
+
+// BackupResult summarizes a backup
+type BackupResult struct {
+	Contents int `json:"contents"`
+	Objects  int `json:"objects"`
+}
+
+// RestoreResult summarizes a restore
+type RestoreResult struct {
+	ImportResult
+	Objects int `json:"objects"`
+}
+
+// Backup writes content metadata and storage objects to w as a zstd-compressed tar archive.
+// Each content is stored as contents/<id>.json followed by its data as objects/<id>.
+func (s *ContentService) Backup(ctx context.Context, w io.Writer, options BackupOptions) (*BackupResult, error) {
+	zw, err := zstd.NewWriter(w)
+	if err != nil {
+		return nil, err
+	}
+	tw := tar.NewWriter(zw)
+
+	result, err := s.writeBackup(ctx, tw, options)
+	if err != nil {
+		zw.Close()
+		return result, err
+	}
+
+	if err := tw.Close(); err != nil {
+		zw.Close()
+		return result, err
+	}
+	return result, zw.Close()
+}
+
+// BackupToStorage streams a backup archive into another storage backend under key
+func (s *ContentService) BackupToStorage(ctx context.Context, dest storage.StorageService, key string, options BackupOptions) (*BackupResult, error) {
+	pr, pw := io.Pipe()
+
+	var result *BackupResult
+	go func() {
+		var err error
+		result, err = s.Backup(ctx, pw, options)
+		pw.CloseWithError(err)
+	}()
+
+	_, err := dest.Upload(ctx, key, pr, storage.UnknownSize, "application/zstd")
+	pr.CloseWithError(err)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *ContentService) writeBackup(ctx context.Context, tw *tar.Writer, options BackupOptions) (*BackupResult, error) {
+	now := s.now()
+	result := &BackupResult{}
+
+	manifest, err := json.Marshal(BackupManifest{
+		Version:   backupFormatVersion,
+		CreatedAt: now,
+		Since:     options.Since,
+	})
+	if err != nil {
+		return result, err
+	}
+	if err := writeTarFile(tw, "manifest.json", manifest, now); err != nil {
+		return result, err
+	}
+
+	filter := model.ContentFilter{UpdatedFrom: options.Since}
+	for offset := 0; ; offset += exportPageSize {
+		items, _, err := s.repo.ListContent(ctx, filter, offset, exportPageSize)
+		if err != nil {
+			return result, err
+		}
+
+		for _, content := range items {
+			data, err := json.Marshal(content)
+			if err != nil {
+				return result, err
+			}
+			if err := writeTarFile(tw, "contents/"+content.ID.String()+".json", data, content.UpdatedAt); err != nil {
+				return result, err
+			}
+			result.Contents++
+
+			written, err := s.writeBackupObject(ctx, tw, content)
+			if err != nil {
+				return result, fmt.Errorf("backing up data for %s: %w", content.ID, err)
+			}
+			if written {
+				result.Objects++
+			}
+		}
+
+		if len(items) < exportPageSize {
+			return result, nil
+		}
+	}
+}
+
+// writeBackupObject copies a content's data into the archive.
+// Content without data in storage (e.g. never uploaded) is backed up as metadata only.
+func (s *ContentService) writeBackupObject(ctx context.Context, tw *tar.Writer, content *model.Content) (bool, error) {
+	if content.StoragePath == "" {
+		return false, nil
+	}
+
+	info, err := s.storage.Stat(storageContext(ctx, content), content.StoragePath)
+	if err != nil {
+		return false, nil
+	}
+
+	data, err := s.storage.Download(storageContext(ctx, content), content.StoragePath)
+	if err != nil {
+		return false, err
+	}
+	defer data.Close()
+
+	header := &tar.Header{
+		Name:    "objects/" + content.ID.String(),
+		Mode:    0o644,
+		Size:    info.Size,
+		ModTime: info.LastModified,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return false, err
+	}
+	if _, err := io.CopyN(tw, data, info.Size); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// Restore rebuilds content metadata and storage objects from a backup archive.
+// Data is written to storage before its metadata row so restored rows never point at missing objects.
+func (s *ContentService) Restore(ctx context.Context, r io.Reader, policy ConflictPolicy) (*RestoreResult, error) {
+	switch policy {
+	case ConflictSkip, ConflictOverwrite, ConflictFail:
+	case "":
+		policy = ConflictSkip
+	default:
+		return nil, ErrInvalidInput
+	}
+
+	zr, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	defer zr.Close()
+	tr := tar.NewReader(zr)
+
+	result := &RestoreResult{}
+	var pending *model.Content
+
+	// flush stores the metadata of a content whose archive entry had no object
+	flush := func() error {
+		if pending == nil {
+			return nil
+		}
+		content := pending
+		pending = nil
+		restore, err := s.restoreDecision(ctx, content.ID, policy, &result.ImportResult)
+		if err != nil || !restore {
+			return err
+		}
+		return s.importContent(ctx, content, policy, &result.ImportResult)
+	}
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return result, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+		}
+
+		dir, name := path.Split(header.Name)
+		switch dir {
+		case "contents/":
+			if err := flush(); err != nil {
+				return result, err
+			}
+			var content model.Content
+			if err := json.NewDecoder(tr).Decode(&content); err != nil {
+				return result, fmt.Errorf("%w: %s: %v", ErrInvalidInput, header.Name, err)
+			}
+			pending = &content
+
+		case "objects/":
+			if pending == nil || pending.ID.String() != name {
+				return result, fmt.Errorf("%w: %s has no preceding metadata", ErrInvalidInput, header.Name)
+			}
+			content := pending
+			pending = nil
+			if err := s.restoreObject(ctx, content, tr, header.Size, policy, result); err != nil {
+				return result, err
+			}
+
+		default:
+			if header.Name != "manifest.json" && !strings.HasSuffix(header.Name, "/") {
+				return result, fmt.Errorf("%w: unexpected archive entry %s", ErrInvalidInput, header.Name)
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// restoreObject uploads a content's data and then stores its metadata
+func (s *ContentService) restoreObject(ctx context.Context, content *model.Content, data io.Reader, size int64, policy ConflictPolicy, result *RestoreResult) error {
+	restore, err := s.restoreDecision(ctx, content.ID, policy, &result.ImportResult)
+	if err != nil || !restore {
+		return err
+	}
+
+	if _, err := s.storage.Upload(storageContext(ctx, content), content.StoragePath, data, size, content.MIMEType); err != nil {
+		return fmt.Errorf("restoring data for %s: %w", content.ID, err)
+	}
+	result.Objects++
+
+	return s.importContent(ctx, content, policy, &result.ImportResult)
+}
+
+// restoreDecision reports whether a content should be restored under policy.
+// Skipped rows are counted here so their data is not uploaded.
+func (s *ContentService) restoreDecision(ctx context.Context, id uuid.UUID, policy ConflictPolicy, result *ImportResult) (bool, error) {
+	_, err := s.repo.GetContentByID(ctx, id)
+	if errors.Is(err, repository.ErrContentNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	switch policy {
+	case ConflictOverwrite:
+		return true, nil
+	case ConflictFail:
+		return false, fmt.Errorf("%w: %s", ErrImportConflict, id)
+	default:
+		result.Skipped++
+		return false, nil
+	}
+}
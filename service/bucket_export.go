@@ -0,0 +1,165 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/storage"
+)
+
+// ErrExportsDisabled is returned when exports aren't configured or the
+// storage backend can't write to other buckets
+var ErrExportsDisabled = errors.New("exports to other buckets are not configured")
+
+// exportManifestName is the key of the manifest under the export prefix
+const exportManifestName = "manifest.ndjson"
+
+// ExportConfig restricts the buckets content can be exported to
+type ExportConfig struct {
+	Buckets []string // Buckets of the storage backend exports may write to
+}
+
+// ConfigureExports lets callers copy content into the given buckets
+func (s *ContentService) ConfigureExports(config ExportConfig) {
+	s.exportConfig = &config
+}
+
+// ExportInput selects the content to export and where to copy it
+type ExportInput struct {
+	Bucket string              `json:"bucket"`
+	Prefix string              `json:"prefix"` // Key prefix in the bucket, e.g. "audits/2025-q1/"
+	Filter model.ContentFilter `json:"filter"`
+}
+
+// ExportResult is the result of an export job
+type ExportResult struct {
+	Bucket   string      `json:"bucket"`
+	Prefix   string      `json:"prefix"`
+	Copied   int         `json:"copied"`
+	Skipped  []uuid.UUID `json:"skipped"`            // Quarantined, unscanned or restricted content, or content without data
+	Manifest string      `json:"manifest,omitempty"` // Key of the manifest, written once every item is copied
+}
+
+// ExportManifestEntry is a line of the manifest of an export, the metadata
+// of a content item and the key its data was copied to
+type ExportManifestEntry struct {
+	Key     string         `json:"key"`
+	Content *model.Content `json:"content"`
+}
+
+// exportPrefix checks the key prefix of an export, which gets a trailing slash
+func exportPrefix(prefix string) (string, error) {
+	prefix = strings.Trim(prefix, "/")
+	for _, segment := range strings.Split(prefix, "/") {
+		if segment == "." || segment == ".." {
+			return "", fmt.Errorf("%w: invalid export prefix %q", ErrInvalidInput, prefix)
+		}
+	}
+	if prefix == "" {
+		return "", nil
+	}
+	return prefix + "/", nil
+}
+
+// StartExport copies the content matching a filter, and a manifest of its
+// metadata, into another bucket in a job. The data is copied by the storage
+// backend, without passing through the service.
+func (s *ContentService) StartExport(ctx context.Context, input ExportInput) (*model.Job, error) {
+	exporter, ok := s.storage.(storage.BucketExporter)
+	if s.exportConfig == nil || !ok {
+		return nil, ErrExportsDisabled
+	}
+	allowed := false
+	for _, bucket := range s.exportConfig.Buckets {
+		allowed = allowed || bucket == input.Bucket
+	}
+	if !allowed {
+		return nil, fmt.Errorf("%w: exports to bucket %q are not allowed", ErrInvalidInput, input.Bucket)
+	}
+	prefix, err := exportPrefix(input.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	if input.Filter.Status != "" && !input.Filter.Status.IsValid() {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidInput, input.Filter.Status)
+	}
+	if input.Filter.FileNameMatch != "" && !input.Filter.FileNameMatch.IsValid() {
+		return nil, fmt.Errorf("%w: unknown file name match %q", ErrInvalidInput, input.Filter.FileNameMatch)
+	}
+
+	input.Prefix = prefix
+	job := &model.Job{Kind: JobKindExport, TenantID: input.Filter.TenantID}
+	return s.startJob(ctx, job, input, nil, func(ctx context.Context, progress *JobProgress) (interface{}, error) {
+		return s.runExport(ctx, exporter, input, progress)
+	})
+}
+
+// runExport copies the content of an export and writes its manifest
+func (s *ContentService) runExport(ctx context.Context, exporter storage.BucketExporter, input ExportInput, progress *JobProgress) (*ExportResult, error) {
+	result := &ExportResult{Bucket: input.Bucket, Prefix: input.Prefix, Skipped: []uuid.UUID{}}
+	if _, total, err := s.repo.ListContent(ctx, input.Filter, 0, 1); err == nil {
+		progress.SetTotal(int64(total))
+	}
+
+	manifest, err := s.copyExport(ctx, exporter, input, result, progress)
+	if err != nil {
+		return result, err
+	}
+	key := input.Prefix + exportManifestName
+	if err := exporter.UploadToBucket(ctx, input.Bucket, key, bytes.NewReader(manifest), int64(len(manifest)), "application/x-ndjson"); err != nil {
+		return result, err
+	}
+	result.Manifest = key
+	return result, nil
+}
+
+// copyExport copies the data of each content item matching the filter of an
+// export and returns the manifest of the copied items
+func (s *ContentService) copyExport(ctx context.Context, exporter storage.BucketExporter, input ExportInput, result *ExportResult, progress *JobProgress) ([]byte, error) {
+	elevated := HasElevatedScope(ctx)
+	var manifest bytes.Buffer
+	encoder := json.NewEncoder(&manifest)
+
+	err := s.repo.ListContentStream(ctx, input.Filter, func(content *model.Content) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		defer progress.Add(1)
+
+		skip := func() {
+			result.Skipped = append(result.Skipped, content.ID)
+		}
+		if CheckScanned(content) != nil || s.piiRestricted(content, elevated) {
+			skip()
+			return nil
+		}
+
+		name, err := SanitizeFileName(content.FileName)
+		if err != nil {
+			name = "data"
+		}
+		// The manifest keeps the full name of names shortened to fit a segment
+		name = shortenFileName(name, s.keyLimits.MaxSegmentBytes)
+		key := input.Prefix + content.ID.String() + "/" + name
+		err = exporter.CopyToBucket(storageContext(ctx, content), content.StoragePath, input.Bucket, key)
+		if errors.Is(err, storage.ErrNotFound) {
+			// The data of a direct upload that never completed
+			skip()
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to copy content %s: %w", content.ID, err)
+		}
+		if err := encoder.Encode(ExportManifestEntry{Key: key, Content: content}); err != nil {
+			return err
+		}
+		result.Copied++
+		return nil
+	})
+	return manifest.Bytes(), err
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository/memory"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+)
+
+// bucketStorage is a memory storage that can write to other buckets, which
+// it keeps as maps of keys to data
+type bucketStorage struct {
+	*memorystorage.MemoryStorage
+
+	mu      sync.Mutex
+	buckets map[string]map[string][]byte
+}
+
+func (s *bucketStorage) CopyToBucket(ctx context.Context, path, bucket, key string) error {
+	data, err := s.Download(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer data.Close()
+	return s.UploadToBucket(ctx, bucket, key, data, -1, "")
+}
+
+func (s *bucketStorage) UploadToBucket(ctx context.Context, bucket, key string, data io.Reader, size int64, contentType string) error {
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.buckets[bucket] == nil {
+		s.buckets[bucket] = make(map[string][]byte)
+	}
+	s.buckets[bucket][key] = b
+	return nil
+}
+
+func TestExport(t *testing.T) {
+	ctx := context.Background()
+	store := &bucketStorage{MemoryStorage: memorystorage.NewMemoryStorage(), buckets: make(map[string]map[string][]byte)}
+	repo := memory.NewMemoryRepository()
+	s := NewContentService(repo, store)
+
+	if _, err := s.StartExport(ctx, ExportInput{Bucket: "audits"}); !errors.Is(err, ErrExportsDisabled) {
+		t.Fatalf("StartExport() before ConfigureExports error = %v, want ErrExportsDisabled", err)
+	}
+	s.ConfigureExports(ExportConfig{Buckets: []string{"audits"}})
+
+	invoice, err := s.CreateContent(ctx, CreateContentInput{FileName: "invoice.pdf", MIMEType: "application/pdf", FileSize: 7, Data: strings.NewReader("invoice"), Metadata: model.Metadata{"category": "invoice"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateContent(ctx, CreateContentInput{FileName: "photo.png", MIMEType: "image/png", FileSize: 5, Data: strings.NewReader("photo")}); err != nil {
+		t.Fatal(err)
+	}
+	// Content whose data was never uploaded is skipped
+	pending := &model.Content{FileName: "receipt.pdf", MIMEType: "application/pdf", Status: model.StatusCreated, Metadata: model.Metadata{"category": "invoice"}}
+	if err := repo.CreateContent(ctx, pending); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, input := range []ExportInput{
+		{Bucket: "elsewhere"},
+		{Bucket: "audits", Prefix: "audits/../contents"},
+		{Bucket: "audits", Filter: model.ContentFilter{Status: "lost"}},
+	} {
+		if _, err := s.StartExport(ctx, input); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("StartExport(%+v) error = %v, want ErrInvalidInput", input, err)
+		}
+	}
+
+	started, err := s.StartExport(ctx, ExportInput{Bucket: "audits", Prefix: "/2025-q1", Filter: model.ContentFilter{Metadata: map[string]interface{}{"category": "invoice"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result ExportResult
+	job := finishedJob(t, s, started.ID, &result)
+	if job.Status != JobDone || job.Progress != 100 {
+		t.Fatalf("export status = %s at %d%% (%s), want done", job.Status, job.Progress, job.Error)
+	}
+	if job.Total != 2 || job.Done != 2 {
+		t.Errorf("export did %d of %d items, want 2 of 2", job.Done, job.Total)
+	}
+	if result.Copied != 1 || len(result.Skipped) != 1 || result.Skipped[0] != pending.ID {
+		t.Errorf("export copied %d and skipped %v, want the invoice copied and the receipt skipped", result.Copied, result.Skipped)
+	}
+	if result.Manifest != "2025-q1/manifest.ndjson" {
+		t.Errorf("manifest = %q, want 2025-q1/manifest.ndjson", result.Manifest)
+	}
+
+	key := "2025-q1/" + invoice.ID.String() + "/invoice.pdf"
+	if got := string(store.buckets["audits"][key]); got != "invoice" {
+		t.Errorf("exported data = %q, want %q", got, "invoice")
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(store.buckets["audits"][result.Manifest]))
+	var entries []ExportManifestEntry
+	for scanner.Scan() {
+		var entry ExportManifestEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 1 || entries[0].Key != key || entries[0].Content.ID != invoice.ID {
+		t.Errorf("manifest entries = %+v, want the invoice at %s", entries, key)
+	}
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+const (
+	// maxBulkDeleteIDs is the largest ID list accepted by a bulk delete
+	maxBulkDeleteIDs = 1000
+
+	// bulkDeleteWorkers bounds the concurrent storage deletions of a bulk delete
+	bulkDeleteWorkers = 8
+
+	// bulkDeleteChunkSize is the number of items an asynchronous bulk delete
+	// removes between two progress updates
+	bulkDeleteChunkSize = 100
+)
+
+// BulkDeleteInput selects the content removed by BulkDelete: either a list
+// of IDs, or everything linked to an entity
+type BulkDeleteInput struct {
+	IDs        []uuid.UUID `json:"ids"`
+	EntityType string      `json:"entity_type"`
+	EntityID   string      `json:"entity_id"`
+}
+
+// BulkDeleteStatus is the outcome of deleting a single content item
+type BulkDeleteStatus string
+
+const (
+	BulkDeleted      BulkDeleteStatus = "deleted"
+	BulkNotFound     BulkDeleteStatus = "not_found"
+	BulkStorageError BulkDeleteStatus = "storage_error" // Deleted, but its data could not be removed
+	BulkRetained     BulkDeleteStatus = "retained"      // Kept by the retention of an entity type it is linked to
+)
+
+// BulkDeleteItem reports what happened to one content item
+type BulkDeleteItem struct {
+	ID     uuid.UUID        `json:"id"`
+	Status BulkDeleteStatus `json:"status"`
+	Error  string           `json:"error,omitempty"`
+}
+
+// BulkDeleteResult summarizes a bulk delete
+type BulkDeleteResult struct {
+	Items   []BulkDeleteItem `json:"items"`
+	Deleted int              `json:"deleted"`
+}
+
+// BulkDelete soft-deletes content in a single repository call, then removes
+// the stored data concurrently
+func (s *ContentService) BulkDelete(ctx context.Context, input BulkDeleteInput) (*BulkDeleteResult, error) {
+	ids, err := s.bulkDeleteIDs(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return s.deleteContents(ctx, ids, nil)
+}
+
+// StartBulkDelete runs a bulk delete of the content of a tenant in a job, in
+// chunks so that it reports progress and can be cancelled between them. The
+// selection is resolved before the job starts.
+func (s *ContentService) StartBulkDelete(ctx context.Context, tenantID string, input BulkDeleteInput) (*model.Job, error) {
+	ids, err := s.bulkDeleteIDs(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &model.Job{Kind: JobKindBulkDelete, TenantID: tenantID}
+	return s.startJob(ctx, job, input, nil, func(ctx context.Context, progress *JobProgress) (interface{}, error) {
+		progress.SetTotal(int64(len(ids)))
+		result := &BulkDeleteResult{Items: []BulkDeleteItem{}}
+		for start := 0; start < len(ids); start += bulkDeleteChunkSize {
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+			chunk := ids[start:min(start+bulkDeleteChunkSize, len(ids))]
+			chunkResult, err := s.deleteContents(ctx, chunk, nil)
+			if err != nil {
+				return result, err
+			}
+			result.Items = append(result.Items, chunkResult.Items...)
+			result.Deleted += chunkResult.Deleted
+			progress.Add(int64(len(chunk)))
+		}
+		return result, nil
+	})
+}
+
+// deleteContents deletes the content with the given distinct IDs. Links in
+// unlinked were just removed but still count towards retention.
+func (s *ContentService) deleteContents(ctx context.Context, ids []uuid.UUID, unlinked []*model.ContentEntityAssociation) (*BulkDeleteResult, error) {
+	result := &BulkDeleteResult{Items: make([]BulkDeleteItem, len(ids))}
+	contents := make(map[uuid.UUID]*model.Content, len(ids))
+	associations := make(map[uuid.UUID][]*model.ContentEntityAssociation, len(ids))
+	existing, err := s.repo.GetContentsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, content := range existing {
+		contents[content.ID] = content
+	}
+
+	var found []uuid.UUID
+	for i, id := range ids {
+		result.Items[i] = BulkDeleteItem{ID: id, Status: BulkNotFound}
+		if contents[id] == nil {
+			continue
+		}
+		// Collect the linked entities before the content disappears
+		associations[id] = s.contentAssociations(ctx, id)
+
+		links := associations[id]
+		for _, association := range unlinked {
+			if association.ContentID == id {
+				links = append(links[:len(links):len(links)], association)
+			}
+		}
+		if err := s.checkRetention(contents[id], links); err != nil {
+			result.Items[i] = BulkDeleteItem{ID: id, Status: BulkRetained, Error: err.Error()}
+			continue
+		}
+		found = append(found, id)
+	}
+
+	deleted, err := s.repo.DeleteContents(ctx, found)
+	if err != nil {
+		return nil, err
+	}
+	deletedSet := make(map[uuid.UUID]bool, len(deleted))
+	for _, id := range deleted {
+		deletedSet[id] = true
+		for _, association := range associations[id] {
+			s.publishEntityEvent(EventContentRemoved, association, contents[id])
+		}
+	}
+
+	// Storage errors don't undo the delete, they are reported per item
+	storageErrors := s.deleteStoredData(ctx, deleted, contents)
+	for i := range result.Items {
+		item := &result.Items[i]
+		if !deletedSet[item.ID] {
+			continue
+		}
+		if err := storageErrors[item.ID]; err != nil {
+			item.Status = BulkStorageError
+			item.Error = err.Error()
+		} else {
+			item.Status = BulkDeleted
+		}
+		result.Deleted++
+	}
+
+	return result, nil
+}
+
+// bulkDeleteIDs resolves the content selected by a bulk delete
+func (s *ContentService) bulkDeleteIDs(ctx context.Context, input BulkDeleteInput) ([]uuid.UUID, error) {
+	byEntity := input.EntityType != "" || input.EntityID != ""
+	switch {
+	case len(input.IDs) > 0 && byEntity:
+		return nil, fmt.Errorf("%w: select content by IDs or by entity, not both", ErrInvalidInput)
+	case len(input.IDs) > maxBulkDeleteIDs:
+		return nil, fmt.Errorf("%w: at most %d IDs can be deleted at once", ErrInvalidInput, maxBulkDeleteIDs)
+	case len(input.IDs) > 0:
+		ids := make([]uuid.UUID, 0, len(input.IDs))
+		seen := make(map[uuid.UUID]bool, len(input.IDs))
+		for _, id := range input.IDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		return ids, nil
+	case input.EntityType == "" || input.EntityID == "":
+		return nil, fmt.Errorf("%w: IDs or an entity are required", ErrInvalidInput)
+	}
+
+	// Collect first so that deleting does not shift the pages being read
+	var ids []uuid.UUID
+	for page := 1; ; page++ {
+		items, _, err := s.repo.ListContentByEntity(ctx, input.EntityType, input.EntityID, repository.ListOptions{Page: page, PageSize: exportPageSize, IncludeDrafts: true})
+		if err != nil {
+			return nil, err
+		}
+		for _, content := range items {
+			ids = append(ids, content.ID)
+		}
+		if len(items) < exportPageSize {
+			break
+		}
+	}
+	return ids, nil
+}
+
+// deleteStoredData removes the data of deleted content with bounded
+// parallelism and returns the errors by content ID
+func (s *ContentService) deleteStoredData(ctx context.Context, ids []uuid.UUID, contents map[uuid.UUID]*model.Content) map[uuid.UUID]error {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		errs    = make(map[uuid.UUID]error)
+		workers = make(chan struct{}, bulkDeleteWorkers)
+	)
+
+	for _, id := range ids {
+		content := contents[id]
+		workers <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+
+			err := s.deleteContentObjects(ctx, content)
+			s.releaseQuota(ctx, content.TenantID, content.FileSize)
+
+			if err != nil {
+				mu.Lock()
+				errs[content.ID] = err
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+	return errs
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/livefire2015/simple-contents/model"
+)
+
+const (
+	// MetadataCategory holds the primary category of classified content and can be used as a filter
+	MetadataCategory = "category"
+	// MetadataCategories holds every category assigned to classified content
+	MetadataCategories = "categories"
+
+	// classificationSampleSize is the amount of leading data passed to classifiers and scanned for PII
+	classificationSampleSize = 64 << 10
+	// classifierTimeout bounds a single classifier so uploads are not held up
+	classifierTimeout = 10 * time.Second
+)
+
+// Classifier assigns categories such as "receipt", "invoice" or "id_document"
+// to uploaded content. sample holds the first bytes of the data; classifiers
+// that need the full object, e.g. external ML services, can download it.
+type Classifier interface {
+	Classify(ctx context.Context, content *model.Content, sample []byte) ([]string, error)
+}
+
+// ClassifierFunc adapts a function to the Classifier interface
+type ClassifierFunc func(ctx context.Context, content *model.Content, sample []byte) ([]string, error)
+
+// Classify calls f
+func (f ClassifierFunc) Classify(ctx context.Context, content *model.Content, sample []byte) ([]string, error) {
+	return f(ctx, content, sample)
+}
+
+// ClassificationRule assigns Category to content matching its patterns
+type ClassificationRule struct {
+	Category        string
+	MIMETypes       []string       // Restricts the rule to these MIME types, any type if empty
+	FileNamePattern *regexp.Regexp // Matched against the file name
+	ContentPattern  *regexp.Regexp // Matched against the leading data of text-like content
+}
+
+// matches reports whether content satisfies the rule
+func (r ClassificationRule) matches(content *model.Content, sample []byte) bool {
+	if len(r.MIMETypes) > 0 && !slices.Contains(r.MIMETypes, content.MIMEType) {
+		return false
+	}
+	if r.FileNamePattern != nil && r.FileNamePattern.MatchString(content.FileName) {
+		return true
+	}
+	return r.ContentPattern != nil && isTextLike(content.MIMEType) && r.ContentPattern.Match(sample)
+}
+
+// isTextLike reports whether data of a MIME type can be searched as text
+func isTextLike(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "text/") ||
+		mimeType == "application/json" ||
+		mimeType == "application/xml" ||
+		mimeType == "application/pdf"
+}
+
+// DefaultClassificationRules returns the built-in rules for common business documents
+func DefaultClassificationRules() []ClassificationRule {
+	return []ClassificationRule{
+		{
+			Category:        "receipt",
+			FileNamePattern: regexp.MustCompile(`(?i)receipt`),
+			ContentPattern:  regexp.MustCompile(`(?i)\breceipt\b|\btotal\s+paid\b`),
+		},
+		{
+			Category:        "invoice",
+			FileNamePattern: regexp.MustCompile(`(?i)invoice|\binv[-_]?\d+`),
+			ContentPattern:  regexp.MustCompile(`(?i)\binvoice\s+(no\.?|number|#)|\bamount\s+due\b`),
+		},
+		{
+			Category:        "id_document",
+			FileNamePattern: regexp.MustCompile(`(?i)passport|driver'?s?[-_ ]?licen[cs]e|\bid[-_ ]?card`),
+			ContentPattern:  regexp.MustCompile(`(?i)\bpassport\s+no\b|\bdate\s+of\s+birth\b`),
+		},
+	}
+}
+
+// RulesClassifier is the built-in classifier matching file names and text patterns
+type RulesClassifier struct {
+	rules []ClassificationRule
+}
+
+// NewRulesClassifier creates a classifier from rules, evaluated in order
+func NewRulesClassifier(rules ...ClassificationRule) *RulesClassifier {
+	return &RulesClassifier{
+		rules: rules,
+	}
+}
+
+// Classify returns the category of every matching rule
+func (c *RulesClassifier) Classify(ctx context.Context, content *model.Content, sample []byte) ([]string, error) {
+	var categories []string
+	for _, rule := range c.rules {
+		if rule.matches(content, sample) && !slices.Contains(categories, rule.Category) {
+			categories = append(categories, rule.Category)
+		}
+	}
+	return categories, nil
+}
+
+// ConfigureClassifiers sets the classifiers run on content after upload
+func (s *ContentService) ConfigureClassifiers(classifiers ...Classifier) {
+	s.classifiers = classifiers
+}
+
+// classify runs every classifier and records the categories in the content's metadata.
+// Classification is best effort: failing classifiers are logged and skipped.
+func (s *ContentService) classify(ctx context.Context, content *model.Content, sample []byte) {
+	var categories []string
+	for _, classifier := range s.classifiers {
+		classifyCtx, cancel := context.WithTimeout(ctx, classifierTimeout)
+		found, err := classifier.Classify(classifyCtx, content, sample)
+		cancel()
+		if err != nil {
+			log.Printf("Failed to classify content %s: %v", content.ID, err)
+			continue
+		}
+		for _, category := range found {
+			if !slices.Contains(categories, category) {
+				categories = append(categories, category)
+			}
+		}
+	}
+	if len(categories) == 0 {
+		return
+	}
+
+	if content.Metadata == nil {
+		content.Metadata = make(model.Metadata)
+	}
+	content.Metadata[MetadataCategory] = categories[0]
+	content.Metadata[MetadataCategories] = categories
+}
+
+// sampleBuffer keeps the first classificationSampleSize bytes written to it
+type sampleBuffer struct {
+	bytes.Buffer
+}
+
+func (b *sampleBuffer) Write(p []byte) (int, error) {
+	if room := classificationSampleSize - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(room, len(p))])
+	}
+	return len(p), nil
+}
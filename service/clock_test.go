@@ -0,0 +1,100 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/clock"
+	"github.com/livefire2015/simple-contents/repository"
+	"github.com/livefire2015/simple-contents/repository/memory"
+)
+
+func TestConfigureClockAndIDs(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	now := clock.NewManual(start)
+	ids := &clock.Sequence{}
+	repo := memory.NewMemoryRepository()
+	repo.ConfigureClock(now)
+	repo.ConfigureIDs(ids)
+	s := newTestInstance(repo)
+	s.ConfigureClock(now)
+	s.ConfigureIDs(ids)
+
+	content, err := s.CreateContent(ctx, CreateContentInput{
+		FileName: "scan.pdf", MIMEType: "application/pdf", FileSize: 3, Data: strings.NewReader("pdf"),
+		EntityType: "claim", EntityID: "c1", DraftTTL: time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := content.ID.String(), "00000000-0000-0000-0000-000000000001"; got != want {
+		t.Errorf("content ID = %s, want %s", got, want)
+	}
+	if !content.CreatedAt.Equal(start) {
+		t.Errorf("CreatedAt = %v, want %v", content.CreatedAt, start)
+	}
+	if want := start.Add(time.Hour); !content.DraftExpiresAt.Equal(want) {
+		t.Errorf("DraftExpiresAt = %v, want %v", content.DraftExpiresAt, want)
+	}
+	associations, _, err := s.ListAssociationsForContent(ctx, content.ID, repository.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(associations) != 1 || associations[0].ID.String() != "00000000-0000-0000-0000-000000000002" || !associations[0].CreatedAt.Equal(start) {
+		t.Errorf("associations = %+v, want the second ID created at %v", associations, start)
+	}
+
+	// The draft expires when the clock passes its TTL, not the system clock
+	for _, step := range []struct {
+		advance time.Duration
+		purged  int
+	}{
+		{advance: 59 * time.Minute, purged: 0},
+		{advance: time.Minute, purged: 1},
+	} {
+		now.Advance(step.advance)
+		purged, err := s.PurgeExpiredDrafts(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(purged) != step.purged {
+			t.Errorf("PurgeExpiredDrafts() at %v purged %d drafts, want %d", now.Now(), len(purged), step.purged)
+		}
+	}
+}
+
+func TestDefaultIDsAreTimeOrdered(t *testing.T) {
+	ctx := context.Background()
+	s := newTestInstance(memory.NewMemoryRepository())
+
+	var previous uuid.UUID
+	for i := 0; i < 3; i++ {
+		content, err := s.CreateContent(ctx, CreateContentInput{
+			FileName: "scan.pdf", MIMEType: "application/pdf", FileSize: 3, Data: strings.NewReader("pdf"),
+			EntityType: "claim", EntityID: "c1",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if content.ID.Version() != 7 {
+			t.Errorf("content ID %s is version %d, want 7", content.ID, content.ID.Version())
+		}
+		if bytes.Compare(content.ID[:], previous[:]) <= 0 {
+			t.Errorf("content ID %s sorts before %s created earlier", content.ID, previous)
+		}
+		previous = content.ID
+
+		associations, _, err := s.ListAssociationsForContent(ctx, content.ID, repository.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(associations) != 1 || associations[0].ID.Version() != 7 {
+			t.Errorf("associations = %+v, want one with a version 7 ID", associations)
+		}
+	}
+}
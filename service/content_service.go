@@ -2,12 +2,18 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
-	"path"
+	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/clock"
 	"github.com/livefire2015/simple-contents/model"
 	"github.com/livefire2015/simple-contents/repository"
 	"github.com/livefire2015/simple-contents/storage"
@@ -16,70 +22,324 @@ import (
 var (
 	ErrContentNotFound = errors.New("content not found")
 	ErrInvalidInput    = errors.New("invalid input parameters")
+	ErrInvalidStatus   = errors.New("invalid content status")
 )
 
+// ContentAPI is the core of ContentService as an interface, for code that
+// calls the service and substitutes a fake for it in tests
+type ContentAPI interface {
+	CreateContent(ctx context.Context, input CreateContentInput) (*model.Content, error)
+	GetContent(ctx context.Context, id uuid.UUID) (*model.Content, error)
+	GetContentData(ctx context.Context, id uuid.UUID) (io.ReadCloser, *model.Content, error)
+	GetContentURL(ctx context.Context, id uuid.UUID, expiry time.Duration) (*ContentURL, error)
+	UpdateContent(ctx context.Context, input UpdateContentInput) (*model.Content, error)
+	UpdateContentStatus(ctx context.Context, id uuid.UUID, status model.ContentStatus) (*model.Content, error)
+	MarkContentAsUploaded(ctx context.Context, id uuid.UUID) (*model.Content, error)
+	DeleteContent(ctx context.Context, id uuid.UUID, options DeleteContentOptions) (*DeleteContentResult, error)
+	ListContent(ctx context.Context, input ListContentInput) (*ListContentResult, error)
+	AssociateContent(ctx context.Context, input AssociateContentInput) (*model.ContentEntityAssociation, error)
+	RemoveAssociation(ctx context.Context, associationID uuid.UUID) error
+	GetContentForEntity(ctx context.Context, entityType string, entityID string, options repository.ListOptions) ([]*model.Content, int64, error)
+	GetContentWithAssociationsForEntity(ctx context.Context, entityType string, entityID string, options repository.ListOptions) ([]*model.EntityContent, int64, error)
+}
+
+var _ ContentAPI = (*ContentService)(nil)
+
 // ContentService handles business logic for content operations
 type ContentService struct {
-	repo    repository.ContentRepository
-	storage storage.StorageService
+	repo              repository.ContentRepository
+	tenants           *TenantService
+	classifiers       []Classifier
+	pii               *PIIConfig
+	imageSanitization *ImageSanitizationConfig
+	storage           storage.StorageService
+	remote            *remoteFetcher
+	webhooks          atomic.Pointer[webhookNotifier]
+	events            *EventBus
+	transcoder        Transcoder
+	converter         DocumentConverter
+	derivativeWorkers chan struct{}
+	deletionPolicy    DeletionPolicy
+	entityTypes       *EntityTypeRegistry
+	attachmentLimits  AttachmentLimits
+	scanner           VirusScanner
+	scanResults       repository.ScanResultRepository
+	metadataSchemas   *MetadataSchemas
+	metadataLimits    MetadataLimits
+	searchIndexes     repository.SearchIndexRepository
+	exportConfig      *ExportConfig
+	jobStore          repository.JobRepository
+	jobs              *jobRunner
+	scanBackoff       time.Duration
+	scans             sync.WaitGroup // Virus scans running in the background
+	auditLog          repository.AuditRepository
+	storageMonitor    *storageMonitor
+	transferAudit     *transferAudit
+	urlExpiry         URLExpiryPolicy
+	clock             clock.Clock
+	ids               clock.IDGenerator
+	keys              KeyStrategy
+	keyLimits         KeyLimits
 }
 
 // NewContentService creates a new content service
 func NewContentService(repo repository.ContentRepository, storage storage.StorageService) *ContentService {
-	return &ContentService{
+	s := &ContentService{
 		repo:    repo,
 		storage: storage,
+		remote:  newRemoteFetcher(DefaultRemoteFetchConfig()),
+		events:  NewEventBus(),
+
+		derivativeWorkers: make(chan struct{}, derivativeWorkers),
+		jobs:              newJobRunner(),
+		deletionPolicy:    DeleteForce,
+		urlExpiry:         DefaultURLExpiryPolicy(),
+		metadataLimits:    DefaultMetadataLimits(),
+		clock:             clock.System,
+		ids:               clock.TimeOrderedIDs,
+		keys:              FlatKeys{},
+		keyLimits:         DefaultKeyLimits(),
 	}
+	s.jobStore, _ = repo.(repository.JobRepository)
+	s.webhooks.Store(newWebhookNotifier(DefaultWebhookConfig()))
+	return s
+}
+
+// ConfigureTenants makes content creation check the tenant registry
+func (s *ContentService) ConfigureTenants(tenants *TenantService) {
+	s.tenants = tenants
+}
+
+// ConfigureClock sets the clock the service takes the time from, e.g. for
+// timestamps, expiries and the cutoffs of sweeps. Durations it measures,
+// such as those of transfers, use the system clock.
+func (s *ContentService) ConfigureClock(c clock.Clock) {
+	s.clock = c
+}
+
+// ConfigureIDs sets the generator of the IDs of new content and
+// associations, time-ordered UUIDv7 by default
+func (s *ContentService) ConfigureIDs(ids clock.IDGenerator) {
+	s.ids = ids
+}
+
+// now returns the current time of the service's clock in UTC
+func (s *ContentService) now() time.Time {
+	return s.clock.Now().UTC()
+}
+
+// storageContext scopes storage operations to the tenant owning content
+func storageContext(ctx context.Context, content *model.Content) context.Context {
+	return storage.WithTenant(ctx, content.TenantID)
 }
 
 // CreateContentInput represents input for creating content
 type CreateContentInput struct {
-	FileName  string
-	MIMEType  string
-	FileSize  int64
-	CreatedBy string
+	TenantID    string    `validate:"max=63"` // Owning tenant, empty for untenanted content
+	FileName    string    `validate:"required,max=255"`
+	Description string    `validate:"max=20000"` // Markdown
+	MIMEType    string    `validate:"required,max=255"`
+	FileSize    int64     // Size in bytes, or storage.UnknownSize for streaming sources
+	Data        io.Reader `validate:"required"` // Content data to upload
+	CreatedBy   string    `validate:"max=255"`  // Ignored when the context carries a principal
 	// ** Crucial for association **
-	EntityType string // e.g., common.EntityTypeTransaction
-	EntityID   string // e.g., the specific transaction ID
+	EntityType string `validate:"max=100"` // e.g., common.EntityTypeTransaction
+	EntityID   string `validate:"max=255"` // e.g., the specific transaction ID
 	// ** End crucial for association **
-	Source   string
-	Metadata model.Metadata
+	Source      string         `validate:"max=100"`
+	ExternalID  string         `validate:"max=255"` // Key of the content in the source system, unique per tenant and source
+	Metadata    model.Metadata `validate:"max=1000"`
+	CallbackURL string         `validate:"omitempty,url,max=2048"` // Invoked once when the content reaches done or error status
+	DraftTTL    time.Duration  // Creates a draft purged unless finalized within the TTL if set
+
+	DerivedFromID *uuid.UUID // Source content of a derivative, e.g. a transcode
+	Derivation    string     `validate:"max=100"` // How the derivative was produced, e.g. "transcode/mp4"
 }
 
 // CreateContent creates a new content item
 func (s *ContentService) CreateContent(ctx context.Context, input CreateContentInput) (*model.Content, error) {
-	if input.FileName == "" || input.MIMEType == "" || input.FileSize <= 0 {
-		return nil, ErrInvalidInput
+	if s.transferAudit != nil && input.Data != nil && input.DerivedFromID == nil {
+		return s.auditUpload(ctx, input)
+	}
+	return s.createContent(ctx, input)
+}
+
+// createContent creates a new content item, storing its data
+func (s *ContentService) createContent(ctx context.Context, input CreateContentInput) (*model.Content, error) {
+	if err := validateInput(input); err != nil {
+		return nil, err
+	}
+	input.CreatedBy = principalOr(ctx, input.CreatedBy)
+	if input.FileSize <= 0 && input.FileSize != storage.UnknownSize {
+		return nil, fmt.Errorf("%w: file_size must be positive", ErrInvalidInput)
+	}
+	fileName, err := SanitizeFileName(input.FileName)
+	if err != nil {
+		return nil, err
+	}
+	input.FileName = fileName
+	if err := validateCallbackURL(input.CallbackURL); err != nil {
+		return nil, err
+	}
+	draftExpiresAt, err := s.draftExpiry(input.DraftTTL)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkStorageAvailable(); err != nil {
+		return nil, err
+	}
+	if input.TenantID != "" && s.tenants != nil {
+		if _, err := s.tenants.GetTenant(ctx, input.TenantID); err != nil {
+			return nil, err
+		}
+	}
+	var entityTypes []string
+	if input.EntityType != "" && input.EntityID != "" {
+		if err := s.checkAssociation(ctx, input.EntityType, input.EntityID, "", input.MIMEType); err != nil {
+			return nil, err
+		}
+		entityTypes = append(entityTypes, input.EntityType)
+	}
+	if err := s.validateMetadata(input.Metadata, input.Source, entityTypes...); err != nil {
+		return nil, err
+	}
+	if err := s.checkExternalID(ctx, input.TenantID, input.Source, input.ExternalID); err != nil {
+		return nil, err
 	}
 
 	// Generate a unique ID for the content
-	contentID := uuid.New()
+	contentID := s.ids.NewID()
 
 	// Create a storage key based on content ID and name
-	storageKey := path.Join(contentID.String(), input.FileName)
+	storageKey, err := s.storageKey(contentID, input.TenantID, input.FileName)
+	if err != nil {
+		return nil, err
+	}
 
-	// Store the content data
-	storagePath, err := s.storage.Upload(ctx, storageKey, nil, input.FileSize, input.MIMEType)
+	// Known sizes are reserved against the tenant's quota before uploading
+	if err := s.reserveQuota(ctx, input.TenantID, input.FileSize); err != nil {
+		return nil, err
+	}
+
+	// Keep the leading data for classification and PII scanning while it streams to storage
+	data := input.Data
+	var sample sampleBuffer
+	if len(s.classifiers) > 0 || s.pii != nil {
+		data = io.TeeReader(data, &sample)
+	}
+	// Hash the data for scan results, which are cached by digest
+	digest := sha256.New()
+	if s.scanner != nil {
+		data = io.TeeReader(data, digest)
+	}
+
+	// Store the content data in the tenant's bucket and prefix
+	storageCtx := storage.WithTenant(ctx, input.TenantID)
+	storagePath, err := s.storage.Upload(storageCtx, storageKey, data, input.FileSize, input.MIMEType)
 	if err != nil {
+		s.releaseQuota(ctx, input.TenantID, input.FileSize)
 		return nil, err
 	}
 
+	// Ask storage for the object's ETag and size. Streaming sources don't
+	// know their length up front, so only then is their quota checked
+	info, err := s.storage.Stat(storageCtx, storagePath)
+	if err != nil {
+		_ = s.storage.Delete(storageCtx, storagePath)
+		s.releaseQuota(ctx, input.TenantID, input.FileSize)
+		return nil, err
+	}
+	if err := s.reconcileQuota(ctx, input.TenantID, input.FileSize, info.Size); err != nil {
+		_ = s.storage.Delete(storageCtx, storagePath)
+		s.releaseQuota(ctx, input.TenantID, input.FileSize)
+		return nil, err
+	}
+	fileSize := info.Size
+
 	// Create the content record
 	content := &model.Content{
 		ID:          contentID,
+		TenantID:    input.TenantID,
+		Status:      model.StatusCreated,
 		FileName:    input.FileName,
+		Description: input.Description,
 		MIMEType:    input.MIMEType,
-		FileSize:    input.FileSize,
+		FileSize:    fileSize,
 		StoragePath: storagePath,
+		ETag:        info.ETag,
+		CreatedBy:   input.CreatedBy,
+		Source:      input.Source,
+		ExternalID:  input.ExternalID,
 		Metadata:    input.Metadata,
+		CallbackURL: input.CallbackURL,
+
+		DerivedFromID:  input.DerivedFromID,
+		Derivation:     input.Derivation,
+		DraftExpiresAt: draftExpiresAt,
+	}
+	s.classify(ctx, content, sample.Bytes())
+	s.scanPII(content, sample.Bytes())
+	s.queueVirusScan(content, hex.EncodeToString(digest.Sum(nil)))
+
+	// Images must not be served with their location data
+	if s.shouldSanitize(content) {
+		if err := s.sanitizeImage(ctx, content); err != nil {
+			_ = s.storage.Delete(storageCtx, storagePath)
+			s.releaseQuota(ctx, input.TenantID, fileSize)
+			if errors.Is(err, errMalformedImage) {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+			}
+			return nil, err
+		}
+		// The tenant is charged for the sanitized copy it is served
+		if err := s.reconcileQuota(ctx, input.TenantID, fileSize, content.FileSize); err != nil {
+			_ = s.storage.Delete(storageCtx, content.StoragePath)
+			if content.OriginalStoragePath != "" {
+				_ = s.storage.Delete(storageCtx, content.OriginalStoragePath)
+			}
+			s.releaseQuota(ctx, input.TenantID, fileSize)
+			return nil, err
+		}
+		fileSize = content.FileSize
+		// The sanitized copy is served, not the data that was hashed, so it's hashed when scanned
+		s.queueVirusScan(content, "")
 	}
 
-	if err := s.repo.CreateContent(ctx, content); err != nil {
-		// Clean up storage if repository creation fails
-		_ = s.storage.Delete(ctx, storagePath)
-		return nil, err
+	var association *model.ContentEntityAssociation
+	if input.EntityType != "" && input.EntityID != "" {
+		association = s.newAssociation(AssociateContentInput{
+			ContentID:    content.ID,
+			EntityType:   input.EntityType,
+			EntityID:     input.EntityID,
+			AssociatedBy: input.CreatedBy,
+		})
 	}
 
+	// The content and its association are stored together or not at all
+	err = s.repo.WithTx(ctx, func(tx repository.ContentRepository) error {
+		if err := tx.CreateContent(ctx, content); err != nil {
+			return err
+		}
+		if association != nil {
+			return createAssociation(ctx, tx, association)
+		}
+		return nil
+	})
+	if err != nil {
+		// Storage isn't transactional, so clean up the uploaded data
+		_ = s.storage.Delete(storageCtx, content.StoragePath)
+		if content.OriginalStoragePath != "" {
+			_ = s.storage.Delete(storageCtx, content.OriginalStoragePath)
+		}
+		s.releaseQuota(ctx, input.TenantID, fileSize)
+		return nil, storeError(err, content)
+	}
+
+	if association != nil {
+		s.publishEntityEvent(EventContentAdded, association, content)
+	}
+	s.scanInBackground(ctx, content)
+
 	return content, nil
 }
 
@@ -96,7 +356,15 @@ func (s *ContentService) GetContent(ctx context.Context, id uuid.UUID) (*model.C
 	return content, nil
 }
 
-// GetContentData retrieves the data for a content item
+// getContentForUpdate reads a content item that is about to be changed from
+// the primary, so the change isn't based on a stale replica or cached copy
+func (s *ContentService) getContentForUpdate(ctx context.Context, id uuid.UUID) (*model.Content, error) {
+	return s.GetContent(repository.ReadFromPrimary(ctx), id)
+}
+
+// GetContentData retrieves the data for a content item. References have
+// no data: they fail with ErrReferenceContent, returned with the content so
+// callers can send clients to its URL.
 func (s *ContentService) GetContentData(ctx context.Context, id uuid.UUID) (io.ReadCloser, *model.Content, error) {
 	content, err := s.repo.GetContentByID(ctx, id)
 	if err != nil {
@@ -105,41 +373,60 @@ func (s *ContentService) GetContentData(ctx context.Context, id uuid.UUID) (io.R
 		}
 		return nil, nil, err
 	}
+	if content.IsReference() {
+		return nil, content, ErrReferenceContent
+	}
+	if err := CheckScanned(content); err != nil {
+		return nil, nil, err
+	}
 
-	data, err := s.storage.Download(ctx, content.StoragePath)
+	data, err := s.storage.Download(storageContext(ctx, content), content.StoragePath)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return data, content, nil
+	return s.auditDownload(ctx, content, data, content.FileSize), content, nil
 }
 
 // UpdateContentInput represents input for updating content
 type UpdateContentInput struct {
-	ID       uuid.UUID
-	FileName string
-	Metadata model.Metadata
+	ID          uuid.UUID      `validate:"required"`
+	FileName    string         `validate:"max=255"`
+	Description *string        `validate:"max=20000"` // Markdown, left unchanged if nil and cleared if empty
+	Metadata    model.Metadata `validate:"max=1000"`
 }
 
 // UpdateContent updates a content item
 func (s *ContentService) UpdateContent(ctx context.Context, input UpdateContentInput) (*model.Content, error) {
-	if input.ID == uuid.Nil {
-		return nil, ErrInvalidInput
+	if err := validateInput(input); err != nil {
+		return nil, err
 	}
 
-	content, err := s.repo.GetContentByID(ctx, input.ID)
+	content, err := s.getContentForUpdate(ctx, input.ID)
 	if err != nil {
-		if errors.Is(err, repository.ErrContentNotFound) {
-			return nil, ErrContentNotFound
-		}
 		return nil, err
 	}
 
 	// Update fields if provided
 	if input.FileName != "" {
-		content.FileName = input.FileName
+		fileName, err := SanitizeFileName(input.FileName)
+		if err != nil {
+			return nil, err
+		}
+		content.FileName = fileName
+	}
+	if input.Description != nil {
+		content.Description = *input.Description
 	}
 	if input.Metadata != nil {
+		entityTypes, err := s.linkedEntityTypes(ctx, content.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.validateMetadata(input.Metadata, content.Source, entityTypes...); err != nil {
+			return nil, err
+		}
+		keepScanMetadata(input.Metadata, content.Metadata)
 		content.Metadata = input.Metadata
 	}
 
@@ -147,251 +434,339 @@ func (s *ContentService) UpdateContent(ctx context.Context, input UpdateContentI
 		return nil, err
 	}
 
+	s.contentChanged(ctx, EventContentUpdated, content)
 	return content, nil
 }
 
-// DeleteContent deletes a content item
-func (s *ContentService) DeleteContent(ctx context.Context, id uuid.UUID) error {
-	content, err := s.repo.GetContentByID(ctx, id)
-	if err != nil {
-		if errors.Is(err, repository.ErrContentNotFound) {
-			return ErrContentNotFound
-		}
-		return err
-	}
+// ListContentInput represents input for listing content
+type ListContentInput struct {
+	TenantID      string
+	Status        model.ContentStatus
+	FileName      string // Matched as FileNameMatch says, a case-insensitive substring if empty
+	FileNameMatch model.FileNameMatch
+	Text          string // Case-insensitive substring of the file name or description
+	MIMEType      string
+	MinSize       *int64
+	MaxSize       *int64
+	CreatedFrom   *time.Time
+	CreatedTo     *time.Time
+	Metadata      map[string]interface{}
+	PinnedBy      string // Only content pinned by this principal
+	CreatedBy     string // Only content created by this principal
+	Kind          model.ContentKind
+	SortBy        model.ContentSortField
+	SortAsc       bool
+	Page          int
+	PageSize      int
+	Total         TotalMode // How the total is reported, counted if empty
+}
 
-	// Delete from repository first
-	if err := s.repo.DeleteContent(ctx, id); err != nil {
-		return err
-	}
+// TotalMode selects how a listing reports the number of matching items
+type TotalMode string
 
-	// Then delete from storage
-	// Note: We don't return storage deletion errors to the caller
-	// as the content is already marked as deleted in the repository
-	_ = s.storage.Delete(ctx, content.StoragePath)
+const (
+	TotalExact    TotalMode = "exact"    // Count the matching items
+	TotalEstimate TotalMode = "estimate" // Use the repository's estimate, cheaper on large tables
+	TotalNone     TotalMode = "none"     // Skip the total and only report whether more items follow
+)
 
-	return nil
+// IsValid reports whether m is a known total mode
+func (m TotalMode) IsValid() bool {
+	switch m {
+	case TotalExact, TotalEstimate, TotalNone:
+		return true
+	}
+	return false
 }
 
-// ListContentInput represents input for listing content
-type ListContentInput struct {
-	MIMEType    string
-	MinSize     *int64
-	MaxSize     *int64
-	CreatedFrom *time.Time
-	CreatedTo   *time.Time
-	Metadata    map[string]interface{}
-	Page        int
-	PageSize    int
+// ListContentResult represents the result of listing content. Without a
+// counted total, TotalCount and TotalPages are estimates or -1.
+type ListContentResult struct {
+	Items          []*model.Content
+	TotalCount     int
+	Page           int
+	PageSize       int
+	TotalPages     int
+	HasMore        bool
+	TotalEstimated bool `json:",omitempty"`
 }
 
-// ListContentResult represents the result of listing content
-type ListContentResult struct {
-	Items      []*model.Content
-	TotalCount int
-	Page       int
-	PageSize   int
-	TotalPages int
+// filter returns the content filter selected by the input
+func (input ListContentInput) filter() model.ContentFilter {
+	return model.ContentFilter{
+		TenantID:      input.TenantID,
+		Status:        input.Status,
+		FileName:      input.FileName,
+		FileNameMatch: input.FileNameMatch,
+		Text:          input.Text,
+		MIMEType:      input.MIMEType,
+		MinSize:       input.MinSize,
+		MaxSize:       input.MaxSize,
+		CreatedFrom:   input.CreatedFrom,
+		CreatedTo:     input.CreatedTo,
+		Metadata:      input.Metadata,
+		PinnedBy:      input.PinnedBy,
+		CreatedBy:     input.CreatedBy,
+		Kind:          input.Kind,
+
+		SortBy:        input.SortBy,
+		SortAscending: input.SortAsc,
+	}
 }
 
 // ListContent lists content items based on filter criteria
 func (s *ContentService) ListContent(ctx context.Context, input ListContentInput) (*ListContentResult, error) {
-	// Set default pagination values if not provided
-	if input.Page <= 0 {
-		input.Page = 1
+	if input.Total == "" || input.Total == TotalExact {
+		return s.SearchContent(ctx, input.filter(), input.Page, input.PageSize)
 	}
-	if input.PageSize <= 0 {
-		input.PageSize = 20
+	if !input.Total.IsValid() {
+		return nil, fmt.Errorf("%w: unknown total mode %q", ErrInvalidInput, input.Total)
 	}
 
-	// Calculate offset for pagination
-	offset := (input.Page - 1) * input.PageSize
+	filter := input.filter()
+	if filter.SortBy != "" && !filter.SortBy.IsValid() {
+		return nil, fmt.Errorf("%w: unknown sort field %q", ErrInvalidInput, filter.SortBy)
+	}
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidInput, filter.Status)
+	}
+	if filter.FileNameMatch != "" && !filter.FileNameMatch.IsValid() {
+		return nil, fmt.Errorf("%w: unknown file name match %q", ErrInvalidInput, filter.FileNameMatch)
+	}
+	if filter.Kind != "" && !filter.Kind.IsValid() {
+		return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidInput, filter.Kind)
+	}
 
-	// Create filter from input
-	filter := model.ContentFilter{
-		MIMEType:    input.MIMEType,
-		MinSize:     input.MinSize,
-		MaxSize:     input.MaxSize,
-		CreatedFrom: input.CreatedFrom,
-		CreatedTo:   input.CreatedTo,
-		Metadata:    input.Metadata,
+	page, pageSize := input.Page, input.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	result, err := s.repo.ListContentPage(ctx, filter, repository.ListOptions{
+		Page:          page,
+		PageSize:      pageSize,
+		ReturnTotal:   input.Total == TotalEstimate,
+		EstimateTotal: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := -1
+	if result.Total >= 0 {
+		totalPages = int((result.Total + int64(pageSize) - 1) / int64(pageSize))
+	}
+	return &ListContentResult{
+		Items:          result.Items,
+		TotalCount:     int(result.Total),
+		Page:           page,
+		PageSize:       pageSize,
+		TotalPages:     totalPages,
+		HasMore:        result.HasMore,
+		TotalEstimated: result.TotalEstimated,
+	}, nil
+}
+
+// SearchContent lists a page of the content matching a filter
+func (s *ContentService) SearchContent(ctx context.Context, filter model.ContentFilter, page, pageSize int) (*ListContentResult, error) {
+	if filter.SortBy != "" && !filter.SortBy.IsValid() {
+		return nil, fmt.Errorf("%w: unknown sort field %q", ErrInvalidInput, filter.SortBy)
+	}
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidInput, filter.Status)
+	}
+	if filter.FileNameMatch != "" && !filter.FileNameMatch.IsValid() {
+		return nil, fmt.Errorf("%w: unknown file name match %q", ErrInvalidInput, filter.FileNameMatch)
+	}
+	if filter.Kind != "" && !filter.Kind.IsValid() {
+		return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidInput, filter.Kind)
+	}
+
+	// Set default pagination values if not provided
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
 	}
 
+	// Calculate offset for pagination
+	offset := (page - 1) * pageSize
+
 	// Get content items
-	items, totalCount, err := s.repo.ListContent(ctx, filter, offset, input.PageSize)
+	items, totalCount, err := s.repo.ListContent(ctx, filter, offset, pageSize)
 	if err != nil {
 		return nil, err
 	}
 
 	// Calculate total pages
-	totalPages := totalCount / input.PageSize
-	if totalCount%input.PageSize > 0 {
+	totalPages := totalCount / pageSize
+	if totalCount%pageSize > 0 {
 		totalPages++
 	}
 
 	return &ListContentResult{
 		Items:      items,
 		TotalCount: totalCount,
-		Page:       input.Page,
-		PageSize:   input.PageSize,
+		Page:       page,
+		PageSize:   pageSize,
 		TotalPages: totalPages,
+		HasMore:    offset+len(items) < totalCount,
 	}, nil
 }
 
-// GetContentURL generates a URL for accessing content
-func (s *ContentService) GetContentURL(ctx context.Context, id uuid.UUID, expiry time.Duration) (string, error) {
+// GetContentURL generates a URL for accessing content, valid for expiry or,
+// if 0, the default of the URL expiry policy. Longer expiries than the
+// policy allows the caller are capped.
+func (s *ContentService) GetContentURL(ctx context.Context, id uuid.UUID, expiry time.Duration) (*ContentURL, error) {
 	content, err := s.repo.GetContentByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, repository.ErrContentNotFound) {
-			return "", ErrContentNotFound
+			return nil, ErrContentNotFound
 		}
-		return "", err
+		return nil, err
+	}
+
+	if err := CheckScanned(content); err != nil {
+		return nil, err
+	}
+	if s.piiRestricted(content, HasElevatedScope(ctx)) {
+		return nil, ErrPIIRestricted
+	}
+	// The URL of a reference doesn't expire
+	if content.IsReference() {
+		return &ContentURL{URL: content.ReferenceURL}, nil
+	}
+
+	granted, err := s.urlExpiryOf(ctx, content, expiry)
+	if err != nil {
+		return nil, err
+	}
+	url, err := s.storage.GetPresignedDownloadURL(storageContext(ctx, content), content.StoragePath, storage.PresignedURLOptions{Expiry: granted})
+	if err != nil {
+		return nil, err
+	}
+
+	if expiry > granted {
+		log.Printf("Issued URL for content %s of tenant %q valid for %s, capped from the %s requested", content.ID, content.TenantID, granted, expiry)
+	} else {
+		log.Printf("Issued URL for content %s of tenant %q valid for %s", content.ID, content.TenantID, granted)
 	}
+	return &ContentURL{URL: url, Expiry: granted, ExpiresAt: s.now().Add(granted)}, nil
+}
 
-	return s.storage.GetPresignedDownloadURL(ctx, content.StoragePath, storage.PresignedURLOptions{Expiry: expiry})
+// MarkContentAsUploaded confirms that the data for a content item is present in
+// storage and records the authoritative size and MIME type reported by the backend.
+func (s *ContentService) MarkContentAsUploaded(ctx context.Context, id uuid.UUID) (*model.Content, error) {
+	return s.MarkContentAsUploadedWithChecksum(ctx, id, "")
 }
 
-// AssociateContentInput defines the input for associating content with an entity
-type AssociateContentInput struct {
-	ContentID           string                 `json:"content_id"`
-	EntityType          string                 `json:"entity_type"`
-	EntityID            string                 `json:"entity_id"`
-	AssociationMetadata map[string]interface{} `json:"association_metadata"`
-	AssociatedBy        string                 `json:"associated_by"` // User/service performing the association
+// MarkContentAsUploadedWithChecksum confirms an upload like
+// MarkContentAsUploaded, also checking the data against a hex or base64
+// SHA-256 the client computed while uploading it. A checksum declared when
+// the upload was created must be the same.
+func (s *ContentService) MarkContentAsUploadedWithChecksum(ctx context.Context, id uuid.UUID, sha256 string) (*model.Content, error) {
+	var checksum []byte
+	if sha256 != "" {
+		var err error
+		if checksum, err = parseSHA256(sha256); err != nil {
+			return nil, err
+		}
+	}
+	content, err := s.getContentForUpdate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if content.Status != model.StatusCreated && content.Status != model.StatusError {
+		return nil, fmt.Errorf("%w: cannot mark content as uploaded, current status: %s", ErrInvalidStatus, content.Status)
+	}
+	if checksum != nil {
+		computed := hex.EncodeToString(checksum)
+		if declared, _ := content.Metadata[MetadataUploadSHA256].(string); declared != "" && declared != computed {
+			return nil, fmt.Errorf("%w: declared SHA-256 %s, computed %s", ErrChecksumMismatch, declared, computed)
+		}
+		if content.Metadata == nil {
+			content.Metadata = make(model.Metadata)
+		}
+		content.Metadata[MetadataUploadSHA256] = computed
+	}
+
+	info, err := s.storage.Stat(storageContext(ctx, content), content.StoragePath)
+	if err != nil {
+		content.Status = model.StatusError
+		if updateErr := s.repo.UpdateContent(ctx, content); updateErr == nil {
+			s.statusChanged(ctx, content)
+		}
+		return nil, fmt.Errorf("failed to get object metadata from storage for %s: %w", content.StoragePath, err)
+	}
+
+	// Corrupted direct uploads are rejected and removed
+	if err := s.verifyUploadChecksum(ctx, content, info); err != nil {
+		if errors.Is(err, ErrChecksumMismatch) {
+			_ = s.storage.Delete(storageContext(ctx, content), content.StoragePath)
+			content.Status = model.StatusError
+			if updateErr := s.repo.UpdateContent(ctx, content); updateErr == nil {
+				s.statusChanged(ctx, content)
+			}
+		}
+		return nil, err
+	}
+
+	// Direct uploads reserved their declared size, which may differ from what was uploaded
+	if err := s.reconcileQuota(ctx, content.TenantID, content.FileSize, info.Size); err != nil {
+		_ = s.storage.Delete(storageContext(ctx, content), content.StoragePath)
+		content.Status = model.StatusError
+		if updateErr := s.repo.UpdateContent(ctx, content); updateErr == nil {
+			s.statusChanged(ctx, content)
+		}
+		return nil, err
+	}
+
+	// Use the authoritative size and type from storage
+	content.FileSize = info.Size
+	content.ETag = info.ETag
+	if info.ContentType != "" {
+		content.MIMEType = info.ContentType
+	}
+	content.Status = model.StatusUploaded
+	// A declared checksum was verified above, so its cached verdict applies
+	declared, _ := content.Metadata[MetadataUploadSHA256].(string)
+	s.queueVirusScan(content, declared)
+
+	if err := s.repo.UpdateContent(ctx, content); err != nil {
+		return nil, fmt.Errorf("failed to update content record after upload confirmation: %w", err)
+	}
+	s.statusChanged(ctx, content)
+	s.scanInBackground(ctx, content)
+
+	return content, nil
 }
 
-// // AssociateContent links an existing content item to an entity.
-// func (s *ContentService) AssociateContent(ctx context.Context, input AssociateContentInput) (*model.ContentEntityAssociation, error) {
-// 	// 1. Validate that the content item exists
-// 	_, err := s.repo.GetContentByID(ctx, input.ContentID)
-// 	if err != nil {
-// 		return nil, fmt.Errorf("content with ID %s not found: %w", input.ContentID, err)
-// 	}
-
-// 	// 2. (Optional) Validate that the associating entity exists by calling another service or based on known types.
-// 	// This depends on your system's architecture.
-
-// 	// 3. Check for existing association if you don't want duplicates (based on unique constraint)
-// 	existingAssoc, err := s.repo.GetAssociationByLink(ctx, input.ContentID, input.EntityType, input.EntityID)
-// 	if err != nil && err != repository.ErrContentNotFound { // Assuming ErrNotFound is a distinct error type
-// 		return nil, fmt.Errorf("error checking for existing association: %w", err)
-// 	}
-// 	if existingAssoc != nil {
-// 		// You might want to update the existing one or return an error, based on policy
-// 		return nil, fmt.Errorf("content %s is already associated with entity %s/%s (association ID: %s)",
-// 			input.ContentID, input.EntityType, input.EntityID, existingAssoc.ID)
-// 	}
-
-// 	association := &model.ContentEntityAssociation{
-// 		ID:                  uuid.NewString(), // Generate new ID for the association
-// 		ContentID:           input.ContentID,
-// 		EntityType:          input.EntityType,
-// 		EntityID:            input.EntityID,
-// 		AssociationMetadata: input.AssociationMetadata,
-// 		CreatedBy:           input.AssociatedBy,
-// 		CreatedAt:           time.Now().UTC(),
-// 		UpdatedAt:           time.Now().UTC(),
-// 	}
-
-// 	if err := s.repo.CreateAssociation(ctx, association); err != nil {
-// 		return nil, fmt.Errorf("failed to create association: %w", err)
-// 	}
-
-// 	return association, nil
-// }
-
-// // GetContentForEntity retrieves content items linked to a specific entity.
-// func (s *ContentService) GetContentForEntity(ctx context.Context, entityType string, entityID string, options repository.ListOptions) ([]*model.Content, int64, error) {
-// 	if entityType == "" || entityID == "" {
-// 		return nil, 0, fmt.Errorf("entityType and entityID are required")
-// 	}
-// 	// This service method now calls the repository method that handles the join
-// 	return s.repo.ListContentByEntity(ctx, entityType, entityID, options)
-// }
-
-// // Inside your service.ContentService
-
-// // Assume s.storage has a method StatObject(ctx, storagePath) (string, error) that returns ObjectMetadata
-// // type ObjectMetadata struct {
-// //    Size int64
-// //    ContentType string // Could also get the ContentType set by the storage service
-// //    // Other relevant metadata
-// // }
-
-// func (s *ContentService) MarkContentAsUploaded(ctx context.Context, contentID string, storagePath string) (*model.Content, error) {
-// 	content, err := s.repo.GetContentByID(ctx, uuid.MustParse(contentID))
-// 	if err != nil {
-// 		return nil, fmt.Errorf("content with ID %s not found: %w", contentID, err)
-// 	}
-
-// 	if content.Status != model.StatusCreated && content.Status != model.StatusError {
-// 		return nil, fmt.Errorf("cannot mark content as uploaded, current status: %s", content.Status)
-// 	}
-
-// 	// At this point, the file is already in the storage (e.g., S3, Minio)
-// 	// We need to fetch the first 512 bytes from storage to verify MIME type.
-
-// 	var detectedMIMEType string
-// 	var actualFileSize int64 // Get this from storage if possible
-
-// 	// --- Hypothetical steps to get header from storage ---
-// 	// This part is pseudo-code as it depends on your StorageService interface and implementation
-// 	// You might need to add a method like `GetFirstNBytes(path, n)` to your StorageService
-// 	// or use the Download method with a ranged request if supported.
-
-// 	fileHeaderReader, err := s.storage.DownloadRange(ctx, storagePath, 0, 511) // Hypothetical method
-// 	if err != nil {
-// 		// Handle error: maybe can't access file, or file too small
-// 		// You might decide to trust client MIME or mark as error
-// 		fmt.Printf("Warning: could not download file header for MIME detection from storage for %s: %v\n", contentID, err)
-// 		// Fallback or error out based on policy
-// 		detectedMIMEType = content.MIMEType // Or mark as error/unknown
-// 	} else {
-// 		defer fileHeaderReader.Close()
-// 		headerBytes, readErr := io.ReadAll(fileHeaderReader)
-// 		if readErr != nil {
-// 			fmt.Printf("Warning: could not read file header for MIME detection for %s: %v\n", contentID, readErr)
-// 			detectedMIMEType = content.MIMEType // Fallback
-// 		} else {
-// 			detectedMIMEType = http.DetectContentType(headerBytes)
-// 			fmt.Printf("Post-upload MIME check for %s. Client: %s, Server detected: %s\n", contentID, content.MIMEType, detectedMIMEType)
-// 			if content.MIMEType != detectedMIMEType {
-// 				// Your policy here: update, log, reject, etc.
-// 				content.MIMEType = detectedMIMEType // Example: update to server-detected
-// 			}
-// 		}
-// 	}
-// 	// --- End hypothetical steps ---
-
-// 	// 1. Get metadata from storage service
-// 	objectMetadata, err := s.storage.StatObject(ctx, storagePath) // This is a hypothetical method you'd add to your StorageService interface and implement
-// 	if err != nil {
-// 		// Potentially mark content as error, or retry, or log and proceed with client-provided size if that's your policy
-// 		s.repo.UpdateStatus(ctx, contentID, model.StatusError)
-// 		return nil, fmt.Errorf("failed to get object metadata from storage for %s: %w", storagePath, err)
-// 	}
-
-// 	actualFileSize := objectMetadata.Size
-// 	// You could also trust the ContentType from storage if it's reliable,
-// 	// or perform your own header download + DetectContentType as discussed before.
-// 	// detectedMIMETypeFromStorage := objectMetadata.ContentType
-
-// 	// (Optional: MIME Type detection by downloading the first 512 bytes, as discussed previously)
-// 	// ... your MIME detection logic here if you don't trust storage-provided MIME ...
-// 	// verifiedMIMEType := ...
-
-// 	content.StoragePath = storagePath
-// 	content.FileSize = actualFileSize // Use the authoritative size from storage
-// 	content.Status = model.StatusUploaded
-// 	// content.MIMEType = verifiedMIMEType // Update if you re-verified
-// 	content.UpdatedAt = time.Now().UTC()
-
-// 	if err := s.repo.Update(ctx, content); err != nil {
-// 		// Consider what to do if DB update fails. File is in storage.
-// 		// Maybe a retry mechanism or an "undo" by deleting from storage is too risky / complex here.
-// 		// Logging this inconsistency is critical.
-// 		return nil, fmt.Errorf("failed to update content record after upload confirmation: %w", err)
-// 	}
-
-// 	return content, nil
-// }
+// UpdateContentStatus records a processing status transition for a content item
+func (s *ContentService) UpdateContentStatus(ctx context.Context, id uuid.UUID, status model.ContentStatus) (*model.Content, error) {
+	if !status.IsValid() {
+		return nil, ErrInvalidInput
+	}
+
+	content, err := s.getContentForUpdate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Failed content may be reprocessed, finished content may not
+	if content.Status == model.StatusDone && status != model.StatusDone {
+		return nil, fmt.Errorf("%w: content is already %s", ErrInvalidStatus, content.Status)
+	}
+
+	content.Status = status
+	if err := s.repo.UpdateContent(ctx, content); err != nil {
+		return nil, err
+	}
+
+	s.statusChanged(ctx, content)
+	return content, nil
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+	"github.com/livefire2015/simple-contents/repository/memory"
+)
+
+// replicaRepository serves content reads that don't request the primary
+// from a stale copy, as a lagging read replica would
+type replicaRepository struct {
+	*memory.MemoryRepository
+	stale map[uuid.UUID]*model.Content
+}
+
+func (r *replicaRepository) GetContentByID(ctx context.Context, id uuid.UUID) (*model.Content, error) {
+	if stale, ok := r.stale[id]; ok && !repository.PrimaryRequested(ctx) {
+		copied := *stale
+		copied.Metadata = model.Metadata{}
+		for key, value := range stale.Metadata {
+			copied.Metadata[key] = value
+		}
+		return &copied, nil
+	}
+	return r.MemoryRepository.GetContentByID(ctx, id)
+}
+
+func TestReadModifyWriteReadsPrimary(t *testing.T) {
+	tests := []struct {
+		name   string
+		update func(ctx context.Context, s *ContentService, id uuid.UUID) error
+	}{
+		{
+			name: "update",
+			update: func(ctx context.Context, s *ContentService, id uuid.UUID) error {
+				_, err := s.UpdateContent(ctx, UpdateContentInput{ID: id, FileName: "renamed.pdf"})
+				return err
+			},
+		},
+		{
+			name: "status",
+			update: func(ctx context.Context, s *ContentService, id uuid.UUID) error {
+				_, err := s.UpdateContentStatus(ctx, id, model.StatusDone)
+				return err
+			},
+		},
+		{
+			name: "release",
+			update: func(ctx context.Context, s *ContentService, id uuid.UUID) error {
+				_, err := s.ReleaseQuarantined(ctx, id, "alice")
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := &replicaRepository{MemoryRepository: memory.NewMemoryRepository(), stale: make(map[uuid.UUID]*model.Content)}
+			s := newTestInstance(repo.MemoryRepository)
+			s.repo = repo
+
+			content := &model.Content{FileName: "report.pdf", Status: model.StatusUploaded, Metadata: model.Metadata{MetadataVirusScan: "infected"}}
+			if err := repo.CreateContent(ctx, content); err != nil {
+				t.Fatal(err)
+			}
+			stale := *content
+			stale.Metadata = model.Metadata{MetadataVirusScan: "infected"}
+			repo.stale[content.ID] = &stale
+
+			// Written after the replica's copy
+			content.Metadata["owner"] = "bob"
+			if err := repo.UpdateContent(ctx, content); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tt.update(ctx, s, content.ID); err != nil {
+				t.Fatalf("update error = %v", err)
+			}
+			got, err := repo.MemoryRepository.GetContentByID(ctx, content.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Metadata["owner"] != "bob" {
+				t.Errorf("metadata = %v, the update was based on the stale copy", got.Metadata)
+			}
+		})
+	}
+}
+
+func TestUpdateContentDescription(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepository()
+	s := newTestInstance(repo)
+	content := &model.Content{FileName: "report.pdf", Description: "Q3 *draft*", Status: model.StatusUploaded}
+	if err := repo.CreateContent(ctx, content); err != nil {
+		t.Fatal(err)
+	}
+
+	empty, long := "", string(make([]rune, 20001))
+	tests := []struct {
+		name        string
+		description *string
+		want        string
+		wantErr     error
+	}{
+		{name: "left out keeps it", want: "Q3 *draft*"},
+		{name: "too long", description: &long, wantErr: ErrInvalidInput},
+		{name: "empty clears it", description: &empty, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, err := s.UpdateContent(ctx, UpdateContentInput{ID: content.ID, FileName: "report.pdf", Description: tt.description})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("UpdateContent() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if updated.Description != tt.want {
+				t.Errorf("description = %q, want %q", updated.Description, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+)
+
+var ErrConversionDisabled = errors.New("document conversion is not configured")
+
+// convertibleMIMETypes lists the office formats converted to PDF
+var convertibleMIMETypes = map[string]string{
+	"application/msword": ".doc",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": ".docx",
+	"application/vnd.ms-excel": ".xls",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         ".xlsx",
+	"application/vnd.ms-powerpoint":                                             ".ppt",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": ".pptx",
+	"application/vnd.oasis.opendocument.text":                                   ".odt",
+	"application/vnd.oasis.opendocument.spreadsheet":                            ".ods",
+	"application/rtf": ".rtf",
+}
+
+// DocumentConverter renders office documents as PDF
+type DocumentConverter interface {
+	// ConvertToPDF converts the document at src, whose name carries the
+	// format's extension, and writes the PDF to dst
+	ConvertToPDF(ctx context.Context, src string, dst string) error
+}
+
+// LibreOfficeConverter converts by running LibreOffice headless
+type LibreOfficeConverter struct {
+	Path string // soffice executable, looked up in PATH if empty
+}
+
+// NewLibreOfficeConverter creates a converter running the soffice at path
+func NewLibreOfficeConverter(path string) *LibreOfficeConverter {
+	return &LibreOfficeConverter{Path: path}
+}
+
+// ConvertToPDF runs soffice --convert-to pdf
+func (c *LibreOfficeConverter) ConvertToPDF(ctx context.Context, src string, dst string) error {
+	path := c.Path
+	if path == "" {
+		path = "soffice"
+	}
+
+	outDir, err := os.MkdirTemp(filepath.Dir(dst), "soffice-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(outDir)
+
+	// A private profile lets conversions run concurrently
+	profile := "-env:UserInstallation=file://" + filepath.ToSlash(filepath.Join(outDir, "profile"))
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, profile, "--headless", "--convert-to", "pdf", "--outdir", outDir, src)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("soffice: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	out := filepath.Join(outDir, strings.TrimSuffix(filepath.Base(src), filepath.Ext(src))+".pdf")
+	if _, err := os.Stat(out); err != nil {
+		return fmt.Errorf("soffice produced no PDF: %s", strings.TrimSpace(stderr.String()))
+	}
+	return os.Rename(out, dst)
+}
+
+// GotenbergConverter converts through a Gotenberg service
+type GotenbergConverter struct {
+	URL    string // Base URL of the Gotenberg service
+	Client *http.Client
+}
+
+// NewGotenbergConverter creates a converter using the Gotenberg service at baseURL
+func NewGotenbergConverter(baseURL string) *GotenbergConverter {
+	return &GotenbergConverter{
+		URL:    strings.TrimSuffix(baseURL, "/"),
+		Client: http.DefaultClient,
+	}
+}
+
+// ConvertToPDF posts the document to Gotenberg's LibreOffice route
+func (c *GotenbergConverter) ConvertToPDF(ctx context.Context, src string, dst string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Stream the multipart body instead of buffering the document
+	pr, pw := io.Pipe()
+	form := multipart.NewWriter(pw)
+	go func() {
+		part, err := form.CreateFormFile("files", filepath.Base(src))
+		if err == nil {
+			_, err = io.Copy(part, f)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL+"/forms/libreoffice/convert", pr)
+	if err != nil {
+		pr.Close()
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	return c.post(req, dst)
+}
+
+// post sends a Gotenberg request and writes the returned PDF to dst
+func (c *GotenbergConverter) post(req *http.Request, dst string) error {
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("gotenberg returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// ConfigureConverter enables conversion of office documents to PDF
+func (s *ContentService) ConfigureConverter(converter DocumentConverter) {
+	s.converter = converter
+}
+
+// ConvertContent starts a job producing a PDF rendition of an office
+// document. The PDF is stored as content derived from it.
+func (s *ContentService) ConvertContent(ctx context.Context, id uuid.UUID) (*model.Job, error) {
+	if s.converter == nil {
+		return nil, ErrConversionDisabled
+	}
+
+	content, err := s.GetContent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ext, ok := convertibleMIMETypes[content.MIMEType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, content.MIMEType)
+	}
+
+	converter := s.converter
+	derive := func(ctx context.Context, content *model.Content, src string, outDir string) ([]DerivedOutput, error) {
+		// Converters detect the format from the file extension
+		input := filepath.Join(filepath.Dir(src), "document"+ext)
+		if err := os.Rename(src, input); err != nil {
+			return nil, err
+		}
+
+		name := strings.TrimSuffix(content.FileName, filepath.Ext(content.FileName)) + ".pdf"
+		out := filepath.Join(outDir, "document.pdf")
+		if err := converter.ConvertToPDF(ctx, input, out); err != nil {
+			return nil, err
+		}
+		return []DerivedOutput{{Path: out, FileName: name, MIMEType: "application/pdf", Primary: true}}, nil
+	}
+
+	return s.startDerivativeJob(ctx, content, "convert/pdf", derive)
+}
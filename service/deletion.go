@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+var ErrContentReferenced = errors.New("content is still associated with an entity")
+
+// DeletionPolicy decides what deleting content linked to several entities does
+type DeletionPolicy string
+
+const (
+	DeleteForce         DeletionPolicy = "force"          // Delete the content whatever links it
+	DeleteUnlink        DeletionPolicy = "unlink"         // Only remove the link to the given entity
+	DeleteLastReference DeletionPolicy = "last_reference" // Remove the link, delete the content once nothing links it
+)
+
+// IsValid reports whether p is a known deletion policy
+func (p DeletionPolicy) IsValid() bool {
+	switch p {
+	case DeleteForce, DeleteUnlink, DeleteLastReference:
+		return true
+	}
+	return false
+}
+
+// ConfigureDeletionPolicy sets the policy used by deletes that don't choose one
+func (s *ContentService) ConfigureDeletionPolicy(policy DeletionPolicy) {
+	s.deletionPolicy = policy
+}
+
+// DeleteContentOptions controls a single content deletion
+type DeleteContentOptions struct {
+	Policy     DeletionPolicy // The configured policy if empty
+	EntityType string         // Entity being detached, required for unlink
+	EntityID   string
+}
+
+// DeleteContentResult reports what a deletion did
+type DeleteContentResult struct {
+	Deleted  bool        `json:"deleted"`
+	Unlinked []uuid.UUID `json:"unlinked"` // Associations removed
+}
+
+// DeleteContent deletes a content item, or detaches it from an entity,
+// according to the deletion policy
+func (s *ContentService) DeleteContent(ctx context.Context, id uuid.UUID, options DeleteContentOptions) (*DeleteContentResult, error) {
+	policy := options.Policy
+	if policy == "" {
+		policy = s.deletionPolicy
+	}
+	if !policy.IsValid() {
+		return nil, fmt.Errorf("%w: unknown deletion policy %q", ErrInvalidInput, policy)
+	}
+	byEntity := options.EntityType != "" && options.EntityID != ""
+	if policy == DeleteUnlink && !byEntity {
+		return nil, fmt.Errorf("%w: unlinking requires an entity", ErrInvalidInput)
+	}
+
+	content, err := s.repo.GetContentByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrContentNotFound) {
+			return nil, ErrContentNotFound
+		}
+		return nil, err
+	}
+
+	// Unlinking alone leaves the content in place
+	if policy != DeleteUnlink {
+		if err := s.checkRetention(content, s.contentAssociations(ctx, content.ID)); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &DeleteContentResult{Unlinked: []uuid.UUID{}}
+	if policy == DeleteForce {
+		if err := s.forceDeleteContent(ctx, content); err != nil {
+			return nil, err
+		}
+		result.Deleted = true
+		return result, nil
+	}
+
+	if byEntity {
+		association, err := s.repo.GetAssociationByLink(ctx, id, options.EntityType, options.EntityID)
+		if err == nil {
+			err = s.repo.DeleteAssociation(ctx, association.ID)
+		}
+		switch {
+		case err == nil:
+			result.Unlinked = append(result.Unlinked, association.ID)
+			s.publishEntityEvent(EventContentRemoved, association, content)
+		case errors.Is(err, repository.ErrAssociationNotFound):
+			return nil, ErrAssociationNotFound
+		default:
+			return nil, err
+		}
+	}
+	if policy == DeleteUnlink {
+		return result, nil
+	}
+
+	// The repository checks for other links atomically with the delete
+	err = s.repo.DeleteContentIfUnreferenced(ctx, id)
+	switch {
+	case err == nil:
+		result.Deleted = true
+		s.deleteContentData(ctx, content)
+	case errors.Is(err, repository.ErrContentReferenced):
+		// Detaching from one entity while others still use the content is not an error
+		if !byEntity {
+			return nil, ErrContentReferenced
+		}
+	case errors.Is(err, repository.ErrContentNotFound):
+		return nil, ErrContentNotFound
+	default:
+		return nil, err
+	}
+	return result, nil
+}
+
+// forceDeleteContent deletes a content item regardless of its associations
+func (s *ContentService) forceDeleteContent(ctx context.Context, content *model.Content) error {
+	// Collect the linked entities before the content disappears
+	associations := s.contentAssociations(ctx, content.ID)
+
+	// Delete from repository first
+	if err := s.repo.DeleteContent(ctx, content.ID); err != nil {
+		if errors.Is(err, repository.ErrContentNotFound) {
+			return ErrContentNotFound
+		}
+		return err
+	}
+
+	for _, association := range associations {
+		s.publishEntityEvent(EventContentRemoved, association, content)
+	}
+
+	s.deleteContentData(ctx, content)
+	return nil
+}
+
+// deleteContentData removes the stored data of deleted content.
+// Note: We don't return storage deletion errors to the caller
+// as the content is already marked as deleted in the repository
+func (s *ContentService) deleteContentData(ctx context.Context, content *model.Content) {
+	_ = s.deleteContentObjects(ctx, content)
+	s.releaseQuota(ctx, content.TenantID, content.FileSize)
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported format")
+)
+
+const (
+	// derivativeJobTimeout bounds a single transcode or conversion
+	derivativeJobTimeout = time.Hour
+	// derivativeWorkers is the number of jobs processed concurrently
+	derivativeWorkers = 2
+	// hlsPlaylistMIMEType identifies playlists whose segment references are rewritten
+	hlsPlaylistMIMEType = "application/vnd.apple.mpegurl"
+)
+
+// DerivativeResult is the result of a transcode or conversion job, whose
+// kind is the derivation, e.g. "transcode/mp4"
+type DerivativeResult struct {
+	PrimaryContentID *uuid.UUID  `json:"primary_content_id,omitempty"` // The derivative to play or render
+	OutputContentIDs []uuid.UUID `json:"output_content_ids,omitempty"`
+}
+
+// DerivedOutput is a file produced by a processor
+type DerivedOutput struct {
+	Path     string // Local path of the produced file
+	FileName string
+	MIMEType string
+	Primary  bool // The entry point, e.g. the MP4 file or the HLS master playlist
+}
+
+// deriveFunc produces derived files in outDir from the local copy of a content item
+type deriveFunc func(ctx context.Context, content *model.Content, src string, outDir string) ([]DerivedOutput, error)
+
+// ListDerivatives retrieves the content derived from a content item
+func (s *ContentService) ListDerivatives(ctx context.Context, id uuid.UUID) ([]*model.Content, error) {
+	filter := model.ContentFilter{DerivedFromID: &id}
+	items, _, err := s.repo.ListContent(ctx, filter, 0, exportPageSize)
+	return items, err
+}
+
+// startDerivativeJob queues derive to run on a content item in the
+// background, with at most derivativeWorkers jobs processed at once
+func (s *ContentService) startDerivativeJob(ctx context.Context, content *model.Content, derivation string, derive deriveFunc) (*model.Job, error) {
+	if content.IsReference() {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupportedFormat, ErrReferenceContent)
+	}
+	contentID := content.ID
+	job := &model.Job{Kind: derivation, TenantID: content.TenantID, ContentID: &contentID}
+	return s.startJob(ctx, job, nil, s.derivativeWorkers, func(ctx context.Context, progress *JobProgress) (interface{}, error) {
+		ctx, cancel := context.WithTimeout(ctx, derivativeJobTimeout)
+		defer cancel()
+
+		outputs, primary, err := s.derive(ctx, content, derivation, derive)
+		return DerivativeResult{PrimaryContentID: primary, OutputContentIDs: outputs}, err
+	})
+}
+
+// derive downloads content to a scratch directory, runs the processor and
+// stores every output as content derived from it
+func (s *ContentService) derive(ctx context.Context, content *model.Content, derivation string, derive deriveFunc) ([]uuid.UUID, *uuid.UUID, error) {
+	workDir, err := os.MkdirTemp("", "derive-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	src := filepath.Join(workDir, "source"+filepath.Ext(content.FileName))
+	if err := s.downloadToFile(ctx, content, src); err != nil {
+		return nil, nil, err
+	}
+
+	outDir := filepath.Join(workDir, "out")
+	if err := os.Mkdir(outDir, 0o700); err != nil {
+		return nil, nil, err
+	}
+
+	outputs, err := derive(ctx, content, src, outDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Playlists reference other outputs, so they are stored last with
+	// references rewritten to the stored items; the primary output goes last
+	ordered := make([]DerivedOutput, 0, len(outputs))
+	for _, pass := range []func(DerivedOutput) bool{
+		func(o DerivedOutput) bool { return o.MIMEType != hlsPlaylistMIMEType && !o.Primary },
+		func(o DerivedOutput) bool { return o.MIMEType == hlsPlaylistMIMEType && !o.Primary },
+		func(o DerivedOutput) bool { return o.Primary },
+	} {
+		for _, output := range outputs {
+			if pass(output) {
+				ordered = append(ordered, output)
+			}
+		}
+	}
+
+	var ids []uuid.UUID
+	var primary *uuid.UUID
+	stored := make(map[string]uuid.UUID)
+	for _, output := range ordered {
+		derived, err := s.storeDerivedOutput(ctx, content, derivation, output, stored)
+		if err != nil {
+			return ids, nil, err
+		}
+		ids = append(ids, derived.ID)
+		stored[output.FileName] = derived.ID
+		if output.Primary {
+			primary = &derived.ID
+		}
+	}
+
+	return ids, primary, nil
+}
+
+// downloadToFile copies the data of content to a local file
+func (s *ContentService) downloadToFile(ctx context.Context, content *model.Content, dst string) error {
+	// Infected data is never handed to external processors
+	if err := CheckScanned(content); err != nil {
+		return err
+	}
+	data, err := s.storage.Download(storageContext(ctx, content), content.StoragePath)
+	if err != nil {
+		return err
+	}
+	defer data.Close()
+
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// storeDerivedOutput creates a content item from a produced file
+func (s *ContentService) storeDerivedOutput(ctx context.Context, parent *model.Content, derivation string, output DerivedOutput, stored map[string]uuid.UUID) (*model.Content, error) {
+	f, err := os.Open(output.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	var data io.Reader = f
+	size := info.Size()
+	if output.MIMEType == hlsPlaylistMIMEType {
+		playlist, err := io.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		playlist = rewritePlaylist(playlist, stored)
+		data, size = bytes.NewReader(playlist), int64(len(playlist))
+	}
+
+	parentID := parent.ID
+	content, err := s.CreateContent(ctx, CreateContentInput{
+		TenantID:      parent.TenantID,
+		FileName:      output.FileName,
+		MIMEType:      output.MIMEType,
+		FileSize:      size,
+		Data:          data,
+		Source:        "derivative",
+		Metadata:      make(model.Metadata),
+		DerivedFromID: &parentID,
+		Derivation:    derivation,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storing %s: %w", output.FileName, err)
+	}
+
+	// Derived content is complete as soon as it is stored
+	return s.UpdateContentStatus(ctx, content.ID, model.StatusDone)
+}
+
+// rewritePlaylist points the URIs of an HLS playlist at the stored outputs.
+// The playlist itself is served from /contents/{id}/data, so "../{id}/data"
+// resolves to the data endpoint of the referenced item.
+func rewritePlaylist(playlist []byte, stored map[string]uuid.UUID) []byte {
+	lines := strings.Split(string(playlist), "\n")
+	for i, line := range lines {
+		uri := strings.TrimSpace(line)
+		if uri == "" || strings.HasPrefix(uri, "#") {
+			continue
+		}
+		if id, ok := stored[uri]; ok {
+			lines[i] = "../" + id.String() + "/data"
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
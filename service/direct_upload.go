@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+	"github.com/livefire2015/simple-contents/storage"
+)
+
+// UploadMethod is how a client sends the data of a direct upload to storage
+type UploadMethod string
+
+const (
+	UploadPut  UploadMethod = "put"  // A presigned PUT of the exact size
+	UploadPost UploadMethod = "post" // A multipart form POST policy, for browsers
+)
+
+const (
+	// MetadataUploadSHA256 holds the hex SHA-256 a direct upload was declared with
+	MetadataUploadSHA256 = "upload_sha256"
+
+	// directUploadExpiry is how long a client has to start a direct upload
+	directUploadExpiry = 15 * time.Minute
+)
+
+var (
+	ErrDirectUploadUnsupported = errors.New("direct uploads are not supported by the storage backend")
+	ErrChecksumMismatch        = errors.New("uploaded data does not match its checksum")
+)
+
+// DirectUploadInput represents a content item whose data the client uploads
+// straight to storage instead of through the service
+type DirectUploadInput struct {
+	TenantID    string         `validate:"max=63"`
+	FileName    string         `validate:"required,max=255"`
+	Description string         `validate:"max=20000"` // Markdown
+	MIMEType    string         `validate:"required,max=255"`
+	FileSize    int64          `validate:"min=1"`                    // Exact size of a PUT, largest size accepted by a POST
+	Method      UploadMethod   `validate:"omitempty,oneof=put post"` // put (the default) or post
+	CreatedBy   string         `validate:"max=255"`                  // Ignored when the context carries a principal
+	EntityType  string         `validate:"max=100"`
+	EntityID    string         `validate:"max=255"`
+	Source      string         `validate:"max=100"`
+	ExternalID  string         `validate:"max=255"` // Unique per tenant and source
+	Metadata    model.Metadata `validate:"max=1000"`
+	CallbackURL string         `validate:"omitempty,url,max=2048"`
+	SHA256      string         // Hex or base64 SHA-256 of the data, enforced on upload if set
+	DraftTTL    time.Duration  // Creates a draft purged unless finalized within the TTL if set
+}
+
+// CreateDirectUpload creates a content item in created status and presigns
+// the upload of its data. The client confirms the upload with
+// MarkContentAsUploaded, which records the size and type storage reports.
+// Classification, PII scanning and image sanitization don't apply, as the
+// data doesn't pass through the service.
+func (s *ContentService) CreateDirectUpload(ctx context.Context, input DirectUploadInput) (*model.Content, *storage.PresignedUpload, error) {
+	if err := validateInput(input); err != nil {
+		return nil, nil, err
+	}
+	fileName, err := SanitizeFileName(input.FileName)
+	if err != nil {
+		return nil, nil, err
+	}
+	input.FileName = fileName
+	input.CreatedBy = principalOr(ctx, input.CreatedBy)
+	if input.Method == "" {
+		input.Method = UploadPut
+	}
+	if err := validateCallbackURL(input.CallbackURL); err != nil {
+		return nil, nil, err
+	}
+	draftExpiresAt, err := s.draftExpiry(input.DraftTTL)
+	if err != nil {
+		return nil, nil, err
+	}
+	var checksum []byte
+	if input.SHA256 != "" {
+		if checksum, err = parseSHA256(input.SHA256); err != nil {
+			return nil, nil, err
+		}
+	}
+	// The client would upload to a backend that is down
+	if err := s.checkStorageAvailable(); err != nil {
+		return nil, nil, err
+	}
+	if input.TenantID != "" && s.tenants != nil {
+		if _, err := s.tenants.GetTenant(ctx, input.TenantID); err != nil {
+			return nil, nil, err
+		}
+	}
+	var entityTypes []string
+	if input.EntityType != "" && input.EntityID != "" {
+		if err := s.checkAssociation(ctx, input.EntityType, input.EntityID, "", input.MIMEType); err != nil {
+			return nil, nil, err
+		}
+		entityTypes = append(entityTypes, input.EntityType)
+	}
+	if err := s.validateMetadata(input.Metadata, input.Source, entityTypes...); err != nil {
+		return nil, nil, err
+	}
+	if err := s.checkExternalID(ctx, input.TenantID, input.Source, input.ExternalID); err != nil {
+		return nil, nil, err
+	}
+
+	uploader, ok := s.storage.(storage.PresignedUploader)
+	if !ok {
+		return nil, nil, ErrDirectUploadUnsupported
+	}
+
+	contentID := s.ids.NewID()
+	storageKey, err := s.storageKey(contentID, input.TenantID, input.FileName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The declared size is reserved until the upload is confirmed
+	if err := s.reserveQuota(ctx, input.TenantID, input.FileSize); err != nil {
+		return nil, nil, err
+	}
+
+	upload, err := presignDirectUpload(storage.WithTenant(ctx, input.TenantID), uploader, storageKey, input.Method, input.MIMEType, input.FileSize, checksum)
+	if err != nil {
+		s.releaseQuota(ctx, input.TenantID, input.FileSize)
+		return nil, nil, err
+	}
+
+	if checksum != nil {
+		if input.Metadata == nil {
+			input.Metadata = make(model.Metadata)
+		}
+		input.Metadata[MetadataUploadSHA256] = hex.EncodeToString(checksum)
+	}
+
+	content := &model.Content{
+		ID:          contentID,
+		TenantID:    input.TenantID,
+		Status:      model.StatusCreated,
+		FileName:    input.FileName,
+		Description: input.Description,
+		MIMEType:    input.MIMEType,
+		FileSize:    input.FileSize,
+		StoragePath: storageKey,
+		CreatedBy:   input.CreatedBy,
+		Source:      input.Source,
+		ExternalID:  input.ExternalID,
+		Metadata:    input.Metadata,
+		CallbackURL: input.CallbackURL,
+
+		DraftExpiresAt: draftExpiresAt,
+	}
+
+	var association *model.ContentEntityAssociation
+	if input.EntityType != "" && input.EntityID != "" {
+		association = s.newAssociation(AssociateContentInput{
+			ContentID:    content.ID,
+			EntityType:   input.EntityType,
+			EntityID:     input.EntityID,
+			AssociatedBy: input.CreatedBy,
+		})
+	}
+
+	err = s.repo.WithTx(ctx, func(tx repository.ContentRepository) error {
+		if err := tx.CreateContent(ctx, content); err != nil {
+			return err
+		}
+		if association != nil {
+			return createAssociation(ctx, tx, association)
+		}
+		return nil
+	})
+	if err != nil {
+		s.releaseQuota(ctx, input.TenantID, input.FileSize)
+		return nil, nil, storeError(err, content)
+	}
+
+	if association != nil {
+		s.publishEntityEvent(EventContentAdded, association, content)
+	}
+
+	return content, upload, nil
+}
+
+// RenewDirectUpload presigns the upload of the data of a direct upload
+// again, e.g. after the first one expired or failed, with the size, type and
+// declared checksum it was created with. The data must not have been
+// confirmed yet.
+func (s *ContentService) RenewDirectUpload(ctx context.Context, id uuid.UUID, method UploadMethod) (*model.Content, *storage.PresignedUpload, error) {
+	if method == "" {
+		method = UploadPut
+	}
+	if method != UploadPut && method != UploadPost {
+		return nil, nil, fmt.Errorf("%w: unknown upload method %q", ErrInvalidInput, method)
+	}
+	content, err := s.getContentForUpdate(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if content.Status != model.StatusCreated || content.IsReference() {
+		return nil, nil, fmt.Errorf("%w: cannot renew the upload of content in status %s", ErrInvalidStatus, content.Status)
+	}
+	var checksum []byte
+	if declared, _ := content.Metadata[MetadataUploadSHA256].(string); declared != "" {
+		if checksum, err = parseSHA256(declared); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := s.checkStorageAvailable(); err != nil {
+		return nil, nil, err
+	}
+	uploader, ok := s.storage.(storage.PresignedUploader)
+	if !ok {
+		return nil, nil, ErrDirectUploadUnsupported
+	}
+
+	upload, err := presignDirectUpload(storageContext(ctx, content), uploader, content.StoragePath, method, content.MIMEType, content.FileSize, checksum)
+	if err != nil {
+		return nil, nil, err
+	}
+	return content, upload, nil
+}
+
+// presignDirectUpload presigns a request writing an object of at most size
+// bytes of a type, and of a SHA-256 if checksum is set
+func presignDirectUpload(ctx context.Context, uploader storage.PresignedUploader, key string, method UploadMethod, mimeType string, size int64, checksum []byte) (*storage.PresignedUpload, error) {
+	options := storage.PresignedUploadOptions{
+		Expiry:      directUploadExpiry,
+		ContentType: mimeType,
+		Size:        size,
+	}
+	if checksum != nil {
+		options.ChecksumSHA256 = base64.StdEncoding.EncodeToString(checksum)
+	}
+	var upload *storage.PresignedUpload
+	var err error
+	if method == UploadPost {
+		upload, err = uploader.PresignPost(ctx, key, options)
+	} else {
+		upload, err = uploader.PresignPut(ctx, key, options)
+	}
+	if err != nil {
+		if errors.Is(err, storage.ErrDirectUploadUnsupported) {
+			return nil, ErrDirectUploadUnsupported
+		}
+		return nil, fmt.Errorf("failed to presign upload: %w", err)
+	}
+	return upload, nil
+}
+
+// parseSHA256 decodes a SHA-256 given as hex or base64
+func parseSHA256(value string) ([]byte, error) {
+	checksum, err := hex.DecodeString(value)
+	if err != nil {
+		checksum, err = base64.StdEncoding.DecodeString(value)
+	}
+	if err != nil || len(checksum) != sha256.Size {
+		return nil, fmt.Errorf("%w: sha256 must be a hex or base64 SHA-256 digest", ErrInvalidInput)
+	}
+	return checksum, nil
+}
+
+// verifyUploadChecksum checks the data of a direct upload against the SHA-256
+// it was declared with. The checksum recorded by storage is used if there is
+// one; otherwise the data is read back and hashed.
+func (s *ContentService) verifyUploadChecksum(ctx context.Context, content *model.Content, info *storage.ObjectInfo) error {
+	expected, _ := content.Metadata[MetadataUploadSHA256].(string)
+	if expected == "" {
+		return nil
+	}
+
+	// Multipart uploads record a checksum of the part checksums, ending in -<parts>
+	actual := ""
+	if recorded, err := base64.StdEncoding.DecodeString(info.ChecksumSHA256); err == nil && len(recorded) == sha256.Size {
+		actual = hex.EncodeToString(recorded)
+	} else {
+		data, err := s.storage.Download(storageContext(ctx, content), content.StoragePath)
+		if err != nil {
+			return fmt.Errorf("failed to read uploaded data for checksum verification: %w", err)
+		}
+		defer data.Close()
+		hash := sha256.New()
+		if _, err := io.Copy(hash, data); err != nil {
+			return fmt.Errorf("failed to read uploaded data for checksum verification: %w", err)
+		}
+		actual = hex.EncodeToString(hash.Sum(nil))
+	}
+
+	if actual != expected {
+		return fmt.Errorf("%w: expected SHA-256 %s, got %s", ErrChecksumMismatch, expected, actual)
+	}
+	return nil
+}
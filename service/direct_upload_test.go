@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/livefire2015/simple-contents/repository/memory"
+	"github.com/livefire2015/simple-contents/storage"
+	"github.com/livefire2015/simple-contents/storage/cachestorage"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+	"github.com/livefire2015/simple-contents/storage/replicatedstorage"
+	"github.com/livefire2015/simple-contents/storage/retrystorage"
+	"github.com/livefire2015/simple-contents/storage/tenantstorage"
+)
+
+// presigningStorage is memory storage that presigns uploads, which the
+// test writes itself
+type presigningStorage struct {
+	*memorystorage.MemoryStorage
+}
+
+func (s *presigningStorage) PresignPut(ctx context.Context, key string, options storage.PresignedUploadOptions) (*storage.PresignedUpload, error) {
+	return &storage.PresignedUpload{Method: http.MethodPut, URL: "https://bucket.example.com/" + key}, nil
+}
+
+func (s *presigningStorage) PresignPost(ctx context.Context, key string, options storage.PresignedUploadOptions) (*storage.PresignedUpload, error) {
+	return &storage.PresignedUpload{Method: http.MethodPost, URL: "https://bucket.example.com/"}, nil
+}
+
+func TestDirectUploadThroughDecorators(t *testing.T) {
+	ctx := context.Background()
+	// The chain cmd/server builds around a backend presigning uploads
+	primary := &presigningStorage{MemoryStorage: memorystorage.NewMemoryStorage()}
+	secondary := memorystorage.NewMemoryStorage()
+	var backend storage.StorageService = retrystorage.NewRetryStorage(primary, retrystorage.DefaultConfig())
+	replicated := replicatedstorage.NewReplicatedStorage(backend, retrystorage.NewRetryStorage(secondary, retrystorage.DefaultConfig()), 16)
+	defer replicated.Close()
+	backend = cachestorage.NewCacheStorage(replicated, cachestorage.Config{MaxBytes: 1 << 20, MaxObjectSize: 1 << 10})
+	store := tenantstorage.NewTenantStorage(backend, tenantstorage.ResolverFunc(
+		func(ctx context.Context, tenantID string) (tenantstorage.Placement, error) {
+			return tenantstorage.Placement{}, nil
+		}))
+	s := NewContentService(memory.NewMemoryRepository(), store)
+
+	data := "invoice data"
+	sum := sha256.Sum256([]byte(data))
+	for _, method := range []UploadMethod{UploadPut, UploadPost} {
+		content, upload, err := s.CreateDirectUpload(ctx, DirectUploadInput{
+			FileName: "invoice.txt", MIMEType: "text/plain", FileSize: int64(len(data)), Method: method, SHA256: hex.EncodeToString(sum[:]),
+		})
+		if err != nil {
+			t.Fatalf("CreateDirectUpload(%s) error = %v", method, err)
+		}
+		if !strings.EqualFold(upload.Method, string(method)) {
+			t.Errorf("upload method = %s, want %s", upload.Method, method)
+		}
+		if _, _, err := s.RenewDirectUpload(ctx, content.ID, method); err != nil {
+			t.Fatalf("RenewDirectUpload(%s) error = %v", method, err)
+		}
+
+		// The client sends the data straight to the bucket
+		if _, err := primary.Upload(ctx, content.StoragePath, strings.NewReader(data), int64(len(data)), "text/plain"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.MarkContentAsUploaded(ctx, content.ID); err != nil {
+			t.Fatalf("MarkContentAsUploaded() error = %v", err)
+		}
+		if err := replicated.Repair(ctx); err != nil {
+			t.Fatalf("Repair() error = %v", err)
+		}
+		if _, err := secondary.Stat(ctx, content.StoragePath); err != nil {
+			t.Errorf("replica Stat() error = %v, want the direct upload mirrored", err)
+		}
+	}
+}
+
+func TestConfirmUploadChecksum(t *testing.T) {
+	data := "invoice data"
+	sum := sha256.Sum256([]byte(data))
+	valid := hex.EncodeToString(sum[:])
+	other := strings.Repeat("0", 64)
+
+	tests := []struct {
+		name     string
+		declared string // SHA-256 the upload was created with
+		computed string // SHA-256 sent with the confirmation
+		wantErr  error
+	}{
+		{name: "none"},
+		{name: "computed", computed: valid},
+		{name: "declared and computed", declared: valid, computed: valid},
+		{name: "computed differs from the data", computed: other, wantErr: ErrChecksumMismatch},
+		{name: "computed differs from the declared", declared: valid, computed: other, wantErr: ErrChecksumMismatch},
+		{name: "malformed", computed: "abc", wantErr: ErrInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := &presigningStorage{MemoryStorage: memorystorage.NewMemoryStorage()}
+			s := NewContentService(memory.NewMemoryRepository(), store)
+
+			content, _, err := s.CreateDirectUpload(ctx, DirectUploadInput{FileName: "invoice.txt", MIMEType: "text/plain", FileSize: int64(len(data)), SHA256: tt.declared})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The first upload expired before the client sent the data
+			if _, upload, err := s.RenewDirectUpload(ctx, content.ID, ""); err != nil || upload.Method != http.MethodPut {
+				t.Fatalf("RenewDirectUpload() = %v, %v, want a presigned PUT", upload, err)
+			}
+			if _, err := store.Upload(ctx, content.StoragePath, strings.NewReader(data), int64(len(data)), "text/plain"); err != nil {
+				t.Fatal(err)
+			}
+
+			confirmed, err := s.MarkContentAsUploadedWithChecksum(ctx, content.ID, tt.computed)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("MarkContentAsUploadedWithChecksum() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if checksum, _ := confirmed.Metadata[MetadataUploadSHA256].(string); tt.computed != "" && checksum != valid {
+				t.Errorf("recorded SHA-256 = %q, want %q", checksum, valid)
+			}
+			// Confirmed uploads can't be renewed
+			if _, _, err := s.RenewDirectUpload(ctx, content.ID, UploadPut); !errors.Is(err, ErrInvalidStatus) {
+				t.Errorf("RenewDirectUpload(uploaded) error = %v, want %v", err, ErrInvalidStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+var (
+	ErrDocumentNotFound = errors.New("document not found")
+	ErrVariantNotFound  = errors.New("document variant not found")
+)
+
+// languageTagPattern matches the BCP 47 tags variants are stored under: a
+// language, an optional script and an optional region or other subtags
+var languageTagPattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{1,8})*$`)
+
+// CanonicalLanguageTag validates a BCP 47 language tag and returns it in its
+// canonical case, e.g. "pt-BR" for "PT-br" and "zh-Hant" for "ZH-HANT"
+func CanonicalLanguageTag(tag string) (string, error) {
+	if !languageTagPattern.MatchString(tag) {
+		return "", fmt.Errorf("%w: invalid language tag %q", ErrInvalidInput, tag)
+	}
+	subtags := strings.Split(tag, "-")
+	subtags[0] = strings.ToLower(subtags[0])
+	for i := 1; i < len(subtags); i++ {
+		switch subtag := subtags[i]; {
+		case len(subtag) == 4 && i == 1:
+			subtags[i] = strings.ToUpper(subtag[:1]) + strings.ToLower(subtag[1:])
+		case len(subtag) == 2:
+			subtags[i] = strings.ToUpper(subtag)
+		default:
+			subtags[i] = strings.ToLower(subtag)
+		}
+	}
+	return strings.Join(subtags, "-"), nil
+}
+
+// DocumentService handles business logic for documents published in several
+// languages
+type DocumentService struct {
+	repo     repository.DocumentRepository
+	contents *ContentService
+}
+
+// NewDocumentService creates a new document service
+func NewDocumentService(repo repository.DocumentRepository, contents *ContentService) *DocumentService {
+	return &DocumentService{
+		repo:     repo,
+		contents: contents,
+	}
+}
+
+// DocumentInput represents the editable fields of a document
+type DocumentInput struct {
+	Name            string `json:"name" validate:"required,max=255"`
+	DefaultLanguage string `json:"default_language" validate:"required,max=35"`
+}
+
+// validate checks the input and canonicalizes its default language
+func (input *DocumentInput) validate() error {
+	if err := validateInput(input); err != nil {
+		return err
+	}
+	language, err := CanonicalLanguageTag(input.DefaultLanguage)
+	if err != nil {
+		return err
+	}
+	input.DefaultLanguage = language
+	return nil
+}
+
+// CreateDocument creates a document without variants in a tenant
+func (s *DocumentService) CreateDocument(ctx context.Context, tenantID string, input DocumentInput) (*model.Document, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	document := &model.Document{
+		TenantID:        tenantID,
+		Name:            input.Name,
+		DefaultLanguage: input.DefaultLanguage,
+	}
+	if err := s.repo.CreateDocument(ctx, document); err != nil {
+		return nil, fmt.Errorf("failed to create document: %w", err)
+	}
+
+	return document, nil
+}
+
+// GetDocument retrieves a document of a tenant. Documents of other tenants
+// are reported as not found.
+func (s *DocumentService) GetDocument(ctx context.Context, tenantID string, id uuid.UUID) (*model.Document, error) {
+	document, err := s.repo.GetDocument(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrDocumentNotFound) {
+			return nil, ErrDocumentNotFound
+		}
+		return nil, err
+	}
+	if document.TenantID != tenantID {
+		return nil, ErrDocumentNotFound
+	}
+	return document, nil
+}
+
+// ListDocuments retrieves a page of the documents of a tenant
+func (s *DocumentService) ListDocuments(ctx context.Context, tenantID string, options repository.ListOptions) ([]*model.Document, int64, error) {
+	return s.repo.ListDocuments(ctx, tenantID, options)
+}
+
+// UpdateDocument replaces the name and default language of a document
+func (s *DocumentService) UpdateDocument(ctx context.Context, tenantID string, id uuid.UUID, input DocumentInput) (*model.Document, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	document, err := s.GetDocument(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	document.Name = input.Name
+	document.DefaultLanguage = input.DefaultLanguage
+
+	if err := s.repo.UpdateDocument(ctx, document); err != nil {
+		if errors.Is(err, repository.ErrDocumentNotFound) {
+			return nil, ErrDocumentNotFound
+		}
+		return nil, fmt.Errorf("failed to update document: %w", err)
+	}
+
+	return s.GetDocument(ctx, tenantID, id)
+}
+
+// DeleteDocument removes a document and its variants. The content of the
+// variants is kept.
+func (s *DocumentService) DeleteDocument(ctx context.Context, tenantID string, id uuid.UUID) error {
+	if _, err := s.GetDocument(ctx, tenantID, id); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteDocument(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrDocumentNotFound) {
+			return ErrDocumentNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// SetVariant makes a content item the variant of a document in a language,
+// replacing the previous variant of that language
+func (s *DocumentService) SetVariant(ctx context.Context, tenantID string, id uuid.UUID, language string, contentID uuid.UUID) (*model.Document, error) {
+	language, err := CanonicalLanguageTag(language)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.GetDocument(ctx, tenantID, id); err != nil {
+		return nil, err
+	}
+	content, err := s.contents.GetContent(ctx, contentID)
+	if err != nil {
+		return nil, err
+	}
+	if content.TenantID != tenantID {
+		return nil, fmt.Errorf("%w: variant content must belong to the tenant of the document", ErrInvalidInput)
+	}
+
+	variant := model.DocumentVariant{Language: language, ContentID: contentID}
+	if err := s.repo.SetDocumentVariant(ctx, id, variant); err != nil {
+		if errors.Is(err, repository.ErrDocumentNotFound) {
+			return nil, ErrDocumentNotFound
+		}
+		return nil, fmt.Errorf("failed to set document variant: %w", err)
+	}
+
+	return s.GetDocument(ctx, tenantID, id)
+}
+
+// DeleteVariant removes the variant of a document in a language. Its content is kept.
+func (s *DocumentService) DeleteVariant(ctx context.Context, tenantID string, id uuid.UUID, language string) error {
+	language, err := CanonicalLanguageTag(language)
+	if err != nil {
+		return err
+	}
+	if _, err := s.GetDocument(ctx, tenantID, id); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteDocumentVariant(ctx, id, language); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrDocumentNotFound):
+			return ErrDocumentNotFound
+		case errors.Is(err, repository.ErrVariantNotFound):
+			return ErrVariantNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// GetDocumentData retrieves the data of the variant of a document that best
+// matches the preferred languages, most preferred first. It also returns the
+// language of the variant served.
+func (s *DocumentService) GetDocumentData(ctx context.Context, tenantID string, id uuid.UUID, preferred []string) (io.ReadCloser, *model.Content, string, error) {
+	document, err := s.GetDocument(ctx, tenantID, id)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	// Variants whose content has been deleted are passed over
+	for _, variant := range rankVariants(document, preferred) {
+		data, content, err := s.contents.GetContentData(ctx, variant.ContentID)
+		if errors.Is(err, ErrContentNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, "", err
+		}
+		return data, content, variant.Language, nil
+	}
+	return nil, nil, "", ErrVariantNotFound
+}
+
+// rankVariants orders the variants of a document from best to worst match
+// for the preferred languages. A preference matches a variant of the same
+// tag first, then a variant of a more general tag ("fr" for "fr-CA"), then a
+// more specific one ("pt-BR" for "pt"); "*" stands for the default language.
+// Unmatched variants follow, the default language first.
+func rankVariants(document *model.Document, preferred []string) []model.DocumentVariant {
+	ranked := make([]model.DocumentVariant, 0, len(document.Variants))
+	used := make(map[string]bool, len(document.Variants))
+	add := func(match func(language string) bool) {
+		for _, variant := range document.Variants {
+			if !used[variant.Language] && match(variant.Language) {
+				used[variant.Language] = true
+				ranked = append(ranked, variant)
+			}
+		}
+	}
+
+	for _, preference := range preferred {
+		if preference == "*" {
+			preference = document.DefaultLanguage
+		}
+		preference = strings.ToLower(preference)
+		add(func(language string) bool { return strings.ToLower(language) == preference })
+		for tag := preference; strings.Contains(tag, "-"); {
+			tag = tag[:strings.LastIndex(tag, "-")]
+			add(func(language string) bool { return strings.ToLower(language) == tag })
+		}
+		add(func(language string) bool { return strings.HasPrefix(strings.ToLower(language), preference+"-") })
+	}
+
+	add(func(language string) bool { return language == document.DefaultLanguage })
+	add(func(string) bool { return true })
+	return ranked
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+	"github.com/livefire2015/simple-contents/storage"
+)
+
+const (
+	// DefaultDownloadLinkExpiry is how long a download link works when no expiry is given
+	DefaultDownloadLinkExpiry = 7 * 24 * time.Hour
+	// MaxDownloadLinkExpiry bounds the lifetime of a download link
+	MaxDownloadLinkExpiry = 30 * 24 * time.Hour
+
+	// downloadLinkRedirectExpiry is the lifetime of the presigned URL a link
+	// redirects to; it only has to outlive the redirect
+	downloadLinkRedirectExpiry = 5 * time.Minute
+	// downloadLinkTokenBytes is the entropy of a token, 128 bits so links
+	// can't be guessed; 32 characters once encoded
+	downloadLinkTokenBytes = 16
+)
+
+var (
+	ErrDownloadLinkNotFound = errors.New("download link not found")
+	ErrDownloadLinkExpired  = errors.New("download link has expired")
+)
+
+// DownloadLinkService manages short download links that can be sent where
+// presigned URLs are too long, e.g. in emails
+type DownloadLinkService struct {
+	repo     repository.DownloadLinkRepository
+	contents *ContentService // Its clock dates and expires the records
+}
+
+// NewDownloadLinkService creates a new download link service
+func NewDownloadLinkService(repo repository.DownloadLinkRepository, contents *ContentService) *DownloadLinkService {
+	return &DownloadLinkService{
+		repo:     repo,
+		contents: contents,
+	}
+}
+
+// DownloadLinkInput represents the settings of a new download link
+type DownloadLinkInput struct {
+	ExpiresIn int                    `json:"expires_in"`                                     // Lifetime in seconds, DefaultDownloadLinkExpiry if 0
+	Mode      model.DownloadLinkMode `json:"mode" validate:"omitempty,oneof=redirect proxy"` // redirect (the default) or proxy
+}
+
+// CreateDownloadLink creates a short link to a content item
+func (s *DownloadLinkService) CreateDownloadLink(ctx context.Context, contentID uuid.UUID, createdBy string, input DownloadLinkInput) (*model.DownloadLink, error) {
+	if err := validateInput(input); err != nil {
+		return nil, err
+	}
+	if input.Mode == "" {
+		input.Mode = model.DownloadLinkRedirect
+	}
+	expiry := time.Duration(input.ExpiresIn) * time.Second
+	if input.ExpiresIn == 0 {
+		expiry = DefaultDownloadLinkExpiry
+	}
+	if expiry <= 0 || expiry > MaxDownloadLinkExpiry {
+		return nil, fmt.Errorf("%w: expires_in must be between 1 and %d seconds", ErrInvalidInput, int(MaxDownloadLinkExpiry.Seconds()))
+	}
+
+	content, err := s.contents.GetContent(ctx, contentID)
+	if err != nil {
+		return nil, err
+	}
+	// Whoever holds the link can download the content, so it can't be used to
+	// get around the restrictions on presigned URLs
+	if err := CheckScanned(content); err != nil {
+		return nil, err
+	}
+	if s.contents.piiRestricted(content, HasElevatedScope(ctx)) {
+		return nil, ErrPIIRestricted
+	}
+
+	link := &model.DownloadLink{
+		ContentID: content.ID,
+		Mode:      input.Mode,
+		CreatedBy: createdBy,
+		ExpiresAt: s.contents.now().Add(expiry),
+	}
+
+	// Retry the unlikely collision of random tokens
+	for attempt := 0; ; attempt++ {
+		if link.Token, err = newDownloadLinkToken(); err != nil {
+			return nil, err
+		}
+		err = s.repo.CreateDownloadLink(ctx, link)
+		if !errors.Is(err, repository.ErrDownloadLinkExists) || attempt == 2 {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create download link: %w", err)
+	}
+
+	return link, nil
+}
+
+// newDownloadLinkToken returns a random hex token
+func newDownloadLinkToken() (string, error) {
+	token := make([]byte, downloadLinkTokenBytes)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// ListDownloadLinks retrieves a page of the links of a content item with their click counts
+func (s *DownloadLinkService) ListDownloadLinks(ctx context.Context, contentID uuid.UUID, options repository.ListOptions) ([]*model.DownloadLink, int64, error) {
+	return s.repo.ListDownloadLinksByContent(ctx, contentID, options)
+}
+
+// DeleteDownloadLink revokes a link of a content item
+func (s *DownloadLinkService) DeleteDownloadLink(ctx context.Context, contentID uuid.UUID, token string) error {
+	link, err := s.repo.GetDownloadLink(ctx, token)
+	if err != nil {
+		if errors.Is(err, repository.ErrDownloadLinkNotFound) {
+			return ErrDownloadLinkNotFound
+		}
+		return err
+	}
+	if link.ContentID != contentID {
+		return ErrDownloadLinkNotFound
+	}
+
+	if err := s.repo.DeleteDownloadLink(ctx, token); err != nil {
+		if errors.Is(err, repository.ErrDownloadLinkNotFound) {
+			return ErrDownloadLinkNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// RedeemDownloadLink records a click on a link and returns the link and its content.
+// Expired links return ErrDownloadLinkExpired.
+func (s *DownloadLinkService) RedeemDownloadLink(ctx context.Context, token string) (*model.DownloadLink, *model.Content, error) {
+	link, err := s.repo.GetDownloadLink(ctx, token)
+	if err != nil {
+		if errors.Is(err, repository.ErrDownloadLinkNotFound) {
+			return nil, nil, ErrDownloadLinkNotFound
+		}
+		return nil, nil, err
+	}
+
+	now := s.contents.now()
+	if !now.Before(link.ExpiresAt) {
+		return nil, nil, ErrDownloadLinkExpired
+	}
+
+	content, err := s.contents.GetContent(ctx, link.ContentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Content can be quarantined after the link was created
+	if err := CheckScanned(content); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.repo.RecordDownloadLinkClick(ctx, token, now); err != nil {
+		if errors.Is(err, repository.ErrDownloadLinkNotFound) {
+			return nil, nil, ErrDownloadLinkNotFound
+		}
+		return nil, nil, err
+	}
+	link.Clicks++
+	link.LastClickedAt = &now
+
+	return link, content, nil
+}
+
+// DownloadLinkURL returns the short-lived presigned URL a redirect link sends its clicks to
+func (s *DownloadLinkService) DownloadLinkURL(ctx context.Context, content *model.Content) (string, error) {
+	return s.contents.storage.GetPresignedDownloadURL(storageContext(ctx, content), content.StoragePath, storage.PresignedURLOptions{Expiry: downloadLinkRedirectExpiry})
+}
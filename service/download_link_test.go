@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/livefire2015/simple-contents/clock"
+	"github.com/livefire2015/simple-contents/repository/memory"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+)
+
+func TestNewDownloadLinkToken(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		token, err := newDownloadLinkToken()
+		if err != nil {
+			t.Fatalf("newDownloadLinkToken() error = %v", err)
+		}
+		if len(token) < 32 {
+			t.Fatalf("token %q has %d characters, want at least 32", token, len(token))
+		}
+		if seen[token] {
+			t.Fatalf("token %q repeated", token)
+		}
+		seen[token] = true
+	}
+}
+
+func TestDownloadLinkExpiry(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepository()
+	contents := NewContentService(repo, memorystorage.NewMemoryStorage())
+	start := time.Date(2024, 7, 5, 12, 0, 0, 0, time.UTC)
+	now := clock.NewManual(start)
+	contents.ConfigureClock(now)
+	links := NewDownloadLinkService(repo, contents)
+
+	content, err := contents.CreateContent(ctx, CreateContentInput{FileName: "invoice.pdf", MIMEType: "application/pdf", FileSize: 1, Data: strings.NewReader("%")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	link, err := links.CreateDownloadLink(ctx, content.ID, "support", DownloadLinkInput{ExpiresIn: 600})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := start.Add(10 * time.Minute); !link.ExpiresAt.Equal(want) {
+		t.Fatalf("ExpiresAt = %v, want %v", link.ExpiresAt, want)
+	}
+
+	tests := []struct {
+		name    string
+		after   time.Duration // Time since the link was created
+		wantErr error
+	}{
+		{name: "before expiry", after: 10*time.Minute - time.Second},
+		{name: "at expiry", after: 10 * time.Minute, wantErr: ErrDownloadLinkExpired},
+		{name: "after expiry", after: time.Hour, wantErr: ErrDownloadLinkExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now.Set(start.Add(tt.after))
+			redeemed, _, err := links.RedeemDownloadLink(ctx, link.Token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RedeemDownloadLink() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && !redeemed.LastClickedAt.Equal(now.Now()) {
+				t.Errorf("LastClickedAt = %v, want %v", redeemed.LastClickedAt, now.Now())
+			}
+		})
+	}
+}
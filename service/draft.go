@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+const (
+	// minDraftTTL and maxDraftTTL bound how long a draft waits to be finalized
+	minDraftTTL = time.Minute
+	maxDraftTTL = 30 * 24 * time.Hour
+)
+
+// draftExpiry returns when a draft created now with a TTL expires, or nil
+// for a TTL of zero, which creates finalized content
+func (s *ContentService) draftExpiry(ttl time.Duration) (*time.Time, error) {
+	if ttl == 0 {
+		return nil, nil
+	}
+	if ttl < minDraftTTL || ttl > maxDraftTTL {
+		return nil, fmt.Errorf("%w: draft TTL must be between %s and %s", ErrInvalidInput, minDraftTTL, maxDraftTTL)
+	}
+	expiresAt := s.now().Add(ttl)
+	return &expiresAt, nil
+}
+
+// FinalizeContent turns a draft into regular content, which entity
+// listings include and which is no longer purged. The entities it is
+// linked to are told it was added, as they weren't while it was a draft.
+// Finalizing content that isn't a draft changes nothing.
+func (s *ContentService) FinalizeContent(ctx context.Context, id uuid.UUID) (*model.Content, error) {
+	content, err := s.getContentForUpdate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !content.IsDraft() {
+		return content, nil
+	}
+
+	content.DraftExpiresAt = nil
+	if err := s.repo.UpdateContent(ctx, content); err != nil {
+		if errors.Is(err, repository.ErrContentNotFound) {
+			return nil, ErrContentNotFound
+		}
+		return nil, err
+	}
+	s.contentChanged(ctx, EventContentAdded, content)
+	return content, nil
+}
+
+// PurgeExpiredDrafts deletes the drafts whose TTL ran out before they were
+// finalized, with their data, and returns their IDs
+func (s *ContentService) PurgeExpiredDrafts(ctx context.Context) ([]uuid.UUID, error) {
+	now := s.now()
+
+	// Collect first so that deleting does not disturb the listing being read
+	var ids []uuid.UUID
+	if err := s.repo.ListContentStream(ctx, model.ContentFilter{DraftsExpired: &now}, func(content *model.Content) error {
+		ids = append(ids, content.ID)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	purged := []uuid.UUID{}
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return purged, ctx.Err()
+		}
+		content, err := s.repo.GetContentByID(repository.ReadFromPrimary(ctx), id)
+		if errors.Is(err, repository.ErrContentNotFound) {
+			continue
+		} else if err != nil {
+			return purged, err
+		}
+		// Finalized since it was listed
+		if !content.IsDraft() {
+			continue
+		}
+		if err := s.forceDeleteContent(ctx, content); err != nil && !errors.Is(err, ErrContentNotFound) {
+			return purged, fmt.Errorf("failed to purge draft %s: %w", id, err)
+		}
+		purged = append(purged, id)
+	}
+	return purged, nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+	"github.com/livefire2015/simple-contents/repository/memory"
+)
+
+func TestDrafts(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepository()
+	s := newTestInstance(repo)
+	events, unsubscribe := s.SubscribeEntity("claim", "c1")
+	defer unsubscribe()
+
+	create := func(name string, ttl time.Duration) (*model.Content, error) {
+		return s.CreateContent(ctx, CreateContentInput{
+			FileName: name, MIMEType: "application/pdf", FileSize: 3, Data: strings.NewReader("pdf"),
+			EntityType: "claim", EntityID: "c1", DraftTTL: ttl,
+		})
+	}
+	for _, ttl := range []time.Duration{time.Second, 31 * 24 * time.Hour} {
+		if _, err := create("scan.pdf", ttl); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("CreateContent(DraftTTL: %s) error = %v, want %v", ttl, err, ErrInvalidInput)
+		}
+	}
+
+	if _, err := create("scan.pdf", 0); err != nil {
+		t.Fatal(err)
+	}
+	<-events // The finalized content was added
+	abandoned, err := create("abandoned.pdf", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, err := create("kept.pdf", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !kept.IsDraft() || kept.DraftExpiresAt.Before(time.Now().Add(59*time.Minute)) {
+		t.Fatalf("DraftExpiresAt = %v, want in an hour", kept.DraftExpiresAt)
+	}
+	select {
+	case event := <-events:
+		t.Fatalf("draft published %s", event.Type)
+	default:
+	}
+
+	listed := func(options repository.ListOptions) int {
+		t.Helper()
+		items, _, err := s.GetContentForEntity(ctx, "claim", "c1", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(items)
+	}
+	if n := listed(repository.ListOptions{}); n != 1 {
+		t.Errorf("entity lists %d items, want drafts left out", n)
+	}
+	if n := listed(repository.ListOptions{IncludeDrafts: true}); n != 3 {
+		t.Errorf("entity lists %d items with drafts, want 3", n)
+	}
+
+	finalized, err := s.FinalizeContent(ctx, kept.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if finalized.IsDraft() {
+		t.Error("finalized content is still a draft")
+	}
+	if event := <-events; event.Type != EventContentAdded || event.ContentID != kept.ID {
+		t.Errorf("finalizing published %s of %s, want %s of the draft", event.Type, event.ContentID, EventContentAdded)
+	}
+	if _, err := s.FinalizeContent(ctx, kept.ID); err != nil {
+		t.Errorf("finalizing again: %v", err)
+	}
+
+	// Only the abandoned draft expired
+	expired, err := repo.GetContentByID(ctx, abandoned.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().UTC().Add(-time.Minute)
+	expired.DraftExpiresAt = &past
+	if err := repo.UpdateContent(ctx, expired); err != nil {
+		t.Fatal(err)
+	}
+	purged, err := s.PurgeExpiredDrafts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(purged) != 1 || purged[0] != abandoned.ID {
+		t.Errorf("PurgeExpiredDrafts() = %v, want the abandoned draft", purged)
+	}
+	if _, err := s.GetContent(ctx, abandoned.ID); !errors.Is(err, ErrContentNotFound) {
+		t.Errorf("GetContent(purged) error = %v, want %v", err, ErrContentNotFound)
+	}
+	if n := listed(repository.ListOptions{IncludeDrafts: true}); n != 2 {
+		t.Errorf("entity lists %d items after purging, want 2", n)
+	}
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// EntityFiles lists the content of an entity as the files of a folder, for
+// the WebDAV and FUSE facades. It returns the content by file name, and the
+// names in listing order.
+func (s *ContentService) EntityFiles(ctx context.Context, entityType, entityID string) (map[string]*model.Content, []string, error) {
+	files := make(map[string]*model.Content)
+	var names []string
+	err := s.EachContentForEntity(ctx, entityType, entityID, repository.ListOptions{}, func(content *model.Content) error {
+		name := entityFileName(content, files)
+		files[name] = content
+		names = append(names, name)
+		return nil
+	})
+	return files, names, err
+}
+
+// entityFileName returns the name of a content item in a folder, suffixing
+// it with the content ID when another item already uses the name
+func entityFileName(content *model.Content, taken map[string]*model.Content) string {
+	name := strings.ReplaceAll(content.FileName, "/", "_")
+	if name == "" || strings.HasPrefix(name, ".") {
+		name = content.ID.String() + name
+	}
+	if _, ok := taken[name]; ok {
+		ext := path.Ext(name)
+		name = strings.TrimSuffix(name, ext) + " (" + content.ID.String()[:8] + ")" + ext
+	}
+	return name
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/livefire2015/simple-contents/model"
+)
+
+var ErrContentRetained = errors.New("content is still under retention")
+
+// EntityType defines an entity type content can be linked to and the rules
+// of its links
+type EntityType struct {
+	Name             string   `json:"name"`
+	AllowedMIMETypes []string `json:"allowed_mime_types"` // e.g. "application/pdf" or "image/*", any type if empty
+	RetentionDays    int      `json:"retention_days"`     // Linked content can't be deleted until this old, 0 = no retention
+	AttachmentLimits          // Override the configured limits, per limit
+}
+
+// allowsMIMEType reports whether content of a MIME type can be linked to entities of the type
+func (t EntityType) allowsMIMEType(mimeType string) bool {
+	if len(t.AllowedMIMETypes) == 0 {
+		return true
+	}
+	mediaType, _, _ := strings.Cut(mimeType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	for _, allowed := range t.AllowedMIMETypes {
+		if prefix, ok := strings.CutSuffix(allowed, "*"); ok && strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+		if mediaType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// EntityTypeRegistry holds the entity types content can be linked to
+type EntityTypeRegistry struct {
+	types map[string]EntityType
+}
+
+// NewEntityTypeRegistry creates a registry of the given entity types
+func NewEntityTypeRegistry(types ...EntityType) (*EntityTypeRegistry, error) {
+	registry := &EntityTypeRegistry{types: make(map[string]EntityType, len(types))}
+	for _, entityType := range types {
+		if entityType.Name == "" {
+			return nil, fmt.Errorf("%w: entity type has no name", ErrInvalidInput)
+		}
+		if _, exists := registry.types[entityType.Name]; exists {
+			return nil, fmt.Errorf("%w: entity type %q is defined twice", ErrInvalidInput, entityType.Name)
+		}
+		if entityType.RetentionDays < 0 {
+			return nil, fmt.Errorf("%w: entity type %q: retention_days must not be negative", ErrInvalidInput, entityType.Name)
+		}
+		if err := entityType.AttachmentLimits.validate(); err != nil {
+			return nil, fmt.Errorf("entity type %q: %w", entityType.Name, err)
+		}
+		for i, mimeType := range entityType.AllowedMIMETypes {
+			entityType.AllowedMIMETypes[i] = strings.ToLower(mimeType)
+		}
+		registry.types[entityType.Name] = entityType
+	}
+	return registry, nil
+}
+
+// LoadEntityTypes reads a JSON array of entity types into a registry
+func LoadEntityTypes(path string) (*EntityTypeRegistry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var types []EntityType
+	if err := json.Unmarshal(data, &types); err != nil {
+		return nil, fmt.Errorf("invalid entity type configuration: %w", err)
+	}
+	return NewEntityTypeRegistry(types...)
+}
+
+// Lookup returns the entity type of a name
+func (r *EntityTypeRegistry) Lookup(name string) (EntityType, bool) {
+	entityType, ok := r.types[name]
+	return entityType, ok
+}
+
+// Types returns the registered entity types sorted by name
+func (r *EntityTypeRegistry) Types() []EntityType {
+	types := make([]EntityType, 0, len(r.types))
+	for _, entityType := range r.types {
+		types = append(types, entityType)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	return types
+}
+
+// ConfigureEntityTypes restricts associations to the types of a registry.
+// Without one, any entity type is accepted.
+func (s *ContentService) ConfigureEntityTypes(registry *EntityTypeRegistry) {
+	s.entityTypes = registry
+}
+
+// EntityTypes returns the configured entity types, nil if any type is accepted
+func (s *ContentService) EntityTypes() []EntityType {
+	if s.entityTypes == nil {
+		return nil
+	}
+	return s.entityTypes.Types()
+}
+
+// checkAssociation validates linking content of a MIME type to an entity,
+// with the given association role, against the rules of its type and the
+// attachment limits
+func (s *ContentService) checkAssociation(ctx context.Context, entityType, entityID, role, mimeType string) error {
+	limits := s.attachmentLimits
+	if s.entityTypes != nil {
+		rules, ok := s.entityTypes.Lookup(entityType)
+		if !ok {
+			return fmt.Errorf("%w: unknown entity type %q", ErrInvalidInput, entityType)
+		}
+		if !rules.allowsMIMEType(mimeType) {
+			return fmt.Errorf("%w: %s content can't be linked to %s entities", ErrInvalidInput, mimeType, entityType)
+		}
+		limits = rules.AttachmentLimits.orDefaults(limits)
+	}
+	return s.checkAttachmentLimits(ctx, limits, entityType, entityID, role)
+}
+
+// retainedUntil returns when the retention of the entity types of the given
+// links of a content item ends, the zero time if it isn't retained
+func (s *ContentService) retainedUntil(content *model.Content, associations []*model.ContentEntityAssociation) time.Time {
+	if s.entityTypes == nil {
+		return time.Time{}
+	}
+
+	var until time.Time
+	for _, association := range associations {
+		rules, ok := s.entityTypes.Lookup(association.EntityType)
+		if !ok || rules.RetentionDays == 0 {
+			continue
+		}
+		if end := content.CreatedAt.AddDate(0, 0, rules.RetentionDays); end.After(until) {
+			until = end
+		}
+	}
+	return until
+}
+
+// checkRetention fails if a content item with the given links can't be deleted yet
+func (s *ContentService) checkRetention(content *model.Content, associations []*model.ContentEntityAssociation) error {
+	if until := s.retainedUntil(content, associations); s.clock.Now().Before(until) {
+		return fmt.Errorf("%w until %s", ErrContentRetained, until.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+)
+
+// EventType identifies what happened to a content item
+type EventType string
+
+const (
+	EventStatusChanged   EventType = "status_changed"
+	EventContentAdded    EventType = "content_added"    // Content was associated with an entity
+	EventContentUpdated  EventType = "content_updated"  // Content associated with an entity changed
+	EventContentRemoved  EventType = "content_removed"  // Content was unlinked from an entity or deleted
+	EventQuotaWarning    EventType = "quota_warning"    // A tenant's usage crossed a warning threshold
+	EventReviewRequested EventType = "review_requested" // An association awaits approval
+	EventReviewDecided   EventType = "review_decided"   // An association was approved or rejected
+	EventReordered       EventType = "reordered"        // The content of an entity was put in a new order
+)
+
+// Event describes a change to a content item
+type Event struct {
+	Type      EventType           `json:"type"`
+	ContentID uuid.UUID           `json:"content_id"`
+	Status    model.ContentStatus `json:"status,omitempty"`
+	Content   *model.Content      `json:"content,omitempty"`
+	Timestamp time.Time           `json:"timestamp"`
+
+	// Set for entity events
+	EntityType    string                   `json:"entity_type,omitempty"`
+	EntityID      string                   `json:"entity_id,omitempty"`
+	AssociationID *uuid.UUID               `json:"association_id,omitempty"`
+	Review        *model.AssociationReview `json:"review,omitempty"`
+	Order         []uuid.UUID              `json:"order,omitempty"` // Placed content of a reordered entity
+
+	// Set for tenant events
+	TenantID    string `json:"tenant_id,omitempty"`
+	UsedBytes   int64  `json:"used_bytes,omitempty"`
+	QuotaBytes  int64  `json:"quota_bytes,omitempty"`
+	WarnPercent int    `json:"warn_percent,omitempty"`
+}
+
+// eventBufferSize is the number of events buffered per subscriber.
+// Slow subscribers miss events rather than blocking publishers.
+const eventBufferSize = 16
+
+// EventBus fans out content events to in-process subscribers
+type EventBus struct {
+	mu          sync.RWMutex
+	nextID      int
+	subscribers map[int]*subscriber
+}
+
+type subscriber struct {
+	match  func(Event) bool
+	events chan Event
+}
+
+// NewEventBus creates a new event bus
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subscribers: make(map[int]*subscriber),
+	}
+}
+
+// Publish delivers an event to every matching subscriber without blocking
+func (b *EventBus) Publish(event Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, sub := range b.subscribers {
+		if sub.match != nil && !sub.match(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel of events accepted by match and a function
+// that ends the subscription. A nil match receives every event.
+func (b *EventBus) Subscribe(match func(Event) bool) (<-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	sub := &subscriber{match: match, events: make(chan Event, eventBufferSize)}
+	b.subscribers[id] = sub
+
+	var once sync.Once
+	return sub.events, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, id)
+			b.mu.Unlock()
+		})
+	}
+}
+
+// Events returns the bus on which the service publishes content events
+func (s *ContentService) Events() *EventBus {
+	return s.events
+}
+
+// SubscribeContent subscribes to the events of a single content item
+func (s *ContentService) SubscribeContent(id uuid.UUID) (<-chan Event, func()) {
+	return s.events.Subscribe(func(e Event) bool {
+		return e.ContentID == id
+	})
+}
+
+// SubscribeEntity subscribes to content changes of a single entity
+func (s *ContentService) SubscribeEntity(entityType, entityID string) (<-chan Event, func()) {
+	return s.events.Subscribe(func(e Event) bool {
+		return e.EntityType == entityType && e.EntityID == entityID
+	})
+}
+
+// SubscribeTenant subscribes to the quota events of a single tenant
+func (s *ContentService) SubscribeTenant(tenantID string) (<-chan Event, func()) {
+	return s.events.Subscribe(func(e Event) bool {
+		return e.Type == EventQuotaWarning && e.TenantID == tenantID
+	})
+}
+
+// publishEntityEvent publishes a content change for the entity of an association
+func (s *ContentService) publishEntityEvent(eventType EventType, association *model.ContentEntityAssociation, content *model.Content) {
+	// Entities hear of drafts once they are finalized
+	if content != nil && content.IsDraft() {
+		return
+	}
+	associationID := association.ID
+	event := Event{
+		Type:          eventType,
+		ContentID:     association.ContentID,
+		Timestamp:     s.now(),
+		EntityType:    association.EntityType,
+		EntityID:      association.EntityID,
+		AssociationID: &associationID,
+	}
+	if association.Review != nil {
+		review := *association.Review
+		event.Review = &review
+	}
+	if content != nil {
+		contentCopy := *content
+		event.Content = &contentCopy
+		event.Status = content.Status
+	}
+	s.events.Publish(event)
+}
+
+// contentChanged publishes an event to every entity the content is linked to
+func (s *ContentService) contentChanged(ctx context.Context, eventType EventType, content *model.Content) {
+	for _, association := range s.contentAssociations(ctx, content.ID) {
+		s.publishEntityEvent(eventType, association, content)
+	}
+}
+
+// statusChanged publishes a status transition and triggers its completion callback
+func (s *ContentService) statusChanged(ctx context.Context, content *model.Content) {
+	contentCopy := *content
+	s.events.Publish(Event{
+		Type:      EventStatusChanged,
+		ContentID: content.ID,
+		Status:    content.Status,
+		Content:   &contentCopy,
+		Timestamp: s.now(),
+	})
+	s.contentChanged(ctx, EventContentUpdated, content)
+	s.notifyCompletion(content)
+}
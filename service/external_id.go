@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// ErrExternalIDExists is returned when content is created with an external
+// ID the tenant already gave content of the same source
+var ErrExternalIDExists = errors.New("external ID already exists")
+
+// GetContentByExternalID retrieves the content a tenant created from a
+// source under an external ID, the key the source system knows it by
+func (s *ContentService) GetContentByExternalID(ctx context.Context, tenantID, source, externalID string) (*model.Content, error) {
+	if externalID == "" {
+		return nil, fmt.Errorf("%w: external ID is required", ErrInvalidInput)
+	}
+	content, err := s.repo.GetContentByExternalID(ctx, tenantID, source, externalID)
+	if err != nil {
+		if errors.Is(err, repository.ErrContentNotFound) {
+			return nil, ErrContentNotFound
+		}
+		return nil, err
+	}
+	return content, nil
+}
+
+// checkExternalID fails with ErrExternalIDExists if content already has an
+// external ID, so data isn't uploaded for content that can't be stored. The
+// repository enforces uniqueness when the content is stored.
+func (s *ContentService) checkExternalID(ctx context.Context, tenantID, source, externalID string) error {
+	if externalID == "" {
+		return nil
+	}
+	_, err := s.repo.GetContentByExternalID(repository.ReadFromPrimary(ctx), tenantID, source, externalID)
+	switch {
+	case err == nil:
+		return externalIDExists(source, externalID)
+	case errors.Is(err, repository.ErrContentNotFound):
+		return nil
+	default:
+		return err
+	}
+}
+
+// storeError returns the error of storing content, reporting a taken
+// external ID as ErrExternalIDExists
+func storeError(err error, content *model.Content) error {
+	if errors.Is(err, repository.ErrExternalIDExists) {
+		return externalIDExists(content.Source, content.ExternalID)
+	}
+	return err
+}
+
+// externalIDExists returns the ErrExternalIDExists of an external ID
+func externalIDExists(source, externalID string) error {
+	return fmt.Errorf("%w: %q from source %q", ErrExternalIDExists, externalID, source)
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/livefire2015/simple-contents/repository/memory"
+)
+
+func TestExternalID(t *testing.T) {
+	ctx := context.Background()
+	s := newTestInstance(memory.NewMemoryRepository())
+	create := func(tenantID, source, externalID string) error {
+		_, err := s.CreateContent(ctx, CreateContentInput{
+			TenantID:   tenantID,
+			FileName:   "invoice.pdf",
+			MIMEType:   "application/pdf",
+			FileSize:   7,
+			Data:       strings.NewReader("invoice"),
+			Source:     source,
+			ExternalID: externalID,
+		})
+		return err
+	}
+	if err := create("acme", "erp", "INV-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		tenantID   string
+		source     string
+		externalID string
+		wantErr    error
+	}{
+		{name: "taken", tenantID: "acme", source: "erp", externalID: "INV-1", wantErr: ErrExternalIDExists},
+		{name: "other source", tenantID: "acme", source: "crm", externalID: "INV-1"},
+		{name: "other tenant", tenantID: "globex", source: "erp", externalID: "INV-1"},
+		{name: "none is never taken", tenantID: "acme", source: "erp"},
+		{name: "none again", tenantID: "acme", source: "erp"},
+		{name: "too long", tenantID: "acme", source: "erp", externalID: strings.Repeat("x", 256), wantErr: ErrInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := create(tt.tenantID, tt.source, tt.externalID); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CreateContent() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	content, err := s.GetContentByExternalID(ctx, "acme", "erp", "INV-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.TenantID != "acme" || content.Source != "erp" || content.ExternalID != "INV-1" {
+		t.Errorf("GetContentByExternalID() = %s/%s/%s, want acme/erp/INV-1", content.TenantID, content.Source, content.ExternalID)
+	}
+	if _, err := s.GetContentByExternalID(ctx, "acme", "erp", "INV-2"); !errors.Is(err, ErrContentNotFound) {
+		t.Errorf("GetContentByExternalID() of an unknown ID error = %v, want %v", err, ErrContentNotFound)
+	}
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// SanitizeFileName returns the file name content is stored and served
+// under, from a name supplied by a client. Only the last element of a path
+// is kept, so the storage key built from it stays under the key prefix of
+// its content, whether the client separates directories with slashes or
+// backslashes. Control and formatting characters, which could split
+// headers or disguise an extension as right-to-left overrides do, are
+// removed, surrounding spaces trimmed and the name normalized to NFC, so
+// that names that look the same are the same.
+func SanitizeFileName(name string) (string, error) {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || unicode.Is(unicode.Cf, r) {
+			return -1
+		}
+		return r
+	}, norm.NFC.String(name))
+	cleaned = strings.ReplaceAll(cleaned, "\\", "/")
+	base := strings.TrimSpace(path.Base(strings.TrimSpace(cleaned)))
+	if base == "" || base == "." || base == ".." || base == "/" {
+		return "", fmt.Errorf("%w: invalid file name %q", ErrInvalidInput, name)
+	}
+	return base, nil
+}
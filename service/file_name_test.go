@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/livefire2015/simple-contents/repository/memory"
+)
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "report.pdf", want: "report.pdf"},
+		{name: "dir/report.pdf", want: "report.pdf"},
+		{name: "../x", want: "x"},
+		{name: "../../other-id/report.pdf", want: "report.pdf"},
+		{name: `..\..\report.pdf`, want: "report.pdf"},
+		{name: `C:\Users\alice\report.pdf`, want: "report.pdf"},
+		{name: "/etc/passwd", want: "passwd"},
+		{name: "..%2f..%2fetc%2fpasswd", want: "..%2f..%2fetc%2fpasswd"}, // Names aren't URL-decoded, so this stays one element
+		{name: "dir/", want: "dir"},
+		{name: "  report.pdf  ", want: "report.pdf"},
+		{name: "report\r\n.pdf", want: "report.pdf"},
+		{name: "report\x00.pdf", want: "report.pdf"},
+		{name: "invoice\u202Efdp.exe", want: "invoicefdp.exe"}, // Right-to-left override showing "exe.pdf"
+		{name: "cafe\u0301.txt", want: "caf\u00e9.txt"},        // Decomposed é is composed
+		{name: "../\x00/..", wantErr: true},
+		{name: "", wantErr: true},
+		{name: " ", wantErr: true},
+		{name: "\x07", wantErr: true},
+		{name: ".", wantErr: true},
+		{name: "..", wantErr: true},
+		{name: "../", wantErr: true},
+		{name: "/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := SanitizeFileName(tt.name)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("SanitizeFileName(%q) = %q, %v, want ErrInvalidInput", tt.name, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("SanitizeFileName(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestFileNamesStayUnderContentKey(t *testing.T) {
+	ctx := context.Background()
+	s := newTestInstance(memory.NewMemoryRepository())
+	s.ConfigureKeyStrategy(TenantPrefixedKeys{})
+
+	content, err := s.CreateContent(ctx, CreateContentInput{
+		TenantID: "../../other", FileName: `..\..\other\secret.pdf`, MIMEType: "application/pdf", FileSize: 3, Data: strings.NewReader("pdf"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "_..%2F..%2Fother/" + content.ID.String() + "/secret.pdf"; content.StoragePath != want {
+		t.Errorf("StoragePath = %q, want %q", content.StoragePath, want)
+	}
+	if content.FileName != "secret.pdf" {
+		t.Errorf("FileName = %q, want secret.pdf", content.FileName)
+	}
+
+	renamed, err := s.UpdateContent(ctx, UpdateContentInput{ID: content.ID, FileName: "../renamed\n.pdf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed.FileName != "renamed.pdf" {
+		t.Errorf("renamed FileName = %q, want renamed.pdf", renamed.FileName)
+	}
+	if _, err := s.UpdateContent(ctx, UpdateContentInput{ID: content.ID, FileName: "../.."}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("renaming to ../.. error = %v, want %v", err, ErrInvalidInput)
+	}
+}
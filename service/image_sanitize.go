@@ -0,0 +1,240 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/storage"
+)
+
+// ErrElevatedScopeRequired is returned when privileged data is requested without an elevated scope
+var ErrElevatedScopeRequired = errors.New("elevated scope required")
+
+// errMalformedImage is returned when an image cannot be parsed for sanitization
+var errMalformedImage = errors.New("malformed image")
+
+// ImageSanitizationConfig controls removal of EXIF, GPS and other embedded metadata from images
+type ImageSanitizationConfig struct {
+	Sources      []string // Sources whose images are sanitized, all sources if empty
+	KeepOriginal bool     // Keep the unsanitized upload, readable only with an elevated scope
+}
+
+// imageSanitizers strip embedded metadata from images of a MIME type
+var imageSanitizers = map[string]func(dst io.Writer, src io.Reader) error{
+	"image/jpeg": stripJPEGMetadata,
+	"image/png":  stripPNGMetadata,
+}
+
+// EnableImageSanitization strips embedded metadata from uploaded images before they are served
+func (s *ContentService) EnableImageSanitization(config ImageSanitizationConfig) {
+	s.imageSanitization = &config
+}
+
+// shouldSanitize reports whether the upload of content must be sanitized
+func (s *ContentService) shouldSanitize(content *model.Content) bool {
+	if s.imageSanitization == nil || imageSanitizers[content.MIMEType] == nil {
+		return false
+	}
+	sources := s.imageSanitization.Sources
+	return len(sources) == 0 || slices.Contains(sources, content.Source)
+}
+
+// sanitizeImage replaces the stored object of content with a copy stripped of
+// embedded metadata. Depending on the configuration the original is kept under
+// OriginalStoragePath or deleted; quota is only charged for the sanitized copy.
+func (s *ContentService) sanitizeImage(ctx context.Context, content *model.Content) error {
+	storageCtx := storageContext(ctx, content)
+	originalPath := content.StoragePath
+
+	original, err := s.storage.Download(storageCtx, originalPath)
+	if err != nil {
+		return err
+	}
+	defer original.Close()
+
+	sanitized, pw := io.Pipe()
+	defer sanitized.Close()
+	go func() {
+		pw.CloseWithError(imageSanitizers[content.MIMEType](pw, original))
+	}()
+
+	sanitizedKey := originalPath + ".sanitized"
+	sanitizedPath, err := s.storage.Upload(storageCtx, sanitizedKey, sanitized, storage.UnknownSize, content.MIMEType)
+	if err != nil {
+		return fmt.Errorf("failed to sanitize image: %w", err)
+	}
+
+	info, err := s.storage.Stat(storageCtx, sanitizedPath)
+	if err != nil {
+		_ = s.storage.Delete(storageCtx, sanitizedPath)
+		return err
+	}
+
+	content.StoragePath = sanitizedPath
+	content.FileSize = info.Size
+	content.ETag = info.ETag
+	if s.imageSanitization.KeepOriginal {
+		content.OriginalStoragePath = originalPath
+	} else {
+		_ = s.storage.Delete(storageCtx, originalPath)
+	}
+	return nil
+}
+
+// GetOriginalContentData retrieves the unsanitized upload of an image; this requires an elevated scope
+func (s *ContentService) GetOriginalContentData(ctx context.Context, id uuid.UUID) (io.ReadCloser, *model.Content, error) {
+	if !HasElevatedScope(ctx) {
+		return nil, nil, ErrElevatedScopeRequired
+	}
+
+	content, err := s.GetContent(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := CheckScanned(content); err != nil {
+		return nil, nil, err
+	}
+
+	originalPath := content.OriginalStoragePath
+	if originalPath == "" {
+		originalPath = content.StoragePath
+	}
+
+	data, err := s.storage.Download(storageContext(ctx, content), originalPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	size := content.FileSize
+	if originalPath != content.StoragePath {
+		size = storage.UnknownSize
+	}
+	return s.auditDownload(ctx, content, data, size), content, nil
+}
+
+// stripJPEGMetadata copies a JPEG without its EXIF/XMP (APP1), other application
+// segments and comments. JFIF (APP0), ICC profiles (APP2) and Adobe color
+// information (APP14) are kept because decoders need them.
+func stripJPEGMetadata(dst io.Writer, src io.Reader) error {
+	r := bufio.NewReader(src)
+
+	var soi [2]byte
+	if _, err := io.ReadFull(r, soi[:]); err != nil || soi != [2]byte{0xFF, 0xD8} {
+		return fmt.Errorf("%w: missing JPEG start of image", errMalformedImage)
+	}
+	if _, err := dst.Write(soi[:]); err != nil {
+		return err
+	}
+
+	for {
+		prefix, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if prefix != 0xFF {
+			return fmt.Errorf("%w: expected JPEG marker", errMalformedImage)
+		}
+
+		marker := byte(0xFF)
+		for marker == 0xFF { // Markers may be preceded by fill bytes
+			if marker, err = r.ReadByte(); err != nil {
+				return err
+			}
+		}
+
+		switch {
+		case marker == 0xDA || marker == 0xD9:
+			// Start of scan: the rest is entropy-coded image data
+			if _, err := dst.Write([]byte{0xFF, marker}); err != nil {
+				return err
+			}
+			_, err := io.Copy(dst, r)
+			return err
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			// Standalone markers have no length
+			if _, err := dst.Write([]byte{0xFF, marker}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var length [2]byte
+		if _, err := io.ReadFull(r, length[:]); err != nil {
+			return err
+		}
+		size := int64(binary.BigEndian.Uint16(length[:])) - 2
+		if size < 0 {
+			return fmt.Errorf("%w: invalid JPEG segment length", errMalformedImage)
+		}
+
+		// APP1 (EXIF, XMP), other APPn and COM segments carry metadata
+		strip := (marker >= 0xE0 && marker <= 0xEF && marker != 0xE0 && marker != 0xE2 && marker != 0xEE) || marker == 0xFE
+		if strip {
+			if _, err := r.Discard(int(size)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, err := dst.Write([]byte{0xFF, marker, length[0], length[1]}); err != nil {
+			return err
+		}
+		if _, err := io.CopyN(dst, r, size); err != nil {
+			return err
+		}
+	}
+}
+
+// pngSignature starts every PNG file
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}
+
+// pngMetadataChunks are the ancillary chunks removed from PNGs
+var pngMetadataChunks = []string{"eXIf", "tEXt", "zTXt", "iTXt", "tIME"}
+
+// stripPNGMetadata copies a PNG without its EXIF, text and timestamp chunks
+func stripPNGMetadata(dst io.Writer, src io.Reader) error {
+	r := bufio.NewReader(src)
+
+	signature := make([]byte, len(pngSignature))
+	if _, err := io.ReadFull(r, signature); err != nil || !bytes.Equal(signature, pngSignature) {
+		return fmt.Errorf("%w: missing PNG signature", errMalformedImage)
+	}
+	if _, err := dst.Write(signature); err != nil {
+		return err
+	}
+
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return err
+		}
+		length := int64(binary.BigEndian.Uint32(header[:4]))
+		chunkType := string(header[4:])
+
+		// Chunk data is followed by a 4 byte CRC
+		if slices.Contains(pngMetadataChunks, chunkType) {
+			if _, err := io.CopyN(io.Discard, r, length+4); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, err := dst.Write(header[:]); err != nil {
+			return err
+		}
+		if _, err := io.CopyN(dst, r, length+4); err != nil {
+			return err
+		}
+		if chunkType == "IEND" {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/clock"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobsDisabled is returned when there is no store to record jobs in
+	ErrJobsDisabled = errors.New("asynchronous jobs are not configured")
+	// ErrJobFinished is returned when cancelling a job that already stopped
+	ErrJobFinished = errors.New("job already finished")
+
+	// errJobCancelled is the cause of the context of a cancelled job
+	errJobCancelled = errors.New("job cancelled")
+)
+
+// JobStatus is the state of an asynchronous job
+type JobStatus = model.JobStatus
+
+const (
+	JobQueued    = model.JobQueued
+	JobRunning   = model.JobRunning
+	JobDone      = model.JobDone
+	JobFailed    = model.JobFailed
+	JobCancelled = model.JobCancelled
+)
+
+// Kinds of the jobs not named after a derivation
+const (
+	JobKindExport     = "export"
+	JobKindBulkDelete = "bulk_delete"
+	JobKindReindex    = "reindex"
+)
+
+const (
+	// jobRetention is how long finished jobs can still be polled
+	jobRetention = 7 * 24 * time.Hour
+	// jobStaleAfter is how long an unfinished job goes without a heartbeat
+	// before it is failed, its instance presumed gone
+	jobStaleAfter = 5 * time.Minute
+	// jobStaleReason is the error of the jobs failed that way
+	jobStaleReason = "the instance running the job stopped"
+)
+
+var (
+	// jobProgressInterval throttles the progress saved while a job runs
+	jobProgressInterval = time.Second
+	// jobHeartbeatInterval is how often a job is saved when its progress
+	// doesn't change, showing that its instance is alive
+	jobHeartbeatInterval = 30 * time.Second
+)
+
+// AdminOnlyJob reports whether a job may only be seen through the admin API
+func AdminOnlyJob(job *model.Job) bool {
+	return job.Kind == JobKindReindex
+}
+
+// ConfigureJobs records asynchronous jobs in a store, where any instance can
+// poll or cancel them
+func (s *ContentService) ConfigureJobs(store repository.JobRepository) {
+	s.jobStore = store
+}
+
+// jobFunc does the work of a job, reporting progress as it goes. Its result
+// is recorded even when it fails.
+type jobFunc func(ctx context.Context, progress *JobProgress) (interface{}, error)
+
+// jobRunner keeps the jobs running in this instance
+type jobRunner struct {
+	mu      sync.Mutex
+	cancels map[uuid.UUID]context.CancelCauseFunc
+	running sync.WaitGroup
+}
+
+func newJobRunner() *jobRunner {
+	return &jobRunner{cancels: make(map[uuid.UUID]context.CancelCauseFunc)}
+}
+
+// JobProgress records how far a running job is
+type JobProgress struct {
+	mu     sync.Mutex
+	store  repository.JobRepository
+	job    *model.Job
+	cancel context.CancelCauseFunc
+	saved  time.Time
+	clock  clock.Clock
+}
+
+// SetTotal sets the units of work of the job, e.g. the items to copy
+func (p *JobProgress) SetTotal(total int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.job.Total = total
+	p.update()
+}
+
+// Add records units of work as done
+func (p *JobProgress) Add(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.job.Done += n
+	p.update()
+}
+
+// update recomputes the percentage and saves it, at most once per
+// jobProgressInterval. Saving picks up cancellations requested on other
+// instances.
+func (p *JobProgress) update() {
+	if p.job.Total > 0 {
+		// 100 is kept for jobs that are done
+		p.job.Progress = min(int(p.job.Done*100/p.job.Total), 99)
+	}
+	if time.Since(p.saved) < jobProgressInterval {
+		return
+	}
+	p.saved = time.Now()
+	if err := p.store.UpdateJob(context.Background(), p.job); err != nil {
+		log.Printf("Failed to save the progress of job %s: %v", p.job.ID, err)
+		return
+	}
+	if p.job.CancelRequested {
+		p.cancel(errJobCancelled)
+	}
+}
+
+// heartbeat saves the job whatever its progress
+func (p *JobProgress) heartbeat() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.job.Status.Finished() {
+		return
+	}
+	p.saved = time.Time{}
+	p.update()
+}
+
+// finish records the outcome of the job
+func (p *JobProgress) finish(ctx context.Context, result interface{}, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	job := p.job
+	if result != nil {
+		data, marshalErr := json.Marshal(result)
+		if marshalErr != nil {
+			err = errors.Join(err, marshalErr)
+		}
+		job.Result = data
+	}
+	switch {
+	case errors.Is(context.Cause(ctx), errJobCancelled):
+		job.Status = JobCancelled
+	case err != nil:
+		job.Status = JobFailed
+		job.Error = err.Error()
+	default:
+		job.Status = JobDone
+		job.Progress = 100
+	}
+	now := p.clock.Now().UTC()
+	job.FinishedAt = &now
+
+	if err := p.store.UpdateJob(context.Background(), job); err != nil {
+		log.Printf("Failed to save the outcome of job %s: %v", job.ID, err)
+	}
+}
+
+// startJob records a job and runs it in the background. If workers is set,
+// the job stays queued until it gets one.
+func (s *ContentService) startJob(ctx context.Context, job *model.Job, input interface{}, workers chan struct{}, run jobFunc) (*model.Job, error) {
+	if s.jobStore == nil {
+		return nil, ErrJobsDisabled
+	}
+	if input != nil {
+		data, err := json.Marshal(input)
+		if err != nil {
+			return nil, err
+		}
+		job.Input = data
+	}
+	job.Status = JobQueued
+	if err := s.jobStore.CreateJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	// The job outlives the request that started it
+	jobCtx, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+	s.jobs.mu.Lock()
+	s.jobs.cancels[job.ID] = cancel
+	s.jobs.mu.Unlock()
+
+	progress := &JobProgress{store: s.jobStore, job: copyJob(job), cancel: cancel, clock: s.clock}
+	s.jobs.running.Add(1)
+	go func() {
+		defer func() {
+			s.jobs.mu.Lock()
+			delete(s.jobs.cancels, job.ID)
+			s.jobs.mu.Unlock()
+			cancel(nil)
+			s.jobs.running.Done()
+		}()
+		if _, _, err := s.SweepJobs(jobCtx); err != nil {
+			log.Printf("Failed to sweep jobs: %v", err)
+		}
+
+		// Queued or running, the job shows it is alive until it finishes
+		heartbeats := time.NewTicker(jobHeartbeatInterval)
+		defer heartbeats.Stop()
+		go func() {
+			for {
+				select {
+				case <-heartbeats.C:
+					progress.heartbeat()
+				case <-jobCtx.Done():
+					return
+				}
+			}
+		}()
+
+		if workers != nil {
+			select {
+			case workers <- struct{}{}:
+				defer func() { <-workers }()
+			case <-jobCtx.Done():
+				progress.finish(jobCtx, nil, jobCtx.Err())
+				return
+			}
+		}
+
+		progress.mu.Lock()
+		progress.job.Status = JobRunning
+		progress.saved = time.Time{}
+		progress.update()
+		progress.mu.Unlock()
+
+		result, err := run(jobCtx, progress)
+		progress.finish(jobCtx, result, err)
+		if err != nil && !errors.Is(context.Cause(jobCtx), errJobCancelled) {
+			log.Printf("Job %s (%s) failed: %v", job.ID, job.Kind, err)
+		}
+	}()
+
+	return job, nil
+}
+
+// SweepJobs fails the jobs whose instance stopped running them, and removes
+// those that finished longer than jobRetention ago. Every instance can sweep
+// at any time.
+func (s *ContentService) SweepJobs(ctx context.Context) (failed, pruned int64, err error) {
+	if s.jobStore == nil {
+		return 0, 0, nil
+	}
+	if failed, err = s.jobStore.FailStaleJobs(ctx, s.clock.Now().Add(-jobStaleAfter), jobStaleReason); err != nil {
+		return 0, 0, err
+	}
+	pruned, err = s.jobStore.PruneJobs(ctx, s.clock.Now().Add(-jobRetention))
+	return failed, pruned, err
+}
+
+// copyJob returns a copy of a job the runner can change
+func copyJob(job *model.Job) *model.Job {
+	jobCopy := *job
+	return &jobCopy
+}
+
+// GetJob returns the state of a job
+func (s *ContentService) GetJob(ctx context.Context, id uuid.UUID) (*model.Job, error) {
+	if s.jobStore == nil {
+		return nil, ErrJobNotFound
+	}
+	job, err := s.jobStore.GetJob(ctx, id)
+	if errors.Is(err, repository.ErrJobNotFound) {
+		return nil, ErrJobNotFound
+	}
+	return job, err
+}
+
+// CancelJob stops a queued or running job. A job running on another
+// instance stops the next time it saves its progress.
+func (s *ContentService) CancelJob(ctx context.Context, id uuid.UUID) (*model.Job, error) {
+	job, err := s.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status.Finished() {
+		return nil, fmt.Errorf("%w: job is %s", ErrJobFinished, job.Status)
+	}
+
+	if err := s.jobStore.RequestJobCancel(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrJobNotFound) {
+			return nil, ErrJobNotFound
+		}
+		return nil, err
+	}
+	s.jobs.mu.Lock()
+	if cancel, ok := s.jobs.cancels[id]; ok {
+		cancel(errJobCancelled)
+	}
+	s.jobs.mu.Unlock()
+
+	job.CancelRequested = true
+	return job, nil
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+	"github.com/livefire2015/simple-contents/repository/memory"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+)
+
+// finishedJob waits for the jobs of a service to finish and returns one of
+// them, decoding its result into result if not nil
+func finishedJob(t *testing.T, s *ContentService, id uuid.UUID, result interface{}) *model.Job {
+	t.Helper()
+	s.jobs.running.Wait()
+	job, err := s.GetJob(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != nil && len(job.Result) > 0 {
+		if err := json.Unmarshal(job.Result, result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return job
+}
+
+// saveEveryProgress makes jobs save each progress update during a test
+func saveEveryProgress(t *testing.T) {
+	interval := jobProgressInterval
+	jobProgressInterval = 0
+	t.Cleanup(func() { jobProgressInterval = interval })
+}
+
+func TestJobProgress(t *testing.T) {
+	saveEveryProgress(t)
+	ctx := context.Background()
+	s := NewContentService(memory.NewMemoryRepository(), memorystorage.NewMemoryStorage())
+
+	step := make(chan struct{})
+	started, err := s.startJob(ctx, &model.Job{Kind: "test", TenantID: "acme"}, map[string]int{"items": 4}, nil, func(ctx context.Context, progress *JobProgress) (interface{}, error) {
+		progress.SetTotal(4)
+		for i := 0; i < 4; i++ {
+			<-step
+			progress.Add(1)
+		}
+		return map[string]int{"processed": 4}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if started.Status != JobQueued || string(started.Input) != `{"items":4}` {
+		t.Errorf("started job = %s with input %s, want queued with its input", started.Status, started.Input)
+	}
+
+	step <- struct{}{}
+	step <- struct{}{}
+	// The second step is saved by the time the job takes the third
+	step <- struct{}{}
+	job, err := s.GetJob(ctx, started.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != JobRunning || job.Done < 2 || job.Total != 4 || job.Progress < 50 {
+		t.Errorf("running job = %s at %d%% (%d of %d), want running at 50%% or more", job.Status, job.Progress, job.Done, job.Total)
+	}
+	step <- struct{}{}
+
+	var result map[string]int
+	job = finishedJob(t, s, started.ID, &result)
+	if job.Status != JobDone || job.Progress != 100 || job.FinishedAt == nil {
+		t.Errorf("finished job = %s at %d%%, want done at 100%%", job.Status, job.Progress)
+	}
+	if result["processed"] != 4 {
+		t.Errorf("job result = %v, want 4 processed", result)
+	}
+	if _, err := s.CancelJob(ctx, started.ID); !errors.Is(err, ErrJobFinished) {
+		t.Errorf("CancelJob() of a finished job error = %v, want ErrJobFinished", err)
+	}
+}
+
+func TestJobFailureKeepsResult(t *testing.T) {
+	ctx := context.Background()
+	s := NewContentService(memory.NewMemoryRepository(), memorystorage.NewMemoryStorage())
+
+	started, err := s.startJob(ctx, &model.Job{Kind: "test"}, nil, nil, func(ctx context.Context, progress *JobProgress) (interface{}, error) {
+		return map[string]int{"processed": 1}, errors.New("storage unavailable")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result map[string]int
+	job := finishedJob(t, s, started.ID, &result)
+	if job.Status != JobFailed || job.Error != "storage unavailable" {
+		t.Errorf("job = %s (%q), want failed with the error", job.Status, job.Error)
+	}
+	if result["processed"] != 1 {
+		t.Errorf("job result = %v, want the partial result", result)
+	}
+}
+
+func TestCancelJob(t *testing.T) {
+	saveEveryProgress(t)
+	ctx := context.Background()
+	repo := memory.NewMemoryRepository()
+	s := NewContentService(repo, memorystorage.NewMemoryStorage())
+	// Another instance sharing the job store
+	other := NewContentService(repo, memorystorage.NewMemoryStorage())
+
+	blocked := func(ctx context.Context, progress *JobProgress) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	polling := func(ctx context.Context, progress *JobProgress) (interface{}, error) {
+		for ctx.Err() == nil {
+			progress.Add(1)
+			time.Sleep(time.Millisecond)
+		}
+		return nil, ctx.Err()
+	}
+
+	tests := []struct {
+		name     string
+		run      jobFunc
+		canceler *ContentService
+	}{
+		{name: "local", run: blocked, canceler: s},
+		{name: "other instance", run: polling, canceler: other},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started, err := s.startJob(ctx, &model.Job{Kind: "test"}, nil, nil, tt.run)
+			if err != nil {
+				t.Fatal(err)
+			}
+			job, err := tt.canceler.CancelJob(ctx, started.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !job.CancelRequested {
+				t.Error("CancelJob() did not flag the job")
+			}
+
+			job = finishedJob(t, s, started.ID, nil)
+			if job.Status != JobCancelled || job.Error != "" {
+				t.Errorf("job = %s (%q), want cancelled", job.Status, job.Error)
+			}
+		})
+	}
+
+	if _, err := s.CancelJob(ctx, uuid.New()); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("CancelJob() of an unknown job error = %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestJobHeartbeat(t *testing.T) {
+	interval := jobHeartbeatInterval
+	jobHeartbeatInterval = 5 * time.Millisecond
+	t.Cleanup(func() { jobHeartbeatInterval = interval })
+	ctx := context.Background()
+	s := NewContentService(memory.NewMemoryRepository(), memorystorage.NewMemoryStorage())
+
+	release := make(chan struct{})
+	started, err := s.startJob(ctx, &model.Job{Kind: "test"}, nil, nil, func(ctx context.Context, progress *JobProgress) (interface{}, error) {
+		<-release
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A job without progress is still saved, so it isn't taken for stale
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := s.GetJob(ctx, started.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.UpdatedAt.After(started.UpdatedAt.Add(20 * time.Millisecond)) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("job not saved by its heartbeat")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(release)
+
+	if job := finishedJob(t, s, started.ID, nil); job.Status != JobDone || job.Progress != 100 {
+		t.Errorf("job = %s at %d%%, want done at 100%%", job.Status, job.Progress)
+	}
+}
+
+func TestJobsDisabled(t *testing.T) {
+	// A repository that doesn't store jobs
+	repo := struct{ repository.ContentRepository }{memory.NewMemoryRepository()}
+	s := NewContentService(repo, memorystorage.NewMemoryStorage())
+
+	if _, err := s.StartBulkDelete(context.Background(), "", BulkDeleteInput{IDs: []uuid.UUID{uuid.New()}}); !errors.Is(err, ErrJobsDisabled) {
+		t.Errorf("StartBulkDelete() error = %v, want ErrJobsDisabled", err)
+	}
+	if _, err := s.GetJob(context.Background(), uuid.New()); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("GetJob() error = %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestStartBulkDelete(t *testing.T) {
+	ctx := context.Background()
+	s := NewContentService(memory.NewMemoryRepository(), memorystorage.NewMemoryStorage())
+
+	var ids []uuid.UUID
+	for i := 0; i < 3; i++ {
+		content, err := s.CreateContent(ctx, CreateContentInput{TenantID: "acme", FileName: "invoice.pdf", MIMEType: "application/pdf", FileSize: 7, Data: strings.NewReader("invoice")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, content.ID)
+	}
+	missing := uuid.New()
+
+	started, err := s.StartBulkDelete(ctx, "acme", BulkDeleteInput{IDs: append(ids, missing)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if started.Kind != JobKindBulkDelete || started.TenantID != "acme" {
+		t.Errorf("job = %s of tenant %q, want a bulk delete of acme", started.Kind, started.TenantID)
+	}
+
+	var result BulkDeleteResult
+	job := finishedJob(t, s, started.ID, &result)
+	if job.Status != JobDone || job.Done != 4 || job.Total != 4 {
+		t.Fatalf("job = %s with %d of %d done (%s), want done with 4 of 4", job.Status, job.Done, job.Total, job.Error)
+	}
+	if result.Deleted != 3 || len(result.Items) != 4 || result.Items[3].Status != BulkNotFound {
+		t.Errorf("result = %+v, want 3 deleted and the missing item not found", result)
+	}
+	if _, err := s.GetContent(ctx, ids[0]); !errors.Is(err, ErrContentNotFound) {
+		t.Errorf("GetContent() of deleted content error = %v, want ErrContentNotFound", err)
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// minKeySegmentBytes is the least a key segment may be limited to, leaving
+// room for a shortened file name's hash and some of its stem
+const minKeySegmentBytes = 32
+
+// shortNameHashLength is the number of hex digits of the SHA-256 of a file
+// name that a shortened name ends with
+const shortNameHashLength = 8
+
+// KeyLimits bounds the storage keys of new content in bytes, as backends
+// refuse longer keys. File names that would make a key too long are
+// shortened in the key; the content keeps its full name.
+type KeyLimits struct {
+	MaxKeyBytes     int // Whole key, e.g. 1024 on S3 and GCS
+	MaxSegmentBytes int // Each slash-separated segment, e.g. 255 on most file systems
+}
+
+// DefaultKeyLimits returns KeyLimits of 1024 bytes per key and 255 per
+// segment, which every supported backend accepts
+func DefaultKeyLimits() KeyLimits {
+	return KeyLimits{
+		MaxKeyBytes:     1024,
+		MaxSegmentBytes: 255,
+	}
+}
+
+// validate checks that the limits leave room for a shortened file name
+func (l KeyLimits) validate() error {
+	if l.MaxSegmentBytes < minKeySegmentBytes {
+		return fmt.Errorf("%w: key segments must be allowed at least %d bytes", ErrInvalidInput, minKeySegmentBytes)
+	}
+	if l.MaxKeyBytes < l.MaxSegmentBytes {
+		return fmt.Errorf("%w: keys must be allowed at least the bytes of a segment", ErrInvalidInput)
+	}
+	return nil
+}
+
+// ConfigureKeyLimits sets the byte limits of the storage keys of new
+// content, DefaultKeyLimits by default
+func (s *ContentService) ConfigureKeyLimits(limits KeyLimits) error {
+	if err := limits.validate(); err != nil {
+		return err
+	}
+	s.keyLimits = limits
+	return nil
+}
+
+// fits reports whether a key is within the limits
+func (l KeyLimits) fits(key string) bool {
+	if len(key) > l.MaxKeyBytes {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if len(segment) > l.MaxSegmentBytes {
+			return false
+		}
+	}
+	return true
+}
+
+// limitKey returns the key of input within the limits. A file name that
+// doesn't fit is shortened to the bytes left for it, so the same name always
+// gets the same key. Keys of strategies whose other segments are too long
+// already are refused.
+func (l KeyLimits) limitKey(strategy KeyStrategy, input KeyInput) (string, error) {
+	key := strategy.Key(input)
+	if l.fits(key) {
+		return key, nil
+	}
+	budget := l.MaxSegmentBytes
+	if rest := l.MaxKeyBytes - (len(key) - len(input.FileName)); rest < budget {
+		budget = rest
+	}
+	if budget >= minKeySegmentBytes {
+		input.FileName = shortenFileName(input.FileName, budget)
+		if key = strategy.Key(input); l.fits(key) {
+			return key, nil
+		}
+	}
+	return "", fmt.Errorf("%w: storage key exceeds %d bytes per key or %d per segment", ErrInvalidInput, l.MaxKeyBytes, l.MaxSegmentBytes)
+}
+
+// shortenFileName returns a name of at most max bytes for name, keeping the
+// start of its stem and its extension, and ending the stem with ~ and the
+// first hex digits of the SHA-256 of the full name, so that long names with
+// the same start still get different keys. Extensions too long to keep are
+// dropped with the rest.
+func shortenFileName(name string, max int) string {
+	if len(name) <= max {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	suffix := "~" + hex.EncodeToString(sum[:])[:shortNameHashLength]
+
+	ext := path.Ext(name)
+	if len(ext) > (max-len(suffix))/2 {
+		ext = ""
+	}
+	stem := strings.TrimSuffix(name, ext)
+	keep := max - len(suffix) - len(ext)
+	// Cut between runes, so the shortened name stays valid UTF-8
+	for keep > 0 && !utf8.RuneStart(stem[keep]) {
+		keep--
+	}
+	return stem[:keep] + suffix + ext
+}
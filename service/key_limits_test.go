@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/repository/memory"
+)
+
+func TestShortenFileName(t *testing.T) {
+	tests := []struct {
+		description string
+		name        string
+		max         int
+		wantExt     string
+	}{
+		{description: "ascii", name: strings.Repeat("a", 300) + ".pdf", max: 255, wantExt: ".pdf"},
+		{description: "accents", name: strings.Repeat("é", 200) + ".docx", max: 255, wantExt: ".docx"},
+		{description: "cjk", name: strings.Repeat("報告", 100) + ".pdf", max: 100, wantExt: ".pdf"},
+		{description: "long extension", name: "report." + strings.Repeat("x", 300), max: 64},
+		{description: "no extension", name: strings.Repeat("b", 40), max: 32},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			got := shortenFileName(tt.name, tt.max)
+			if len(got) > tt.max {
+				t.Errorf("shortenFileName() is %d bytes, want at most %d", len(got), tt.max)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("shortenFileName() = %q, not valid UTF-8", got)
+			}
+			if ext := path.Ext(got); tt.wantExt != "" && ext != tt.wantExt {
+				t.Errorf("shortenFileName() = %q, want extension %q", got, tt.wantExt)
+			}
+			if again := shortenFileName(tt.name, tt.max); again != got {
+				t.Errorf("shortenFileName() = %q, then %q, want the same name", got, again)
+			}
+		})
+	}
+
+	if got := shortenFileName("scan.pdf", 255); got != "scan.pdf" {
+		t.Errorf("shortenFileName() of a short name = %q, want it unchanged", got)
+	}
+	a := shortenFileName(strings.Repeat("a", 300)+"1.pdf", 255)
+	b := shortenFileName(strings.Repeat("a", 300)+"2.pdf", 255)
+	if a == b {
+		t.Errorf("shortenFileName() = %q for two names, want different names", a)
+	}
+}
+
+func TestKeyLimits(t *testing.T) {
+	input := KeyInput{
+		ContentID: uuid.MustParse("0190a6e2-7c1e-7b3a-9f4e-2d5c8b1a6f00"),
+		TenantID:  "acme",
+		FileName:  strings.Repeat("報告", 60) + ".pdf", // 364 bytes
+	}
+
+	tests := []struct {
+		description string
+		limits      KeyLimits
+		strategy    KeyStrategy
+		tenantID    string
+		wantErr     bool
+	}{
+		{description: "segment", limits: DefaultKeyLimits(), strategy: FlatKeys{}},
+		{description: "key", limits: KeyLimits{MaxKeyBytes: 128, MaxSegmentBytes: 255}, strategy: TenantPrefixedKeys{}},
+		{description: "long prefix", limits: KeyLimits{MaxKeyBytes: 64, MaxSegmentBytes: 64}, strategy: TenantPrefixedKeys{}, tenantID: strings.Repeat("t", 63), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			input := input
+			if tt.tenantID != "" {
+				input.TenantID = tt.tenantID
+			}
+			key, err := tt.limits.limitKey(tt.strategy, input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidInput) {
+					t.Fatalf("limitKey() error = %v, want %v", err, ErrInvalidInput)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("limitKey() error = %v", err)
+			}
+			if !tt.limits.fits(key) {
+				t.Errorf("limitKey() = %q, beyond the limits %+v", key, tt.limits)
+			}
+			if !strings.HasSuffix(key, ".pdf") || !strings.Contains(key, input.ContentID.String()+"/") {
+				t.Errorf("limitKey() = %q, want the content ID and the extension kept", key)
+			}
+		})
+	}
+
+	short := input
+	short.FileName = "scan.pdf"
+	if key, err := DefaultKeyLimits().limitKey(FlatKeys{}, short); err != nil || key != "0190a6e2-7c1e-7b3a-9f4e-2d5c8b1a6f00/scan.pdf" {
+		t.Errorf("limitKey() of a short name = %q, %v, want the key unchanged", key, err)
+	}
+}
+
+func TestConfigureKeyLimits(t *testing.T) {
+	s := newTestInstance(memory.NewMemoryRepository())
+	for _, limits := range []KeyLimits{
+		{MaxKeyBytes: 1024, MaxSegmentBytes: 16},
+		{MaxKeyBytes: 100, MaxSegmentBytes: 255},
+	} {
+		if err := s.ConfigureKeyLimits(limits); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("ConfigureKeyLimits(%+v) error = %v, want %v", limits, err, ErrInvalidInput)
+		}
+	}
+	if err := s.ConfigureKeyLimits(KeyLimits{MaxKeyBytes: 512, MaxSegmentBytes: 128}); err != nil {
+		t.Errorf("ConfigureKeyLimits() error = %v", err)
+	}
+}
+
+func TestLongFileNamesKeepTheirName(t *testing.T) {
+	ctx := context.Background()
+	s := newTestInstance(memory.NewMemoryRepository())
+	fileName := strings.Repeat("提案書", 80) + ".pdf" // 244 characters, 724 bytes
+
+	content, err := s.CreateContent(ctx, CreateContentInput{
+		FileName: fileName, MIMEType: "application/pdf", FileSize: 3, Data: strings.NewReader("pdf"),
+	})
+	if err != nil {
+		t.Fatalf("CreateContent() error = %v", err)
+	}
+	if content.FileName != fileName {
+		t.Errorf("FileName = %q, want the full name", content.FileName)
+	}
+	if !DefaultKeyLimits().fits(content.StoragePath) || !strings.HasSuffix(content.StoragePath, ".pdf") {
+		t.Errorf("StoragePath = %q, want a shortened name keeping its extension", content.StoragePath)
+	}
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+	"github.com/livefire2015/simple-contents/storage"
+)
+
+// ReconcileOptions controls a consistency check between metadata and storage
+type ReconcileOptions struct {
+	Repair bool // Mark content whose data is missing as errored
+}
+
+// ReconcileResult summarizes a reconciliation run
+type ReconcileResult struct {
+	Checked      int         `json:"checked"`
+	MissingData  []uuid.UUID `json:"missing_data"`  // Uploaded content without a stored object
+	SizeMismatch []uuid.UUID `json:"size_mismatch"` // Stored object size differs from the recorded size
+	Repaired     int         `json:"repaired"`
+}
+
+// Reconcile checks that every uploaded content item has its data in storage
+func (s *ContentService) Reconcile(ctx context.Context, options ReconcileOptions) (*ReconcileResult, error) {
+	result := &ReconcileResult{
+		MissingData:  []uuid.UUID{},
+		SizeMismatch: []uuid.UUID{},
+	}
+
+	var missing []*model.Content
+	for offset := 0; ; offset += exportPageSize {
+		items, _, err := s.repo.ListContent(ctx, model.ContentFilter{}, offset, exportPageSize)
+		if err != nil {
+			return result, err
+		}
+
+		for _, content := range items {
+			// Content that was never uploaded, or already failed, has no data to check
+			if content.Status != model.StatusUploaded && content.Status != model.StatusDone || content.IsReference() {
+				continue
+			}
+			result.Checked++
+
+			info, err := s.storage.Stat(storageContext(ctx, content), content.StoragePath)
+			if err != nil {
+				result.MissingData = append(result.MissingData, content.ID)
+				missing = append(missing, content)
+				continue
+			}
+			if content.FileSize >= 0 && info.Size != content.FileSize {
+				result.SizeMismatch = append(result.SizeMismatch, content.ID)
+			}
+		}
+
+		if len(items) < exportPageSize {
+			break
+		}
+	}
+
+	if options.Repair {
+		for _, listed := range missing {
+			// The listing may be stale; repair the current record
+			content, err := s.getContentForUpdate(ctx, listed.ID)
+			if errors.Is(err, ErrContentNotFound) {
+				continue
+			} else if err != nil {
+				return result, fmt.Errorf("failed to repair content %s: %w", listed.ID, err)
+			}
+			content.Status = model.StatusError
+			content.UpdatedAt = s.now()
+			if err := s.repo.UpdateContent(ctx, content); err != nil {
+				return result, fmt.Errorf("failed to repair content %s: %w", content.ID, err)
+			}
+			s.statusChanged(ctx, content)
+			result.Repaired++
+		}
+	}
+
+	return result, nil
+}
+
+// PruneDeleted permanently removes the records of content deleted before a
+// cutoff. Their data was removed from storage when they were deleted.
+func (s *ContentService) PruneDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	if deletedBefore.IsZero() {
+		return 0, fmt.Errorf("%w: pruning requires a cutoff time", ErrInvalidInput)
+	}
+	return s.repo.PruneDeletedContent(ctx, deletedBefore)
+}
+
+// PurgeOptions selects the content removed by Purge
+type PurgeOptions struct {
+	Status model.ContentStatus // Only purge content in this status, any status if empty
+	Before time.Time           // Only purge content last updated before this time
+	DryRun bool                // Report what would be purged without deleting
+}
+
+// PurgeResult summarizes a purge run
+type PurgeResult struct {
+	Purged []uuid.UUID `json:"purged"`
+	DryRun bool        `json:"dry_run"`
+}
+
+// Purge permanently deletes content matching the options, including its data
+func (s *ContentService) Purge(ctx context.Context, options PurgeOptions) (*PurgeResult, error) {
+	if options.Before.IsZero() {
+		return nil, fmt.Errorf("%w: purge requires a cutoff time", ErrInvalidInput)
+	}
+	if options.Status != "" && !options.Status.IsValid() {
+		return nil, ErrInvalidStatus
+	}
+
+	// Collect first so that deleting does not shift the pages being read
+	var ids []uuid.UUID
+	for offset := 0; ; offset += exportPageSize {
+		items, _, err := s.repo.ListContent(ctx, model.ContentFilter{}, offset, exportPageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, content := range items {
+			if options.Status != "" && content.Status != options.Status {
+				continue
+			}
+			if !content.UpdatedAt.Before(options.Before) {
+				continue
+			}
+			ids = append(ids, content.ID)
+		}
+
+		if len(items) < exportPageSize {
+			break
+		}
+	}
+
+	result := &PurgeResult{Purged: []uuid.UUID{}, DryRun: options.DryRun}
+	for _, id := range ids {
+		if !options.DryRun {
+			if _, err := s.DeleteContent(ctx, id, DeleteContentOptions{Policy: DeleteForce}); err != nil && !errors.Is(err, ErrContentNotFound) {
+				return result, fmt.Errorf("failed to purge content %s: %w", id, err)
+			}
+		}
+		result.Purged = append(result.Purged, id)
+	}
+
+	return result, nil
+}
+
+// SweepResult summarizes a sweep deleting content
+type SweepResult struct {
+	Deleted  int `json:"deleted"`
+	Retained int `json:"retained"` // Kept by the retention of an entity type they are linked to
+}
+
+// sweepContents deletes content in chunks, like a bulk delete
+func (s *ContentService) sweepContents(ctx context.Context, ids []uuid.UUID) (*SweepResult, error) {
+	result := &SweepResult{}
+	for start := 0; start < len(ids); start += bulkDeleteChunkSize {
+		deleted, err := s.deleteContents(ctx, ids[start:min(start+bulkDeleteChunkSize, len(ids))], nil)
+		if err != nil {
+			return result, err
+		}
+		result.Deleted += deleted.Deleted
+		for _, item := range deleted.Items {
+			if item.Status == BulkRetained {
+				result.Retained++
+			}
+		}
+	}
+	return result, nil
+}
+
+// forEachTenant calls fn with every registered tenant
+func (s *ContentService) forEachTenant(ctx context.Context, fn func(tenant *model.Tenant) error) error {
+	if s.tenants == nil {
+		return nil
+	}
+	for page := 1; ; page++ {
+		tenants, _, err := s.tenants.ListTenants(ctx, repository.ListOptions{Page: page, PageSize: exportPageSize})
+		if err != nil {
+			return err
+		}
+		for _, tenant := range tenants {
+			if err := fn(tenant); err != nil {
+				return err
+			}
+		}
+		if len(tenants) < exportPageSize {
+			return nil
+		}
+	}
+}
+
+// SweepRetention deletes the content of each tenant that is older than the
+// maximum age of its retention policy
+func (s *ContentService) SweepRetention(ctx context.Context) (*SweepResult, error) {
+	result := &SweepResult{}
+	err := s.forEachTenant(ctx, func(tenant *model.Tenant) error {
+		if tenant.Retention.MaxAgeDays <= 0 {
+			return nil
+		}
+		cutoff := s.now().AddDate(0, 0, -tenant.Retention.MaxAgeDays)
+		filter := model.ContentFilter{TenantID: tenant.ID, CreatedTo: &cutoff}
+
+		var ids []uuid.UUID
+		if err := s.repo.ListContentStream(ctx, filter, func(content *model.Content) error {
+			ids = append(ids, content.ID)
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		swept, err := s.sweepContents(ctx, ids)
+		result.Deleted += swept.Deleted
+		result.Retained += swept.Retained
+		if err != nil {
+			return fmt.Errorf("failed to sweep tenant %s: %w", tenant.ID, err)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// CleanupAbandonedUploads deletes the content created longer ago than
+// olderThan whose data never reached storage, e.g. direct uploads the
+// client gave up on
+func (s *ContentService) CleanupAbandonedUploads(ctx context.Context, olderThan time.Duration) (*SweepResult, error) {
+	if olderThan <= 0 {
+		return nil, fmt.Errorf("%w: abandoned uploads need a positive age", ErrInvalidInput)
+	}
+	cutoff := s.now().Add(-olderThan)
+	filter := model.ContentFilter{Status: model.StatusCreated, CreatedTo: &cutoff}
+
+	var created []*model.Content
+	if err := s.repo.ListContentStream(ctx, filter, func(content *model.Content) error {
+		created = append(created, content)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// Content created with its data stays in the created status too
+	var ids []uuid.UUID
+	for _, content := range created {
+		if _, err := s.storage.Stat(storageContext(ctx, content), content.StoragePath); errors.Is(err, storage.ErrNotFound) {
+			ids = append(ids, content.ID)
+		}
+	}
+	return s.sweepContents(ctx, ids)
+}
+
+// RecalculateAllUsage recomputes the stored bytes of every tenant and
+// returns the number of tenants recounted
+func (s *ContentService) RecalculateAllUsage(ctx context.Context) (int, error) {
+	recounted := 0
+	err := s.forEachTenant(ctx, func(tenant *model.Tenant) error {
+		if _, err := s.RecalculateUsage(ctx, tenant.ID); err != nil {
+			return fmt.Errorf("failed to recalculate the usage of tenant %s: %w", tenant.ID, err)
+		}
+		recounted++
+		return nil
+	})
+	return recounted, err
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// markdownToHTML converts the commonly used subset of Markdown to HTML:
+// headings, paragraphs, lists, block quotes, fenced code, rules, emphasis,
+// inline code and links. Raw HTML in the source is escaped.
+func markdownToHTML(source string) string {
+	var out strings.Builder
+	lines := strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n")
+
+	var paragraph []string
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			out.WriteString("<p>" + markdownInline(strings.Join(paragraph, "\n")) + "</p>\n")
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flushParagraph()
+
+		case strings.HasPrefix(trimmed, "```"):
+			flushParagraph()
+			out.WriteString("<pre><code>")
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				out.WriteString(html.EscapeString(lines[i]) + "\n")
+			}
+			out.WriteString("</code></pre>\n")
+
+		case markdownHeading.MatchString(trimmed):
+			flushParagraph()
+			m := markdownHeading.FindStringSubmatch(trimmed)
+			level := string(rune('0' + len(m[1])))
+			out.WriteString("<h" + level + ">" + markdownInline(m[2]) + "</h" + level + ">\n")
+
+		case markdownRule.MatchString(trimmed):
+			flushParagraph()
+			out.WriteString("<hr>\n")
+
+		case strings.HasPrefix(trimmed, ">"):
+			flushParagraph()
+			var quote []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				quote = append(quote, strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(lines[i]), ">"), " "))
+			}
+			i--
+			out.WriteString("<blockquote>\n" + markdownToHTML(strings.Join(quote, "\n")) + "</blockquote>\n")
+
+		case markdownBullet.MatchString(line), markdownNumbered.MatchString(line):
+			flushParagraph()
+			pattern, tag := markdownBullet, "ul"
+			if markdownNumbered.MatchString(line) {
+				pattern, tag = markdownNumbered, "ol"
+			}
+			out.WriteString("<" + tag + ">\n")
+			for ; i < len(lines) && pattern.MatchString(lines[i]); i++ {
+				item := pattern.ReplaceAllString(lines[i], "")
+				out.WriteString("<li>" + markdownInline(item) + "</li>\n")
+			}
+			i--
+			out.WriteString("</" + tag + ">\n")
+
+		default:
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	flushParagraph()
+
+	return out.String()
+}
+
+var (
+	markdownHeading  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+	markdownRule     = regexp.MustCompile(`^(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	markdownBullet   = regexp.MustCompile(`^\s*[-*+]\s+`)
+	markdownNumbered = regexp.MustCompile(`^\s*\d+[.)]\s+`)
+
+	markdownBold   = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	markdownItalic = regexp.MustCompile(`\*(.+?)\*|\b_(.+?)_\b`)
+	markdownLink   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+)
+
+// markdownInline converts inline Markdown in a block of text
+func markdownInline(text string) string {
+	// Code spans are emitted verbatim, everything else is formatted
+	parts := strings.Split(text, "`")
+	var out strings.Builder
+	for i, part := range parts {
+		if i%2 == 1 && i < len(parts)-1 {
+			out.WriteString("<code>" + html.EscapeString(part) + "</code>")
+			continue
+		}
+		if i%2 == 1 {
+			out.WriteString("`")
+		}
+		out.WriteString(markdownFormat(html.EscapeString(part)))
+	}
+	return strings.ReplaceAll(out.String(), "\n", "<br>\n")
+}
+
+// markdownFormat applies links and emphasis to escaped text
+func markdownFormat(text string) string {
+	text = markdownLink.ReplaceAllStringFunc(text, func(match string) string {
+		m := markdownLink.FindStringSubmatch(match)
+		if !safeLinkTarget(html.UnescapeString(m[2])) {
+			return m[1]
+		}
+		return `<a href="` + m[2] + `">` + m[1] + "</a>"
+	})
+	text = markdownBold.ReplaceAllString(text, "<strong>$1$2</strong>")
+	text = markdownItalic.ReplaceAllString(text, "<em>$1$2</em>")
+	return text
+}
+
+// safeLinkTarget rejects link targets that could run script, e.g. javascript: URLs
+func safeLinkTarget(target string) bool {
+	lower := strings.ToLower(target)
+	if i := strings.IndexAny(lower, ":/?#"); i >= 0 && lower[i] == ':' {
+		return strings.HasPrefix(lower, "http:") || strings.HasPrefix(lower, "https:") || strings.HasPrefix(lower, "mailto:")
+	}
+	return true
+}
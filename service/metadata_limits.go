@@ -0,0 +1,154 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/validate"
+)
+
+// Metadata value types, as named by JSON Schema
+const (
+	MetadataTypeString  = "string"
+	MetadataTypeNumber  = "number"
+	MetadataTypeBoolean = "boolean"
+	MetadataTypeNull    = "null"
+	MetadataTypeObject  = "object"
+	MetadataTypeArray   = "array"
+)
+
+// MetadataLimits bounds the metadata clients can store on content, which is
+// kept in a single column and returned by every listing. A limit of 0 is
+// unlimited.
+type MetadataLimits struct {
+	MaxBytes int // Size of the metadata encoded as JSON
+	MaxKeys  int // Keys of the metadata, counting those of nested objects
+	MaxDepth int // Nesting of objects and arrays, 1 for flat metadata
+	// ValueTypes are the JSON types values may have, all of them if empty
+	ValueTypes []string
+}
+
+// DefaultMetadataLimits returns MetadataLimits allowing 64 KiB of metadata
+// with up to 200 keys nested 5 deep
+func DefaultMetadataLimits() MetadataLimits {
+	return MetadataLimits{
+		MaxBytes: 64 << 10,
+		MaxKeys:  200,
+		MaxDepth: 5,
+	}
+}
+
+// validate checks that no limit is negative and the value types are known
+func (l MetadataLimits) validate() error {
+	if l.MaxBytes < 0 || l.MaxKeys < 0 || l.MaxDepth < 0 {
+		return fmt.Errorf("%w: metadata limits must not be negative", ErrInvalidInput)
+	}
+	for _, valueType := range l.ValueTypes {
+		switch valueType {
+		case MetadataTypeString, MetadataTypeNumber, MetadataTypeBoolean, MetadataTypeNull, MetadataTypeObject, MetadataTypeArray:
+		default:
+			return fmt.Errorf("%w: unknown metadata value type %q", ErrInvalidInput, valueType)
+		}
+	}
+	return nil
+}
+
+// ConfigureMetadataLimits replaces the limits metadata is checked against
+// when content is created or its metadata updated
+func (s *ContentService) ConfigureMetadataLimits(limits MetadataLimits) error {
+	if err := limits.validate(); err != nil {
+		return err
+	}
+	s.metadataLimits = limits
+	return nil
+}
+
+// check fails with validate.Errors naming every value of metadata beyond the
+// limits. Values are named by their path, e.g. metadata.customer.tags.0.
+func (l MetadataLimits) check(metadata model.Metadata) error {
+	if len(metadata) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("%w: metadata is not valid JSON: %v", ErrInvalidInput, err)
+	}
+	if l.MaxBytes > 0 && len(data) > l.MaxBytes {
+		return fmt.Errorf("%w: %w", ErrInvalidInput, validate.Errors{{
+			Field:   "metadata",
+			Message: fmt.Sprintf("must be at most %d bytes, got %d", l.MaxBytes, len(data)),
+		}})
+	}
+
+	// Walk the metadata as JSON, whatever Go types it holds
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("%w: metadata is not valid JSON: %v", ErrInvalidInput, err)
+	}
+	allowed := make(map[string]bool, len(l.ValueTypes))
+	for _, valueType := range l.ValueTypes {
+		allowed[valueType] = true
+	}
+	var errs validate.Errors
+	keys := 0
+	var walk func(value interface{}, path string, depth int)
+	walk = func(value interface{}, path string, depth int) {
+		if valueType := jsonType(value); depth > 0 && len(allowed) > 0 && !allowed[valueType] {
+			errs = append(errs, validate.FieldError{Field: path, Message: fmt.Sprintf("must not be of type %s", valueType)})
+			return
+		}
+		switch value := value.(type) {
+		case map[string]interface{}:
+			if l.MaxDepth > 0 && depth >= l.MaxDepth {
+				errs = append(errs, validate.FieldError{Field: path, Message: fmt.Sprintf("must not nest more than %d levels", l.MaxDepth)})
+				return
+			}
+			keys += len(value)
+			names := make([]string, 0, len(value))
+			for name := range value {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				walk(value[name], path+"."+name, depth+1)
+			}
+		case []interface{}:
+			if l.MaxDepth > 0 && depth >= l.MaxDepth {
+				errs = append(errs, validate.FieldError{Field: path, Message: fmt.Sprintf("must not nest more than %d levels", l.MaxDepth)})
+				return
+			}
+			for i, element := range value {
+				walk(element, path+"."+strconv.Itoa(i), depth+1)
+			}
+		}
+	}
+	walk(document, "metadata", 0)
+	if l.MaxKeys > 0 && keys > l.MaxKeys {
+		errs = append(validate.Errors{{Field: "metadata", Message: fmt.Sprintf("must have at most %d keys, got %d", l.MaxKeys, keys)}}, errs...)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidInput, errs)
+	}
+	return nil
+}
+
+// jsonType returns the JSON Schema type of a decoded JSON value
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return MetadataTypeString
+	case float64:
+		return MetadataTypeNumber
+	case bool:
+		return MetadataTypeBoolean
+	case map[string]interface{}:
+		return MetadataTypeObject
+	case []interface{}:
+		return MetadataTypeArray
+	default:
+		return MetadataTypeNull
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository/memory"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+	"github.com/livefire2015/simple-contents/validate"
+)
+
+func TestMetadataLimits(t *testing.T) {
+	limits := MetadataLimits{MaxBytes: 100, MaxKeys: 4, MaxDepth: 2, ValueTypes: []string{"string", "number", "object", "array"}}
+	tests := []struct {
+		name     string
+		metadata model.Metadata
+		want     validate.Errors
+	}{
+		{name: "empty"},
+		{name: "within limits", metadata: model.Metadata{"a": "x", "b": []int{1}, "c": map[string]interface{}{"d": 2.5}}},
+		{name: "too large", metadata: model.Metadata{"blob": strings.Repeat("x", 100)}, want: validate.Errors{
+			{Field: "metadata", Message: "must be at most 100 bytes, got 111"},
+		}},
+		{name: "too many keys", metadata: model.Metadata{"a": 1, "b": 2, "c": map[string]interface{}{"d": 3, "e": 4}}, want: validate.Errors{
+			{Field: "metadata", Message: "must have at most 4 keys, got 5"},
+		}},
+		{name: "too deep", metadata: model.Metadata{"a": map[string]interface{}{"b": map[string]interface{}{}}, "c": [][]int{{1}}}, want: validate.Errors{
+			{Field: "metadata.a.b", Message: "must not nest more than 2 levels"},
+			{Field: "metadata.c.0", Message: "must not nest more than 2 levels"},
+		}},
+		{name: "value types", metadata: model.Metadata{"a": true, "b": []interface{}{"x", nil}}, want: validate.Errors{
+			{Field: "metadata.a", Message: "must not be of type boolean"},
+			{Field: "metadata.b.1", Message: "must not be of type null"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := limits.check(tt.metadata)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("check() error = %v, want nil", err)
+				}
+				return
+			}
+			var errs validate.Errors
+			if !errors.As(err, &errs) || !errors.Is(err, ErrInvalidInput) {
+				t.Fatalf("check() error = %v, want validate.Errors", err)
+			}
+			if !reflect.DeepEqual(errs, tt.want) {
+				t.Errorf("check() = %v, want %v", errs, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetadataLimitsAppliedOnUpdate(t *testing.T) {
+	ctx := context.Background()
+	s := NewContentService(memory.NewMemoryRepository(), memorystorage.NewMemoryStorage())
+	if err := s.ConfigureMetadataLimits(MetadataLimits{ValueTypes: []string{"strings"}}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("ConfigureMetadataLimits() error = %v, want ErrInvalidInput", err)
+	}
+	if err := s.ConfigureMetadataLimits(MetadataLimits{MaxKeys: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := s.CreateContent(ctx, CreateContentInput{FileName: "a.txt", MIMEType: "text/plain", FileSize: 1, Data: strings.NewReader("a"), Metadata: model.Metadata{"a": 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UpdateContent(ctx, UpdateContentInput{ID: content.ID, Metadata: model.Metadata{"a": 1, "b": 2}}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("UpdateContent() error = %v, want ErrInvalidInput", err)
+	}
+}
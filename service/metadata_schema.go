@@ -0,0 +1,206 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// MetadataSchemaConfig holds the JSON Schemas the intrinsic metadata of
+// content must satisfy, by the source of the content and by the entity
+// types it is linked to
+type MetadataSchemaConfig struct {
+	Sources     map[string]json.RawMessage `json:"sources"`
+	EntityTypes map[string]json.RawMessage `json:"entity_types"`
+}
+
+// MetadataSchemas are the compiled schemas of a MetadataSchemaConfig
+type MetadataSchemas struct {
+	sources     map[string]*jsonschema.Schema
+	entityTypes map[string]*jsonschema.Schema
+}
+
+// NewMetadataSchemas compiles the schemas of a configuration. Schemas can
+// only reference each other's definitions, not remote documents.
+func NewMetadataSchemas(config MetadataSchemaConfig) (*MetadataSchemas, error) {
+	sources, err := compileMetadataSchemas("source", config.Sources)
+	if err != nil {
+		return nil, err
+	}
+	entityTypes, err := compileMetadataSchemas("entity_type", config.EntityTypes)
+	if err != nil {
+		return nil, err
+	}
+	return &MetadataSchemas{sources: sources, entityTypes: entityTypes}, nil
+}
+
+// compileMetadataSchemas compiles the schemas of one kind by name
+func compileMetadataSchemas(kind string, raw map[string]json.RawMessage) (map[string]*jsonschema.Schema, error) {
+	schemas := make(map[string]*jsonschema.Schema, len(raw))
+	for name, schema := range raw {
+		if name == "" {
+			return nil, fmt.Errorf("%w: metadata schema has no %s", ErrInvalidInput, kind)
+		}
+
+		compiler := jsonschema.NewCompiler()
+		compiler.LoadURL = func(url string) (io.ReadCloser, error) {
+			return nil, fmt.Errorf("loading %s is not allowed", url)
+		}
+		url := "metadata://" + kind + "/" + name
+		if err := compiler.AddResource(url, bytes.NewReader(schema)); err != nil {
+			return nil, fmt.Errorf("%w: metadata schema of %s %q: %v", ErrInvalidInput, kind, name, err)
+		}
+		compiled, err := compiler.Compile(url)
+		if err != nil {
+			return nil, fmt.Errorf("%w: metadata schema of %s %q: %v", ErrInvalidInput, kind, name, err)
+		}
+		schemas[name] = compiled
+	}
+	return schemas, nil
+}
+
+// LoadMetadataSchemas reads a JSON MetadataSchemaConfig and compiles its schemas
+func LoadMetadataSchemas(path string) (*MetadataSchemas, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config MetadataSchemaConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid metadata schema configuration: %w", err)
+	}
+	return NewMetadataSchemas(config)
+}
+
+// ConfigureMetadataSchemas makes content creation and metadata updates check
+// metadata against the schemas of its source and entity types
+func (s *ContentService) ConfigureMetadataSchemas(schemas *MetadataSchemas) {
+	s.metadataSchemas = schemas
+}
+
+// MetadataFieldError is a reason metadata doesn't match a schema
+type MetadataFieldError struct {
+	Field   string `json:"field"` // JSON pointer into the metadata, empty for the metadata as a whole
+	Message string `json:"message"`
+}
+
+// MetadataValidationError is returned when metadata doesn't match the schema
+// of its source or of an entity type its content is linked to
+type MetadataValidationError struct {
+	Schema string               // e.g. `source "billing"`
+	Fields []MetadataFieldError // Ordered by field
+}
+
+func (e *MetadataValidationError) Error() string {
+	reasons := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		if field.Field == "" {
+			reasons[i] = field.Message
+		} else {
+			reasons[i] = field.Field + ": " + field.Message
+		}
+	}
+	return fmt.Sprintf("metadata does not match the schema of %s: %s", e.Schema, strings.Join(reasons, "; "))
+}
+
+// Unwrap makes errors.Is(err, ErrInvalidInput) match
+func (e *MetadataValidationError) Unwrap() error {
+	return ErrInvalidInput
+}
+
+// validateMetadata checks metadata against the metadata limits, then the
+// schema of a source, if it has one, then those of the entity types
+func (s *ContentService) validateMetadata(metadata model.Metadata, source string, entityTypes ...string) error {
+	if err := s.metadataLimits.check(metadata); err != nil {
+		return err
+	}
+	if s.metadataSchemas == nil {
+		return nil
+	}
+
+	// The schema sees the metadata as JSON, whatever Go types it holds
+	if metadata == nil {
+		metadata = model.Metadata{}
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("%w: metadata is not valid JSON: %v", ErrInvalidInput, err)
+	}
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("%w: metadata is not valid JSON: %v", ErrInvalidInput, err)
+	}
+
+	if schema, ok := s.metadataSchemas.sources[source]; ok && source != "" {
+		if err := validateAgainst(schema, document, fmt.Sprintf("source %q", source)); err != nil {
+			return err
+		}
+	}
+	for _, entityType := range entityTypes {
+		if schema, ok := s.metadataSchemas.entityTypes[entityType]; ok {
+			if err := validateAgainst(schema, document, fmt.Sprintf("entity type %q", entityType)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateAgainst validates a JSON document against a schema, reporting
+// every failing field
+func validateAgainst(schema *jsonschema.Schema, document interface{}, name string) error {
+	err := schema.Validate(document)
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+
+	// Only the innermost errors name the offending fields
+	var fields []MetadataFieldError
+	var collect func(*jsonschema.ValidationError)
+	collect = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			fields = append(fields, MetadataFieldError{Field: e.InstanceLocation, Message: e.Message})
+		}
+		for _, cause := range e.Causes {
+			collect(cause)
+		}
+	}
+	collect(validationErr)
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+
+	return &MetadataValidationError{Schema: name, Fields: fields}
+}
+
+// linkedEntityTypes returns the distinct entity types a content item is linked to
+func (s *ContentService) linkedEntityTypes(ctx context.Context, contentID uuid.UUID) ([]string, error) {
+	if s.metadataSchemas == nil || len(s.metadataSchemas.entityTypes) == 0 {
+		return nil, nil
+	}
+	associations, _, err := s.repo.ListAssociationsByContent(ctx, contentID, repository.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var types []string
+	seen := make(map[string]bool)
+	for _, association := range associations {
+		if !seen[association.EntityType] {
+			seen[association.EntityType] = true
+			types = append(types, association.EntityType)
+		}
+	}
+	sort.Strings(types)
+	return types, nil
+}
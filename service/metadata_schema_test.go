@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository/memory"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+)
+
+func TestMetadataSchemas(t *testing.T) {
+	schemas, err := NewMetadataSchemas(MetadataSchemaConfig{
+		Sources: map[string]json.RawMessage{
+			"billing": json.RawMessage(`{
+				"type": "object",
+				"required": ["invoice_number"],
+				"properties": {
+					"invoice_number": {"type": "string", "pattern": "^INV-[0-9]+$"},
+					"amount": {"type": "number", "minimum": 0}
+				}
+			}`),
+		},
+		EntityTypes: map[string]json.RawMessage{
+			"dispute": json.RawMessage(`{"type": "object", "required": ["case_id"]}`),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		source     string
+		entityType string
+		metadata   model.Metadata
+		wantFields []string
+	}{
+		{name: "valid", source: "billing", metadata: model.Metadata{"invoice_number": "INV-1", "amount": 12.5}},
+		{name: "no schema", source: "scanner", metadata: model.Metadata{"anything": true}},
+		{name: "missing required", source: "billing", metadata: nil, wantFields: []string{""}},
+		{name: "several fields", source: "billing", metadata: model.Metadata{"invoice_number": "1", "amount": -3}, wantFields: []string{"/amount", "/invoice_number"}},
+		{name: "integer values", source: "billing", metadata: model.Metadata{"invoice_number": "INV-2", "amount": 3}},
+		{name: "entity type", source: "billing", entityType: "dispute", metadata: model.Metadata{"invoice_number": "INV-1"}, wantFields: []string{""}},
+		{name: "entity type satisfied", source: "billing", entityType: "dispute", metadata: model.Metadata{"invoice_number": "INV-1", "case_id": "c1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := NewContentService(memory.NewMemoryRepository(), memorystorage.NewMemoryStorage())
+			s.ConfigureMetadataSchemas(schemas)
+
+			input := CreateContentInput{FileName: "invoice.pdf", MIMEType: "application/pdf", FileSize: 3, Data: strings.NewReader("pdf"), Source: tt.source, Metadata: tt.metadata}
+			if tt.entityType != "" {
+				input.EntityType, input.EntityID = tt.entityType, "d1"
+			}
+			_, err := s.CreateContent(ctx, input)
+
+			if tt.wantFields == nil {
+				if err != nil {
+					t.Fatalf("CreateContent() error = %v", err)
+				}
+				return
+			}
+			var validationErr *MetadataValidationError
+			if !errors.As(err, &validationErr) || !errors.Is(err, ErrInvalidInput) {
+				t.Fatalf("CreateContent() error = %v, want a MetadataValidationError", err)
+			}
+			var fields []string
+			for _, field := range validationErr.Fields {
+				fields = append(fields, field.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.wantFields, ",") {
+				t.Errorf("failing fields = %q, want %q", fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestUpdateContentChecksLinkedEntityTypes(t *testing.T) {
+	ctx := context.Background()
+	schemas, err := NewMetadataSchemas(MetadataSchemaConfig{
+		EntityTypes: map[string]json.RawMessage{
+			"dispute": json.RawMessage(`{"type": "object", "required": ["case_id"]}`),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewContentService(memory.NewMemoryRepository(), memorystorage.NewMemoryStorage())
+	s.ConfigureMetadataSchemas(schemas)
+
+	content, err := s.CreateContent(ctx, CreateContentInput{FileName: "photo.pdf", MIMEType: "application/pdf", FileSize: 3, Data: strings.NewReader("pdf"), EntityType: "dispute", EntityID: "d1", Metadata: model.Metadata{"case_id": "c1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.UpdateContent(ctx, UpdateContentInput{ID: content.ID, Metadata: model.Metadata{"note": "no case"}})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("UpdateContent() error = %v, want ErrInvalidInput", err)
+	}
+	if _, err := s.UpdateContent(ctx, UpdateContentInput{ID: content.ID, Metadata: model.Metadata{"case_id": "c2"}}); err != nil {
+		t.Errorf("UpdateContent() error = %v", err)
+	}
+}
+
+func TestNewMetadataSchemasRejectsRemoteReferences(t *testing.T) {
+	_, err := NewMetadataSchemas(MetadataSchemaConfig{
+		Sources: map[string]json.RawMessage{"billing": json.RawMessage(`{"$ref": "https://example.com/schema.json"}`)},
+	})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("NewMetadataSchemas() error = %v, want ErrInvalidInput", err)
+	}
+}
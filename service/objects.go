@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/livefire2015/simple-contents/model"
+)
+
+const (
+	// MetadataObjectBucket holds the gateway bucket an object was written to
+	MetadataObjectBucket = "s3_bucket"
+	// MetadataObjectKey holds the key an object was written under
+	MetadataObjectKey = "s3_key"
+
+	// SourceObjectGateway is the source of content written through the S3-compatible gateway
+	SourceObjectGateway = "s3_gateway"
+
+	// maxListObjects is the largest page of a ListObjects call
+	maxListObjects = 1000
+
+	// Code points reserved for UTF-16 surrogates, which strings can't hold
+	surrogateMin = 0xD800
+	surrogateMax = 0xDFFF
+)
+
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectBucket maps a bucket of the S3-compatible gateway onto the catalogue.
+// Objects are created in the bucket's tenant and, when their key has a
+// directory, linked to the entity of EntityType named by its first segment,
+// e.g. "cust-42/statement.pdf".
+type ObjectBucket struct {
+	Name       string
+	TenantID   string
+	EntityType string
+}
+
+// ParseObjectBuckets parses a comma-separated list of bucket mappings of the
+// form name=tenant:entity_type, where the tenant and entity type may be empty
+func ParseObjectBuckets(spec string) ([]ObjectBucket, error) {
+	var buckets []ObjectBucket
+	for _, entry := range strings.Split(spec, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		name, mapping, _ := strings.Cut(entry, "=")
+		tenantID, entityType, _ := strings.Cut(mapping, ":")
+		if name == "" {
+			return nil, fmt.Errorf("%w: bucket mapping %q has no name", ErrInvalidInput, entry)
+		}
+		buckets = append(buckets, ObjectBucket{Name: name, TenantID: tenantID, EntityType: entityType})
+	}
+	return buckets, nil
+}
+
+// objectFilter selects the content written to a bucket, optionally under one key
+func objectFilter(bucket ObjectBucket, key string) model.ContentFilter {
+	filter := model.ContentFilter{
+		TenantID: bucket.TenantID,
+		Metadata: map[string]interface{}{MetadataObjectBucket: bucket.Name},
+	}
+	if key != "" {
+		filter.Metadata[MetadataObjectKey] = key
+	}
+	return filter
+}
+
+// ObjectKey returns the gateway key of a content item, empty if it wasn't written through the gateway
+func ObjectKey(content *model.Content) string {
+	key, _ := content.Metadata[MetadataObjectKey].(string)
+	return key
+}
+
+// PutObjectInput represents an object written through the gateway
+type PutObjectInput struct {
+	Key       string
+	MIMEType  string
+	Size      int64
+	Data      io.Reader
+	CreatedBy string
+}
+
+// PutObject stores an object as content and replaces any object previously
+// stored under the same key
+func (s *ContentService) PutObject(ctx context.Context, bucket ObjectBucket, input PutObjectInput) (*model.Content, error) {
+	if input.Key == "" || strings.HasSuffix(input.Key, "/") {
+		return nil, fmt.Errorf("%w: invalid object key %q", ErrInvalidInput, input.Key)
+	}
+	previous, err := s.findObjects(ctx, bucket, input.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	createInput := CreateContentInput{
+		TenantID:  bucket.TenantID,
+		FileName:  path.Base(input.Key),
+		MIMEType:  input.MIMEType,
+		FileSize:  input.Size,
+		Data:      input.Data,
+		CreatedBy: input.CreatedBy,
+		Source:    SourceObjectGateway,
+		Metadata: model.Metadata{
+			MetadataObjectBucket: bucket.Name,
+			MetadataObjectKey:    input.Key,
+		},
+	}
+	if entityID, _, ok := strings.Cut(input.Key, "/"); ok && bucket.EntityType != "" && entityID != "" {
+		createInput.EntityType = bucket.EntityType
+		createInput.EntityID = entityID
+	}
+
+	content, err := s.CreateContent(ctx, createInput)
+	if err != nil {
+		return nil, err
+	}
+
+	// Like S3, the last write of a key wins
+	for _, old := range previous {
+		if _, err := s.DeleteContent(ctx, old.ID, DeleteContentOptions{Policy: DeleteForce}); err != nil && !errors.Is(err, ErrContentNotFound) {
+			return nil, err
+		}
+	}
+	return content, nil
+}
+
+// GetObject retrieves the content stored under a key of a bucket
+func (s *ContentService) GetObject(ctx context.Context, bucket ObjectBucket, key string) (*model.Content, error) {
+	objects, err := s.findObjects(ctx, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, ErrObjectNotFound
+	}
+	return objects[0], nil
+}
+
+// DeleteObject deletes the content stored under a key of a bucket
+func (s *ContentService) DeleteObject(ctx context.Context, bucket ObjectBucket, key string) error {
+	objects, err := s.findObjects(ctx, bucket, key)
+	if err != nil {
+		return err
+	}
+	if len(objects) == 0 {
+		return ErrObjectNotFound
+	}
+	for _, object := range objects {
+		if _, err := s.DeleteContent(ctx, object.ID, DeleteContentOptions{Policy: DeleteForce}); err != nil && !errors.Is(err, ErrContentNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// findObjects returns the content stored under a key, newest first
+func (s *ContentService) findObjects(ctx context.Context, bucket ObjectBucket, key string) ([]*model.Content, error) {
+	objects, _, err := s.repo.ListContent(ctx, objectFilter(bucket, key), 0, maxListObjects)
+	return objects, err
+}
+
+// ListObjectsInput selects a page of the objects of a bucket, as ListObjectsV2 does
+type ListObjectsInput struct {
+	Prefix     string
+	Delimiter  string
+	StartAfter string // Only keys after this one, also used to continue a listing
+	MaxKeys    int
+}
+
+// ListObjectsResult is a page of the objects of a bucket in key order
+type ListObjectsResult struct {
+	Objects        []*model.Content
+	CommonPrefixes []string
+	IsTruncated    bool
+	NextStartAfter string // Key or common prefix to continue after when truncated
+}
+
+// ListObjects lists the objects of a bucket in key order. With a delimiter,
+// keys sharing the part of their name up to the first delimiter after the
+// prefix are rolled up into a common prefix.
+func (s *ContentService) ListObjects(ctx context.Context, bucket ObjectBucket, input ListObjectsInput) (*ListObjectsResult, error) {
+	if input.MaxKeys <= 0 || input.MaxKeys > maxListObjects {
+		input.MaxKeys = maxListObjects
+	}
+
+	// Keys up to after were listed already, as were those under rolledUp
+	after, rolledUp, from := input.StartAfter, "", input.StartAfter
+	if input.Delimiter != "" && strings.HasSuffix(input.StartAfter, input.Delimiter) {
+		// A common prefix ended the previous page
+		rolledUp = input.StartAfter
+		if end, ok := prefixEnd(rolledUp); ok {
+			from = end
+		}
+	}
+
+	result := &ListObjectsResult{}
+	filter := objectFilter(bucket, "")
+	entries := 0
+	for {
+		objects, err := s.repo.ListContentByMetadataKey(ctx, filter, MetadataObjectKey, input.Prefix, from, exportPageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		skipped := false
+		for _, object := range objects {
+			key := ObjectKey(object)
+			if key <= after || rolledUp != "" && strings.HasPrefix(key, rolledUp) {
+				continue
+			}
+
+			commonPrefix := ""
+			if input.Delimiter != "" {
+				if i := strings.Index(key[len(input.Prefix):], input.Delimiter); i >= 0 {
+					commonPrefix = key[:len(input.Prefix)+i+len(input.Delimiter)]
+				}
+			}
+
+			if entries == input.MaxKeys {
+				result.IsTruncated = true
+				return result, nil
+			}
+			entries++
+			if commonPrefix == "" {
+				result.Objects = append(result.Objects, object)
+				result.NextStartAfter, after = key, key
+				continue
+			}
+			result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix)
+			result.NextStartAfter, after, rolledUp = commonPrefix, commonPrefix, commonPrefix
+
+			// Continue after the keys under the common prefix rather than reading them
+			if end, ok := prefixEnd(commonPrefix); ok {
+				from, skipped = end, true
+				break
+			}
+		}
+
+		if skipped {
+			continue
+		}
+		if len(objects) < exportPageSize || ObjectKey(objects[len(objects)-1]) == from {
+			break
+		}
+		from = ObjectKey(objects[len(objects)-1])
+	}
+	result.NextStartAfter = ""
+	return result, nil
+}
+
+// prefixEnd returns the smallest string that sorts after every string
+// starting with prefix, if there's one
+func prefixEnd(prefix string) (string, bool) {
+	if !utf8.ValidString(prefix) {
+		return "", false
+	}
+	runes := []rune(prefix)
+	for i := len(runes) - 1; i >= 0; i-- {
+		if runes[i] < utf8.MaxRune {
+			runes[i]++
+			if runes[i] == surrogateMin {
+				runes[i] = surrogateMax + 1
+			}
+			return string(runes[:i+1]), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/livefire2015/simple-contents/repository/memory"
+)
+
+func TestListObjectsPages(t *testing.T) {
+	ctx := context.Background()
+	s := newTestInstance(memory.NewMemoryRepository())
+	bucket := ObjectBucket{Name: "files"}
+	for _, key := range []string{"a.txt", "docs/1", "docs/2", "docs/sub/3", "photos/x", "z.txt"} {
+		if _, err := s.PutObject(ctx, bucket, PutObjectInput{Key: key, MIMEType: "text/plain", Size: 1, Data: strings.NewReader("x")}); err != nil {
+			t.Fatalf("PutObject(%q) error = %v", key, err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		input ListObjectsInput
+		want  [][]string
+	}{
+		{
+			name:  "delimiter",
+			input: ListObjectsInput{Delimiter: "/", MaxKeys: 2},
+			want:  [][]string{{"a.txt", "docs/"}, {"photos/", "z.txt"}},
+		},
+		{
+			name:  "prefix and delimiter",
+			input: ListObjectsInput{Prefix: "docs/", Delimiter: "/", MaxKeys: 2},
+			want:  [][]string{{"docs/1", "docs/2"}, {"docs/sub/"}},
+		},
+		{
+			name:  "no delimiter",
+			input: ListObjectsInput{MaxKeys: 4},
+			want:  [][]string{{"a.txt", "docs/1", "docs/2", "docs/sub/3"}, {"photos/x", "z.txt"}},
+		},
+		{
+			name:  "start after",
+			input: ListObjectsInput{StartAfter: "docs/2"},
+			want:  [][]string{{"docs/sub/3", "photos/x", "z.txt"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := tt.input
+			var pages [][]string
+			for {
+				result, err := s.ListObjects(ctx, bucket, input)
+				if err != nil {
+					t.Fatalf("ListObjects() error = %v", err)
+				}
+				var page []string
+				for _, object := range result.Objects {
+					page = append(page, ObjectKey(object))
+				}
+				page = append(page, result.CommonPrefixes...)
+				slices.Sort(page)
+				pages = append(pages, page)
+				if !result.IsTruncated {
+					break
+				}
+				input.StartAfter = result.NextStartAfter
+			}
+			if !slices.EqualFunc(pages, tt.want, slices.Equal) {
+				t.Errorf("pages = %q, want %q", pages, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrefixEnd(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+		wantOK bool
+	}{
+		{prefix: "docs/", want: "docs0", wantOK: true},
+		{prefix: "a\U0010FFFF", want: "b", wantOK: true},
+		{prefix: "x\uD7FF", want: "x\uE000", wantOK: true},
+		{prefix: "\U0010FFFF", wantOK: false},
+		{prefix: "\xff", wantOK: false},
+	}
+	for _, tt := range tests {
+		got, ok := prefixEnd(tt.prefix)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("prefixEnd(%q) = %q, %v, want %q, %v", tt.prefix, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+)
+
+// TransferOwnershipInput selects the content of a tenant handed over from one
+// principal to another, e.g. when an employee leaves
+type TransferOwnershipInput struct {
+	TenantID      string      `json:"tenant_id" validate:"max=63"` // Empty for untenanted content
+	From          string      `json:"from" validate:"required,max=255"`
+	To            string      `json:"to" validate:"required,max=255"`
+	IDs           []uuid.UUID `json:"ids" validate:"max=1000"` // Only these items, all content of From if empty
+	TransferredBy string      `json:"-" validate:"max=255"`
+}
+
+// TransferOwnershipResult lists the content handed over
+type TransferOwnershipResult struct {
+	IDs         []uuid.UUID `json:"ids"`
+	Transferred int         `json:"transferred"`
+}
+
+// TransferOwnership makes To the creator of the content From created in a
+// tenant, in a single repository call. Content created by someone else, or
+// in another tenant, is left out. Each transferred item is written to the
+// audit log with the principal who transferred it.
+func (s *ContentService) TransferOwnership(ctx context.Context, input TransferOwnershipInput) (*TransferOwnershipResult, error) {
+	if err := validateInput(input); err != nil {
+		return nil, err
+	}
+	if input.From == input.To {
+		return nil, fmt.Errorf("%w: from and to are the same principal", ErrInvalidInput)
+	}
+
+	ids, err := s.repo.TransferOwnership(ctx, input.TenantID, input.From, input.To, input.IDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		s.audit(ctx, model.AuditEvent{Action: "content.transfer_ownership", Subject: id.String(), Actor: input.TransferredBy, Detail: input.From + " -> " + input.To},
+			"Content %s transferred from %q to %q by %q", id, input.From, input.To, input.TransferredBy)
+	}
+	return &TransferOwnershipResult{IDs: ids, Transferred: len(ids)}, nil
+}
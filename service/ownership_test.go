@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository/memory"
+)
+
+func TestTransferOwnership(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   func(ids map[string]uuid.UUID) TransferOwnershipInput
+		want    []string // Items transferred
+		wantErr error
+	}{
+		{
+			name: "all content of the tenant",
+			input: func(map[string]uuid.UUID) TransferOwnershipInput {
+				return TransferOwnershipInput{TenantID: "acme", From: "alice", To: "bob"}
+			},
+			want: []string{"alice-1", "alice-2"},
+		},
+		{
+			name: "listed items only",
+			input: func(ids map[string]uuid.UUID) TransferOwnershipInput {
+				return TransferOwnershipInput{TenantID: "acme", From: "alice", To: "bob", IDs: []uuid.UUID{ids["alice-2"], ids["carol"], ids["alice-other-tenant"]}}
+			},
+			want: []string{"alice-2"},
+		},
+		{
+			name: "same principal",
+			input: func(map[string]uuid.UUID) TransferOwnershipInput {
+				return TransferOwnershipInput{From: "alice", To: "alice"}
+			},
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "no recipient",
+			input:   func(map[string]uuid.UUID) TransferOwnershipInput { return TransferOwnershipInput{From: "alice"} },
+			wantErr: ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := memory.NewMemoryRepository()
+			s := newTestInstance(repo)
+			s.EnableAuditLog(repo)
+
+			ids := make(map[string]uuid.UUID)
+			for name, content := range map[string]*model.Content{
+				"alice-1":            {TenantID: "acme", CreatedBy: "alice"},
+				"alice-2":            {TenantID: "acme", CreatedBy: "alice"},
+				"carol":              {TenantID: "acme", CreatedBy: "carol"},
+				"alice-other-tenant": {TenantID: "globex", CreatedBy: "alice"},
+			} {
+				content.FileName = name + ".pdf"
+				if err := repo.CreateContent(ctx, content); err != nil {
+					t.Fatal(err)
+				}
+				ids[name] = content.ID
+			}
+
+			result, err := s.TransferOwnership(ctx, tt.input(ids))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("TransferOwnership() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var transferred []string
+			for name, id := range ids {
+				content, err := repo.GetContentByID(ctx, id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if content.CreatedBy == "bob" {
+					transferred = append(transferred, name)
+				}
+			}
+			sort.Strings(transferred)
+			if result.Transferred != len(tt.want) || len(transferred) != len(tt.want) {
+				t.Fatalf("transferred %v (reported %d), want %v", transferred, result.Transferred, tt.want)
+			}
+			for i := range tt.want {
+				if transferred[i] != tt.want[i] {
+					t.Errorf("transferred %v, want %v", transferred, tt.want)
+				}
+			}
+
+			// Every transfer is in the audit trail
+			if pruned, _ := s.PruneAuditLog(ctx, time.Now().Add(time.Hour)); pruned != int64(len(tt.want)) {
+				t.Errorf("audit events = %d, want %d", pruned, len(tt.want))
+			}
+		})
+	}
+}
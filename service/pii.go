@@ -0,0 +1,113 @@
+package service
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/livefire2015/simple-contents/model"
+)
+
+// ErrPIIRestricted is returned when flagged content is accessed without an elevated scope
+var ErrPIIRestricted = errors.New("content contains PII and requires an elevated scope")
+
+const (
+	// MetadataPIIDetected is set to true on content in which PII was found
+	MetadataPIIDetected = "pii_detected"
+	// MetadataPIIFlags lists the kinds of PII found, e.g. "ssn", "pan", "email"
+	MetadataPIIFlags = "pii_flags"
+)
+
+// PIIConfig controls PII detection on uploaded content
+type PIIConfig struct {
+	BlockPresignedURLs bool // Refuse presigned links to flagged content without an elevated scope
+}
+
+// piiPattern detects one kind of PII in text
+type piiPattern struct {
+	flag    string
+	pattern *regexp.Regexp
+	valid   func(match []byte) bool // Optional check that rules out false positives
+}
+
+var piiPatterns = []piiPattern{
+	{
+		flag:    "ssn",
+		pattern: regexp.MustCompile(`\b(?:00[1-9]|0[1-9]\d|[1-578]\d{2}|6[0-57-9]\d|66[0-57-9])-(?:0[1-9]|[1-9]\d)-(?:000[1-9]|00[1-9]\d|0[1-9]\d{2}|[1-9]\d{3})\b`),
+	},
+	{
+		flag:    "pan",
+		pattern: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		valid:   luhnValid,
+	},
+	{
+		flag:    "email",
+		pattern: regexp.MustCompile(`(?i)\b[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}\b`),
+	},
+}
+
+// luhnValid reports whether the digits in match form a card number with a valid Luhn checksum
+func luhnValid(match []byte) bool {
+	sum, digits := 0, 0
+	for i := len(match) - 1; i >= 0; i-- {
+		c := match[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if digits%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+	}
+	return digits >= 13 && digits <= 19 && sum%10 == 0
+}
+
+// detectPII returns the kinds of PII found in text
+func detectPII(text []byte) []string {
+	var flags []string
+	for _, p := range piiPatterns {
+		for _, match := range p.pattern.FindAll(text, -1) {
+			if p.valid == nil || p.valid(match) {
+				flags = append(flags, p.flag)
+				break
+			}
+		}
+	}
+	return flags
+}
+
+// EnablePIIDetection scans the text of uploaded content for PII and flags it in metadata
+func (s *ContentService) EnablePIIDetection(config PIIConfig) {
+	s.pii = &config
+}
+
+// scanPII sets the compliance flags of content from the leading data of text-like content
+func (s *ContentService) scanPII(content *model.Content, sample []byte) {
+	if s.pii == nil || !isTextLike(content.MIMEType) {
+		return
+	}
+
+	flags := detectPII(sample)
+	if len(flags) == 0 {
+		return
+	}
+
+	if content.Metadata == nil {
+		content.Metadata = make(model.Metadata)
+	}
+	content.Metadata[MetadataPIIDetected] = true
+	content.Metadata[MetadataPIIFlags] = flags
+}
+
+// piiRestricted reports whether access to flagged content must be refused
+func (s *ContentService) piiRestricted(content *model.Content, elevated bool) bool {
+	if s.pii == nil || !s.pii.BlockPresignedURLs || elevated {
+		return false
+	}
+	detected, _ := content.Metadata[MetadataPIIDetected].(bool)
+	return detected
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+var ErrPinNotFound = errors.New("pin not found")
+
+// PinContent stars a content item for a principal. Pinning is idempotent.
+func (s *ContentService) PinContent(ctx context.Context, principal string, id uuid.UUID) (*model.Pin, error) {
+	if principal == "" {
+		return nil, ErrInvalidInput
+	}
+
+	// Only existing content can be pinned
+	if _, err := s.GetContent(ctx, id); err != nil {
+		return nil, err
+	}
+
+	pin := &model.Pin{Principal: principal, ContentID: id}
+	if err := s.repo.PinContent(ctx, pin); err != nil {
+		return nil, err
+	}
+	return pin, nil
+}
+
+// UnpinContent removes the star of a principal from a content item
+func (s *ContentService) UnpinContent(ctx context.Context, principal string, id uuid.UUID) error {
+	if principal == "" {
+		return ErrInvalidInput
+	}
+
+	if err := s.repo.UnpinContent(ctx, principal, id); err != nil {
+		if errors.Is(err, repository.ErrPinNotFound) {
+			return ErrPinNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package service
+
+import "context"
+
+type principalKey struct{}
+
+// WithPrincipal marks ctx as acting for an authenticated principal, who is
+// recorded as the creator of the content and associations made with it,
+// whatever the input says
+func WithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the principal ctx was marked with by WithPrincipal,
+// or an empty string
+func PrincipalFrom(ctx context.Context) string {
+	principal, _ := ctx.Value(principalKey{}).(string)
+	return principal
+}
+
+// principalOr returns the principal of ctx, or fallback for calls made
+// without one, such as those of background jobs
+func principalOr(ctx context.Context, fallback string) string {
+	if principal := PrincipalFrom(ctx); principal != "" {
+		return principal
+	}
+	return fallback
+}
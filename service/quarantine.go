@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+const (
+	// MetadataQuarantineReleasedBy holds who released quarantined content as a false positive
+	MetadataQuarantineReleasedBy = "quarantine_released_by"
+	// MetadataQuarantineReleasedAt holds when quarantined content was released
+	MetadataQuarantineReleasedAt = "quarantine_released_at"
+
+	// scanReleased marks infected content that was reviewed as a false positive
+	scanReleased = "released"
+)
+
+var (
+	ErrContentQuarantined = errors.New("content is quarantined")
+	ErrNotQuarantined     = errors.New("content is not quarantined")
+)
+
+// IsQuarantined reports whether content was found infected and not released since.
+// The data of quarantined content is only served through the quarantine review.
+func IsQuarantined(content *model.Content) bool {
+	verdict, _ := content.Metadata[MetadataVirusScan].(string)
+	return verdict == scanInfected
+}
+
+// ListQuarantined retrieves a page of the quarantined content of every tenant
+func (s *ContentService) ListQuarantined(ctx context.Context, page, pageSize int) (*ListContentResult, error) {
+	filter := model.ContentFilter{
+		Metadata: map[string]interface{}{MetadataVirusScan: scanInfected},
+	}
+	return s.SearchContent(ctx, filter, page, pageSize)
+}
+
+// getQuarantined retrieves a content item that must be quarantined
+func (s *ContentService) getQuarantined(ctx context.Context, id uuid.UUID) (*model.Content, error) {
+	content, err := s.GetContent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !IsQuarantined(content) {
+		return nil, ErrNotQuarantined
+	}
+	return content, nil
+}
+
+// GetQuarantinedData retrieves the data of quarantined content for review.
+// The reviewer must acknowledge that the data is infected.
+func (s *ContentService) GetQuarantinedData(ctx context.Context, id uuid.UUID, reviewer string, acknowledged bool) (io.ReadCloser, *model.Content, error) {
+	if reviewer == "" {
+		return nil, nil, fmt.Errorf("%w: reviewer is required", ErrInvalidInput)
+	}
+	if !acknowledged {
+		return nil, nil, fmt.Errorf("%w: downloading quarantined content must be acknowledged", ErrInvalidInput)
+	}
+
+	content, err := s.getQuarantined(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := s.storage.Download(storageContext(ctx, content), content.StoragePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.auditQuarantine(ctx, "download", content, reviewer)
+	return data, content, nil
+}
+
+// ReleaseQuarantined clears content found infected as a false positive, so it
+// is served again
+func (s *ContentService) ReleaseQuarantined(ctx context.Context, id uuid.UUID, reviewer string) (*model.Content, error) {
+	if reviewer == "" {
+		return nil, fmt.Errorf("%w: reviewer is required", ErrInvalidInput)
+	}
+
+	content, err := s.getQuarantined(repository.ReadFromPrimary(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+
+	content.Metadata[MetadataVirusScan] = scanReleased
+	content.Metadata[MetadataQuarantineReleasedBy] = reviewer
+	content.Metadata[MetadataQuarantineReleasedAt] = s.now().Format(time.RFC3339)
+	if err := s.repo.UpdateContent(ctx, content); err != nil {
+		return nil, err
+	}
+
+	s.auditQuarantine(ctx, "release", content, reviewer)
+	return content, nil
+}
+
+// DestroyQuarantined deletes quarantined content and its data, whatever links
+// it or retains it
+func (s *ContentService) DestroyQuarantined(ctx context.Context, id uuid.UUID, reviewer string) error {
+	if reviewer == "" {
+		return fmt.Errorf("%w: reviewer is required", ErrInvalidInput)
+	}
+
+	content, err := s.getQuarantined(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.forceDeleteContent(ctx, content); err != nil {
+		return err
+	}
+
+	s.auditQuarantine(ctx, "destroy", content, reviewer)
+	return nil
+}
+
+// quarantineActions maps the audited reviews of quarantined content to how they're logged
+var quarantineActions = map[string]string{
+	"download": "downloaded",
+	"release":  "released",
+	"destroy":  "destroyed",
+}
+
+// auditQuarantine records a review of quarantined content
+func (s *ContentService) auditQuarantine(ctx context.Context, action string, content *model.Content, reviewer string) {
+	signature, _ := content.Metadata[MetadataVirusSignature].(string)
+	s.audit(ctx, model.AuditEvent{Action: "quarantine." + action, Subject: content.ID.String(), Actor: reviewer, Detail: signature},
+		"quarantined content %s (%s) %s by %q", content.ID, signature, quarantineActions[action], reviewer)
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/livefire2015/simple-contents/model"
+)
+
+// ErrQuotaExceeded is matched by every QuotaExceededError
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// DefaultQuotaWarnPercents are the warning thresholds of tenants that don't configure their own
+var DefaultQuotaWarnPercents = []int{80, 90}
+
+// QuotaExceededError is returned when storing data would take a tenant over its hard quota
+type QuotaExceededError struct {
+	TenantID       string
+	QuotaBytes     int64
+	UsedBytes      int64
+	RequestedBytes int64
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("quota exceeded for tenant %s: %d of %d bytes used, %d requested",
+		e.TenantID, e.UsedBytes, e.QuotaBytes, e.RequestedBytes)
+}
+
+// Unwrap makes errors.Is(err, ErrQuotaExceeded) match
+func (e *QuotaExceededError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// reserveQuota adds size to the usage of a tenant, failing with a
+// QuotaExceededError if that would exceed its hard quota
+func (s *ContentService) reserveQuota(ctx context.Context, tenantID string, size int64) error {
+	if tenantID == "" || s.tenants == nil || size <= 0 {
+		return nil
+	}
+
+	tenant, err := s.tenants.GetTenant(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	// Adding first makes concurrent uploads see each other's reservations
+	used, err := s.tenants.repo.AddTenantUsage(ctx, tenantID, size)
+	if err != nil {
+		return err
+	}
+
+	if tenant.QuotaBytes > 0 && used > tenant.QuotaBytes {
+		s.releaseQuota(ctx, tenantID, size)
+		return &QuotaExceededError{
+			TenantID:       tenantID,
+			QuotaBytes:     tenant.QuotaBytes,
+			UsedBytes:      used - size,
+			RequestedBytes: size,
+		}
+	}
+
+	s.checkQuotaWarning(tenant, used-size, used)
+	return nil
+}
+
+// releaseQuota subtracts size from the usage of a tenant
+func (s *ContentService) releaseQuota(ctx context.Context, tenantID string, size int64) {
+	if tenantID == "" || s.tenants == nil || size <= 0 {
+		return
+	}
+
+	if _, err := s.tenants.repo.AddTenantUsage(ctx, tenantID, -size); err != nil {
+		log.Printf("Failed to release %d bytes of quota for tenant %s: %v", size, tenantID, err)
+	}
+}
+
+// reconcileQuota corrects the usage of a tenant once the stored size of data
+// is known, after reserved bytes were reserved for it. It fails like
+// reserveQuota if the data turned out larger and doesn't fit the quota.
+func (s *ContentService) reconcileQuota(ctx context.Context, tenantID string, reserved, stored int64) error {
+	delta := stored - max(reserved, 0)
+	if delta > 0 {
+		return s.reserveQuota(ctx, tenantID, delta)
+	}
+	s.releaseQuota(ctx, tenantID, -delta)
+	return nil
+}
+
+// checkQuotaWarning publishes a warning when usage crosses a threshold of the quota
+func (s *ContentService) checkQuotaWarning(tenant *model.Tenant, before, after int64) {
+	if tenant.QuotaBytes <= 0 {
+		return
+	}
+
+	percents := tenant.QuotaWarnPercents
+	if len(percents) == 0 {
+		percents = DefaultQuotaWarnPercents
+	}
+
+	// Only the highest threshold crossed by this change is reported
+	crossed := 0
+	for _, percent := range percents {
+		threshold := tenant.QuotaBytes * int64(percent) / 100
+		if before < threshold && after >= threshold {
+			crossed = max(crossed, percent)
+		}
+	}
+	if crossed == 0 {
+		return
+	}
+
+	s.events.Publish(Event{
+		Type:        EventQuotaWarning,
+		Timestamp:   s.now(),
+		TenantID:    tenant.ID,
+		UsedBytes:   after,
+		QuotaBytes:  tenant.QuotaBytes,
+		WarnPercent: crossed,
+	})
+}
+
+// RecalculateUsage recomputes the stored bytes of a tenant from its content,
+// correcting drift from failed deletes or restores
+func (s *ContentService) RecalculateUsage(ctx context.Context, tenantID string) (*model.Tenant, error) {
+	if s.tenants == nil {
+		return nil, ErrTenantNotFound
+	}
+
+	tenant, err := s.tenants.GetTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	filter := model.ContentFilter{TenantID: tenantID}
+	for offset := 0; ; offset += exportPageSize {
+		items, _, err := s.repo.ListContent(ctx, filter, offset, exportPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, content := range items {
+			total += max(content.FileSize, 0)
+		}
+		if len(items) < exportPageSize {
+			break
+		}
+	}
+
+	// The recount replaces the usage outright, so reservations made while
+	// counting aren't added on top of content the count already includes
+	if err := s.tenants.repo.SetTenantUsage(ctx, tenantID, total); err != nil {
+		return nil, err
+	}
+	tenant.UsedBytes = total
+
+	return tenant, nil
+}
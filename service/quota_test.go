@@ -0,0 +1,112 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/livefire2015/simple-contents/repository/memory"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+)
+
+// pngChunk encodes a PNG chunk; the sanitizer doesn't check CRCs
+func pngChunk(chunkType string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, chunkType...)
+	chunk = append(chunk, data...)
+	return append(chunk, 0, 0, 0, 0)
+}
+
+func TestQuotaChargesStoredSize(t *testing.T) {
+	var png []byte
+	png = append(png, pngSignature...)
+	png = append(png, pngChunk("IHDR", make([]byte, 13))...)
+	png = append(png, pngChunk("tEXt", bytes.Repeat([]byte("x"), 1000))...)
+	png = append(png, pngChunk("IEND", nil)...)
+
+	tests := []struct {
+		name     string
+		sanitize bool
+		fileSize int64
+	}{
+		{name: "stored as uploaded", sanitize: false, fileSize: int64(len(png))},
+		{name: "sanitized", sanitize: true, fileSize: int64(len(png))},
+		{name: "streamed and sanitized", sanitize: true, fileSize: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := memory.NewMemoryRepository()
+			tenants := NewTenantService(repo)
+			if _, err := tenants.CreateTenant(ctx, TenantInput{ID: "acme", Name: "Acme"}); err != nil {
+				t.Fatal(err)
+			}
+			s := NewContentService(repo, memorystorage.NewMemoryStorage())
+			s.ConfigureTenants(tenants)
+			if tt.sanitize {
+				s.EnableImageSanitization(ImageSanitizationConfig{})
+			}
+
+			content, err := s.CreateContent(ctx, CreateContentInput{
+				TenantID: "acme",
+				FileName: "photo.png",
+				MIMEType: "image/png",
+				FileSize: tt.fileSize,
+				Data:     bytes.NewReader(png),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.sanitize && content.FileSize >= int64(len(png)) {
+				t.Fatalf("sanitized size %d, want less than %d", content.FileSize, len(png))
+			}
+
+			tenant, err := tenants.GetTenant(ctx, "acme")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tenant.UsedBytes != content.FileSize {
+				t.Errorf("used bytes = %d, want the stored size %d", tenant.UsedBytes, content.FileSize)
+			}
+
+			if _, err := s.DeleteContent(ctx, content.ID, DeleteContentOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			if tenant, _ = tenants.GetTenant(ctx, "acme"); tenant.UsedBytes != 0 {
+				t.Errorf("used bytes after delete = %d, want 0", tenant.UsedBytes)
+			}
+		})
+	}
+}
+
+func TestRecalculateUsage(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepository()
+	tenants := NewTenantService(repo)
+	if _, err := tenants.CreateTenant(ctx, TenantInput{ID: "acme", Name: "Acme"}); err != nil {
+		t.Fatal(err)
+	}
+	s := NewContentService(repo, memorystorage.NewMemoryStorage())
+	s.ConfigureTenants(tenants)
+
+	for _, data := range []string{"first", "second"} {
+		if _, err := s.CreateContent(ctx, CreateContentInput{TenantID: "acme", FileName: data + ".txt", MIMEType: "text/plain", FileSize: int64(len(data)), Data: bytes.NewReader([]byte(data))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Drift, e.g. from a failed delete
+	if _, err := repo.AddTenantUsage(ctx, "acme", 1000); err != nil {
+		t.Fatal(err)
+	}
+
+	tenant, err := s.RecalculateUsage(ctx, "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := tenants.GetTenant(ctx, "acme")
+	if tenant.UsedBytes != 11 || stored.UsedBytes != 11 {
+		t.Errorf("recalculated usage = %d, stored %d, want 11", tenant.UsedBytes, stored.UsedBytes)
+	}
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+	"github.com/livefire2015/simple-contents/storage"
+)
+
+var (
+	ErrReferenceContent     = errors.New("content is a reference without stored data")
+	ErrReferenceUnreachable = errors.New("reference URL is not reachable")
+)
+
+const (
+	// referenceCheckTimeout bounds a single reachability check
+	referenceCheckTimeout = 15 * time.Second
+	// defaultReferenceCheckAge is how long a check stays current by default
+	defaultReferenceCheckAge = 24 * time.Hour
+)
+
+// CreateReferenceInput represents a document catalogued by the URL of the
+// system of record that keeps its data
+type CreateReferenceInput struct {
+	TenantID    string         `validate:"max=63"`
+	URL         string         `validate:"required,url,max=2048"`
+	FileName    string         `validate:"max=255"`   // Defaults to the last segment of the URL path
+	Description string         `validate:"max=20000"` // Markdown
+	MIMEType    string         `validate:"max=255"`   // application/octet-stream if empty
+	CreatedBy   string         `validate:"max=255"`   // Ignored when the context carries a principal
+	EntityType  string         `validate:"max=100"`
+	EntityID    string         `validate:"max=255"`
+	Source      string         `validate:"max=100"`
+	ExternalID  string         `validate:"max=255"` // Unique per tenant and source
+	Metadata    model.Metadata `validate:"max=1000"`
+	Verify      bool           // Check that the URL can be reached before creating the reference
+}
+
+// CreateReference catalogues a document whose data stays in another system.
+// No data is stored, so the reference is done at once, isn't charged to the
+// tenant's quota and isn't classified or scanned.
+func (s *ContentService) CreateReference(ctx context.Context, input CreateReferenceInput) (*model.Content, error) {
+	if err := validateInput(input); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(input.URL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid reference URL", ErrInvalidInput)
+	}
+	if err := checkRemoteScheme(u); err != nil {
+		return nil, err
+	}
+	if input.FileName == "" {
+		input.FileName = path.Base(u.Path)
+	}
+	fileName, err := SanitizeFileName(input.FileName)
+	if err != nil {
+		return nil, err
+	}
+	if input.MIMEType == "" {
+		input.MIMEType = "application/octet-stream"
+	}
+	input.CreatedBy = principalOr(ctx, input.CreatedBy)
+
+	if input.TenantID != "" && s.tenants != nil {
+		if _, err := s.tenants.GetTenant(ctx, input.TenantID); err != nil {
+			return nil, err
+		}
+	}
+	var entityTypes []string
+	if input.EntityType != "" && input.EntityID != "" {
+		if err := s.checkAssociation(ctx, input.EntityType, input.EntityID, "", input.MIMEType); err != nil {
+			return nil, err
+		}
+		entityTypes = append(entityTypes, input.EntityType)
+	}
+	if err := s.validateMetadata(input.Metadata, input.Source, entityTypes...); err != nil {
+		return nil, err
+	}
+	if err := s.checkExternalID(ctx, input.TenantID, input.Source, input.ExternalID); err != nil {
+		return nil, err
+	}
+
+	content := &model.Content{
+		ID:           s.ids.NewID(),
+		TenantID:     input.TenantID,
+		Status:       model.StatusDone,
+		Kind:         model.KindReference,
+		ReferenceURL: u.String(),
+		FileName:     fileName,
+		Description:  input.Description,
+		MIMEType:     input.MIMEType,
+		FileSize:     storage.UnknownSize,
+		CreatedBy:    input.CreatedBy,
+		Source:       input.Source,
+		ExternalID:   input.ExternalID,
+		Metadata:     input.Metadata,
+	}
+	if input.Verify {
+		if err := s.remote.reachable(ctx, content.ReferenceURL); err != nil {
+			return nil, err
+		}
+		checkedAt := s.now()
+		content.ReferenceStatus = model.ReferenceReachable
+		content.ReferenceCheckedAt = &checkedAt
+	}
+
+	var association *model.ContentEntityAssociation
+	if input.EntityType != "" && input.EntityID != "" {
+		association = s.newAssociation(AssociateContentInput{
+			ContentID:    content.ID,
+			EntityType:   input.EntityType,
+			EntityID:     input.EntityID,
+			AssociatedBy: input.CreatedBy,
+		})
+	}
+
+	err = s.repo.WithTx(ctx, func(tx repository.ContentRepository) error {
+		if err := tx.CreateContent(ctx, content); err != nil {
+			return err
+		}
+		if association != nil {
+			return createAssociation(ctx, tx, association)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, storeError(err, content)
+	}
+
+	if association != nil {
+		s.publishEntityEvent(EventContentAdded, association, content)
+	}
+	return content, nil
+}
+
+// ReferenceCheckResult summarizes a run of reachability checks
+type ReferenceCheckResult struct {
+	Checked     int `json:"checked"`
+	Unreachable int `json:"unreachable"`
+}
+
+// CheckReferences checks that the URLs of references not checked within
+// olderThan can still be reached, recording the outcome on each
+func (s *ContentService) CheckReferences(ctx context.Context, olderThan time.Duration) (*ReferenceCheckResult, error) {
+	if olderThan <= 0 {
+		return nil, fmt.Errorf("%w: reference checks need a positive age", ErrInvalidInput)
+	}
+	cutoff := s.now().Add(-olderThan)
+
+	var ids []uuid.UUID
+	if err := s.repo.ListContentStream(ctx, model.ContentFilter{Kind: model.KindReference}, func(content *model.Content) error {
+		if content.ReferenceCheckedAt == nil || content.ReferenceCheckedAt.Before(cutoff) {
+			ids = append(ids, content.ID)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	result := &ReferenceCheckResult{}
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		content, err := s.checkReference(ctx, id)
+		if errors.Is(err, ErrContentNotFound) {
+			continue
+		} else if err != nil {
+			return result, fmt.Errorf("failed to check reference %s: %w", id, err)
+		}
+		result.Checked++
+		if content.ReferenceStatus == model.ReferenceUnreachable {
+			result.Unreachable++
+		}
+	}
+	return result, nil
+}
+
+// checkReference checks the URL of a reference and records the outcome
+func (s *ContentService) checkReference(ctx context.Context, id uuid.UUID) (*model.Content, error) {
+	content, err := s.getContentForUpdate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	status := model.ReferenceReachable
+	if err := s.remote.reachable(ctx, content.ReferenceURL); err != nil {
+		status = model.ReferenceUnreachable
+		if content.ReferenceStatus != model.ReferenceUnreachable {
+			log.Printf("Reference %s of tenant %q became unreachable: %v", content.ID, content.TenantID, err)
+		}
+	}
+	now := s.now()
+	content.ReferenceStatus = status
+	content.ReferenceCheckedAt = &now
+	if err := s.repo.UpdateContent(ctx, content); err != nil {
+		if errors.Is(err, repository.ErrContentNotFound) {
+			return nil, ErrContentNotFound
+		}
+		return nil, err
+	}
+	return content, nil
+}
+
+// reachable checks that a URL answers a HEAD request, or a GET of its first
+// byte where HEAD isn't allowed, without an error status
+func (f *remoteFetcher) reachable(ctx context.Context, rawURL string) error {
+	ctx, cancel := context.WithTimeout(ctx, referenceCheckTimeout)
+	defer cancel()
+
+	status, err := f.probe(ctx, http.MethodHead, rawURL)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = f.probe(ctx, http.MethodGet, rawURL)
+	}
+	if err != nil {
+		if errors.Is(err, ErrForbiddenAddress) || errors.Is(err, ErrInvalidInput) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrReferenceUnreachable, err)
+	}
+	if status >= http.StatusBadRequest {
+		return fmt.Errorf("%w: status %d", ErrReferenceUnreachable, status)
+	}
+	return nil
+}
+
+// probe sends a request for at most the first byte of a URL and returns the
+// status of the response
+func (f *remoteFetcher) probe(ctx context.Context, method, rawURL string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return 0, ErrInvalidInput
+	}
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<10))
+	return resp.StatusCode, nil
+}
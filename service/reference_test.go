@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository/memory"
+)
+
+func TestReferences(t *testing.T) {
+	var gone atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case gone.Load() || r.URL.Path == "/missing.pdf":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodHead:
+			// Like some document systems, only GET is allowed
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		default:
+			w.Write([]byte("%"))
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	s := newTestInstance(memory.NewMemoryRepository())
+	s.ConfigureRemoteFetch(RemoteFetchConfig{AllowPrivateNetworks: true})
+
+	tests := []struct {
+		name    string
+		input   CreateReferenceInput
+		wantErr error
+	}{
+		{name: "unchecked", input: CreateReferenceInput{URL: server.URL + "/missing.pdf"}},
+		{name: "verified", input: CreateReferenceInput{URL: server.URL + "/contracts/msa.pdf", Verify: true}},
+		{name: "verified unreachable", input: CreateReferenceInput{URL: server.URL + "/missing.pdf", Verify: true}, wantErr: ErrReferenceUnreachable},
+		{name: "not http", input: CreateReferenceInput{URL: "ftp://example.com/msa.pdf"}, wantErr: ErrInvalidInput},
+		{name: "relative", input: CreateReferenceInput{URL: "/msa.pdf"}, wantErr: ErrInvalidInput},
+	}
+	var references []*model.Content
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := s.CreateReference(ctx, tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateReference() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !content.IsReference() || content.Status != model.StatusDone || content.StoragePath != "" {
+				t.Errorf("CreateReference() = kind %q, status %q, path %q, want a done reference without data", content.Kind, content.Status, content.StoragePath)
+			}
+			if verified := content.ReferenceStatus == model.ReferenceReachable; verified != tt.input.Verify {
+				t.Errorf("reference status = %q, verified %v", content.ReferenceStatus, tt.input.Verify)
+			}
+			references = append(references, content)
+		})
+	}
+	if len(references) != 2 {
+		t.Fatalf("created %d references, want 2", len(references))
+	}
+	if references[1].FileName != "msa.pdf" {
+		t.Errorf("file name = %q, want the last segment of the URL", references[1].FileName)
+	}
+	if _, content, err := s.GetContentData(ctx, references[1].ID); !errors.Is(err, ErrReferenceContent) || content == nil {
+		t.Errorf("GetContentData() error = %v, want %v with the content", err, ErrReferenceContent)
+	}
+
+	// The unchecked reference is checked, the verified one is current
+	result, err := s.CheckReferences(ctx, defaultReferenceCheckAge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Checked != 1 || result.Unreachable != 1 {
+		t.Errorf("CheckReferences() = %+v, want 1 checked and unreachable", result)
+	}
+	unchecked, _ := s.GetContent(ctx, references[0].ID)
+	if unchecked.ReferenceStatus != model.ReferenceUnreachable || unchecked.ReferenceCheckedAt == nil {
+		t.Errorf("reference status = %q checked at %v, want unreachable", unchecked.ReferenceStatus, unchecked.ReferenceCheckedAt)
+	}
+
+	// Files aren't listed as references
+	if _, err := s.CreateContent(ctx, CreateContentInput{FileName: "a.txt", MIMEType: "text/plain", FileSize: 1, Data: strings.NewReader("a")}); err != nil {
+		t.Fatal(err)
+	}
+	listed, err := s.ListContent(ctx, ListContentInput{Kind: model.KindReference})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listed.TotalCount != 2 {
+		t.Errorf("listed %d references, want 2", listed.TotalCount)
+	}
+
+	if _, err := s.DeleteContent(ctx, references[1].ID, DeleteContentOptions{}); err != nil {
+		t.Errorf("DeleteContent() of a reference error = %v", err)
+	}
+}
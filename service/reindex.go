@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// ErrReindexDisabled is returned when the repository's search indexes can't be rebuilt
+var ErrReindexDisabled = errors.New("search index rebuilds are not configured")
+
+// ConfigureSearchIndexes lets administrators rebuild the search indexes of
+// the repository, after they are corrupted or the way they are built changes
+func (s *ContentService) ConfigureSearchIndexes(indexes repository.SearchIndexRepository) {
+	s.searchIndexes = indexes
+}
+
+// ReindexOptions controls a rebuild of the search indexes
+type ReindexOptions struct {
+	Indexes []string      // Only rebuild these indexes, all of them if empty
+	Pause   time.Duration // Wait between two rebuilds, spreading the load they put on the repository
+}
+
+// ReindexProgress reports a search index that was rebuilt
+type ReindexProgress struct {
+	Index string `json:"index"`
+	Done  int    `json:"done"` // Indexes rebuilt so far, this one included
+	Total int    `json:"total"`
+	Took  string `json:"took"`
+}
+
+// ReindexResult summarizes a rebuild of the search indexes
+type ReindexResult struct {
+	Rebuilt []string `json:"rebuilt"`
+	Took    string   `json:"took"`
+}
+
+// Reindex rebuilds the search indexes one after the other, calling
+// progress, if not nil, as each is done
+func (s *ContentService) Reindex(ctx context.Context, options ReindexOptions, progress func(ReindexProgress)) (*ReindexResult, error) {
+	indexes, err := s.reindexTargets(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	return s.rebuildIndexes(ctx, indexes, options.Pause, progress)
+}
+
+// StartReindex rebuilds the search indexes in a job, which only the admin
+// API exposes
+func (s *ContentService) StartReindex(ctx context.Context, options ReindexOptions) (*model.Job, error) {
+	indexes, err := s.reindexTargets(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &model.Job{Kind: JobKindReindex}
+	input := struct {
+		Indexes []string `json:"indexes"`
+		Pause   string   `json:"pause"`
+	}{indexes, options.Pause.String()}
+	return s.startJob(ctx, job, input, nil, func(ctx context.Context, progress *JobProgress) (interface{}, error) {
+		progress.SetTotal(int64(len(indexes)))
+		return s.rebuildIndexes(ctx, indexes, options.Pause, func(ReindexProgress) { progress.Add(1) })
+	})
+}
+
+// reindexTargets checks the options of a rebuild and returns the indexes to rebuild
+func (s *ContentService) reindexTargets(ctx context.Context, options ReindexOptions) ([]string, error) {
+	if s.searchIndexes == nil {
+		return nil, ErrReindexDisabled
+	}
+	if options.Pause < 0 {
+		return nil, fmt.Errorf("%w: negative pause", ErrInvalidInput)
+	}
+
+	indexes, err := s.searchIndexes.SearchIndexes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(options.Indexes) > 0 {
+		known := make(map[string]bool, len(indexes))
+		for _, index := range indexes {
+			known[index] = true
+		}
+		for _, index := range options.Indexes {
+			if !known[index] {
+				return nil, fmt.Errorf("%w: unknown search index %q", ErrInvalidInput, index)
+			}
+		}
+		indexes = options.Indexes
+	}
+	return indexes, nil
+}
+
+// rebuildIndexes rebuilds indexes one after the other, pausing in between
+func (s *ContentService) rebuildIndexes(ctx context.Context, indexes []string, pause time.Duration, progress func(ReindexProgress)) (*ReindexResult, error) {
+	start := time.Now()
+	result := &ReindexResult{Rebuilt: []string{}}
+	for i, index := range indexes {
+		if i > 0 && pause > 0 {
+			select {
+			case <-time.After(pause):
+			case <-ctx.Done():
+				return result, ctx.Err()
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		began := time.Now()
+		if err := s.searchIndexes.RebuildSearchIndex(ctx, index); err != nil {
+			return result, err
+		}
+		result.Rebuilt = append(result.Rebuilt, index)
+		if progress != nil {
+			progress(ReindexProgress{Index: index, Done: i + 1, Total: len(indexes), Took: time.Since(began).String()})
+		}
+	}
+	result.Took = time.Since(start).String()
+	return result, nil
+}
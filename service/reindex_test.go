@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/livefire2015/simple-contents/repository/memory"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+)
+
+func TestReindex(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepository()
+	s := NewContentService(repo, memorystorage.NewMemoryStorage())
+
+	if _, err := s.Reindex(ctx, ReindexOptions{}, nil); !errors.Is(err, ErrReindexDisabled) {
+		t.Fatalf("Reindex() before ConfigureSearchIndexes error = %v, want ErrReindexDisabled", err)
+	}
+	s.ConfigureSearchIndexes(repo)
+
+	tests := []struct {
+		name    string
+		options ReindexOptions
+		want    []string
+		wantErr error
+	}{
+		{name: "all", want: []string{"contents_by_status", "contents_by_metadata"}},
+		{name: "selected", options: ReindexOptions{Indexes: []string{"contents_by_metadata"}}, want: []string{"contents_by_metadata"}},
+		{name: "unknown", options: ReindexOptions{Indexes: []string{"contents_by_name"}}, wantErr: ErrInvalidInput},
+		{name: "negative pause", options: ReindexOptions{Pause: -1}, wantErr: ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reported []ReindexProgress
+			result, err := s.Reindex(ctx, tt.options, func(progress ReindexProgress) {
+				reported = append(reported, progress)
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Reindex() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if len(result.Rebuilt) != len(tt.want) || len(reported) != len(tt.want) {
+				t.Fatalf("Reindex() rebuilt %v and reported %d indexes, want %v", result.Rebuilt, len(reported), tt.want)
+			}
+			for i, index := range tt.want {
+				if result.Rebuilt[i] != index {
+					t.Errorf("rebuilt index %d = %s, want %s", i, result.Rebuilt[i], index)
+				}
+				if p := reported[i]; p.Index != index || p.Done != i+1 || p.Total != len(tt.want) {
+					t.Errorf("progress %d = %+v, want %s, %d of %d", i, p, index, i+1, len(tt.want))
+				}
+			}
+		})
+	}
+}
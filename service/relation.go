@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+const (
+	// DefaultRelationDepth is how far a traversal follows relations if not set
+	DefaultRelationDepth = 3
+	// MaxRelationDepth is the deepest traversal allowed
+	MaxRelationDepth = 10
+	// maxRelationNodes bounds the content a single traversal returns
+	maxRelationNodes = 500
+)
+
+var (
+	ErrRelationNotFound = errors.New("relation not found")
+	ErrRelationExists   = errors.New("relation already exists")
+)
+
+// CreateRelationInput defines a relation from a content item to another
+type CreateRelationInput struct {
+	SourceID  uuid.UUID          `json:"-" validate:"required"`
+	TargetID  uuid.UUID          `json:"target_id" validate:"required"`
+	Type      model.RelationType `json:"type"`
+	CreatedBy string             `json:"created_by" validate:"max=255"`
+}
+
+// CreateRelation links two content items of the same tenant with a typed
+// relation. Relations of a type can't form a cycle, so a draft can't
+// supersede its own successor.
+func (s *ContentService) CreateRelation(ctx context.Context, input CreateRelationInput) (*model.ContentRelation, error) {
+	if err := validateInput(input); err != nil {
+		return nil, err
+	}
+	if !input.Type.IsValid() {
+		return nil, fmt.Errorf("%w: unknown relation type %q", ErrInvalidInput, input.Type)
+	}
+	if input.SourceID == input.TargetID {
+		return nil, fmt.Errorf("%w: a relation links two different content items", ErrInvalidInput)
+	}
+
+	source, err := s.GetContent(ctx, input.SourceID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.GetContent(ctx, input.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	if source.TenantID != target.TenantID {
+		return nil, fmt.Errorf("%w: related content must belong to the same tenant", ErrInvalidInput)
+	}
+
+	cycle, err := s.reachable(ctx, input.TargetID, input.SourceID, input.Type)
+	if err != nil {
+		return nil, err
+	}
+	if cycle {
+		return nil, fmt.Errorf("%w: a %s relation from %s to %s would form a cycle", ErrInvalidInput, input.Type, input.SourceID, input.TargetID)
+	}
+
+	relation := &model.ContentRelation{
+		Type:      input.Type,
+		SourceID:  input.SourceID,
+		TargetID:  input.TargetID,
+		CreatedBy: input.CreatedBy,
+	}
+	if err := s.repo.CreateRelation(ctx, relation); err != nil {
+		if errors.Is(err, repository.ErrRelationExists) {
+			return nil, ErrRelationExists
+		}
+		return nil, err
+	}
+	return relation, nil
+}
+
+// reachable reports whether to can be reached from from by following
+// relations of a type
+func (s *ContentService) reachable(ctx context.Context, from, to uuid.UUID, relationType model.RelationType) (bool, error) {
+	visited := map[uuid.UUID]bool{from: true}
+	queue := []uuid.UUID{from}
+	for len(queue) > 0 {
+		relations, err := s.repo.ListRelations(ctx, queue[0], model.RelationsOutgoing, relationType)
+		if err != nil {
+			return false, err
+		}
+		queue = queue[1:]
+		for _, relation := range relations {
+			if relation.TargetID == to {
+				return true, nil
+			}
+			if !visited[relation.TargetID] {
+				visited[relation.TargetID] = true
+				queue = append(queue, relation.TargetID)
+			}
+		}
+	}
+	return false, nil
+}
+
+// ListRelations retrieves the relations of a content item in a direction,
+// optionally of one type
+func (s *ContentService) ListRelations(ctx context.Context, id uuid.UUID, direction model.RelationDirection, relationType model.RelationType) ([]*model.ContentRelation, error) {
+	if err := validateRelationQuery(direction, relationType); err != nil {
+		return nil, err
+	}
+	if _, err := s.GetContent(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListRelations(ctx, id, direction, relationType)
+}
+
+// DeleteRelation removes a relation of a content item, at either end of it
+func (s *ContentService) DeleteRelation(ctx context.Context, contentID, relationID uuid.UUID) error {
+	relation, err := s.repo.GetRelation(ctx, relationID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRelationNotFound) {
+			return ErrRelationNotFound
+		}
+		return err
+	}
+	if relation.SourceID != contentID && relation.TargetID != contentID {
+		return ErrRelationNotFound
+	}
+
+	if err := s.repo.DeleteRelation(ctx, relationID); err != nil {
+		if errors.Is(err, repository.ErrRelationNotFound) {
+			return ErrRelationNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// RelationGraphInput selects the relations a traversal follows
+type RelationGraphInput struct {
+	Direction model.RelationDirection // Both if empty
+	Type      model.RelationType      // Every type if empty
+	Depth     int                     // DefaultRelationDepth if 0
+}
+
+// RelationGraph is the content reachable from a content item and the
+// relations between them
+type RelationGraph struct {
+	Nodes     []*model.Content         `json:"nodes"`
+	Relations []*model.ContentRelation `json:"relations"`
+	Truncated bool                     `json:"truncated,omitempty"` // Whether maxRelationNodes cut the traversal short
+}
+
+// TraverseRelations walks the relations of a content item breadth first, up
+// to a depth. Deleted content and the relations to it are left out.
+func (s *ContentService) TraverseRelations(ctx context.Context, id uuid.UUID, input RelationGraphInput) (*RelationGraph, error) {
+	if input.Direction == "" {
+		input.Direction = model.RelationsBoth
+	}
+	if input.Depth == 0 {
+		input.Depth = DefaultRelationDepth
+	}
+	if input.Depth < 0 || input.Depth > MaxRelationDepth {
+		return nil, fmt.Errorf("%w: depth must be between 1 and %d", ErrInvalidInput, MaxRelationDepth)
+	}
+	if err := validateRelationQuery(input.Direction, input.Type); err != nil {
+		return nil, err
+	}
+
+	root, err := s.GetContent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &RelationGraph{Nodes: []*model.Content{root}, Relations: []*model.ContentRelation{}}
+	visited := map[uuid.UUID]bool{id: true}
+	seenRelations := make(map[uuid.UUID]bool)
+	level := []uuid.UUID{id}
+	for depth := 0; depth < input.Depth && len(level) > 0; depth++ {
+		var next []uuid.UUID
+		for _, nodeID := range level {
+			relations, err := s.repo.ListRelations(ctx, nodeID, input.Direction, input.Type)
+			if err != nil {
+				return nil, err
+			}
+			for _, relation := range relations {
+				if seenRelations[relation.ID] {
+					continue
+				}
+				other := relation.TargetID
+				if other == nodeID {
+					other = relation.SourceID
+				}
+
+				if !visited[other] {
+					if len(graph.Nodes) == maxRelationNodes {
+						graph.Truncated = true
+						return graph, nil
+					}
+					content, err := s.repo.GetContentByID(ctx, other)
+					if errors.Is(err, repository.ErrContentNotFound) {
+						continue
+					}
+					if err != nil {
+						return nil, err
+					}
+					visited[other] = true
+					graph.Nodes = append(graph.Nodes, content)
+					next = append(next, other)
+				}
+				seenRelations[relation.ID] = true
+				graph.Relations = append(graph.Relations, relation)
+			}
+		}
+		level = next
+	}
+	return graph, nil
+}
+
+// validateRelationQuery checks the direction and optional type of a relation query
+func validateRelationQuery(direction model.RelationDirection, relationType model.RelationType) error {
+	if !direction.IsValid() {
+		return fmt.Errorf("%w: unknown relation direction %q", ErrInvalidInput, direction)
+	}
+	if relationType != "" && !relationType.IsValid() {
+		return fmt.Errorf("%w: unknown relation type %q", ErrInvalidInput, relationType)
+	}
+	return nil
+}
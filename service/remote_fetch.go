@@ -0,0 +1,325 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/storage"
+)
+
+var (
+	ErrRemoteFetchFailed   = errors.New("failed to fetch remote content")
+	ErrRemoteTooLarge      = errors.New("remote content exceeds the maximum size")
+	ErrForbiddenAddress    = errors.New("remote address is not allowed")
+	ErrContentTypeMismatch = errors.New("remote content does not match its declared type")
+)
+
+// RemoteFetchConfig limits how content is fetched from remote URLs
+type RemoteFetchConfig struct {
+	MaxSize              int64         // Maximum number of bytes accepted
+	Timeout              time.Duration // Deadline for the whole transfer
+	AllowPrivateNetworks bool          // Permit loopback, private and link-local addresses
+}
+
+// DefaultRemoteFetchConfig returns the limits used unless configured otherwise
+func DefaultRemoteFetchConfig() RemoteFetchConfig {
+	return RemoteFetchConfig{
+		MaxSize: 100 << 20,
+		Timeout: 2 * time.Minute,
+	}
+}
+
+// remoteFetcher downloads remote content with SSRF protection
+type remoteFetcher struct {
+	config RemoteFetchConfig
+	client *http.Client
+}
+
+func newRemoteFetcher(config RemoteFetchConfig) *remoteFetcher {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		// Check the resolved address at dial time so DNS rebinding can't bypass it
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if config.AllowPrivateNetworks {
+				return nil
+			}
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublicIP(ip) {
+				return ErrForbiddenAddress
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &remoteFetcher{
+		config: config,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   config.Timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 5 {
+					return errors.New("too many redirects")
+				}
+				return checkRemoteScheme(req.URL)
+			},
+		},
+	}
+}
+
+// nonPublicNetworks are the IANA special-purpose ranges that aren't globally
+// reachable, plus the IPv6 ranges that embed an IPv4 address (NAT64, 6to4,
+// Teredo) and could be used to reach any of the IPv4 ones
+var nonPublicNetworks = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "This network"
+	netip.MustParsePrefix("10.0.0.0/8"),      // Private
+	netip.MustParsePrefix("100.64.0.0/10"),   // Carrier-grade NAT
+	netip.MustParsePrefix("127.0.0.0/8"),     // Loopback
+	netip.MustParsePrefix("169.254.0.0/16"),  // Link-local, including cloud metadata
+	netip.MustParsePrefix("172.16.0.0/12"),   // Private
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // Documentation
+	netip.MustParsePrefix("192.88.99.0/24"),  // 6to4 relay anycast
+	netip.MustParsePrefix("192.168.0.0/16"),  // Private
+	netip.MustParsePrefix("198.18.0.0/15"),   // Benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // Documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // Documentation
+	netip.MustParsePrefix("224.0.0.0/4"),     // Multicast
+	netip.MustParsePrefix("240.0.0.0/4"),     // Reserved and broadcast
+
+	netip.MustParsePrefix("::/96"),          // Unspecified, loopback and IPv4-compatible
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64
+	netip.MustParsePrefix("64:ff9b:1::/48"), // Local-use NAT64
+	netip.MustParsePrefix("100::/64"),       // Discard-only
+	netip.MustParsePrefix("2001::/23"),      // IETF protocol assignments, including Teredo
+	netip.MustParsePrefix("2001:db8::/32"),  // Documentation
+	netip.MustParsePrefix("2002::/16"),      // 6to4
+	netip.MustParsePrefix("3fff::/20"),      // Documentation
+	netip.MustParsePrefix("5f00::/16"),      // Segment routing
+	netip.MustParsePrefix("fc00::/7"),       // Unique local
+	netip.MustParsePrefix("fe80::/10"),      // Link-local
+	netip.MustParsePrefix("fec0::/10"),      // Site-local
+	netip.MustParsePrefix("ff00::/8"),       // Multicast
+}
+
+// isPublicIP reports whether ip is routable on the public internet
+func isPublicIP(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	// IPv4-mapped addresses reach the IPv4 host they embed
+	addr = addr.Unmap()
+	for _, network := range nonPublicNetworks {
+		if network.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+func checkRemoteScheme(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: unsupported scheme %q", ErrInvalidInput, u.Scheme)
+	}
+	return nil
+}
+
+// remoteContent is an open remote response ready to be stored
+type remoteContent struct {
+	body     io.ReadCloser
+	data     io.Reader
+	size     int64
+	mimeType string
+	fileName string
+}
+
+// fetch opens rawURL and verifies its size and content type
+func (f *remoteFetcher) fetch(ctx context.Context, rawURL string) (*remoteContent, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil, ErrInvalidInput
+	}
+	if err := checkRemoteScheme(u); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, ErrInvalidInput
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrForbiddenAddress) || errors.Is(err, ErrInvalidInput) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrRemoteFetchFailed, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: remote returned status %d", ErrRemoteFetchFailed, resp.StatusCode)
+	}
+	if resp.ContentLength > f.config.MaxSize {
+		resp.Body.Close()
+		return nil, ErrRemoteTooLarge
+	}
+
+	body := &maxSizeReader{r: resp.Body, remaining: f.config.MaxSize, err: ErrRemoteTooLarge}
+
+	// Sniff the start of the body to verify the declared type
+	head := make([]byte, 512)
+	n, err := io.ReadFull(body, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %v", ErrRemoteFetchFailed, err)
+	}
+	head = head[:n]
+
+	mimeType, err := verifyContentType(resp.Header.Get("Content-Type"), http.DetectContentType(head))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	size := resp.ContentLength
+	if size < 0 {
+		size = storage.UnknownSize
+	}
+
+	return &remoteContent{
+		body:     resp.Body,
+		data:     io.MultiReader(bytes.NewReader(head), body),
+		size:     size,
+		mimeType: mimeType,
+		fileName: path.Base(resp.Request.URL.Path),
+	}, nil
+}
+
+// verifyContentType returns the MIME type to store, rejecting bodies that
+// clearly aren't what the remote declared, such as HTML error pages
+func verifyContentType(declared, sniffed string) (string, error) {
+	declaredType, _, err := mime.ParseMediaType(declared)
+	if err != nil || declaredType == "application/octet-stream" {
+		declaredType = ""
+	}
+	sniffedType, _, _ := mime.ParseMediaType(sniffed)
+
+	switch {
+	case declaredType == "":
+		return sniffedType, nil
+	case sniffedType == declaredType, sniffedType == "application/octet-stream":
+		return declaredType, nil
+	case strings.HasPrefix(sniffedType, "text/") && !strings.HasPrefix(declaredType, "text/"):
+		return "", fmt.Errorf("%w: declared %s, detected %s", ErrContentTypeMismatch, declaredType, sniffedType)
+	case !strings.HasPrefix(sniffedType, "application/") && topLevelType(sniffedType) != topLevelType(declaredType):
+		return "", fmt.Errorf("%w: declared %s, detected %s", ErrContentTypeMismatch, declaredType, sniffedType)
+	}
+	return declaredType, nil
+}
+
+func topLevelType(mediaType string) string {
+	topLevel, _, _ := strings.Cut(mediaType, "/")
+	return topLevel
+}
+
+// maxSizeReader fails with err once more than remaining bytes have been read
+type maxSizeReader struct {
+	r         io.Reader
+	remaining int64
+	err       error
+}
+
+func (m *maxSizeReader) Read(p []byte) (int, error) {
+	n, err := m.r.Read(p)
+	m.remaining -= int64(n)
+	if m.remaining < 0 {
+		return n, m.err
+	}
+	return n, err
+}
+
+// ConfigureRemoteFetch sets the limits used by CreateContentFromURL
+func (s *ContentService) ConfigureRemoteFetch(config RemoteFetchConfig) {
+	s.remote = newRemoteFetcher(config)
+}
+
+// CreateContentFromURLInput represents input for creating content from a remote URL
+type CreateContentFromURLInput struct {
+	TenantID    string         `validate:"max=63"`
+	URL         string         `validate:"required,url,max=2048"`
+	FileName    string         `validate:"max=255"`   // Defaults to the last segment of the URL path
+	Description string         `validate:"max=20000"` // Markdown
+	CreatedBy   string         `validate:"max=255"`
+	Source      string         `validate:"max=100"` // Defaults to remote_url
+	ExternalID  string         `validate:"max=255"` // Unique per tenant and source
+	Metadata    model.Metadata `validate:"max=1000"`
+	CallbackURL string         `validate:"omitempty,url,max=2048"`
+}
+
+// CreateContentFromURL fetches a remote URL and stores the response as content
+func (s *ContentService) CreateContentFromURL(ctx context.Context, input CreateContentFromURLInput) (*model.Content, error) {
+	if err := validateInput(input); err != nil {
+		return nil, err
+	}
+	source := input.Source
+	if source == "" {
+		source = "remote_url"
+	}
+	// Don't fetch what can't be stored
+	if err := s.checkStorageAvailable(); err != nil {
+		return nil, err
+	}
+	if err := s.checkExternalID(ctx, input.TenantID, source, input.ExternalID); err != nil {
+		return nil, err
+	}
+
+	remote, err := s.remote.fetch(ctx, input.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer remote.body.Close()
+
+	fileName := input.FileName
+	if fileName == "" {
+		fileName = remote.fileName
+	}
+	if fileName == "" || fileName == "/" || fileName == "." {
+		fileName = "download"
+	}
+
+	return s.CreateContent(ctx, CreateContentInput{
+		TenantID:    input.TenantID,
+		FileName:    fileName,
+		Description: input.Description,
+		MIMEType:    remote.mimeType,
+		FileSize:    remote.size,
+		Data:        remote.data,
+		CreatedBy:   input.CreatedBy,
+		Source:      source,
+		ExternalID:  input.ExternalID,
+		Metadata:    input.Metadata,
+		CallbackURL: input.CallbackURL,
+	})
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "93.184.216.34", want: true},
+		{ip: "8.8.8.8", want: true},
+		{ip: "2606:4700:4700::1111", want: true},
+		{ip: "0.0.0.0", want: false},
+		{ip: "10.1.2.3", want: false},
+		{ip: "100.64.0.1", want: false},
+		{ip: "100.127.255.254", want: false},
+		{ip: "127.0.0.1", want: false},
+		{ip: "169.254.169.254", want: false},
+		{ip: "172.16.0.1", want: false},
+		{ip: "192.0.0.8", want: false},
+		{ip: "192.0.2.1", want: false},
+		{ip: "192.168.1.1", want: false},
+		{ip: "198.18.0.1", want: false},
+		{ip: "198.19.255.255", want: false},
+		{ip: "224.0.0.1", want: false},
+		{ip: "255.255.255.255", want: false},
+		{ip: "::", want: false},
+		{ip: "::1", want: false},
+		{ip: "::ffff:127.0.0.1", want: false},
+		{ip: "::ffff:169.254.169.254", want: false},
+		{ip: "64:ff9b::a9fe:a9fe", want: false},
+		{ip: "64:ff9b:1::1", want: false},
+		{ip: "2002:a9fe:a9fe::1", want: false},
+		{ip: "2001:0:4136:e378::1", want: false},
+		{ip: "2001:db8::1", want: false},
+		{ip: "fd00::1", want: false},
+		{ip: "fe80::1", want: false},
+		{ip: "ff02::1", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := isPublicIP(net.ParseIP(tt.ip)); got != tt.want {
+				t.Errorf("isPublicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// PDFRenderer prints HTML documents to PDF
+type PDFRenderer interface {
+	// RenderPDF renders a complete HTML document and writes the PDF to dst
+	RenderPDF(ctx context.Context, html []byte, dst string) error
+}
+
+// RenderPDF posts the document to Gotenberg's Chromium route
+func (c *GotenbergConverter) RenderPDF(ctx context.Context, html []byte, dst string) error {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("files", "index.html")
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(html); err != nil {
+		return err
+	}
+	if err := form.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL+"/forms/chromium/convert/html", &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	return c.post(req, dst)
+}
+
+// ChromiumRenderer renders by running a headless Chromium or Chrome
+type ChromiumRenderer struct {
+	Path string // Browser executable, looked up in PATH if empty
+}
+
+// NewChromiumRenderer creates a renderer running the browser at path
+func NewChromiumRenderer(path string) *ChromiumRenderer {
+	return &ChromiumRenderer{Path: path}
+}
+
+// RenderPDF runs the browser with --print-to-pdf
+func (c *ChromiumRenderer) RenderPDF(ctx context.Context, html []byte, dst string) error {
+	path := c.Path
+	if path == "" {
+		path = "chromium"
+	}
+
+	workDir, err := os.MkdirTemp("", "render-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	src := filepath.Join(workDir, "index.html")
+	if err := os.WriteFile(src, html, 0o600); err != nil {
+		return err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path,
+		"--headless", "--disable-gpu", "--no-pdf-header-footer",
+		"--user-data-dir="+filepath.Join(workDir, "profile"),
+		"--print-to-pdf="+dst, "file://"+filepath.ToSlash(src))
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("chromium: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if _, err := os.Stat(dst); err != nil {
+		return fmt.Errorf("chromium produced no PDF: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+var ErrInvalidReviewTransition = errors.New("invalid review transition")
+
+// ReviewTransitionInput moves an association through the approval workflow
+type ReviewTransitionInput struct {
+	State    model.ReviewState `json:"state"`                                // Target state
+	Reviewer string            `json:"reviewer" validate:"required,max=255"` // Who requests the review, or decides it
+	Reason   string            `json:"reason" validate:"max=2000"`           // Required when rejecting
+}
+
+// TransitionReview requests a review of an association, or approves or
+// rejects a pending one. Rejected links can be submitted for review again;
+// approved links are final.
+func (s *ContentService) TransitionReview(ctx context.Context, associationID uuid.UUID, input ReviewTransitionInput) (*model.ContentEntityAssociation, error) {
+	if !input.State.IsValid() {
+		return nil, fmt.Errorf("%w: unknown review state %q", ErrInvalidInput, input.State)
+	}
+	if err := validateInput(input); err != nil {
+		return nil, err
+	}
+	if input.State == model.ReviewRejected && input.Reason == "" {
+		return nil, fmt.Errorf("%w: a reason is required when rejecting", ErrInvalidInput)
+	}
+
+	association, err := s.repo.GetAssociationByID(ctx, associationID)
+	if err != nil {
+		if errors.Is(err, repository.ErrAssociationNotFound) {
+			return nil, ErrAssociationNotFound
+		}
+		return nil, err
+	}
+
+	current := model.ReviewState("")
+	if association.Review != nil {
+		current = association.Review.State
+	}
+
+	now := s.now()
+	eventType := EventReviewDecided
+	switch input.State {
+	case model.ReviewPending:
+		if current != "" && current != model.ReviewRejected {
+			return nil, fmt.Errorf("%w: association is already %s", ErrInvalidReviewTransition, current)
+		}
+		association.Review = &model.AssociationReview{
+			State:       model.ReviewPending,
+			RequestedBy: input.Reviewer,
+			RequestedAt: now,
+		}
+		eventType = EventReviewRequested
+	default:
+		if current != model.ReviewPending {
+			return nil, fmt.Errorf("%w: association is not pending review", ErrInvalidReviewTransition)
+		}
+		association.Review = &model.AssociationReview{
+			State:       input.State,
+			RequestedBy: association.Review.RequestedBy,
+			RequestedAt: association.Review.RequestedAt,
+			Reviewer:    input.Reviewer,
+			Reason:      input.Reason,
+			DecidedAt:   &now,
+		}
+	}
+
+	if err := s.repo.UpdateAssociation(ctx, association); err != nil {
+		if errors.Is(err, repository.ErrAssociationNotFound) {
+			return nil, ErrAssociationNotFound
+		}
+		return nil, fmt.Errorf("failed to update association: %w", err)
+	}
+
+	content, _ := s.repo.GetContentByID(ctx, association.ContentID)
+	s.publishEntityEvent(eventType, association, content)
+
+	return association, nil
+}
+
+// ListReviews retrieves the associations in a review state, optionally
+// restricted to one entity type
+func (s *ContentService) ListReviews(ctx context.Context, entityType string, state model.ReviewState, options repository.ListOptions) ([]*model.ContentEntityAssociation, int64, error) {
+	if !state.IsValid() {
+		return nil, 0, fmt.Errorf("%w: unknown review state %q", ErrInvalidInput, state)
+	}
+	return s.repo.ListAssociationsByReviewState(ctx, entityType, state, options)
+}
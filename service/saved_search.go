@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+var (
+	ErrSavedSearchNotFound = errors.New("saved search not found")
+	ErrSavedSearchExists   = errors.New("saved search already exists")
+)
+
+// SavedSearchService manages the named content filters of principals
+type SavedSearchService struct {
+	repo     repository.SavedSearchRepository
+	contents *ContentService
+}
+
+// NewSavedSearchService creates a new saved search service
+func NewSavedSearchService(repo repository.SavedSearchRepository, contents *ContentService) *SavedSearchService {
+	return &SavedSearchService{
+		repo:     repo,
+		contents: contents,
+	}
+}
+
+// SavedSearchInput represents the settings of a saved search when creating or updating it
+type SavedSearchInput struct {
+	Name        string              `json:"name" validate:"required,max=255"`
+	Description string              `json:"description" validate:"max=2000"`
+	Filter      model.ContentFilter `json:"filter"`
+}
+
+// validate checks the input and scopes its filter to principal
+func (input *SavedSearchInput) validate(principal string) error {
+	if principal == "" {
+		return fmt.Errorf("%w: principal is required", ErrInvalidInput)
+	}
+	if err := validateInput(input); err != nil {
+		return err
+	}
+	if input.Filter.SortBy != "" && !input.Filter.SortBy.IsValid() {
+		return fmt.Errorf("%w: unknown sort field %q", ErrInvalidInput, input.Filter.SortBy)
+	}
+	if input.Filter.Status != "" && !input.Filter.Status.IsValid() {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidInput, input.Filter.Status)
+	}
+	if input.Filter.PinnedBy != "" && input.Filter.PinnedBy != principal {
+		return fmt.Errorf("%w: a saved search can only filter on its owner's pins", ErrInvalidInput)
+	}
+	// The tenant is the one the search is saved in, never taken from the filter
+	input.Filter.TenantID = ""
+	return nil
+}
+
+// CreateSavedSearch saves a filter under a name for a principal
+func (s *SavedSearchService) CreateSavedSearch(ctx context.Context, principal, tenantID string, input SavedSearchInput) (*model.SavedSearch, error) {
+	if err := input.validate(principal); err != nil {
+		return nil, err
+	}
+
+	search := &model.SavedSearch{
+		Principal:   principal,
+		TenantID:    tenantID,
+		Name:        input.Name,
+		Description: input.Description,
+		Filter:      input.Filter,
+	}
+
+	if err := s.repo.CreateSavedSearch(ctx, search); err != nil {
+		if errors.Is(err, repository.ErrSavedSearchExists) {
+			return nil, ErrSavedSearchExists
+		}
+		return nil, fmt.Errorf("failed to create saved search: %w", err)
+	}
+
+	return search, nil
+}
+
+// GetSavedSearch retrieves a saved search of a principal. Searches of other
+// principals are reported as not found.
+func (s *SavedSearchService) GetSavedSearch(ctx context.Context, principal string, id uuid.UUID) (*model.SavedSearch, error) {
+	search, err := s.repo.GetSavedSearch(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrSavedSearchNotFound) {
+			return nil, ErrSavedSearchNotFound
+		}
+		return nil, err
+	}
+	if search.Principal != principal {
+		return nil, ErrSavedSearchNotFound
+	}
+	return search, nil
+}
+
+// ListSavedSearches retrieves a page of the saved searches of a principal
+func (s *SavedSearchService) ListSavedSearches(ctx context.Context, principal string, options repository.ListOptions) ([]*model.SavedSearch, int64, error) {
+	if principal == "" {
+		return nil, 0, fmt.Errorf("%w: principal is required", ErrInvalidInput)
+	}
+	return s.repo.ListSavedSearchesByPrincipal(ctx, principal, options)
+}
+
+// UpdateSavedSearch replaces the name and filter of a saved search
+func (s *SavedSearchService) UpdateSavedSearch(ctx context.Context, principal string, id uuid.UUID, input SavedSearchInput) (*model.SavedSearch, error) {
+	if err := input.validate(principal); err != nil {
+		return nil, err
+	}
+
+	search, err := s.GetSavedSearch(ctx, principal, id)
+	if err != nil {
+		return nil, err
+	}
+
+	search.Name = input.Name
+	search.Description = input.Description
+	search.Filter = input.Filter
+
+	if err := s.repo.UpdateSavedSearch(ctx, search); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrSavedSearchNotFound):
+			return nil, ErrSavedSearchNotFound
+		case errors.Is(err, repository.ErrSavedSearchExists):
+			return nil, ErrSavedSearchExists
+		}
+		return nil, fmt.Errorf("failed to update saved search: %w", err)
+	}
+
+	return search, nil
+}
+
+// DeleteSavedSearch removes a saved search of a principal
+func (s *SavedSearchService) DeleteSavedSearch(ctx context.Context, principal string, id uuid.UUID) error {
+	if _, err := s.GetSavedSearch(ctx, principal, id); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteSavedSearch(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrSavedSearchNotFound) {
+			return ErrSavedSearchNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// RunSavedSearch lists a page of the content matching a saved search
+func (s *SavedSearchService) RunSavedSearch(ctx context.Context, principal string, id uuid.UUID, page, pageSize int) (*ListContentResult, error) {
+	search, err := s.GetSavedSearch(ctx, principal, id)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := search.Filter
+	filter.TenantID = search.TenantID
+	return s.contents.SearchContent(ctx, filter, page, pageSize)
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// Names of the tasks a Scheduler can run
+const (
+	TaskRetention        = "retention"         // Deletes content past the retention of its tenant
+	TaskReconcile        = "reconcile"         // Finds uploaded content whose data is missing from storage
+	TaskAbandonedUploads = "abandoned_uploads" // Deletes content whose data never arrived
+	TaskUsage            = "usage"             // Recomputes the storage usage of every tenant
+	TaskJobs             = "jobs"              // Fails jobs whose instance stopped and prunes finished ones
+	TaskVirusScans       = "virus_scans"       // Scans again content whose scan was lost
+	TaskDeletions        = "deletions"         // Retries the storage deletions queued during an outage
+	TaskReferences       = "references"        // Checks that the URLs of references can be reached
+	TaskDrafts           = "drafts"            // Purges the drafts never finalized within their TTL
+)
+
+const (
+	// schedulerLockName is the lock held by the instance running scheduled tasks
+	schedulerLockName = "simple-contents/scheduler"
+	// defaultAbandonedUploadAge is how long content waits for its data by default
+	defaultAbandonedUploadAge = 24 * time.Hour
+)
+
+// ScheduleConfig sets when the scheduled tasks run
+type ScheduleConfig struct {
+	// Tasks maps task names to cron expressions evaluated in UTC, e.g.
+	// {"retention": "0 3 * * *"}; tasks without one don't run
+	Tasks map[string]string `json:"tasks"`
+	// AbandonedUploadAge is how long content waits for its data before
+	// abandoned_uploads deletes it, e.g. "48h"; 24h if empty
+	AbandonedUploadAge string `json:"abandoned_upload_age"`
+	// RepairMissingData makes reconcile mark content without data as errored
+	RepairMissingData bool `json:"repair_missing_data"`
+	// ReferenceCheckAge is how long the last check of a reference stays
+	// current before references checks it again, e.g. "6h"; 24h if empty
+	ReferenceCheckAge string `json:"reference_check_age"`
+}
+
+// LoadScheduleConfig reads a JSON ScheduleConfig
+func LoadScheduleConfig(path string) (ScheduleConfig, error) {
+	var config ScheduleConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("invalid schedule configuration: %w", err)
+	}
+	return config, nil
+}
+
+// scheduledTask is a task with its schedule
+type scheduledTask struct {
+	name     string
+	schedule *CronSchedule
+	next     time.Time
+}
+
+// Scheduler runs maintenance tasks on cron schedules. With several
+// instances sharing a repository, only the one holding the scheduler lock
+// runs them.
+type Scheduler struct {
+	content       *ContentService
+	locks         repository.LockRepository
+	tasks         []*scheduledTask
+	abandonedAge  time.Duration
+	repairMissing bool
+	referenceAge  time.Duration
+	lock          repository.Lock // Held while this instance runs the tasks
+
+	now     func() time.Time
+	runTask func(ctx context.Context, name string) error
+}
+
+// NewScheduler checks a schedule configuration. locks may be nil when the
+// service runs as a single instance.
+func NewScheduler(content *ContentService, locks repository.LockRepository, config ScheduleConfig) (*Scheduler, error) {
+	s := &Scheduler{
+		content:       content,
+		locks:         locks,
+		abandonedAge:  defaultAbandonedUploadAge,
+		repairMissing: config.RepairMissingData,
+		referenceAge:  defaultReferenceCheckAge,
+		now:           content.now,
+	}
+	s.runTask = s.run
+
+	if config.AbandonedUploadAge != "" {
+		age, err := time.ParseDuration(config.AbandonedUploadAge)
+		if err != nil || age <= 0 {
+			return nil, fmt.Errorf("%w: invalid abandoned upload age %q", ErrInvalidInput, config.AbandonedUploadAge)
+		}
+		s.abandonedAge = age
+	}
+	if config.ReferenceCheckAge != "" {
+		age, err := time.ParseDuration(config.ReferenceCheckAge)
+		if err != nil || age <= 0 {
+			return nil, fmt.Errorf("%w: invalid reference check age %q", ErrInvalidInput, config.ReferenceCheckAge)
+		}
+		s.referenceAge = age
+	}
+
+	for name, expr := range config.Tasks {
+		switch name {
+		case TaskRetention, TaskReconcile, TaskAbandonedUploads, TaskUsage, TaskJobs, TaskVirusScans, TaskDeletions, TaskReferences, TaskDrafts:
+		default:
+			return nil, fmt.Errorf("%w: unknown scheduled task %q", ErrInvalidInput, name)
+		}
+		schedule, err := ParseCronSchedule(expr)
+		if err != nil {
+			return nil, fmt.Errorf("scheduled task %s: %w", name, err)
+		}
+		s.tasks = append(s.tasks, &scheduledTask{name: name, schedule: schedule})
+	}
+	// Tasks due at the same time run in a stable order
+	sort.Slice(s.tasks, func(i, j int) bool { return s.tasks[i].name < s.tasks[j].name })
+	return s, nil
+}
+
+// Run runs the tasks as they fall due until ctx is cancelled, then gives
+// up the scheduler lock
+func (s *Scheduler) Run(ctx context.Context) {
+	if len(s.tasks) == 0 {
+		return
+	}
+	defer s.releaseLock()
+
+	now := s.now()
+	for _, task := range s.tasks {
+		task.next = task.schedule.Next(now)
+	}
+	for {
+		next := time.Time{}
+		for _, task := range s.tasks {
+			if !task.next.IsZero() && (next.IsZero() || task.next.Before(next)) {
+				next = task.next
+			}
+		}
+		if next.IsZero() {
+			return
+		}
+
+		timer := time.NewTimer(next.Sub(s.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.runDue(ctx, s.now())
+	}
+}
+
+// runDue runs the tasks due at now if this instance leads, and schedules
+// their next runs
+func (s *Scheduler) runDue(ctx context.Context, now time.Time) {
+	var due []*scheduledTask
+	for _, task := range s.tasks {
+		if !task.next.IsZero() && !task.next.After(now) {
+			due = append(due, task)
+			task.next = task.schedule.Next(now)
+		}
+	}
+	if len(due) == 0 || !s.lead(ctx) {
+		return
+	}
+
+	for _, task := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		start := time.Now()
+		if err := s.runTask(ctx, task.name); err != nil {
+			log.Printf("Scheduled task %s failed after %s: %v", task.name, time.Since(start).Round(time.Millisecond), err)
+		}
+	}
+}
+
+// lead reports whether this instance holds the scheduler lock, taking it
+// if no other instance does
+func (s *Scheduler) lead(ctx context.Context) bool {
+	if s.locks == nil {
+		return true
+	}
+	if s.lock != nil {
+		if s.lock.Held(ctx) {
+			return true
+		}
+		log.Printf("Lost the scheduler lock")
+		s.lock = nil
+	}
+
+	lock, err := s.locks.TryLock(ctx, schedulerLockName)
+	if errors.Is(err, repository.ErrLockHeld) {
+		return false
+	} else if err != nil {
+		log.Printf("Failed to take the scheduler lock: %v", err)
+		return false
+	}
+	log.Printf("Took the scheduler lock, running scheduled tasks")
+	s.lock = lock
+	return true
+}
+
+// releaseLock lets another instance run the tasks
+func (s *Scheduler) releaseLock() {
+	if s.lock == nil {
+		return
+	}
+	if err := s.lock.Release(context.Background()); err != nil {
+		log.Printf("Failed to release the scheduler lock: %v", err)
+	}
+	s.lock = nil
+}
+
+// run runs a task and logs what it did
+func (s *Scheduler) run(ctx context.Context, name string) error {
+	switch name {
+	case TaskRetention:
+		result, err := s.content.SweepRetention(ctx)
+		if err == nil {
+			log.Printf("Retention sweep deleted %d items, %d retained by their entities", result.Deleted, result.Retained)
+		}
+		return err
+	case TaskReconcile:
+		result, err := s.content.Reconcile(ctx, ReconcileOptions{Repair: s.repairMissing})
+		if err == nil {
+			log.Printf("Reconciliation checked %d items: %d missing data, %d size mismatches, %d repaired", result.Checked, len(result.MissingData), len(result.SizeMismatch), result.Repaired)
+		}
+		return err
+	case TaskAbandonedUploads:
+		result, err := s.content.CleanupAbandonedUploads(ctx, s.abandonedAge)
+		if err == nil {
+			log.Printf("Abandoned upload cleanup deleted %d items", result.Deleted)
+		}
+		return err
+	case TaskUsage:
+		recounted, err := s.content.RecalculateAllUsage(ctx)
+		if err == nil {
+			log.Printf("Recalculated the usage of %d tenants", recounted)
+		}
+		return err
+	case TaskJobs:
+		failed, pruned, err := s.content.SweepJobs(ctx)
+		if err == nil {
+			log.Printf("Job sweep failed %d stale jobs and pruned %d finished ones", failed, pruned)
+		}
+		return err
+	case TaskVirusScans:
+		requeued, err := s.content.RequeueStalledScans(ctx)
+		if err == nil {
+			log.Printf("Requeued %d stalled virus scans", requeued)
+		}
+		return err
+	case TaskDeletions:
+		deleted, err := s.content.RetryPendingDeletions(ctx)
+		if err == nil {
+			log.Printf("Deleted %d objects queued while storage was unavailable", deleted)
+		}
+		return err
+	case TaskReferences:
+		result, err := s.content.CheckReferences(ctx, s.referenceAge)
+		if err == nil {
+			log.Printf("Checked %d references, %d unreachable", result.Checked, result.Unreachable)
+		}
+		return err
+	case TaskDrafts:
+		purged, err := s.content.PurgeExpiredDrafts(ctx)
+		if len(purged) > 0 {
+			log.Printf("Purged %d expired drafts", len(purged))
+		}
+		return err
+	}
+	return fmt.Errorf("unknown scheduled task %q", name)
+}
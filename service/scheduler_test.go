@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository/memory"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	// A Wednesday
+	after := time.Date(2025, 1, 15, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr    string
+		want    time.Time
+		wantErr bool
+	}{
+		{expr: "* * * * *", want: time.Date(2025, 1, 15, 10, 8, 0, 0, time.UTC)},
+		{expr: "*/15 * * * *", want: time.Date(2025, 1, 15, 10, 15, 0, 0, time.UTC)},
+		{expr: "5 * * * *", want: time.Date(2025, 1, 15, 11, 5, 0, 0, time.UTC)},
+		{expr: "0 3 * * *", want: time.Date(2025, 1, 16, 3, 0, 0, 0, time.UTC)},
+		{expr: "30 2 * * 1-5", want: time.Date(2025, 1, 16, 2, 30, 0, 0, time.UTC)},
+		{expr: "0 0 * * 7", want: time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 1 */3 *", want: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 12 1,20 * *", want: time.Date(2025, 1, 20, 12, 0, 0, 0, time.UTC)},
+		// Either day field matches when both are restricted
+		{expr: "0 0 31 * 5", want: time.Date(2025, 1, 17, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 29 2 *", want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 30 2 *", want: time.Time{}},
+		{expr: "@daily", want: time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{expr: "@weekly", want: time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 3 * *", wantErr: true},
+		{expr: "60 * * * *", wantErr: true},
+		{expr: "5-1 * * * *", wantErr: true},
+		{expr: "*/0 * * * *", wantErr: true},
+		{expr: "0 3 * * mon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			schedule, err := ParseCronSchedule(tt.expr)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidInput) {
+					t.Fatalf("ParseCronSchedule() error = %v, want ErrInvalidInput", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := schedule.Next(after); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSchedulerRejectsInvalidConfig(t *testing.T) {
+	s := NewContentService(memory.NewMemoryRepository(), memorystorage.NewMemoryStorage())
+	for _, config := range []ScheduleConfig{
+		{Tasks: map[string]string{"vacuum": "@daily"}},
+		{Tasks: map[string]string{TaskUsage: "every day"}},
+		{AbandonedUploadAge: "-1h"},
+	} {
+		if _, err := NewScheduler(s, nil, config); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("NewScheduler(%+v) error = %v, want ErrInvalidInput", config, err)
+		}
+	}
+}
+
+func TestSchedulerRunsOnLeaderOnly(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepository()
+	config := ScheduleConfig{Tasks: map[string]string{TaskUsage: "0 * * * *", TaskRetention: "0 3 * * *"}}
+
+	// Two replicas sharing the repository
+	var runs [2][]string
+	var schedulers [2]*Scheduler
+	for i := range schedulers {
+		scheduler, err := NewScheduler(NewContentService(repo, memorystorage.NewMemoryStorage()), repo, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scheduler.runTask = func(ctx context.Context, name string) error {
+			runs[i] = append(runs[i], name)
+			return nil
+		}
+		for _, task := range scheduler.tasks {
+			task.next = task.schedule.Next(time.Date(2025, 1, 15, 2, 30, 0, 0, time.UTC))
+		}
+		schedulers[i] = scheduler
+	}
+
+	for _, scheduler := range schedulers {
+		scheduler.runDue(ctx, time.Date(2025, 1, 15, 3, 0, 0, 0, time.UTC))
+	}
+	if strings.Join(runs[0], ",") != "retention,usage" || len(runs[1]) != 0 {
+		t.Fatalf("runs = %v, want both tasks run by the first replica only", runs)
+	}
+
+	// The other replica takes over once the leader stops
+	schedulers[0].releaseLock()
+	for _, scheduler := range schedulers[1:] {
+		scheduler.runDue(ctx, time.Date(2025, 1, 15, 4, 0, 0, 0, time.UTC))
+	}
+	if strings.Join(runs[1], ",") != "usage" {
+		t.Errorf("runs of the second replica = %v, want usage", runs[1])
+	}
+}
+
+func TestSweepRetention(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepository()
+	store := memorystorage.NewMemoryStorage()
+	tenants := NewTenantService(repo)
+	s := NewContentService(repo, store)
+	s.ConfigureTenants(tenants)
+
+	for _, input := range []TenantInput{
+		{ID: "acme", Name: "Acme", Retention: model.RetentionPolicy{MaxAgeDays: 30}},
+		{ID: "globex", Name: "Globex"},
+	} {
+		if _, err := tenants.CreateTenant(ctx, input); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	old := time.Now().UTC().AddDate(0, 0, -31)
+	create := func(tenantID string, createdAt time.Time) *model.Content {
+		content := &model.Content{TenantID: tenantID, FileName: "a.txt", MIMEType: "text/plain", FileSize: 1, StoragePath: tenantID + "/" + createdAt.Format(time.RFC3339), Status: model.StatusUploaded, CreatedAt: createdAt}
+		if _, err := store.Upload(ctx, content.StoragePath, strings.NewReader("a"), 1, content.MIMEType); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.CreateContent(ctx, content); err != nil {
+			t.Fatal(err)
+		}
+		return content
+	}
+	expired := create("acme", old)
+	recent := create("acme", time.Now().UTC())
+	kept := create("globex", old)
+
+	result, err := s.SweepRetention(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Deleted != 1 {
+		t.Errorf("SweepRetention() deleted %d items, want 1", result.Deleted)
+	}
+	if _, err := s.GetContent(ctx, expired.ID); !errors.Is(err, ErrContentNotFound) {
+		t.Errorf("GetContent() of expired content error = %v, want ErrContentNotFound", err)
+	}
+	for _, content := range []*model.Content{recent, kept} {
+		if _, err := s.GetContent(ctx, content.ID); err != nil {
+			t.Errorf("GetContent() of content within retention error = %v", err)
+		}
+	}
+}
+
+func TestCleanupAbandonedUploads(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepository()
+	store := memorystorage.NewMemoryStorage()
+	s := NewContentService(repo, store)
+
+	old := time.Now().UTC().Add(-48 * time.Hour)
+	abandoned := &model.Content{FileName: "a.pdf", MIMEType: "application/pdf", StoragePath: "direct/a.pdf", Status: model.StatusCreated, CreatedAt: old}
+	pending := &model.Content{FileName: "b.pdf", MIMEType: "application/pdf", StoragePath: "direct/b.pdf", Status: model.StatusCreated}
+	stored := &model.Content{FileName: "c.pdf", MIMEType: "application/pdf", StoragePath: "direct/c.pdf", Status: model.StatusCreated, CreatedAt: old}
+	if _, err := store.Upload(ctx, stored.StoragePath, strings.NewReader("c"), 1, stored.MIMEType); err != nil {
+		t.Fatal(err)
+	}
+	for _, content := range []*model.Content{abandoned, pending, stored} {
+		if err := repo.CreateContent(ctx, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := s.CleanupAbandonedUploads(ctx, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Deleted != 1 {
+		t.Errorf("CleanupAbandonedUploads() deleted %d items, want 1", result.Deleted)
+	}
+	if _, err := s.GetContent(ctx, abandoned.ID); !errors.Is(err, ErrContentNotFound) {
+		t.Errorf("GetContent() of the abandoned upload error = %v, want ErrContentNotFound", err)
+	}
+	for _, content := range []*model.Content{pending, stored} {
+		if _, err := s.GetContent(ctx, content.ID); err != nil {
+			t.Errorf("GetContent() of %s error = %v", content.FileName, err)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package service
+
+import "context"
+
+type elevatedScopeKey struct{}
+
+// WithElevatedScope marks ctx as acting with privileged access, e.g. to
+// restricted content. The transport layer decides which callers receive it.
+func WithElevatedScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, elevatedScopeKey{}, true)
+}
+
+// HasElevatedScope reports whether ctx was marked by WithElevatedScope
+func HasElevatedScope(ctx context.Context) bool {
+	elevated, _ := ctx.Value(elevatedScopeKey{}).(bool)
+	return elevated
+}
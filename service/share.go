@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// maxSharePasswordLength is the longest password bcrypt can hash
+const maxSharePasswordLength = 72
+
+var (
+	ErrShareNotFound         = errors.New("share not found")
+	ErrShareExpired          = errors.New("share has expired")
+	ErrShareRevoked          = errors.New("share has been revoked")
+	ErrShareExhausted        = errors.New("share has no downloads left")
+	ErrSharePasswordRequired = errors.New("share requires a password")
+	ErrShareWrongPassword    = errors.New("wrong share password")
+)
+
+// ShareService manages public shares, through which people without an
+// account, e.g. customers, download content
+type ShareService struct {
+	repo     repository.ShareRepository
+	contents *ContentService // Its clock dates and expires the records
+}
+
+// NewShareService creates a new share service
+func NewShareService(repo repository.ShareRepository, contents *ContentService) *ShareService {
+	return &ShareService{
+		repo:     repo,
+		contents: contents,
+	}
+}
+
+// ShareInput represents the settings of a new share
+type ShareInput struct {
+	Password     string `json:"password"`                       // Required to download if set
+	ExpiresIn    int    `json:"expires_in" validate:"min=0"`    // Lifetime in seconds, 0 for a share working until revoked
+	MaxDownloads int64  `json:"max_downloads" validate:"min=0"` // 0 for unlimited downloads
+}
+
+// CreateShare creates a public share of a content item
+func (s *ShareService) CreateShare(ctx context.Context, contentID uuid.UUID, createdBy string, input ShareInput) (*model.Share, error) {
+	if err := validateInput(input); err != nil {
+		return nil, err
+	}
+	// bcrypt hashes at most 72 bytes, whatever the characters
+	if len(input.Password) > maxSharePasswordLength {
+		return nil, fmt.Errorf("%w: password must be at most %d bytes", ErrInvalidInput, maxSharePasswordLength)
+	}
+
+	content, err := s.contents.GetContent(ctx, contentID)
+	if err != nil {
+		return nil, err
+	}
+	// Anyone with the link can download the content, as with download links
+	if err := CheckScanned(content); err != nil {
+		return nil, err
+	}
+	if s.contents.piiRestricted(content, HasElevatedScope(ctx)) {
+		return nil, ErrPIIRestricted
+	}
+
+	share := &model.Share{
+		ContentID:    content.ID,
+		CreatedBy:    createdBy,
+		MaxDownloads: input.MaxDownloads,
+	}
+	if input.ExpiresIn > 0 {
+		expiresAt := s.contents.now().Add(time.Duration(input.ExpiresIn) * time.Second)
+		share.ExpiresAt = &expiresAt
+	}
+	if input.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash share password: %w", err)
+		}
+		share.PasswordHash = string(hash)
+	}
+
+	// Retry the unlikely collision of random tokens
+	for attempt := 0; ; attempt++ {
+		if share.Token, err = newDownloadLinkToken(); err != nil {
+			return nil, err
+		}
+		err = s.repo.CreateShare(ctx, share)
+		if !errors.Is(err, repository.ErrShareExists) || attempt == 2 {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create share: %w", err)
+	}
+
+	s.contents.audit(ctx, model.AuditEvent{Action: "share.create", Subject: content.ID.String(), Actor: createdBy},
+		"Content %s shared by %q, password %v, max downloads %d", content.ID, createdBy, share.HasPassword(), share.MaxDownloads)
+	return share, nil
+}
+
+// ListShares retrieves a page of the shares of a content item, revoked ones included
+func (s *ShareService) ListShares(ctx context.Context, contentID uuid.UUID, options repository.ListOptions) ([]*model.Share, int64, error) {
+	return s.repo.ListSharesByContent(ctx, contentID, options)
+}
+
+// RevokeShare stops a share of a content item from working
+func (s *ShareService) RevokeShare(ctx context.Context, contentID uuid.UUID, token string, revokedBy string) error {
+	share, err := s.repo.GetShare(ctx, token)
+	if err != nil {
+		if errors.Is(err, repository.ErrShareNotFound) {
+			return ErrShareNotFound
+		}
+		return err
+	}
+	if share.ContentID != contentID {
+		return ErrShareNotFound
+	}
+
+	if err := s.repo.RevokeShare(ctx, token, s.contents.now()); err != nil {
+		if errors.Is(err, repository.ErrShareNotFound) {
+			return ErrShareNotFound
+		}
+		return err
+	}
+
+	s.contents.audit(ctx, model.AuditEvent{Action: "share.revoke", Subject: contentID.String(), Actor: revokedBy},
+		"Share of content %s revoked by %q after %d downloads", contentID, revokedBy, share.Downloads)
+	return nil
+}
+
+// RedeemShare checks the password of a share, counts a download and
+// returns the share and its content. Shares that no longer work return
+// ErrShareExpired, ErrShareRevoked or ErrShareExhausted.
+func (s *ShareService) RedeemShare(ctx context.Context, token, password string) (*model.Share, *model.Content, error) {
+	share, err := s.repo.GetShare(ctx, token)
+	if err != nil {
+		if errors.Is(err, repository.ErrShareNotFound) {
+			return nil, nil, ErrShareNotFound
+		}
+		return nil, nil, err
+	}
+
+	now := s.contents.now()
+	switch {
+	case share.RevokedAt != nil:
+		return nil, nil, ErrShareRevoked
+	case share.ExpiresAt != nil && !now.Before(*share.ExpiresAt):
+		return nil, nil, ErrShareExpired
+	case share.MaxDownloads > 0 && share.Downloads >= share.MaxDownloads:
+		return nil, nil, ErrShareExhausted
+	}
+
+	if share.HasPassword() {
+		if password == "" {
+			return nil, nil, ErrSharePasswordRequired
+		}
+		if bcrypt.CompareHashAndPassword([]byte(share.PasswordHash), []byte(password)) != nil {
+			return nil, nil, ErrShareWrongPassword
+		}
+	}
+
+	content, err := s.contents.GetContent(ctx, share.ContentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Content can be quarantined after the share was created
+	if err := CheckScanned(content); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.repo.RecordShareDownload(ctx, token, now); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrShareNotFound):
+			return nil, nil, ErrShareNotFound
+		case errors.Is(err, repository.ErrShareExhausted):
+			// Another download took the last one
+			return nil, nil, ErrShareExhausted
+		}
+		return nil, nil, err
+	}
+	share.Downloads++
+	share.LastDownloadedAt = &now
+
+	return share, content, nil
+}
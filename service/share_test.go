@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/livefire2015/simple-contents/clock"
+	"github.com/livefire2015/simple-contents/repository"
+	"github.com/livefire2015/simple-contents/repository/memory"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+)
+
+func TestShares(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepository()
+	contents := NewContentService(repo, memorystorage.NewMemoryStorage())
+	start := time.Date(2024, 7, 5, 12, 0, 0, 0, time.UTC)
+	now := clock.NewManual(start)
+	contents.ConfigureClock(now)
+	shares := NewShareService(repo, contents)
+
+	content, err := contents.CreateContent(ctx, CreateContentInput{FileName: "invoice.pdf", MIMEType: "application/pdf", FileSize: 1, Data: strings.NewReader("%")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	protected, err := shares.CreateShare(ctx, content.ID, "support", ShareInput{Password: "s3cret", MaxDownloads: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if protected.PasswordHash == "" || strings.Contains(protected.PasswordHash, "s3cret") {
+		t.Fatalf("password hash = %q, want a hash of the password", protected.PasswordHash)
+	}
+	expiring, err := shares.CreateShare(ctx, content.ID, "support", ShareInput{ExpiresIn: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, err := shares.CreateShare(ctx, content.ID, "support", ShareInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shares.RevokeShare(ctx, content.ID, revoked.Token, "support"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		password string
+		after    time.Duration // Time since the shares were created
+		wantErr  error
+	}{
+		{name: "missing password", token: protected.Token, wantErr: ErrSharePasswordRequired},
+		{name: "wrong password", token: protected.Token, password: "guess", wantErr: ErrShareWrongPassword},
+		{name: "first download", token: protected.Token, password: "s3cret"},
+		{name: "last download", token: protected.Token, password: "s3cret"},
+		{name: "no downloads left", token: protected.Token, password: "s3cret", wantErr: ErrShareExhausted},
+		{name: "before expiry", token: expiring.Token, after: 59 * time.Second},
+		{name: "expired", token: expiring.Token, after: time.Minute, wantErr: ErrShareExpired},
+		{name: "revoked", token: revoked.Token, wantErr: ErrShareRevoked},
+		{name: "unknown", token: "0123456789abcdef", wantErr: ErrShareNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now.Set(start.Add(tt.after))
+			share, got, err := shares.RedeemShare(ctx, tt.token, tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RedeemShare() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (got.ID != content.ID || share.LastDownloadedAt == nil || !share.LastDownloadedAt.Equal(now.Now())) {
+				t.Errorf("RedeemShare() = %+v, %v, want a download of the content", share, got.ID)
+			}
+		})
+	}
+
+	listed, total, err := shares.ListShares(ctx, content.ID, repository.ListOptions{ReturnTotal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 || len(listed) != 3 {
+		t.Fatalf("ListShares() = %d shares of %d, want the 3 shares", len(listed), total)
+	}
+	for _, share := range listed {
+		if share.Token == protected.Token && share.Downloads != 2 {
+			t.Errorf("protected share downloaded %d times, want 2", share.Downloads)
+		}
+	}
+}
+
+func TestCreateShareValidation(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepository()
+	contents := NewContentService(repo, memorystorage.NewMemoryStorage())
+	shares := NewShareService(repo, contents)
+	content, err := contents.CreateContent(ctx, CreateContentInput{FileName: "a.txt", MIMEType: "text/plain", FileSize: 1, Data: strings.NewReader("a")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		input ShareInput
+	}{
+		{name: "negative expiry", input: ShareInput{ExpiresIn: -1}},
+		{name: "negative max downloads", input: ShareInput{MaxDownloads: -1}},
+		{name: "password too long", input: ShareInput{Password: strings.Repeat("x", 73)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := shares.CreateShare(ctx, content.ID, "", tt.input); !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("CreateShare() error = %v, want ErrInvalidInput", err)
+			}
+		})
+	}
+}
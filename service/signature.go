@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+	"github.com/livefire2015/simple-contents/signature"
+	"github.com/livefire2015/simple-contents/storage"
+)
+
+var (
+	ErrSignatureNotFound = errors.New("signature request not found")
+	ErrUnknownProvider   = errors.New("unknown signature provider")
+)
+
+// SignedDerivation marks content that is the signed version of a document
+const SignedDerivation = "signature"
+
+// SignatureService sends content for e-signature and stores the signed documents
+type SignatureService struct {
+	repo      repository.SignatureRepository
+	contents  *ContentService
+	providers map[string]signature.Provider
+}
+
+// NewSignatureService creates a new signature service
+func NewSignatureService(repo repository.SignatureRepository, contents *ContentService) *SignatureService {
+	return &SignatureService{
+		repo:      repo,
+		contents:  contents,
+		providers: make(map[string]signature.Provider),
+	}
+}
+
+// RegisterProvider makes a provider available under its name
+func (s *SignatureService) RegisterProvider(provider signature.Provider) {
+	s.providers[provider.Name()] = provider
+}
+
+// RequestSignatureInput represents a request to sign a content item
+type RequestSignatureInput struct {
+	Provider    string         `json:"provider"`
+	Signers     []model.Signer `json:"signers" validate:"min=1,max=50"`
+	Subject     string         `json:"subject" validate:"max=255"`
+	Message     string         `json:"message" validate:"max=2000"`
+	RequestedBy string         `json:"requested_by" validate:"max=255"`
+}
+
+// RequestSignature sends a content item to a provider for signature
+func (s *SignatureService) RequestSignature(ctx context.Context, contentID uuid.UUID, input RequestSignatureInput) (*model.SignatureRequest, error) {
+	provider, ok := s.providers[input.Provider]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, input.Provider)
+	}
+	if err := validateInput(input); err != nil {
+		return nil, err
+	}
+	signers := make([]signature.Signer, len(input.Signers))
+	for i, signer := range input.Signers {
+		if signer.Name == "" || !strings.Contains(signer.Email, "@") {
+			return nil, fmt.Errorf("%w: signers need a name and an email address", ErrInvalidInput)
+		}
+		signers[i] = signature.Signer{Name: signer.Name, Email: signer.Email}
+	}
+
+	data, content, err := s.contents.GetContentData(ctx, contentID)
+	if err != nil {
+		return nil, err
+	}
+	defer data.Close()
+
+	subject := input.Subject
+	if subject == "" {
+		subject = "Please sign " + content.FileName
+	}
+
+	providerRequestID, err := provider.Send(ctx, signature.Envelope{
+		Document: data,
+		FileName: content.FileName,
+		MIMEType: content.MIMEType,
+		Subject:  subject,
+		Message:  input.Message,
+		Signers:  signers,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send %s for signature: %w", contentID, err)
+	}
+
+	request := &model.SignatureRequest{
+		ContentID:         contentID,
+		Provider:          provider.Name(),
+		ProviderRequestID: providerRequestID,
+		Status:            string(signature.StatusSent),
+		Signers:           input.Signers,
+		Subject:           subject,
+		CreatedBy:         input.RequestedBy,
+	}
+	if err := s.repo.CreateSignatureRequest(ctx, request); err != nil {
+		return nil, fmt.Errorf("failed to record signature request: %w", err)
+	}
+
+	return request, nil
+}
+
+// GetSignatureRequest retrieves a signature request by ID
+func (s *SignatureService) GetSignatureRequest(ctx context.Context, id uuid.UUID) (*model.SignatureRequest, error) {
+	request, err := s.repo.GetSignatureRequest(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrSignatureNotFound) {
+			return nil, ErrSignatureNotFound
+		}
+		return nil, err
+	}
+	return request, nil
+}
+
+// ListSignatureRequests retrieves a page of the signature requests of a content item
+func (s *SignatureService) ListSignatureRequests(ctx context.Context, contentID uuid.UUID, options repository.ListOptions) ([]*model.SignatureRequest, int64, error) {
+	if _, err := s.contents.GetContent(ctx, contentID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListSignatureRequestsByContent(ctx, contentID, options)
+}
+
+// HandleWebhook applies a provider notification and returns the response
+// body the provider expects. Once a request is completed the signed document
+// is stored as content derived from the original. Errors make the provider
+// retry the notification.
+func (s *SignatureService) HandleWebhook(ctx context.Context, providerName string, header http.Header, body []byte) (string, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownProvider, providerName)
+	}
+
+	event, err := provider.ParseWebhook(header, body)
+	if err != nil {
+		return "", err
+	}
+	if event.ProviderRequestID == "" {
+		return event.Ack, nil
+	}
+
+	request, err := s.repo.GetSignatureRequestByProviderID(ctx, providerName, event.ProviderRequestID)
+	if err != nil {
+		if errors.Is(err, repository.ErrSignatureNotFound) {
+			// Requests sent by other systems on the same account
+			return event.Ack, nil
+		}
+		return "", err
+	}
+
+	// Notifications can be repeated and arrive out of order
+	if signature.Status(request.Status).IsFinal() && request.SignedContentID != nil {
+		return event.Ack, nil
+	}
+
+	request.Status = string(event.Status)
+	if event.Status.IsFinal() && request.CompletedAt == nil {
+		now := s.contents.now()
+		request.CompletedAt = &now
+	}
+
+	var storeErr error
+	if event.Status == signature.StatusCompleted {
+		signed, err := s.storeSigned(ctx, provider, request)
+		if err != nil {
+			storeErr = err
+			request.Error = err.Error()
+		} else {
+			request.SignedContentID = &signed.ID
+			request.Error = ""
+		}
+	}
+
+	if err := s.repo.UpdateSignatureRequest(ctx, request); err != nil {
+		return "", err
+	}
+	if storeErr != nil {
+		return "", storeErr
+	}
+	return event.Ack, nil
+}
+
+// storeSigned downloads the signed document and stores it as a new version of the original
+func (s *SignatureService) storeSigned(ctx context.Context, provider signature.Provider, request *model.SignatureRequest) (*model.Content, error) {
+	original, err := s.contents.GetContent(ctx, request.ContentID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := provider.DownloadSigned(ctx, request.ProviderRequestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download signed document: %w", err)
+	}
+	defer data.Close()
+
+	originalID := original.ID
+	name := strings.TrimSuffix(original.FileName, filepath.Ext(original.FileName)) + "-signed.pdf"
+	signed, err := s.contents.CreateContent(ctx, CreateContentInput{
+		TenantID:      original.TenantID,
+		FileName:      name,
+		MIMEType:      "application/pdf",
+		FileSize:      storage.UnknownSize,
+		Data:          data,
+		CreatedBy:     request.CreatedBy,
+		Source:        "signature:" + request.Provider,
+		Metadata:      model.Metadata{"signature_request_id": request.ID.String()},
+		DerivedFromID: &originalID,
+		Derivation:    SignedDerivation,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store signed document: %w", err)
+	}
+
+	return s.contents.UpdateContentStatus(ctx, signed.ID, model.StatusDone)
+}
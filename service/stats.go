@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/livefire2015/simple-contents/model"
+)
+
+// ContentStatsResult holds the per-group totals of the content matching a listing filter
+type ContentStatsResult struct {
+	GroupBy    model.ContentGroupBy        `json:"group_by"`
+	Buckets    []*model.ContentStatsBucket `json:"buckets"`
+	Count      int64                       `json:"count"`
+	TotalBytes int64                       `json:"total_bytes"`
+}
+
+// ContentStats counts and sums the size of the content selected by input,
+// grouped by a field. Pagination and sorting of the input are ignored.
+func (s *ContentService) ContentStats(ctx context.Context, input ListContentInput, groupBy model.ContentGroupBy) (*ContentStatsResult, error) {
+	if !groupBy.IsValid() {
+		return nil, fmt.Errorf("%w: unknown grouping %q", ErrInvalidInput, groupBy)
+	}
+
+	buckets, err := s.repo.ContentStats(ctx, input.filter(), groupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ContentStatsResult{GroupBy: groupBy, Buckets: buckets}
+	for _, bucket := range buckets {
+		result.Count += bucket.Count
+		result.TotalBytes += bucket.TotalBytes
+	}
+	return result, nil
+}
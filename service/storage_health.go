@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+	"github.com/livefire2015/simple-contents/storage"
+)
+
+// ErrStorageUnavailable is matched by every StorageUnavailableError
+var ErrStorageUnavailable = errors.New("storage is unavailable")
+
+// storageHealthProbe is the object whose metadata health checks ask storage
+// for. It doesn't exist, a backend that says so is healthy.
+const storageHealthProbe = ".simple-contents-health-check"
+
+// pendingDeletionBatch is the number of queued deletions retried per listing
+const pendingDeletionBatch = 100
+
+// StorageUnavailableError is returned for uploads refused while storage
+// health checks fail
+type StorageUnavailableError struct {
+	Since      time.Time     // When storage was found unavailable
+	RetryAfter time.Duration // How long clients should wait before trying again
+}
+
+func (e *StorageUnavailableError) Error() string {
+	return fmt.Sprintf("storage is unavailable since %s, retry in %s", e.Since.Format(time.RFC3339), e.RetryAfter)
+}
+
+// Unwrap makes errors.Is(err, ErrStorageUnavailable) match
+func (e *StorageUnavailableError) Unwrap() error {
+	return ErrStorageUnavailable
+}
+
+// StorageHealthConfig controls the checks that put the service in degraded
+// mode while storage is down
+type StorageHealthConfig struct {
+	Interval         time.Duration // Time between checks
+	Timeout          time.Duration // Deadline of a check
+	FailureThreshold int           // Consecutive failed checks that degrade the service
+	RetryAfter       time.Duration // Wait suggested to clients whose uploads are refused
+}
+
+// DefaultStorageHealthConfig returns a StorageHealthConfig that notices an
+// outage within half a minute
+func DefaultStorageHealthConfig() StorageHealthConfig {
+	return StorageHealthConfig{
+		Interval:         10 * time.Second,
+		Timeout:          5 * time.Second,
+		FailureThreshold: 3,
+		RetryAfter:       30 * time.Second,
+	}
+}
+
+// StorageHealth is the state of storage as seen by the health checks
+type StorageHealth struct {
+	Degraded  bool       `json:"degraded"`
+	Since     *time.Time `json:"since,omitempty"` // When the service was degraded
+	LastCheck time.Time  `json:"last_check"`
+	LastError string     `json:"last_error,omitempty"` // Error of the last failed check
+}
+
+// storageMonitor tracks the results of storage health checks
+type storageMonitor struct {
+	config    StorageHealthConfig
+	deletions repository.PendingDeletionRepository
+
+	mu       sync.Mutex
+	failures int
+	health   StorageHealth
+	// pending is set while deletions may be queued, including those left
+	// by an earlier run of the service
+	pending bool
+}
+
+// record updates the health with the result of a check and reports whether
+// storage just recovered
+func (m *storageMonitor) record(err error, now time.Time) (degraded, recovered bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err == nil {
+		recovered = m.health.Degraded
+		m.failures = 0
+		m.health = StorageHealth{LastCheck: now}
+		return false, recovered
+	}
+
+	m.failures++
+	m.health.LastCheck = now
+	m.health.LastError = err.Error()
+	if !m.health.Degraded && m.failures >= m.config.FailureThreshold {
+		m.health.Degraded = true
+		m.health.Since = &now
+		return true, false
+	}
+	return false, false
+}
+
+// unavailable returns the error refusing uploads, or nil if storage is healthy
+func (m *storageMonitor) unavailable() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.health.Degraded {
+		return nil
+	}
+	return &StorageUnavailableError{Since: *m.health.Since, RetryAfter: m.config.RetryAfter}
+}
+
+// setPending records whether deletions may be waiting to be retried
+func (m *storageMonitor) setPending(pending bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pending = pending
+}
+
+// hasPending reports whether deletions may be waiting to be retried
+func (m *storageMonitor) hasPending() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.pending
+}
+
+// EnableStorageHealthChecks makes RunStorageHealthChecks degrade the service
+// while storage fails its checks: metadata is still served, uploads are
+// refused with a StorageUnavailableError and the data of deleted content is
+// queued in deletions, to be deleted once storage is healthy again.
+func (s *ContentService) EnableStorageHealthChecks(config StorageHealthConfig, deletions repository.PendingDeletionRepository) {
+	defaults := DefaultStorageHealthConfig()
+	if config.Interval <= 0 {
+		config.Interval = defaults.Interval
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaults.Timeout
+	}
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = defaults.FailureThreshold
+	}
+	if config.RetryAfter <= 0 {
+		config.RetryAfter = defaults.RetryAfter
+	}
+	s.storageMonitor = &storageMonitor{config: config, deletions: deletions, pending: deletions != nil}
+}
+
+// StorageHealth returns the state of storage, which is always healthy
+// without health checks
+func (s *ContentService) StorageHealth() StorageHealth {
+	if s.storageMonitor == nil {
+		return StorageHealth{}
+	}
+	s.storageMonitor.mu.Lock()
+	defer s.storageMonitor.mu.Unlock()
+	return s.storageMonitor.health
+}
+
+// RunStorageHealthChecks checks storage every interval until ctx is done
+func (s *ContentService) RunStorageHealthChecks(ctx context.Context) {
+	if s.storageMonitor == nil {
+		return
+	}
+
+	ticker := time.NewTicker(s.storageMonitor.config.Interval)
+	defer ticker.Stop()
+	for {
+		_ = s.CheckStorage(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckStorage asks storage for the metadata of a probe object and records
+// whether it answered. Once storage is healthy, the deletions queued while it
+// wasn't are retried.
+func (s *ContentService) CheckStorage(ctx context.Context) error {
+	m := s.storageMonitor
+	if m == nil {
+		return nil
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, m.config.Timeout)
+	_, err := s.storage.Stat(checkCtx, storageHealthProbe)
+	cancel()
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrAccessDenied) {
+		// An answer about the probe, storage is up
+		err = nil
+	}
+	if ctx.Err() != nil {
+		// Shutting down says nothing about storage
+		return ctx.Err()
+	}
+
+	degraded, recovered := m.record(err, time.Now())
+	switch {
+	case degraded:
+		log.Printf("Storage is unavailable, refusing uploads until it recovers: %v", err)
+	case recovered:
+		log.Printf("Storage has recovered, accepting uploads again")
+	}
+	if err != nil {
+		return err
+	}
+
+	if m.hasPending() {
+		deleted, err := s.RetryPendingDeletions(ctx)
+		if deleted > 0 {
+			log.Printf("Deleted %d objects queued while storage was unavailable", deleted)
+		}
+		if err != nil {
+			log.Printf("Failed to retry queued storage deletions: %v", err)
+		}
+	}
+	return nil
+}
+
+// checkStorageAvailable refuses uploads while the service is degraded,
+// rather than letting them wait for a backend that is down
+func (s *ContentService) checkStorageAvailable() error {
+	if s.storageMonitor == nil {
+		return nil
+	}
+	return s.storageMonitor.unavailable()
+}
+
+// deleteContentObjects removes the stored data of deleted content and
+// returns the first error
+func (s *ContentService) deleteContentObjects(ctx context.Context, content *model.Content) error {
+	if content.IsReference() {
+		return nil
+	}
+	err := s.deleteObject(ctx, content.TenantID, content.StoragePath)
+	if content.OriginalStoragePath != "" {
+		if originalErr := s.deleteObject(ctx, content.TenantID, content.OriginalStoragePath); err == nil {
+			err = originalErr
+		}
+	}
+	return err
+}
+
+// deleteObject deletes a stored object of deleted content. With health
+// checks enabled, objects are queued instead while storage is degraded, and
+// when storage fails to delete them.
+func (s *ContentService) deleteObject(ctx context.Context, tenantID, storagePath string) error {
+	m := s.storageMonitor
+	if m == nil || m.deletions == nil {
+		return s.storage.Delete(storage.WithTenant(ctx, tenantID), storagePath)
+	}
+
+	if m.unavailable() == nil {
+		err := s.storage.Delete(storage.WithTenant(ctx, tenantID), storagePath)
+		if err == nil || errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrAccessDenied) {
+			return err
+		}
+	}
+
+	deletion := &model.PendingDeletion{TenantID: tenantID, StoragePath: storagePath}
+	if err := m.deletions.QueueDeletion(ctx, deletion); err != nil {
+		return fmt.Errorf("failed to queue the deletion of %s: %w", storagePath, err)
+	}
+	m.setPending(true)
+	return nil
+}
+
+// RetryPendingDeletions deletes the objects queued while storage was
+// unavailable, oldest first, and returns how many it deleted. It stops at
+// the first object storage still fails to delete.
+func (s *ContentService) RetryPendingDeletions(ctx context.Context) (int, error) {
+	m := s.storageMonitor
+	if m == nil || m.deletions == nil {
+		return 0, nil
+	}
+	if err := m.unavailable(); err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for {
+		deletions, err := m.deletions.ListPendingDeletions(ctx, pendingDeletionBatch)
+		if err != nil {
+			return deleted, err
+		}
+
+		for _, deletion := range deletions {
+			err := s.storage.Delete(storage.WithTenant(ctx, deletion.TenantID), deletion.StoragePath)
+			if err != nil && !errors.Is(err, storage.ErrNotFound) {
+				return deleted, fmt.Errorf("failed to delete %s: %w", deletion.StoragePath, err)
+			}
+			if err := m.deletions.RemovePendingDeletion(ctx, deletion.ID); err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+
+		if len(deletions) < pendingDeletionBatch {
+			m.setPending(false)
+			return deleted, nil
+		}
+	}
+}
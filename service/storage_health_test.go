@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository/memory"
+	"github.com/livefire2015/simple-contents/storage"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+)
+
+var errOutage = errors.New("connection refused")
+
+// outageStorage is a memory storage whose Stat and Delete fail while down is
+// set, and that counts the deletions it is asked for
+type outageStorage struct {
+	*memorystorage.MemoryStorage
+
+	down    atomic.Bool
+	deletes atomic.Int32
+}
+
+func (s *outageStorage) Stat(ctx context.Context, path string) (*storage.ObjectInfo, error) {
+	if s.down.Load() {
+		return nil, errOutage
+	}
+	return s.MemoryStorage.Stat(ctx, path)
+}
+
+func (s *outageStorage) Delete(ctx context.Context, path string) error {
+	s.deletes.Add(1)
+	if s.down.Load() {
+		return errOutage
+	}
+	return s.MemoryStorage.Delete(ctx, path)
+}
+
+func TestStorageDegradedMode(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepository()
+	store := &outageStorage{MemoryStorage: memorystorage.NewMemoryStorage()}
+	s := NewContentService(repo, store)
+	s.EnableStorageHealthChecks(StorageHealthConfig{FailureThreshold: 2, RetryAfter: time.Minute}, repo)
+
+	create := func() (*model.Content, error) {
+		return s.CreateContent(ctx, CreateContentInput{FileName: "a.txt", MIMEType: "text/plain", FileSize: 1, Data: strings.NewReader("a")})
+	}
+	content, err := create()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One failed check isn't an outage yet
+	store.down.Store(true)
+	if err := s.CheckStorage(ctx); !errors.Is(err, errOutage) {
+		t.Fatalf("CheckStorage() error = %v, want the storage error", err)
+	}
+	if s.StorageHealth().Degraded {
+		t.Fatal("degraded after a single failed check")
+	}
+	_ = s.CheckStorage(ctx)
+	if health := s.StorageHealth(); !health.Degraded || health.Since == nil || health.LastError == "" {
+		t.Fatalf("StorageHealth() = %+v, want degraded since the second failed check", health)
+	}
+
+	var unavailableErr *StorageUnavailableError
+	if _, err := create(); !errors.As(err, &unavailableErr) || unavailableErr.RetryAfter != time.Minute {
+		t.Fatalf("CreateContent() while degraded error = %v, want a StorageUnavailableError retrying after a minute", err)
+	}
+	if _, _, err := s.CreateDirectUpload(ctx, DirectUploadInput{FileName: "b.txt", MIMEType: "text/plain", FileSize: 1}); !errors.Is(err, ErrStorageUnavailable) {
+		t.Fatalf("CreateDirectUpload() while degraded error = %v, want ErrStorageUnavailable", err)
+	}
+	if _, err := s.GetContent(ctx, content.ID); err != nil {
+		t.Fatalf("GetContent() while degraded error = %v", err)
+	}
+
+	// The deletion is queued without waiting for storage
+	store.deletes.Store(0)
+	if _, err := s.DeleteContent(ctx, content.ID, DeleteContentOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if n := store.deletes.Load(); n != 0 {
+		t.Errorf("storage asked for %d deletions while degraded, want 0", n)
+	}
+	queued, err := repo.ListPendingDeletions(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queued) != 1 || queued[0].StoragePath != content.StoragePath {
+		t.Fatalf("pending deletions = %v, want %s", queued, content.StoragePath)
+	}
+
+	// Recovery accepts uploads again and carries out the queued deletion
+	store.down.Store(false)
+	if err := s.CheckStorage(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s.StorageHealth().Degraded {
+		t.Error("still degraded after a successful check")
+	}
+	if queued, _ := repo.ListPendingDeletions(ctx, 10); len(queued) != 0 {
+		t.Errorf("%d deletions still pending after recovery", len(queued))
+	}
+	if _, err := store.MemoryStorage.Stat(ctx, content.StoragePath); err == nil {
+		t.Error("data of the deleted content still stored after recovery")
+	}
+	if _, err := create(); err != nil {
+		t.Errorf("CreateContent() after recovery error = %v", err)
+	}
+}
+
+func TestFailedDeletionIsQueued(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepository()
+	store := &outageStorage{MemoryStorage: memorystorage.NewMemoryStorage()}
+	s := NewContentService(repo, store)
+	s.EnableStorageHealthChecks(StorageHealthConfig{}, repo)
+
+	content, err := s.CreateContent(ctx, CreateContentInput{FileName: "a.txt", MIMEType: "text/plain", FileSize: 1, Data: strings.NewReader("a")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Storage fails before any check noticed
+	store.down.Store(true)
+	result, err := s.BulkDelete(ctx, BulkDeleteInput{IDs: []uuid.UUID{content.ID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Items) != 1 || result.Items[0].Status != BulkDeleted {
+		t.Errorf("bulk delete items = %+v, want deleted with the storage deletion queued", result.Items)
+	}
+
+	if _, err := s.RetryPendingDeletions(ctx); !errors.Is(err, errOutage) {
+		t.Fatalf("RetryPendingDeletions() during the outage error = %v, want the storage error", err)
+	}
+	store.down.Store(false)
+	deleted, err := s.RetryPendingDeletions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 1 {
+		t.Errorf("RetryPendingDeletions() = %d, want 1", deleted)
+	}
+	if _, err := store.MemoryStorage.Stat(ctx, content.StoragePath); err == nil {
+		t.Error("data of the deleted content still stored after the retry")
+	}
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+)
+
+// untenantedKeyPrefix is the tenant segment of keys of untenanted content
+const untenantedKeyPrefix = "_"
+
+const (
+	defaultKeyLayoutDepth = 1
+	maxKeyLayoutDepth     = 8
+	defaultKeyLayoutLimit = 100
+)
+
+// KeyInput is what the storage key of new content is built from
+type KeyInput struct {
+	ContentID uuid.UUID
+	TenantID  string
+	FileName  string
+	CreatedAt time.Time // UTC
+}
+
+// KeyStrategy builds the storage keys of new content. Content keeps the key
+// it was stored under, so changing the strategy only affects new content.
+type KeyStrategy interface {
+	Key(input KeyInput) string
+}
+
+// FlatKeys stores content under {id}/{file name}, the default
+type FlatKeys struct{}
+
+func (FlatKeys) Key(input KeyInput) string {
+	return path.Join(input.ContentID.String(), input.FileName)
+}
+
+// DatePartitionedKeys prefixes the keys of Next with the creation date as
+// {yyyy}/{mm}/{dd}, so lifecycle rules can target the content of a period
+type DatePartitionedKeys struct {
+	Next KeyStrategy // FlatKeys if nil
+}
+
+func (k DatePartitionedKeys) Key(input KeyInput) string {
+	return path.Join(input.CreatedAt.Format("2006/01/02"), nextKey(k.Next, input))
+}
+
+// HashPrefixedKeys prefixes the keys of Next with the first two hex digits
+// of the SHA-256 of the content ID, spreading writes over 256 prefixes
+// whatever the order of the IDs
+type HashPrefixedKeys struct {
+	Next KeyStrategy // FlatKeys if nil
+}
+
+func (k HashPrefixedKeys) Key(input KeyInput) string {
+	sum := sha256.Sum256(input.ContentID[:])
+	return path.Join(hex.EncodeToString(sum[:1]), nextKey(k.Next, input))
+}
+
+// TenantPrefixedKeys prefixes the keys of Next with the tenant ID, or _ for
+// untenanted content, so lifecycle rules can target the content of a tenant
+// in a shared bucket
+type TenantPrefixedKeys struct {
+	Next KeyStrategy // FlatKeys if nil
+}
+
+func (k TenantPrefixedKeys) Key(input KeyInput) string {
+	tenant := input.TenantID
+	if tenant == "" {
+		tenant = untenantedKeyPrefix
+	} else if !tenantIDPattern.MatchString(tenant) {
+		// Tenant IDs aren't checked without a tenant registry; escaping
+		// keeps one from adding or leaving segments of the key
+		tenant = untenantedKeyPrefix + url.PathEscape(tenant)
+	}
+	return path.Join(tenant, nextKey(k.Next, input))
+}
+
+// nextKey returns the key of the strategy a prefix is put in front of
+func nextKey(next KeyStrategy, input KeyInput) string {
+	if next == nil {
+		next = FlatKeys{}
+	}
+	return next.Key(input)
+}
+
+// ParseKeyStrategy parses a key layout: flat, or the prefixes put in front
+// of {id}/{file name} separated by slashes, each of tenant, date and hash,
+// e.g. tenant/date
+func ParseKeyStrategy(layout string) (KeyStrategy, error) {
+	if layout == "" || layout == "flat" {
+		return FlatKeys{}, nil
+	}
+	prefixes := strings.Split(layout, "/")
+	seen := make(map[string]bool, len(prefixes))
+	var strategy KeyStrategy = FlatKeys{}
+	for i := len(prefixes) - 1; i >= 0; i-- {
+		prefix := prefixes[i]
+		if seen[prefix] {
+			return nil, fmt.Errorf("%w: key prefix %q is repeated", ErrInvalidInput, prefix)
+		}
+		seen[prefix] = true
+		switch prefix {
+		case "tenant":
+			strategy = TenantPrefixedKeys{Next: strategy}
+		case "date":
+			strategy = DatePartitionedKeys{Next: strategy}
+		case "hash":
+			strategy = HashPrefixedKeys{Next: strategy}
+		default:
+			return nil, fmt.Errorf("%w: unknown key prefix %q, want tenant, date or hash", ErrInvalidInput, prefix)
+		}
+	}
+	return strategy, nil
+}
+
+// ConfigureKeyStrategy sets how the storage keys of new content are built
+func (s *ContentService) ConfigureKeyStrategy(strategy KeyStrategy) {
+	s.keys = strategy
+}
+
+// storageKey returns the key new content is stored under, within the key
+// limits of the service
+func (s *ContentService) storageKey(contentID uuid.UUID, tenantID, fileName string) (string, error) {
+	return s.keyLimits.limitKey(s.keys, KeyInput{ContentID: contentID, TenantID: tenantID, FileName: fileName, CreatedAt: s.now()})
+}
+
+// KeyPrefixStats is the stored content under a key prefix
+type KeyPrefixStats struct {
+	Prefix   string    `json:"prefix"`
+	Objects  int64     `json:"objects"`
+	Bytes    int64     `json:"bytes"`
+	NewestAt time.Time `json:"newest_at"` // Creation time of the newest content
+}
+
+// KeyLayoutReport shows how stored content spreads over the leading
+// segments of its storage keys, to spot prefixes that take most writes
+type KeyLayoutReport struct {
+	Depth    int               `json:"depth"`
+	Objects  int64             `json:"objects"`
+	Distinct int               `json:"distinct_prefixes"`
+	TopShare float64           `json:"top_share"` // Share of the objects under the largest prefix
+	Prefixes []*KeyPrefixStats `json:"prefixes"`  // Largest first, up to the limit
+}
+
+// KeyLayout reports the content stored under each prefix of depth key
+// segments, of one tenant or all if tenantID is empty. The file name is
+// never part of a prefix. Only the limit largest prefixes are listed.
+func (s *ContentService) KeyLayout(ctx context.Context, tenantID string, depth, limit int) (*KeyLayoutReport, error) {
+	if depth == 0 {
+		depth = defaultKeyLayoutDepth
+	}
+	if depth < 1 || depth > maxKeyLayoutDepth {
+		return nil, fmt.Errorf("%w: depth must be between 1 and %d", ErrInvalidInput, maxKeyLayoutDepth)
+	}
+	if limit <= 0 {
+		limit = defaultKeyLayoutLimit
+	}
+
+	report := &KeyLayoutReport{Depth: depth}
+	prefixes := make(map[string]*KeyPrefixStats)
+	err := s.repo.ListContentStream(ctx, model.ContentFilter{TenantID: tenantID}, func(content *model.Content) error {
+		if content.StoragePath == "" {
+			return nil
+		}
+		prefix := keyPrefix(content.StoragePath, depth)
+		stats, ok := prefixes[prefix]
+		if !ok {
+			stats = &KeyPrefixStats{Prefix: prefix}
+			prefixes[prefix] = stats
+		}
+		stats.Objects++
+		if content.FileSize > 0 {
+			stats.Bytes += content.FileSize
+		}
+		if content.CreatedAt.After(stats.NewestAt) {
+			stats.NewestAt = content.CreatedAt
+		}
+		report.Objects++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.Prefixes = make([]*KeyPrefixStats, 0, len(prefixes))
+	for _, stats := range prefixes {
+		report.Prefixes = append(report.Prefixes, stats)
+	}
+	sort.Slice(report.Prefixes, func(i, j int) bool {
+		a, b := report.Prefixes[i], report.Prefixes[j]
+		if a.Objects != b.Objects {
+			return a.Objects > b.Objects
+		}
+		return a.Prefix < b.Prefix
+	})
+	report.Distinct = len(report.Prefixes)
+	if report.Objects > 0 {
+		report.TopShare = float64(report.Prefixes[0].Objects) / float64(report.Objects)
+	}
+	if len(report.Prefixes) > limit {
+		report.Prefixes = report.Prefixes[:limit]
+	}
+	return report, nil
+}
+
+// keyPrefix returns up to depth leading segments of a key, leaving out its
+// last segment, the file name
+func keyPrefix(key string, depth int) string {
+	segments := strings.Split(key, "/")
+	segments = segments[:len(segments)-1]
+	if len(segments) > depth {
+		segments = segments[:depth]
+	}
+	return strings.Join(segments, "/") + "/"
+}
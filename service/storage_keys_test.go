@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/clock"
+	"github.com/livefire2015/simple-contents/repository/memory"
+)
+
+func TestKeyStrategies(t *testing.T) {
+	input := KeyInput{
+		ContentID: uuid.MustParse("0190a6e2-7c1e-7b3a-9f4e-2d5c8b1a6f00"),
+		TenantID:  "acme",
+		FileName:  "scan.pdf",
+		CreatedAt: time.Date(2024, 7, 5, 23, 30, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		layout  string
+		want    string
+		wantErr bool
+	}{
+		{layout: "", want: "0190a6e2-7c1e-7b3a-9f4e-2d5c8b1a6f00/scan.pdf"},
+		{layout: "flat", want: "0190a6e2-7c1e-7b3a-9f4e-2d5c8b1a6f00/scan.pdf"},
+		{layout: "date", want: "2024/07/05/0190a6e2-7c1e-7b3a-9f4e-2d5c8b1a6f00/scan.pdf"},
+		{layout: "tenant/date", want: "acme/2024/07/05/0190a6e2-7c1e-7b3a-9f4e-2d5c8b1a6f00/scan.pdf"},
+		{layout: "hash/tenant", want: hashPrefix(input.ContentID) + "/acme/0190a6e2-7c1e-7b3a-9f4e-2d5c8b1a6f00/scan.pdf"},
+		{layout: "date/date", wantErr: true},
+		{layout: "month", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.layout, func(t *testing.T) {
+			strategy, err := ParseKeyStrategy(tt.layout)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidInput) {
+					t.Fatalf("ParseKeyStrategy(%q) error = %v, want %v", tt.layout, err, ErrInvalidInput)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseKeyStrategy(%q) error = %v", tt.layout, err)
+			}
+			if got := strategy.Key(input); got != tt.want {
+				t.Errorf("Key() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	untenanted := input
+	untenanted.TenantID = ""
+	if got, want := (TenantPrefixedKeys{}).Key(untenanted), "_/0190a6e2-7c1e-7b3a-9f4e-2d5c8b1a6f00/scan.pdf"; got != want {
+		t.Errorf("Key() of untenanted content = %q, want %q", got, want)
+	}
+}
+
+// hashPrefix returns the hash prefix HashPrefixedKeys puts in front of the keys of a content ID
+func hashPrefix(id uuid.UUID) string {
+	key := (HashPrefixedKeys{}).Key(KeyInput{ContentID: id, FileName: "f"})
+	prefix, _, _ := strings.Cut(key, "/")
+	return prefix
+}
+
+func TestKeyLayout(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepository()
+	s := newTestInstance(repo)
+	now := clock.NewManual(time.Date(2024, 7, 5, 12, 0, 0, 0, time.UTC))
+	s.ConfigureClock(now)
+	s.ConfigureKeyStrategy(DatePartitionedKeys{})
+
+	for _, day := range []int{0, 0, 1} {
+		now.Set(time.Date(2024, 7, 5+day, 12, 0, 0, 0, time.UTC))
+		content, err := s.CreateContent(ctx, CreateContentInput{
+			FileName: "scan.pdf", MIMEType: "application/pdf", FileSize: 3, Data: strings.NewReader("pdf"),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(content.StoragePath, now.Now().Format("2006/01/02/")) {
+			t.Errorf("StoragePath = %q, want under the creation date", content.StoragePath)
+		}
+	}
+
+	report, err := s.KeyLayout(ctx, "", 3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Objects != 3 || report.Distinct != 2 || len(report.Prefixes) != 2 {
+		t.Fatalf("KeyLayout() = %+v, want 3 objects under 2 prefixes", report)
+	}
+	if top := report.Prefixes[0]; top.Prefix != "2024/07/05/" || top.Objects != 2 || top.Bytes != 6 {
+		t.Errorf("largest prefix = %+v, want 2 objects of 3 bytes under 2024/07/05/", top)
+	}
+	if report.TopShare < 0.66 || report.TopShare > 0.67 {
+		t.Errorf("TopShare = %v, want 2/3", report.TopShare)
+	}
+
+	if report, err := s.KeyLayout(ctx, "", 1, 0); err != nil || report.Distinct != 1 || report.Prefixes[0].Prefix != "2024/" {
+		t.Errorf("KeyLayout(depth 1) = %+v, %v, want a single prefix 2024/", report, err)
+	}
+	if _, err := s.KeyLayout(ctx, "", 9, 0); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("KeyLayout(depth 9) error = %v, want %v", err, ErrInvalidInput)
+	}
+}
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+var (
+	ErrTemplateNotFound  = errors.New("template not found")
+	ErrTemplateExists    = errors.New("template already exists")
+	ErrRenderingDisabled = errors.New("PDF rendering is not configured")
+)
+
+// templateIDPattern restricts template IDs to values safe for URLs
+var templateIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// TemplateService manages document templates and renders them to content
+type TemplateService struct {
+	repo     repository.TemplateRepository
+	contents *ContentService
+	renderer PDFRenderer
+}
+
+// NewTemplateService creates a new template service
+func NewTemplateService(repo repository.TemplateRepository, contents *ContentService) *TemplateService {
+	return &TemplateService{
+		repo:     repo,
+		contents: contents,
+	}
+}
+
+// ConfigureRenderer enables rendering templates to PDF
+func (s *TemplateService) ConfigureRenderer(renderer PDFRenderer) {
+	s.renderer = renderer
+}
+
+// TemplateInput represents the settings of a template when creating or updating it
+type TemplateInput struct {
+	ID          string               `json:"id"`
+	Name        string               `json:"name" validate:"required,max=255"`
+	Format      model.TemplateFormat `json:"format"`
+	Body        string               `json:"body"`
+	Stylesheet  string               `json:"stylesheet"`
+	FileName    string               `json:"file_name" validate:"max=255"`
+	Description string               `json:"description" validate:"max=2000"`
+}
+
+// validate checks the settings shared by create and update
+func (input TemplateInput) validate() error {
+	if err := validateInput(input); err != nil {
+		return err
+	}
+	if !input.Format.IsValid() {
+		return fmt.Errorf("%w: template format must be html or markdown", ErrInvalidInput)
+	}
+	if _, err := parseTemplate(input.Format, input.Body); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	return nil
+}
+
+// executor is the part of text/template and html/template used for rendering
+type executor interface {
+	Execute(w io.Writer, data any) error
+}
+
+// parseTemplate parses a template body. HTML templates escape the payload
+// contextually; Markdown is escaped when converted to HTML.
+func parseTemplate(format model.TemplateFormat, body string) (executor, error) {
+	if format == model.TemplateHTML {
+		return htmltemplate.New("body").Option("missingkey=error").Parse(body)
+	}
+	return texttemplate.New("body").Option("missingkey=error").Parse(body)
+}
+
+// CreateTemplate registers a new template
+func (s *TemplateService) CreateTemplate(ctx context.Context, input TemplateInput) (*model.Template, error) {
+	if !templateIDPattern.MatchString(input.ID) {
+		return nil, fmt.Errorf("%w: template id must be lowercase letters, digits and dashes", ErrInvalidInput)
+	}
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	template := &model.Template{
+		ID:          input.ID,
+		Name:        input.Name,
+		Format:      input.Format,
+		Body:        input.Body,
+		Stylesheet:  input.Stylesheet,
+		FileName:    input.FileName,
+		Description: input.Description,
+	}
+
+	if err := s.repo.CreateTemplate(ctx, template); err != nil {
+		if errors.Is(err, repository.ErrTemplateExists) {
+			return nil, ErrTemplateExists
+		}
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+
+	return template, nil
+}
+
+// GetTemplate retrieves a template by ID
+func (s *TemplateService) GetTemplate(ctx context.Context, id string) (*model.Template, error) {
+	template, err := s.repo.GetTemplate(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrTemplateNotFound) {
+			return nil, ErrTemplateNotFound
+		}
+		return nil, err
+	}
+	return template, nil
+}
+
+// ListTemplates retrieves a page of templates
+func (s *TemplateService) ListTemplates(ctx context.Context, options repository.ListOptions) ([]*model.Template, int64, error) {
+	return s.repo.ListTemplates(ctx, options)
+}
+
+// UpdateTemplate replaces an existing template
+func (s *TemplateService) UpdateTemplate(ctx context.Context, id string, input TemplateInput) (*model.Template, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	template, err := s.GetTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	template.Name = input.Name
+	template.Format = input.Format
+	template.Body = input.Body
+	template.Stylesheet = input.Stylesheet
+	template.FileName = input.FileName
+	template.Description = input.Description
+
+	if err := s.repo.UpdateTemplate(ctx, template); err != nil {
+		if errors.Is(err, repository.ErrTemplateNotFound) {
+			return nil, ErrTemplateNotFound
+		}
+		return nil, fmt.Errorf("failed to update template: %w", err)
+	}
+
+	return template, nil
+}
+
+// DeleteTemplate removes a template. Content rendered from it is kept.
+func (s *TemplateService) DeleteTemplate(ctx context.Context, id string) error {
+	if err := s.repo.DeleteTemplate(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrTemplateNotFound) {
+			return ErrTemplateNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// RenderOutput is the format of rendered documents
+type RenderOutput string
+
+const (
+	RenderPDF  RenderOutput = "pdf"
+	RenderHTML RenderOutput = "html"
+)
+
+// RenderTemplateInput represents a request to render a template to content
+type RenderTemplateInput struct {
+	Payload    map[string]interface{} `json:"payload"`   // Data referenced by the template
+	Output     RenderOutput           `json:"output"`    // pdf unless set
+	FileName   string                 `json:"file_name"` // Defaults to the template's file name or ID
+	TenantID   string                 `json:"-"`
+	EntityType string                 `json:"entity_type"` // Entity the document is attached to, e.g. a transaction
+	EntityID   string                 `json:"entity_id"`
+	CreatedBy  string                 `json:"created_by"`
+	Metadata   model.Metadata         `json:"metadata"`
+}
+
+// RenderHTML renders a template with a payload to a complete HTML document
+func (s *TemplateService) RenderHTML(ctx context.Context, id string, payload map[string]interface{}) ([]byte, *model.Template, error) {
+	template, err := s.GetTemplate(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tmpl, err := parseTemplate(template.Format, template.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, payload); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	content := body.String()
+	if template.Format == model.TemplateMarkdown {
+		content = markdownToHTML(content)
+	}
+
+	return htmlDocument(template, content), template, nil
+}
+
+// htmlDocument wraps rendered content in a document with the template's stylesheet
+func htmlDocument(template *model.Template, content string) []byte {
+	style := ""
+	if template.Stylesheet != "" {
+		style = "<style>\n" + template.Stylesheet + "\n</style>\n"
+	}
+
+	// HTML templates may be complete documents already
+	if strings.Contains(strings.ToLower(content), "<html") {
+		if i := strings.Index(strings.ToLower(content), "</head>"); i >= 0 && style != "" {
+			content = content[:i] + style + content[i:]
+		}
+		return []byte(content)
+	}
+
+	var doc bytes.Buffer
+	doc.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	doc.WriteString("<title>" + htmltemplate.HTMLEscapeString(template.Name) + "</title>\n")
+	doc.WriteString(style)
+	doc.WriteString("</head>\n<body>\n" + content + "</body>\n</html>\n")
+	return doc.Bytes()
+}
+
+// RenderTemplate renders a template with a payload and stores the document as
+// content, associated with an entity if one is given
+func (s *TemplateService) RenderTemplate(ctx context.Context, id string, input RenderTemplateInput) (*model.Content, error) {
+	output := input.Output
+	if output == "" {
+		output = RenderPDF
+	}
+	if output != RenderPDF && output != RenderHTML {
+		return nil, fmt.Errorf("%w: output must be pdf or html", ErrInvalidInput)
+	}
+	if output == RenderPDF && s.renderer == nil {
+		return nil, ErrRenderingDisabled
+	}
+
+	doc, template, err := s.RenderHTML(ctx, id, input.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	fileName := input.FileName
+	if fileName == "" {
+		fileName = template.FileName
+	}
+	if fileName == "" {
+		fileName = template.ID
+	}
+	fileName = strings.TrimSuffix(fileName, filepath.Ext(fileName)) + "." + string(output)
+
+	data, mimeType := doc, "text/html"
+	if output == RenderPDF {
+		data, err = s.renderPDF(ctx, doc)
+		if err != nil {
+			return nil, err
+		}
+		mimeType = "application/pdf"
+	}
+
+	metadata := input.Metadata
+	if metadata == nil {
+		metadata = make(model.Metadata)
+	}
+	metadata["template_id"] = template.ID
+
+	content, err := s.contents.CreateContent(ctx, CreateContentInput{
+		TenantID:   input.TenantID,
+		FileName:   fileName,
+		MIMEType:   mimeType,
+		FileSize:   int64(len(data)),
+		Data:       bytes.NewReader(data),
+		CreatedBy:  input.CreatedBy,
+		EntityType: input.EntityType,
+		EntityID:   input.EntityID,
+		Source:     "template",
+		Metadata:   metadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.contents.UpdateContentStatus(ctx, content.ID, model.StatusDone)
+}
+
+// renderPDF prints an HTML document with the configured renderer
+func (s *TemplateService) renderPDF(ctx context.Context, doc []byte) ([]byte, error) {
+	workDir, err := os.MkdirTemp("", "template-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	dst := filepath.Join(workDir, "document.pdf")
+	if err := s.renderer.RenderPDF(ctx, doc, dst); err != nil {
+		return nil, fmt.Errorf("failed to render PDF: %w", err)
+	}
+	return os.ReadFile(dst)
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+var (
+	ErrTenantNotFound = errors.New("tenant not found")
+	ErrTenantExists   = errors.New("tenant already exists")
+	ErrPrefixConflict = errors.New("storage prefix overlaps another tenant's")
+)
+
+// tenantIDPattern restricts tenant IDs to values safe for storage keys and URLs
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// TenantService handles business logic for tenant management
+type TenantService struct {
+	repo repository.TenantRepository
+}
+
+// NewTenantService creates a new tenant service
+func NewTenantService(repo repository.TenantRepository) *TenantService {
+	return &TenantService{
+		repo: repo,
+	}
+}
+
+// TenantInput represents the settings of a tenant when creating or updating it
+type TenantInput struct {
+	ID                  string                `json:"id"`
+	Name                string                `json:"name" validate:"required,max=255"`
+	StorageBackend      string                `json:"storage_backend" validate:"max=100"`
+	StoragePrefix       string                `json:"storage_prefix" validate:"max=255"`
+	CDN                 string                `json:"cdn" validate:"max=100"`
+	QuotaBytes          int64                 `json:"quota_bytes" validate:"min=0"`
+	QuotaWarnPercents   []int                 `json:"quota_warn_percents" validate:"max=10"`
+	Retention           model.RetentionPolicy `json:"retention"`
+	EncryptionKeyRef    string                `json:"encryption_key_ref" validate:"max=255"`
+	MaxURLExpirySeconds int                   `json:"max_url_expiry_seconds" validate:"min=0"`
+}
+
+// validate checks the settings shared by create and update
+func (input TenantInput) validate() error {
+	if err := validateInput(input); err != nil {
+		return err
+	}
+	for _, percent := range input.QuotaWarnPercents {
+		if percent <= 0 || percent > 100 {
+			return fmt.Errorf("%w: quota_warn_percents must be between 1 and 100", ErrInvalidInput)
+		}
+	}
+	return nil
+}
+
+// normalizeStoragePrefix checks a storage prefix and ends it with a "/", so
+// that a prefix never matches the keys of a sibling, e.g. "acme" those of "acme-eu"
+func normalizeStoragePrefix(prefix string) (string, error) {
+	if prefix == "" {
+		return "", nil
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	for _, segment := range strings.Split(prefix, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", fmt.Errorf("%w: storage_prefix must be relative and must not contain empty, . or .. segments", ErrInvalidInput)
+		}
+	}
+	return prefix + "/", nil
+}
+
+// storagePrefix returns the key prefix the objects of a tenant are stored under
+func storagePrefix(tenant *model.Tenant) string {
+	if tenant.StoragePrefix == "" {
+		return tenant.ID + "/"
+	}
+	return tenant.StoragePrefix
+}
+
+// checkPrefixConflict fails if the objects of tenant could be confused with
+// those of another tenant stored on the same backend, i.e. if either prefix
+// starts with the other
+func (s *TenantService) checkPrefixConflict(ctx context.Context, tenant *model.Tenant) error {
+	const pageSize = 100
+
+	prefix := storagePrefix(tenant)
+	for page := 1; ; page++ {
+		tenants, _, err := s.repo.ListTenants(ctx, repository.ListOptions{Page: page, PageSize: pageSize})
+		if err != nil {
+			return err
+		}
+		for _, other := range tenants {
+			if other.ID == tenant.ID || other.StorageBackend != tenant.StorageBackend {
+				continue
+			}
+			otherPrefix := storagePrefix(other)
+			if strings.HasPrefix(prefix, otherPrefix) || strings.HasPrefix(otherPrefix, prefix) {
+				return fmt.Errorf("%w: %q and %q of tenant %s", ErrPrefixConflict, prefix, otherPrefix, other.ID)
+			}
+		}
+		if len(tenants) < pageSize {
+			return nil
+		}
+	}
+}
+
+// CreateTenant registers a new tenant
+func (s *TenantService) CreateTenant(ctx context.Context, input TenantInput) (*model.Tenant, error) {
+	if !tenantIDPattern.MatchString(input.ID) {
+		return nil, fmt.Errorf("%w: tenant id must be lowercase letters, digits and dashes", ErrInvalidInput)
+	}
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+	prefix, err := normalizeStoragePrefix(input.StoragePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant := &model.Tenant{
+		ID:                  input.ID,
+		Name:                input.Name,
+		StorageBackend:      input.StorageBackend,
+		StoragePrefix:       prefix,
+		CDN:                 input.CDN,
+		QuotaBytes:          input.QuotaBytes,
+		QuotaWarnPercents:   input.QuotaWarnPercents,
+		Retention:           input.Retention,
+		EncryptionKeyRef:    input.EncryptionKeyRef,
+		MaxURLExpirySeconds: input.MaxURLExpirySeconds,
+	}
+	if err := s.checkPrefixConflict(ctx, tenant); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateTenant(ctx, tenant); err != nil {
+		if errors.Is(err, repository.ErrTenantExists) {
+			return nil, ErrTenantExists
+		}
+		return nil, fmt.Errorf("failed to create tenant: %w", err)
+	}
+
+	return tenant, nil
+}
+
+// GetTenant retrieves a tenant by ID
+func (s *TenantService) GetTenant(ctx context.Context, id string) (*model.Tenant, error) {
+	tenant, err := s.repo.GetTenant(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrTenantNotFound) {
+			return nil, ErrTenantNotFound
+		}
+		return nil, err
+	}
+	return tenant, nil
+}
+
+// ListTenants retrieves a page of tenants
+func (s *TenantService) ListTenants(ctx context.Context, options repository.ListOptions) ([]*model.Tenant, int64, error) {
+	return s.repo.ListTenants(ctx, options)
+}
+
+// UpdateTenant replaces the settings of an existing tenant
+func (s *TenantService) UpdateTenant(ctx context.Context, id string, input TenantInput) (*model.Tenant, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+	prefix, err := normalizeStoragePrefix(input.StoragePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant, err := s.GetTenant(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant.Name = input.Name
+	tenant.StorageBackend = input.StorageBackend
+	tenant.StoragePrefix = prefix
+	tenant.CDN = input.CDN
+	tenant.QuotaBytes = input.QuotaBytes
+	tenant.QuotaWarnPercents = input.QuotaWarnPercents
+	tenant.Retention = input.Retention
+	tenant.EncryptionKeyRef = input.EncryptionKeyRef
+	tenant.MaxURLExpirySeconds = input.MaxURLExpirySeconds
+	if err := s.checkPrefixConflict(ctx, tenant); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateTenant(ctx, tenant); err != nil {
+		if errors.Is(err, repository.ErrTenantNotFound) {
+			return nil, ErrTenantNotFound
+		}
+		return nil, fmt.Errorf("failed to update tenant: %w", err)
+	}
+
+	return tenant, nil
+}
+
+// DeleteTenant removes a tenant from the registry
+func (s *TenantService) DeleteTenant(ctx context.Context, id string) error {
+	if err := s.repo.DeleteTenant(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrTenantNotFound) {
+			return ErrTenantNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/livefire2015/simple-contents/repository/memory"
+)
+
+func TestNormalizeStoragePrefix(t *testing.T) {
+	tests := []struct {
+		prefix  string
+		want    string
+		wantErr bool
+	}{
+		{prefix: "", want: ""},
+		{prefix: "acme", want: "acme/"},
+		{prefix: "acme/", want: "acme/"},
+		{prefix: "customers/acme", want: "customers/acme/"},
+		{prefix: "/acme", wantErr: true},
+		{prefix: "..", wantErr: true},
+		{prefix: "../other", wantErr: true},
+		{prefix: "acme/../other", wantErr: true},
+		{prefix: "acme/./x", wantErr: true},
+		{prefix: "acme//x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := normalizeStoragePrefix(tt.prefix)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("normalizeStoragePrefix(%q) error = %v, want ErrInvalidInput", tt.prefix, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("normalizeStoragePrefix(%q) = %q, %v, want %q", tt.prefix, got, err, tt.want)
+		}
+	}
+}
+
+func TestCreateTenantRejectsOverlappingPrefixes(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   TenantInput
+		wantErr error
+	}{
+		{name: "distinct prefix", input: TenantInput{ID: "beta", Name: "Beta", StoragePrefix: "tenants/beta"}},
+		{name: "sibling of a prefix", input: TenantInput{ID: "gamma", Name: "Gamma", StoragePrefix: "tenants/acme-eu"}},
+		{name: "inside another prefix", input: TenantInput{ID: "delta", Name: "Delta", StoragePrefix: "tenants/acme/delta"}, wantErr: ErrPrefixConflict},
+		{name: "containing another prefix", input: TenantInput{ID: "epsilon", Name: "Epsilon", StoragePrefix: "tenants"}, wantErr: ErrPrefixConflict},
+		{name: "another tenant's default prefix", input: TenantInput{ID: "zeta", Name: "Zeta", StoragePrefix: "legacy"}, wantErr: ErrPrefixConflict},
+		{name: "default prefix inside another prefix", input: TenantInput{ID: "tenants", Name: "Tenants"}, wantErr: ErrPrefixConflict},
+		{name: "other backend", input: TenantInput{ID: "eta", Name: "Eta", StorageBackend: "dedicated", StoragePrefix: "tenants/acme"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := NewTenantService(memory.NewMemoryRepository())
+			if _, err := s.CreateTenant(ctx, TenantInput{ID: "acme", Name: "Acme", StoragePrefix: "tenants/acme"}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.CreateTenant(ctx, TenantInput{ID: "legacy", Name: "Legacy"}); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := s.CreateTenant(ctx, tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CreateTenant() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+)
+
+var ErrTranscodingDisabled = errors.New("transcoding is not configured")
+
+// TranscodeProfile names a set of web-friendly output formats
+type TranscodeProfile string
+
+const (
+	ProfileMP4 TranscodeProfile = "mp4" // H.264/AAC MP4, or AAC M4A for audio
+	ProfileHLS TranscodeProfile = "hls" // HLS renditions with a master playlist
+)
+
+// IsValid reports whether p is a known profile
+func (p TranscodeProfile) IsValid() bool {
+	return p == ProfileMP4 || p == ProfileHLS
+}
+
+// TranscodeRequest describes a single transcode of a local media file
+type TranscodeRequest struct {
+	Source   string // Local path of the input
+	MIMEType string // MIME type of the input, audio/* is transcoded without video
+	Profile  TranscodeProfile
+	OutDir   string // Directory receiving the outputs
+}
+
+// Transcoder produces web-friendly renditions of audio and video
+type Transcoder interface {
+	Transcode(ctx context.Context, req TranscodeRequest) ([]DerivedOutput, error)
+}
+
+// hlsRendition is one variant of an HLS ladder
+type hlsRendition struct {
+	Name         string
+	Height       int
+	VideoBitrate string
+	Bandwidth    int // Advertised in the master playlist, in bits per second
+}
+
+// hlsLadder lists the HLS renditions produced for video
+var hlsLadder = []hlsRendition{
+	{Name: "360p", Height: 360, VideoBitrate: "800k", Bandwidth: 928000},
+	{Name: "720p", Height: 720, VideoBitrate: "2800k", Bandwidth: 2928000},
+}
+
+// FFmpegTranscoder transcodes by running the ffmpeg executable
+type FFmpegTranscoder struct {
+	Path string // ffmpeg executable, looked up in PATH if empty
+}
+
+// NewFFmpegTranscoder creates a transcoder running the ffmpeg at path
+func NewFFmpegTranscoder(path string) *FFmpegTranscoder {
+	return &FFmpegTranscoder{Path: path}
+}
+
+// Transcode runs ffmpeg for the requested profile
+func (t *FFmpegTranscoder) Transcode(ctx context.Context, req TranscodeRequest) ([]DerivedOutput, error) {
+	audioOnly := strings.HasPrefix(req.MIMEType, "audio/")
+
+	switch req.Profile {
+	case ProfileMP4:
+		return t.transcodeMP4(ctx, req, audioOnly)
+	case ProfileHLS:
+		return t.transcodeHLS(ctx, req, audioOnly)
+	}
+	return nil, fmt.Errorf("%w: profile %q", ErrUnsupportedFormat, req.Profile)
+}
+
+func (t *FFmpegTranscoder) transcodeMP4(ctx context.Context, req TranscodeRequest, audioOnly bool) ([]DerivedOutput, error) {
+	if audioOnly {
+		out := filepath.Join(req.OutDir, "audio.m4a")
+		err := t.run(ctx, "-i", req.Source, "-vn", "-c:a", "aac", "-b:a", "128k", "-movflags", "+faststart", out)
+		if err != nil {
+			return nil, err
+		}
+		return []DerivedOutput{{Path: out, FileName: "audio.m4a", MIMEType: "audio/mp4", Primary: true}}, nil
+	}
+
+	out := filepath.Join(req.OutDir, "video.mp4")
+	err := t.run(ctx, "-i", req.Source,
+		"-c:v", "libx264", "-preset", "veryfast", "-crf", "23", "-pix_fmt", "yuv420p",
+		"-c:a", "aac", "-b:a", "128k", "-movflags", "+faststart", out)
+	if err != nil {
+		return nil, err
+	}
+	return []DerivedOutput{{Path: out, FileName: "video.mp4", MIMEType: "video/mp4", Primary: true}}, nil
+}
+
+func (t *FFmpegTranscoder) transcodeHLS(ctx context.Context, req TranscodeRequest, audioOnly bool) ([]DerivedOutput, error) {
+	ladder := hlsLadder
+	if audioOnly {
+		ladder = []hlsRendition{{Name: "audio", Bandwidth: 160000}}
+	}
+
+	var outputs []DerivedOutput
+	var master bytes.Buffer
+	master.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+
+	for _, rendition := range ladder {
+		playlist := rendition.Name + ".m3u8"
+		args := []string{"-i", req.Source}
+		if audioOnly {
+			args = append(args, "-vn")
+		} else {
+			args = append(args,
+				"-vf", fmt.Sprintf("scale=-2:%d", rendition.Height),
+				"-c:v", "libx264", "-preset", "veryfast", "-b:v", rendition.VideoBitrate, "-pix_fmt", "yuv420p")
+		}
+		args = append(args,
+			"-c:a", "aac", "-b:a", "128k",
+			"-f", "hls", "-hls_time", "6", "-hls_playlist_type", "vod",
+			"-hls_segment_filename", filepath.Join(req.OutDir, rendition.Name+"_%03d.ts"),
+			filepath.Join(req.OutDir, playlist))
+		if err := t.run(ctx, args...); err != nil {
+			return nil, err
+		}
+
+		segments, err := filepath.Glob(filepath.Join(req.OutDir, rendition.Name+"_*.ts"))
+		if err != nil {
+			return nil, err
+		}
+		for _, segment := range segments {
+			outputs = append(outputs, DerivedOutput{Path: segment, FileName: filepath.Base(segment), MIMEType: "video/mp2t"})
+		}
+		outputs = append(outputs, DerivedOutput{Path: filepath.Join(req.OutDir, playlist), FileName: playlist, MIMEType: hlsPlaylistMIMEType})
+
+		fmt.Fprintf(&master, "#EXT-X-STREAM-INF:BANDWIDTH=%d", rendition.Bandwidth)
+		if rendition.Height > 0 {
+			fmt.Fprintf(&master, ",RESOLUTION=%dx%d", rendition.Height*16/9, rendition.Height)
+		}
+		fmt.Fprintf(&master, "\n%s\n", playlist)
+	}
+
+	masterPath := filepath.Join(req.OutDir, "master.m3u8")
+	if err := os.WriteFile(masterPath, master.Bytes(), 0o600); err != nil {
+		return nil, err
+	}
+	outputs = append(outputs, DerivedOutput{Path: masterPath, FileName: "master.m3u8", MIMEType: hlsPlaylistMIMEType, Primary: true})
+
+	return outputs, nil
+}
+
+// run executes ffmpeg, returning the tail of its output on failure
+func (t *FFmpegTranscoder) run(ctx context.Context, args ...string) error {
+	path := t.Path
+	if path == "" {
+		path = "ffmpeg"
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, append([]string{"-hide_banner", "-nostdin", "-y"}, args...)...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > 500 {
+			msg = msg[len(msg)-500:]
+		}
+		return fmt.Errorf("ffmpeg: %w: %s", err, msg)
+	}
+	return nil
+}
+
+// ConfigureTranscoder enables transcode jobs
+func (s *ContentService) ConfigureTranscoder(transcoder Transcoder) {
+	s.transcoder = transcoder
+}
+
+// TranscodeContent starts a job producing a web-friendly rendition of audio
+// or video content. The outputs are stored as content derived from it.
+func (s *ContentService) TranscodeContent(ctx context.Context, id uuid.UUID, profile TranscodeProfile) (*model.Job, error) {
+	if s.transcoder == nil {
+		return nil, ErrTranscodingDisabled
+	}
+	if !profile.IsValid() {
+		return nil, ErrInvalidInput
+	}
+
+	transcoder := s.transcoder
+	derive := func(ctx context.Context, content *model.Content, src string, outDir string) ([]DerivedOutput, error) {
+		return transcoder.Transcode(ctx, TranscodeRequest{
+			Source:   src,
+			MIMEType: content.MIMEType,
+			Profile:  profile,
+			OutDir:   outDir,
+		})
+	}
+
+	content, err := s.GetContent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !isMedia(content.MIMEType) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, content.MIMEType)
+	}
+
+	return s.startDerivativeJob(ctx, content, "transcode/"+string(profile), derive)
+}
+
+// isMedia reports whether a MIME type is audio or video
+func isMedia(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "audio/") || strings.HasPrefix(mimeType, "video/")
+}
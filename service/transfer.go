@@ -0,0 +1,248 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+// RecordType identifies the kind of row in an NDJSON export
+type RecordType string
+
+const (
+	RecordTypeContent RecordType = "content"
+	// Associations will be exported once they are persisted by the repository
+)
+
+// ExportRecord is a single NDJSON line of an export
+type ExportRecord struct {
+	Type    RecordType     `json:"type"`
+	Content *model.Content `json:"content,omitempty"`
+}
+
+// ConflictPolicy decides what happens when an imported ID already exists
+type ConflictPolicy string
+
+const (
+	ConflictSkip      ConflictPolicy = "skip"      // Keep the existing row
+	ConflictOverwrite ConflictPolicy = "overwrite" // Replace the existing row
+	ConflictFail      ConflictPolicy = "fail"      // Abort the import
+)
+
+var ErrImportConflict = errors.New("imported record already exists")
+
+// ImportResult summarizes an import
+type ImportResult struct {
+	Created     int `json:"created"`
+	Overwritten int `json:"overwritten"`
+	Skipped     int `json:"skipped"`
+}
+
+// exportPageSize is the number of rows read per repository call during export
+const exportPageSize = 500
+
+// ExportContents writes every content row to w as NDJSON and returns the number of records written
+func (s *ContentService) ExportContents(ctx context.Context, w io.Writer) (int, error) {
+	encoder := json.NewEncoder(w)
+	written := 0
+
+	err := s.repo.ListContentStream(ctx, model.ContentFilter{}, func(item *model.Content) error {
+		if err := encoder.Encode(ExportRecord{Type: RecordTypeContent, Content: item}); err != nil {
+			return err
+		}
+		written++
+		return nil
+	})
+	return written, err
+}
+
+// EachContent calls fn for every content item selected by input, in the
+// order of the listing. Pagination of the input is ignored.
+func (s *ContentService) EachContent(ctx context.Context, input ListContentInput, fn func(*model.Content) error) error {
+	filter := input.filter()
+	if filter.SortBy != "" && !filter.SortBy.IsValid() {
+		return fmt.Errorf("%w: unknown sort field %q", ErrInvalidInput, filter.SortBy)
+	}
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidInput, filter.Status)
+	}
+
+	return s.repo.ListContentStream(ctx, filter, fn)
+}
+
+// EachContentForEntity calls fn for every content item linked to an entity,
+// filtered and sorted by options. Pagination of the options is ignored.
+func (s *ContentService) EachContentForEntity(ctx context.Context, entityType, entityID string, options repository.ListOptions, fn func(*model.Content) error) error {
+	if entityType == "" || entityID == "" {
+		return ErrInvalidInput
+	}
+
+	for page := 1; ; page++ {
+		items, _, err := s.repo.ListContentByEntity(ctx, entityType, entityID, repository.ListOptions{Page: page, PageSize: exportPageSize, PinnedBy: options.PinnedBy, SortBy: options.SortBy, IncludeDrafts: options.IncludeDrafts})
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+
+		if len(items) < exportPageSize {
+			return nil
+		}
+	}
+}
+
+// ImportContents reads NDJSON records from r and stores them with their original IDs
+func (s *ContentService) ImportContents(ctx context.Context, r io.Reader, policy ConflictPolicy) (*ImportResult, error) {
+	switch policy {
+	case ConflictSkip, ConflictOverwrite, ConflictFail:
+	case "":
+		policy = ConflictSkip
+	default:
+		return nil, ErrInvalidInput
+	}
+
+	result := &ImportResult{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+
+	var batch []importRecord
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record ExportRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return result, fmt.Errorf("%w: line %d: %v", ErrInvalidInput, line, err)
+		}
+		if record.Type != RecordTypeContent || record.Content == nil || record.Content.ID == uuid.Nil {
+			return result, fmt.Errorf("%w: line %d: unsupported record", ErrInvalidInput, line)
+		}
+
+		if batch = append(batch, importRecord{line: line, content: record.Content}); len(batch) == exportPageSize {
+			if err := s.importBatch(ctx, batch, policy, result); err != nil {
+				return result, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return result, err
+	}
+
+	return result, s.importBatch(ctx, batch, policy, result)
+}
+
+// importRecord is a content row read from an import with its line number
+type importRecord struct {
+	line    int
+	content *model.Content
+}
+
+// importBatch stores a batch of content rows according to policy, looking up
+// existing rows with one query and creating new rows together
+func (s *ContentService) importBatch(ctx context.Context, batch []importRecord, policy ConflictPolicy, result *ImportResult) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(batch))
+	for i, record := range batch {
+		ids[i] = record.content.ID
+	}
+	existing, err := s.repo.GetContentsByIDs(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", batch[0].line, err)
+	}
+	exists := make(map[uuid.UUID]bool, len(existing))
+	for _, content := range existing {
+		exists[content.ID] = true
+	}
+
+	var creates []*model.Content
+	pending := make(map[uuid.UUID]int) // Index in creates of the IDs created by this batch
+	createPending := func() error {
+		if len(creates) == 0 {
+			return nil
+		}
+		if err := s.repo.CreateContentBatch(ctx, creates); err != nil {
+			return fmt.Errorf("lines %d-%d: %w", batch[0].line, batch[len(batch)-1].line, err)
+		}
+		result.Created += len(creates)
+		return nil
+	}
+
+	for _, record := range batch {
+		content := record.content
+		index, isPending := pending[content.ID]
+		if !exists[content.ID] && !isPending {
+			pending[content.ID] = len(creates)
+			creates = append(creates, content)
+			continue
+		}
+
+		switch policy {
+		case ConflictOverwrite:
+			// A row repeated within the batch replaces the one waiting to be created
+			if isPending {
+				creates[index] = content
+			} else if err := s.repo.UpdateContent(ctx, content); err != nil {
+				return fmt.Errorf("line %d: %w", record.line, err)
+			}
+			result.Overwritten++
+		case ConflictFail:
+			// Rows before the conflict are kept, as they are when importing one by one
+			if err := createPending(); err != nil {
+				return err
+			}
+			return fmt.Errorf("line %d: %w: %s", record.line, ErrImportConflict, content.ID)
+		default:
+			result.Skipped++
+		}
+	}
+
+	return createPending()
+}
+
+// importContent stores a single content row according to policy
+func (s *ContentService) importContent(ctx context.Context, content *model.Content, policy ConflictPolicy, result *ImportResult) error {
+	_, err := s.repo.GetContentByID(ctx, content.ID)
+	if err != nil && !errors.Is(err, repository.ErrContentNotFound) {
+		return err
+	}
+
+	if err != nil {
+		// A deleted row still holds the ID
+		if err := s.repo.CreateContent(ctx, content); errors.Is(err, repository.ErrContentExists) {
+			return fmt.Errorf("%w: %s", ErrImportConflict, content.ID)
+		} else if err != nil {
+			return err
+		}
+		result.Created++
+		return nil
+	}
+
+	switch policy {
+	case ConflictOverwrite:
+		if err := s.repo.UpdateContent(ctx, content); err != nil {
+			return err
+		}
+		result.Overwritten++
+	case ConflictFail:
+		return fmt.Errorf("%w: %s", ErrImportConflict, content.ID)
+	default:
+		result.Skipped++
+	}
+	return nil
+}
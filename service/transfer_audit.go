@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+)
+
+// TransferDirection tells uploads from downloads
+type TransferDirection string
+
+const (
+	TransferUpload   TransferDirection = "upload"
+	TransferDownload TransferDirection = "download"
+)
+
+// TransferRecord describes the data moved by one upload or download of
+// content data, whether it completed or not
+type TransferRecord struct {
+	Direction TransferDirection
+	ContentID uuid.UUID // Nil for uploads that failed before the content was created
+	TenantID  string
+	FileName  string
+	Bytes     int64 // Bytes received from the client, or read from storage for it
+	Size      int64 // Bytes of a complete transfer, storage.UnknownSize if not known
+	Duration  time.Duration
+	Complete  bool  // Whether the whole data was transferred
+	Err       error // Why the transfer failed; nil for downloads the client abandoned
+}
+
+// TransferMetrics receives a record of every transfer
+type TransferMetrics interface {
+	Transfer(record TransferRecord)
+}
+
+// transferDetail is the audit event detail of a transfer, as JSON
+type transferDetail struct {
+	FileName   string `json:"file_name,omitempty"`
+	Bytes      int64  `json:"bytes"`
+	Size       int64  `json:"size"`
+	DurationMS int64  `json:"duration_ms"`
+	Complete   bool   `json:"complete"`
+	Error      string `json:"error,omitempty"`
+}
+
+// transferAudit records transfers
+type transferAudit struct {
+	metrics TransferMetrics
+}
+
+// EnableTransferAudit records every upload through the service and every
+// download of content data, with its byte count and duration, as a
+// content.upload or content.download audit event and in metrics, which may
+// be nil. Derivatives the service produces itself aren't recorded.
+func (s *ContentService) EnableTransferAudit(metrics TransferMetrics) {
+	s.transferAudit = &transferAudit{metrics: metrics}
+}
+
+// recordTransfer reports a finished transfer to the metrics and the audit log
+func (s *ContentService) recordTransfer(ctx context.Context, record TransferRecord) {
+	if s.transferAudit.metrics != nil {
+		s.transferAudit.metrics.Transfer(record)
+	}
+
+	detail := transferDetail{
+		FileName:   record.FileName,
+		Bytes:      record.Bytes,
+		Size:       record.Size,
+		DurationMS: record.Duration.Milliseconds(),
+		Complete:   record.Complete,
+	}
+	if record.Err != nil {
+		detail.Error = record.Err.Error()
+	}
+	encoded, _ := json.Marshal(detail)
+
+	subject := ""
+	if record.ContentID != uuid.Nil {
+		subject = record.ContentID.String()
+	}
+	// Aborted requests are recorded too, after their context is cancelled
+	s.audit(context.WithoutCancel(ctx), model.AuditEvent{Action: "content." + string(record.Direction), Subject: subject, Actor: record.TenantID, Detail: string(encoded)},
+		"%s of %q by tenant %q: %d of %d bytes in %s, complete %v", record.Direction, record.FileName, record.TenantID, record.Bytes, record.Size, record.Duration, record.Complete)
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r     io.Reader
+	bytes int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.bytes += int64(n)
+	return n, err
+}
+
+// auditUpload creates content as CreateContent does, recording the upload
+// of its data. Uploads rejected before any data was read aren't recorded.
+func (s *ContentService) auditUpload(ctx context.Context, input CreateContentInput) (*model.Content, error) {
+	counter := &countingReader{r: input.Data}
+	input.Data = counter
+	start := time.Now()
+
+	content, err := s.createContent(ctx, input)
+	if err != nil && counter.bytes == 0 {
+		return nil, err
+	}
+
+	record := TransferRecord{
+		Direction: TransferUpload,
+		TenantID:  input.TenantID,
+		FileName:  input.FileName,
+		Bytes:     counter.bytes,
+		Size:      input.FileSize,
+		Duration:  time.Since(start),
+		Complete:  err == nil,
+		Err:       err,
+	}
+	if content != nil {
+		record.ContentID = content.ID
+		record.FileName = content.FileName
+	}
+	if record.Complete && record.Size < 0 {
+		// A streamed upload turned out to be as long as what was received
+		record.Size = counter.bytes
+	}
+	s.recordTransfer(ctx, record)
+	return content, err
+}
+
+// auditDownload wraps the data of content so that the download is recorded
+// when it's closed. size is storage.UnknownSize when the data isn't that of
+// content.FileSize, e.g. the original of a sanitized image.
+func (s *ContentService) auditDownload(ctx context.Context, content *model.Content, data io.ReadCloser, size int64) io.ReadCloser {
+	if s.transferAudit == nil {
+		return data
+	}
+	return &auditedReader{ReadCloser: data, service: s, ctx: ctx, content: content, size: size, start: time.Now()}
+}
+
+// auditedReader counts the bytes read from content data and records the
+// download when closed
+type auditedReader struct {
+	io.ReadCloser
+	service *ContentService
+	ctx     context.Context
+	content *model.Content
+	size    int64
+	start   time.Time
+
+	bytes int64
+	eof   bool
+	err   error // First read error other than io.EOF
+	once  sync.Once
+}
+
+func (r *auditedReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.bytes += int64(n)
+	if errors.Is(err, io.EOF) {
+		r.eof = true
+	} else if err != nil && r.err == nil {
+		r.err = err
+	}
+	return n, err
+}
+
+func (r *auditedReader) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(func() {
+		// Readers that know the size may stop without reading the EOF
+		complete := r.err == nil && r.eof
+		if r.size >= 0 {
+			complete = r.err == nil && r.bytes == r.size
+		}
+		r.service.recordTransfer(r.ctx, TransferRecord{
+			Direction: TransferDownload,
+			ContentID: r.content.ID,
+			TenantID:  r.content.TenantID,
+			FileName:  r.content.FileName,
+			Bytes:     r.bytes,
+			Size:      r.size,
+			Duration:  time.Since(r.start),
+			Complete:  complete,
+			Err:       r.err,
+		})
+	})
+	return err
+}
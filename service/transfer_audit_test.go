@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository/memory"
+	"github.com/livefire2015/simple-contents/storage"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+)
+
+// transferRecorder keeps the transfers and audit events it's given
+type transferRecorder struct {
+	mu        sync.Mutex
+	transfers []TransferRecord
+	events    []*model.AuditEvent
+}
+
+func (r *transferRecorder) Transfer(record TransferRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.transfers = append(r.transfers, record)
+}
+
+func (r *transferRecorder) RecordAuditEvent(ctx context.Context, event *model.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *transferRecorder) PruneAuditEvents(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+// failingReader returns data, then fails
+type failingReader struct {
+	data io.Reader
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	n, err := r.data.Read(p)
+	if err == io.EOF {
+		return n, errors.New("connection reset by peer")
+	}
+	return n, err
+}
+
+func TestTransferAudit(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepository()
+	s := NewContentService(repo, memorystorage.NewMemoryStorage())
+	recorder := &transferRecorder{}
+	s.EnableAuditLog(recorder)
+	s.EnableTransferAudit(recorder)
+
+	content, err := s.CreateContent(ctx, CreateContentInput{TenantID: "acme", FileName: "a.txt", MIMEType: "text/plain", FileSize: storage.UnknownSize, Data: strings.NewReader("statement")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateContent(ctx, CreateContentInput{TenantID: "acme", FileName: "b.txt", MIMEType: "text/plain", FileSize: 20, Data: &failingReader{data: strings.NewReader("partial")}}); err == nil {
+		t.Fatal("CreateContent() of a broken upload succeeded")
+	}
+	// Rejected before reading any data
+	if _, err := s.CreateContent(ctx, CreateContentInput{FileName: "../", MIMEType: "text/plain", FileSize: 1, Data: strings.NewReader("x")}); err == nil {
+		t.Fatal("CreateContent() of an invalid name succeeded")
+	}
+
+	download := func(n int64) {
+		data, _, err := s.GetContentData(ctx, content.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.CopyN(io.Discard, data, n)
+		data.Close()
+	}
+	download(9)
+	download(4)
+
+	tests := []struct {
+		name      string
+		direction TransferDirection
+		bytes     int64
+		size      int64
+		complete  bool
+		failed    bool
+	}{
+		{name: "streamed upload", direction: TransferUpload, bytes: 9, size: 9, complete: true},
+		{name: "broken upload", direction: TransferUpload, bytes: 7, size: 20, failed: true},
+		{name: "complete download", direction: TransferDownload, bytes: 9, size: 9, complete: true},
+		{name: "abandoned download", direction: TransferDownload, bytes: 4, size: 9},
+	}
+	if len(recorder.transfers) != len(tests) || len(recorder.events) != len(tests) {
+		t.Fatalf("recorded %d transfers and %d events, want %d", len(recorder.transfers), len(recorder.events), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := recorder.transfers[i]
+			if got.Direction != tt.direction || got.Bytes != tt.bytes || got.Size != tt.size || got.Complete != tt.complete || (got.Err != nil) != tt.failed {
+				t.Errorf("transfer = %+v, want %s of %d of %d bytes, complete %v, failed %v", got, tt.direction, tt.bytes, tt.size, tt.complete, tt.failed)
+			}
+			if got.TenantID != "acme" {
+				t.Errorf("tenant = %q, want acme", got.TenantID)
+			}
+
+			event := recorder.events[i]
+			var detail transferDetail
+			if err := json.Unmarshal([]byte(event.Detail), &detail); err != nil {
+				t.Fatalf("event detail %q: %v", event.Detail, err)
+			}
+			if event.Action != "content."+string(tt.direction) || event.Actor != "acme" || detail.Bytes != tt.bytes || detail.Complete != tt.complete {
+				t.Errorf("audit event = %+v, want the transfer", event)
+			}
+		})
+	}
+}
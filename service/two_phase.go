@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+)
+
+var ErrContentConfirmed = errors.New("content is already confirmed")
+
+// defaultPrepareTTL is how long prepared content waits to be confirmed when
+// the input sets no draft TTL
+const defaultPrepareTTL = time.Hour
+
+// The methods below let an application embedding the service attach content
+// to records of its own database without orphans when its transaction fails:
+//
+//	content, err := contents.PrepareContent(ctx, input)
+//	// store content.ID in the application's transaction
+//	if err := tx.Commit(); err != nil {
+//		contents.AbortContent(ctx, content.ID)
+//		return err
+//	}
+//	contents.ConfirmContent(ctx, content.ID)
+//
+// Prepared content is a draft, so content that is neither confirmed nor
+// aborted, e.g. when the application stops in between, is purged by the
+// drafts task once its TTL runs out.
+
+// PrepareContent stores content as a draft to be confirmed or aborted once
+// the application's transaction has ended, within input.DraftTTL or an hour
+func (s *ContentService) PrepareContent(ctx context.Context, input CreateContentInput) (*model.Content, error) {
+	if input.DraftTTL == 0 {
+		input.DraftTTL = defaultPrepareTTL
+	}
+	return s.CreateContent(ctx, input)
+}
+
+// ConfirmContent keeps prepared content for good. Confirming it again
+// changes nothing; content aborted or purged is ErrContentNotFound.
+func (s *ContentService) ConfirmContent(ctx context.Context, id uuid.UUID) (*model.Content, error) {
+	return s.FinalizeContent(ctx, id)
+}
+
+// AbortContent deletes prepared content and its data. Content already
+// aborted or purged is no error, while confirmed content is kept and
+// ErrContentConfirmed returned.
+func (s *ContentService) AbortContent(ctx context.Context, id uuid.UUID) error {
+	content, err := s.getContentForUpdate(ctx, id)
+	if errors.Is(err, ErrContentNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !content.IsDraft() {
+		return ErrContentConfirmed
+	}
+	if err := s.forceDeleteContent(ctx, content); err != nil && !errors.Is(err, ErrContentNotFound) {
+		return err
+	}
+	return nil
+}
+
+// WithPreparedContent prepares content and calls fn with it, typically to
+// store its ID in a transaction of the application that fn commits. The
+// content is confirmed if fn returns nil and aborted otherwise, returning
+// the error of fn.
+func (s *ContentService) WithPreparedContent(ctx context.Context, input CreateContentInput, fn func(content *model.Content) error) (*model.Content, error) {
+	content, err := s.PrepareContent(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(content); err != nil {
+		if abortErr := s.AbortContent(ctx, content.ID); abortErr != nil {
+			// Left for the drafts task to purge
+			log.Printf("Failed to abort prepared content %s: %v", content.ID, abortErr)
+		}
+		return nil, err
+	}
+	confirmed, err := s.ConfirmContent(ctx, content.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm prepared content %s: %w", content.ID, err)
+	}
+	return confirmed, nil
+}
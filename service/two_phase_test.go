@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository/memory"
+)
+
+func TestWithPreparedContent(t *testing.T) {
+	errRollback := errors.New("parent save failed")
+	tests := []struct {
+		name      string
+		fn        func(*model.Content) error
+		wantErr   error
+		wantFound bool
+	}{
+		{name: "committed", fn: func(*model.Content) error { return nil }, wantFound: true},
+		{name: "rolled back", fn: func(*model.Content) error { return errRollback }, wantErr: errRollback},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestInstance(memory.NewMemoryRepository())
+
+			var prepared *model.Content
+			content, err := s.WithPreparedContent(ctx, CreateContentInput{
+				FileName: "receipt.pdf", MIMEType: "application/pdf", FileSize: 3, Data: strings.NewReader("pdf"),
+			}, func(content *model.Content) error {
+				if !content.IsDraft() {
+					t.Error("prepared content isn't a draft")
+				}
+				prepared = content
+				return tt.fn(content)
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WithPreparedContent() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && content.IsDraft() {
+				t.Error("confirmed content is still a draft")
+			}
+
+			stored, err := s.GetContent(ctx, prepared.ID)
+			if found := err == nil; found != tt.wantFound {
+				t.Fatalf("GetContent() error = %v, want found %v", err, tt.wantFound)
+			}
+			if stored != nil && stored.IsDraft() {
+				t.Error("stored content is still a draft")
+			}
+		})
+	}
+}
+
+func TestAbortContent(t *testing.T) {
+	ctx := context.Background()
+	s := newTestInstance(memory.NewMemoryRepository())
+	content, err := s.PrepareContent(ctx, CreateContentInput{
+		FileName: "receipt.pdf", MIMEType: "application/pdf", FileSize: 3, Data: strings.NewReader("pdf"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ConfirmContent(ctx, content.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.AbortContent(ctx, content.ID); !errors.Is(err, ErrContentConfirmed) {
+		t.Errorf("AbortContent(confirmed) error = %v, want %v", err, ErrContentConfirmed)
+	}
+	if _, err := s.GetContent(ctx, content.ID); err != nil {
+		t.Errorf("confirmed content was deleted: %v", err)
+	}
+	if err := s.AbortContent(ctx, uuid.New()); err != nil {
+		t.Errorf("AbortContent(unknown) error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+const (
+	// DefaultUploadRequestExpiry is how long an upload request works when no expiry is given
+	DefaultUploadRequestExpiry = 7 * 24 * time.Hour
+	// MaxUploadRequestExpiry bounds the lifetime of an upload request
+	MaxUploadRequestExpiry = 30 * 24 * time.Hour
+
+	// uploadRequestSource is the source of content uploaded through upload requests
+	uploadRequestSource = "upload_request"
+)
+
+var (
+	ErrUploadRequestNotFound  = errors.New("upload request not found")
+	ErrUploadRequestExpired   = errors.New("upload request has expired")
+	ErrUploadRequestRevoked   = errors.New("upload request has been revoked")
+	ErrUploadRequestExhausted = errors.New("upload request has no uploads left")
+	ErrUploadRequestTooLarge  = errors.New("file is larger than the upload request allows")
+)
+
+// UploadRequestService manages upload requests, through which external
+// parties, e.g. cardholders, upload documents attached to an entity without
+// authenticating
+type UploadRequestService struct {
+	repo     repository.UploadRequestRepository
+	contents *ContentService // Its clock dates and expires the records
+}
+
+// NewUploadRequestService creates a new upload request service
+func NewUploadRequestService(repo repository.UploadRequestRepository, contents *ContentService) *UploadRequestService {
+	return &UploadRequestService{
+		repo:     repo,
+		contents: contents,
+	}
+}
+
+// UploadRequestInput represents the settings of a new upload request
+type UploadRequestInput struct {
+	Recipient   string `json:"recipient" validate:"max=255"`   // Who the request is sent to, for the record
+	Message     string `json:"message" validate:"max=2000"`    // Instructions shown on the upload page
+	ExpiresIn   int    `json:"expires_in"`                     // Lifetime in seconds, DefaultUploadRequestExpiry if 0
+	MaxUploads  int64  `json:"max_uploads" validate:"min=0"`   // 0 for unlimited uploads
+	MaxFileSize int64  `json:"max_file_size" validate:"min=0"` // Largest file in bytes, 0 for the service's limits only
+}
+
+// CreateUploadRequest creates an upload request for files attached to an
+// entity and owned by tenantID
+func (s *UploadRequestService) CreateUploadRequest(ctx context.Context, tenantID, entityType, entityID, createdBy string, input UploadRequestInput) (*model.UploadRequest, error) {
+	if entityType == "" || entityID == "" {
+		return nil, fmt.Errorf("%w: entity type and ID are required", ErrInvalidInput)
+	}
+	if err := validateInput(input); err != nil {
+		return nil, err
+	}
+	expiry := time.Duration(input.ExpiresIn) * time.Second
+	if input.ExpiresIn == 0 {
+		expiry = DefaultUploadRequestExpiry
+	}
+	if expiry <= 0 || expiry > MaxUploadRequestExpiry {
+		return nil, fmt.Errorf("%w: expires_in must be between 1 and %d seconds", ErrInvalidInput, int(MaxUploadRequestExpiry.Seconds()))
+	}
+	if s.contents.entityTypes != nil {
+		if _, ok := s.contents.entityTypes.Lookup(entityType); !ok {
+			return nil, fmt.Errorf("%w: unknown entity type %q", ErrInvalidInput, entityType)
+		}
+	}
+	if tenantID != "" && s.contents.tenants != nil {
+		if _, err := s.contents.tenants.GetTenant(ctx, tenantID); err != nil {
+			return nil, err
+		}
+	}
+
+	request := &model.UploadRequest{
+		TenantID:    tenantID,
+		EntityType:  entityType,
+		EntityID:    entityID,
+		Recipient:   input.Recipient,
+		Message:     input.Message,
+		CreatedBy:   createdBy,
+		ExpiresAt:   s.contents.now().Add(expiry),
+		MaxUploads:  input.MaxUploads,
+		MaxFileSize: input.MaxFileSize,
+	}
+
+	// Retry the unlikely collision of random tokens
+	var err error
+	for attempt := 0; ; attempt++ {
+		if request.Token, err = newDownloadLinkToken(); err != nil {
+			return nil, err
+		}
+		err = s.repo.CreateUploadRequest(ctx, request)
+		if !errors.Is(err, repository.ErrUploadRequestExists) || attempt == 2 {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload request: %w", err)
+	}
+
+	s.contents.audit(ctx, model.AuditEvent{Action: "upload_request.create", Subject: entityType + "/" + entityID, Actor: createdBy, Detail: request.Recipient},
+		"Upload request for %s %s sent to %q by %q", entityType, entityID, request.Recipient, createdBy)
+	return request, nil
+}
+
+// ListUploadRequests retrieves a page of the upload requests of an entity,
+// revoked ones included
+func (s *UploadRequestService) ListUploadRequests(ctx context.Context, entityType, entityID string, options repository.ListOptions) ([]*model.UploadRequest, int64, error) {
+	return s.repo.ListUploadRequestsByEntity(ctx, entityType, entityID, options)
+}
+
+// RevokeUploadRequest stops an upload request of an entity from accepting files
+func (s *UploadRequestService) RevokeUploadRequest(ctx context.Context, entityType, entityID, token, revokedBy string) error {
+	request, err := s.getUploadRequest(ctx, token)
+	if err != nil {
+		return err
+	}
+	if request.EntityType != entityType || request.EntityID != entityID {
+		return ErrUploadRequestNotFound
+	}
+
+	if err := s.repo.RevokeUploadRequest(ctx, token, s.contents.now()); err != nil {
+		if errors.Is(err, repository.ErrUploadRequestNotFound) {
+			return ErrUploadRequestNotFound
+		}
+		return err
+	}
+
+	s.contents.audit(ctx, model.AuditEvent{Action: "upload_request.revoke", Subject: entityType + "/" + entityID, Actor: revokedBy},
+		"Upload request for %s %s revoked by %q after %d uploads", entityType, entityID, revokedBy, request.Uploads)
+	return nil
+}
+
+// getUploadRequest retrieves an upload request by its token
+func (s *UploadRequestService) getUploadRequest(ctx context.Context, token string) (*model.UploadRequest, error) {
+	request, err := s.repo.GetUploadRequest(ctx, token)
+	if err != nil {
+		if errors.Is(err, repository.ErrUploadRequestNotFound) {
+			return nil, ErrUploadRequestNotFound
+		}
+		return nil, err
+	}
+	return request, nil
+}
+
+// OpenUploadRequest returns an upload request that still accepts files, or
+// ErrUploadRequestExpired, ErrUploadRequestRevoked or ErrUploadRequestExhausted
+func (s *UploadRequestService) OpenUploadRequest(ctx context.Context, token string) (*model.UploadRequest, error) {
+	request, err := s.getUploadRequest(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case request.RevokedAt != nil:
+		return nil, ErrUploadRequestRevoked
+	case !s.contents.now().Before(request.ExpiresAt):
+		return nil, ErrUploadRequestExpired
+	case request.MaxUploads > 0 && request.Uploads >= request.MaxUploads:
+		return nil, ErrUploadRequestExhausted
+	}
+	return request, nil
+}
+
+// UploadRequestFile is a file an external party uploads through a request
+type UploadRequestFile struct {
+	FileName string
+	MIMEType string
+	FileSize int64 // Size in bytes, or storage.UnknownSize
+	Data     io.Reader
+}
+
+// FulfillUploadRequest stores a file uploaded through an upload request as
+// content of its tenant attached to its entity
+func (s *UploadRequestService) FulfillUploadRequest(ctx context.Context, token string, file UploadRequestFile) (*model.Content, error) {
+	request, err := s.OpenUploadRequest(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if request.MaxFileSize > 0 && file.FileSize > request.MaxFileSize {
+		return nil, fmt.Errorf("%w: %d bytes, at most %d", ErrUploadRequestTooLarge, file.FileSize, request.MaxFileSize)
+	}
+
+	if err := s.repo.ReserveUpload(ctx, token); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrUploadRequestNotFound):
+			return nil, ErrUploadRequestNotFound
+		case errors.Is(err, repository.ErrUploadRequestExhausted):
+			// Another upload took the last one
+			return nil, ErrUploadRequestExhausted
+		}
+		return nil, err
+	}
+
+	data := file.Data
+	if request.MaxFileSize > 0 {
+		// Streamed files are cut off past the limit, failing the upload
+		data = &maxSizeReader{r: data, remaining: request.MaxFileSize, err: ErrUploadRequestTooLarge}
+	}
+	content, err := s.contents.CreateContent(ctx, CreateContentInput{
+		TenantID:   request.TenantID,
+		FileName:   file.FileName,
+		MIMEType:   file.MIMEType,
+		FileSize:   file.FileSize,
+		Data:       data,
+		CreatedBy:  request.CreatedBy,
+		EntityType: request.EntityType,
+		EntityID:   request.EntityID,
+		Source:     uploadRequestSource,
+		Metadata:   make(model.Metadata),
+	})
+	if err != nil {
+		if releaseErr := s.repo.ReleaseUpload(context.WithoutCancel(ctx), token); releaseErr != nil {
+			return nil, fmt.Errorf("%w (and failed to release the upload: %v)", err, releaseErr)
+		}
+		return nil, err
+	}
+
+	s.contents.audit(ctx, model.AuditEvent{Action: "upload_request.upload", Subject: content.ID.String(), Actor: request.Recipient, Detail: request.EntityType + "/" + request.EntityID},
+		"Content %s uploaded through the upload request for %s %s sent to %q", content.ID, request.EntityType, request.EntityID, request.Recipient)
+	return content, nil
+}
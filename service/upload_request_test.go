@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/livefire2015/simple-contents/clock"
+	"github.com/livefire2015/simple-contents/repository"
+	"github.com/livefire2015/simple-contents/repository/memory"
+	"github.com/livefire2015/simple-contents/storage"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+)
+
+func TestUploadRequests(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepository()
+	contents := NewContentService(repo, memorystorage.NewMemoryStorage())
+	requests := NewUploadRequestService(repo, contents)
+
+	request, err := requests.CreateUploadRequest(ctx, "", "dispute", "D-42", "agent", UploadRequestInput{Recipient: "cardholder@example.com", MaxUploads: 2, MaxFileSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, err := requests.CreateUploadRequest(ctx, "", "dispute", "D-42", "agent", UploadRequestInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := requests.RevokeUploadRequest(ctx, "dispute", "D-42", revoked.Token, "agent"); err != nil {
+		t.Fatal(err)
+	}
+
+	upload := func(token, name, data string, size int64) error {
+		_, err := requests.FulfillUploadRequest(ctx, token, UploadRequestFile{FileName: name, MIMEType: "application/pdf", FileSize: size, Data: strings.NewReader(data)})
+		return err
+	}
+	tests := []struct {
+		name    string
+		token   string
+		data    string
+		size    int64 // Declared size, the length of data if 0
+		wantErr error
+	}{
+		{name: "first file", token: request.Token, data: "receipt"},
+		{name: "declared too large", token: request.Token, data: "statement", size: 11, wantErr: ErrUploadRequestTooLarge},
+		{name: "streamed too large", token: request.Token, data: "bank statement", size: storage.UnknownSize, wantErr: ErrUploadRequestTooLarge},
+		{name: "last file", token: request.Token, data: "id card"},
+		{name: "no uploads left", token: request.Token, data: "more", wantErr: ErrUploadRequestExhausted},
+		{name: "revoked", token: revoked.Token, data: "receipt", wantErr: ErrUploadRequestRevoked},
+		{name: "unknown", token: "0123456789abcdef", data: "receipt", wantErr: ErrUploadRequestNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size := tt.size
+			if size == 0 {
+				size = int64(len(tt.data))
+			}
+			if err := upload(tt.token, tt.name+".pdf", tt.data, size); !errors.Is(err, tt.wantErr) {
+				t.Errorf("FulfillUploadRequest() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Only the accepted files are attached and counted
+	attached, total, err := contents.GetContentWithAssociationsForEntity(ctx, "dispute", "D-42", repository.ListOptions{ReturnTotal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(attached) != 2 {
+		t.Fatalf("%d files attached to the dispute, want 2", total)
+	}
+	for _, item := range attached {
+		if item.Content.Source != uploadRequestSource {
+			t.Errorf("source of %s = %q, want %q", item.Content.FileName, item.Content.Source, uploadRequestSource)
+		}
+	}
+	stored, err := repo.GetUploadRequest(ctx, request.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Uploads != 2 {
+		t.Errorf("uploads = %d, want 2", stored.Uploads)
+	}
+}
+
+func TestCreateUploadRequestValidation(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepository()
+	requests := NewUploadRequestService(repo, NewContentService(repo, memorystorage.NewMemoryStorage()))
+
+	tests := []struct {
+		name       string
+		entityType string
+		input      UploadRequestInput
+	}{
+		{name: "no entity", input: UploadRequestInput{}},
+		{name: "negative expiry", entityType: "dispute", input: UploadRequestInput{ExpiresIn: -1}},
+		{name: "expiry too long", entityType: "dispute", input: UploadRequestInput{ExpiresIn: int(MaxUploadRequestExpiry.Seconds()) + 1}},
+		{name: "negative max uploads", entityType: "dispute", input: UploadRequestInput{MaxUploads: -1}},
+		{name: "negative max file size", entityType: "dispute", input: UploadRequestInput{MaxFileSize: -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entityID := ""
+			if tt.entityType != "" {
+				entityID = "D-1"
+			}
+			if _, err := requests.CreateUploadRequest(ctx, "", tt.entityType, entityID, "agent", tt.input); !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("CreateUploadRequest() error = %v, want ErrInvalidInput", err)
+			}
+		})
+	}
+}
+
+func TestUploadRequestExpiry(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepository()
+	contents := NewContentService(repo, memorystorage.NewMemoryStorage())
+	start := time.Date(2024, 7, 5, 12, 0, 0, 0, time.UTC)
+	now := clock.NewManual(start)
+	contents.ConfigureClock(now)
+	requests := NewUploadRequestService(repo, contents)
+
+	request, err := requests.CreateUploadRequest(ctx, "", "dispute", "D-42", "agent", UploadRequestInput{ExpiresIn: 3600})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := start.Add(time.Hour); !request.ExpiresAt.Equal(want) {
+		t.Fatalf("ExpiresAt = %v, want %v", request.ExpiresAt, want)
+	}
+
+	tests := []struct {
+		name    string
+		after   time.Duration // Time since the request was created
+		wantErr error
+	}{
+		{name: "before expiry", after: time.Hour - time.Second},
+		{name: "at expiry", after: time.Hour, wantErr: ErrUploadRequestExpired},
+		{name: "after expiry", after: 2 * time.Hour, wantErr: ErrUploadRequestExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now.Set(start.Add(tt.after))
+			if _, err := requests.OpenUploadRequest(ctx, request.Token); !errors.Is(err, tt.wantErr) {
+				t.Errorf("OpenUploadRequest() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/livefire2015/simple-contents/model"
+)
+
+// URLExpiryPolicy bounds the lifetime of the presigned URLs GetContentURL
+// issues. Clients asking for longer-lived URLs get the maximum instead.
+type URLExpiryPolicy struct {
+	Default time.Duration // Lifetime of URLs requested without one
+	Max     time.Duration // Longest lifetime, unless the tenant sets its own
+	// ElevatedMax is the longest lifetime granted to callers with the
+	// elevated scope, when longer than that of the tenant
+	ElevatedMax time.Duration
+}
+
+// DefaultURLExpiryPolicy returns a URLExpiryPolicy issuing URLs valid for
+// an hour and at most a day
+func DefaultURLExpiryPolicy() URLExpiryPolicy {
+	return URLExpiryPolicy{
+		Default: time.Hour,
+		Max:     24 * time.Hour,
+	}
+}
+
+// ConfigureURLExpiry replaces the policy bounding presigned URL lifetimes
+func (s *ContentService) ConfigureURLExpiry(policy URLExpiryPolicy) {
+	s.urlExpiry = policy
+}
+
+// ContentURL is a presigned URL of content data and when it expires
+type ContentURL struct {
+	URL       string
+	Expiry    time.Duration // Lifetime granted, which may be shorter than requested
+	ExpiresAt time.Time
+}
+
+// urlExpiryOf returns the lifetime of a URL of content asked to live for
+// requested, 0 for the default
+func (s *ContentService) urlExpiryOf(ctx context.Context, content *model.Content, requested time.Duration) (time.Duration, error) {
+	expiry := requested
+	if expiry <= 0 {
+		expiry = s.urlExpiry.Default
+	}
+
+	max := s.urlExpiry.Max
+	if content.TenantID != "" && s.tenants != nil {
+		tenant, err := s.tenants.GetTenant(ctx, content.TenantID)
+		if err != nil && !errors.Is(err, ErrTenantNotFound) {
+			return 0, err
+		}
+		if tenant != nil && tenant.MaxURLExpirySeconds > 0 {
+			max = time.Duration(tenant.MaxURLExpirySeconds) * time.Second
+		}
+	}
+	if HasElevatedScope(ctx) && s.urlExpiry.ElevatedMax > max {
+		max = s.urlExpiry.ElevatedMax
+	}
+
+	if max > 0 && expiry > max {
+		expiry = max
+	}
+	return expiry, nil
+}
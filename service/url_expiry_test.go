@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/livefire2015/simple-contents/repository/memory"
+	"github.com/livefire2015/simple-contents/storage"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+)
+
+// presignRecorder is a memory storage keeping the expiry of the last URL
+// it presigned
+type presignRecorder struct {
+	*memorystorage.MemoryStorage
+	expiry time.Duration
+}
+
+func (s *presignRecorder) GetPresignedDownloadURL(ctx context.Context, path string, options storage.PresignedURLOptions) (string, error) {
+	s.expiry = options.Expiry
+	return s.MemoryStorage.GetPresignedDownloadURL(ctx, path, options)
+}
+
+func TestGetContentURLCapsExpiry(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepository()
+	tenants := NewTenantService(repo)
+	if _, err := tenants.CreateTenant(ctx, TenantInput{ID: "acme", Name: "Acme"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tenants.CreateTenant(ctx, TenantInput{ID: "strict", Name: "Strict", MaxURLExpirySeconds: 600}); err != nil {
+		t.Fatal(err)
+	}
+	store := &presignRecorder{MemoryStorage: memorystorage.NewMemoryStorage()}
+	s := NewContentService(repo, store)
+	s.ConfigureTenants(tenants)
+	s.ConfigureURLExpiry(URLExpiryPolicy{Default: time.Hour, Max: 24 * time.Hour, ElevatedMax: 7 * 24 * time.Hour})
+
+	acme, err := s.CreateContent(ctx, CreateContentInput{TenantID: "acme", FileName: "a.txt", MIMEType: "text/plain", FileSize: 1, Data: strings.NewReader("a")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	strict, err := s.CreateContent(ctx, CreateContentInput{TenantID: "strict", FileName: "b.txt", MIMEType: "text/plain", FileSize: 1, Data: strings.NewReader("b")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	month := 30 * 24 * time.Hour
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		tenantURL bool // Of the content of the tenant with its own maximum
+		requested time.Duration
+		want      time.Duration
+	}{
+		{name: "default", ctx: ctx, want: time.Hour},
+		{name: "within the maximum", ctx: ctx, requested: 2 * time.Hour, want: 2 * time.Hour},
+		{name: "capped", ctx: ctx, requested: month, want: 24 * time.Hour},
+		{name: "tenant maximum", ctx: ctx, tenantURL: true, requested: time.Hour, want: 10 * time.Minute},
+		{name: "elevated", ctx: WithElevatedScope(ctx), requested: month, want: 7 * 24 * time.Hour},
+		{name: "elevated below the tenant's", ctx: WithElevatedScope(ctx), tenantURL: true, requested: month, want: 7 * 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := acme
+			if tt.tenantURL {
+				content = strict
+			}
+			before := time.Now()
+			got, err := s.GetContentURL(tt.ctx, content.ID, tt.requested)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Expiry != tt.want || store.expiry != tt.want {
+				t.Errorf("expiry = %s, presigned for %s, want %s", got.Expiry, store.expiry, tt.want)
+			}
+			if got.ExpiresAt.Before(before.Add(tt.want)) || got.ExpiresAt.After(time.Now().Add(tt.want)) {
+				t.Errorf("expires at %s, want in %s", got.ExpiresAt, tt.want)
+			}
+		})
+	}
+}
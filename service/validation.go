@@ -0,0 +1,16 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/livefire2015/simple-contents/validate"
+)
+
+// validateInput checks an input against the rules of its validate tags. The
+// error wraps ErrInvalidInput and the validate.Errors of the failing fields.
+func validateInput(input interface{}) error {
+	if err := validate.Struct(input); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	return nil
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+)
+
+const (
+	// MetadataVirusScan holds the state of the virus scan of uploaded
+	// content: "pending", "clean", "infected" or "failed"
+	MetadataVirusScan = "virus_scan"
+	// MetadataVirusSignature holds the name of the virus found in infected content
+	MetadataVirusSignature = "virus_signature"
+	// MetadataSHA256 holds the hex SHA-256 of the uploaded data
+	MetadataSHA256 = "sha256"
+
+	// virusScanTimeout bounds a single scan, including the wait for a free scanner
+	virusScanTimeout = 2 * time.Minute
+	// virusScanAttempts is how many times a failing scan is tried, with a
+	// backoff starting at virusScanBackoff and doubled after each attempt
+	virusScanAttempts = 3
+	virusScanBackoff  = 10 * time.Second
+	// virusScanStallAfter is how long a scan stays pending before it is
+	// presumed lost with the instance that ran it, well past the time the
+	// attempts and their backoff can take
+	virusScanStallAfter = 15 * time.Minute
+	// signatureVersionTTL is how long a scanner's signature database version is reused
+	signatureVersionTTL = time.Minute
+)
+
+// Verdicts of the virus scan, in the virus_scan metadata
+const (
+	scanPending  = "pending"
+	scanClean    = "clean"
+	scanInfected = "infected"
+	scanFailed   = "failed"
+)
+
+// ErrScanPending is returned when the data of content is read before the virus scan cleared it
+var ErrScanPending = errors.New("content has not been cleared by the virus scan")
+
+// VirusScanner checks data for viruses. SignatureVersion identifies the
+// signature database verdicts are given with, so that a verdict can be
+// reused for identical data until the database is updated.
+type VirusScanner interface {
+	Scan(ctx context.Context, data io.Reader) (infected bool, signature string, err error)
+	SignatureVersion(ctx context.Context) (string, error)
+}
+
+// ClamdScanner scans through a clamd daemon
+type ClamdScanner struct {
+	network string
+	address string
+
+	mu        sync.Mutex
+	version   string
+	versionAt time.Time
+}
+
+// NewClamdScanner creates a scanner using the clamd listening on address,
+// a host:port or the path of a Unix socket
+func NewClamdScanner(address string) *ClamdScanner {
+	network := "tcp"
+	if strings.HasPrefix(address, "/") {
+		network = "unix"
+	}
+	return &ClamdScanner{network: network, address: address}
+}
+
+// command sends a clamd command and returns its reply. send writes any data
+// the command streams after it.
+func (c *ClamdScanner) command(ctx context.Context, command string, send func(w io.Writer) error) (string, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	// The z prefix makes clamd delimit the command and its reply with NUL
+	if _, err := io.WriteString(conn, "z"+command+"\x00"); err != nil {
+		return "", err
+	}
+	if send != nil {
+		if err := send(conn); err != nil {
+			return "", err
+		}
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil {
+		return "", fmt.Errorf("clamd %s: %w", command, err)
+	}
+	return strings.TrimSuffix(reply, "\x00"), nil
+}
+
+// Scan streams the data to clamd with INSTREAM
+func (c *ClamdScanner) Scan(ctx context.Context, data io.Reader) (bool, string, error) {
+	reply, err := c.command(ctx, "INSTREAM", func(w io.Writer) error {
+		chunk := make([]byte, 64<<10)
+		for {
+			n, err := io.ReadFull(data, chunk)
+			if n > 0 {
+				if err := binary.Write(w, binary.BigEndian, uint32(n)); err != nil {
+					return err
+				}
+				if _, err := w.Write(chunk[:n]); err != nil {
+					return err
+				}
+			}
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+		}
+		// A zero-length chunk ends the stream
+		return binary.Write(w, binary.BigEndian, uint32(0))
+	})
+	if err != nil {
+		return false, "", err
+	}
+
+	// The reply is "stream: OK" or "stream: <signature> FOUND"
+	result := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case result == "OK":
+		return false, "", nil
+	case strings.HasSuffix(result, " FOUND"):
+		return true, strings.TrimSuffix(result, " FOUND"), nil
+	}
+	return false, "", fmt.Errorf("clamd INSTREAM: %s", reply)
+}
+
+// SignatureVersion returns the version of clamd's signature database, from a
+// reply to VERSION such as "ClamAV 1.0.5/27313/Tue Jun 11 08:24:52 2024"
+func (c *ClamdScanner) SignatureVersion(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.version != "" && time.Since(c.versionAt) < signatureVersionTTL {
+		return c.version, nil
+	}
+
+	reply, err := c.command(ctx, "VERSION", nil)
+	if err != nil {
+		return "", err
+	}
+	parts := strings.Split(reply, "/")
+	if len(parts) < 2 {
+		return "", fmt.Errorf("clamd VERSION: unexpected reply %q", reply)
+	}
+
+	c.version = parts[0] + "/" + parts[1]
+	c.versionAt = time.Now()
+	return c.version, nil
+}
+
+// EnableVirusScanning scans uploaded content in the background. Scan
+// results are kept in results by digest, so identical data isn't scanned
+// again until the signature database changes.
+func (s *ContentService) EnableVirusScanning(scanner VirusScanner, results repository.ScanResultRepository) {
+	s.scanner = scanner
+	s.scanResults = results
+	s.scanBackoff = virusScanBackoff
+}
+
+// CheckScanned returns the error reading the data of content fails with
+// unless the virus scan cleared it. Content uploaded while scanning was
+// disabled has no verdict and can be read.
+func CheckScanned(content *model.Content) error {
+	verdict, _ := content.Metadata[MetadataVirusScan].(string)
+	switch verdict {
+	case scanInfected:
+		return ErrContentQuarantined
+	case scanPending, scanFailed:
+		return ErrScanPending
+	}
+	return nil
+}
+
+// scanMetadataKeys are the metadata only the virus scan and the quarantine review set
+var scanMetadataKeys = []string{
+	MetadataVirusScan,
+	MetadataVirusSignature,
+	MetadataSHA256,
+	MetadataQuarantineReleasedBy,
+	MetadataQuarantineReleasedAt,
+}
+
+// keepScanMetadata copies the scan metadata of current into metadata that
+// replaces it, so clients can't clear a verdict or one still pending
+func keepScanMetadata(metadata, current model.Metadata) {
+	for _, key := range scanMetadataKeys {
+		if value, ok := current[key]; ok {
+			metadata[key] = value
+		} else {
+			delete(metadata, key)
+		}
+	}
+}
+
+// queueVirusScan marks content as waiting for its virus scan, which
+// scanInBackground starts once the content is stored. digest is the hex
+// SHA-256 of the stored data, or empty if it must be hashed while scanning.
+func (s *ContentService) queueVirusScan(content *model.Content, digest string) {
+	if s.scanner == nil {
+		return
+	}
+	if content.Metadata == nil {
+		content.Metadata = make(model.Metadata)
+	}
+	if digest != "" {
+		content.Metadata[MetadataSHA256] = digest
+	} else {
+		delete(content.Metadata, MetadataSHA256)
+	}
+	content.Metadata[MetadataVirusScan] = scanPending
+	delete(content.Metadata, MetadataVirusSignature)
+}
+
+// scanInBackground scans the data of content queued for a virus scan after
+// the request that stored it returns
+func (s *ContentService) scanInBackground(ctx context.Context, content *model.Content) {
+	if s.scanner == nil || content.Metadata[MetadataVirusScan] != scanPending {
+		return
+	}
+
+	// The scan outlives the request that started it
+	ctx = context.WithoutCancel(ctx)
+	s.scans.Add(1)
+	go func() {
+		defer s.scans.Done()
+		s.runVirusScan(ctx, content.ID)
+	}()
+}
+
+// runVirusScan scans the data of a content item and records the verdict in
+// its metadata. Scans that fail are retried; if every attempt fails the
+// content is flagged as such and its data stays unreadable.
+func (s *ContentService) runVirusScan(ctx context.Context, id uuid.UUID) {
+	content, err := s.getContentForUpdate(ctx, id)
+	if err != nil {
+		log.Printf("Virus scan of content %s skipped: %v", id, err)
+		return
+	}
+	digest, _ := content.Metadata[MetadataSHA256].(string)
+
+	var result *model.ScanResult
+	backoff := s.scanBackoff
+	for attempt := 1; ; attempt++ {
+		scanCtx, cancel := context.WithTimeout(ctx, virusScanTimeout)
+		result, err = s.scanResult(scanCtx, content, digest)
+		cancel()
+		if err == nil || attempt == virusScanAttempts {
+			break
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+
+	// The content may have changed while it was scanned
+	content, getErr := s.getContentForUpdate(ctx, id)
+	if getErr != nil {
+		log.Printf("Virus scan of content %s not recorded: %v", id, getErr)
+		return
+	}
+	if content.Metadata[MetadataVirusScan] != scanPending {
+		return
+	}
+
+	switch {
+	case err != nil:
+		log.Printf("Virus scan of content %s failed: %v", id, err)
+		content.Metadata[MetadataVirusScan] = scanFailed
+	case result.Infected:
+		content.Metadata[MetadataVirusScan] = scanInfected
+		content.Metadata[MetadataVirusSignature] = result.Signature
+	default:
+		content.Metadata[MetadataVirusScan] = scanClean
+	}
+	if result != nil {
+		content.Metadata[MetadataSHA256] = result.Digest
+	}
+	if err := s.repo.UpdateContent(ctx, content); err != nil {
+		log.Printf("Failed to record virus scan of content %s: %v", id, err)
+	}
+}
+
+// scanResult returns the cached result for the digest under the current
+// signature database, scanning the stored data of content if there is none.
+// Without a digest, the data is hashed as it is scanned.
+func (s *ContentService) scanResult(ctx context.Context, content *model.Content, digest string) (*model.ScanResult, error) {
+	version, err := s.scanner.SignatureVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.scanResults != nil && digest != "" {
+		result, err := s.scanResults.GetScanResult(ctx, digest, version)
+		if err == nil {
+			return result, nil
+		}
+		if !errors.Is(err, repository.ErrScanResultNotFound) {
+			log.Printf("Failed to look up scan result of %s: %v", digest, err)
+		}
+	}
+
+	data, err := s.storage.Download(storageContext(ctx, content), content.StoragePath)
+	if err != nil {
+		return nil, err
+	}
+	defer data.Close()
+
+	hash := sha256.New()
+	infected, signature, err := s.scanner.Scan(ctx, io.TeeReader(data, hash))
+	if err != nil {
+		return nil, err
+	}
+	if digest == "" {
+		digest = hex.EncodeToString(hash.Sum(nil))
+	}
+
+	result := &model.ScanResult{
+		Digest:           digest,
+		SignatureVersion: version,
+		Infected:         infected,
+		Signature:        signature,
+		ScannedAt:        s.clock.Now(),
+	}
+	if s.scanResults != nil {
+		if err := s.scanResults.SaveScanResult(ctx, result); err != nil {
+			log.Printf("Failed to save scan result of %s: %v", digest, err)
+		}
+	}
+	return result, nil
+}
+
+// RequeueStalledScans scans again the content whose scan has been pending
+// for longer than virusScanStallAfter, e.g. because the instance scanning it
+// stopped, and returns the number of scans started
+func (s *ContentService) RequeueStalledScans(ctx context.Context) (int, error) {
+	if s.scanner == nil {
+		return 0, nil
+	}
+	cutoff := s.clock.Now().Add(-virusScanStallAfter)
+	filter := model.ContentFilter{Metadata: map[string]interface{}{MetadataVirusScan: scanPending}}
+
+	var stalled []*model.Content
+	if err := s.repo.ListContentStream(ctx, filter, func(content *model.Content) error {
+		if content.UpdatedAt.Before(cutoff) {
+			stalled = append(stalled, content)
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	for _, content := range stalled {
+		s.scanInBackground(ctx, content)
+	}
+	return len(stalled), nil
+}
@@ -0,0 +1,336 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository/memory"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+)
+
+// fakeClamd answers clamd commands on a local listener. reply gives the
+// reply to an INSTREAM of the streamed data.
+type fakeClamd struct {
+	listener net.Listener
+	reply    func(data []byte) string
+
+	mu     sync.Mutex
+	chunks []int // Chunk sizes of the last INSTREAM, without the terminating one
+	data   []byte
+}
+
+func newFakeClamd(t *testing.T, reply func(data []byte) string) *fakeClamd {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	c := &fakeClamd{listener: listener, reply: reply}
+	go c.serve()
+	return c
+}
+
+func (c *fakeClamd) serve() {
+	for {
+		conn, err := c.listener.Accept()
+		if err != nil {
+			return
+		}
+		go c.handle(conn)
+	}
+}
+
+func (c *fakeClamd) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	command, err := r.ReadString(0)
+	if err != nil {
+		return
+	}
+
+	switch command {
+	case "zVERSION\x00":
+		io.WriteString(conn, "ClamAV 1.0.5/27313/Tue Jun 11 08:24:52 2024\x00")
+	case "zINSTREAM\x00":
+		var chunks []int
+		var data []byte
+		for {
+			var size uint32
+			if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+				return
+			}
+			if size == 0 {
+				break
+			}
+			chunk := make([]byte, size)
+			if _, err := io.ReadFull(r, chunk); err != nil {
+				return
+			}
+			chunks = append(chunks, int(size))
+			data = append(data, chunk...)
+		}
+		c.mu.Lock()
+		c.chunks, c.data = chunks, data
+		c.mu.Unlock()
+		io.WriteString(conn, c.reply(data)+"\x00")
+	default:
+		io.WriteString(conn, "UNKNOWN COMMAND\x00")
+	}
+}
+
+func TestClamdScannerScan(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          []byte
+		reply         string
+		wantChunks    []int
+		wantInfected  bool
+		wantSignature string
+		wantErr       bool
+	}{
+		{name: "clean", data: []byte("statement"), reply: "stream: OK", wantChunks: []int{9}},
+		{name: "empty", data: nil, reply: "stream: OK", wantChunks: nil},
+		{
+			name:          "infected",
+			data:          []byte("X5O!P%@AP[4\\PZX54(P^)7CC)7}$EICAR"),
+			reply:         "stream: Eicar-Test-Signature FOUND",
+			wantChunks:    []int{33},
+			wantInfected:  true,
+			wantSignature: "Eicar-Test-Signature",
+		},
+		{
+			name:       "several chunks",
+			data:       bytes.Repeat([]byte("x"), 150<<10),
+			reply:      "stream: OK",
+			wantChunks: []int{64 << 10, 64 << 10, 22 << 10},
+		},
+		{name: "error reply", data: []byte("statement"), reply: "INSTREAM size limit exceeded. ERROR", wantChunks: []int{9}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clamd := newFakeClamd(t, func([]byte) string { return tt.reply })
+			scanner := NewClamdScanner(clamd.listener.Addr().String())
+
+			infected, signature, err := scanner.Scan(context.Background(), bytes.NewReader(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if infected != tt.wantInfected || signature != tt.wantSignature {
+				t.Errorf("Scan() = %v, %q, want %v, %q", infected, signature, tt.wantInfected, tt.wantSignature)
+			}
+
+			clamd.mu.Lock()
+			defer clamd.mu.Unlock()
+			if !bytes.Equal(clamd.data, tt.data) {
+				t.Errorf("clamd received %d bytes, want the %d scanned", len(clamd.data), len(tt.data))
+			}
+			if len(clamd.chunks) != len(tt.wantChunks) {
+				t.Fatalf("clamd received chunks %v, want %v", clamd.chunks, tt.wantChunks)
+			}
+			for i := range clamd.chunks {
+				if clamd.chunks[i] != tt.wantChunks[i] {
+					t.Fatalf("clamd received chunks %v, want %v", clamd.chunks, tt.wantChunks)
+				}
+			}
+		})
+	}
+}
+
+func TestClamdScannerSignatureVersion(t *testing.T) {
+	clamd := newFakeClamd(t, nil)
+	scanner := NewClamdScanner(clamd.listener.Addr().String())
+
+	version, err := scanner.SignatureVersion(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != "ClamAV 1.0.5/27313" {
+		t.Errorf("SignatureVersion() = %q, want %q", version, "ClamAV 1.0.5/27313")
+	}
+}
+
+// gatedScanner finds data containing "EICAR" infected once release is
+// closed, or fails every scan if err is set
+type gatedScanner struct {
+	release chan struct{}
+	err     error
+
+	mu    sync.Mutex
+	scans int
+}
+
+func (s *gatedScanner) Scan(ctx context.Context, data io.Reader) (bool, string, error) {
+	<-s.release
+	s.mu.Lock()
+	s.scans++
+	s.mu.Unlock()
+	if s.err != nil {
+		return false, "", s.err
+	}
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return false, "", err
+	}
+	if strings.Contains(string(b), "EICAR") {
+		return true, "Eicar-Test-Signature", nil
+	}
+	return false, "", nil
+}
+
+func (s *gatedScanner) SignatureVersion(ctx context.Context) (string, error) {
+	return "test/1", nil
+}
+
+func TestVirusScanBlocksReads(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		direct    bool
+		scanErr   error
+		wantScan  string
+		wantAfter error
+	}{
+		{name: "clean", data: "statement", wantScan: scanClean},
+		{name: "infected", data: "EICAR", wantScan: scanInfected, wantAfter: ErrContentQuarantined},
+		{name: "failed", data: "statement", scanErr: errors.New("clamd unavailable"), wantScan: scanFailed, wantAfter: ErrScanPending},
+		{name: "direct clean", data: "statement", direct: true, wantScan: scanClean},
+		{name: "direct infected", data: "EICAR", direct: true, wantScan: scanInfected, wantAfter: ErrContentQuarantined},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := memory.NewMemoryRepository()
+			store := memorystorage.NewMemoryStorage()
+			s := NewContentService(repo, store)
+			scanner := &gatedScanner{release: make(chan struct{}), err: tt.scanErr}
+			s.EnableVirusScanning(scanner, repo)
+			s.scanBackoff = 0
+
+			var content *model.Content
+			if tt.direct {
+				// The client stored the data of a direct upload itself
+				content = &model.Content{FileName: "statement.pdf", MIMEType: "application/pdf", FileSize: int64(len(tt.data)), StoragePath: "direct/statement.pdf", Status: model.StatusCreated}
+				if _, err := store.Upload(ctx, content.StoragePath, strings.NewReader(tt.data), content.FileSize, content.MIMEType); err != nil {
+					t.Fatal(err)
+				}
+				if err := repo.CreateContent(ctx, content); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := s.MarkContentAsUploaded(ctx, content.ID); err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				var err error
+				content, err = s.CreateContent(ctx, CreateContentInput{FileName: "statement.pdf", MIMEType: "application/pdf", FileSize: int64(len(tt.data)), Data: strings.NewReader(tt.data)})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if _, _, err := s.GetContentData(ctx, content.ID); !errors.Is(err, ErrScanPending) {
+				t.Errorf("GetContentData() during the scan error = %v, want ErrScanPending", err)
+			}
+			// Replacing the metadata doesn't clear the pending scan
+			if _, err := s.UpdateContent(ctx, UpdateContentInput{ID: content.ID, Metadata: model.Metadata{MetadataVirusScan: scanClean}}); err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := s.GetContentData(ctx, content.ID); !errors.Is(err, ErrScanPending) {
+				t.Errorf("GetContentData() after a metadata update error = %v, want ErrScanPending", err)
+			}
+
+			close(scanner.release)
+			s.scans.Wait()
+
+			got, err := s.GetContent(ctx, content.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Metadata[MetadataVirusScan] != tt.wantScan {
+				t.Errorf("virus_scan = %v, want %q", got.Metadata[MetadataVirusScan], tt.wantScan)
+			}
+			if tt.scanErr == nil && got.Metadata[MetadataSHA256] == nil {
+				t.Error("sha256 of the scanned data not recorded")
+			}
+			data, _, err := s.GetContentData(ctx, content.ID)
+			if !errors.Is(err, tt.wantAfter) {
+				t.Fatalf("GetContentData() after the scan error = %v, want %v", err, tt.wantAfter)
+			}
+			if data != nil {
+				data.Close()
+			}
+		})
+	}
+}
+
+func TestVirusScanReusesResultByDigest(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepository()
+	s := NewContentService(repo, memorystorage.NewMemoryStorage())
+	scanner := &gatedScanner{release: make(chan struct{})}
+	close(scanner.release)
+	s.EnableVirusScanning(scanner, repo)
+
+	for i := 0; i < 2; i++ {
+		if _, err := s.CreateContent(ctx, CreateContentInput{FileName: "statement.pdf", MIMEType: "application/pdf", FileSize: 9, Data: strings.NewReader("statement")}); err != nil {
+			t.Fatal(err)
+		}
+		s.scans.Wait()
+	}
+
+	if scanner.scans != 1 {
+		t.Errorf("identical data scanned %d times, want 1", scanner.scans)
+	}
+}
+
+func TestRequeueStalledScans(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewMemoryRepository()
+	store := memorystorage.NewMemoryStorage()
+	s := NewContentService(repo, store)
+	scanner := &gatedScanner{release: make(chan struct{})}
+	close(scanner.release)
+	s.EnableVirusScanning(scanner, repo)
+
+	// The instance scanning the first item stopped long ago
+	stalled := &model.Content{FileName: "a.pdf", MIMEType: "application/pdf", FileSize: 1, StoragePath: "a.pdf", Status: model.StatusUploaded, Metadata: model.Metadata{MetadataVirusScan: scanPending}, UpdatedAt: time.Now().Add(-time.Hour)}
+	recent := &model.Content{FileName: "b.pdf", MIMEType: "application/pdf", FileSize: 1, StoragePath: "b.pdf", Status: model.StatusUploaded, Metadata: model.Metadata{MetadataVirusScan: scanPending}}
+	for _, content := range []*model.Content{stalled, recent} {
+		if _, err := store.Upload(ctx, content.StoragePath, strings.NewReader("x"), 1, content.MIMEType); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.CreateContent(ctx, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	requeued, err := s.RequeueStalledScans(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.scans.Wait()
+	if requeued != 1 {
+		t.Errorf("RequeueStalledScans() = %d, want 1", requeued)
+	}
+	for content, want := range map[*model.Content]string{stalled: scanClean, recent: scanPending} {
+		got, err := s.GetContent(ctx, content.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Metadata[MetadataVirusScan] != want {
+			t.Errorf("virus_scan of %s = %v, want %s", content.FileName, got.Metadata[MetadataVirusScan], want)
+		}
+	}
+}
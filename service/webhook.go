@@ -0,0 +1,194 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+)
+
+// WebhookConfig controls delivery of completion callbacks
+type WebhookConfig struct {
+	Secret         []byte        // HMAC-SHA256 key used to sign payloads, unsigned if empty
+	MaxAttempts    int           // Delivery attempts before giving up
+	InitialBackoff time.Duration // Backoff before the first retry, doubled on each attempt
+	Timeout        time.Duration // Timeout for a single delivery attempt
+}
+
+// DefaultWebhookConfig returns the delivery settings used unless configured otherwise
+func DefaultWebhookConfig() WebhookConfig {
+	return WebhookConfig{
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+		Timeout:        10 * time.Second,
+	}
+}
+
+// WebhookPayload is the JSON body sent to a content's callback URL
+type WebhookPayload struct {
+	ContentID uuid.UUID           `json:"content_id"`
+	Status    model.ContentStatus `json:"status"`
+	FileName  string              `json:"file_name"`
+	MIMEType  string              `json:"mime_type"`
+	FileSize  int64               `json:"file_size"`
+	Timestamp time.Time           `json:"timestamp"`
+}
+
+// webhookNotifier delivers signed completion callbacks
+type webhookNotifier struct {
+	config WebhookConfig
+	client *http.Client
+
+	mu       sync.Mutex
+	inFlight map[uuid.UUID]struct{}
+}
+
+func newWebhookNotifier(config WebhookConfig) *webhookNotifier {
+	client := newRemoteFetcher(DefaultRemoteFetchConfig()).client
+	client.Timeout = config.Timeout
+
+	return &webhookNotifier{
+		config:   config,
+		client:   client,
+		inFlight: make(map[uuid.UUID]struct{}),
+	}
+}
+
+// ConfigureWebhooks sets the signing secret and retry policy for callbacks.
+// Deliveries already in progress keep their previous settings.
+func (s *ContentService) ConfigureWebhooks(config WebhookConfig) {
+	s.webhooks.Store(newWebhookNotifier(config))
+}
+
+// WebhookConfig returns the current callback delivery settings
+func (s *ContentService) WebhookConfig() WebhookConfig {
+	return s.webhooks.Load().config
+}
+
+// validateCallbackURL checks that a callback URL can be delivered to
+func validateCallbackURL(rawURL string) error {
+	if rawURL == "" {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return ErrInvalidInput
+	}
+	return checkRemoteScheme(u)
+}
+
+// sign returns the signature header value for a payload sent at timestamp
+func (n *webhookNotifier) sign(timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, n.config.Secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// notifyCompletion delivers the callback of a content that reached a terminal
+// status, unless it has already been delivered or is being delivered
+func (s *ContentService) notifyCompletion(content *model.Content) {
+	if content.CallbackURL == "" || content.CallbackSentAt != nil || !content.Status.IsTerminal() {
+		return
+	}
+
+	n := s.webhooks.Load()
+	n.mu.Lock()
+	if _, busy := n.inFlight[content.ID]; busy {
+		n.mu.Unlock()
+		return
+	}
+	n.inFlight[content.ID] = struct{}{}
+	n.mu.Unlock()
+
+	payload := WebhookPayload{
+		ContentID: content.ID,
+		Status:    content.Status,
+		FileName:  content.FileName,
+		MIMEType:  content.MIMEType,
+		FileSize:  content.FileSize,
+		Timestamp: s.now(),
+	}
+
+	go func() {
+		defer func() {
+			n.mu.Lock()
+			delete(n.inFlight, content.ID)
+			n.mu.Unlock()
+		}()
+
+		// Recording the callback first makes sure that of several instances
+		// completing the same content, only one sends it
+		ctx := context.Background()
+		marked, err := s.repo.MarkCallbackSent(ctx, content.ID, s.now())
+		if err != nil {
+			log.Printf("Failed to record callback delivery for content %s: %v", content.ID, err)
+			return
+		}
+		if !marked {
+			return
+		}
+
+		if err := n.deliver(content.CallbackURL, payload); err != nil {
+			log.Printf("Failed to deliver callback for content %s: %v", content.ID, err)
+			if err := s.repo.ClearCallbackSent(ctx, content.ID); err != nil {
+				log.Printf("Failed to reset callback delivery for content %s: %v", content.ID, err)
+			}
+		}
+	}()
+}
+
+// deliver posts payload to callbackURL, retrying with exponential backoff
+func (n *webhookNotifier) deliver(callbackURL string, payload WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(payload.Timestamp.Unix(), 10)
+
+	backoff := n.config.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err = n.post(callbackURL, timestamp, body)
+		if err == nil || attempt >= n.config.MaxAttempts {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (n *webhookNotifier) post(callbackURL, timestamp string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	if len(n.config.Secret) > 0 {
+		req.Header.Set("X-Webhook-Signature", n.sign(timestamp, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("callback returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository/memory"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+)
+
+// newTestInstance creates a service on repo whose callbacks may reach the test server
+func newTestInstance(repo *memory.MemoryRepository) *ContentService {
+	s := NewContentService(repo, memorystorage.NewMemoryStorage())
+	s.ConfigureWebhooks(WebhookConfig{MaxAttempts: 1, Timeout: time.Second})
+	s.webhooks.Load().client = http.DefaultClient
+	return s
+}
+
+func TestNotifyCompletionSendsOnceAcrossInstances(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		wantSent   int32
+		wantMarked bool
+	}{
+		{name: "delivered", status: http.StatusOK, wantSent: 1, wantMarked: true},
+		// A failed delivery is forgotten so the callback can be sent again
+		{name: "failed", status: http.StatusInternalServerError, wantSent: 1, wantMarked: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				sent.Add(1)
+				// Answer slowly so the other instance tries while this delivery is under way
+				time.Sleep(100 * time.Millisecond)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			ctx := context.Background()
+			repo := memory.NewMemoryRepository()
+			content := &model.Content{ID: uuid.New(), Status: model.StatusDone, CallbackURL: server.URL, Metadata: model.Metadata{}}
+			if err := repo.CreateContent(ctx, content); err != nil {
+				t.Fatal(err)
+			}
+
+			// Two instances completing the same content both try to notify
+			newTestInstance(repo).notifyCompletion(content)
+			newTestInstance(repo).notifyCompletion(content)
+
+			deadline := time.Now().Add(2 * time.Second)
+			for time.Now().Before(deadline) {
+				stored, err := repo.GetContentByID(ctx, content.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if (stored.CallbackSentAt != nil) == tt.wantMarked && sent.Load() >= tt.wantSent {
+					break
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			time.Sleep(50 * time.Millisecond)
+
+			if got := sent.Load(); got != tt.wantSent {
+				t.Errorf("callback sent %d times, want %d", got, tt.wantSent)
+			}
+			stored, err := repo.GetContentByID(ctx, content.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if marked := stored.CallbackSentAt != nil; marked != tt.wantMarked {
+				t.Errorf("callback recorded = %v, want %v", marked, tt.wantMarked)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package docusign
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/livefire2015/simple-contents/signature"
+)
+
+// Config holds the DocuSign eSignature API settings
+type Config struct {
+	BaseURL     string // REST base, e.g. https://demo.docusign.net/restapi
+	AccountID   string
+	AccessToken string // OAuth access token
+	ConnectKey  string // HMAC key of the Connect configuration, webhooks are not verified if empty
+}
+
+// Provider implements signature.Provider using DocuSign envelopes
+type Provider struct {
+	config Config
+	client *http.Client
+}
+
+// NewProvider creates a new DocuSign provider
+func NewProvider(config Config) *Provider {
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+	return &Provider{
+		config: config,
+		client: http.DefaultClient,
+	}
+}
+
+// Name identifies the provider
+func (p *Provider) Name() string {
+	return "docusign"
+}
+
+func (p *Provider) envelopesURL() string {
+	return p.config.BaseURL + "/v2.1/accounts/" + p.config.AccountID + "/envelopes"
+}
+
+func (p *Provider) do(req *http.Request) (*http.Response, error) {
+	req.Header.Set("Authorization", "Bearer "+p.config.AccessToken)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("docusign returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+// Send creates and sends an envelope with a single document
+func (p *Provider) Send(ctx context.Context, envelope signature.Envelope) (string, error) {
+	document, err := io.ReadAll(envelope.Document)
+	if err != nil {
+		return "", err
+	}
+
+	type signer struct {
+		Email        string `json:"email"`
+		Name         string `json:"name"`
+		RecipientID  string `json:"recipientId"`
+		RoutingOrder string `json:"routingOrder"`
+	}
+	signers := make([]signer, len(envelope.Signers))
+	for i, s := range envelope.Signers {
+		signers[i] = signer{Email: s.Email, Name: s.Name, RecipientID: strconv.Itoa(i + 1), RoutingOrder: "1"}
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"emailSubject": envelope.Subject,
+		"emailBlurb":   envelope.Message,
+		"documents": []map[string]string{{
+			"documentId":     "1",
+			"name":           envelope.FileName,
+			"fileExtension":  strings.TrimPrefix(filepath.Ext(envelope.FileName), "."),
+			"documentBase64": base64.StdEncoding.EncodeToString(document),
+		}},
+		"recipients": map[string]interface{}{"signers": signers},
+		"status":     "sent",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.envelopesURL(), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		EnvelopeID string `json:"envelopeId"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if result.EnvelopeID == "" {
+		return "", fmt.Errorf("docusign returned no envelope ID")
+	}
+	return result.EnvelopeID, nil
+}
+
+// connectEvents maps Connect event names to request statuses
+var connectEvents = map[string]signature.Status{
+	"envelope-sent":      signature.StatusSent,
+	"envelope-completed": signature.StatusCompleted,
+	"envelope-declined":  signature.StatusDeclined,
+	"envelope-voided":    signature.StatusVoided,
+}
+
+// ParseWebhook verifies and decodes a Connect JSON notification
+func (p *Provider) ParseWebhook(header http.Header, body []byte) (*signature.Event, error) {
+	if p.config.ConnectKey != "" {
+		mac := hmac.New(sha256.New, []byte(p.config.ConnectKey))
+		mac.Write(body)
+		expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+		// Connect sends one signature header per configured key
+		verified := false
+		for i := 1; header.Get("X-DocuSign-Signature-"+strconv.Itoa(i)) != ""; i++ {
+			if hmac.Equal([]byte(header.Get("X-DocuSign-Signature-"+strconv.Itoa(i))), []byte(expected)) {
+				verified = true
+				break
+			}
+		}
+		if !verified {
+			return nil, fmt.Errorf("%w: bad HMAC signature", signature.ErrInvalidWebhook)
+		}
+	}
+
+	var notification struct {
+		Event string `json:"event"`
+		Data  struct {
+			EnvelopeID string `json:"envelopeId"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, fmt.Errorf("%w: %v", signature.ErrInvalidWebhook, err)
+	}
+
+	status, ok := connectEvents[notification.Event]
+	if !ok {
+		return &signature.Event{}, nil
+	}
+	return &signature.Event{ProviderRequestID: notification.Data.EnvelopeID, Status: status}, nil
+}
+
+// DownloadSigned returns the envelope's documents combined into one PDF
+func (p *Provider) DownloadSigned(ctx context.Context, providerRequestID string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.envelopesURL()+"/"+providerRequestID+"/documents/combined", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,203 @@
+package dropboxsign
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/livefire2015/simple-contents/signature"
+)
+
+// DefaultBaseURL is the Dropbox Sign API endpoint
+const DefaultBaseURL = "https://api.hellosign.com/v3"
+
+// webhookAck is the response body Dropbox Sign requires from callbacks
+const webhookAck = "Hello API Event Received"
+
+// Config holds the Dropbox Sign API settings
+type Config struct {
+	BaseURL  string // DefaultBaseURL if empty
+	APIKey   string // Also used to verify callbacks
+	TestMode bool   // Send requests that are not legally binding
+}
+
+// Provider implements signature.Provider using Dropbox Sign signature requests
+type Provider struct {
+	config Config
+	client *http.Client
+}
+
+// NewProvider creates a new Dropbox Sign provider
+func NewProvider(config Config) *Provider {
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultBaseURL
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+	return &Provider{
+		config: config,
+		client: http.DefaultClient,
+	}
+}
+
+// Name identifies the provider
+func (p *Provider) Name() string {
+	return "dropboxsign"
+}
+
+func (p *Provider) do(req *http.Request) (*http.Response, error) {
+	req.SetBasicAuth(p.config.APIKey, "")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("dropbox sign returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+// Send creates a signature request for a single document
+func (p *Provider) Send(ctx context.Context, envelope signature.Envelope) (string, error) {
+	// Stream the multipart body instead of buffering the document
+	pr, pw := io.Pipe()
+	form := multipart.NewWriter(pw)
+	go func() {
+		fields := map[string]string{
+			"title":   envelope.Subject,
+			"subject": envelope.Subject,
+			"message": envelope.Message,
+		}
+		if p.config.TestMode {
+			fields["test_mode"] = "1"
+		}
+		for i, signer := range envelope.Signers {
+			prefix := "signers[" + strconv.Itoa(i) + "]"
+			fields[prefix+"[name]"] = signer.Name
+			fields[prefix+"[email_address]"] = signer.Email
+		}
+
+		var err error
+		for name, value := range fields {
+			if err = form.WriteField(name, value); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			var part io.Writer
+			part, err = form.CreateFormFile("file[0]", envelope.FileName)
+			if err == nil {
+				_, err = io.Copy(part, envelope.Document)
+			}
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.BaseURL+"/signature_request/send", pr)
+	if err != nil {
+		pr.Close()
+		return "", err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	resp, err := p.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		SignatureRequest struct {
+			SignatureRequestID string `json:"signature_request_id"`
+		} `json:"signature_request"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if result.SignatureRequest.SignatureRequestID == "" {
+		return "", fmt.Errorf("dropbox sign returned no signature request ID")
+	}
+	return result.SignatureRequest.SignatureRequestID, nil
+}
+
+// callbackEvents maps callback event types to request statuses
+var callbackEvents = map[string]signature.Status{
+	"signature_request_sent":       signature.StatusSent,
+	"signature_request_all_signed": signature.StatusCompleted,
+	"signature_request_declined":   signature.StatusDeclined,
+	"signature_request_canceled":   signature.StatusVoided,
+	"signature_request_expired":    signature.StatusVoided,
+}
+
+// ParseWebhook verifies and decodes an account or app callback. Callbacks
+// are multipart forms whose "json" field holds the event.
+func (p *Provider) ParseWebhook(header http.Header, body []byte) (*signature.Event, error) {
+	payload := body
+	if mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type")); err == nil && strings.HasPrefix(mediaType, "multipart/") {
+		form, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(1 << 20)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", signature.ErrInvalidWebhook, err)
+		}
+		defer form.RemoveAll()
+		if len(form.Value["json"]) == 0 {
+			return nil, fmt.Errorf("%w: missing json field", signature.ErrInvalidWebhook)
+		}
+		payload = []byte(form.Value["json"][0])
+	}
+
+	var callback struct {
+		Event struct {
+			EventTime string `json:"event_time"`
+			EventType string `json:"event_type"`
+			EventHash string `json:"event_hash"`
+		} `json:"event"`
+		SignatureRequest struct {
+			SignatureRequestID string `json:"signature_request_id"`
+		} `json:"signature_request"`
+	}
+	if err := json.Unmarshal(payload, &callback); err != nil {
+		return nil, fmt.Errorf("%w: %v", signature.ErrInvalidWebhook, err)
+	}
+
+	mac := hmac.New(sha256.New, []byte(p.config.APIKey))
+	mac.Write([]byte(callback.Event.EventTime + callback.Event.EventType))
+	if !hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(callback.Event.EventHash)) {
+		return nil, fmt.Errorf("%w: bad event hash", signature.ErrInvalidWebhook)
+	}
+
+	event := &signature.Event{Ack: webhookAck}
+	if status, ok := callbackEvents[callback.Event.EventType]; ok {
+		event.ProviderRequestID = callback.SignatureRequest.SignatureRequestID
+		event.Status = status
+	}
+	return event, nil
+}
+
+// DownloadSigned returns the signed document as a PDF
+func (p *Provider) DownloadSigned(ctx context.Context, providerRequestID string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.config.BaseURL+"/signature_request/files/"+providerRequestID+"?file_type=pdf", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
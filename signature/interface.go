@@ -0,0 +1,61 @@
+package signature
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// ErrInvalidWebhook is returned for webhooks that fail verification or cannot be parsed
+var ErrInvalidWebhook = errors.New("invalid signature webhook")
+
+// Status is the state of a signature request at the provider
+type Status string
+
+const (
+	StatusSent      Status = "sent"      // Waiting for signers
+	StatusCompleted Status = "completed" // Every signer has signed
+	StatusDeclined  Status = "declined"  // A signer declined to sign
+	StatusVoided    Status = "voided"    // The request was cancelled or expired
+)
+
+// IsFinal reports whether no further events are expected for a request
+func (s Status) IsFinal() bool {
+	return s == StatusCompleted || s == StatusDeclined || s == StatusVoided
+}
+
+// Signer is a person asked to sign a document
+type Signer struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Envelope is a document sent for signature
+type Envelope struct {
+	Document io.Reader
+	FileName string
+	MIMEType string
+	Subject  string
+	Message  string
+	Signers  []Signer
+}
+
+// Event is a status change reported by a provider webhook
+type Event struct {
+	ProviderRequestID string // Provider's identifier of the request, empty for events that can be ignored
+	Status            Status
+	Ack               string // Response body the provider expects, if any
+}
+
+// Provider sends documents for signature through an e-signature service
+type Provider interface {
+	// Name identifies the provider in URLs and stored requests, e.g. "docusign"
+	Name() string
+	// Send creates a signature request and returns the provider's identifier for it
+	Send(ctx context.Context, envelope Envelope) (providerRequestID string, err error)
+	// ParseWebhook verifies and decodes a webhook delivered by the provider
+	ParseWebhook(header http.Header, body []byte) (*Event, error)
+	// DownloadSigned returns the signed document of a completed request as a PDF
+	DownloadSigned(ctx context.Context, providerRequestID string) (io.ReadCloser, error)
+}
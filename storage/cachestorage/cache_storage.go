@@ -0,0 +1,169 @@
+package cachestorage
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/livefire2015/simple-contents/storage"
+)
+
+// Tier is an optional second cache level, such as a local disk or Redis.
+// Versions of an object are identified by its path and ETag, and Invalidate
+// drops every version of a path, including ones the LRU no longer holds.
+type Tier interface {
+	Get(ctx context.Context, path, etag string) ([]byte, bool)
+	Set(ctx context.Context, path, etag string, data []byte)
+	Invalidate(ctx context.Context, path string)
+}
+
+// Config controls the size of the cache and which objects are admitted
+type Config struct {
+	MaxBytes      int64 // Total size of the in-memory LRU
+	MaxObjectSize int64 // Objects larger than this are never cached
+	Tier          Tier  // Optional second level consulted on LRU misses
+}
+
+// CacheStorage is a read-through cache in front of a StorageService.
+// Entries are keyed by storage path and ETag so overwritten objects are never served stale.
+type CacheStorage struct {
+	next   storage.StorageService
+	config Config
+	lru    *lru
+}
+
+// NewCacheStorage creates a new caching storage decorator
+func NewCacheStorage(next storage.StorageService, config Config) *CacheStorage {
+	if config.MaxObjectSize <= 0 || config.MaxObjectSize > config.MaxBytes {
+		config.MaxObjectSize = config.MaxBytes
+	}
+
+	return &CacheStorage{
+		next:   next,
+		config: config,
+		lru:    newLRU(config.MaxBytes),
+	}
+}
+
+// cacheKey identifies a specific version of an object
+func cacheKey(path, etag string) string {
+	return path + "@" + etag
+}
+
+// Upload saves content data to storage and invalidates cached versions of the path
+func (s *CacheStorage) Upload(ctx context.Context, key string, data io.Reader, size int64, contentType string) (string, error) {
+	path, err := s.next.Upload(ctx, key, data, size, contentType)
+	if err != nil {
+		return "", err
+	}
+
+	s.invalidate(ctx, path)
+	return path, nil
+}
+
+// Download gets content data from the cache, falling back to storage on a miss
+func (s *CacheStorage) Download(ctx context.Context, path string) (io.ReadCloser, error) {
+	info, err := s.next.Stat(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Objects without an ETag can't be validated, so don't cache them
+	if info.ETag == "" || info.Size > s.config.MaxObjectSize {
+		return s.next.Download(ctx, path)
+	}
+
+	key := cacheKey(path, info.ETag)
+	if data, ok := s.lru.get(key); ok {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	if s.config.Tier != nil {
+		if data, ok := s.config.Tier.Get(ctx, path, info.ETag); ok {
+			s.lru.add(key, path, data)
+			return io.NopCloser(bytes.NewReader(data)), nil
+		}
+	}
+
+	reader, err := s.next.Download(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	s.lru.add(key, path, data)
+	if s.config.Tier != nil {
+		s.config.Tier.Set(ctx, path, info.ETag, data)
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Stat returns information about a stored object
+func (s *CacheStorage) Stat(ctx context.Context, path string) (*storage.ObjectInfo, error) {
+	return s.next.Stat(ctx, path)
+}
+
+// GetPresignedDownloadURL returns a URL for accessing the content
+func (s *CacheStorage) GetPresignedDownloadURL(ctx context.Context, path string, options storage.PresignedURLOptions) (string, error) {
+	return s.next.GetPresignedDownloadURL(ctx, path, options)
+}
+
+// PresignPut presigns a PUT of an object, if the backend supports direct
+// uploads. Cached data of the key is dropped, as the client overwrites it.
+func (s *CacheStorage) PresignPut(ctx context.Context, key string, options storage.PresignedUploadOptions) (*storage.PresignedUpload, error) {
+	uploader, ok := s.next.(storage.PresignedUploader)
+	if !ok {
+		return nil, storage.ErrDirectUploadUnsupported
+	}
+	s.invalidate(ctx, key)
+	return uploader.PresignPut(ctx, key, options)
+}
+
+// PresignPost presigns a POST policy for an object, if the backend supports
+// direct uploads. Cached data of the key is dropped, as the client
+// overwrites it.
+func (s *CacheStorage) PresignPost(ctx context.Context, key string, options storage.PresignedUploadOptions) (*storage.PresignedUpload, error) {
+	uploader, ok := s.next.(storage.PresignedUploader)
+	if !ok {
+		return nil, storage.ErrDirectUploadUnsupported
+	}
+	s.invalidate(ctx, key)
+	return uploader.PresignPost(ctx, key, options)
+}
+
+// CopyToBucket copies an object to another bucket of the backend
+func (s *CacheStorage) CopyToBucket(ctx context.Context, path, bucket, key string) error {
+	exporter, ok := s.next.(storage.BucketExporter)
+	if !ok {
+		return storage.ErrBucketExportUnsupported
+	}
+	return exporter.CopyToBucket(ctx, path, bucket, key)
+}
+
+// UploadToBucket writes an object to another bucket of the backend
+func (s *CacheStorage) UploadToBucket(ctx context.Context, bucket, key string, data io.Reader, size int64, contentType string) error {
+	exporter, ok := s.next.(storage.BucketExporter)
+	if !ok {
+		return storage.ErrBucketExportUnsupported
+	}
+	return exporter.UploadToBucket(ctx, bucket, key, data, size, contentType)
+}
+
+// Delete removes content data from storage and from the cache
+func (s *CacheStorage) Delete(ctx context.Context, path string) error {
+	s.invalidate(ctx, path)
+	return s.next.Delete(ctx, path)
+}
+
+// invalidate drops every cached version of path
+func (s *CacheStorage) invalidate(ctx context.Context, path string) {
+	s.lru.removePath(path)
+	if s.config.Tier != nil {
+		s.config.Tier.Invalidate(ctx, path)
+	}
+}
@@ -0,0 +1,200 @@
+package cachestorage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/livefire2015/simple-contents/storage"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+)
+
+// countingStorage is memory storage counting the downloads reaching it
+type countingStorage struct {
+	*memorystorage.MemoryStorage
+	downloads map[string]int
+}
+
+func newCountingStorage() *countingStorage {
+	return &countingStorage{MemoryStorage: memorystorage.NewMemoryStorage(), downloads: make(map[string]int)}
+}
+
+func (s *countingStorage) Download(ctx context.Context, path string) (io.ReadCloser, error) {
+	s.downloads[path]++
+	return s.MemoryStorage.Download(ctx, path)
+}
+
+// put writes an object straight to a backend
+func put(t *testing.T, backend storage.StorageService, path, data string) {
+	t.Helper()
+	if _, err := backend.Upload(context.Background(), path, strings.NewReader(data), int64(len(data)), "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// read downloads an object through the cache
+func read(t *testing.T, cache *CacheStorage, path string) string {
+	t.Helper()
+	reader, err := cache.Download(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Download(%q) error = %v", path, err)
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCacheStorage(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		run           func(t *testing.T, cache *CacheStorage, backend *countingStorage)
+		path          string
+		wantData      string
+		wantDownloads int // Downloads of path that reached the backend
+	}{
+		{
+			name:          "miss",
+			run:           func(t *testing.T, cache *CacheStorage, backend *countingStorage) { put(t, backend, "a", "aaaa") },
+			path:          "a",
+			wantData:      "aaaa",
+			wantDownloads: 1,
+		},
+		{
+			name: "hit",
+			run: func(t *testing.T, cache *CacheStorage, backend *countingStorage) {
+				put(t, backend, "a", "aaaa")
+				read(t, cache, "a")
+			},
+			path:          "a",
+			wantData:      "aaaa",
+			wantDownloads: 1,
+		},
+		{
+			name: "overwritten behind the cache",
+			run: func(t *testing.T, cache *CacheStorage, backend *countingStorage) {
+				put(t, backend, "a", "aaaa")
+				read(t, cache, "a")
+				// The new ETag misses the cached version
+				put(t, backend, "a", "AAAA")
+			},
+			path:          "a",
+			wantData:      "AAAA",
+			wantDownloads: 2,
+		},
+		{
+			name: "invalidated by upload",
+			run: func(t *testing.T, cache *CacheStorage, backend *countingStorage) {
+				put(t, cache, "a", "aaaa")
+				read(t, cache, "a")
+				put(t, cache, "a", "aaaa")
+			},
+			path:          "a",
+			wantData:      "aaaa",
+			wantDownloads: 2,
+		},
+		{
+			name: "evicted",
+			run: func(t *testing.T, cache *CacheStorage, backend *countingStorage) {
+				put(t, backend, "a", "aaaa")
+				put(t, backend, "b", "bbbb")
+				put(t, backend, "c", "cccc")
+				read(t, cache, "a")
+				read(t, cache, "b")
+				// Reading a makes b the least recently used
+				read(t, cache, "a")
+				read(t, cache, "c")
+			},
+			path:          "b",
+			wantData:      "bbbb",
+			wantDownloads: 2,
+		},
+		{
+			name: "kept over eviction",
+			run: func(t *testing.T, cache *CacheStorage, backend *countingStorage) {
+				put(t, backend, "a", "aaaa")
+				put(t, backend, "b", "bbbb")
+				put(t, backend, "c", "cccc")
+				read(t, cache, "a")
+				read(t, cache, "b")
+				read(t, cache, "a")
+				read(t, cache, "c")
+			},
+			path:          "a",
+			wantData:      "aaaa",
+			wantDownloads: 1,
+		},
+		{
+			name: "too large to cache",
+			run: func(t *testing.T, cache *CacheStorage, backend *countingStorage) {
+				put(t, backend, "large", "0123456789")
+				read(t, cache, "large")
+			},
+			path:          "large",
+			wantData:      "0123456789",
+			wantDownloads: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newCountingStorage()
+			cache := NewCacheStorage(backend, Config{MaxBytes: 10, MaxObjectSize: 8})
+			tt.run(t, cache, backend)
+
+			if got := read(t, cache, tt.path); got != tt.wantData {
+				t.Errorf("Download(%q) = %q, want %q", tt.path, got, tt.wantData)
+			}
+			if got := backend.downloads[tt.path]; got != tt.wantDownloads {
+				t.Errorf("backend downloads of %q = %d, want %d", tt.path, got, tt.wantDownloads)
+			}
+		})
+	}
+
+	t.Run("invalidated by delete", func(t *testing.T) {
+		backend := newCountingStorage()
+		cache := NewCacheStorage(backend, Config{MaxBytes: 10})
+		put(t, backend, "a", "aaaa")
+		read(t, cache, "a")
+		if err := cache.Delete(ctx, "a"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := cache.Download(ctx, "a"); !errors.Is(err, memorystorage.ErrContentNotFound) {
+			t.Errorf("Download() of a deleted object error = %v, want %v", err, memorystorage.ErrContentNotFound)
+		}
+		if cache.lru.size != 0 {
+			t.Errorf("LRU holds %d bytes after the delete, want 0", cache.lru.size)
+		}
+	})
+}
+
+func TestCacheStoragePassesThrough(t *testing.T) {
+	ctx := context.Background()
+	backend := newCountingStorage()
+	cache := NewCacheStorage(backend, Config{MaxBytes: 10})
+	put(t, backend, "a", "aaaa")
+
+	info, err := cache.Stat(ctx, "a")
+	if err != nil || info.Size != 4 || info.ETag == "" {
+		t.Errorf("Stat() = %+v, %v, want the object of the backend", info, err)
+	}
+	if url, err := cache.GetPresignedDownloadURL(ctx, "a", storage.PresignedURLOptions{}); err != nil || url != "memory://a" {
+		t.Errorf("GetPresignedDownloadURL() = %q, %v, want the URL of the backend", url, err)
+	}
+	// Memory storage can't presign uploads or write to other buckets
+	if _, err := cache.PresignPut(ctx, "a", storage.PresignedUploadOptions{}); !errors.Is(err, storage.ErrDirectUploadUnsupported) {
+		t.Errorf("PresignPut() error = %v, want %v", err, storage.ErrDirectUploadUnsupported)
+	}
+	if err := cache.CopyToBucket(ctx, "a", "audit", "a"); !errors.Is(err, storage.ErrBucketExportUnsupported) {
+		t.Errorf("CopyToBucket() error = %v, want %v", err, storage.ErrBucketExportUnsupported)
+	}
+	if backend.downloads["a"] != 0 {
+		t.Errorf("backend downloads = %d, want none", backend.downloads["a"])
+	}
+}
@@ -0,0 +1,204 @@
+package cachestorage
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// diskEntry is a cached object version stored as a file
+type diskEntry struct {
+	file string // Path of the file relative to the tier directory
+	size int64
+}
+
+// DiskTier is a second-level cache that keeps object data in a local
+// directory. Each object path gets its own subdirectory holding a file per
+// version, so that invalidating a path removes every version of it. The least
+// recently used files are evicted to stay within the byte budget.
+type DiskTier struct {
+	dir      string
+	maxBytes int64
+
+	mu    sync.Mutex
+	size  int64
+	order *list.List
+	files map[string]*list.Element
+}
+
+// NewDiskTier creates a disk cache tier rooted at dir, holding at most
+// maxBytes of data. Files left in dir by a previous process are kept, oldest
+// first in line for eviction.
+func NewDiskTier(dir string, maxBytes int64) (*DiskTier, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	t := &DiskTier{
+		dir:      dir,
+		maxBytes: maxBytes,
+		order:    list.New(),
+		files:    make(map[string]*list.Element),
+	}
+	if err := t.load(); err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	t.evict()
+	t.mu.Unlock()
+	return t, nil
+}
+
+// load indexes the files already in the directory by modification time
+func (t *DiskTier) load() error {
+	type existing struct {
+		diskEntry
+		modTime int64
+	}
+	var found []existing
+
+	err := filepath.WalkDir(t.dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(t.dir, path)
+		if err != nil {
+			return err
+		}
+		// Leftover temporary files and anything not in a path directory are removed
+		if filepath.Dir(rel) == "." {
+			return os.Remove(path)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		found = append(found, existing{diskEntry{file: rel, size: info.Size()}, info.ModTime().UnixNano()})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].modTime > found[j].modTime })
+	for _, f := range found {
+		entry := f.diskEntry
+		t.files[entry.file] = t.order.PushBack(&entry)
+		t.size += entry.size
+	}
+	return nil
+}
+
+// Get reads the cached data of a version of path
+func (t *DiskTier) Get(ctx context.Context, path, etag string) ([]byte, bool) {
+	file := t.fileName(path, etag)
+
+	t.mu.Lock()
+	elem, ok := t.files[file]
+	if ok {
+		t.order.MoveToFront(elem)
+	}
+	t.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(filepath.Join(t.dir, file))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Set writes the data of a version of path, replacing any previous value
+// atomically, and evicts the least recently used files over the budget
+func (t *DiskTier) Set(ctx context.Context, path, etag string, data []byte) {
+	if int64(len(data)) > t.maxBytes {
+		return
+	}
+
+	file := t.fileName(path, etag)
+	if err := os.MkdirAll(filepath.Join(t.dir, filepath.Dir(file)), 0o755); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(t.dir, "tmp-*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Renaming under the lock keeps the index in step with the files
+	if err := os.Rename(tmp.Name(), filepath.Join(t.dir, file)); err != nil {
+		return
+	}
+	if elem, ok := t.files[file]; ok {
+		t.remove(elem)
+	}
+	t.files[file] = t.order.PushFront(&diskEntry{file: file, size: int64(len(data))})
+	t.size += int64(len(data))
+	t.evict()
+}
+
+// Invalidate removes every cached version of path
+func (t *DiskTier) Invalidate(ctx context.Context, path string) {
+	pathDir := hashName(path)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for file, elem := range t.files {
+		if filepath.Dir(file) == pathDir {
+			t.remove(elem)
+		}
+	}
+	// Files the index doesn't know of, e.g. from a failed load, go too
+	_ = os.RemoveAll(filepath.Join(t.dir, pathDir))
+}
+
+// evict removes the least recently used files until the tier fits its budget.
+// The caller must hold t.mu.
+func (t *DiskTier) evict() {
+	for t.size > t.maxBytes && t.order.Len() > 0 {
+		elem := t.order.Back()
+		entry := elem.Value.(*diskEntry)
+		t.remove(elem)
+		_ = os.Remove(filepath.Join(t.dir, entry.file))
+		_ = os.Remove(filepath.Join(t.dir, filepath.Dir(entry.file)))
+	}
+}
+
+// remove drops an entry from the index. The caller must hold t.mu.
+func (t *DiskTier) remove(elem *list.Element) {
+	entry := elem.Value.(*diskEntry)
+	t.order.Remove(elem)
+	delete(t.files, entry.file)
+	t.size -= entry.size
+}
+
+// fileName maps a version of path to a file name that is safe on any
+// filesystem, in a directory shared by the versions of path
+func (t *DiskTier) fileName(path, etag string) string {
+	return filepath.Join(hashName(path), hashName(etag))
+}
+
+func hashName(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
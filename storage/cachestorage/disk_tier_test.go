@@ -0,0 +1,119 @@
+package cachestorage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+)
+
+func TestDiskTierEvictsOverBudget(t *testing.T) {
+	ctx := context.Background()
+	tier, err := NewDiskTier(t.TempDir(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tier.Set(ctx, "a", "1", []byte("aaaa"))
+	tier.Set(ctx, "b", "1", []byte("bbbb"))
+	// Reading a makes b the least recently used
+	if _, ok := tier.Get(ctx, "a", "1"); !ok {
+		t.Fatal("a missing before eviction")
+	}
+	tier.Set(ctx, "c", "1", []byte("cccc"))
+
+	tests := []struct {
+		path   string
+		wantOK bool
+	}{
+		{path: "a", wantOK: true},
+		{path: "b", wantOK: false},
+		{path: "c", wantOK: true},
+	}
+	for _, tt := range tests {
+		if _, ok := tier.Get(ctx, tt.path, "1"); ok != tt.wantOK {
+			t.Errorf("Get(%q) cached = %v, want %v", tt.path, ok, tt.wantOK)
+		}
+	}
+	if tier.size > 10 {
+		t.Errorf("tier holds %d bytes, budget is 10", tier.size)
+	}
+}
+
+func TestDiskTierKeepsBudgetAcrossRestarts(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	tier, err := NewDiskTier(dir, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tier.Set(ctx, "a", "1", []byte("aaaa"))
+	tier.Set(ctx, "b", "1", []byte("bbbb"))
+
+	reopened, err := NewDiskTier(dir, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.size != 4 {
+		t.Errorf("reopened tier holds %d bytes, want 4", reopened.size)
+	}
+	if data, ok := reopened.Get(ctx, "b", "1"); !ok || string(data) != "bbbb" {
+		t.Errorf("Get(b) = %q, %v, want the most recent file kept", data, ok)
+	}
+}
+
+func TestCacheStorageInvalidatesDiskVersionsEvictedFromMemory(t *testing.T) {
+	ctx := context.Background()
+	tier, err := NewDiskTier(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := memorystorage.NewMemoryStorage()
+	// The LRU only fits one object, so the first one lives on disk alone
+	cache := NewCacheStorage(backend, Config{MaxBytes: 8, Tier: tier})
+
+	for _, key := range []string{"first", "second"} {
+		if _, err := cache.Upload(ctx, key, strings.NewReader(key[:5]), 5, "text/plain"); err != nil {
+			t.Fatal(err)
+		}
+		reader, err := cache.Download(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, reader)
+		reader.Close()
+	}
+
+	info, err := backend.Stat(ctx, "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tier.Get(ctx, "first", info.ETag); !ok {
+		t.Fatal("first not cached on disk")
+	}
+
+	if err := cache.Delete(ctx, "first"); err != nil {
+		t.Fatal(err)
+	}
+	if data, ok := tier.Get(ctx, "first", info.ETag); ok {
+		t.Errorf("deleted object still readable from disk: %q", data)
+	}
+	if _, err := cache.Download(ctx, "first"); err == nil {
+		t.Error("Download() of deleted object succeeded")
+	}
+	if data, ok := tier.Get(ctx, "second", mustETag(t, backend, "second")); !ok || !bytes.Equal(data, []byte("secon")) {
+		t.Errorf("unrelated object dropped from disk: %q, %v", data, ok)
+	}
+}
+
+func mustETag(t *testing.T, backend *memorystorage.MemoryStorage, path string) string {
+	t.Helper()
+	info, err := backend.Stat(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.ETag
+}
@@ -0,0 +1,92 @@
+package cachestorage
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lruEntry is a cached object in the LRU
+type lruEntry struct {
+	key  string
+	path string
+	data []byte
+}
+
+// lru is a size-bounded least-recently-used cache of object data
+type lru struct {
+	mu       sync.Mutex
+	maxBytes int64
+	size     int64
+	order    *list.List
+	items    map[string]*list.Element
+	byPath   map[string]map[string]struct{}
+}
+
+func newLRU(maxBytes int64) *lru {
+	return &lru{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+		byPath:   make(map[string]map[string]struct{}),
+	}
+}
+
+// get returns the cached data for key and marks it as recently used
+func (c *lru) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*lruEntry).data, true
+}
+
+// add stores data for key, evicting the least recently used entries as needed
+func (c *lru) add(key, path string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if int64(len(data)) > c.maxBytes {
+		return
+	}
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+	}
+
+	c.items[key] = c.order.PushFront(&lruEntry{key: key, path: path, data: data})
+	if c.byPath[path] == nil {
+		c.byPath[path] = make(map[string]struct{})
+	}
+	c.byPath[path][key] = struct{}{}
+	c.size += int64(len(data))
+
+	for c.size > c.maxBytes {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// removePath drops every cached version of path
+func (c *lru) removePath(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.byPath[path] {
+		if elem, ok := c.items[key]; ok {
+			c.removeElement(elem)
+		}
+	}
+}
+
+func (c *lru) removeElement(elem *list.Element) {
+	entry := elem.Value.(*lruEntry)
+	c.order.Remove(elem)
+	delete(c.items, entry.key)
+	delete(c.byPath[entry.path], entry.key)
+	if len(c.byPath[entry.path]) == 0 {
+		delete(c.byPath, entry.path)
+	}
+	c.size -= int64(len(entry.data))
+}
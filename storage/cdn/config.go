@@ -0,0 +1,92 @@
+// Package cdn signs download URLs of CDNs whose origin is a storage bucket
+package cdn
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/livefire2015/simple-contents/storage"
+)
+
+// Kinds of CDN
+const (
+	KindCloudFront = "cloudfront"
+	KindCloudCDN   = "cloudcdn"
+)
+
+// Config describes a CDN in front of a bucket
+type Config struct {
+	Name           string `json:"name"`
+	Kind           string `json:"kind"`             // cloudfront or cloudcdn
+	BaseURL        string `json:"base_url"`         // Scheme and host the CDN serves objects from
+	KeyID          string `json:"key_id"`           // CloudFront public key ID or Cloud CDN key name
+	PrivateKeyFile string `json:"private_key_file"` // CloudFront: PEM RSA private key
+	KeyFile        string `json:"key_file"`         // Cloud CDN: base64url encoded signing key
+}
+
+// LoadConfig reads a JSON array of CDN configurations and returns their signers by name
+func LoadConfig(path string) (map[string]storage.URLSigner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var configs []Config
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("invalid CDN configuration: %w", err)
+	}
+
+	signers := make(map[string]storage.URLSigner, len(configs))
+	for _, config := range configs {
+		signer, err := NewSigner(config)
+		if err != nil {
+			return nil, fmt.Errorf("CDN %q: %w", config.Name, err)
+		}
+		signers[config.Name] = signer
+	}
+	return signers, nil
+}
+
+// NewSigner creates the signer of a CDN, reading its key from disk
+func NewSigner(config Config) (storage.URLSigner, error) {
+	if config.Name == "" || config.BaseURL == "" || config.KeyID == "" {
+		return nil, fmt.Errorf("name, base_url and key_id are required")
+	}
+
+	switch config.Kind {
+	case KindCloudFront:
+		pemData, err := os.ReadFile(config.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		privateKey, err := ParseRSAPrivateKey(pemData)
+		if err != nil {
+			return nil, err
+		}
+		return NewCloudFrontSigner(config.BaseURL, config.KeyID, privateKey), nil
+	case KindCloudCDN:
+		encoded, err := os.ReadFile(config.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		key, err := base64.URLEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid signing key: %w", err)
+		}
+		return NewCloudCDNSigner(config.BaseURL, config.KeyID, key), nil
+	default:
+		return nil, fmt.Errorf("unknown kind %q", config.Kind)
+	}
+}
+
+// escapeKey percent-encodes the segments of an object key for use in a URL path
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -2,11 +2,15 @@ package gcp
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"net/http"
 	"time"
 
 	gcpstorage "cloud.google.com/go/storage"
 	"github.com/livefire2015/simple-contents/storage"
+	"google.golang.org/api/googleapi"
 )
 
 // GCPStorage implements StorageService using Google Cloud Storage
@@ -23,8 +27,27 @@ func NewGCPStorage(client *gcpstorage.Client, bucketName string) *GCPStorage {
 	}
 }
 
-// Store saves content data to storage and returns the path
-func (s *GCPStorage) Uploaded(ctx context.Context, key string, data io.Reader, size int64, contentType string) (string, error) {
+// translateError wraps the errors GCS reports for missing objects and refused
+// access with storage.ErrNotFound and storage.ErrAccessDenied
+func translateError(err error) error {
+	if errors.Is(err, gcpstorage.ErrObjectNotExist) {
+		return fmt.Errorf("%w: %w", storage.ErrNotFound, err)
+	}
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case http.StatusNotFound:
+			return fmt.Errorf("%w: %w", storage.ErrNotFound, err)
+		case http.StatusForbidden, http.StatusUnauthorized:
+			return fmt.Errorf("%w: %w", storage.ErrAccessDenied, err)
+		}
+	}
+	return err
+}
+
+// Upload saves content data to storage and returns the path.
+// The GCS writer streams data in chunks, so the size may be UnknownSize.
+func (s *GCPStorage) Upload(ctx context.Context, key string, data io.Reader, size int64, contentType string) (string, error) {
 	bucket := s.client.Bucket(s.bucketName)
 	obj := bucket.Object(key)
 	writer := obj.NewWriter(ctx)
@@ -46,14 +69,53 @@ func (s *GCPStorage) Uploaded(ctx context.Context, key string, data io.Reader, s
 func (s *GCPStorage) Download(ctx context.Context, path string) (io.ReadCloser, error) {
 	bucket := s.client.Bucket(s.bucketName)
 	obj := bucket.Object(path)
-	return obj.NewReader(ctx)
+	reader, err := obj.NewReader(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return reader, nil
+}
+
+// Stat returns information about a stored object
+func (s *GCPStorage) Stat(ctx context.Context, path string) (*storage.ObjectInfo, error) {
+	bucket := s.client.Bucket(s.bucketName)
+	attrs, err := bucket.Object(path).Attrs(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &storage.ObjectInfo{
+		Size:         attrs.Size,
+		ContentType:  attrs.ContentType,
+		ETag:         attrs.Etag,
+		LastModified: attrs.Updated,
+	}, nil
 }
 
 // Delete removes content data from storage
 func (s *GCPStorage) Delete(ctx context.Context, path string) error {
 	bucket := s.client.Bucket(s.bucketName)
 	obj := bucket.Object(path)
-	return obj.Delete(ctx)
+	return translateError(obj.Delete(ctx))
+}
+
+// CopyToBucket copies an object to another bucket with a server-side copy
+func (s *GCPStorage) CopyToBucket(ctx context.Context, path, bucket, key string) error {
+	src := s.client.Bucket(s.bucketName).Object(path)
+	_, err := s.client.Bucket(bucket).Object(key).CopierFrom(src).Run(ctx)
+	return translateError(err)
+}
+
+// UploadToBucket writes an object to another bucket
+func (s *GCPStorage) UploadToBucket(ctx context.Context, bucket, key string, data io.Reader, size int64, contentType string) error {
+	writer := s.client.Bucket(bucket).Object(key).NewWriter(ctx)
+	writer.ContentType = contentType
+
+	if _, err := io.Copy(writer, data); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
 }
 
 // GetURL returns a URL for accessing the content
@@ -2,22 +2,105 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"io"
 	"time"
 	// Assuming your model package path
 )
 
+// UnknownSize may be passed as the size to Upload when the length of the data
+// is not known in advance, e.g. for chunked HTTP bodies or streaming sources.
+const UnknownSize int64 = -1
+
 // PresignedURLOptions provides options for generating presigned URLs.
 type PresignedURLOptions struct {
 	Expiry time.Duration
 	// Add other options like content type for upload URLs if needed
 }
 
+var (
+	// ErrDirectUploadUnsupported is returned when a backend can't presign uploads
+	ErrDirectUploadUnsupported = errors.New("storage backend does not support direct uploads")
+	// ErrBucketExportUnsupported is returned when a backend can't write to other buckets
+	ErrBucketExportUnsupported = errors.New("storage backend does not support exports to other buckets")
+	// ErrNotFound is wrapped by backends in the error for a missing object
+	ErrNotFound = errors.New("object not found")
+	// ErrAccessDenied is wrapped by backends when they refuse access to an object
+	ErrAccessDenied = errors.New("access to object denied")
+)
+
+// PresignedUploadOptions constrains what a presigned upload can write.
+type PresignedUploadOptions struct {
+	Expiry      time.Duration
+	ContentType string // Content type the object must be uploaded with
+	Size        int64  // Exact size of a PUT, largest size accepted by a POST
+	// ChecksumSHA256 is the base64 SHA-256 the data must have, checked by the
+	// backend as it receives the upload. Not checked if empty.
+	ChecksumSHA256 string
+}
+
+// PresignedUpload is a request a client sends the data of an object with,
+// directly to the storage backend.
+type PresignedUpload struct {
+	Method    string            `json:"method"` // PUT or POST
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers,omitempty"` // Headers a PUT must be sent with
+	Fields    map[string]string `json:"fields,omitempty"`  // Form fields a multipart POST must send before the file
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// PresignedUploader is implemented by backends that clients can upload to directly.
+// PresignPost returns a POST policy for browser forms, with conditions on the
+// content type and length.
+type PresignedUploader interface {
+	PresignPut(ctx context.Context, key string, options PresignedUploadOptions) (*PresignedUpload, error)
+	PresignPost(ctx context.Context, key string, options PresignedUploadOptions) (*PresignedUpload, error)
+}
+
+// BucketExporter is implemented by backends that can write objects into other
+// buckets of the same service, e.g. to export content for auditors.
+// CopyToBucket copies an object server side, without its data passing
+// through the service.
+type BucketExporter interface {
+	CopyToBucket(ctx context.Context, path, bucket, key string) error
+	UploadToBucket(ctx context.Context, bucket, key string, data io.Reader, size int64, contentType string) error
+}
+
+// ObjectInfo describes a stored object as reported by the storage backend.
+type ObjectInfo struct {
+	Size         int64     // Size of the object in bytes
+	ContentType  string    // Content type recorded by the backend
+	ETag         string    // Entity tag of the object, without surrounding quotes
+	LastModified time.Time // Time the object was last written
+	// ChecksumSHA256 is the base64 SHA-256 of the object if the backend recorded one
+	ChecksumSHA256 string
+}
+
 // StorageService defines the interface for file storage operations.
 type StorageService interface {
 	Upload(ctx context.Context, key string, data io.Reader, size int64, contentType string) (path string, err error)
 	Download(ctx context.Context, path string) (io.ReadCloser, error)
-	//GetPresignedUploadURL(ctx context.Context, contentID string, fileName string, mimeType string, options PresignedURLOptions) (url *url.URL, additionalHeaders map[string]string, err error)
+	Stat(ctx context.Context, path string) (*ObjectInfo, error)
 	GetPresignedDownloadURL(ctx context.Context, path string, options PresignedURLOptions) (url string, err error)
 	Delete(ctx context.Context, path string) error
 }
+
+// URLSigner signs download URLs of a CDN whose origin is a storage bucket, so
+// that downloads are cached at the edge without exposing the bucket
+type URLSigner interface {
+	SignURL(key string, expiry time.Duration) (string, error)
+}
+
+type tenantContextKey struct{}
+
+// WithTenant returns a context that scopes storage operations to a tenant.
+// Backends that isolate tenants resolve the bucket and key prefix from it.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant set by WithTenant, or "" if there is none
+func TenantFromContext(ctx context.Context) string {
+	tenantID, _ := ctx.Value(tenantContextKey{}).(string)
+	return tenantID
+}
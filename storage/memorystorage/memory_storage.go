@@ -3,27 +3,38 @@ package memorystorage
 import (
 	"bytes"
 	"context"
-	"errors"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
 	"io"
 	"sync"
+	"time"
 
 	"github.com/livefire2015/simple-contents/storage"
 )
 
 var (
-	ErrContentNotFound = errors.New("content not found in storage")
+	ErrContentNotFound = fmt.Errorf("content not found in storage: %w", storage.ErrNotFound)
 )
 
+// memoryObject is a single object held by MemoryStorage
+type memoryObject struct {
+	data         []byte
+	contentType  string
+	etag         string
+	lastModified time.Time
+}
+
 // MemoryStorage implements StorageService using in-memory storage
 type MemoryStorage struct {
 	mu      sync.RWMutex
-	storage map[string][]byte
+	storage map[string]*memoryObject
 }
 
 // NewMemoryStorage creates a new in-memory storage service
 func NewMemoryStorage() *MemoryStorage {
 	return &MemoryStorage{
-		storage: make(map[string][]byte),
+		storage: make(map[string]*memoryObject),
 	}
 }
 
@@ -39,7 +50,13 @@ func (s *MemoryStorage) Upload(ctx context.Context, key string, data io.Reader,
 	}
 
 	// Store the data with the key as the path
-	s.storage[key] = content
+	sum := md5.Sum(content)
+	s.storage[key] = &memoryObject{
+		data:         content,
+		contentType:  contentType,
+		etag:         hex.EncodeToString(sum[:]),
+		lastModified: time.Now(),
+	}
 
 	return key, nil
 }
@@ -49,13 +66,31 @@ func (s *MemoryStorage) Download(ctx context.Context, path string) (io.ReadClose
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	content, exists := s.storage[path]
+	obj, exists := s.storage[path]
 	if !exists {
 		return nil, ErrContentNotFound
 	}
 
 	// Return a reader for the content
-	return io.NopCloser(bytes.NewReader(content)), nil
+	return io.NopCloser(bytes.NewReader(obj.data)), nil
+}
+
+// Stat returns information about a stored object
+func (s *MemoryStorage) Stat(ctx context.Context, path string) (*storage.ObjectInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	obj, exists := s.storage[path]
+	if !exists {
+		return nil, ErrContentNotFound
+	}
+
+	return &storage.ObjectInfo{
+		Size:         int64(len(obj.data)),
+		ContentType:  obj.contentType,
+		ETag:         obj.etag,
+		LastModified: obj.lastModified,
+	}, nil
 }
 
 // Delete removes content data from storage
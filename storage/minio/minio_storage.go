@@ -2,7 +2,10 @@ package minio
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"net/http"
+	"time"
 
 	"github.com/livefire2015/simple-contents/storage"
 	"github.com/minio/minio-go/v7"
@@ -22,7 +25,24 @@ func NewMinioStorage(client *minio.Client, bucketName string) *MinioStorage {
 	}
 }
 
-// Upload saves content data to storage and returns the path
+// translateError wraps the errors MinIO reports for missing objects and
+// refused access with storage.ErrNotFound and storage.ErrAccessDenied
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	response := minio.ToErrorResponse(err)
+	switch {
+	case response.Code == "NoSuchKey" || response.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %w", storage.ErrNotFound, err)
+	case response.Code == "AccessDenied" || response.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("%w: %w", storage.ErrAccessDenied, err)
+	}
+	return err
+}
+
+// Upload saves content data to storage and returns the path.
+// MinIO switches to a multipart upload on its own when size is UnknownSize.
 func (s *MinioStorage) Upload(ctx context.Context, key string, data io.Reader, size int64, contentType string) (string, error) {
 	_, err := s.client.PutObject(ctx, s.bucketName, key, data, size, minio.PutObjectOptions{
 		ContentType: contentType,
@@ -44,9 +64,42 @@ func (s *MinioStorage) Download(ctx context.Context, path string) (io.ReadCloser
 	return obj, nil
 }
 
+// Stat returns information about a stored object
+func (s *MinioStorage) Stat(ctx context.Context, path string) (*storage.ObjectInfo, error) {
+	info, err := s.client.StatObject(ctx, s.bucketName, path, minio.StatObjectOptions{Checksum: true})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &storage.ObjectInfo{
+		Size:         info.Size,
+		ContentType:  info.ContentType,
+		ETag:         info.ETag,
+		LastModified: info.LastModified,
+
+		ChecksumSHA256: info.ChecksumSHA256,
+	}, nil
+}
+
 // Delete removes content data from storage
 func (s *MinioStorage) Delete(ctx context.Context, path string) error {
-	return s.client.RemoveObject(ctx, s.bucketName, path, minio.RemoveObjectOptions{})
+	return translateError(s.client.RemoveObject(ctx, s.bucketName, path, minio.RemoveObjectOptions{}))
+}
+
+// CopyToBucket copies an object to another bucket with a server-side copy
+func (s *MinioStorage) CopyToBucket(ctx context.Context, path, bucket, key string) error {
+	_, err := s.client.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: bucket, Object: key},
+		minio.CopySrcOptions{Bucket: s.bucketName, Object: path})
+	return translateError(err)
+}
+
+// UploadToBucket writes an object to another bucket
+func (s *MinioStorage) UploadToBucket(ctx context.Context, bucket, key string, data io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, bucket, key, data, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	return translateError(err)
 }
 
 // GetURL returns a URL for accessing the content
@@ -59,3 +112,55 @@ func (s *MinioStorage) GetPresignedDownloadURL(ctx context.Context, path string,
 
 	return presignedURL.String(), nil
 }
+
+// PresignPut generates a presigned PUT of an object. MinIO doesn't sign the
+// headers of a PUT, so the content type, size and checksum are only checked
+// when the upload is confirmed.
+func (s *MinioStorage) PresignPut(ctx context.Context, key string, options storage.PresignedUploadOptions) (*storage.PresignedUpload, error) {
+	presignedURL, err := s.client.PresignedPutObject(ctx, s.bucketName, key, options.Expiry)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string]string{"Content-Type": options.ContentType}
+	if options.ChecksumSHA256 != "" {
+		headers["x-amz-checksum-sha256"] = options.ChecksumSHA256
+	}
+	return &storage.PresignedUpload{
+		Method:    http.MethodPut,
+		URL:       presignedURL.String(),
+		Headers:   headers,
+		ExpiresAt: time.Now().Add(options.Expiry),
+	}, nil
+}
+
+// PresignPost generates a POST policy for a browser form uploading an object
+// of the given type and at most the given size
+func (s *MinioStorage) PresignPost(ctx context.Context, key string, options storage.PresignedUploadOptions) (*storage.PresignedUpload, error) {
+	expiresAt := time.Now().Add(options.Expiry)
+	policy := minio.NewPostPolicy()
+	for _, err := range []error{
+		policy.SetBucket(s.bucketName),
+		policy.SetKey(key),
+		policy.SetExpires(expiresAt),
+		policy.SetContentType(options.ContentType),
+		policy.SetContentLengthRange(1, options.Size),
+		policy.SetChecksum(minio.NewChecksumString(minio.ChecksumSHA256, options.ChecksumSHA256)),
+	} {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	presignedURL, fields, err := s.client.PresignedPostPolicy(ctx, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &storage.PresignedUpload{
+		Method:    http.MethodPost,
+		URL:       presignedURL.String(),
+		Fields:    fields,
+		ExpiresAt: expiresAt,
+	}, nil
+}
@@ -0,0 +1,291 @@
+package replicatedstorage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/livefire2015/simple-contents/storage"
+)
+
+// replicationKind is the change to apply to the secondary backend
+type replicationKind int
+
+const (
+	replicateUpload replicationKind = iota
+	replicateDelete
+)
+
+// replicationOp is a pending change to the secondary backend
+type replicationOp struct {
+	kind replicationKind
+	path string
+	seq  uint64
+}
+
+// ReplicatedStorage writes to a primary backend and mirrors every change to a
+// secondary backend asynchronously. Reads fail over to the secondary when the
+// primary is unavailable. Mirror writes that fail are kept for Repair.
+type ReplicatedStorage struct {
+	primary   storage.StorageService
+	secondary storage.StorageService
+	timeout   time.Duration
+
+	queue chan replicationOp
+	done  chan struct{}
+
+	mu      sync.Mutex
+	seq     uint64
+	pending map[string]replicationOp
+}
+
+// NewReplicatedStorage creates a new replicating storage service and starts
+// its mirror worker. queueSize bounds the number of in-flight mirror writes;
+// when the queue is full, changes are left for Repair.
+func NewReplicatedStorage(primary, secondary storage.StorageService, queueSize int) *ReplicatedStorage {
+	s := &ReplicatedStorage{
+		primary:   primary,
+		secondary: secondary,
+		timeout:   5 * time.Minute,
+		queue:     make(chan replicationOp, queueSize),
+		done:      make(chan struct{}),
+		pending:   make(map[string]replicationOp),
+	}
+	go s.worker()
+	return s
+}
+
+// Close stops the mirror worker after the queued changes have been applied
+func (s *ReplicatedStorage) Close() {
+	close(s.queue)
+	<-s.done
+}
+
+// Upload saves content data to the primary and queues a mirror write
+func (s *ReplicatedStorage) Upload(ctx context.Context, key string, data io.Reader, size int64, contentType string) (string, error) {
+	path, err := s.primary.Upload(ctx, key, data, size, contentType)
+	if err != nil {
+		return "", err
+	}
+
+	s.enqueue(replicateUpload, path)
+	return path, nil
+}
+
+// Download gets content data from the primary, falling back to the secondary
+func (s *ReplicatedStorage) Download(ctx context.Context, path string) (io.ReadCloser, error) {
+	reader, err := s.primary.Download(ctx, path)
+	if err == nil || ctx.Err() != nil {
+		return reader, err
+	}
+
+	reader, secondaryErr := s.secondary.Download(ctx, path)
+	if secondaryErr != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
+// Stat returns information about a stored object, falling back to the secondary
+func (s *ReplicatedStorage) Stat(ctx context.Context, path string) (*storage.ObjectInfo, error) {
+	info, err := s.primary.Stat(ctx, path)
+	if err == nil || ctx.Err() != nil {
+		return info, err
+	}
+
+	info, secondaryErr := s.secondary.Stat(ctx, path)
+	if secondaryErr != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// GetPresignedDownloadURL returns a URL from the primary, falling back to the secondary
+func (s *ReplicatedStorage) GetPresignedDownloadURL(ctx context.Context, path string, options storage.PresignedURLOptions) (string, error) {
+	url, err := s.primary.GetPresignedDownloadURL(ctx, path, options)
+	if err == nil || ctx.Err() != nil {
+		return url, err
+	}
+
+	url, secondaryErr := s.secondary.GetPresignedDownloadURL(ctx, path, options)
+	if secondaryErr != nil {
+		return "", err
+	}
+	return url, nil
+}
+
+// PresignPut presigns a PUT of an object to the primary, if it supports
+// direct uploads. The object is mirrored by Repair once the client has
+// uploaded it.
+func (s *ReplicatedStorage) PresignPut(ctx context.Context, key string, options storage.PresignedUploadOptions) (*storage.PresignedUpload, error) {
+	uploader, ok := s.primary.(storage.PresignedUploader)
+	if !ok {
+		return nil, storage.ErrDirectUploadUnsupported
+	}
+	upload, err := uploader.PresignPut(ctx, key, options)
+	if err != nil {
+		return nil, err
+	}
+	s.markPending(replicateUpload, key)
+	return upload, nil
+}
+
+// PresignPost presigns a POST policy for an object of the primary, if it
+// supports direct uploads. The object is mirrored by Repair once the client
+// has uploaded it.
+func (s *ReplicatedStorage) PresignPost(ctx context.Context, key string, options storage.PresignedUploadOptions) (*storage.PresignedUpload, error) {
+	uploader, ok := s.primary.(storage.PresignedUploader)
+	if !ok {
+		return nil, storage.ErrDirectUploadUnsupported
+	}
+	upload, err := uploader.PresignPost(ctx, key, options)
+	if err != nil {
+		return nil, err
+	}
+	s.markPending(replicateUpload, key)
+	return upload, nil
+}
+
+// CopyToBucket copies an object of the primary to another of its buckets
+func (s *ReplicatedStorage) CopyToBucket(ctx context.Context, path, bucket, key string) error {
+	exporter, ok := s.primary.(storage.BucketExporter)
+	if !ok {
+		return storage.ErrBucketExportUnsupported
+	}
+	return exporter.CopyToBucket(ctx, path, bucket, key)
+}
+
+// UploadToBucket writes an object to another bucket of the primary. Objects
+// outside the bucket of the primary aren't mirrored.
+func (s *ReplicatedStorage) UploadToBucket(ctx context.Context, bucket, key string, data io.Reader, size int64, contentType string) error {
+	exporter, ok := s.primary.(storage.BucketExporter)
+	if !ok {
+		return storage.ErrBucketExportUnsupported
+	}
+	return exporter.UploadToBucket(ctx, bucket, key, data, size, contentType)
+}
+
+// Delete removes content data from the primary and queues a mirror delete
+func (s *ReplicatedStorage) Delete(ctx context.Context, path string) error {
+	if err := s.primary.Delete(ctx, path); err != nil {
+		return err
+	}
+
+	s.enqueue(replicateDelete, path)
+	return nil
+}
+
+// Pending returns the number of changes not yet applied to the secondary
+func (s *ReplicatedStorage) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.pending)
+}
+
+// Repair retries every change that has not reached the secondary yet
+func (s *ReplicatedStorage) Repair(ctx context.Context) error {
+	s.mu.Lock()
+	ops := make([]replicationOp, 0, len(s.pending))
+	for _, op := range s.pending {
+		ops = append(ops, op)
+	}
+	s.mu.Unlock()
+
+	var errs []error
+	for _, op := range ops {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.apply(ctx, op); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		s.resolve(op)
+	}
+	return errors.Join(errs...)
+}
+
+// RunRepair calls Repair every interval until ctx is done
+func (s *ReplicatedStorage) RunRepair(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = s.Repair(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// enqueue records a change as pending and hands it to the worker if there is room
+func (s *ReplicatedStorage) enqueue(kind replicationKind, path string) {
+	op := s.markPending(kind, path)
+
+	select {
+	case s.queue <- op:
+	default:
+		// Queue is full, the change stays pending until Repair
+	}
+}
+
+// markPending records a change as pending, to be applied by the worker or
+// by Repair
+func (s *ReplicatedStorage) markPending(kind replicationKind, path string) replicationOp {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	op := replicationOp{kind: kind, path: path, seq: s.seq}
+	s.pending[path] = op
+	return op
+}
+
+// worker applies queued changes in order
+func (s *ReplicatedStorage) worker() {
+	defer close(s.done)
+
+	for op := range s.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		if err := s.apply(ctx, op); err == nil {
+			s.resolve(op)
+		}
+		cancel()
+	}
+}
+
+// resolve clears a pending change unless a newer change to the same path was made
+func (s *ReplicatedStorage) resolve(op replicationOp) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, ok := s.pending[op.path]; ok && current.seq <= op.seq {
+		delete(s.pending, op.path)
+	}
+}
+
+// apply performs a change on the secondary backend
+func (s *ReplicatedStorage) apply(ctx context.Context, op replicationOp) error {
+	if op.kind == replicateDelete {
+		return s.secondary.Delete(ctx, op.path)
+	}
+
+	info, err := s.primary.Stat(ctx, op.path)
+	if err != nil {
+		return err
+	}
+
+	reader, err := s.primary.Download(ctx, op.path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	_, err = s.secondary.Upload(ctx, op.path, reader, info.Size, info.ContentType)
+	return err
+}
@@ -0,0 +1,79 @@
+package retrystorage
+
+import (
+	"sync"
+	"time"
+)
+
+// circuitBreaker stops calls to a backend after too many consecutive failures
+// and lets a single trial call through once the open period has elapsed
+type circuitBreaker struct {
+	mu               sync.Mutex
+	failureThreshold int
+	openDuration     time.Duration
+	failures         int
+	openedAt         time.Time
+	halfOpen         bool
+}
+
+func newCircuitBreaker(failureThreshold int, openDuration time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		failureThreshold: failureThreshold,
+		openDuration:     openDuration,
+	}
+}
+
+// allow reports whether a call may proceed
+func (b *circuitBreaker) allow() bool {
+	if b.failureThreshold <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.failureThreshold {
+		return true
+	}
+	if b.halfOpen || time.Since(b.openedAt) < b.openDuration {
+		return false
+	}
+
+	// Let one trial call through
+	b.halfOpen = true
+	return true
+}
+
+// success records a successful call and closes the circuit
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.halfOpen = false
+}
+
+// cancel records a call abandoned by its caller. A trial call that was
+// abandoned lets the next call through as the new trial.
+func (b *circuitBreaker) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.halfOpen = false
+}
+
+// failure records a failed call and reports whether it opened the circuit
+func (b *circuitBreaker) failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.failureThreshold <= 0 || b.failures < b.failureThreshold {
+		return false
+	}
+
+	wasOpen := b.failures > b.failureThreshold && !b.halfOpen
+	b.openedAt = time.Now()
+	b.halfOpen = false
+	return !wasOpen
+}
@@ -0,0 +1,260 @@
+package retrystorage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"time"
+
+	"github.com/livefire2015/simple-contents/storage"
+)
+
+var (
+	ErrCircuitOpen = errors.New("storage circuit breaker is open")
+)
+
+// Metrics receives events about failed storage calls
+type Metrics interface {
+	Retry(op string, attempt int, err error)
+	Failure(op string, err error)
+	CircuitOpened()
+}
+
+// Config controls retries, timeouts and circuit breaking
+type Config struct {
+	MaxAttempts      int                  // Total attempts for idempotent operations
+	InitialBackoff   time.Duration        // Backoff before the first retry
+	MaxBackoff       time.Duration        // Upper bound for the backoff between attempts
+	Timeout          time.Duration        // Per-attempt timeout for Stat, Delete and URL generation
+	FailureThreshold int                  // Consecutive failures that open the circuit, 0 disables it
+	OpenDuration     time.Duration        // How long the circuit stays open before a trial call
+	Retryable        func(err error) bool // Decides whether an error is worth retrying
+	Metrics          Metrics              // Optional failure metrics
+}
+
+// DefaultConfig returns a Config suitable for cloud object stores
+func DefaultConfig() Config {
+	return Config{
+		MaxAttempts:      3,
+		InitialBackoff:   100 * time.Millisecond,
+		MaxBackoff:       2 * time.Second,
+		Timeout:          10 * time.Second,
+		FailureThreshold: 5,
+		OpenDuration:     30 * time.Second,
+	}
+}
+
+// RetryStorage wraps a StorageService with retries, timeouts and a circuit breaker.
+// Uploads are never retried because the data reader cannot be replayed.
+type RetryStorage struct {
+	next    storage.StorageService
+	config  Config
+	breaker *circuitBreaker
+}
+
+// NewRetryStorage creates a new retrying storage decorator
+func NewRetryStorage(next storage.StorageService, config Config) *RetryStorage {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 1
+	}
+	if config.Retryable == nil {
+		config.Retryable = isRetryable
+	}
+
+	return &RetryStorage{
+		next:    next,
+		config:  config,
+		breaker: newCircuitBreaker(config.FailureThreshold, config.OpenDuration),
+	}
+}
+
+// isRetryable retries everything except cancellation by the caller and
+// answers that won't change on another attempt
+func isRetryable(err error) bool {
+	return !errors.Is(err, context.Canceled) && !isDefinitive(err)
+}
+
+// isDefinitive reports whether err is a backend's answer about an object,
+// rather than a sign that the backend is failing
+func isDefinitive(err error) bool {
+	return errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrAccessDenied)
+}
+
+// Upload saves content data to storage and returns the path
+func (s *RetryStorage) Upload(ctx context.Context, key string, data io.Reader, size int64, contentType string) (string, error) {
+	var path string
+	err := s.call(ctx, "upload", 1, 0, func(ctx context.Context) error {
+		var err error
+		path, err = s.next.Upload(ctx, key, data, size, contentType)
+		return err
+	})
+	return path, err
+}
+
+// Download gets content data from storage
+func (s *RetryStorage) Download(ctx context.Context, path string) (io.ReadCloser, error) {
+	var reader io.ReadCloser
+	// No per-attempt timeout, it would cut off the returned stream
+	err := s.call(ctx, "download", s.config.MaxAttempts, 0, func(ctx context.Context) error {
+		var err error
+		reader, err = s.next.Download(ctx, path)
+		return err
+	})
+	return reader, err
+}
+
+// Stat returns information about a stored object
+func (s *RetryStorage) Stat(ctx context.Context, path string) (*storage.ObjectInfo, error) {
+	var info *storage.ObjectInfo
+	err := s.call(ctx, "stat", s.config.MaxAttempts, s.config.Timeout, func(ctx context.Context) error {
+		var err error
+		info, err = s.next.Stat(ctx, path)
+		return err
+	})
+	return info, err
+}
+
+// GetPresignedDownloadURL returns a URL for accessing the content
+func (s *RetryStorage) GetPresignedDownloadURL(ctx context.Context, path string, options storage.PresignedURLOptions) (string, error) {
+	var url string
+	err := s.call(ctx, "presign_download", s.config.MaxAttempts, s.config.Timeout, func(ctx context.Context) error {
+		var err error
+		url, err = s.next.GetPresignedDownloadURL(ctx, path, options)
+		return err
+	})
+	return url, err
+}
+
+// Delete removes content data from storage
+func (s *RetryStorage) Delete(ctx context.Context, path string) error {
+	return s.call(ctx, "delete", s.config.MaxAttempts, s.config.Timeout, func(ctx context.Context) error {
+		return s.next.Delete(ctx, path)
+	})
+}
+
+// PresignPut presigns a PUT of an object, if the backend supports direct uploads
+func (s *RetryStorage) PresignPut(ctx context.Context, key string, options storage.PresignedUploadOptions) (*storage.PresignedUpload, error) {
+	uploader, ok := s.next.(storage.PresignedUploader)
+	if !ok {
+		return nil, storage.ErrDirectUploadUnsupported
+	}
+	var upload *storage.PresignedUpload
+	err := s.call(ctx, "presign_put", s.config.MaxAttempts, s.config.Timeout, func(ctx context.Context) error {
+		var err error
+		upload, err = uploader.PresignPut(ctx, key, options)
+		return err
+	})
+	return upload, err
+}
+
+// PresignPost presigns a POST policy for an object, if the backend supports
+// direct uploads
+func (s *RetryStorage) PresignPost(ctx context.Context, key string, options storage.PresignedUploadOptions) (*storage.PresignedUpload, error) {
+	uploader, ok := s.next.(storage.PresignedUploader)
+	if !ok {
+		return nil, storage.ErrDirectUploadUnsupported
+	}
+	var upload *storage.PresignedUpload
+	err := s.call(ctx, "presign_post", s.config.MaxAttempts, s.config.Timeout, func(ctx context.Context) error {
+		var err error
+		upload, err = uploader.PresignPost(ctx, key, options)
+		return err
+	})
+	return upload, err
+}
+
+// CopyToBucket copies an object to another bucket of the backend. Copies
+// aren't bounded by the timeout, large objects take a while.
+func (s *RetryStorage) CopyToBucket(ctx context.Context, path, bucket, key string) error {
+	exporter, ok := s.next.(storage.BucketExporter)
+	if !ok {
+		return storage.ErrBucketExportUnsupported
+	}
+	return s.call(ctx, "copy_to_bucket", s.config.MaxAttempts, 0, func(ctx context.Context) error {
+		return exporter.CopyToBucket(ctx, path, bucket, key)
+	})
+}
+
+// UploadToBucket writes an object to another bucket of the backend
+func (s *RetryStorage) UploadToBucket(ctx context.Context, bucket, key string, data io.Reader, size int64, contentType string) error {
+	exporter, ok := s.next.(storage.BucketExporter)
+	if !ok {
+		return storage.ErrBucketExportUnsupported
+	}
+	return s.call(ctx, "upload_to_bucket", 1, 0, func(ctx context.Context) error {
+		return exporter.UploadToBucket(ctx, bucket, key, data, size, contentType)
+	})
+}
+
+// call runs fn up to attempts times with exponential backoff and jitter
+func (s *RetryStorage) call(ctx context.Context, op string, attempts int, timeout time.Duration, fn func(ctx context.Context) error) error {
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if !s.breaker.allow() {
+			return ErrCircuitOpen
+		}
+
+		err = s.attempt(ctx, timeout, fn)
+		if err == nil || isDefinitive(err) {
+			// The backend answered, even if the object is missing or off limits
+			s.breaker.success()
+			return err
+		}
+		if ctx.Err() != nil {
+			// The caller gave up, which says nothing about the backend. A
+			// per-attempt timeout, with the caller still waiting, does count.
+			s.breaker.cancel()
+			err = ctx.Err()
+			break
+		}
+
+		if s.breaker.failure() && s.config.Metrics != nil {
+			s.config.Metrics.CircuitOpened()
+		}
+		if attempt == attempts || !s.config.Retryable(err) {
+			break
+		}
+		if s.config.Metrics != nil {
+			s.config.Metrics.Retry(op, attempt, err)
+		}
+
+		timer := time.NewTimer(s.backoff(attempt))
+		select {
+		case <-timer.C:
+			continue
+		case <-ctx.Done():
+			timer.Stop()
+			err = ctx.Err()
+		}
+		break
+	}
+
+	if s.config.Metrics != nil {
+		s.config.Metrics.Failure(op, err)
+	}
+	return err
+}
+
+// attempt runs fn once, bounded by timeout if one is set
+func (s *RetryStorage) attempt(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return fn(ctx)
+}
+
+// backoff returns the delay before the given retry using full jitter
+func (s *RetryStorage) backoff(attempt int) time.Duration {
+	backoff := s.config.InitialBackoff << (attempt - 1)
+	if backoff <= 0 || (s.config.MaxBackoff > 0 && backoff > s.config.MaxBackoff) {
+		backoff = s.config.MaxBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return rand.N(backoff)
+}
@@ -0,0 +1,100 @@
+package retrystorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/livefire2015/simple-contents/storage"
+)
+
+// failingStorage fails Stat with err and counts the calls
+type failingStorage struct {
+	storage.StorageService
+	err   error
+	calls int
+}
+
+func (f *failingStorage) Stat(ctx context.Context, path string) (*storage.ObjectInfo, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &storage.ObjectInfo{}, nil
+}
+
+func (f *failingStorage) Download(ctx context.Context, path string) (io.ReadCloser, error) {
+	f.calls++
+	return nil, f.err
+}
+
+func testConfig() Config {
+	return Config{
+		MaxAttempts:      3,
+		FailureThreshold: 2,
+		OpenDuration:     time.Hour,
+	}
+}
+
+func TestRetryStorageClassifiesErrors(t *testing.T) {
+	errUnavailable := errors.New("503 service unavailable")
+
+	tests := []struct {
+		name      string
+		err       error
+		wantCalls int
+		wantOpen  bool
+	}{
+		{name: "success", err: nil, wantCalls: 1},
+		{name: "not found", err: fmt.Errorf("%w: NoSuchKey", storage.ErrNotFound), wantCalls: 1},
+		{name: "access denied", err: fmt.Errorf("%w: AccessDenied", storage.ErrAccessDenied), wantCalls: 1},
+		{name: "transient failure", err: errUnavailable, wantCalls: 2, wantOpen: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &failingStorage{err: tt.err}
+			s := NewRetryStorage(backend, testConfig())
+
+			// Repeat the call so the breaker sees several consecutive outcomes
+			for range 3 {
+				_, err := s.Stat(context.Background(), "key")
+				if tt.err == nil && err != nil {
+					t.Fatalf("Stat() error = %v", err)
+				}
+			}
+
+			_, err := s.Stat(context.Background(), "key")
+			if open := errors.Is(err, ErrCircuitOpen); open != tt.wantOpen {
+				t.Errorf("circuit open = %v, want %v", open, tt.wantOpen)
+			}
+			if !tt.wantOpen && backend.calls != 4*tt.wantCalls {
+				t.Errorf("backend called %d times, want %d", backend.calls, 4*tt.wantCalls)
+			}
+			if tt.wantOpen && backend.calls != tt.wantCalls {
+				t.Errorf("backend called %d times before opening, want %d", backend.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryStorageIgnoresCallerCancellation(t *testing.T) {
+	backend := &failingStorage{err: context.Canceled}
+	s := NewRetryStorage(backend, testConfig())
+
+	for range 5 {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := s.Download(ctx, "key"); !errors.Is(err, context.Canceled) {
+			t.Fatalf("Download() error = %v, want context.Canceled", err)
+		}
+	}
+
+	backend.err = nil
+	if _, err := s.Stat(context.Background(), "key"); err != nil {
+		t.Errorf("Stat() after cancellations error = %v, want the circuit closed", err)
+	}
+}
@@ -0,0 +1,142 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+)
+
+// ErrObjectChanged is returned when an object is overwritten during a parallel
+// download, so its parts would come from different versions
+var ErrObjectChanged = errors.New("object changed during download")
+
+// ErrPartLength is returned when a ranged part of a parallel download holds
+// more or fewer bytes than its range, which would misalign the parts after it
+var ErrPartLength = errors.New("ranged part has the wrong length")
+
+// ParallelDownloadConfig controls ranged, concurrent downloads of large objects
+type ParallelDownloadConfig struct {
+	Concurrency int   // Number of parts fetched at the same time
+	PartSize    int64 // Size of each ranged part in bytes
+}
+
+// enabled reports whether parallel downloads are configured
+func (c ParallelDownloadConfig) enabled() bool {
+	return c.Concurrency > 1 && c.PartSize > 0
+}
+
+// partResult holds the outcome of fetching a single ranged part
+type partResult struct {
+	data []byte
+	err  error
+}
+
+// parallelReader streams reassembled parts and stops the fetchers on Close
+type parallelReader struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+}
+
+// Close stops any in-flight part requests and closes the stream
+func (r *parallelReader) Close() error {
+	r.cancel()
+	return r.PipeReader.Close()
+}
+
+// downloadParallel fetches an object of the given size and ETag in ranged
+// parts and writes them to the returned stream in order. At most Concurrency
+// parts are fetched or buffered at any time.
+func (s *S3Storage) downloadParallel(ctx context.Context, path string, size int64, etag string) io.ReadCloser {
+	ctx, cancel := context.WithCancel(ctx)
+	pr, pw := io.Pipe()
+
+	partSize := s.parallel.PartSize
+	numParts := (size + partSize - 1) / partSize
+	results := make([]chan partResult, numParts)
+	for i := range results {
+		results[i] = make(chan partResult, 1)
+	}
+	slots := make(chan struct{}, s.parallel.Concurrency)
+
+	// Dispatch part requests as slots become available
+	go func() {
+		for i := int64(0); i < numParts; i++ {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			start := i * partSize
+			end := min(start+partSize, size) - 1
+			go func(result chan<- partResult) {
+				data, err := s.fetchRange(ctx, path, etag, start, end)
+				result <- partResult{data: data, err: err}
+			}(results[i])
+		}
+	}()
+
+	// Write parts to the stream in order, releasing a slot for each one written
+	go func() {
+		defer cancel()
+		for _, result := range results {
+			var res partResult
+			select {
+			case res = <-result:
+			case <-ctx.Done():
+				pw.CloseWithError(ctx.Err())
+				return
+			}
+			if res.err != nil {
+				pw.CloseWithError(res.err)
+				return
+			}
+			if _, err := pw.Write(res.data); err != nil {
+				return
+			}
+			<-slots
+		}
+		pw.Close()
+	}()
+
+	return &parallelReader{PipeReader: pr, cancel: cancel}
+}
+
+// fetchRange downloads the inclusive byte range [start, end] of an object,
+// failing with ErrObjectChanged if its ETag is no longer etag and with
+// ErrPartLength if the response doesn't hold exactly the range
+func (s *S3Storage) fetchRange(ctx context.Context, path, etag string, start, end int64) ([]byte, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(path),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+	}
+	if etag != "" {
+		input.IfMatch = aws.String(`"` + etag + `"`)
+	}
+	result, err := s.client.GetObject(ctx, input)
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "PreconditionFailed" {
+			return nil, fmt.Errorf("%w: %s", ErrObjectChanged, path)
+		}
+		return nil, translateError(err)
+	}
+	defer result.Body.Close()
+
+	// Read one byte past the range to notice responses that are too long
+	want := end - start + 1
+	data, err := io.ReadAll(io.LimitReader(result.Body, want+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) != want {
+		return nil, fmt.Errorf("%w: %s bytes %d-%d returned %d bytes", ErrPartLength, path, start, end, len(data))
+	}
+	return data, nil
+}
@@ -0,0 +1,121 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakeObjectServer serves a single object, switching to a new version after
+// the given number of ranged GETs (never if switchAfter is 0). The GET
+// numbered shortGet, counting from 1, returns a byte less than its range.
+type fakeObjectServer struct {
+	data        []byte
+	switchAfter int32
+	shortGet    int32
+	gets        atomic.Int32
+}
+
+func (f *fakeObjectServer) etag() string {
+	if f.switchAfter > 0 && f.gets.Load() >= f.switchAfter {
+		return `"v2"`
+	}
+	return `"v1"`
+}
+
+func (f *fakeObjectServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodHead {
+		w.Header().Set("ETag", f.etag())
+		w.Header().Set("Content-Length", strconv.Itoa(len(f.data)))
+		return
+	}
+
+	etag := f.etag()
+	get := f.gets.Add(1)
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && ifMatch != etag {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>PreconditionFailed</Code><Message>At least one of the pre-conditions you specified did not hold</Message></Error>`)
+		return
+	}
+
+	var start, end int
+	if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end); err != nil {
+		start, end = 0, len(f.data)-1
+	}
+	if get == f.shortGet {
+		end--
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Length", strconv.Itoa(end-start+1))
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(f.data)))
+	w.WriteHeader(http.StatusPartialContent)
+	w.Write(f.data[start : end+1])
+}
+
+func newTestStorage(t *testing.T, handler http.Handler) *S3Storage {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials:  aws.AnonymousCredentials{},
+	})
+	storage := NewS3Storage(client, "bucket", "us-east-1")
+	storage.EnableParallelDownload(ParallelDownloadConfig{Concurrency: 2, PartSize: 10})
+	return storage
+}
+
+func TestDownloadParallel(t *testing.T) {
+	data := []byte(strings.Repeat("0123456789abcdef", 8))
+
+	tests := []struct {
+		name        string
+		switchAfter int32
+		shortGet    int32
+		wantErr     error
+	}{
+		{name: "unchanged object", switchAfter: 0},
+		{name: "object overwritten mid-download", switchAfter: 3, wantErr: ErrObjectChanged},
+		{name: "short ranged part", shortGet: 2, wantErr: ErrPartLength},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := newTestStorage(t, &fakeObjectServer{data: data, switchAfter: tt.switchAfter, shortGet: tt.shortGet})
+
+			reader, err := storage.Download(context.Background(), "key")
+			if err != nil {
+				t.Fatalf("Download() error = %v", err)
+			}
+			defer reader.Close()
+
+			got, err := io.ReadAll(reader)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("reading download error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("reading download error = %v", err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("downloaded %q, want %q", got, data)
+			}
+		})
+	}
+}
@@ -1,19 +1,33 @@
 package s3
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/livefire2015/simple-contents/storage"
 )
 
+// multipartPartSize is the chunk size used when streaming data of unknown length
+const multipartPartSize = 8 << 20
+
 // S3Storage implements StorageService using AWS S3
 type S3Storage struct {
 	client     *s3.Client
 	bucketName string
 	region     string
+	parallel   ParallelDownloadConfig
 }
 
 // NewS3Storage creates a new S3 storage service
@@ -25,47 +39,274 @@ func NewS3Storage(client *s3.Client, bucketName, region string) *S3Storage {
 	}
 }
 
-// Upload saves content data to storage and returns the path
+// translateError wraps the errors S3 reports for missing objects and refused
+// access with storage.ErrNotFound and storage.ErrAccessDenied
+func translateError(err error) error {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "NoSuchKey", "NotFound":
+			return fmt.Errorf("%w: %w", storage.ErrNotFound, err)
+		case "AccessDenied", "Forbidden":
+			return fmt.Errorf("%w: %w", storage.ErrAccessDenied, err)
+		}
+	}
+	var respErr *smithyhttp.ResponseError
+	if errors.As(err, &respErr) {
+		switch respErr.HTTPStatusCode() {
+		case http.StatusNotFound:
+			return fmt.Errorf("%w: %w", storage.ErrNotFound, err)
+		case http.StatusForbidden:
+			return fmt.Errorf("%w: %w", storage.ErrAccessDenied, err)
+		}
+	}
+	return err
+}
+
+// EnableParallelDownload makes Download fetch objects larger than one part
+// as concurrent ranged requests
+func (s *S3Storage) EnableParallelDownload(config ParallelDownloadConfig) {
+	s.parallel = config
+}
+
+// Upload saves content data to storage and returns the path.
+// Data of unknown size is streamed to S3 as a multipart upload.
 func (s *S3Storage) Upload(ctx context.Context, key string, data io.Reader, size int64, contentType string) (string, error) {
+	if size == storage.UnknownSize {
+		if err := s.uploadMultipart(ctx, key, data, contentType); err != nil {
+			return "", err
+		}
+		return key, nil
+	}
+
 	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucketName),
+		Key:           aws.String(key),
+		Body:          data,
+		ContentLength: aws.Int64(size),
+		ContentType:   aws.String(contentType),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// uploadMultipart reads data in fixed-size chunks and uploads each chunk as a part
+func (s *S3Storage) uploadMultipart(ctx context.Context, key string, data io.Reader, contentType string) error {
+	created, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
 		Bucket:      aws.String(s.bucketName),
 		Key:         aws.String(key),
-		Body:        data,
 		ContentType: aws.String(contentType),
 	})
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	return key, nil
+	abort := func(cause error) error {
+		// Abort so that S3 does not keep the orphaned parts around
+		_, _ = s.client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(s.bucketName),
+			Key:      aws.String(key),
+			UploadId: created.UploadId,
+		})
+		return cause
+	}
+
+	var parts []types.CompletedPart
+	buf := make([]byte, multipartPartSize)
+	for partNumber := int32(1); ; partNumber++ {
+		n, readErr := io.ReadFull(data, buf)
+		if readErr != nil && !errors.Is(readErr, io.EOF) && !errors.Is(readErr, io.ErrUnexpectedEOF) {
+			return abort(readErr)
+		}
+
+		// An empty object still needs a single (empty) part
+		if n > 0 || partNumber == 1 {
+			part, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+				Bucket:        aws.String(s.bucketName),
+				Key:           aws.String(key),
+				UploadId:      created.UploadId,
+				PartNumber:    aws.Int32(partNumber),
+				Body:          bytes.NewReader(buf[:n]),
+				ContentLength: aws.Int64(int64(n)),
+			})
+			if err != nil {
+				return abort(err)
+			}
+			parts = append(parts, types.CompletedPart{
+				ETag:       part.ETag,
+				PartNumber: aws.Int32(partNumber),
+			})
+		}
+
+		if readErr != nil {
+			break
+		}
+	}
+
+	_, err = s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucketName),
+		Key:             aws.String(key),
+		UploadId:        created.UploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		return abort(err)
+	}
+
+	return nil
 }
 
-// Download gets content data from storage
-func (s *S3Storage) Downloa(ctx context.Context, path string) (io.ReadCloser, error) {
+// Download gets content data from storage.
+// Large objects are fetched in parallel ranged parts when enabled.
+func (s *S3Storage) Download(ctx context.Context, path string) (io.ReadCloser, error) {
+	if s.parallel.enabled() {
+		info, err := s.Stat(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		if info.Size > s.parallel.PartSize {
+			return s.downloadParallel(ctx, path, info.Size, info.ETag), nil
+		}
+	}
+
 	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(s.bucketName),
 		Key:    aws.String(path),
 	})
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 
 	return result.Body, nil
 }
 
+// Stat returns information about a stored object
+func (s *S3Storage) Stat(ctx context.Context, path string) (*storage.ObjectInfo, error) {
+	result, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(s.bucketName),
+		Key:          aws.String(path),
+		ChecksumMode: types.ChecksumModeEnabled,
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &storage.ObjectInfo{
+		Size:         aws.ToInt64(result.ContentLength),
+		ContentType:  aws.ToString(result.ContentType),
+		ETag:         strings.Trim(aws.ToString(result.ETag), `"`),
+		LastModified: aws.ToTime(result.LastModified),
+
+		ChecksumSHA256: aws.ToString(result.ChecksumSHA256),
+	}, nil
+}
+
 // Delete removes content data from storage
 func (s *S3Storage) Delete(ctx context.Context, path string) error {
 	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
 		Bucket: aws.String(s.bucketName),
 		Key:    aws.String(path),
 	})
-	return err
+	return translateError(err)
+}
+
+// CopyToBucket copies an object to another bucket with a server-side copy,
+// which S3 limits to objects of at most 5 GB
+func (s *S3Storage) CopyToBucket(ctx context.Context, path, bucket, key string) error {
+	_, err := s.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String(bucket),
+		Key:        aws.String(key),
+		CopySource: aws.String(url.PathEscape(s.bucketName + "/" + path)),
+	})
+	return translateError(err)
+}
+
+// UploadToBucket writes an object to another bucket
+func (s *S3Storage) UploadToBucket(ctx context.Context, bucket, key string, data io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(bucket),
+		Key:           aws.String(key),
+		Body:          data,
+		ContentLength: aws.Int64(size),
+		ContentType:   aws.String(contentType),
+	})
+	return translateError(err)
+}
+
+// PresignPut generates a presigned PUT of an object of the given type and size
+func (s *S3Storage) PresignPut(ctx context.Context, key string, options storage.PresignedUploadOptions) (*storage.PresignedUpload, error) {
+	presignClient := s3.NewPresignClient(s.client)
+
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucketName),
+		Key:           aws.String(key),
+		ContentType:   aws.String(options.ContentType),
+		ContentLength: aws.Int64(options.Size),
+	}
+	if options.ChecksumSHA256 != "" {
+		input.ChecksumSHA256 = aws.String(options.ChecksumSHA256)
+	}
+	request, err := presignClient.PresignPutObject(ctx, input, func(opts *s3.PresignOptions) {
+		opts.Expires = options.Expiry
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string)
+	for name := range request.SignedHeader {
+		if name != "Host" {
+			headers[name] = request.SignedHeader.Get(name)
+		}
+	}
+	return &storage.PresignedUpload{
+		Method:    request.Method,
+		URL:       request.URL,
+		Headers:   headers,
+		ExpiresAt: time.Now().Add(options.Expiry),
+	}, nil
 }
 
-// // GetPresignedUploadURL generates a presigned URL for uploading content
-// func (s *S3Storage) GetPresignedUploadURL(ctx context.Context, contentID string, fileName string, mimeType string, options storage.PresignedURLOptions) (url *url.URL, additionalHeaders map[string]string, err error) {
-// 	return request.URL, nil
-// }
+// PresignPost generates a POST policy for a browser form uploading an object
+// of the given type and at most the given size
+func (s *S3Storage) PresignPost(ctx context.Context, key string, options storage.PresignedUploadOptions) (*storage.PresignedUpload, error) {
+	presignClient := s3.NewPresignClient(s.client)
+
+	request, err := presignClient.PresignPostObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	}, func(opts *s3.PresignPostOptions) {
+		opts.Expires = options.Expiry
+		opts.Conditions = []interface{}{
+			[]interface{}{"content-length-range", 1, options.Size},
+			map[string]string{"Content-Type": options.ContentType},
+		}
+		if options.ChecksumSHA256 != "" {
+			opts.Conditions = append(opts.Conditions,
+				map[string]string{"x-amz-checksum-algorithm": "SHA256"},
+				map[string]string{"x-amz-checksum-sha256": options.ChecksumSHA256})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The policy is only satisfied if the form sends the values it names
+	request.Values["Content-Type"] = options.ContentType
+	if options.ChecksumSHA256 != "" {
+		request.Values["x-amz-checksum-algorithm"] = "SHA256"
+		request.Values["x-amz-checksum-sha256"] = options.ChecksumSHA256
+	}
+	return &storage.PresignedUpload{
+		Method:    http.MethodPost,
+		URL:       request.URL,
+		Fields:    request.Values,
+		ExpiresAt: time.Now().Add(options.Expiry),
+	}, nil
+}
 
 func (s *S3Storage) GetPresignedDownloadURL(ctx context.Context, storagePath string, options storage.PresignedURLOptions) (url string, err error) {
 	presignClient := s3.NewPresignClient(s.client)
@@ -0,0 +1,241 @@
+package tenantstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/livefire2015/simple-contents/storage"
+)
+
+var (
+	// ErrUnknownBackend is returned when a tenant is placed on a backend that is not registered
+	ErrUnknownBackend = errors.New("unknown storage backend")
+	// ErrUnknownCDN is returned when a tenant's downloads go through a CDN that is not registered
+	ErrUnknownCDN = errors.New("unknown CDN")
+)
+
+// Placement describes where the data of a tenant is stored
+type Placement struct {
+	Backend string // Name of a registered backend, the default backend if empty
+	Prefix  string // Key prefix prepended to every object of the tenant
+	CDN     string // Name of a registered CDN serving download URLs, presigned storage URLs if empty
+}
+
+// Resolver looks up the placement of a tenant at runtime
+type Resolver interface {
+	ResolvePlacement(ctx context.Context, tenantID string) (Placement, error)
+}
+
+// ResolverFunc adapts a function to the Resolver interface
+type ResolverFunc func(ctx context.Context, tenantID string) (Placement, error)
+
+// ResolvePlacement calls f
+func (f ResolverFunc) ResolvePlacement(ctx context.Context, tenantID string) (Placement, error) {
+	return f(ctx, tenantID)
+}
+
+// TenantStorage routes storage operations to the bucket and key prefix of the
+// tenant found in the context (see storage.WithTenant). Operations without a
+// tenant go to the default backend unprefixed.
+type TenantStorage struct {
+	defaultBackend storage.StorageService
+	resolver       Resolver
+
+	mu       sync.RWMutex
+	backends map[string]storage.StorageService
+	cdns     map[string]storage.URLSigner
+}
+
+// NewTenantStorage creates a tenant-aware storage service
+func NewTenantStorage(defaultBackend storage.StorageService, resolver Resolver) *TenantStorage {
+	return &TenantStorage{
+		defaultBackend: defaultBackend,
+		resolver:       resolver,
+		backends:       make(map[string]storage.StorageService),
+		cdns:           make(map[string]storage.URLSigner),
+	}
+}
+
+// RegisterBackend makes a backend, typically a dedicated bucket, available to tenants by name
+func (t *TenantStorage) RegisterBackend(name string, backend storage.StorageService) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.backends[name] = backend
+}
+
+// RegisterCDN makes a CDN in front of the tenants' buckets available to tenants by name
+func (t *TenantStorage) RegisterCDN(name string, signer storage.URLSigner) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cdns[name] = signer
+}
+
+// Backends returns the names of the registered backends
+func (t *TenantStorage) Backends() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	names := make([]string, 0, len(t.backends))
+	for name := range t.backends {
+		names = append(names, name)
+	}
+	return names
+}
+
+// resolve returns the backend and key prefix of the tenant in ctx
+func (t *TenantStorage) resolve(ctx context.Context) (storage.StorageService, string, error) {
+	backend, placement, err := t.resolvePlacement(ctx)
+	return backend, placement.Prefix, err
+}
+
+// resolvePlacement returns the backend and placement of the tenant in ctx,
+// with the default key prefix filled in
+func (t *TenantStorage) resolvePlacement(ctx context.Context) (storage.StorageService, Placement, error) {
+	tenantID := storage.TenantFromContext(ctx)
+	if tenantID == "" {
+		return t.defaultBackend, Placement{}, nil
+	}
+
+	placement, err := t.resolver.ResolvePlacement(ctx, tenantID)
+	if err != nil {
+		return nil, Placement{}, fmt.Errorf("failed to resolve storage for tenant %s: %w", tenantID, err)
+	}
+
+	backend := t.defaultBackend
+	if placement.Backend != "" {
+		t.mu.RLock()
+		named, ok := t.backends[placement.Backend]
+		t.mu.RUnlock()
+		if !ok {
+			return nil, Placement{}, fmt.Errorf("%w: %s", ErrUnknownBackend, placement.Backend)
+		}
+		backend = named
+	}
+
+	// Without an explicit prefix every tenant still gets its own key space
+	if placement.Prefix == "" {
+		placement.Prefix = tenantID + "/"
+	}
+
+	return backend, placement, nil
+}
+
+// Upload saves data under the tenant's prefix and returns the unprefixed path
+func (t *TenantStorage) Upload(ctx context.Context, key string, data io.Reader, size int64, contentType string) (string, error) {
+	backend, prefix, err := t.resolve(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	path, err := backend.Upload(ctx, prefix+key, data, size, contentType)
+	if err != nil {
+		return "", err
+	}
+
+	// Paths are stored without the prefix so that a tenant can be moved by
+	// changing its placement and copying its objects
+	return strings.TrimPrefix(path, prefix), nil
+}
+
+// Download gets data from the tenant's backend
+func (t *TenantStorage) Download(ctx context.Context, path string) (io.ReadCloser, error) {
+	backend, prefix, err := t.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return backend.Download(ctx, prefix+path)
+}
+
+// Stat returns information about an object of the tenant
+func (t *TenantStorage) Stat(ctx context.Context, path string) (*storage.ObjectInfo, error) {
+	backend, prefix, err := t.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return backend.Stat(ctx, prefix+path)
+}
+
+// GetPresignedDownloadURL generates a signed URL on the tenant's CDN, or a
+// presigned URL on the tenant's backend if the tenant has no CDN
+func (t *TenantStorage) GetPresignedDownloadURL(ctx context.Context, path string, options storage.PresignedURLOptions) (string, error) {
+	backend, placement, err := t.resolvePlacement(ctx)
+	if err != nil {
+		return "", err
+	}
+	if placement.CDN == "" {
+		return backend.GetPresignedDownloadURL(ctx, placement.Prefix+path, options)
+	}
+
+	t.mu.RLock()
+	signer, ok := t.cdns[placement.CDN]
+	t.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownCDN, placement.CDN)
+	}
+	return signer.SignURL(placement.Prefix+path, options.Expiry)
+}
+
+// PresignPut presigns a PUT of an object under the tenant's prefix
+func (t *TenantStorage) PresignPut(ctx context.Context, key string, options storage.PresignedUploadOptions) (*storage.PresignedUpload, error) {
+	backend, prefix, err := t.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	uploader, ok := backend.(storage.PresignedUploader)
+	if !ok {
+		return nil, storage.ErrDirectUploadUnsupported
+	}
+	return uploader.PresignPut(ctx, prefix+key, options)
+}
+
+// PresignPost presigns a POST policy for an object under the tenant's prefix
+func (t *TenantStorage) PresignPost(ctx context.Context, key string, options storage.PresignedUploadOptions) (*storage.PresignedUpload, error) {
+	backend, prefix, err := t.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	uploader, ok := backend.(storage.PresignedUploader)
+	if !ok {
+		return nil, storage.ErrDirectUploadUnsupported
+	}
+	return uploader.PresignPost(ctx, prefix+key, options)
+}
+
+// CopyToBucket copies an object of the tenant to another bucket of the tenant's backend
+func (t *TenantStorage) CopyToBucket(ctx context.Context, path, bucket, key string) error {
+	backend, prefix, err := t.resolve(ctx)
+	if err != nil {
+		return err
+	}
+	exporter, ok := backend.(storage.BucketExporter)
+	if !ok {
+		return storage.ErrBucketExportUnsupported
+	}
+	return exporter.CopyToBucket(ctx, prefix+path, bucket, key)
+}
+
+// UploadToBucket writes an object to another bucket of the tenant's backend
+func (t *TenantStorage) UploadToBucket(ctx context.Context, bucket, key string, data io.Reader, size int64, contentType string) error {
+	backend, _, err := t.resolve(ctx)
+	if err != nil {
+		return err
+	}
+	exporter, ok := backend.(storage.BucketExporter)
+	if !ok {
+		return storage.ErrBucketExportUnsupported
+	}
+	return exporter.UploadToBucket(ctx, bucket, key, data, size, contentType)
+}
+
+// Delete removes an object of the tenant
+func (t *TenantStorage) Delete(ctx context.Context, path string) error {
+	backend, prefix, err := t.resolve(ctx)
+	if err != nil {
+		return err
+	}
+	return backend.Delete(ctx, prefix+path)
+}
@@ -0,0 +1,135 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"connectrpc.com/connect"
+	contentsv1 "github.com/livefire2015/simple-contents/gen/contents/v1"
+	"github.com/livefire2015/simple-contents/gen/contents/v1/contentsv1connect"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ConnectConfig controls which browser pages may call the Connect API
+type ConnectConfig struct {
+	// AllowedOrigins are the origins, e.g. https://app.example.com, whose
+	// pages may call the API besides the service itself; "*" allows any origin
+	AllowedOrigins []string
+}
+
+// Connect returns the path and handler serving the API over the Connect
+// protocol, and gRPC-Web, so browser clients can call it, including the
+// streaming RPCs, without a proxy. Requests with a gRPC content type are
+// served by the gRPC server instead, see Handler.
+func (s *ContentServer) Connect(config ConnectConfig) (string, http.Handler) {
+	path, handler := contentsv1connect.NewContentServiceHandler(connectServer{server: s})
+	return path, config.cors(handler)
+}
+
+// cors answers preflight requests and allows the configured origins to read
+// responses, including the headers and trailers Connect and gRPC-Web use
+func (c ConnectConfig) cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !c.allowed(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Add("Vary", "Origin")
+		w.Header().Set("Access-Control-Expose-Headers", "Grpc-Status, Grpc-Message, Grpc-Status-Details-Bin")
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Connect-Protocol-Version, Connect-Timeout-Ms, Grpc-Timeout, X-Grpc-Web, X-User-Agent, X-Tenant-ID")
+			w.Header().Set("Access-Control-Max-Age", "7200")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowed reports whether pages of origin may call the API
+func (c ConnectConfig) allowed(origin string) bool {
+	return slices.Contains(c.AllowedOrigins, "*") ||
+		slices.ContainsFunc(c.AllowedOrigins, func(allowed string) bool { return strings.EqualFold(allowed, origin) })
+}
+
+// connectServer adapts the gRPC server to the Connect handler interface. The
+// tenant header is passed on as incoming metadata, as it is for gRPC calls.
+type connectServer struct {
+	server *ContentServer
+}
+
+// callContext returns ctx carrying the request headers as incoming metadata
+func callContext(ctx context.Context, header http.Header) context.Context {
+	md := metadata.MD{}
+	if tenant := header.Get("X-Tenant-ID"); tenant != "" {
+		md.Set(tenantMetadataKey, tenant)
+	}
+	return metadata.NewIncomingContext(ctx, md)
+}
+
+// connectError converts a gRPC status error to a Connect error; the codes
+// of both protocols are the same
+func connectError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if st, ok := status.FromError(err); ok {
+		return connect.NewError(connect.Code(st.Code()), errors.New(st.Message()))
+	}
+	return err
+}
+
+// unary calls a unary RPC of the gRPC server for a Connect request
+func unary[Req, Res any](ctx context.Context, req *connect.Request[Req], call func(context.Context, *Req) (*Res, error)) (*connect.Response[Res], error) {
+	res, err := call(callContext(ctx, req.Header()), req.Msg)
+	if err != nil {
+		return nil, connectError(err)
+	}
+	return connect.NewResponse(res), nil
+}
+
+func (c connectServer) CreateContent(ctx context.Context, req *connect.Request[contentsv1.CreateContentRequest]) (*connect.Response[contentsv1.Content], error) {
+	return unary(ctx, req, c.server.CreateContent)
+}
+
+func (c connectServer) GetContent(ctx context.Context, req *connect.Request[contentsv1.GetContentRequest]) (*connect.Response[contentsv1.Content], error) {
+	return unary(ctx, req, c.server.GetContent)
+}
+
+func (c connectServer) ListContents(ctx context.Context, req *connect.Request[contentsv1.ListContentsRequest]) (*connect.Response[contentsv1.ListContentsResponse], error) {
+	return unary(ctx, req, c.server.ListContents)
+}
+
+func (c connectServer) UpdateContent(ctx context.Context, req *connect.Request[contentsv1.UpdateContentRequest]) (*connect.Response[contentsv1.Content], error) {
+	return unary(ctx, req, c.server.UpdateContent)
+}
+
+func (c connectServer) DeleteContent(ctx context.Context, req *connect.Request[contentsv1.DeleteContentRequest]) (*connect.Response[contentsv1.DeleteContentResponse], error) {
+	return unary(ctx, req, c.server.DeleteContent)
+}
+
+func (c connectServer) AssociateContent(ctx context.Context, req *connect.Request[contentsv1.AssociateContentRequest]) (*connect.Response[contentsv1.Association], error) {
+	return unary(ctx, req, c.server.AssociateContent)
+}
+
+func (c connectServer) ListEntityContents(ctx context.Context, req *connect.Request[contentsv1.ListEntityContentsRequest]) (*connect.Response[contentsv1.ListEntityContentsResponse], error) {
+	return unary(ctx, req, c.server.ListEntityContents)
+}
+
+func (c connectServer) DownloadContent(ctx context.Context, req *connect.Request[contentsv1.DownloadContentRequest], stream *connect.ServerStream[contentsv1.DownloadContentResponse]) error {
+	return connectError(c.server.downloadContent(callContext(ctx, req.Header()), req.Msg, stream.Send))
+}
+
+func (c connectServer) WatchEntity(ctx context.Context, req *connect.Request[contentsv1.WatchEntityRequest], stream *connect.ServerStream[contentsv1.EntityEvent]) error {
+	// Sending no message flushes the response headers
+	opened := func() error { return stream.Send(nil) }
+	return connectError(c.server.watchEntity(callContext(ctx, req.Header()), req.Msg, opened, stream.Send))
+}
@@ -0,0 +1,152 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	contentsv1 "github.com/livefire2015/simple-contents/gen/contents/v1"
+	"github.com/livefire2015/simple-contents/gen/contents/v1/contentsv1connect"
+)
+
+func TestConnectStreamsEntityEvents(t *testing.T) {
+	server, _ := newTestServer(t)
+	client := contentsv1connect.NewContentServiceClient(http.DefaultClient, server.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.WatchEntity(ctx, connect.NewRequest(&contentsv1.WatchEntityRequest{EntityType: "order", EntityId: "42"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	// The server has subscribed once the call returns
+	created := make(chan string, 1)
+	go func() {
+		res, err := client.CreateContent(ctx, connect.NewRequest(&contentsv1.CreateContentRequest{
+			FileName:   "invoice.pdf",
+			MimeType:   "application/pdf",
+			Data:       []byte("%PDF-1.4"),
+			EntityType: "order",
+			EntityId:   "42",
+		}))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		created <- res.Msg.GetId()
+	}()
+
+	if !stream.Receive() {
+		t.Fatalf("no event received: %v", stream.Err())
+	}
+	event := stream.Msg()
+	if id := <-created; event.GetContentId() != id {
+		t.Errorf("event for content %s, want %s", event.GetContentId(), id)
+	}
+	if event.GetType() != "content_added" || event.GetEntityId() != "42" {
+		t.Errorf("event = %v", event)
+	}
+}
+
+func TestConnectWatchEntityRequiresEntity(t *testing.T) {
+	server, _ := newTestServer(t)
+	client := contentsv1connect.NewContentServiceClient(http.DefaultClient, server.URL)
+
+	stream, err := client.WatchEntity(context.Background(), connect.NewRequest(&contentsv1.WatchEntityRequest{EntityType: "order"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	if stream.Receive() {
+		t.Fatal("received an event")
+	}
+	if code := connect.CodeOf(stream.Err()); code != connect.CodeInvalidArgument {
+		t.Errorf("code = %s, want %s", code, connect.CodeInvalidArgument)
+	}
+}
+
+func TestDownloadContentStreamsChunks(t *testing.T) {
+	_, client := newTestServer(t)
+	ctx := context.Background()
+
+	// Larger than one chunk
+	data := bytes.Repeat([]byte("0123456789abcdef"), downloadChunkSize/8)
+	content, err := client.CreateContent(ctx, &contentsv1.CreateContentRequest{FileName: "big.bin", MimeType: "application/octet-stream", Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream, err := client.DownloadContent(ctx, &contentsv1.DownloadContentRequest{Id: content.GetId()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.GetContent().GetId() != content.GetId() {
+		t.Fatalf("first message = %v, want the content", first)
+	}
+
+	var got []byte
+	chunks := 0
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, msg.GetChunk()...)
+		chunks++
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("downloaded %d bytes, want %d", len(got), len(data))
+	}
+	if chunks < 2 {
+		t.Errorf("data sent in %d chunks, want several", chunks)
+	}
+}
+
+func TestConnectCORS(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	tests := []struct {
+		name       string
+		origin     string
+		wantStatus int
+		wantAllow  string
+	}{
+		{name: "allowed origin", origin: "https://app.example.com", wantStatus: http.StatusNoContent, wantAllow: "https://app.example.com"},
+		{name: "other origin", origin: "https://evil.example.com", wantAllow: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodOptions, server.URL+contentsv1connect.ContentServiceGetContentProcedure, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if got := resp.Header.Get("Access-Control-Allow-Origin"); got != tt.wantAllow {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantAllow)
+			}
+			if tt.wantStatus != 0 && resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package grpc
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+	contentsv1 "github.com/livefire2015/simple-contents/gen/contents/v1"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/service"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var statuses = map[model.ContentStatus]contentsv1.ContentStatus{
+	model.StatusCreated:  contentsv1.ContentStatus_CONTENT_STATUS_CREATED,
+	model.StatusUploaded: contentsv1.ContentStatus_CONTENT_STATUS_UPLOADED,
+	model.StatusDone:     contentsv1.ContentStatus_CONTENT_STATUS_DONE,
+	model.StatusError:    contentsv1.ContentStatus_CONTENT_STATUS_ERROR,
+}
+
+// fromStatus returns the model status of s, empty if unspecified
+func fromStatus(s contentsv1.ContentStatus) model.ContentStatus {
+	for status, value := range statuses {
+		if value == s {
+			return status
+		}
+	}
+	return ""
+}
+
+// toStruct converts metadata to a Struct through JSON, so values of any
+// type the JSON API accepts are kept
+func toStruct(m map[string]interface{}) *structpb.Struct {
+	if m == nil {
+		return nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil
+	}
+	var s structpb.Struct
+	if err := protojson.Unmarshal(data, &s); err != nil {
+		return nil
+	}
+	return &s
+}
+
+// fromStruct converts a Struct to metadata, nil if unset
+func fromStruct(s *structpb.Struct) map[string]interface{} {
+	if s == nil {
+		return nil
+	}
+	return s.AsMap()
+}
+
+// toContent converts a content item to its message
+func toContent(content *model.Content) *contentsv1.Content {
+	msg := &contentsv1.Content{
+		Id:          content.ID.String(),
+		TenantId:    content.TenantID,
+		Status:      statuses[content.Status],
+		FileName:    content.FileName,
+		MimeType:    content.MIMEType,
+		FileSize:    content.FileSize,
+		Etag:        content.ETag,
+		Derivation:  content.Derivation,
+		CreatedBy:   content.CreatedBy,
+		CreatedAt:   timestamppb.New(content.CreatedAt),
+		UpdatedAt:   timestamppb.New(content.UpdatedAt),
+		Source:      content.Source,
+		Metadata:    toStruct(content.Metadata),
+		CallbackUrl: content.CallbackURL,
+	}
+	if content.DerivedFromID != nil {
+		msg.DerivedFromId = content.DerivedFromID.String()
+	}
+	return msg
+}
+
+// toContents converts content items to messages
+func toContents(contents []*model.Content) []*contentsv1.Content {
+	messages := make([]*contentsv1.Content, len(contents))
+	for i, content := range contents {
+		messages[i] = toContent(content)
+	}
+	return messages
+}
+
+// toAssociation converts an association to its message
+func toAssociation(association *model.ContentEntityAssociation) *contentsv1.Association {
+	return &contentsv1.Association{
+		Id:                  association.ID.String(),
+		ContentId:           association.ContentID.String(),
+		EntityType:          association.EntityType,
+		EntityId:            association.EntityID,
+		AssociationMetadata: toStruct(association.AssociationMetadata),
+		CreatedBy:           association.CreatedBy,
+		CreatedAt:           timestamppb.New(association.CreatedAt),
+		UpdatedAt:           timestamppb.New(association.UpdatedAt),
+		Position:            int32(association.Position),
+	}
+}
+
+// toEntityEvent converts an entity event to its message
+func toEntityEvent(event service.Event) *contentsv1.EntityEvent {
+	msg := &contentsv1.EntityEvent{
+		Type:       string(event.Type),
+		Timestamp:  timestamppb.New(event.Timestamp),
+		EntityType: event.EntityType,
+		EntityId:   event.EntityID,
+	}
+	// Reorder events concern the entity, not one content item
+	if event.ContentID != uuid.Nil {
+		msg.ContentId = event.ContentID.String()
+	}
+	if event.AssociationID != nil {
+		msg.AssociationId = event.AssociationID.String()
+	}
+	if event.Content != nil {
+		msg.Content = toContent(event.Content)
+	}
+	for _, id := range event.Order {
+		msg.Order = append(msg.Order, id.String())
+	}
+	return msg
+}
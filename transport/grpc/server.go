@@ -0,0 +1,329 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	contentsv1 "github.com/livefire2015/simple-contents/gen/contents/v1"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+	"github.com/livefire2015/simple-contents/service"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// tenantMetadataKey carries the tenant a call acts for, like the X-Tenant-ID header
+	tenantMetadataKey = "x-tenant-id"
+	// downloadChunkSize is the size of the data messages DownloadContent sends
+	downloadChunkSize = 64 << 10
+)
+
+// ContentServer serves the contents.v1 API defined in proto/contents/v1 on
+// top of the content service
+type ContentServer struct {
+	contentsv1.UnimplementedContentServiceServer
+	contentService *service.ContentService
+}
+
+// NewContentServer creates a new gRPC content server
+func NewContentServer(contentService *service.ContentService) *ContentServer {
+	return &ContentServer{contentService: contentService}
+}
+
+// Register registers the server's services with a gRPC server
+func (s *ContentServer) Register(server *grpc.Server) {
+	contentsv1.RegisterContentServiceServer(server, s)
+}
+
+// Gateway returns the JSON API generated from the proto's HTTP annotations,
+// which calls the server in process
+func (s *ContentServer) Gateway(ctx context.Context) (http.Handler, error) {
+	mux := runtime.NewServeMux(runtime.WithIncomingHeaderMatcher(func(header string) (string, bool) {
+		if strings.EqualFold(header, tenantMetadataKey) {
+			return tenantMetadataKey, true
+		}
+		return runtime.DefaultHeaderMatcher(header)
+	}))
+	if err := contentsv1.RegisterContentServiceHandlerServer(ctx, mux, s); err != nil {
+		return nil, err
+	}
+	return mux, nil
+}
+
+// Handler serves gRPC requests with grpcServer and everything else with
+// httpHandler, over HTTP/1.1, HTTP/2 with TLS and cleartext HTTP/2 (h2c),
+// so both protocols can share one listener
+func Handler(grpcServer *grpc.Server, httpHandler http.Handler) http.Handler {
+	return h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		httpHandler.ServeHTTP(w, r)
+	}), &http2.Server{})
+}
+
+// callTenant returns the tenant a call acts for
+func callTenant(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(tenantMetadataKey); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// parseID parses a UUID request field
+func parseID(value, field string) (uuid.UUID, error) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, status.Errorf(codes.InvalidArgument, "invalid %s", field)
+	}
+	return id, nil
+}
+
+// errorStatus maps service errors to gRPC status codes. Unexpected errors
+// are reported with message instead of their details.
+func errorStatus(err error, message string) error {
+	switch {
+	case errors.Is(err, service.ErrContentNotFound), errors.Is(err, service.ErrAssociationNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidInput), errors.Is(err, service.ErrTenantNotFound):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, service.ErrAssociationExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, service.ErrContentQuarantined), errors.Is(err, service.ErrElevatedScopeRequired):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, service.ErrQuotaExceeded):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, service.ErrAttachmentLimit), errors.Is(err, service.ErrContentReferenced),
+		errors.Is(err, service.ErrContentRetained), errors.Is(err, service.ErrInvalidStatus),
+		errors.Is(err, service.ErrScanPending):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, message)
+	}
+}
+
+// CreateContent stores a new content item from inline data
+func (s *ContentServer) CreateContent(ctx context.Context, req *contentsv1.CreateContentRequest) (*contentsv1.Content, error) {
+	content, err := s.contentService.CreateContent(ctx, service.CreateContentInput{
+		TenantID:    callTenant(ctx),
+		FileName:    req.GetFileName(),
+		MIMEType:    req.GetMimeType(),
+		FileSize:    int64(len(req.GetData())),
+		Data:        bytes.NewReader(req.GetData()),
+		CreatedBy:   req.GetCreatedBy(),
+		EntityType:  req.GetEntityType(),
+		EntityID:    req.GetEntityId(),
+		Source:      req.GetSource(),
+		Metadata:    fromStruct(req.GetMetadata()),
+		CallbackURL: req.GetCallbackUrl(),
+	})
+	if err != nil {
+		return nil, errorStatus(err, "failed to create content")
+	}
+	return toContent(content), nil
+}
+
+// GetContent returns a content item's metadata
+func (s *ContentServer) GetContent(ctx context.Context, req *contentsv1.GetContentRequest) (*contentsv1.Content, error) {
+	id, err := parseID(req.GetId(), "content ID")
+	if err != nil {
+		return nil, err
+	}
+	content, err := s.contentService.GetContent(ctx, id)
+	if err != nil {
+		return nil, errorStatus(err, "failed to retrieve content")
+	}
+	return toContent(content), nil
+}
+
+// ListContents lists content matching the filters
+func (s *ContentServer) ListContents(ctx context.Context, req *contentsv1.ListContentsRequest) (*contentsv1.ListContentsResponse, error) {
+	result, err := s.contentService.ListContent(ctx, service.ListContentInput{
+		TenantID: callTenant(ctx),
+		Status:   fromStatus(req.GetStatus()),
+		MIMEType: req.GetMimeType(),
+		SortBy:   model.ContentSortField(req.GetSortBy()),
+		SortAsc:  req.GetSortAscending(),
+		Page:     int(req.GetPage()),
+		PageSize: int(req.GetPageSize()),
+	})
+	if err != nil {
+		return nil, errorStatus(err, "failed to list content")
+	}
+
+	return &contentsv1.ListContentsResponse{
+		Items:      toContents(result.Items),
+		TotalCount: int64(result.TotalCount),
+		Page:       int32(result.Page),
+		PageSize:   int32(result.PageSize),
+		TotalPages: int32(result.TotalPages),
+		HasMore:    result.HasMore,
+	}, nil
+}
+
+// UpdateContent renames a content item or replaces its metadata
+func (s *ContentServer) UpdateContent(ctx context.Context, req *contentsv1.UpdateContentRequest) (*contentsv1.Content, error) {
+	id, err := parseID(req.GetId(), "content ID")
+	if err != nil {
+		return nil, err
+	}
+	content, err := s.contentService.UpdateContent(ctx, service.UpdateContentInput{
+		ID:       id,
+		FileName: req.GetFileName(),
+		Metadata: fromStruct(req.GetMetadata()),
+	})
+	if err != nil {
+		return nil, errorStatus(err, "failed to update content")
+	}
+	return toContent(content), nil
+}
+
+// DeleteContent deletes a content item, or detaches it from an entity
+func (s *ContentServer) DeleteContent(ctx context.Context, req *contentsv1.DeleteContentRequest) (*contentsv1.DeleteContentResponse, error) {
+	id, err := parseID(req.GetId(), "content ID")
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.contentService.DeleteContent(ctx, id, service.DeleteContentOptions{
+		Policy:     service.DeletionPolicy(req.GetPolicy()),
+		EntityType: req.GetEntityType(),
+		EntityID:   req.GetEntityId(),
+	})
+	if err != nil {
+		return nil, errorStatus(err, "failed to delete content")
+	}
+
+	unlinked := make([]string, len(result.Unlinked))
+	for i, associationID := range result.Unlinked {
+		unlinked[i] = associationID.String()
+	}
+	return &contentsv1.DeleteContentResponse{Deleted: result.Deleted, Unlinked: unlinked}, nil
+}
+
+// AssociateContent links a content item to an entity
+func (s *ContentServer) AssociateContent(ctx context.Context, req *contentsv1.AssociateContentRequest) (*contentsv1.Association, error) {
+	id, err := parseID(req.GetContentId(), "content ID")
+	if err != nil {
+		return nil, err
+	}
+	association, err := s.contentService.AssociateContent(ctx, service.AssociateContentInput{
+		ContentID:           id,
+		EntityType:          req.GetEntityType(),
+		EntityID:            req.GetEntityId(),
+		AssociationMetadata: fromStruct(req.GetAssociationMetadata()),
+		AssociatedBy:        req.GetAssociatedBy(),
+		RequireReview:       req.GetRequireReview(),
+	})
+	if err != nil {
+		return nil, errorStatus(err, "failed to associate content")
+	}
+	return toAssociation(association), nil
+}
+
+// ListEntityContents lists the content linked to an entity
+func (s *ContentServer) ListEntityContents(ctx context.Context, req *contentsv1.ListEntityContentsRequest) (*contentsv1.ListEntityContentsResponse, error) {
+	options := repository.ListOptions{
+		Page:        int(req.GetPage()),
+		PageSize:    int(req.GetPageSize()),
+		ReturnTotal: true,
+	}
+	if options.Page <= 0 {
+		options.Page = 1
+	}
+	if options.PageSize <= 0 {
+		options.PageSize = 20
+	}
+
+	contents, total, err := s.contentService.GetContentForEntity(ctx, req.GetEntityType(), req.GetEntityId(), options)
+	if err != nil {
+		return nil, errorStatus(err, "failed to list entity content")
+	}
+	return &contentsv1.ListEntityContentsResponse{
+		Items:      toContents(contents),
+		TotalCount: total,
+		Page:       int32(options.Page),
+		PageSize:   int32(options.PageSize),
+	}, nil
+}
+
+// DownloadContent streams a content item's metadata, then its data in chunks
+func (s *ContentServer) DownloadContent(req *contentsv1.DownloadContentRequest, stream grpc.ServerStreamingServer[contentsv1.DownloadContentResponse]) error {
+	return s.downloadContent(stream.Context(), req, stream.Send)
+}
+
+// downloadContent sends the messages of a download with send, for gRPC and Connect streams
+func (s *ContentServer) downloadContent(ctx context.Context, req *contentsv1.DownloadContentRequest, send func(*contentsv1.DownloadContentResponse) error) error {
+	id, err := parseID(req.GetId(), "content ID")
+	if err != nil {
+		return err
+	}
+	data, content, err := s.contentService.GetContentData(ctx, id)
+	if err != nil {
+		return errorStatus(err, "failed to retrieve content data")
+	}
+	defer data.Close()
+
+	if err := send(&contentsv1.DownloadContentResponse{Part: &contentsv1.DownloadContentResponse_Content{Content: toContent(content)}}); err != nil {
+		return err
+	}
+	// Each message is marshalled by send, so the buffer can be reused
+	buf := make([]byte, downloadChunkSize)
+	for {
+		n, err := data.Read(buf)
+		if n > 0 {
+			if err := send(&contentsv1.DownloadContentResponse{Part: &contentsv1.DownloadContentResponse_Chunk{Chunk: buf[:n]}}); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return status.Error(codes.Internal, "failed to read content data")
+		}
+	}
+}
+
+// WatchEntity streams the content changes of an entity until the call ends
+func (s *ContentServer) WatchEntity(req *contentsv1.WatchEntityRequest, stream grpc.ServerStreamingServer[contentsv1.EntityEvent]) error {
+	opened := func() error { return stream.SendHeader(metadata.MD{}) }
+	return s.watchEntity(stream.Context(), req, opened, stream.Send)
+}
+
+// watchEntity sends the events of an entity with send until ctx is done,
+// for gRPC and Connect streams. Once subscribed, it calls opened to send the
+// response headers, so clients know no later event will be missed.
+func (s *ContentServer) watchEntity(ctx context.Context, req *contentsv1.WatchEntityRequest, opened func() error, send func(*contentsv1.EntityEvent) error) error {
+	if req.GetEntityType() == "" || req.GetEntityId() == "" {
+		return status.Error(codes.InvalidArgument, "entity type and ID are required")
+	}
+	events, unsubscribe := s.contentService.SubscribeEntity(req.GetEntityType(), req.GetEntityId())
+	defer unsubscribe()
+	if err := opened(); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-events:
+			if err := send(toEntityEvent(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
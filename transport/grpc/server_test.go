@@ -0,0 +1,149 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	contentsv1 "github.com/livefire2015/simple-contents/gen/contents/v1"
+	"github.com/livefire2015/simple-contents/repository/memory"
+	"github.com/livefire2015/simple-contents/service"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// newTestServer serves the gRPC API, its gateway and a plain route from one listener
+func newTestServer(t *testing.T) (*httptest.Server, contentsv1.ContentServiceClient) {
+	t.Helper()
+	contentService := service.NewContentService(memory.NewMemoryRepository(), memorystorage.NewMemoryStorage())
+	grpcServer := grpc.NewServer()
+	contentServer := NewContentServer(contentService)
+	contentServer.Register(grpcServer)
+	gateway, err := contentServer.Gateway(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := chi.NewRouter()
+	router.Get("/health", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) })
+	router.Handle("/api/v2/*", gateway)
+	connectPath, connectHandler := contentServer.Connect(ConnectConfig{AllowedOrigins: []string{"https://app.example.com"}})
+	router.Handle(connectPath+"*", connectHandler)
+	server := httptest.NewServer(Handler(grpcServer, router))
+	t.Cleanup(server.Close)
+
+	conn, err := grpc.NewClient(strings.TrimPrefix(server.URL, "http://"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return server, contentsv1.NewContentServiceClient(conn)
+}
+
+func TestGatewayAndGRPCShareListener(t *testing.T) {
+	server, client := newTestServer(t)
+	ctx := context.Background()
+
+	// Created over JSON with the tenant header...
+	body := `{"fileName": "notes.txt", "mimeType": "text/plain", "data": "aGVsbG8=", "metadata": {"project": "apollo"}}`
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/api/v2/contents", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Tenant-ID", "acme")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("create status = %d", resp.StatusCode)
+	}
+	var created struct {
+		ID       string `json:"id"`
+		FileSize string `json:"fileSize"`
+		Status   string `json:"status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	if created.FileSize != "5" {
+		t.Errorf("fileSize = %q, want 5", created.FileSize)
+	}
+
+	// ...is read back over gRPC
+	content, err := client.GetContent(ctx, &contentsv1.GetContentRequest{Id: created.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.GetTenantId() != "acme" {
+		t.Errorf("tenant = %q, want acme", content.GetTenantId())
+	}
+	if content.GetFileName() != "notes.txt" || content.GetMetadata().GetFields()["project"].GetStringValue() != "apollo" {
+		t.Errorf("GetContent = %v", content)
+	}
+	if content.GetStatus().String() != created.Status {
+		t.Errorf("status over gRPC = %s, over JSON = %s", content.GetStatus(), created.Status)
+	}
+
+	// Other routes are still served by the router
+	resp, err = http.Get(server.URL + "/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("health status = %d", resp.StatusCode)
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	_, client := newTestServer(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+		want codes.Code
+	}{
+		{
+			name: "malformed ID",
+			call: func() error {
+				_, err := client.GetContent(ctx, &contentsv1.GetContentRequest{Id: "nope"})
+				return err
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			name: "unknown content",
+			call: func() error {
+				_, err := client.GetContent(ctx, &contentsv1.GetContentRequest{Id: "6f1c1f2e-8a3b-4c55-9d0e-1b2c3d4e5f60"})
+				return err
+			},
+			want: codes.NotFound,
+		},
+		{
+			name: "missing entity",
+			call: func() error {
+				_, err := client.ListEntityContents(ctx, &contentsv1.ListEntityContentsRequest{EntityType: "order"})
+				return err
+			},
+			want: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := status.Code(tt.call()); got != tt.want {
+				t.Errorf("code = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,491 @@
+package http
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// AdminHandler handles HTTP requests for administrative operations.
+// Its routes are kept apart from the public API and require the admin scope.
+type AdminHandler struct {
+	contentService *service.ContentService
+	tenantService  *service.TenantService
+	tokens         map[string]string // Admin name to bearer token
+}
+
+// NewAdminHandler creates a new admin HTTP handler.
+// Requests must present token as a bearer token, and act as the admin named
+// "admin"; an empty token disables the admin API unless AddAdmin is used.
+func NewAdminHandler(contentService *service.ContentService, tenantService *service.TenantService, token string) *AdminHandler {
+	h := &AdminHandler{
+		contentService: contentService,
+		tenantService:  tenantService,
+		tokens:         make(map[string]string),
+	}
+	if token != "" {
+		h.tokens["admin"] = token
+	}
+	return h
+}
+
+// AddAdmin lets requests presenting token act as the named admin, so that
+// audited operations record who performed them
+func (h *AdminHandler) AddAdmin(name, token string) {
+	h.tokens[name] = token
+}
+
+// ParseAdminTokens parses admin tokens given as name=token pairs separated by commas
+func ParseAdminTokens(s string) (map[string]string, error) {
+	tokens := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		name, token, ok := strings.Cut(pair, "=")
+		if !ok || name == "" || token == "" {
+			return nil, fmt.Errorf("invalid admin token %q, expected name=token", pair)
+		}
+		tokens[name] = token
+	}
+	return tokens, nil
+}
+
+type adminIdentityKey struct{}
+
+// adminIdentity returns the name of the admin authenticated for the request
+func adminIdentity(r *http.Request) string {
+	name, _ := r.Context().Value(adminIdentityKey{}).(string)
+	return name
+}
+
+// RegisterRoutes registers HTTP routes for admin operations
+func (h *AdminHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/admin/v1", func(r chi.Router) {
+		r.Use(h.requireAdminScope)
+
+		r.Get("/export", h.ExportContents)
+		r.Post("/import", h.ImportContents)
+		r.Get("/backup", h.Backup)
+		r.Post("/restore", h.Restore)
+		r.Post("/reconcile", h.Reconcile)
+		r.Get("/storage/health", h.StorageHealth)
+		r.Get("/storage/key-layout", h.KeyLayout)
+		r.Post("/purge", h.Purge)
+		r.Post("/prune-deleted", h.PruneDeleted)
+		r.Post("/prune-audit", h.PruneAuditLog)
+		r.Post("/reindex", h.Reindex)
+		r.Post("/ownership/transfer", h.TransferOwnership)
+		r.Get("/jobs/{jobID}", h.GetJob)
+		r.Post("/jobs/{jobID}/cancel", h.CancelJob)
+		r.Get("/webhooks", h.GetWebhookConfig)
+		r.Put("/webhooks", h.UpdateWebhookConfig)
+
+		r.Route("/quarantine", func(r chi.Router) {
+			r.Get("/", h.ListQuarantined)
+			r.Get("/{id}/data", h.GetQuarantinedData)
+			r.Post("/{id}/release", h.ReleaseQuarantined)
+			r.Delete("/{id}", h.DestroyQuarantined)
+		})
+
+		r.Route("/tenants", func(r chi.Router) {
+			r.Post("/", h.CreateTenant)
+			r.Get("/", h.ListTenants)
+			r.Get("/{tenantID}", h.GetTenant)
+			r.Put("/{tenantID}", h.UpdateTenant)
+			r.Delete("/{tenantID}", h.DeleteTenant)
+			r.Post("/{tenantID}/usage/recalculate", h.RecalculateTenantUsage)
+			r.Get("/{tenantID}/events", h.TenantEvents)
+		})
+	})
+}
+
+// requireAdminScope rejects requests that do not carry the admin token
+func (h *AdminHandler) requireAdminScope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(h.tokens) == 0 {
+			errorResponse(w, http.StatusForbidden, "Admin API is disabled")
+			return
+		}
+
+		// Every token is compared so the time taken doesn't reveal which one matched
+		var admin string
+		for name, token := range h.tokens {
+			if bearerTokenMatches(r, token) {
+				admin = name
+			}
+		}
+		if admin == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			errorResponse(w, http.StatusUnauthorized, "Admin scope required")
+			return
+		}
+
+		// Admins see restricted content, e.g. in backups
+		ctx := context.WithValue(r.Context(), adminIdentityKey{}, admin)
+		next.ServeHTTP(w, r.WithContext(service.WithElevatedScope(ctx)))
+	})
+}
+
+// bearerTokenMatches reports whether the request carries token as its bearer token
+func bearerTokenMatches(r *http.Request, token string) bool {
+	presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1
+}
+
+// Reconcile handles checking metadata against stored data
+func (h *AdminHandler) Reconcile(w http.ResponseWriter, r *http.Request) {
+	options := service.ReconcileOptions{
+		Repair: r.URL.Query().Get("repair") == "true",
+	}
+
+	result, err := h.contentService.Reconcile(r.Context(), options)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to reconcile contents")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// StorageHealth handles reporting whether the service is degraded by a storage outage
+func (h *AdminHandler) StorageHealth(w http.ResponseWriter, r *http.Request) {
+	health := h.contentService.StorageHealth()
+	w.Header().Set("Content-Type", "application/json")
+	if health.Degraded {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(health)
+}
+
+// KeyLayout handles reporting how stored content spreads over storage key prefixes
+func (h *AdminHandler) KeyLayout(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var depth, limit int
+	var err error
+	if value := query.Get("depth"); value != "" {
+		if depth, err = strconv.Atoi(value); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid depth parameter")
+			return
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 {
+			errorResponse(w, http.StatusBadRequest, "Invalid limit parameter")
+			return
+		}
+	}
+
+	report, err := h.contentService.KeyLayout(r.Context(), query.Get("tenant_id"), depth, limit)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			invalidInputResponse(w, err)
+			return
+		}
+		errorResponse(w, http.StatusInternalServerError, "Failed to report the storage key layout")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// Purge handles permanently deleting content older than a cutoff
+func (h *AdminHandler) Purge(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	before, err := time.Parse(time.RFC3339, query.Get("before"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid before parameter")
+		return
+	}
+
+	options := service.PurgeOptions{
+		Status: model.ContentStatus(query.Get("status")),
+		Before: before,
+		DryRun: query.Get("dry_run") == "true",
+	}
+
+	result, err := h.contentService.Purge(r.Context(), options)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput), errors.Is(err, service.ErrInvalidStatus):
+			invalidInputResponse(w, err)
+		default:
+			errorResponse(w, http.StatusInternalServerError, "Failed to purge contents")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// PruneDeleted handles permanently removing the records of content deleted before a cutoff
+func (h *AdminHandler) PruneDeleted(w http.ResponseWriter, r *http.Request) {
+	before, err := time.Parse(time.RFC3339, r.URL.Query().Get("before"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid before parameter")
+		return
+	}
+
+	pruned, err := h.contentService.PruneDeleted(r.Context(), before)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			invalidInputResponse(w, err)
+		} else {
+			errorResponse(w, http.StatusInternalServerError, "Failed to prune deleted contents")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int64{"pruned": pruned})
+}
+
+// PruneAuditLog handles permanently removing the audit events that occurred before a cutoff
+func (h *AdminHandler) PruneAuditLog(w http.ResponseWriter, r *http.Request) {
+	before, err := time.Parse(time.RFC3339, r.URL.Query().Get("before"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid before parameter")
+		return
+	}
+
+	pruned, err := h.contentService.PruneAuditLog(r.Context(), before)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			invalidInputResponse(w, err)
+		} else {
+			errorResponse(w, http.StatusInternalServerError, "Failed to prune the audit log")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int64{"pruned": pruned})
+}
+
+// Reindex handles rebuilding the search indexes. The progress of each index
+// is streamed as a line of NDJSON, followed by the summary, or by an error
+// if a rebuild fails after the response started. With async=true the
+// indexes are rebuilt in a job instead.
+func (h *AdminHandler) Reindex(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	options := service.ReindexOptions{Indexes: query["index"]}
+	if pause := query.Get("pause"); pause != "" {
+		d, err := time.ParseDuration(pause)
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid pause parameter")
+			return
+		}
+		options.Pause = d
+	}
+
+	if query.Get("async") == "true" {
+		job, err := h.contentService.StartReindex(r.Context(), options)
+		switch {
+		case err == nil:
+			jobAcceptedResponse(w, "/admin/v1/jobs/", job)
+		case errors.Is(err, service.ErrInvalidInput):
+			invalidInputResponse(w, err)
+		case errors.Is(err, service.ErrReindexDisabled), errors.Is(err, service.ErrJobsDisabled):
+			errorResponse(w, http.StatusNotImplemented, err.Error())
+		default:
+			log.Printf("Error starting a search index rebuild: %v", err)
+			errorResponse(w, http.StatusInternalServerError, "Failed to start job")
+		}
+		return
+	}
+
+	encoder := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	started := false
+	result, err := h.contentService.Reindex(r.Context(), options, func(progress service.ReindexProgress) {
+		if !started {
+			started = true
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+		}
+		encoder.Encode(progress)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	})
+
+	switch {
+	case err == nil:
+		if !started {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+		}
+		encoder.Encode(result)
+	case started:
+		log.Printf("Error rebuilding search indexes: %v", err)
+		encoder.Encode(map[string]string{"error": "Failed to rebuild search indexes"})
+	case errors.Is(err, service.ErrInvalidInput):
+		invalidInputResponse(w, err)
+	case errors.Is(err, service.ErrReindexDisabled):
+		errorResponse(w, http.StatusNotImplemented, err.Error())
+	default:
+		log.Printf("Error rebuilding search indexes: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to rebuild search indexes")
+	}
+}
+
+// webhookConfigResponse is the admin view of the webhook settings; the secret is never returned
+type webhookConfigResponse struct {
+	Signed         bool   `json:"signed"`
+	MaxAttempts    int    `json:"max_attempts"`
+	InitialBackoff string `json:"initial_backoff"`
+	Timeout        string `json:"timeout"`
+}
+
+// webhookConfigRequest updates the webhook settings, omitted fields are left unchanged
+type webhookConfigRequest struct {
+	Secret         *string `json:"secret"`
+	MaxAttempts    *int    `json:"max_attempts"`
+	InitialBackoff *string `json:"initial_backoff"`
+	Timeout        *string `json:"timeout"`
+}
+
+func newWebhookConfigResponse(config service.WebhookConfig) webhookConfigResponse {
+	return webhookConfigResponse{
+		Signed:         len(config.Secret) > 0,
+		MaxAttempts:    config.MaxAttempts,
+		InitialBackoff: config.InitialBackoff.String(),
+		Timeout:        config.Timeout.String(),
+	}
+}
+
+// GetWebhookConfig handles reading the callback delivery settings
+func (h *AdminHandler) GetWebhookConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newWebhookConfigResponse(h.contentService.WebhookConfig()))
+}
+
+// UpdateWebhookConfig handles changing the callback delivery settings
+func (h *AdminHandler) UpdateWebhookConfig(w http.ResponseWriter, r *http.Request) {
+	var req webhookConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	config := h.contentService.WebhookConfig()
+	if req.Secret != nil {
+		config.Secret = []byte(*req.Secret)
+	}
+	if req.MaxAttempts != nil {
+		if *req.MaxAttempts < 1 {
+			errorResponse(w, http.StatusBadRequest, "max_attempts must be at least 1")
+			return
+		}
+		config.MaxAttempts = *req.MaxAttempts
+	}
+	if req.InitialBackoff != nil {
+		backoff, err := time.ParseDuration(*req.InitialBackoff)
+		if err != nil || backoff < 0 {
+			errorResponse(w, http.StatusBadRequest, "Invalid initial_backoff")
+			return
+		}
+		config.InitialBackoff = backoff
+	}
+	if req.Timeout != nil {
+		timeout, err := time.ParseDuration(*req.Timeout)
+		if err != nil || timeout <= 0 {
+			errorResponse(w, http.StatusBadRequest, "Invalid timeout")
+			return
+		}
+		config.Timeout = timeout
+	}
+
+	h.contentService.ConfigureWebhooks(config)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newWebhookConfigResponse(config))
+}
+
+// ExportContents handles exporting all content metadata as NDJSON
+func (h *AdminHandler) ExportContents(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", "attachment; filename=contents.ndjson")
+
+	if _, err := h.contentService.ExportContents(r.Context(), w); err != nil {
+		// Headers have already been sent, the truncated body signals the failure
+		log.Printf("Error exporting contents: %v", err)
+	}
+}
+
+// ImportContents handles importing content metadata from an NDJSON body
+func (h *AdminHandler) ImportContents(w http.ResponseWriter, r *http.Request) {
+	policy := service.ConflictPolicy(r.URL.Query().Get("conflict"))
+
+	result, err := h.contentService.ImportContents(r.Context(), r.Body, policy)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput):
+			invalidInputResponse(w, err)
+		case errors.Is(err, service.ErrImportConflict):
+			errorResponse(w, http.StatusConflict, err.Error())
+		default:
+			errorResponse(w, http.StatusInternalServerError, "Failed to import contents")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// Backup handles streaming a backup archive of metadata and data
+func (h *AdminHandler) Backup(w http.ResponseWriter, r *http.Request) {
+	var options service.BackupOptions
+	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+		since, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid since parameter")
+			return
+		}
+		options.Since = &since
+	}
+
+	w.Header().Set("Content-Type", "application/zstd")
+	w.Header().Set("Content-Disposition", "attachment; filename=contents-backup.tar.zst")
+
+	if _, err := h.contentService.Backup(r.Context(), w, options); err != nil {
+		// Headers have already been sent, the truncated archive signals the failure
+		log.Printf("Error writing backup: %v", err)
+	}
+}
+
+// Restore handles restoring metadata and data from a backup archive
+func (h *AdminHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	policy := service.ConflictPolicy(r.URL.Query().Get("conflict"))
+
+	result, err := h.contentService.Restore(r.Context(), r.Body, policy)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput):
+			invalidInputResponse(w, err)
+		case errors.Is(err, service.ErrImportConflict):
+			errorResponse(w, http.StatusConflict, err.Error())
+		default:
+			errorResponse(w, http.StatusInternalServerError, "Failed to restore backup")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
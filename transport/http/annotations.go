@@ -0,0 +1,148 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// annotationErrorResponse maps annotation service errors to HTTP responses
+func annotationErrorResponse(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		invalidInputResponse(w, err)
+	case errors.Is(err, service.ErrContentNotFound):
+		errorResponse(w, http.StatusNotFound, "Content not found")
+	case errors.Is(err, service.ErrAnnotationNotFound):
+		errorResponse(w, http.StatusNotFound, "Annotation not found")
+	default:
+		errorResponse(w, http.StatusInternalServerError, message)
+	}
+}
+
+// annotationIDs parses the content and annotation IDs of a request
+func annotationIDs(w http.ResponseWriter, r *http.Request) (contentID, annotationID uuid.UUID, ok bool) {
+	contentID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	annotationID, err = uuid.Parse(chi.URLParam(r, "annotationID"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid annotation ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return contentID, annotationID, true
+}
+
+// CreateAnnotation handles adding a comment to a content item
+func (h *ContentHandler) CreateAnnotation(w http.ResponseWriter, r *http.Request) {
+	contentID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	var input service.AnnotationInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	annotation, err := h.annotationService.CreateAnnotation(r.Context(), contentID, input)
+	if err != nil {
+		annotationErrorResponse(w, err, "Failed to create annotation")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(annotation)
+}
+
+// ListAnnotations handles listing the comments of a content item
+func (h *ContentHandler) ListAnnotations(w http.ResponseWriter, r *http.Request) {
+	contentID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	options := listOptions(r)
+	annotations, total, err := h.annotationService.ListAnnotations(r.Context(), contentID, options)
+	if err != nil {
+		annotationErrorResponse(w, err, "Failed to list annotations")
+		return
+	}
+	if annotations == nil {
+		annotations = []*model.Annotation{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items":      annotations,
+		"totalCount": total,
+		"page":       options.Page,
+		"pageSize":   options.PageSize,
+	})
+}
+
+// GetAnnotation handles retrieving a comment
+func (h *ContentHandler) GetAnnotation(w http.ResponseWriter, r *http.Request) {
+	contentID, annotationID, ok := annotationIDs(w, r)
+	if !ok {
+		return
+	}
+
+	annotation, err := h.annotationService.GetAnnotation(r.Context(), contentID, annotationID)
+	if err != nil {
+		annotationErrorResponse(w, err, "Failed to get annotation")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(annotation)
+}
+
+// UpdateAnnotation handles editing the body or anchor of a comment
+func (h *ContentHandler) UpdateAnnotation(w http.ResponseWriter, r *http.Request) {
+	contentID, annotationID, ok := annotationIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var input service.AnnotationInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	annotation, err := h.annotationService.UpdateAnnotation(r.Context(), contentID, annotationID, input)
+	if err != nil {
+		annotationErrorResponse(w, err, "Failed to update annotation")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(annotation)
+}
+
+// DeleteAnnotation handles removing a comment
+func (h *ContentHandler) DeleteAnnotation(w http.ResponseWriter, r *http.Request) {
+	contentID, annotationID, ok := annotationIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.annotationService.DeleteAnnotation(r.Context(), contentID, annotationID); err != nil {
+		annotationErrorResponse(w, err, "Failed to delete annotation")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
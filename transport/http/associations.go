@@ -0,0 +1,319 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// listOptions parses page and pageSize query parameters
+func listOptions(r *http.Request) repository.ListOptions {
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	pageSize, _ := strconv.Atoi(query.Get("pageSize"))
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	return repository.ListOptions{
+		Page:        page,
+		PageSize:    pageSize,
+		ReturnTotal: true,
+	}
+}
+
+// AssociateContent handles linking a content item to an entity
+func (h *ContentHandler) AssociateContent(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	var input service.AssociateContentInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	input.ContentID = id
+	// The association is made by the caller, not whoever the body names
+	input.AssociatedBy = requestPrincipal(r)
+
+	association, err := h.contentService.AssociateContent(r.Context(), input)
+	if err != nil {
+		if errors.Is(err, service.ErrContentNotFound) {
+			errorResponse(w, http.StatusNotFound, "Content not found")
+		} else if errors.Is(err, service.ErrInvalidInput) {
+			invalidInputResponse(w, err)
+		} else if errors.Is(err, service.ErrAssociationExists) || errors.Is(err, service.ErrAttachmentLimit) {
+			errorResponse(w, http.StatusConflict, err.Error())
+		} else {
+			errorResponse(w, http.StatusInternalServerError, "Failed to associate content")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(association)
+}
+
+// ListContentAssociations handles listing the entities a content item is linked to
+func (h *ContentHandler) ListContentAssociations(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	options := listOptions(r)
+	associations, total, err := h.contentService.ListAssociationsForContent(r.Context(), id, options)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to list associations")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items":      associations,
+		"totalCount": total,
+		"page":       options.Page,
+		"pageSize":   options.PageSize,
+	})
+}
+
+// RemoveAssociation handles unlinking a content item from an entity
+func (h *ContentHandler) RemoveAssociation(w http.ResponseWriter, r *http.Request) {
+	associationID, err := uuid.Parse(chi.URLParam(r, "associationID"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid association ID")
+		return
+	}
+
+	if err := h.contentService.RemoveAssociation(r.Context(), associationID); err != nil {
+		if errors.Is(err, service.ErrAssociationNotFound) {
+			errorResponse(w, http.StatusNotFound, "Association not found")
+		} else {
+			errorResponse(w, http.StatusInternalServerError, "Failed to remove association")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TransitionReview handles requesting, approving or rejecting the review of an association
+func (h *ContentHandler) TransitionReview(w http.ResponseWriter, r *http.Request) {
+	associationID, err := uuid.Parse(chi.URLParam(r, "associationID"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid association ID")
+		return
+	}
+
+	var input service.ReviewTransitionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	association, err := h.contentService.TransitionReview(r.Context(), associationID, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAssociationNotFound):
+			errorResponse(w, http.StatusNotFound, "Association not found")
+		case errors.Is(err, service.ErrInvalidInput):
+			invalidInputResponse(w, err)
+		case errors.Is(err, service.ErrInvalidReviewTransition):
+			errorResponse(w, http.StatusConflict, err.Error())
+		default:
+			errorResponse(w, http.StatusInternalServerError, "Failed to update review")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(association)
+}
+
+// ListReviews handles listing associations by review state, pending by default
+func (h *ContentHandler) ListReviews(w http.ResponseWriter, r *http.Request) {
+	state := model.ReviewState(r.URL.Query().Get("state"))
+	if state == "" {
+		state = model.ReviewPending
+	}
+
+	options := listOptions(r)
+	associations, total, err := h.contentService.ListReviews(r.Context(), r.URL.Query().Get("entity_type"), state, options)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			invalidInputResponse(w, err)
+		} else {
+			errorResponse(w, http.StatusInternalServerError, "Failed to list reviews")
+		}
+		return
+	}
+	if associations == nil {
+		associations = []*model.ContentEntityAssociation{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items":      associations,
+		"totalCount": total,
+		"page":       options.Page,
+		"pageSize":   options.PageSize,
+	})
+}
+
+// ListEntityContents handles listing the content linked to an entity
+func (h *ContentHandler) ListEntityContents(w http.ResponseWriter, r *http.Request) {
+	entityType := chi.URLParam(r, "type")
+	entityID := chi.URLParam(r, "entityID")
+
+	options := listOptions(r)
+	principal, ok := pinnedBy(r)
+	if !ok {
+		errorResponse(w, http.StatusBadRequest, "X-Principal-ID header is required to list pinned content")
+		return
+	}
+	options.PinnedBy = principal
+	if options.CreatedBy, ok = createdBy(r); !ok {
+		errorResponse(w, http.StatusBadRequest, "X-Principal-ID header is required to list your own content")
+		return
+	}
+	options.IncludeDrafts = r.URL.Query().Get("include_drafts") == "true"
+	switch sortBy := r.URL.Query().Get("sortBy"); sortBy {
+	case "", string(model.SortByCreatedAt):
+	case repository.SortByPosition:
+		options.SortBy = sortBy
+	default:
+		errorResponse(w, http.StatusBadRequest, "Unknown sort field: "+sortBy)
+		return
+	}
+
+	if wantsCSV(r) {
+		writeContentsCSV(w, r, "contents.csv", func(fn func(*model.Content) error) error {
+			return h.contentService.EachContentForEntity(r.Context(), entityType, entityID, options, fn)
+		})
+		return
+	}
+
+	withAssociations, ok := includeAssociations(w, r)
+	if !ok {
+		return
+	}
+
+	var items interface{}
+	var total int64
+	var err error
+	if withAssociations {
+		var contents []*model.EntityContent
+		contents, total, err = h.contentService.GetContentWithAssociationsForEntity(r.Context(), entityType, entityID, options)
+		items = newEntityContentResponses(contents)
+	} else {
+		var contents []*model.Content
+		contents, total, err = h.contentService.GetContentForEntity(r.Context(), entityType, entityID, options)
+		items = newContentResponses(contents)
+	}
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			invalidInputResponse(w, err)
+		} else {
+			errorResponse(w, http.StatusInternalServerError, "Failed to list entity content")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items":      items,
+		"totalCount": total,
+		"page":       options.Page,
+		"pageSize":   options.PageSize,
+	})
+}
+
+// includeAssociations parses the comma-separated include query parameter of
+// an entity listing. It writes an error response and returns false if an
+// include is unknown.
+func includeAssociations(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	param := r.URL.Query().Get("include")
+	if param == "" {
+		return false, true
+	}
+
+	associations := false
+	for _, include := range strings.Split(param, ",") {
+		switch strings.TrimSpace(include) {
+		case "associations":
+			associations = true
+		default:
+			errorResponse(w, http.StatusBadRequest, "Unknown include: "+include)
+			return false, false
+		}
+	}
+	return associations, true
+}
+
+// ReorderEntityContents handles putting the content of an entity in a user-defined order
+func (h *ContentHandler) ReorderEntityContents(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		ContentIDs []uuid.UUID `json:"content_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	err := h.contentService.ReorderEntityContents(r.Context(), chi.URLParam(r, "type"), chi.URLParam(r, "entityID"), input.ContentIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput):
+			invalidInputResponse(w, err)
+		case errors.Is(err, service.ErrAssociationNotFound):
+			errorResponse(w, http.StatusNotFound, "Content is not linked to the entity")
+		default:
+			errorResponse(w, http.StatusInternalServerError, "Failed to reorder entity content")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteEntityContents handles the deletion of an entity upstream: its
+// associations are removed and, with purge=true, its orphaned content deleted
+func (h *ContentHandler) DeleteEntityContents(w http.ResponseWriter, r *http.Request) {
+	input := service.DeleteEntityInput{
+		EntityType: chi.URLParam(r, "type"),
+		EntityID:   chi.URLParam(r, "entityID"),
+		Purge:      r.URL.Query().Get("purge") == "true",
+		DeletedBy:  r.URL.Query().Get("deleted_by"),
+	}
+
+	result, err := h.contentService.DeleteEntity(r.Context(), input)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			invalidInputResponse(w, err)
+		} else {
+			errorResponse(w, http.StatusInternalServerError, "Failed to delete entity content")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,152 @@
+package http
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// csvColumns maps the columns a CSV listing can select to their values
+var csvColumns = map[string]func(*model.Content) string{
+	"id":            func(c *model.Content) string { return c.ID.String() },
+	"tenant_id":     func(c *model.Content) string { return c.TenantID },
+	"status":        func(c *model.Content) string { return string(c.Status) },
+	"file_name":     func(c *model.Content) string { return c.FileName },
+	"description":   func(c *model.Content) string { return c.Description },
+	"mime_type":     func(c *model.Content) string { return c.MIMEType },
+	"file_size":     func(c *model.Content) string { return strconv.FormatInt(c.FileSize, 10) },
+	"etag":          func(c *model.Content) string { return c.ETag },
+	"source":        func(c *model.Content) string { return c.Source },
+	"external_id":   func(c *model.Content) string { return c.ExternalID },
+	"kind":          func(c *model.Content) string { return string(kindOf(c)) },
+	"reference_url": func(c *model.Content) string { return c.ReferenceURL },
+	"derived_from_id": func(c *model.Content) string {
+		if c.DerivedFromID == nil {
+			return ""
+		}
+		return c.DerivedFromID.String()
+	},
+	"derivation": func(c *model.Content) string { return c.Derivation },
+	"created_by": func(c *model.Content) string { return c.CreatedBy },
+	"created_at": func(c *model.Content) string { return c.CreatedAt.UTC().Format(time.RFC3339) },
+	"updated_at": func(c *model.Content) string { return c.UpdatedAt.UTC().Format(time.RFC3339) },
+	"draft_expires_at": func(c *model.Content) string {
+		if c.DraftExpiresAt == nil {
+			return ""
+		}
+		return c.DraftExpiresAt.UTC().Format(time.RFC3339)
+	},
+	"metadata": func(c *model.Content) string {
+		if len(c.Metadata) == 0 {
+			return ""
+		}
+		data, _ := json.Marshal(c.Metadata)
+		return string(data)
+	},
+}
+
+// defaultCSVColumns are exported when a request doesn't select columns
+var defaultCSVColumns = []string{"id", "file_name", "mime_type", "file_size", "status", "source", "created_by", "created_at", "updated_at"}
+
+// wantsCSV reports whether a listing should be returned as CSV, requested
+// with format=csv or an Accept header naming text/csv
+func wantsCSV(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "csv"
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/csv")
+}
+
+// selectedCSVColumns parses the comma-separated columns query parameter.
+// It writes an error response and returns false if a column is unknown.
+func selectedCSVColumns(w http.ResponseWriter, r *http.Request) ([]string, bool) {
+	param := r.URL.Query().Get("columns")
+	if param == "" {
+		return defaultCSVColumns, true
+	}
+
+	columns := strings.Split(param, ",")
+	for i, column := range columns {
+		columns[i] = strings.TrimSpace(column)
+		if _, ok := csvColumns[columns[i]]; !ok {
+			errorResponse(w, http.StatusBadRequest, "Unknown column: "+columns[i])
+			return nil, false
+		}
+	}
+	return columns, true
+}
+
+// csvCell neutralizes values a spreadsheet would evaluate as a formula
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// writeContentsCSV streams the content visited by each as CSV rows. Errors
+// raised before the first row get a JSON error response; later errors
+// truncate the body, since the status has already been sent.
+func writeContentsCSV(w http.ResponseWriter, r *http.Request, filename string, each func(fn func(*model.Content) error) error) {
+	columns, ok := selectedCSVColumns(w, r)
+	if !ok {
+		return
+	}
+
+	writer := csv.NewWriter(w)
+	started := false
+	start := func() error {
+		started = true
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", contentDisposition("attachment", filename))
+		return writer.Write(columns)
+	}
+
+	row := make([]string, len(columns))
+	rows := 0
+	err := each(func(content *model.Content) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		for i, column := range columns {
+			row[i] = csvCell(csvColumns[column](content))
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+
+		// Flush regularly so large exports reach the client as they are read
+		if rows++; rows%100 == 0 {
+			writer.Flush()
+			if flusher, ok := w.(http.Flusher); ok {
+				flusher.Flush()
+			}
+		}
+		return writer.Error()
+	})
+	if err == nil && !started {
+		err = start()
+	}
+
+	switch {
+	case err == nil:
+		writer.Flush()
+	case started:
+		writer.Flush()
+		log.Printf("Error writing CSV listing: %v", err)
+	case errors.Is(err, service.ErrInvalidInput):
+		invalidInputResponse(w, err)
+	default:
+		errorResponse(w, http.StatusInternalServerError, "Failed to list content")
+	}
+}
@@ -0,0 +1,101 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// derivativeJobErrorResponse maps errors from starting a derivative job
+func derivativeJobErrorResponse(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrContentNotFound):
+		errorResponse(w, http.StatusNotFound, "Content not found")
+	case errors.Is(err, service.ErrInvalidInput):
+		invalidInputResponse(w, err)
+	case errors.Is(err, service.ErrUnsupportedFormat):
+		errorResponse(w, http.StatusUnsupportedMediaType, err.Error())
+	case errors.Is(err, service.ErrTranscodingDisabled), errors.Is(err, service.ErrConversionDisabled), errors.Is(err, service.ErrJobsDisabled):
+		errorResponse(w, http.StatusNotImplemented, err.Error())
+	default:
+		errorResponse(w, http.StatusInternalServerError, "Failed to start job")
+	}
+}
+
+// TranscodeContent handles requesting a web-friendly rendition of audio or video
+func (h *ContentHandler) TranscodeContent(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	var input struct {
+		Profile service.TranscodeProfile `json:"profile"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if input.Profile == "" {
+		input.Profile = service.ProfileMP4
+	}
+
+	job, err := h.contentService.TranscodeContent(r.Context(), id, input.Profile)
+	if err != nil {
+		derivativeJobErrorResponse(w, err)
+		return
+	}
+
+	jobAcceptedResponse(w, "/api/v1/jobs/", job)
+}
+
+// ConvertContent handles requesting a PDF rendition of an office document
+func (h *ContentHandler) ConvertContent(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	job, err := h.contentService.ConvertContent(r.Context(), id)
+	if err != nil {
+		derivativeJobErrorResponse(w, err)
+		return
+	}
+
+	jobAcceptedResponse(w, "/api/v1/jobs/", job)
+}
+
+// ListDerivatives handles listing the content derived from a content item
+func (h *ContentHandler) ListDerivatives(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	if _, err := h.contentService.GetContent(r.Context(), id); err != nil {
+		if errors.Is(err, service.ErrContentNotFound) {
+			errorResponse(w, http.StatusNotFound, "Content not found")
+		} else {
+			errorResponse(w, http.StatusInternalServerError, "Failed to retrieve content")
+		}
+		return
+	}
+
+	items, err := h.contentService.ListDerivatives(r.Context(), id)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to list derivatives")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items": items,
+	})
+}
@@ -0,0 +1,143 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// isJSONRequest reports whether the body of a request is JSON
+func isJSONRequest(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "application/json"
+}
+
+// createDirectUpload handles a create request with a JSON body: it creates the
+// content record and returns the presigned upload the client sends the data with
+func (h *ContentHandler) createDirectUpload(w http.ResponseWriter, r *http.Request) {
+	var request directUploadRequest
+	if !decodeRequest(w, r, &request) {
+		return
+	}
+	if request.Metadata == nil {
+		request.Metadata = make(model.Metadata)
+	}
+
+	content, upload, err := h.contentService.CreateDirectUpload(r.Context(), service.DirectUploadInput{
+		TenantID:    requestTenant(r),
+		FileName:    fileNameOf(request.FileName, request.Name),
+		Description: request.Description,
+		MIMEType:    request.MIMEType,
+		FileSize:    request.FileSize,
+		Method:      request.Upload,
+		CreatedBy:   requestPrincipal(r),
+		EntityType:  request.EntityType,
+		EntityID:    request.EntityID,
+		Source:      request.Source,
+		ExternalID:  request.ExternalID,
+		Metadata:    request.Metadata,
+		CallbackURL: request.CallbackURL,
+		SHA256:      request.SHA256,
+		DraftTTL:    time.Duration(request.DraftTTL) * time.Second,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput):
+			invalidInputResponse(w, err)
+		case errors.Is(err, service.ErrTenantNotFound):
+			errorResponse(w, http.StatusBadRequest, "Unknown tenant")
+		case errors.Is(err, service.ErrQuotaExceeded):
+			errorResponse(w, quotaExceededStatus(err), err.Error())
+		case errors.Is(err, service.ErrAttachmentLimit), errors.Is(err, service.ErrExternalIDExists):
+			errorResponse(w, http.StatusConflict, err.Error())
+		case errors.Is(err, service.ErrDirectUploadUnsupported):
+			errorResponse(w, http.StatusNotImplemented, err.Error())
+		case errors.Is(err, service.ErrStorageUnavailable):
+			storageUnavailableResponse(w, err)
+		default:
+			errorResponse(w, http.StatusInternalServerError, "Failed to create content")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(directUploadResponse{contentResponse: newContentResponse(content), Upload: upload})
+}
+
+// MarkContentUploaded handles the confirmation of a direct upload
+func (h *ContentHandler) MarkContentUploaded(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	// Clients may send the SHA-256 they computed while uploading
+	var request confirmUploadRequest
+	if isJSONRequest(r) && !decodeRequest(w, r, &request) {
+		return
+	}
+
+	content, err := h.contentService.MarkContentAsUploadedWithChecksum(r.Context(), id, request.SHA256)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput):
+			invalidInputResponse(w, err)
+		case errors.Is(err, service.ErrContentNotFound):
+			errorResponse(w, http.StatusNotFound, "Content not found")
+		case errors.Is(err, service.ErrInvalidStatus):
+			errorResponse(w, http.StatusConflict, err.Error())
+		case errors.Is(err, service.ErrChecksumMismatch):
+			errorResponse(w, http.StatusUnprocessableEntity, err.Error())
+		case errors.Is(err, service.ErrQuotaExceeded):
+			errorResponse(w, quotaExceededStatus(err), err.Error())
+		default:
+			errorResponse(w, http.StatusBadGateway, "Failed to confirm upload: "+err.Error())
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newContentResponse(content))
+}
+
+// RenewDirectUpload handles presigning the upload of a direct upload again,
+// for clients retrying an upload that expired or failed
+func (h *ContentHandler) RenewDirectUpload(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	method := service.UploadMethod(r.URL.Query().Get("upload"))
+	content, upload, err := h.contentService.RenewDirectUpload(r.Context(), id, method)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput):
+			invalidInputResponse(w, err)
+		case errors.Is(err, service.ErrContentNotFound):
+			errorResponse(w, http.StatusNotFound, "Content not found")
+		case errors.Is(err, service.ErrInvalidStatus):
+			errorResponse(w, http.StatusConflict, err.Error())
+		case errors.Is(err, service.ErrDirectUploadUnsupported):
+			errorResponse(w, http.StatusNotImplemented, err.Error())
+		case errors.Is(err, service.ErrStorageUnavailable):
+			storageUnavailableResponse(w, err)
+		default:
+			errorResponse(w, http.StatusInternalServerError, "Failed to renew upload")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(directUploadResponse{contentResponse: newContentResponse(content), Upload: upload})
+}
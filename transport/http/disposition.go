@@ -0,0 +1,57 @@
+package http
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// contentDisposition returns a Content-Disposition header naming a file,
+// quoted or RFC 2231 encoded as the name needs, so that no name can add
+// parameters or headers. Names stored before file names were sanitized are
+// sanitized here; a name that can't be is left out.
+func contentDisposition(disposition, fileName string) string {
+	name, err := service.SanitizeFileName(fileName)
+	if err != nil {
+		return disposition
+	}
+	if value := mime.FormatMediaType(disposition, map[string]string{"filename": name}); value != "" {
+		return value
+	}
+	return disposition
+}
+
+// activeMIMETypes are the types, besides the XML dialects, that browsers
+// render as documents able to run scripts
+var activeMIMETypes = map[string]bool{
+	"text/html":             true,
+	"application/xhtml+xml": true,
+	"text/xml":              true,
+	"application/xml":       true,
+	"text/xsl":              true,
+}
+
+// activeContent reports whether a browser opening data of a MIME type could
+// run scripts in it, with the origin it is served from: HTML, and SVG or
+// any other XML, which can carry scripts or style sheets loading them.
+// Types that don't parse count as active, as browsers may sniff them.
+func activeContent(mimeType string) bool {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return true
+	}
+	return activeMIMETypes[mediaType] || strings.HasSuffix(mediaType, "+xml")
+}
+
+// dataDisposition returns the disposition data of a MIME type is served
+// with: inline only if the request asks for it with inline=true, the
+// preview can show the type and it isn't active content, so uploaded HTML
+// or SVG is downloaded rather than run from the service's origin
+func dataDisposition(r *http.Request, mimeType string) string {
+	if r.URL.Query().Get("inline") == "true" && previewKind(mimeType) != "" && !activeContent(mimeType) {
+		return "inline"
+	}
+	return "attachment"
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"mime"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/livefire2015/simple-contents/model"
+)
+
+func TestContentDisposition(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		want     string
+		wantName string
+	}{
+		{name: "token", fileName: "report.pdf", want: "attachment; filename=report.pdf", wantName: "report.pdf"},
+		{name: "spaces", fileName: "Q1 report.pdf", want: `attachment; filename="Q1 report.pdf"`, wantName: "Q1 report.pdf"},
+		{name: "parameter injection", fileName: `a.pdf"; filename="evil.exe`, wantName: `a.pdf"; filename="evil.exe`},
+		{name: "header injection", fileName: "a.pdf\r\nSet-Cookie: session=x", wantName: "a.pdfSet-Cookie: session=x"},
+		{name: "non-ASCII", fileName: "résumé.pdf", want: "attachment; filename*=utf-8''r%C3%A9sum%C3%A9.pdf", wantName: "résumé.pdf"},
+		{name: "traversal", fileName: "../../etc/passwd", want: "attachment; filename=passwd", wantName: "passwd"},
+		{name: "invalid", fileName: "..", want: "attachment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := contentDisposition("attachment", tt.fileName)
+			if tt.want != "" && got != tt.want {
+				t.Errorf("contentDisposition(%q) = %q, want %q", tt.fileName, got, tt.want)
+			}
+			disposition, params, err := mime.ParseMediaType(got)
+			if err != nil || disposition != "attachment" || params["filename"] != tt.wantName || len(params) > 1 {
+				t.Errorf("contentDisposition(%q) = %q parses as %q, %v, %v, want the single file name %q", tt.fileName, got, disposition, params, err, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestDataDisposition(t *testing.T) {
+	tests := []struct {
+		mimeType string
+		inline   bool
+		want     string
+	}{
+		{mimeType: "image/png", inline: true, want: "inline"},
+		{mimeType: "image/png", want: "attachment"},
+		{mimeType: "application/pdf", inline: true, want: "inline"},
+		{mimeType: "text/html", inline: true, want: "attachment"},
+		{mimeType: "text/html; charset=utf-8", inline: true, want: "attachment"},
+		{mimeType: "application/xhtml+xml", inline: true, want: "attachment"},
+		{mimeType: "image/svg+xml", inline: true, want: "attachment"},
+		{mimeType: "Image/SVG+XML", inline: true, want: "attachment"},
+		{mimeType: "image/svg+xml ", inline: true, want: "attachment"},
+		{mimeType: "image/svg+xml; x=1", inline: true, want: "attachment"},
+		{mimeType: "text/xml", inline: true, want: "attachment"},
+		{mimeType: "application/xml", inline: true, want: "attachment"},
+		{mimeType: "image/png; =", inline: true, want: "attachment"},
+		{mimeType: "", inline: true, want: "attachment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mimeType, func(t *testing.T) {
+			target := "/api/v1/contents/42/data"
+			if tt.inline {
+				target += "?inline=true"
+			}
+			w := httptest.NewRecorder()
+			setContentDataHeaders(w, httptest.NewRequest("GET", target, nil), &model.Content{MIMEType: tt.mimeType, FileName: "file"})
+
+			disposition, _, err := mime.ParseMediaType(w.Header().Get("Content-Disposition"))
+			if err != nil || disposition != tt.want {
+				t.Errorf("Content-Disposition = %q, want %s", w.Header().Get("Content-Disposition"), tt.want)
+			}
+			if got := w.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+				t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+			}
+		})
+	}
+}
+
+func TestObjectHeadersForceAttachment(t *testing.T) {
+	for _, mimeType := range []string{"text/html", "image/svg+xml", "image/png"} {
+		w := httptest.NewRecorder()
+		writeObjectHeaders(w, &model.Content{MIMEType: mimeType, FileName: "object"})
+
+		disposition, _, err := mime.ParseMediaType(w.Header().Get("Content-Disposition"))
+		if err != nil || disposition != "attachment" {
+			t.Errorf("Content-Disposition of %s = %q, want attachment", mimeType, w.Header().Get("Content-Disposition"))
+		}
+		if got := w.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+			t.Errorf("X-Content-Type-Options of %s = %q, want nosniff", mimeType, got)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// documentErrorResponse maps document service errors to HTTP responses
+func documentErrorResponse(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		invalidInputResponse(w, err)
+	case errors.Is(err, service.ErrDocumentNotFound):
+		errorResponse(w, http.StatusNotFound, "Document not found")
+	case errors.Is(err, service.ErrVariantNotFound):
+		errorResponse(w, http.StatusNotFound, "Document variant not found")
+	case errors.Is(err, service.ErrContentNotFound):
+		errorResponse(w, http.StatusNotFound, "Content not found")
+	case errors.Is(err, service.ErrContentQuarantined):
+		errorResponse(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrScanPending):
+		errorResponse(w, http.StatusConflict, err.Error())
+	default:
+		errorResponse(w, http.StatusInternalServerError, message)
+	}
+}
+
+// documentID parses the document ID of a request
+func documentID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "documentID"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid document ID")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// preferredLanguages returns the languages a request accepts, most preferred
+// first. The lang query parameter takes precedence over the Accept-Language
+// header; languages with a quality of 0 are left out.
+func preferredLanguages(r *http.Request) []string {
+	var languages []string
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		languages = append(languages, lang)
+	}
+
+	type weighted struct {
+		language string
+		quality  float64
+	}
+	var accepted []weighted
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		language, params, _ := strings.Cut(part, ";")
+		language = strings.TrimSpace(language)
+		if language == "" {
+			continue
+		}
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality > 0 {
+			accepted = append(accepted, weighted{language, quality})
+		}
+	}
+	sort.SliceStable(accepted, func(i, j int) bool { return accepted[i].quality > accepted[j].quality })
+
+	for _, a := range accepted {
+		languages = append(languages, a.language)
+	}
+	return languages
+}
+
+// CreateDocument handles creating a document in the requesting tenant
+func (h *ContentHandler) CreateDocument(w http.ResponseWriter, r *http.Request) {
+	var input service.DocumentInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	document, err := h.documentService.CreateDocument(r.Context(), requestTenant(r), input)
+	if err != nil {
+		documentErrorResponse(w, err, "Failed to create document")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(document)
+}
+
+// ListDocuments handles listing the documents of the requesting tenant
+func (h *ContentHandler) ListDocuments(w http.ResponseWriter, r *http.Request) {
+	options := listOptions(r)
+	documents, total, err := h.documentService.ListDocuments(r.Context(), requestTenant(r), options)
+	if err != nil {
+		documentErrorResponse(w, err, "Failed to list documents")
+		return
+	}
+	if documents == nil {
+		documents = []*model.Document{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items":      documents,
+		"totalCount": total,
+		"page":       options.Page,
+		"pageSize":   options.PageSize,
+	})
+}
+
+// GetDocument handles retrieving a document with its variants
+func (h *ContentHandler) GetDocument(w http.ResponseWriter, r *http.Request) {
+	id, ok := documentID(w, r)
+	if !ok {
+		return
+	}
+
+	document, err := h.documentService.GetDocument(r.Context(), requestTenant(r), id)
+	if err != nil {
+		documentErrorResponse(w, err, "Failed to get document")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(document)
+}
+
+// UpdateDocument handles renaming a document or changing its default language
+func (h *ContentHandler) UpdateDocument(w http.ResponseWriter, r *http.Request) {
+	id, ok := documentID(w, r)
+	if !ok {
+		return
+	}
+
+	var input service.DocumentInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	document, err := h.documentService.UpdateDocument(r.Context(), requestTenant(r), id, input)
+	if err != nil {
+		documentErrorResponse(w, err, "Failed to update document")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(document)
+}
+
+// DeleteDocument handles removing a document
+func (h *ContentHandler) DeleteDocument(w http.ResponseWriter, r *http.Request) {
+	id, ok := documentID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.documentService.DeleteDocument(r.Context(), requestTenant(r), id); err != nil {
+		documentErrorResponse(w, err, "Failed to delete document")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetDocumentVariant handles setting the content of a document in a language
+func (h *ContentHandler) SetDocumentVariant(w http.ResponseWriter, r *http.Request) {
+	id, ok := documentID(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		ContentID uuid.UUID `json:"content_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	document, err := h.documentService.SetVariant(r.Context(), requestTenant(r), id, chi.URLParam(r, "language"), input.ContentID)
+	if err != nil {
+		documentErrorResponse(w, err, "Failed to set document variant")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(document)
+}
+
+// DeleteDocumentVariant handles removing the variant of a document in a language
+func (h *ContentHandler) DeleteDocumentVariant(w http.ResponseWriter, r *http.Request) {
+	id, ok := documentID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.documentService.DeleteVariant(r.Context(), requestTenant(r), id, chi.URLParam(r, "language")); err != nil {
+		documentErrorResponse(w, err, "Failed to delete document variant")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetDocumentData handles streaming the variant of a document that best
+// matches the Accept-Language header of the request
+func (h *ContentHandler) GetDocumentData(w http.ResponseWriter, r *http.Request) {
+	id, ok := documentID(w, r)
+	if !ok {
+		return
+	}
+
+	data, content, language, err := h.documentService.GetDocumentData(r.Context(), requestTenant(r), id, preferredLanguages(r))
+	if err != nil {
+		documentErrorResponse(w, err, "Failed to retrieve document data")
+		return
+	}
+	defer data.Close()
+
+	w.Header().Set("Content-Language", language)
+	w.Header().Add("Vary", "Accept-Language")
+	h.serveContentData(w, r, data, content, false)
+}
@@ -0,0 +1,170 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// downloadLinkResponse is a download link with the short URL to share
+type downloadLinkResponse struct {
+	*model.DownloadLink
+	URL string `json:"url"`
+}
+
+// downloadLinkErrorResponse maps download link service errors to HTTP responses
+func downloadLinkErrorResponse(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		invalidInputResponse(w, err)
+	case errors.Is(err, service.ErrContentNotFound):
+		errorResponse(w, http.StatusNotFound, "Content not found")
+	case errors.Is(err, service.ErrDownloadLinkNotFound):
+		errorResponse(w, http.StatusNotFound, "Download link not found")
+	case errors.Is(err, service.ErrDownloadLinkExpired):
+		errorResponse(w, http.StatusGone, "Download link has expired")
+	case errors.Is(err, service.ErrPIIRestricted), errors.Is(err, service.ErrContentQuarantined):
+		errorResponse(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrScanPending):
+		errorResponse(w, http.StatusConflict, err.Error())
+	default:
+		errorResponse(w, http.StatusInternalServerError, message)
+	}
+}
+
+// publicURL returns the URL of a path on the configured public base URL or
+// else on the host the request was made to
+func (h *ContentHandler) publicURL(r *http.Request, path string) string {
+	baseURL := h.publicBaseURL
+	if baseURL == "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		baseURL = scheme + "://" + r.Host
+	}
+	return strings.TrimSuffix(baseURL, "/") + path
+}
+
+// downloadLinkURL returns the short URL of a token
+func (h *ContentHandler) downloadLinkURL(r *http.Request, token string) string {
+	return h.publicURL(r, "/dl/"+token)
+}
+
+// CreateDownloadLink handles creating a short download link to a content item
+func (h *ContentHandler) CreateDownloadLink(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	var input service.DownloadLinkInput
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	link, err := h.downloadLinkService.CreateDownloadLink(r.Context(), id, requestPrincipal(r), input)
+	if err != nil {
+		downloadLinkErrorResponse(w, err, "Failed to create download link")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(downloadLinkResponse{DownloadLink: link, URL: h.downloadLinkURL(r, link.Token)})
+}
+
+// ListDownloadLinks handles listing the download links of a content item with their clicks
+func (h *ContentHandler) ListDownloadLinks(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	options := listOptions(r)
+	links, total, err := h.downloadLinkService.ListDownloadLinks(r.Context(), id, options)
+	if err != nil {
+		downloadLinkErrorResponse(w, err, "Failed to list download links")
+		return
+	}
+
+	items := make([]downloadLinkResponse, len(links))
+	for i, link := range links {
+		items[i] = downloadLinkResponse{DownloadLink: link, URL: h.downloadLinkURL(r, link.Token)}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items":      items,
+		"totalCount": total,
+		"page":       options.Page,
+		"pageSize":   options.PageSize,
+	})
+}
+
+// DeleteDownloadLink handles revoking a download link
+func (h *ContentHandler) DeleteDownloadLink(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	if err := h.downloadLinkService.DeleteDownloadLink(r.Context(), id, chi.URLParam(r, "token")); err != nil {
+		downloadLinkErrorResponse(w, err, "Failed to delete download link")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RedeemDownloadLink handles a click on a short download link: it redirects
+// to a short-lived presigned URL or streams the data, depending on the link
+func (h *ContentHandler) RedeemDownloadLink(w http.ResponseWriter, r *http.Request) {
+	link, content, err := h.downloadLinkService.RedeemDownloadLink(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		downloadLinkErrorResponse(w, err, "Failed to redeem download link")
+		return
+	}
+
+	// Clicks are counted, so responses must not be cached
+	w.Header().Set("Cache-Control", "no-store")
+
+	if link.Mode == model.DownloadLinkRedirect {
+		url, err := h.downloadLinkService.DownloadLinkURL(r.Context(), content)
+		if err != nil {
+			errorResponse(w, http.StatusInternalServerError, "Failed to generate content URL")
+			return
+		}
+		http.Redirect(w, r, url, http.StatusFound)
+		return
+	}
+
+	data, _, err := h.contentService.GetContentData(r.Context(), content.ID)
+	if errors.Is(err, service.ErrReferenceContent) {
+		http.Redirect(w, r, content.ReferenceURL, http.StatusFound)
+		return
+	}
+	if err != nil {
+		downloadLinkErrorResponse(w, err, "Failed to retrieve content data")
+		return
+	}
+	defer data.Close()
+
+	setContentDataHeaders(w, r, content)
+	w.Header().Set("Content-Length", strconv.FormatInt(content.FileSize, 10))
+	io.Copy(h.throttle.writer(r.Context(), w, content.TenantID), data)
+}
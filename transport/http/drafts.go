@@ -0,0 +1,48 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// draftTTL parses the draft_ttl of a create request, in seconds. Empty
+// creates finalized content.
+func draftTTL(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, true
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds <= 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// FinalizeContent handles turning a draft into regular content
+func (h *ContentHandler) FinalizeContent(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	content, err := h.contentService.FinalizeContent(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrContentNotFound) {
+			errorResponse(w, http.StatusNotFound, "Content not found")
+		} else {
+			errorResponse(w, http.StatusInternalServerError, "Failed to finalize content")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newContentResponse(content))
+}
@@ -0,0 +1,267 @@
+package http
+
+import (
+	_ "embed"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/service"
+	"github.com/livefire2015/simple-contents/storage"
+)
+
+// The types below are the JSON contract of the content API, described by
+// openapi.json. They are mapped explicitly from the models and to the
+// service inputs, so renaming a model field can't change the API. Resources
+// name their fields in snake_case and listings their envelope in camelCase,
+// like the page and pageSize query parameters.
+
+//go:generate go run gen_ts_client.go
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// serveOpenAPI serves the OpenAPI description of the content API
+func serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
+
+// contentResponse is a content item as the API returns it. Storage paths
+// are internal and left out.
+type contentResponse struct {
+	ID                 uuid.UUID             `json:"id"`
+	TenantID           string                `json:"tenant_id,omitempty"`
+	Status             model.ContentStatus   `json:"status"`
+	FileName           string                `json:"file_name"`
+	Description        string                `json:"description,omitempty"`
+	MIMEType           string                `json:"mime_type"`
+	FileSize           int64                 `json:"file_size"`
+	ETag               string                `json:"etag,omitempty"`
+	DerivedFromID      *uuid.UUID            `json:"derived_from_id,omitempty"`
+	Derivation         string                `json:"derivation,omitempty"`
+	Source             string                `json:"source"`
+	ExternalID         string                `json:"external_id,omitempty"`
+	Metadata           model.Metadata        `json:"metadata,omitempty"`
+	CallbackURL        string                `json:"callback_url,omitempty"`
+	CallbackSentAt     *time.Time            `json:"callback_sent_at,omitempty"`
+	Kind               model.ContentKind     `json:"kind"`
+	ReferenceURL       string                `json:"reference_url,omitempty"`
+	ReferenceStatus    model.ReferenceStatus `json:"reference_status,omitempty"`
+	ReferenceCheckedAt *time.Time            `json:"reference_checked_at,omitempty"`
+	DraftExpiresAt     *time.Time            `json:"draft_expires_at,omitempty"`
+	CreatedBy          string                `json:"created_by"`
+	CreatedAt          time.Time             `json:"created_at"`
+	UpdatedAt          time.Time             `json:"updated_at"`
+	DeletedAt          *time.Time            `json:"deleted_at,omitempty"`
+}
+
+// newContentResponse returns the response of a content item
+func newContentResponse(content *model.Content) contentResponse {
+	return contentResponse{
+		ID:                 content.ID,
+		TenantID:           content.TenantID,
+		Status:             content.Status,
+		FileName:           content.FileName,
+		Description:        content.Description,
+		MIMEType:           content.MIMEType,
+		FileSize:           content.FileSize,
+		ETag:               content.ETag,
+		DerivedFromID:      content.DerivedFromID,
+		Derivation:         content.Derivation,
+		Source:             content.Source,
+		ExternalID:         content.ExternalID,
+		Metadata:           content.Metadata,
+		CallbackURL:        content.CallbackURL,
+		CallbackSentAt:     content.CallbackSentAt,
+		Kind:               kindOf(content),
+		ReferenceURL:       content.ReferenceURL,
+		ReferenceStatus:    content.ReferenceStatus,
+		ReferenceCheckedAt: content.ReferenceCheckedAt,
+		DraftExpiresAt:     content.DraftExpiresAt,
+		CreatedBy:          content.CreatedBy,
+		CreatedAt:          content.CreatedAt,
+		UpdatedAt:          content.UpdatedAt,
+		DeletedAt:          content.DeletedAt,
+	}
+}
+
+// kindOf returns the kind of a content item, file unless it is a reference
+func kindOf(content *model.Content) model.ContentKind {
+	if content.IsReference() {
+		return model.KindReference
+	}
+	return model.KindFile
+}
+
+// newContentResponses returns the responses of content items, never nil
+func newContentResponses(contents []*model.Content) []contentResponse {
+	responses := make([]contentResponse, len(contents))
+	for i, content := range contents {
+		responses[i] = newContentResponse(content)
+	}
+	return responses
+}
+
+// entityContentResponse is a content item listed with the association
+// linking it to the entity
+type entityContentResponse struct {
+	contentResponse
+	Association *model.ContentEntityAssociation `json:"association"`
+}
+
+// newEntityContentResponses returns the responses of the content of an entity, never nil
+func newEntityContentResponses(contents []*model.EntityContent) []entityContentResponse {
+	responses := make([]entityContentResponse, len(contents))
+	for i, content := range contents {
+		responses[i] = entityContentResponse{contentResponse: newContentResponse(content.Content), Association: content.Association}
+	}
+	return responses
+}
+
+// contentListResponse is a page of content items
+type contentListResponse struct {
+	Items          []contentResponse `json:"items"`
+	TotalCount     int               `json:"totalCount"`
+	Page           int               `json:"page"`
+	PageSize       int               `json:"pageSize"`
+	TotalPages     int               `json:"totalPages"`
+	HasMore        bool              `json:"hasMore"`
+	TotalEstimated bool              `json:"totalEstimated,omitempty"`
+}
+
+// newContentListResponse returns the response of a content listing
+func newContentListResponse(result *service.ListContentResult) contentListResponse {
+	return contentListResponse{
+		Items:          newContentResponses(result.Items),
+		TotalCount:     result.TotalCount,
+		Page:           result.Page,
+		PageSize:       result.PageSize,
+		TotalPages:     result.TotalPages,
+		HasMore:        result.HasMore,
+		TotalEstimated: result.TotalEstimated,
+	}
+}
+
+// directUploadResponse is content created for a direct upload, with the
+// presigned upload the client sends the data with
+type directUploadResponse struct {
+	contentResponse
+	Upload *storage.PresignedUpload `json:"upload"`
+}
+
+// directUploadRequest is the JSON body of a create request whose data the
+// client uploads straight to storage
+type directUploadRequest struct {
+	FileName    string               `json:"file_name" validate:"max=255"`
+	Name        string               `json:"name" validate:"max=255"` // Deprecated: use file_name
+	Description string               `json:"description" validate:"max=20000"`
+	MIMEType    string               `json:"mime_type" validate:"required,max=255"`
+	FileSize    int64                `json:"file_size" validate:"min=1"`
+	Upload      service.UploadMethod `json:"upload" validate:"omitempty,oneof=put post"`
+	EntityType  string               `json:"entity_type" validate:"max=100"`
+	EntityID    string               `json:"entity_id" validate:"max=255"`
+	Source      string               `json:"source" validate:"max=100"`
+	ExternalID  string               `json:"external_id" validate:"max=255"`
+	Metadata    model.Metadata       `json:"metadata" validate:"max=1000"`
+	CallbackURL string               `json:"callback_url" validate:"omitempty,url,max=2048"`
+	SHA256      string               `json:"sha256" validate:"max=88"`               // Hex or base64 SHA-256 the upload must match
+	DraftTTL    int64                `json:"draft_ttl" validate:"min=0,max=2592000"` // Seconds a draft waits to be finalized, none if 0
+}
+
+// confirmUploadRequest is the optional JSON body confirming a direct upload
+type confirmUploadRequest struct {
+	SHA256 string `json:"sha256" validate:"max=88"` // Hex or base64 SHA-256 the client computed while uploading
+}
+
+// createContentFromURLRequest is the JSON body of a request creating content
+// fetched from a remote URL
+type createContentFromURLRequest struct {
+	URL         string         `json:"url" validate:"required,url,max=2048"`
+	FileName    string         `json:"file_name" validate:"max=255"`
+	Name        string         `json:"name" validate:"max=255"` // Deprecated: use file_name
+	Description string         `json:"description" validate:"max=20000"`
+	Source      string         `json:"source" validate:"max=100"`
+	ExternalID  string         `json:"external_id" validate:"max=255"`
+	Metadata    model.Metadata `json:"metadata" validate:"max=1000"`
+	CallbackURL string         `json:"callback_url" validate:"omitempty,url,max=2048"`
+}
+
+// input returns the service input of the request
+func (request createContentFromURLRequest) input(tenantID string) service.CreateContentFromURLInput {
+	return service.CreateContentFromURLInput{
+		TenantID:    tenantID,
+		URL:         request.URL,
+		FileName:    fileNameOf(request.FileName, request.Name),
+		Description: request.Description,
+		Source:      request.Source,
+		ExternalID:  request.ExternalID,
+		Metadata:    request.Metadata,
+		CallbackURL: request.CallbackURL,
+	}
+}
+
+// createReferenceRequest is the JSON body of a request cataloguing a
+// document kept by another system
+type createReferenceRequest struct {
+	URL         string         `json:"url" validate:"required,url,max=2048"`
+	FileName    string         `json:"file_name" validate:"max=255"`
+	Description string         `json:"description" validate:"max=20000"`
+	MIMEType    string         `json:"mime_type" validate:"max=255"`
+	EntityType  string         `json:"entity_type" validate:"max=100"`
+	EntityID    string         `json:"entity_id" validate:"max=255"`
+	Source      string         `json:"source" validate:"max=100"`
+	ExternalID  string         `json:"external_id" validate:"max=255"`
+	Metadata    model.Metadata `json:"metadata" validate:"max=1000"`
+	Verify      bool           `json:"verify"`
+}
+
+// input returns the service input of the request
+func (request createReferenceRequest) input(tenantID string) service.CreateReferenceInput {
+	return service.CreateReferenceInput{
+		TenantID:    tenantID,
+		URL:         request.URL,
+		FileName:    request.FileName,
+		Description: request.Description,
+		MIMEType:    request.MIMEType,
+		EntityType:  request.EntityType,
+		EntityID:    request.EntityID,
+		Source:      request.Source,
+		ExternalID:  request.ExternalID,
+		Metadata:    request.Metadata,
+		Verify:      request.Verify,
+	}
+}
+
+// updateContentRequest is the JSON body of a content update
+type updateContentRequest struct {
+	FileName    string         `json:"file_name" validate:"max=255"`
+	Name        string         `json:"name" validate:"max=255"`          // Deprecated: use file_name
+	Description *string        `json:"description" validate:"max=20000"` // Cleared by ""
+	Metadata    model.Metadata `json:"metadata" validate:"max=1000"`
+}
+
+// input returns the service input of the request
+func (request updateContentRequest) input(id uuid.UUID) service.UpdateContentInput {
+	return service.UpdateContentInput{
+		ID:          id,
+		FileName:    fileNameOf(request.FileName, request.Name),
+		Description: request.Description,
+		Metadata:    request.Metadata,
+	}
+}
+
+// updateContentStatusRequest is the JSON body of a status transition
+type updateContentStatusRequest struct {
+	Status model.ContentStatus `json:"status" validate:"required,oneof=created uploaded done error"`
+}
+
+// fileNameOf returns the file name of a request, given as file_name or as
+// the name it was called before
+func fileNameOf(fileName, name string) string {
+	if fileName != "" {
+		return fileName
+	}
+	return name
+}
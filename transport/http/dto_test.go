@@ -0,0 +1,178 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository/memory"
+	"github.com/livefire2015/simple-contents/service"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// openAPISchema compiles a schema of the components of openapi.json
+func openAPISchema(t *testing.T, name string) *jsonschema.Schema {
+	t.Helper()
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+	if err := compiler.AddResource("openapi.json", bytes.NewReader(openAPISpec)); err != nil {
+		t.Fatal(err)
+	}
+	schema, err := compiler.Compile("openapi.json#/components/schemas/" + name)
+	if err != nil {
+		t.Fatalf("compiling schema %s: %v", name, err)
+	}
+	return schema
+}
+
+// validateJSON checks a JSON document against a schema of openapi.json
+func validateJSON(t *testing.T, name string, data []byte) {
+	t.Helper()
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatalf("invalid JSON %s: %v", data, err)
+	}
+	if err := openAPISchema(t, name).Validate(document); err != nil {
+		t.Errorf("%s doesn't match schema %s: %v", data, name, err)
+	}
+}
+
+func TestContentResponseMatchesSpec(t *testing.T) {
+	now := time.Now()
+	derivedFrom := uuid.New()
+	content := &model.Content{
+		ID:                  uuid.New(),
+		TenantID:            "acme",
+		Status:              model.StatusDone,
+		FileName:            "invoice.pdf",
+		Description:         "Invoice for **March**",
+		MIMEType:            "application/pdf",
+		FileSize:            1024,
+		StoragePath:         "contents/invoice.pdf",
+		OriginalStoragePath: "originals/invoice.pdf",
+		ETag:                "d41d8cd98f00b204e9800998ecf8427e",
+		DerivedFromID:       &derivedFrom,
+		Derivation:          "convert/pdf",
+		CreatedBy:           "alice",
+		CreatedAt:           now,
+		UpdatedAt:           now,
+		DeletedAt:           &now,
+		Source:              "email_attachment",
+		ExternalID:          "INV-2024-0042",
+		Metadata:            model.Metadata{"category": "invoice"},
+		CallbackURL:         "https://example.com/callback",
+		CallbackSentAt:      &now,
+		Kind:                model.KindReference,
+		ReferenceURL:        "https://erp.example.com/invoices/42",
+		ReferenceStatus:     model.ReferenceReachable,
+		ReferenceCheckedAt:  &now,
+		DraftExpiresAt:      &now,
+	}
+	data, err := json.Marshal(newContentResponse(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	validateJSON(t, "Content", data)
+
+	// Every property of the spec is mapped, and nothing internal leaks
+	var fields map[string]interface{}
+	json.Unmarshal(data, &fields)
+	var spec struct {
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]interface{} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatal(err)
+	}
+	for property := range spec.Components.Schemas["ContentFields"].Properties {
+		if _, ok := fields[property]; !ok {
+			t.Errorf("response has no %s field", property)
+		}
+	}
+	for _, internal := range []string{"storage_path", "original_storage_path"} {
+		if _, ok := fields[internal]; ok {
+			t.Errorf("response exposes %s", internal)
+		}
+	}
+}
+
+func TestContentHandlersMatchSpec(t *testing.T) {
+	contentService := service.NewContentService(memory.NewMemoryRepository(), memorystorage.NewMemoryStorage())
+	router := chi.NewRouter()
+	NewContentHandler(contentService).RegisterRoutes(router)
+
+	do := func(method, path, contentType string, body []byte) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, bytes.NewReader(body))
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	// Uploaded with the file name under its former name
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	writer.WriteField("name", "report.txt")
+	writer.WriteField("entity_type", "order")
+	writer.WriteField("entity_id", "42")
+	part, _ := writer.CreateFormFile("file", "upload.txt")
+	part.Write([]byte("quarterly report"))
+	writer.Close()
+	w := do(http.MethodPost, "/api/v1/contents/", writer.FormDataContentType(), form.Bytes())
+	if w.Code != http.StatusCreated {
+		t.Fatalf("upload status = %d: %s", w.Code, w.Body)
+	}
+	var created contentResponse
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.FileName != "report.txt" {
+		t.Errorf("file_name = %q, want the name field", created.FileName)
+	}
+	id := created.ID.String()
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		wantStatus  int
+		schema      string
+	}{
+		{name: "stream", method: http.MethodPost, path: "/api/v1/contents/stream?file_name=notes.txt", contentType: "text/plain", body: "notes", wantStatus: http.StatusCreated, schema: "Content"},
+		{name: "get", method: http.MethodGet, path: "/api/v1/contents/" + id, wantStatus: http.StatusOK, schema: "Content"},
+		{name: "update", method: http.MethodPut, path: "/api/v1/contents/" + id, contentType: "application/json", body: `{"file_name":"renamed.txt"}`, wantStatus: http.StatusOK, schema: "Content"},
+		{name: "list", method: http.MethodGet, path: "/api/v1/contents?pageSize=1", wantStatus: http.StatusOK, schema: "ContentList"},
+		{name: "entity", method: http.MethodGet, path: "/api/v1/entities/order/42/contents", wantStatus: http.StatusOK, schema: "EntityContentList"},
+		{name: "entity with associations", method: http.MethodGet, path: "/api/v1/entities/order/42/contents?include=associations", wantStatus: http.StatusOK, schema: "EntityContentList"},
+		{name: "status", method: http.MethodPut, path: "/api/v1/contents/" + id + "/status", contentType: "application/json", body: `{"status":"bogus"}`, wantStatus: http.StatusBadRequest, schema: "Error"},
+		{name: "not found", method: http.MethodGet, path: "/api/v1/contents/" + uuid.NewString(), wantStatus: http.StatusNotFound, schema: "Error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(tt.method, tt.path, tt.contentType, []byte(tt.body))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			validateJSON(t, tt.schema, w.Body.Bytes())
+		})
+	}
+
+	w = do(http.MethodGet, "/api/v1/contents/"+id, "", nil)
+	if !strings.Contains(w.Body.String(), `"file_name":"renamed.txt"`) {
+		t.Errorf("update by file_name not applied: %s", w.Body)
+	}
+}
@@ -0,0 +1,177 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+const (
+	// maxLongPollWait caps the ?wait= parameter of the events endpoint
+	maxLongPollWait = 60 * time.Second
+	// sseHeartbeatInterval keeps idle SSE connections open through proxies
+	sseHeartbeatInterval = 15 * time.Second
+)
+
+// ContentEvents streams status transitions of a content item as Server-Sent Events.
+// With ?wait=<seconds> it instead long-polls for the next transition and returns the content.
+func (h *ContentHandler) ContentEvents(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	// Subscribe before reading the current state so no transition is missed
+	events, unsubscribe := h.contentService.SubscribeContent(id)
+	defer unsubscribe()
+
+	content, err := h.contentService.GetContent(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrContentNotFound) {
+			errorResponse(w, http.StatusNotFound, "Content not found")
+		} else {
+			errorResponse(w, http.StatusInternalServerError, "Failed to retrieve content")
+		}
+		return
+	}
+
+	if waitStr := r.URL.Query().Get("wait"); waitStr != "" {
+		waitSeconds, err := strconv.Atoi(waitStr)
+		if err != nil || waitSeconds < 0 {
+			errorResponse(w, http.StatusBadRequest, "Invalid wait parameter")
+			return
+		}
+		h.longPollStatus(w, r, content, events, min(time.Duration(waitSeconds)*time.Second, maxLongPollWait))
+		return
+	}
+
+	h.streamStatus(w, r, content, events)
+}
+
+// longPollStatus waits for the next status transition and responds with the content.
+// Content that already finished processing is returned immediately.
+func (h *ContentHandler) longPollStatus(w http.ResponseWriter, r *http.Request, content *model.Content, events <-chan service.Event, wait time.Duration) {
+	if !content.Status.IsTerminal() {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		select {
+		case event := <-events:
+			content = event.Content
+		case <-timer.C:
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newContentResponse(content))
+}
+
+// streamStatus writes the current status and every following transition as SSE
+// until processing finishes or the client disconnects
+func (h *ContentHandler) streamStatus(w http.ResponseWriter, r *http.Request, content *model.Content, events <-chan service.Event) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		errorResponse(w, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	if err := writeSSE(w, "status", content); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for !content.Status.IsTerminal() {
+		select {
+		case event := <-events:
+			content = event.Content
+			if err := writeSSE(w, "status", content); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// writeSSE writes a single Server-Sent Event with a JSON payload
+func writeSSE(w http.ResponseWriter, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return err
+}
+
+// wsPingInterval keeps idle WebSocket connections open through proxies
+const wsPingInterval = 30 * time.Second
+
+// EntityEvents broadcasts content added/removed/updated events of an entity over a WebSocket
+func (h *ContentHandler) EntityEvents(w http.ResponseWriter, r *http.Request) {
+	entityType := chi.URLParam(r, "type")
+	entityID := chi.URLParam(r, "entityID")
+
+	events, unsubscribe := h.contentService.SubscribeEntity(entityType, entityID)
+	defer unsubscribe()
+
+	h.serveWebSocket(w, r, func(conn *wsConn) {
+		defer conn.Close()
+		streamEntityEvents(conn, events)
+	})
+}
+
+// streamEntityEvents writes events to conn until the client goes away
+func streamEntityEvents(conn *wsConn, events <-chan service.Event) {
+	closed := make(chan struct{})
+	go func() {
+		conn.readLoop()
+		close(closed)
+	}()
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case event := <-events:
+			payload, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if err := conn.WriteText(payload); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.Ping(); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
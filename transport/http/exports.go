@@ -0,0 +1,44 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// CreateExport handles starting a copy of filtered content into another bucket
+func (h *ContentHandler) CreateExport(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Bucket string              `json:"bucket"`
+		Prefix string              `json:"prefix"`
+		Filter model.ContentFilter `json:"filter"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Callers only export the content of their own tenant
+	input.Filter.TenantID = requestTenant(r)
+	job, err := h.contentService.StartExport(r.Context(), service.ExportInput{
+		Bucket: input.Bucket,
+		Prefix: input.Prefix,
+		Filter: input.Filter,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput):
+			invalidInputResponse(w, err)
+		case errors.Is(err, service.ErrExportsDisabled), errors.Is(err, service.ErrJobsDisabled):
+			errorResponse(w, http.StatusNotImplemented, err.Error())
+		default:
+			errorResponse(w, http.StatusInternalServerError, "Failed to start export")
+		}
+		return
+	}
+
+	jobAcceptedResponse(w, "/api/v1/jobs/", job)
+}
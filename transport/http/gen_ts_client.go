@@ -0,0 +1,28 @@
+//go:build ignore
+
+// gen_ts_client writes the TypeScript client of openapi.json to clients/ts.
+// It is run by go generate ./transport/http.
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/livefire2015/simple-contents/transport/http/tsgen"
+)
+
+const output = "../../clients/ts/src/client.ts"
+
+func main() {
+	spec, err := os.ReadFile("openapi.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+	client, err := tsgen.Generate(spec)
+	if err != nil {
+		log.Fatalf("Failed to generate the TypeScript client: %v", err)
+	}
+	if err := os.WriteFile(output, client, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -3,7 +3,9 @@ package http
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
@@ -12,35 +14,306 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/google/uuid"
 	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
 	"github.com/livefire2015/simple-contents/service"
+	"github.com/livefire2015/simple-contents/storage"
+	"github.com/livefire2015/simple-contents/validate"
 )
 
 // ContentHandler handles HTTP requests for content operations
 type ContentHandler struct {
-	contentService *service.ContentService
+	contentService       *service.ContentService
+	annotationService    *service.AnnotationService
+	documentService      *service.DocumentService
+	signatureService     *service.SignatureService
+	templateService      *service.TemplateService
+	savedSearchService   *service.SavedSearchService
+	downloadLinkService  *service.DownloadLinkService
+	shareService         *service.ShareService
+	uploadRequestService *service.UploadRequestService
+	publicBaseURL        string
+	throttle             *DownloadThrottle
+	verifyDownloads      bool
+	preview              PreviewConfig
+	webSocket            WebSocketConfig
+	uploads              *uploadTracker
+	elevatedToken        string
 }
 
 // NewContentHandler creates a new content HTTP handler
 func NewContentHandler(contentService *service.ContentService) *ContentHandler {
 	return &ContentHandler{
 		contentService: contentService,
+		uploads:        newUploadTracker(),
 	}
 }
 
+// EnableDownloadThrottle limits the bandwidth used when streaming content data
+func (h *ContentHandler) EnableDownloadThrottle(throttle *DownloadThrottle) {
+	h.throttle = throttle
+}
+
+// EnableDownloadVerification checks streamed content data against its
+// stored checksum, reporting mismatches in the X-Checksum-Error trailer
+func (h *ContentHandler) EnableDownloadVerification() {
+	h.verifyDownloads = true
+}
+
+// EnableAnnotations serves the comments of content items
+func (h *ContentHandler) EnableAnnotations(annotationService *service.AnnotationService) {
+	h.annotationService = annotationService
+}
+
+// EnableSignatures serves e-signature requests and provider webhooks
+func (h *ContentHandler) EnableSignatures(signatureService *service.SignatureService) {
+	h.signatureService = signatureService
+}
+
+// EnableTemplates serves document templates and rendering
+func (h *ContentHandler) EnableTemplates(templateService *service.TemplateService) {
+	h.templateService = templateService
+}
+
+// EnableDocuments serves documents published in several languages
+func (h *ContentHandler) EnableDocuments(documentService *service.DocumentService) {
+	h.documentService = documentService
+}
+
+// EnableSavedSearches serves the named content filters of principals
+func (h *ContentHandler) EnableSavedSearches(savedSearchService *service.SavedSearchService) {
+	h.savedSearchService = savedSearchService
+}
+
+// EnableDownloadLinks serves short download links under /dl on baseURL, the
+// public URL of the service; links use the host of the request if it's empty
+func (h *ContentHandler) EnableDownloadLinks(downloadLinkService *service.DownloadLinkService, baseURL string) {
+	h.downloadLinkService = downloadLinkService
+	h.publicBaseURL = baseURL
+}
+
+// EnableShares serves public shares of content under /s on baseURL, as
+// EnableDownloadLinks does short download links
+func (h *ContentHandler) EnableShares(shareService *service.ShareService, baseURL string) {
+	h.shareService = shareService
+	h.publicBaseURL = baseURL
+}
+
+// EnableUploadRequests serves upload requests, through which external
+// parties upload files under /u on baseURL, as EnableDownloadLinks does
+// short download links
+func (h *ContentHandler) EnableUploadRequests(uploadRequestService *service.UploadRequestService, baseURL string) {
+	h.uploadRequestService = uploadRequestService
+	h.publicBaseURL = baseURL
+}
+
+// EnableElevatedScope grants requests presenting token as a bearer token
+// privileged access, e.g. to content flagged for PII
+func (h *ContentHandler) EnableElevatedScope(token string) {
+	h.elevatedToken = token
+}
+
+// elevateScope marks the context of requests carrying the elevated token
+func (h *ContentHandler) elevateScope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.elevatedToken != "" && bearerTokenMatches(r, h.elevatedToken) {
+			r = r.WithContext(service.WithElevatedScope(r.Context()))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// principalContext marks the context of requests with their principal, so
+// the content and associations they create are recorded as theirs
+func principalContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if principal := requestPrincipal(r); principal != "" {
+			r = r.WithContext(service.WithPrincipal(r.Context(), principal))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// trackWrites records the repository writes of each request, so repositories
+// with read replicas can route the request's later reads to the primary
+func trackWrites(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(repository.TrackWrites(r.Context())))
+	})
+}
+
 // RegisterRoutes registers HTTP routes for content operations
 func (h *ContentHandler) RegisterRoutes(r chi.Router) {
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
+	r.Use(h.elevateScope)
+	r.Use(principalContext)
+	r.Use(trackWrites)
 
 	r.Route("/api/v1/contents", func(r chi.Router) {
 		r.Post("/", h.CreateContent)
+		r.Post("/stream", h.CreateContentFromStream)
+		r.Post("/from-url", h.CreateContentFromURL)
+		r.Post("/references", h.CreateReference)
 		r.Get("/", h.ListContents)
+		r.Get("/stats", h.ContentStats)
+		r.Get("/by-external-id/{source}/{id}", h.GetContentByExternalID)
 		r.Get("/{id}", h.GetContent)
 		r.Put("/{id}", h.UpdateContent)
+		r.Delete("/", h.BulkDeleteContents)
 		r.Delete("/{id}", h.DeleteContent)
 		r.Get("/{id}/data", h.GetContentData)
+		r.Head("/{id}/data", h.HeadContentData)
+		r.Get("/{id}/preview", h.PreviewContent)
 		r.Get("/{id}/url", h.GetContentURL)
+		r.Put("/{id}/pin", h.PinContent)
+		r.Delete("/{id}/pin", h.UnpinContent)
+		r.Put("/{id}/status", h.UpdateContentStatus)
+		r.Post("/{id}/upload", h.RenewDirectUpload)
+		r.Post("/{id}/uploaded", h.MarkContentUploaded)
+		r.Post("/{id}/finalize", h.FinalizeContent)
+		r.Get("/{id}/events", h.ContentEvents)
+		r.Post("/{id}/associations", h.AssociateContent)
+		r.Get("/{id}/associations", h.ListContentAssociations)
+		r.Delete("/{id}/associations/{associationID}", h.RemoveAssociation)
+		r.Put("/{id}/associations/{associationID}/review", h.TransitionReview)
+		r.Post("/{id}/transcode", h.TranscodeContent)
+		r.Post("/{id}/convert", h.ConvertContent)
+		r.Get("/{id}/derivatives", h.ListDerivatives)
+		r.Post("/{id}/relations", h.CreateRelation)
+		r.Get("/{id}/relations", h.ListRelations)
+		r.Get("/{id}/relations/graph", h.GetRelationGraph)
+		r.Delete("/{id}/relations/{relationID}", h.DeleteRelation)
+		if h.signatureService != nil {
+			r.Post("/{id}/signatures", h.RequestSignature)
+			r.Get("/{id}/signatures", h.ListSignatureRequests)
+		}
+		if h.downloadLinkService != nil {
+			r.Post("/{id}/links", h.CreateDownloadLink)
+			r.Get("/{id}/links", h.ListDownloadLinks)
+			r.Delete("/{id}/links/{token}", h.DeleteDownloadLink)
+		}
+		if h.shareService != nil {
+			r.Post("/{id}/shares", h.CreateShare)
+			r.Get("/{id}/shares", h.ListShares)
+			r.Delete("/{id}/shares/{token}", h.RevokeShare)
+		}
+		if h.annotationService != nil {
+			r.Route("/{id}/annotations", func(r chi.Router) {
+				r.Post("/", h.CreateAnnotation)
+				r.Get("/", h.ListAnnotations)
+				r.Get("/{annotationID}", h.GetAnnotation)
+				r.Put("/{annotationID}", h.UpdateAnnotation)
+				r.Delete("/{annotationID}", h.DeleteAnnotation)
+			})
+		}
 	})
+
+	r.Route("/api/v1/entities/{type}/{entityID}", func(r chi.Router) {
+		r.Get("/contents", h.ListEntityContents)
+		r.Delete("/contents", h.DeleteEntityContents)
+		r.Patch("/contents/order", h.ReorderEntityContents)
+		if h.uploadRequestService != nil {
+			r.Post("/upload-requests", h.CreateUploadRequest)
+			r.Get("/upload-requests", h.ListUploadRequests)
+			r.Delete("/upload-requests/{token}", h.RevokeUploadRequest)
+		}
+	})
+
+	if h.downloadLinkService != nil {
+		r.Get("/dl/{token}", h.RedeemDownloadLink)
+	}
+	if h.shareService != nil {
+		r.Get("/s/{token}", h.RedeemShare)
+		r.Post("/s/{token}", h.RedeemShare)
+	}
+	if h.uploadRequestService != nil {
+		r.Get("/u/{token}", h.UploadRequestForm)
+		r.Post("/u/{token}", h.FulfillUploadRequest)
+	}
+
+	r.Route("/api/v1/uploads/{uploadID}", func(r chi.Router) {
+		r.Get("/progress", h.GetUploadProgress)
+		r.Get("/events", h.UploadProgressEvents)
+	})
+
+	r.Get("/api/v1/openapi.json", serveOpenAPI)
+	r.Get("/ws/entities/{type}/{entityID}", h.EntityEvents)
+	r.Get("/api/v1/jobs/{jobID}", h.GetJob)
+	r.Post("/api/v1/jobs/{jobID}/cancel", h.CancelJob)
+	r.Post("/api/v1/exports", h.CreateExport)
+	r.Get("/api/v1/reviews", h.ListReviews)
+
+	if h.templateService != nil {
+		r.Route("/api/v1/templates", func(r chi.Router) {
+			r.Post("/", h.CreateTemplate)
+			r.Get("/", h.ListTemplates)
+			r.Get("/{templateID}", h.GetTemplate)
+			r.Put("/{templateID}", h.UpdateTemplate)
+			r.Delete("/{templateID}", h.DeleteTemplate)
+			r.Post("/{templateID}/preview", h.PreviewTemplate)
+			r.Post("/{templateID}/render", h.RenderTemplate)
+		})
+	}
+
+	if h.documentService != nil {
+		r.Route("/api/v1/documents", func(r chi.Router) {
+			r.Post("/", h.CreateDocument)
+			r.Get("/", h.ListDocuments)
+			r.Get("/{documentID}", h.GetDocument)
+			r.Put("/{documentID}", h.UpdateDocument)
+			r.Delete("/{documentID}", h.DeleteDocument)
+			r.Get("/{documentID}/data", h.GetDocumentData)
+			r.Put("/{documentID}/variants/{language}", h.SetDocumentVariant)
+			r.Delete("/{documentID}/variants/{language}", h.DeleteDocumentVariant)
+		})
+	}
+
+	if h.savedSearchService != nil {
+		r.Route("/api/v1/saved-searches", func(r chi.Router) {
+			r.Post("/", h.CreateSavedSearch)
+			r.Get("/", h.ListSavedSearches)
+			r.Get("/{searchID}", h.GetSavedSearch)
+			r.Put("/{searchID}", h.UpdateSavedSearch)
+			r.Delete("/{searchID}", h.DeleteSavedSearch)
+			r.Get("/{searchID}/results", h.RunSavedSearch)
+		})
+	}
+
+	if h.signatureService != nil {
+		r.Get("/api/v1/signatures/{requestID}", h.GetSignatureRequest)
+		r.Post("/api/v1/signatures/webhooks/{provider}", h.SignatureWebhook)
+	}
+}
+
+// requestTenant returns the tenant a request acts for, taken from the X-Tenant-ID header
+func requestTenant(r *http.Request) string {
+	return r.Header.Get("X-Tenant-ID")
+}
+
+// quotaExceededStatus returns 413 for uploads larger than the whole quota and
+// 507 for uploads that don't fit in the space left
+func quotaExceededStatus(err error) int {
+	var quotaErr *service.QuotaExceededError
+	if errors.As(err, &quotaErr) && quotaErr.RequestedBytes > quotaErr.QuotaBytes {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusInsufficientStorage
+}
+
+// setRetryAfter tells clients refused while storage is unavailable when to
+// try again
+func setRetryAfter(w http.ResponseWriter, err error) {
+	var unavailableErr *service.StorageUnavailableError
+	if errors.As(err, &unavailableErr) {
+		seconds := int((unavailableErr.RetryAfter + time.Second - 1) / time.Second)
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+}
+
+// storageUnavailableResponse sends a 503 for uploads refused while storage is down
+func storageUnavailableResponse(w http.ResponseWriter, err error) {
+	setRetryAfter(w, err)
+	errorResponse(w, http.StatusServiceUnavailable, err.Error())
 }
 
 // errorResponse sends an error response with the given status code and message
@@ -50,8 +323,61 @@ func errorResponse(w http.ResponseWriter, code int, message string) {
 	json.NewEncoder(w).Encode(map[string]string{"error": message})
 }
 
-// CreateContent handles the creation of new content
+// invalidInputResponse sends a 400 for invalid input, listing the failing
+// fields when the input breaks its validation rules or metadata doesn't
+// match its schema
+func invalidInputResponse(w http.ResponseWriter, err error) {
+	var fields interface{}
+	var metadataErr *service.MetadataValidationError
+	var validationErrs validate.Errors
+	switch {
+	case errors.As(err, &metadataErr):
+		fields = metadataErr.Fields
+	case errors.As(err, &validationErrs):
+		fields = validationErrs
+	default:
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":  err.Error(),
+		"fields": fields,
+	})
+}
+
+// decodeRequest decodes the JSON body of a request into v and checks it
+// against its validate tags. It writes an error response and returns false
+// if the body is malformed or invalid.
+func decodeRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return false
+	}
+	if err := validate.Struct(v); err != nil {
+		invalidInputResponse(w, fmt.Errorf("%w: %w", service.ErrInvalidInput, err))
+		return false
+	}
+	return true
+}
+
+// CreateContent handles the creation of new content. A JSON body creates a
+// direct upload instead of carrying the data.
 func (h *ContentHandler) CreateContent(w http.ResponseWriter, r *http.Request) {
+	if isJSONRequest(r) {
+		h.createDirectUpload(w, r)
+		return
+	}
+
+	finish, ok := h.trackUpload(w, r)
+	if !ok {
+		return
+	}
+	var content *model.Content
+	defer func() { finish(content) }()
+
 	// Parse multipart form
 	err := r.ParseMultipartForm(32 << 20) // 32MB max memory
 	if err != nil {
@@ -59,9 +385,20 @@ func (h *ContentHandler) CreateContent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get form values
-	name := r.FormValue("name")
+	// Get form values, the file name also under the name it was called before
+	name := fileNameOf(r.FormValue("file_name"), r.FormValue("name"))
+	description := r.FormValue("description")
+	source := r.FormValue("source")
+	externalID := r.FormValue("external_id")
 	metadataStr := r.FormValue("metadata")
+	callbackURL := r.FormValue("callback_url")
+	entityType := r.FormValue("entity_type")
+	entityID := r.FormValue("entity_id")
+	ttl, ok := draftTTL(r.FormValue("draft_ttl"))
+	if !ok {
+		errorResponse(w, http.StatusBadRequest, "Invalid draft_ttl")
+		return
+	}
 
 	// Parse metadata if provided
 	var metadata model.Metadata
@@ -84,17 +421,35 @@ func (h *ContentHandler) CreateContent(w http.ResponseWriter, r *http.Request) {
 
 	// Create content
 	input := service.CreateContentInput{
-		FileName: name,
-		MIMEType: header.Header.Get("Content-Type"),
-		FileSize: header.Size,
-		Metadata: metadata,
+		TenantID:    requestTenant(r),
+		FileName:    name,
+		Description: description,
+		MIMEType:    header.Header.Get("Content-Type"),
+		FileSize:    header.Size,
+		Data:        file,
+		Source:      source,
+		ExternalID:  externalID,
+		Metadata:    metadata,
+		CallbackURL: callbackURL,
+		EntityType:  entityType,
+		EntityID:    entityID,
+		DraftTTL:    ttl,
 	}
 
-	content, err := h.contentService.CreateContent(r.Context(), input)
+	content, err = h.contentService.CreateContent(r.Context(), input)
 	if err != nil {
-		if errors.Is(err, service.ErrInvalidInput) {
-			errorResponse(w, http.StatusBadRequest, err.Error())
-		} else {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput):
+			invalidInputResponse(w, err)
+		case errors.Is(err, service.ErrTenantNotFound):
+			errorResponse(w, http.StatusBadRequest, "Unknown tenant")
+		case errors.Is(err, service.ErrQuotaExceeded):
+			errorResponse(w, quotaExceededStatus(err), err.Error())
+		case errors.Is(err, service.ErrAttachmentLimit), errors.Is(err, service.ErrExternalIDExists):
+			errorResponse(w, http.StatusConflict, err.Error())
+		case errors.Is(err, service.ErrStorageUnavailable):
+			storageUnavailableResponse(w, err)
+		default:
 			errorResponse(w, http.StatusInternalServerError, "Failed to create content")
 		}
 		return
@@ -103,7 +458,101 @@ func (h *ContentHandler) CreateContent(w http.ResponseWriter, r *http.Request) {
 	// Return created content
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(content)
+	json.NewEncoder(w).Encode(newContentResponse(content))
+}
+
+// CreateContentFromStream handles the creation of content from a raw request body.
+// The body may be chunked, in which case the size is determined after upload.
+func (h *ContentHandler) CreateContentFromStream(w http.ResponseWriter, r *http.Request) {
+	finish, ok := h.trackUpload(w, r)
+	if !ok {
+		return
+	}
+	var content *model.Content
+	defer func() { finish(content) }()
+
+	size := r.ContentLength
+	if size < 0 {
+		size = storage.UnknownSize
+	}
+	ttl, ok := draftTTL(r.URL.Query().Get("draft_ttl"))
+	if !ok {
+		errorResponse(w, http.StatusBadRequest, "Invalid draft_ttl")
+		return
+	}
+
+	input := service.CreateContentInput{
+		TenantID:    requestTenant(r),
+		FileName:    fileNameOf(r.URL.Query().Get("file_name"), r.URL.Query().Get("name")),
+		MIMEType:    r.Header.Get("Content-Type"),
+		FileSize:    size,
+		Data:        r.Body,
+		Source:      r.URL.Query().Get("source"),
+		ExternalID:  r.URL.Query().Get("external_id"),
+		Metadata:    make(model.Metadata),
+		CallbackURL: r.URL.Query().Get("callback_url"),
+		DraftTTL:    ttl,
+	}
+
+	content, err := h.contentService.CreateContent(r.Context(), input)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput):
+			invalidInputResponse(w, err)
+		case errors.Is(err, service.ErrTenantNotFound):
+			errorResponse(w, http.StatusBadRequest, "Unknown tenant")
+		case errors.Is(err, service.ErrQuotaExceeded):
+			errorResponse(w, quotaExceededStatus(err), err.Error())
+		case errors.Is(err, service.ErrAttachmentLimit), errors.Is(err, service.ErrExternalIDExists):
+			errorResponse(w, http.StatusConflict, err.Error())
+		case errors.Is(err, service.ErrStorageUnavailable):
+			storageUnavailableResponse(w, err)
+		default:
+			errorResponse(w, http.StatusInternalServerError, "Failed to create content")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newContentResponse(content))
+}
+
+// CreateContentFromURL handles the creation of content fetched from a remote URL
+func (h *ContentHandler) CreateContentFromURL(w http.ResponseWriter, r *http.Request) {
+	var request createContentFromURLRequest
+	if !decodeRequest(w, r, &request) {
+		return
+	}
+
+	content, err := h.contentService.CreateContentFromURL(r.Context(), request.input(requestTenant(r)))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput), errors.Is(err, service.ErrForbiddenAddress):
+			invalidInputResponse(w, err)
+		case errors.Is(err, service.ErrTenantNotFound):
+			errorResponse(w, http.StatusBadRequest, "Unknown tenant")
+		case errors.Is(err, service.ErrQuotaExceeded):
+			errorResponse(w, quotaExceededStatus(err), err.Error())
+		case errors.Is(err, service.ErrExternalIDExists):
+			errorResponse(w, http.StatusConflict, err.Error())
+		case errors.Is(err, service.ErrContentTypeMismatch):
+			errorResponse(w, http.StatusUnprocessableEntity, err.Error())
+		case errors.Is(err, service.ErrRemoteTooLarge):
+			errorResponse(w, http.StatusRequestEntityTooLarge, err.Error())
+		case errors.Is(err, service.ErrRemoteFetchFailed):
+			errorResponse(w, http.StatusBadGateway, err.Error())
+		case errors.Is(err, service.ErrStorageUnavailable):
+			storageUnavailableResponse(w, err)
+		default:
+			errorResponse(w, http.StatusInternalServerError, "Failed to create content")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newContentResponse(content))
 }
 
 // GetContent handles retrieving content metadata by ID
@@ -126,7 +575,27 @@ func (h *ContentHandler) GetContent(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(content)
+	json.NewEncoder(w).Encode(newContentResponse(content))
+}
+
+// GetContentByExternalID handles retrieving the content of the request
+// tenant by the source it came from and the ID it has there
+func (h *ContentHandler) GetContentByExternalID(w http.ResponseWriter, r *http.Request) {
+	content, err := h.contentService.GetContentByExternalID(r.Context(), requestTenant(r), chi.URLParam(r, "source"), chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput):
+			invalidInputResponse(w, err)
+		case errors.Is(err, service.ErrContentNotFound):
+			errorResponse(w, http.StatusNotFound, "Content not found")
+		default:
+			errorResponse(w, http.StatusInternalServerError, "Failed to retrieve content")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newContentResponse(content))
 }
 
 // UpdateContent handles updating content metadata
@@ -138,37 +607,57 @@ func (h *ContentHandler) UpdateContent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var input struct {
-		Name        string         `json:"name"`
-		Description string         `json:"description"`
-		Metadata    model.Metadata `json:"metadata"`
+	var request updateContentRequest
+	if !decodeRequest(w, r, &request) {
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+	content, err := h.contentService.UpdateContent(r.Context(), request.input(id))
+	if err != nil {
+		if errors.Is(err, service.ErrContentNotFound) {
+			errorResponse(w, http.StatusNotFound, "Content not found")
+		} else if errors.Is(err, service.ErrInvalidInput) {
+			invalidInputResponse(w, err)
+		} else {
+			errorResponse(w, http.StatusInternalServerError, "Failed to update content")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newContentResponse(content))
+}
+
+// UpdateContentStatus handles processing status transitions reported by workers
+func (h *ContentHandler) UpdateContentStatus(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
 		return
 	}
 
-	updateInput := service.UpdateContentInput{
-		ID:       id,
-		FileName: input.Name,
-		Metadata: input.Metadata,
+	var request updateContentStatusRequest
+	if !decodeRequest(w, r, &request) {
+		return
 	}
 
-	content, err := h.contentService.UpdateContent(r.Context(), updateInput)
+	content, err := h.contentService.UpdateContentStatus(r.Context(), id, request.Status)
 	if err != nil {
 		if errors.Is(err, service.ErrContentNotFound) {
 			errorResponse(w, http.StatusNotFound, "Content not found")
 		} else if errors.Is(err, service.ErrInvalidInput) {
-			errorResponse(w, http.StatusBadRequest, err.Error())
+			invalidInputResponse(w, err)
+		} else if errors.Is(err, service.ErrInvalidStatus) {
+			errorResponse(w, http.StatusConflict, err.Error())
 		} else {
-			errorResponse(w, http.StatusInternalServerError, "Failed to update content")
+			errorResponse(w, http.StatusInternalServerError, "Failed to update content status")
 		}
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(content)
+	json.NewEncoder(w).Encode(newContentResponse(content))
 }
 
 // DeleteContent handles deleting content
@@ -180,19 +669,77 @@ func (h *ContentHandler) DeleteContent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.contentService.DeleteContent(r.Context(), id)
+	query := r.URL.Query()
+	result, err := h.contentService.DeleteContent(r.Context(), id, service.DeleteContentOptions{
+		Policy:     service.DeletionPolicy(query.Get("policy")),
+		EntityType: query.Get("entity_type"),
+		EntityID:   query.Get("entity_id"),
+	})
 	if err != nil {
-		if errors.Is(err, service.ErrContentNotFound) {
+		switch {
+		case errors.Is(err, service.ErrContentNotFound):
 			errorResponse(w, http.StatusNotFound, "Content not found")
-		} else {
+		case errors.Is(err, service.ErrAssociationNotFound):
+			errorResponse(w, http.StatusNotFound, "Association not found")
+		case errors.Is(err, service.ErrInvalidInput):
+			invalidInputResponse(w, err)
+		case errors.Is(err, service.ErrContentReferenced), errors.Is(err, service.ErrContentRetained):
+			errorResponse(w, http.StatusConflict, err.Error())
+		default:
 			errorResponse(w, http.StatusInternalServerError, "Failed to delete content")
 		}
 		return
 	}
 
+	// Content that is only detached from an entity is still there
+	if !result.Deleted {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+		return
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// BulkDeleteContents handles deleting a list of content items, or all content
+// of an entity. With async=true the items are deleted in a job.
+func (h *ContentHandler) BulkDeleteContents(w http.ResponseWriter, r *http.Request) {
+	var input service.BulkDeleteInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if r.URL.Query().Get("async") == "true" {
+		job, err := h.contentService.StartBulkDelete(r.Context(), requestTenant(r), input)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrInvalidInput):
+				invalidInputResponse(w, err)
+			case errors.Is(err, service.ErrJobsDisabled):
+				errorResponse(w, http.StatusNotImplemented, err.Error())
+			default:
+				errorResponse(w, http.StatusInternalServerError, "Failed to start job")
+			}
+			return
+		}
+		jobAcceptedResponse(w, "/api/v1/jobs/", job)
+		return
+	}
+
+	result, err := h.contentService.BulkDelete(r.Context(), input)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			invalidInputResponse(w, err)
+		} else {
+			errorResponse(w, http.StatusInternalServerError, "Failed to delete content")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
 // GetContentData handles retrieving content data
 func (h *ContentHandler) GetContentData(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
@@ -202,30 +749,143 @@ func (h *ContentHandler) GetContentData(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	data, content, err := h.contentService.GetContentData(r.Context(), id)
+	// The unsanitized original of an image is only served to privileged callers
+	original := r.URL.Query().Get("original") == "true"
+
+	var data io.ReadCloser
+	var content *model.Content
+	if original {
+		data, content, err = h.contentService.GetOriginalContentData(r.Context(), id)
+	} else {
+		data, content, err = h.contentService.GetContentData(r.Context(), id)
+	}
 	if err != nil {
-		if errors.Is(err, service.ErrContentNotFound) {
+		switch {
+		case errors.Is(err, service.ErrReferenceContent):
+			// The data of a reference is served by its system of record
+			http.Redirect(w, r, content.ReferenceURL, http.StatusFound)
+		case errors.Is(err, service.ErrContentNotFound):
 			errorResponse(w, http.StatusNotFound, "Content not found")
-		} else {
+		case errors.Is(err, service.ErrElevatedScopeRequired), errors.Is(err, service.ErrContentQuarantined):
+			errorResponse(w, http.StatusForbidden, err.Error())
+		case errors.Is(err, service.ErrScanPending):
+			errorResponse(w, http.StatusConflict, err.Error())
+		default:
 			errorResponse(w, http.StatusInternalServerError, "Failed to retrieve content data")
 		}
 		return
 	}
 	defer data.Close()
 
+	h.serveContentData(w, r, data, content, original)
+}
+
+// serveContentData streams the data of a content item with its headers. The
+// original of a sanitized image has no ETag or known size.
+func (h *ContentHandler) serveContentData(w http.ResponseWriter, r *http.Request, data io.Reader, content *model.Content, original bool) {
+	// Clients validate the ETag as they would against S3
+	if !original && content.ETag != "" {
+		etag := `"` + content.ETag + `"`
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	// Detect storage corruption while streaming. The response is chunked so
+	// that a mismatch can be reported in a trailer.
+	var body io.Reader = data
+	var verifier *checksumReader
+	if h.verifyDownloads && !original {
+		verifier = newChecksumReader(data, content.ETag, content.FileSize)
+	}
+	if verifier != nil {
+		body = verifier
+		w.Header().Set("Trailer", checksumErrorTrailer)
+	}
+
 	// Set appropriate headers
-	w.Header().Set("Content-Type", content.MIMEType)
-	w.Header().Set("Content-Disposition", "attachment; filename="+content.FileName)
-	w.Header().Set("Content-Length", strconv.FormatInt(content.FileSize, 10))
+	setContentDataHeaders(w, r, content)
+	if !original && verifier == nil {
+		w.Header().Set("Content-Length", strconv.FormatInt(content.FileSize, 10))
+	}
 
 	// Stream the data to the response
-	_, err = io.Copy(w, data)
-	if err != nil {
+	_, err := io.Copy(h.throttle.writer(r.Context(), w, content.TenantID), body)
+	if errors.Is(err, errChecksumMismatch) {
+		w.Header().Set(checksumErrorTrailer, err.Error())
+		log.Printf("Corrupt data for content %s at %s: %v", content.ID, content.StoragePath, err)
+	} else if err != nil {
 		// Log the error but don't return a response as headers have already been sent
 		// log.Printf("Error streaming content data: %v", err)
 	}
 }
 
+// HeadContentData handles probing content data: it returns the headers of
+// GetContentData from the catalogue without reading the object from storage
+func (h *ContentHandler) HeadContentData(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	original := r.URL.Query().Get("original") == "true"
+	if original && !service.HasElevatedScope(r.Context()) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	content, err := h.contentService.GetContent(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrContentNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	switch err := service.CheckScanned(content); {
+	case errors.Is(err, service.ErrContentQuarantined):
+		w.WriteHeader(http.StatusForbidden)
+		return
+	case errors.Is(err, service.ErrScanPending):
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	if content.IsReference() {
+		http.Redirect(w, r, content.ReferenceURL, http.StatusFound)
+		return
+	}
+
+	// The size and ETag in the catalogue describe the stored data, not the original
+	if !original && content.ETag != "" {
+		etag := `"` + content.ETag + `"`
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	setContentDataHeaders(w, r, content)
+	if !original {
+		w.Header().Set("Content-Length", strconv.FormatInt(content.FileSize, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// setContentDataHeaders sets the headers describing the data of a content item.
+// Data is only served inline, with inline=true, if the preview can show it.
+func setContentDataHeaders(w http.ResponseWriter, r *http.Request, content *model.Content) {
+	w.Header().Set("Content-Type", content.MIMEType)
+	w.Header().Set("Content-Disposition", contentDisposition(dataDisposition(r, content.MIMEType), content.FileName))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// The data endpoint always serves the whole object
+	w.Header().Set("Accept-Ranges", "none")
+}
+
 // GetContentURL handles generating a URL for accessing content
 func (h *ContentHandler) GetContentURL(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
@@ -235,9 +895,9 @@ func (h *ContentHandler) GetContentURL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse expiry time from query parameter (default to 1 hour)
+	// Parse expiry time from query parameter, 0 for the service's default
 	expiryStr := r.URL.Query().Get("expiry")
-	expiry := 1 * time.Hour
+	var expiry time.Duration
 	if expiryStr != "" {
 		expirySeconds, err := strconv.ParseInt(expiryStr, 10, 64)
 		if err == nil && expirySeconds > 0 {
@@ -245,22 +905,34 @@ func (h *ContentHandler) GetContentURL(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	url, err := h.contentService.GetContentURL(r.Context(), id, expiry)
+	contentURL, err := h.contentService.GetContentURL(r.Context(), id, expiry)
 	if err != nil {
-		if errors.Is(err, service.ErrContentNotFound) {
+		switch {
+		case errors.Is(err, service.ErrContentNotFound):
 			errorResponse(w, http.StatusNotFound, "Content not found")
-		} else {
+		case errors.Is(err, service.ErrPIIRestricted), errors.Is(err, service.ErrContentQuarantined):
+			errorResponse(w, http.StatusForbidden, err.Error())
+		case errors.Is(err, service.ErrScanPending):
+			errorResponse(w, http.StatusConflict, err.Error())
+		default:
 			errorResponse(w, http.StatusInternalServerError, "Failed to generate content URL")
 		}
 		return
 	}
 
+	// The URL of a reference doesn't expire
+	response := map[string]interface{}{"url": contentURL.URL}
+	if contentURL.Expiry > 0 {
+		response["expires_in"] = int64(contentURL.Expiry / time.Second)
+		response["expires_at"] = contentURL.ExpiresAt
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"url": url})
+	json.NewEncoder(w).Encode(response)
 }
 
-// ListContents handles listing content items
-func (h *ContentHandler) ListContents(w http.ResponseWriter, r *http.Request) {
+// listContentInput parses the filter, sort and pagination query parameters
+// of a content listing. It writes an error response and returns false if they are invalid.
+func listContentInput(w http.ResponseWriter, r *http.Request) (service.ListContentInput, bool) {
 	// Parse query parameters
 	query := r.URL.Query()
 
@@ -300,24 +972,104 @@ func (h *ContentHandler) ListContents(w http.ResponseWriter, r *http.Request) {
 	if metadataStr := query.Get("metadata"); metadataStr != "" {
 		if err := json.Unmarshal([]byte(metadataStr), &metadata); err != nil {
 			errorResponse(w, http.StatusBadRequest, "Invalid metadata format")
-			return
+			return service.ListContentInput{}, false
 		}
 	}
+	if category := query.Get("category"); category != "" {
+		if metadata == nil {
+			metadata = make(map[string]interface{})
+		}
+		metadata[service.MetadataCategory] = category
+	}
 
-	input := service.ListContentInput{
-		MIMEType:    contentType,
-		MinSize:     minSize,
-		MaxSize:     maxSize,
-		CreatedFrom: createdFrom,
-		CreatedTo:   createdTo,
-		Metadata:    metadata,
-		Page:        page,
-		PageSize:    pageSize,
+	principal, ok := pinnedBy(r)
+	if !ok {
+		errorResponse(w, http.StatusBadRequest, "X-Principal-ID header is required to list pinned content")
+		return service.ListContentInput{}, false
+	}
+	creator, ok := createdBy(r)
+	if !ok {
+		errorResponse(w, http.StatusBadRequest, "X-Principal-ID header is required to list your own content")
+		return service.ListContentInput{}, false
+	}
+
+	return service.ListContentInput{
+		TenantID:      requestTenant(r),
+		Status:        model.ContentStatus(query.Get("status")),
+		FileName:      query.Get("filename"),
+		FileNameMatch: model.FileNameMatch(query.Get("filenameMatch")),
+		Text:          query.Get("q"),
+		MIMEType:      contentType,
+		MinSize:       minSize,
+		MaxSize:       maxSize,
+		CreatedFrom:   createdFrom,
+		CreatedTo:     createdTo,
+		Metadata:      metadata,
+		PinnedBy:      principal,
+		CreatedBy:     creator,
+		Kind:          model.ContentKind(query.Get("kind")),
+		SortBy:        model.ContentSortField(query.Get("sortBy")),
+		SortAsc:       query.Get("sortOrder") == "asc",
+		Page:          page,
+		PageSize:      pageSize,
+		Total:         service.TotalMode(query.Get("total")),
+	}, true
+}
+
+// ListContents handles listing content items
+func (h *ContentHandler) ListContents(w http.ResponseWriter, r *http.Request) {
+	input, ok := listContentInput(w, r)
+	if !ok {
+		return
+	}
+
+	if wantsCSV(r) {
+		writeContentsCSV(w, r, "contents.csv", func(fn func(*model.Content) error) error {
+			return h.contentService.EachContent(r.Context(), input, fn)
+		})
+		return
+	}
+	if wantsNDJSON(r) {
+		writeContentsNDJSON(w, func(fn func(*model.Content) error) error {
+			return h.contentService.EachContent(r.Context(), input, fn)
+		})
+		return
 	}
 
 	result, err := h.contentService.ListContent(r.Context(), input)
 	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, "Failed to list content")
+		if errors.Is(err, service.ErrInvalidInput) {
+			invalidInputResponse(w, err)
+		} else {
+			errorResponse(w, http.StatusInternalServerError, "Failed to list content")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newContentListResponse(result))
+}
+
+// ContentStats handles counting the content matching the listing filters
+// per group, e.g. ?groupBy=mime_type
+func (h *ContentHandler) ContentStats(w http.ResponseWriter, r *http.Request) {
+	input, ok := listContentInput(w, r)
+	if !ok {
+		return
+	}
+
+	groupBy := model.ContentGroupBy(r.URL.Query().Get("groupBy"))
+	if groupBy == "" {
+		groupBy = model.GroupByMIMEType
+	}
+
+	result, err := h.contentService.ContentStats(r.Context(), input, groupBy)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			invalidInputResponse(w, err)
+		} else {
+			errorResponse(w, http.StatusInternalServerError, "Failed to compute content statistics")
+		}
 		return
 	}
 
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// jobAcceptedResponse answers a request that started a job with the job and
+// where to poll it
+func jobAcceptedResponse(w http.ResponseWriter, prefix string, job *model.Job) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", prefix+job.ID.String())
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// jobErrorResponse maps errors from polling or cancelling a job
+func jobErrorResponse(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrJobNotFound):
+		errorResponse(w, http.StatusNotFound, "Job not found")
+	case errors.Is(err, service.ErrJobFinished):
+		errorResponse(w, http.StatusConflict, err.Error())
+	default:
+		errorResponse(w, http.StatusInternalServerError, "Failed to process job")
+	}
+}
+
+// tenantJob returns a job of the tenant of a request. The jobs of other
+// tenants and those only administrators see are not found.
+func (h *ContentHandler) tenantJob(r *http.Request, id uuid.UUID) (*model.Job, error) {
+	job, err := h.contentService.GetJob(r.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	if job.TenantID != requestTenant(r) || service.AdminOnlyJob(job) {
+		return nil, service.ErrJobNotFound
+	}
+	return job, nil
+}
+
+// GetJob handles polling a job, e.g. a transcode or an export
+func (h *ContentHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "jobID"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	job, err := h.tenantJob(r, id)
+	if err != nil {
+		jobErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// CancelJob handles stopping a queued or running job
+func (h *ContentHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "jobID"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	job, err := h.tenantJob(r, id)
+	if err == nil {
+		job, err = h.contentService.CancelJob(r.Context(), id)
+	}
+	if err != nil {
+		jobErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// GetJob handles polling a job of any tenant or kind
+func (h *AdminHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "jobID"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	job, err := h.contentService.GetJob(r.Context(), id)
+	if err != nil {
+		jobErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// CancelJob handles stopping a job of any tenant or kind
+func (h *AdminHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "jobID"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	job, err := h.contentService.CancelJob(r.Context(), id)
+	if err != nil {
+		jobErrorResponse(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
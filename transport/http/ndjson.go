@@ -0,0 +1,64 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// wantsNDJSON reports whether a listing should be streamed as newline-delimited
+// JSON, requested with format=ndjson or an Accept header naming application/x-ndjson
+func wantsNDJSON(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "ndjson"
+	}
+	return strings.Contains(r.Header.Get("Accept"), "application/x-ndjson")
+}
+
+// writeContentsNDJSON streams the content visited by each as one JSON object
+// per line, without counting or paging it. Errors raised before the first
+// item get a JSON error response; later errors truncate the body.
+func writeContentsNDJSON(w http.ResponseWriter, each func(fn func(*model.Content) error) error) {
+	encoder := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	started := false
+	start := func() {
+		started = true
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+	}
+
+	items := 0
+	err := each(func(content *model.Content) error {
+		if !started {
+			start()
+		}
+		if err := encoder.Encode(newContentResponse(content)); err != nil {
+			return err
+		}
+
+		// Flush regularly so large listings reach the client as they are read
+		if items++; items%100 == 0 && flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+
+	switch {
+	case err == nil:
+		if !started {
+			start()
+		}
+	case started:
+		log.Printf("Error writing NDJSON listing: %v", err)
+	case errors.Is(err, service.ErrInvalidInput):
+		invalidInputResponse(w, err)
+	default:
+		errorResponse(w, http.StatusInternalServerError, "Failed to list content")
+	}
+}
@@ -0,0 +1,32 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// TransferOwnership handles handing the content of a principal over to
+// another, recorded in the audit log as done by the admin
+func (h *AdminHandler) TransferOwnership(w http.ResponseWriter, r *http.Request) {
+	var input service.TransferOwnershipInput
+	if !decodeRequest(w, r, &input) {
+		return
+	}
+	input.TransferredBy = adminIdentity(r)
+
+	result, err := h.contentService.TransferOwnership(r.Context(), input)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidInput) {
+			invalidInputResponse(w, err)
+		} else {
+			errorResponse(w, http.StatusInternalServerError, "Failed to transfer ownership")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
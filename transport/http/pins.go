@@ -0,0 +1,84 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// requestPrincipal returns the user or agent a request acts for, taken from the X-Principal-ID header
+func requestPrincipal(r *http.Request) string {
+	return r.Header.Get("X-Principal-ID")
+}
+
+// pinnedBy returns the principal whose pins filter a listing, if pinned=true is set
+func pinnedBy(r *http.Request) (string, bool) {
+	if r.URL.Query().Get("pinned") != "true" {
+		return "", true
+	}
+	principal := requestPrincipal(r)
+	return principal, principal != ""
+}
+
+// createdBy returns the principal whose content a listing is limited to:
+// that of the request for created_by=me, or the one named
+func createdBy(r *http.Request) (string, bool) {
+	creator := r.URL.Query().Get("created_by")
+	if creator != "me" {
+		return creator, true
+	}
+	principal := requestPrincipal(r)
+	return principal, principal != ""
+}
+
+// PinContent handles starring a content item for the requesting principal
+func (h *ContentHandler) PinContent(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	pin, err := h.contentService.PinContent(r.Context(), requestPrincipal(r), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrContentNotFound):
+			errorResponse(w, http.StatusNotFound, "Content not found")
+		case errors.Is(err, service.ErrInvalidInput):
+			errorResponse(w, http.StatusBadRequest, "X-Principal-ID header is required")
+		default:
+			errorResponse(w, http.StatusInternalServerError, "Failed to pin content")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pin)
+}
+
+// UnpinContent handles removing the star of the requesting principal
+func (h *ContentHandler) UnpinContent(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	if err := h.contentService.UnpinContent(r.Context(), requestPrincipal(r), id); err != nil {
+		switch {
+		case errors.Is(err, service.ErrPinNotFound):
+			errorResponse(w, http.StatusNotFound, "Pin not found")
+		case errors.Is(err, service.ErrInvalidInput):
+			errorResponse(w, http.StatusBadRequest, "X-Principal-ID header is required")
+		default:
+			errorResponse(w, http.StatusInternalServerError, "Failed to unpin content")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
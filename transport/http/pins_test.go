@@ -0,0 +1,82 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/livefire2015/simple-contents/repository/memory"
+	"github.com/livefire2015/simple-contents/service"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+)
+
+func TestCreatedByFilter(t *testing.T) {
+	contentService := service.NewContentService(memory.NewMemoryRepository(), memorystorage.NewMemoryStorage())
+	router := chi.NewRouter()
+	NewContentHandler(contentService).RegisterRoutes(router)
+
+	do := func(method, path, principal, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		if principal != "" {
+			r.Header.Set("X-Principal-ID", principal)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	ids := make(map[string]string)
+	for _, principal := range []string{"alice", "bob"} {
+		w := do(http.MethodPost, "/api/v1/contents/stream?file_name="+principal+".txt", principal, "data")
+		if w.Code != http.StatusCreated {
+			t.Fatalf("upload status = %d: %s", w.Code, w.Body)
+		}
+		var created contentResponse
+		json.Unmarshal(w.Body.Bytes(), &created)
+		if created.CreatedBy != principal {
+			t.Errorf("created_by = %q, want %q", created.CreatedBy, principal)
+		}
+		ids[principal] = created.ID.String()
+
+		// The body can't claim someone else made the association
+		w = do(http.MethodPost, "/api/v1/contents/"+created.ID.String()+"/associations", principal, `{"entity_type":"order","entity_id":"42","associated_by":"mallory"}`)
+		if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"created_by":"`+principal+`"`) {
+			t.Fatalf("associate status = %d: %s", w.Code, w.Body)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		principal  string
+		wantStatus int
+		want       string // ID of the only item listed
+	}{
+		{name: "me", path: "/api/v1/contents?created_by=me", principal: "alice", wantStatus: http.StatusOK, want: ids["alice"]},
+		{name: "named", path: "/api/v1/contents?created_by=bob", principal: "alice", wantStatus: http.StatusOK, want: ids["bob"]},
+		{name: "entity", path: "/api/v1/entities/order/42/contents?created_by=me", principal: "bob", wantStatus: http.StatusOK, want: ids["bob"]},
+		{name: "me without principal", path: "/api/v1/contents?created_by=me", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(http.MethodGet, tt.path, tt.principal, "")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.want == "" {
+				return
+			}
+			var page struct {
+				Items []contentResponse `json:"items"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &page)
+			if len(page.Items) != 1 || page.Items[0].ID.String() != tt.want {
+				t.Errorf("listed %+v, want only %s", page.Items, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// previewStyle is the only style of the preview page, allowed by its hash
+const previewStyle = `html,body{margin:0;height:100%;background:#f4f4f4;font-family:sans-serif}` +
+	`body{display:flex;align-items:center;justify-content:center}` +
+	`img,video{max-width:100%;max-height:100%;object-fit:contain}` +
+	`iframe{border:0;width:100%;height:100%}`
+
+var previewStyleHash = func() string {
+	sum := sha256.Sum256([]byte(previewStyle))
+	return "'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'"
+}()
+
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.FileName}}</title>
+<style>{{.Style}}</style>
+</head>
+<body>
+{{- if eq .Kind "image"}}
+<img src="{{.DataURL}}" alt="{{.FileName}}">
+{{- else if eq .Kind "pdf"}}
+<iframe src="{{.PDFURL}}" title="{{.FileName}}"></iframe>
+{{- else if eq .Kind "video"}}
+<video src="{{.DataURL}}" controls preload="metadata"></video>
+{{- else if eq .Kind "audio"}}
+<audio src="{{.DataURL}}" controls preload="metadata"></audio>
+{{- else}}
+<p><a href="data" download="{{.FileName}}">{{.FileName}}</a> ({{.MIMEType}}, {{.FileSize}} bytes) cannot be previewed</p>
+{{- end}}
+</body>
+</html>
+`))
+
+// previewKind returns how the preview page shows a MIME type, empty if it
+// can't. SVG and other active content is left out as it could run scripts
+// when opened inline.
+func previewKind(mimeType string) string {
+	if activeContent(mimeType) {
+		return ""
+	}
+	mimeType, _, _ = mime.ParseMediaType(mimeType)
+	switch {
+	case mimeType == "application/pdf":
+		return "pdf"
+	case strings.HasPrefix(mimeType, "image/"):
+		return "image"
+	case strings.HasPrefix(mimeType, "video/"):
+		return "video"
+	case strings.HasPrefix(mimeType, "audio/"):
+		return "audio"
+	}
+	return ""
+}
+
+// PreviewConfig controls the HTML preview of content
+type PreviewConfig struct {
+	// FrameAncestors are the origins allowed to embed previews, e.g. internal
+	// tools; only the service itself if empty
+	FrameAncestors []string
+	// PDFViewerURL is a PDF.js viewer (viewer.html) showing PDFs; the
+	// browser's viewer is used if empty. The viewer must be able to fetch
+	// the data, i.e. be served from the same origin or allowed by CORS.
+	PDFViewerURL string
+}
+
+// ConfigurePreview changes how the HTML preview of content may be embedded and shows PDFs
+func (h *ContentHandler) ConfigurePreview(config PreviewConfig) {
+	h.preview = config
+}
+
+// previewPolicy returns the Content-Security-Policy of the preview page:
+// no scripts, and media loaded from the service only
+func (h *ContentHandler) previewPolicy() string {
+	frameSrc := "'self'"
+	if viewer, err := url.Parse(h.preview.PDFViewerURL); err == nil && viewer.Host != "" {
+		frameSrc += " " + viewer.Scheme + "://" + viewer.Host
+	}
+	frameAncestors := "'self'"
+	if len(h.preview.FrameAncestors) > 0 {
+		frameAncestors = strings.Join(h.preview.FrameAncestors, " ")
+	}
+	return "default-src 'none'; img-src 'self'; media-src 'self'; frame-src " + frameSrc +
+		"; style-src " + previewStyleHash + "; base-uri 'none'; form-action 'none'; frame-ancestors " + frameAncestors
+}
+
+// PreviewContent handles rendering a minimal HTML viewer of a content item
+// that internal tools can embed in an iframe
+func (h *ContentHandler) PreviewContent(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	content, err := h.contentService.GetContent(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrContentNotFound) {
+			errorResponse(w, http.StatusNotFound, "Content not found")
+		} else {
+			errorResponse(w, http.StatusInternalServerError, "Failed to get content")
+		}
+		return
+	}
+
+	// Media is loaded relative to the preview, from the data endpoint
+	dataURL := "data?inline=true"
+	pdfURL := dataURL
+	if h.preview.PDFViewerURL != "" {
+		pdfURL = h.preview.PDFViewerURL + "?file=" + url.QueryEscape("/api/v1/contents/"+content.ID.String()+"/"+dataURL)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", h.previewPolicy())
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("Cache-Control", "private, no-cache")
+
+	err = previewTemplate.Execute(w, map[string]interface{}{
+		"Kind":     previewKind(content.MIMEType),
+		"FileName": content.FileName,
+		"MIMEType": content.MIMEType,
+		"FileSize": content.FileSize,
+		"DataURL":  dataURL,
+		"PDFURL":   pdfURL,
+		"Style":    template.CSS(previewStyle),
+	})
+	if err != nil {
+		log.Printf("Error rendering preview of content %s: %v", content.ID, err)
+	}
+}
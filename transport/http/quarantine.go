@@ -0,0 +1,103 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// quarantineErrorResponse maps quarantine review errors to HTTP responses
+func quarantineErrorResponse(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		invalidInputResponse(w, err)
+	case errors.Is(err, service.ErrContentNotFound):
+		errorResponse(w, http.StatusNotFound, "Content not found")
+	case errors.Is(err, service.ErrNotQuarantined):
+		errorResponse(w, http.StatusConflict, err.Error())
+	default:
+		errorResponse(w, http.StatusInternalServerError, message)
+	}
+}
+
+// ListQuarantined handles listing the content found infected
+func (h *AdminHandler) ListQuarantined(w http.ResponseWriter, r *http.Request) {
+	options := listOptions(r)
+	result, err := h.contentService.ListQuarantined(r.Context(), options.Page, options.PageSize)
+	if err != nil {
+		quarantineErrorResponse(w, err, "Failed to list quarantined contents")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newContentListResponse(result))
+}
+
+// GetQuarantinedData handles downloading quarantined content for review. The
+// request must acknowledge the risk with acknowledge=true.
+func (h *AdminHandler) GetQuarantinedData(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	acknowledged := r.URL.Query().Get("acknowledge") == "true"
+	data, content, err := h.contentService.GetQuarantinedData(r.Context(), id, adminIdentity(r), acknowledged)
+	if err != nil {
+		quarantineErrorResponse(w, err, "Failed to retrieve quarantined data")
+		return
+	}
+	defer data.Close()
+
+	// Never let a browser render or sniff infected data
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", contentDisposition("attachment", content.FileName))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Length", strconv.FormatInt(content.FileSize, 10))
+	if _, err := io.Copy(w, data); err != nil {
+		log.Printf("Error streaming quarantined content %s: %v", content.ID, err)
+	}
+}
+
+// ReleaseQuarantined handles clearing quarantined content as a false positive
+func (h *AdminHandler) ReleaseQuarantined(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	content, err := h.contentService.ReleaseQuarantined(r.Context(), id, adminIdentity(r))
+	if err != nil {
+		quarantineErrorResponse(w, err, "Failed to release quarantined content")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newContentResponse(content))
+}
+
+// DestroyQuarantined handles deleting quarantined content and its data
+func (h *AdminHandler) DestroyQuarantined(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	if err := h.contentService.DestroyQuarantined(r.Context(), id, adminIdentity(r)); err != nil {
+		quarantineErrorResponse(w, err, "Failed to destroy quarantined content")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
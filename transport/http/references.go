@@ -0,0 +1,39 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// CreateReference handles cataloguing a document whose data stays at a URL
+// of another system
+func (h *ContentHandler) CreateReference(w http.ResponseWriter, r *http.Request) {
+	var request createReferenceRequest
+	if !decodeRequest(w, r, &request) {
+		return
+	}
+
+	content, err := h.contentService.CreateReference(r.Context(), request.input(requestTenant(r)))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput), errors.Is(err, service.ErrForbiddenAddress):
+			invalidInputResponse(w, err)
+		case errors.Is(err, service.ErrTenantNotFound):
+			errorResponse(w, http.StatusBadRequest, "Unknown tenant")
+		case errors.Is(err, service.ErrAttachmentLimit), errors.Is(err, service.ErrExternalIDExists):
+			errorResponse(w, http.StatusConflict, err.Error())
+		case errors.Is(err, service.ErrReferenceUnreachable):
+			errorResponse(w, http.StatusUnprocessableEntity, err.Error())
+		default:
+			errorResponse(w, http.StatusInternalServerError, "Failed to create reference")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newContentResponse(content))
+}
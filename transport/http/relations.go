@@ -0,0 +1,134 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// relationErrorResponse maps relation errors to HTTP errors
+func relationErrorResponse(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrContentNotFound):
+		errorResponse(w, http.StatusNotFound, "Content not found")
+	case errors.Is(err, service.ErrRelationNotFound):
+		errorResponse(w, http.StatusNotFound, "Relation not found")
+	case errors.Is(err, service.ErrRelationExists):
+		errorResponse(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrInvalidInput):
+		invalidInputResponse(w, err)
+	default:
+		errorResponse(w, http.StatusInternalServerError, message)
+	}
+}
+
+// relationDirection returns the direction query parameter, both by default
+func relationDirection(r *http.Request) model.RelationDirection {
+	if direction := r.URL.Query().Get("direction"); direction != "" {
+		return model.RelationDirection(direction)
+	}
+	return model.RelationsBoth
+}
+
+// CreateRelation handles relating a content item to another
+func (h *ContentHandler) CreateRelation(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	var input service.CreateRelationInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	input.SourceID = id
+
+	relation, err := h.contentService.CreateRelation(r.Context(), input)
+	if err != nil {
+		relationErrorResponse(w, err, "Failed to create relation")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(relation)
+}
+
+// ListRelations handles listing the relations of a content item
+func (h *ContentHandler) ListRelations(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	relationType := model.RelationType(r.URL.Query().Get("type"))
+	relations, err := h.contentService.ListRelations(r.Context(), id, relationDirection(r), relationType)
+	if err != nil {
+		relationErrorResponse(w, err, "Failed to list relations")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items": relations,
+	})
+}
+
+// GetRelationGraph handles traversing the relations of a content item
+func (h *ContentHandler) GetRelationGraph(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	input := service.RelationGraphInput{
+		Direction: relationDirection(r),
+		Type:      model.RelationType(r.URL.Query().Get("type")),
+	}
+	if depth := r.URL.Query().Get("depth"); depth != "" {
+		if input.Depth, err = strconv.Atoi(depth); err != nil || input.Depth <= 0 {
+			errorResponse(w, http.StatusBadRequest, "Invalid depth")
+			return
+		}
+	}
+
+	graph, err := h.contentService.TraverseRelations(r.Context(), id, input)
+	if err != nil {
+		relationErrorResponse(w, err, "Failed to traverse relations")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(graph)
+}
+
+// DeleteRelation handles removing a relation of a content item
+func (h *ContentHandler) DeleteRelation(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+	relationID, err := uuid.Parse(chi.URLParam(r, "relationID"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid relation ID")
+		return
+	}
+
+	if err := h.contentService.DeleteRelation(r.Context(), id, relationID); err != nil {
+		relationErrorResponse(w, err, "Failed to delete relation")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
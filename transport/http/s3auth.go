@@ -0,0 +1,276 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	sigV4Algorithm = "AWS4-HMAC-SHA256"
+
+	// Values of x-amz-content-sha256 that don't carry the payload hash
+	unsignedPayload          = "UNSIGNED-PAYLOAD"
+	streamingSignedPayload   = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	streamingUnsignedTrailer = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+
+	// maxRequestSkew is how far the signing time of a request may be from now
+	maxRequestSkew = 15 * time.Minute
+)
+
+var (
+	errSignatureMismatch = errors.New("the request signature does not match")
+	errPayloadMismatch   = errors.New("the payload does not match its signed hash")
+)
+
+// sigV4Request is the verified signature of an S3 request
+type sigV4Request struct {
+	accessKey   string
+	signingKey  []byte
+	amzDate     string
+	scope       string
+	signature   string
+	payloadHash string
+}
+
+// verifySigV4 checks the AWS Signature Version 4 Authorization header of a
+// request against the credentials of the gateway
+func verifySigV4(r *http.Request, accessKey, secretKey, region string, now time.Time) (*sigV4Request, error) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, sigV4Algorithm+" ") {
+		return nil, errors.New("only AWS Signature Version 4 authorization is supported")
+	}
+
+	fields := make(map[string]string)
+	for _, field := range strings.Split(strings.TrimPrefix(auth, sigV4Algorithm+" "), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+		fields[name] = value
+	}
+	credential := strings.Split(fields["Credential"], "/")
+	if len(credential) != 5 || credential[3] != "s3" || credential[4] != "aws4_request" {
+		return nil, errors.New("malformed credential")
+	}
+	if credential[0] != accessKey {
+		return nil, errors.New("unknown access key")
+	}
+	if credential[2] != region {
+		return nil, fmt.Errorf("the region %q is wrong; expecting %q", credential[2], region)
+	}
+
+	amzDate := r.Header.Get("X-Amz-Date")
+	signedAt, err := time.Parse("20060102T150405Z", amzDate)
+	if err != nil || !strings.HasPrefix(amzDate, credential[1]) {
+		return nil, errors.New("missing or malformed X-Amz-Date")
+	}
+	if skew := now.Sub(signedAt); skew > maxRequestSkew || skew < -maxRequestSkew {
+		return nil, errors.New("the difference between the request time and the current time is too large")
+	}
+
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	if payloadHash == "" {
+		return nil, errors.New("missing X-Amz-Content-Sha256")
+	}
+
+	signedHeaders := strings.Split(fields["SignedHeaders"], ";")
+	if !slices.Contains(signedHeaders, "host") {
+		return nil, errors.New("the host header must be signed")
+	}
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		s3EscapePath(r.URL.Path),
+		canonicalQuery(r.URL.Query()),
+		canonicalHeaders(r, signedHeaders),
+		fields["SignedHeaders"],
+		payloadHash,
+	}, "\n")
+
+	request := &sigV4Request{
+		accessKey:   credential[0],
+		amzDate:     amzDate,
+		scope:       strings.Join(credential[1:], "/"),
+		payloadHash: payloadHash,
+	}
+	request.signingKey = hmacSHA256([]byte("AWS4"+secretKey), credential[1])
+	for _, part := range credential[2:] {
+		request.signingKey = hmacSHA256(request.signingKey, part)
+	}
+	request.signature = request.sign(sigV4Algorithm, sha256Hex([]byte(canonicalRequest)))
+
+	if !hmac.Equal([]byte(request.signature), []byte(fields["Signature"])) {
+		return nil, errSignatureMismatch
+	}
+	return request, nil
+}
+
+// sign signs a string to sign made of the algorithm, the request date and
+// scope, and the given lines
+func (s *sigV4Request) sign(algorithm string, lines ...string) string {
+	stringToSign := strings.Join(append([]string{algorithm, s.amzDate, s.scope}, lines...), "\n")
+	return hex.EncodeToString(hmacSHA256(s.signingKey, stringToSign))
+}
+
+// body returns the payload of a signed request, decoding aws-chunked bodies
+// and failing the read if the payload doesn't match what was signed
+func (s *sigV4Request) body(r *http.Request) (io.Reader, error) {
+	switch {
+	case s.payloadHash == unsignedPayload:
+		return r.Body, nil
+	case s.payloadHash == streamingSignedPayload:
+		return &chunkedReader{r: bufio.NewReader(r.Body), request: s, previous: s.signature}, nil
+	case s.payloadHash == streamingUnsignedTrailer:
+		return &chunkedReader{r: bufio.NewReader(r.Body)}, nil
+	case strings.HasPrefix(s.payloadHash, "STREAMING-"):
+		return nil, fmt.Errorf("payload signing %s is not supported", s.payloadHash)
+	}
+	return &hashVerifyingReader{r: r.Body, hash: sha256.New(), expected: s.payloadHash}, nil
+}
+
+// hashVerifyingReader fails at EOF if the data read doesn't have the expected SHA-256
+type hashVerifyingReader struct {
+	r        io.Reader
+	hash     hash.Hash
+	expected string
+}
+
+func (v *hashVerifyingReader) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	v.hash.Write(p[:n])
+	if err == io.EOF && hex.EncodeToString(v.hash.Sum(nil)) != v.expected {
+		return n, errPayloadMismatch
+	}
+	return n, err
+}
+
+// chunkedReader decodes an aws-chunked body. With a request set, the
+// signature of each chunk is verified against the previous one.
+type chunkedReader struct {
+	r         *bufio.Reader
+	request   *sigV4Request
+	previous  string
+	remaining []byte
+	done      bool
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	for len(c.remaining) == 0 {
+		if c.done {
+			return 0, io.EOF
+		}
+		if err := c.nextChunk(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, c.remaining)
+	c.remaining = c.remaining[n:]
+	return n, nil
+}
+
+// maxChunkSize bounds the memory used to verify a single chunk
+const maxChunkSize = 16 << 20
+
+// nextChunk reads and verifies the next chunk of the body
+func (c *chunkedReader) nextChunk() error {
+	header, err := c.r.ReadString('\n')
+	if err != nil {
+		return io.ErrUnexpectedEOF
+	}
+	sizeHex, extension, _ := strings.Cut(strings.TrimRight(header, "\r\n"), ";")
+	size, err := strconv.ParseInt(sizeHex, 16, 64)
+	if err != nil || size < 0 || size > maxChunkSize {
+		return errors.New("malformed aws-chunked body")
+	}
+
+	chunk := make([]byte, size)
+	if _, err := io.ReadFull(c.r, chunk); err != nil {
+		return io.ErrUnexpectedEOF
+	}
+
+	if c.request != nil {
+		signature := strings.TrimPrefix(extension, "chunk-signature=")
+		expected := c.request.sign(sigV4Algorithm+"-PAYLOAD", c.previous, sha256Hex(nil), sha256Hex(chunk))
+		if !hmac.Equal([]byte(signature), []byte(expected)) {
+			return errPayloadMismatch
+		}
+		c.previous = signature
+	}
+
+	if size == 0 {
+		// Trailing headers, e.g. checksums, follow the last chunk and are not used
+		c.done = true
+		return nil
+	}
+	if crlf, err := c.r.Peek(2); err == nil && bytes.Equal(crlf, []byte("\r\n")) {
+		c.r.Discard(2)
+	}
+	c.remaining = chunk
+	return nil
+}
+
+// s3EscapePath encodes a path as S3 canonical requests do: every byte except
+// unreserved characters and slashes is percent-encoded
+func s3EscapePath(path string) string {
+	return strings.ReplaceAll(s3Escape(path), "%2F", "/")
+}
+
+// s3Escape percent-encodes everything but RFC 3986 unreserved characters
+func s3Escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// canonicalQuery encodes query parameters sorted by name and value
+func canonicalQuery(query url.Values) string {
+	var pairs []string
+	for name, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, s3Escape(name)+"="+s3Escape(value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// canonicalHeaders lists the signed headers of a request with their trimmed values
+func canonicalHeaders(r *http.Request, signedHeaders []string) string {
+	var b strings.Builder
+	for _, name := range signedHeaders {
+		var value string
+		switch name {
+		case "host":
+			value = r.Host
+		case "content-length":
+			value = strconv.FormatInt(r.ContentLength, 10)
+		default:
+			var values []string
+			for _, v := range r.Header.Values(name) {
+				values = append(values, strings.Join(strings.Fields(v), " "))
+			}
+			value = strings.Join(values, ",")
+		}
+		b.WriteString(name + ":" + value + "\n")
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,456 @@
+package http
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/service"
+	"github.com/livefire2015/simple-contents/storage"
+)
+
+// s3Namespace is the XML namespace of S3 responses
+const s3Namespace = "http://s3.amazonaws.com/doc/2006-03-01/"
+
+// S3GatewayConfig configures the S3-compatible gateway
+type S3GatewayConfig struct {
+	AccessKey string
+	SecretKey string
+	Region    string // us-east-1 if empty
+	Buckets   []service.ObjectBucket
+	Throttle  *DownloadThrottle // Optional bandwidth limits for GetObject
+}
+
+// S3GatewayHandler serves a minimal S3-compatible API (path-style
+// PutObject, GetObject, HeadObject, DeleteObject, ListObjectsV2 and
+// ListBuckets) so S3 tools can write content that is catalogued like uploads
+type S3GatewayHandler struct {
+	contentService *service.ContentService
+	config         S3GatewayConfig
+	buckets        map[string]service.ObjectBucket
+}
+
+// NewS3GatewayHandler creates a new S3-compatible gateway handler.
+// Requests must be signed with Signature Version 4 using the configured credentials.
+func NewS3GatewayHandler(contentService *service.ContentService, config S3GatewayConfig) *S3GatewayHandler {
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	buckets := make(map[string]service.ObjectBucket, len(config.Buckets))
+	for _, bucket := range config.Buckets {
+		buckets[bucket.Name] = bucket
+	}
+	return &S3GatewayHandler{
+		contentService: contentService,
+		config:         config,
+		buckets:        buckets,
+	}
+}
+
+// RegisterRoutes registers the S3 API routes
+func (h *S3GatewayHandler) RegisterRoutes(r chi.Router) {
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recoverer)
+	r.Use(h.requireSignature)
+
+	r.Get("/", h.ListBuckets)
+	r.Route("/{bucket}", func(r chi.Router) {
+		r.Head("/", h.HeadBucket)
+		r.Get("/", h.ListObjects)
+		r.Put("/*", h.PutObject)
+		r.Get("/*", h.GetObject)
+		r.Head("/*", h.GetObject)
+		r.Delete("/*", h.DeleteObject)
+	})
+}
+
+// s3Error is the XML error body of the S3 API
+type s3Error struct {
+	XMLName  xml.Name `xml:"Error"`
+	Code     string   `xml:"Code"`
+	Message  string   `xml:"Message"`
+	Resource string   `xml:"Resource,omitempty"`
+}
+
+// s3ErrorResponse writes an S3 error. HEAD responses carry no body.
+func s3ErrorResponse(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		xmlBody(w, s3Error{Code: code, Message: message, Resource: r.URL.Path})
+	}
+}
+
+// xmlResponse writes a successful S3 XML response
+func xmlResponse(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	xmlBody(w, v)
+}
+
+func xmlBody(w io.Writer, v interface{}) {
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(v)
+}
+
+type s3SignatureKey struct{}
+
+// requireSignature rejects requests that are not signed with the gateway's credentials
+func (h *S3GatewayHandler) requireSignature(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature, err := verifySigV4(r, h.config.AccessKey, h.config.SecretKey, h.config.Region, time.Now())
+		if err != nil {
+			code := "AccessDenied"
+			if errors.Is(err, errSignatureMismatch) {
+				code = "SignatureDoesNotMatch"
+			}
+			s3ErrorResponse(w, r, http.StatusForbidden, code, err.Error())
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), s3SignatureKey{}, signature)))
+	})
+}
+
+// requestSignature returns the verified signature of a request
+func requestSignature(r *http.Request) *sigV4Request {
+	signature, _ := r.Context().Value(s3SignatureKey{}).(*sigV4Request)
+	return signature
+}
+
+// bucket returns the mapping of the request's bucket, writing NoSuchBucket if it is unknown
+func (h *S3GatewayHandler) bucket(w http.ResponseWriter, r *http.Request) (service.ObjectBucket, bool) {
+	bucket, ok := h.buckets[chi.URLParam(r, "bucket")]
+	if !ok {
+		s3ErrorResponse(w, r, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist")
+	}
+	return bucket, ok
+}
+
+// objectKey returns the object key of a request from its unescaped path
+func objectKey(r *http.Request) string {
+	return strings.TrimPrefix(r.URL.Path, "/"+chi.URLParam(r, "bucket")+"/")
+}
+
+// objectErrorResponse maps content service errors to S3 errors
+func objectErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrObjectNotFound):
+		s3ErrorResponse(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist")
+	case errors.Is(err, service.ErrInvalidInput):
+		s3ErrorResponse(w, r, http.StatusBadRequest, "InvalidArgument", err.Error())
+	case errors.Is(err, errPayloadMismatch):
+		s3ErrorResponse(w, r, http.StatusBadRequest, "XAmzContentSHA256Mismatch", err.Error())
+	case errors.Is(err, service.ErrQuotaExceeded):
+		s3ErrorResponse(w, r, http.StatusForbidden, "QuotaExceeded", err.Error())
+	case errors.Is(err, service.ErrAttachmentLimit), errors.Is(err, service.ErrScanPending):
+		s3ErrorResponse(w, r, http.StatusConflict, "OperationAborted", err.Error())
+	case errors.Is(err, service.ErrContentRetained), errors.Is(err, service.ErrContentQuarantined):
+		s3ErrorResponse(w, r, http.StatusForbidden, "AccessDenied", err.Error())
+	case errors.Is(err, service.ErrStorageUnavailable):
+		setRetryAfter(w, err)
+		s3ErrorResponse(w, r, http.StatusServiceUnavailable, "ServiceUnavailable", err.Error())
+	default:
+		log.Printf("S3 gateway error: %v", err)
+		s3ErrorResponse(w, r, http.StatusInternalServerError, "InternalError", "We encountered an internal error. Please try again.")
+	}
+}
+
+type s3Bucket struct {
+	Name         string `xml:"Name"`
+	CreationDate string `xml:"CreationDate"`
+}
+
+type listAllMyBucketsResult struct {
+	XMLName xml.Name   `xml:"ListAllMyBucketsResult"`
+	Xmlns   string     `xml:"xmlns,attr"`
+	Owner   s3Owner    `xml:"Owner"`
+	Buckets []s3Bucket `xml:"Buckets>Bucket"`
+}
+
+type s3Owner struct {
+	ID string `xml:"ID"`
+}
+
+// ListBuckets handles listing the configured buckets
+func (h *S3GatewayHandler) ListBuckets(w http.ResponseWriter, r *http.Request) {
+	result := listAllMyBucketsResult{Xmlns: s3Namespace, Owner: s3Owner{ID: h.config.AccessKey}}
+	for _, bucket := range h.config.Buckets {
+		result.Buckets = append(result.Buckets, s3Bucket{Name: bucket.Name, CreationDate: time.Unix(0, 0).UTC().Format(time.RFC3339)})
+	}
+	xmlResponse(w, result)
+}
+
+// HeadBucket handles checking that a bucket exists
+func (h *S3GatewayHandler) HeadBucket(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.bucket(w, r); ok {
+		w.Header().Set("X-Amz-Bucket-Region", h.config.Region)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+type s3Object struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int64  `xml:"Size"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+type s3CommonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+type listBucketResult struct {
+	XMLName               xml.Name         `xml:"ListBucketResult"`
+	Xmlns                 string           `xml:"xmlns,attr"`
+	Name                  string           `xml:"Name"`
+	Prefix                string           `xml:"Prefix"`
+	Delimiter             string           `xml:"Delimiter,omitempty"`
+	StartAfter            string           `xml:"StartAfter,omitempty"`
+	ContinuationToken     string           `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string           `xml:"NextContinuationToken,omitempty"`
+	KeyCount              int              `xml:"KeyCount"`
+	MaxKeys               int              `xml:"MaxKeys"`
+	IsTruncated           bool             `xml:"IsTruncated"`
+	Contents              []s3Object       `xml:"Contents"`
+	CommonPrefixes        []s3CommonPrefix `xml:"CommonPrefixes"`
+}
+
+// ListObjects handles ListObjectsV2
+func (h *S3GatewayHandler) ListObjects(w http.ResponseWriter, r *http.Request) {
+	bucket, ok := h.bucket(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	if query.Get("list-type") != "2" {
+		s3ErrorResponse(w, r, http.StatusNotImplemented, "NotImplemented", "Only ListObjectsV2 is supported")
+		return
+	}
+
+	input := service.ListObjectsInput{
+		Prefix:     query.Get("prefix"),
+		Delimiter:  query.Get("delimiter"),
+		StartAfter: query.Get("start-after"),
+	}
+	input.MaxKeys, _ = strconv.Atoi(query.Get("max-keys"))
+	token := query.Get("continuation-token")
+	if token != "" {
+		after, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil {
+			s3ErrorResponse(w, r, http.StatusBadRequest, "InvalidArgument", "The continuation token provided is incorrect")
+			return
+		}
+		if string(after) > input.StartAfter {
+			input.StartAfter = string(after)
+		}
+	}
+
+	objects, err := h.contentService.ListObjects(r.Context(), bucket, input)
+	if err != nil {
+		objectErrorResponse(w, r, err)
+		return
+	}
+
+	result := listBucketResult{
+		Xmlns:             s3Namespace,
+		Name:              bucket.Name,
+		Prefix:            input.Prefix,
+		Delimiter:         input.Delimiter,
+		StartAfter:        query.Get("start-after"),
+		ContinuationToken: token,
+		MaxKeys:           input.MaxKeys,
+		IsTruncated:       objects.IsTruncated,
+	}
+	if result.MaxKeys <= 0 || result.MaxKeys > 1000 {
+		result.MaxKeys = 1000
+	}
+	if objects.IsTruncated {
+		result.NextContinuationToken = base64.RawURLEncoding.EncodeToString([]byte(objects.NextStartAfter))
+	}
+	for _, content := range objects.Objects {
+		result.Contents = append(result.Contents, s3Object{
+			Key:          service.ObjectKey(content),
+			LastModified: content.UpdatedAt.UTC().Format("2006-01-02T15:04:05.000Z"),
+			ETag:         `"` + content.ETag + `"`,
+			Size:         content.FileSize,
+			StorageClass: "STANDARD",
+		})
+	}
+	for _, prefix := range objects.CommonPrefixes {
+		result.CommonPrefixes = append(result.CommonPrefixes, s3CommonPrefix{Prefix: prefix})
+	}
+	result.KeyCount = len(result.Contents) + len(result.CommonPrefixes)
+
+	xmlResponse(w, result)
+}
+
+// PutObject handles storing an object as content
+func (h *S3GatewayHandler) PutObject(w http.ResponseWriter, r *http.Request) {
+	bucket, ok := h.bucket(w, r)
+	if !ok {
+		return
+	}
+	if r.Header.Get("X-Amz-Copy-Source") != "" || r.URL.Query().Has("uploadId") || r.URL.Query().Has("partNumber") {
+		s3ErrorResponse(w, r, http.StatusNotImplemented, "NotImplemented", "Copies and multipart uploads are not supported")
+		return
+	}
+
+	signature := requestSignature(r)
+	data, err := signature.body(r)
+	if err != nil {
+		s3ErrorResponse(w, r, http.StatusNotImplemented, "NotImplemented", err.Error())
+		return
+	}
+
+	size := r.ContentLength
+	if decoded := r.Header.Get("X-Amz-Decoded-Content-Length"); decoded != "" {
+		size, err = strconv.ParseInt(decoded, 10, 64)
+		if err != nil {
+			s3ErrorResponse(w, r, http.StatusBadRequest, "InvalidArgument", "Invalid x-amz-decoded-content-length")
+			return
+		}
+	}
+	if size < 0 {
+		size = storage.UnknownSize
+	}
+
+	mimeType := r.Header.Get("Content-Type")
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+
+	content, err := h.contentService.PutObject(r.Context(), bucket, service.PutObjectInput{
+		Key:       objectKey(r),
+		MIMEType:  mimeType,
+		Size:      size,
+		Data:      data,
+		CreatedBy: signature.accessKey,
+	})
+	if err != nil {
+		objectErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", `"`+content.ETag+`"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetObject handles GetObject and HeadObject, with single byte ranges
+func (h *S3GatewayHandler) GetObject(w http.ResponseWriter, r *http.Request) {
+	bucket, ok := h.bucket(w, r)
+	if !ok {
+		return
+	}
+
+	object, err := h.contentService.GetObject(r.Context(), bucket, objectKey(r))
+	if err != nil {
+		objectErrorResponse(w, r, err)
+		return
+	}
+	writeObjectHeaders(w, object)
+
+	start, length, ranged := int64(0), object.FileSize, false
+	if header := r.Header.Get("Range"); header != "" {
+		start, length, ok = parseByteRange(header, object.FileSize)
+		if !ok {
+			w.Header().Set("Content-Range", "bytes */"+strconv.FormatInt(object.FileSize, 10))
+			s3ErrorResponse(w, r, http.StatusRequestedRangeNotSatisfiable, "InvalidRange", "The requested range is not satisfiable")
+			return
+		}
+		ranged = true
+		w.Header().Set("Content-Range", "bytes "+strconv.FormatInt(start, 10)+"-"+strconv.FormatInt(start+length-1, 10)+"/"+strconv.FormatInt(object.FileSize, 10))
+	}
+	w.Header().Set("Content-Length", strconv.FormatInt(length, 10))
+
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	data, _, err := h.contentService.GetContentData(r.Context(), object.ID)
+	if err != nil {
+		objectErrorResponse(w, r, err)
+		return
+	}
+	defer data.Close()
+
+	if ranged {
+		if _, err := io.CopyN(io.Discard, data, start); err != nil {
+			objectErrorResponse(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusPartialContent)
+	}
+	if _, err := io.CopyN(h.config.Throttle.writer(r.Context(), w, object.TenantID), data, length); err != nil {
+		log.Printf("Error streaming object %s: %v", object.ID, err)
+	}
+}
+
+// writeObjectHeaders sets the metadata headers of an object response.
+// Objects are always attachments, as the gateway shares the origin of the
+// API and browsers opening an object must not run active content.
+func writeObjectHeaders(w http.ResponseWriter, object *model.Content) {
+	w.Header().Set("Content-Type", object.MIMEType)
+	w.Header().Set("Content-Disposition", contentDisposition("attachment", object.FileName))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("ETag", `"`+object.ETag+`"`)
+	w.Header().Set("Last-Modified", object.UpdatedAt.UTC().Format(http.TimeFormat))
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("X-Amz-Meta-Content-Id", object.ID.String())
+}
+
+// parseByteRange parses a single-range Range header against an object size
+func parseByteRange(header string, size int64) (start, length int64, ok bool) {
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, false
+	}
+	first, last, _ := strings.Cut(spec, "-")
+	if first == "" {
+		// A suffix range: the last n bytes
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, false
+		}
+		n = min(n, size)
+		return size - n, n, size > 0
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return 0, 0, false
+	}
+	end := size - 1
+	if last != "" {
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+			return 0, 0, false
+		}
+		end = min(end, size-1)
+	}
+	return start, end - start + 1, true
+}
+
+// DeleteObject handles deleting an object. Like S3, deleting a missing key succeeds.
+func (h *S3GatewayHandler) DeleteObject(w http.ResponseWriter, r *http.Request) {
+	bucket, ok := h.bucket(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.contentService.DeleteObject(r.Context(), bucket, objectKey(r))
+	if err != nil && !errors.Is(err, service.ErrObjectNotFound) {
+		objectErrorResponse(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,153 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// savedSearchErrorResponse maps saved search service errors to HTTP responses
+func savedSearchErrorResponse(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		invalidInputResponse(w, err)
+	case errors.Is(err, service.ErrSavedSearchNotFound):
+		errorResponse(w, http.StatusNotFound, "Saved search not found")
+	case errors.Is(err, service.ErrSavedSearchExists):
+		errorResponse(w, http.StatusConflict, "Saved search already exists")
+	default:
+		errorResponse(w, http.StatusInternalServerError, message)
+	}
+}
+
+// savedSearchID parses the saved search ID of a request
+func savedSearchID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "searchID"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid saved search ID")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// CreateSavedSearch handles saving a content filter for the requesting principal
+func (h *ContentHandler) CreateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	var input service.SavedSearchInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	search, err := h.savedSearchService.CreateSavedSearch(r.Context(), requestPrincipal(r), requestTenant(r), input)
+	if err != nil {
+		savedSearchErrorResponse(w, err, "Failed to create saved search")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(search)
+}
+
+// ListSavedSearches handles listing the saved searches of the requesting principal
+func (h *ContentHandler) ListSavedSearches(w http.ResponseWriter, r *http.Request) {
+	options := listOptions(r)
+	searches, total, err := h.savedSearchService.ListSavedSearches(r.Context(), requestPrincipal(r), options)
+	if err != nil {
+		savedSearchErrorResponse(w, err, "Failed to list saved searches")
+		return
+	}
+	if searches == nil {
+		searches = []*model.SavedSearch{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items":      searches,
+		"totalCount": total,
+		"page":       options.Page,
+		"pageSize":   options.PageSize,
+	})
+}
+
+// GetSavedSearch handles retrieving a saved search
+func (h *ContentHandler) GetSavedSearch(w http.ResponseWriter, r *http.Request) {
+	id, ok := savedSearchID(w, r)
+	if !ok {
+		return
+	}
+
+	search, err := h.savedSearchService.GetSavedSearch(r.Context(), requestPrincipal(r), id)
+	if err != nil {
+		savedSearchErrorResponse(w, err, "Failed to get saved search")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(search)
+}
+
+// UpdateSavedSearch handles renaming or changing the filter of a saved search
+func (h *ContentHandler) UpdateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	id, ok := savedSearchID(w, r)
+	if !ok {
+		return
+	}
+
+	var input service.SavedSearchInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	search, err := h.savedSearchService.UpdateSavedSearch(r.Context(), requestPrincipal(r), id, input)
+	if err != nil {
+		savedSearchErrorResponse(w, err, "Failed to update saved search")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(search)
+}
+
+// DeleteSavedSearch handles removing a saved search
+func (h *ContentHandler) DeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	id, ok := savedSearchID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.savedSearchService.DeleteSavedSearch(r.Context(), requestPrincipal(r), id); err != nil {
+		savedSearchErrorResponse(w, err, "Failed to delete saved search")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RunSavedSearch handles listing the content matching a saved search
+func (h *ContentHandler) RunSavedSearch(w http.ResponseWriter, r *http.Request) {
+	id, ok := savedSearchID(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	pageSize, _ := strconv.Atoi(query.Get("pageSize"))
+
+	result, err := h.savedSearchService.RunSavedSearch(r.Context(), requestPrincipal(r), id, page, pageSize)
+	if err != nil {
+		savedSearchErrorResponse(w, err, "Failed to run saved search")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newContentListResponse(result))
+}
@@ -0,0 +1,228 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// publicPageStyle is the only style of the pages of shares and upload
+// requests, allowed by its hash
+const publicPageStyle = `html,body{margin:0;height:100%;background:#f4f4f4;font-family:sans-serif}` +
+	`body{display:flex;align-items:center;justify-content:center}` +
+	`form,p{background:#fff;padding:2em;border-radius:4px}` +
+	`input{display:block;margin:1em 0}`
+
+var publicPageStyleHash = func() string {
+	sum := sha256.Sum256([]byte(publicPageStyle))
+	return "'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'"
+}()
+
+// publicPagePolicy is the Content-Security-Policy of the pages of shares and
+// upload requests: no scripts, and forms posting to the service only
+var publicPagePolicy = "default-src 'none'; style-src " + publicPageStyleHash + "; base-uri 'none'; form-action 'self'; frame-ancestors 'none'"
+
+// shareTemplate asks for the password of a share, or tells why it doesn't work
+var shareTemplate = template.Must(template.New("share").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Shared file</title>
+<style>{{.Style}}</style>
+</head>
+<body>
+{{- if .AskPassword}}
+<form method="post">
+<label for="password">This file is protected by a password.</label>
+<input type="password" id="password" name="password" autofocus required>
+{{- if .Message}}
+<em>{{.Message}}</em>
+{{- end}}
+<button type="submit">Download</button>
+</form>
+{{- else}}
+<p>{{.Message}}</p>
+{{- end}}
+</body>
+</html>
+`))
+
+// shareResponse is a share with the public URL to send
+type shareResponse struct {
+	*model.Share
+	URL               string `json:"url"`
+	PasswordProtected bool   `json:"password_protected"`
+}
+
+// newShareResponse returns the response of a share
+func (h *ContentHandler) newShareResponse(r *http.Request, share *model.Share) shareResponse {
+	return shareResponse{Share: share, URL: h.publicURL(r, "/s/"+share.Token), PasswordProtected: share.HasPassword()}
+}
+
+// shareErrorResponse maps share service errors to HTTP responses
+func shareErrorResponse(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		invalidInputResponse(w, err)
+	case errors.Is(err, service.ErrContentNotFound):
+		errorResponse(w, http.StatusNotFound, "Content not found")
+	case errors.Is(err, service.ErrShareNotFound):
+		errorResponse(w, http.StatusNotFound, "Share not found")
+	case errors.Is(err, service.ErrPIIRestricted), errors.Is(err, service.ErrContentQuarantined):
+		errorResponse(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrScanPending):
+		errorResponse(w, http.StatusConflict, err.Error())
+	default:
+		errorResponse(w, http.StatusInternalServerError, message)
+	}
+}
+
+// CreateShare handles creating a public share of a content item
+func (h *ContentHandler) CreateShare(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	var input service.ShareInput
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	share, err := h.shareService.CreateShare(r.Context(), id, requestPrincipal(r), input)
+	if err != nil {
+		shareErrorResponse(w, err, "Failed to create share")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(h.newShareResponse(r, share))
+}
+
+// ListShares handles listing the shares of a content item with their downloads
+func (h *ContentHandler) ListShares(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	options := listOptions(r)
+	shares, total, err := h.shareService.ListShares(r.Context(), id, options)
+	if err != nil {
+		shareErrorResponse(w, err, "Failed to list shares")
+		return
+	}
+
+	items := make([]shareResponse, len(shares))
+	for i, share := range shares {
+		items[i] = h.newShareResponse(r, share)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items":      items,
+		"totalCount": total,
+		"page":       options.Page,
+		"pageSize":   options.PageSize,
+	})
+}
+
+// RevokeShare handles revoking a share
+func (h *ContentHandler) RevokeShare(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	if err := h.shareService.RevokeShare(r.Context(), id, chi.URLParam(r, "token"), requestPrincipal(r)); err != nil {
+		shareErrorResponse(w, err, "Failed to revoke share")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// sharePage renders the share page with a status
+func sharePage(w http.ResponseWriter, status int, askPassword bool, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", publicPagePolicy)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(status)
+
+	err := shareTemplate.Execute(w, map[string]interface{}{
+		"AskPassword": askPassword,
+		"Message":     message,
+		"Style":       template.CSS(publicPageStyle),
+	})
+	if err != nil {
+		log.Printf("Error rendering share page: %v", err)
+	}
+}
+
+// RedeemShare handles opening a public share: it streams the data, or
+// answers with a page asking for the password of a protected share, which
+// is posted back as the password form field
+func (h *ContentHandler) RedeemShare(w http.ResponseWriter, r *http.Request) {
+	// Downloads are counted and may need a password, so nothing is cached
+	w.Header().Set("Cache-Control", "no-store")
+
+	password := ""
+	if r.Method == http.MethodPost {
+		password = r.PostFormValue("password")
+	}
+	share, content, err := h.shareService.RedeemShare(r.Context(), chi.URLParam(r, "token"), password)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrSharePasswordRequired):
+			sharePage(w, http.StatusUnauthorized, true, "")
+		case errors.Is(err, service.ErrShareWrongPassword):
+			sharePage(w, http.StatusUnauthorized, true, "Wrong password, please try again.")
+		case errors.Is(err, service.ErrShareNotFound), errors.Is(err, service.ErrContentNotFound):
+			sharePage(w, http.StatusNotFound, false, "This link does not exist.")
+		case errors.Is(err, service.ErrShareExpired), errors.Is(err, service.ErrShareRevoked), errors.Is(err, service.ErrShareExhausted):
+			sharePage(w, http.StatusGone, false, "This link is no longer available.")
+		case errors.Is(err, service.ErrContentQuarantined), errors.Is(err, service.ErrScanPending):
+			sharePage(w, http.StatusForbidden, false, "This file is not available for download.")
+		default:
+			log.Printf("Error redeeming share: %v", err)
+			sharePage(w, http.StatusInternalServerError, false, "The file could not be retrieved, please try again later.")
+		}
+		return
+	}
+
+	data, _, err := h.contentService.GetContentData(r.Context(), share.ContentID)
+	if errors.Is(err, service.ErrReferenceContent) {
+		http.Redirect(w, r, content.ReferenceURL, http.StatusFound)
+		return
+	}
+	if err != nil {
+		sharePage(w, http.StatusInternalServerError, false, "The file could not be retrieved, please try again later.")
+		return
+	}
+	defer data.Close()
+
+	setContentDataHeaders(w, r, content)
+	w.Header().Set("Content-Length", strconv.FormatInt(content.FileSize, 10))
+	io.Copy(h.throttle.writer(r.Context(), w, content.TenantID), data)
+}
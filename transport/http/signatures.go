@@ -0,0 +1,129 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/service"
+	"github.com/livefire2015/simple-contents/signature"
+)
+
+// maxSignatureWebhookSize bounds the body of provider notifications
+const maxSignatureWebhookSize = 1 << 20
+
+// signatureErrorResponse maps signature service errors to HTTP responses
+func signatureErrorResponse(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput), errors.Is(err, service.ErrUnknownProvider):
+		invalidInputResponse(w, err)
+	case errors.Is(err, service.ErrContentNotFound):
+		errorResponse(w, http.StatusNotFound, "Content not found")
+	case errors.Is(err, service.ErrSignatureNotFound):
+		errorResponse(w, http.StatusNotFound, "Signature request not found")
+	default:
+		errorResponse(w, http.StatusInternalServerError, message)
+	}
+}
+
+// RequestSignature handles sending a content item for e-signature
+func (h *ContentHandler) RequestSignature(w http.ResponseWriter, r *http.Request) {
+	contentID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	var input service.RequestSignatureInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	request, err := h.signatureService.RequestSignature(r.Context(), contentID, input)
+	if err != nil {
+		signatureErrorResponse(w, err, "Failed to send for signature")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(request)
+}
+
+// ListSignatureRequests handles listing the signature requests of a content item
+func (h *ContentHandler) ListSignatureRequests(w http.ResponseWriter, r *http.Request) {
+	contentID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid content ID")
+		return
+	}
+
+	options := listOptions(r)
+	requests, total, err := h.signatureService.ListSignatureRequests(r.Context(), contentID, options)
+	if err != nil {
+		signatureErrorResponse(w, err, "Failed to list signature requests")
+		return
+	}
+	if requests == nil {
+		requests = []*model.SignatureRequest{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items":      requests,
+		"totalCount": total,
+		"page":       options.Page,
+		"pageSize":   options.PageSize,
+	})
+}
+
+// GetSignatureRequest handles retrieving a signature request
+func (h *ContentHandler) GetSignatureRequest(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "requestID"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid signature request ID")
+		return
+	}
+
+	request, err := h.signatureService.GetSignatureRequest(r.Context(), id)
+	if err != nil {
+		signatureErrorResponse(w, err, "Failed to get signature request")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(request)
+}
+
+// SignatureWebhook handles status notifications from e-signature providers
+func (h *ContentHandler) SignatureWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSignatureWebhookSize))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	provider := chi.URLParam(r, "provider")
+	ack, err := h.signatureService.HandleWebhook(r.Context(), provider, r.Header, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUnknownProvider):
+			errorResponse(w, http.StatusNotFound, "Unknown signature provider")
+		case errors.Is(err, signature.ErrInvalidWebhook):
+			errorResponse(w, http.StatusUnauthorized, err.Error())
+		default:
+			log.Printf("Failed to process %s signature webhook: %v", provider, err)
+			errorResponse(w, http.StatusInternalServerError, "Failed to process webhook")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	io.WriteString(w, ack)
+}
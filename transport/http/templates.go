@@ -0,0 +1,153 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// templateErrorResponse maps template service errors to HTTP responses
+func templateErrorResponse(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		invalidInputResponse(w, err)
+	case errors.Is(err, service.ErrTemplateNotFound):
+		errorResponse(w, http.StatusNotFound, "Template not found")
+	case errors.Is(err, service.ErrTemplateExists):
+		errorResponse(w, http.StatusConflict, "Template already exists")
+	case errors.Is(err, service.ErrRenderingDisabled):
+		errorResponse(w, http.StatusNotImplemented, err.Error())
+	case errors.Is(err, service.ErrTenantNotFound):
+		errorResponse(w, http.StatusBadRequest, "Unknown tenant")
+	case errors.Is(err, service.ErrQuotaExceeded):
+		errorResponse(w, quotaExceededStatus(err), err.Error())
+	case errors.Is(err, service.ErrAttachmentLimit):
+		errorResponse(w, http.StatusConflict, err.Error())
+	default:
+		errorResponse(w, http.StatusInternalServerError, message)
+	}
+}
+
+// CreateTemplate handles registering a new template
+func (h *ContentHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	var input service.TemplateInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	template, err := h.templateService.CreateTemplate(r.Context(), input)
+	if err != nil {
+		templateErrorResponse(w, err, "Failed to create template")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(template)
+}
+
+// ListTemplates handles listing templates
+func (h *ContentHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	options := listOptions(r)
+	templates, total, err := h.templateService.ListTemplates(r.Context(), options)
+	if err != nil {
+		templateErrorResponse(w, err, "Failed to list templates")
+		return
+	}
+	if templates == nil {
+		templates = []*model.Template{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items":      templates,
+		"totalCount": total,
+		"page":       options.Page,
+		"pageSize":   options.PageSize,
+	})
+}
+
+// GetTemplate handles retrieving a template
+func (h *ContentHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	template, err := h.templateService.GetTemplate(r.Context(), chi.URLParam(r, "templateID"))
+	if err != nil {
+		templateErrorResponse(w, err, "Failed to get template")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(template)
+}
+
+// UpdateTemplate handles replacing a template
+func (h *ContentHandler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	var input service.TemplateInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	template, err := h.templateService.UpdateTemplate(r.Context(), chi.URLParam(r, "templateID"), input)
+	if err != nil {
+		templateErrorResponse(w, err, "Failed to update template")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(template)
+}
+
+// DeleteTemplate handles removing a template
+func (h *ContentHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	if err := h.templateService.DeleteTemplate(r.Context(), chi.URLParam(r, "templateID")); err != nil {
+		templateErrorResponse(w, err, "Failed to delete template")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PreviewTemplate handles rendering a template with a payload to HTML without storing it
+func (h *ContentHandler) PreviewTemplate(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Payload map[string]interface{} `json:"payload"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	doc, _, err := h.templateService.RenderHTML(r.Context(), chi.URLParam(r, "templateID"), input.Payload)
+	if err != nil {
+		templateErrorResponse(w, err, "Failed to render template")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(doc)
+}
+
+// RenderTemplate handles rendering a template to a stored document
+func (h *ContentHandler) RenderTemplate(w http.ResponseWriter, r *http.Request) {
+	var input service.RenderTemplateInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	input.TenantID = requestTenant(r)
+
+	content, err := h.templateService.RenderTemplate(r.Context(), chi.URLParam(r, "templateID"), input)
+	if err != nil {
+		templateErrorResponse(w, err, "Failed to render template")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newContentResponse(content))
+}
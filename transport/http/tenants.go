@@ -0,0 +1,164 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// tenantErrorResponse maps tenant service errors to HTTP responses
+func tenantErrorResponse(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		invalidInputResponse(w, err)
+	case errors.Is(err, service.ErrTenantNotFound):
+		errorResponse(w, http.StatusNotFound, "Tenant not found")
+	case errors.Is(err, service.ErrTenantExists):
+		errorResponse(w, http.StatusConflict, "Tenant already exists")
+	case errors.Is(err, service.ErrPrefixConflict):
+		errorResponse(w, http.StatusConflict, err.Error())
+	default:
+		errorResponse(w, http.StatusInternalServerError, message)
+	}
+}
+
+// CreateTenant handles registering a new tenant
+func (h *AdminHandler) CreateTenant(w http.ResponseWriter, r *http.Request) {
+	var input service.TenantInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenant, err := h.tenantService.CreateTenant(r.Context(), input)
+	if err != nil {
+		tenantErrorResponse(w, err, "Failed to create tenant")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tenant)
+}
+
+// ListTenants handles listing tenants
+func (h *AdminHandler) ListTenants(w http.ResponseWriter, r *http.Request) {
+	options := listOptions(r)
+	tenants, total, err := h.tenantService.ListTenants(r.Context(), options)
+	if err != nil {
+		tenantErrorResponse(w, err, "Failed to list tenants")
+		return
+	}
+	if tenants == nil {
+		tenants = []*model.Tenant{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items":      tenants,
+		"totalCount": total,
+		"page":       options.Page,
+		"pageSize":   options.PageSize,
+	})
+}
+
+// GetTenant handles retrieving a tenant
+func (h *AdminHandler) GetTenant(w http.ResponseWriter, r *http.Request) {
+	tenant, err := h.tenantService.GetTenant(r.Context(), chi.URLParam(r, "tenantID"))
+	if err != nil {
+		tenantErrorResponse(w, err, "Failed to get tenant")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tenant)
+}
+
+// UpdateTenant handles replacing the settings of a tenant
+func (h *AdminHandler) UpdateTenant(w http.ResponseWriter, r *http.Request) {
+	var input service.TenantInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenant, err := h.tenantService.UpdateTenant(r.Context(), chi.URLParam(r, "tenantID"), input)
+	if err != nil {
+		tenantErrorResponse(w, err, "Failed to update tenant")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tenant)
+}
+
+// DeleteTenant handles removing a tenant
+func (h *AdminHandler) DeleteTenant(w http.ResponseWriter, r *http.Request) {
+	if err := h.tenantService.DeleteTenant(r.Context(), chi.URLParam(r, "tenantID")); err != nil {
+		tenantErrorResponse(w, err, "Failed to delete tenant")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RecalculateTenantUsage handles recomputing a tenant's stored bytes from its content
+func (h *AdminHandler) RecalculateTenantUsage(w http.ResponseWriter, r *http.Request) {
+	tenant, err := h.contentService.RecalculateUsage(r.Context(), chi.URLParam(r, "tenantID"))
+	if err != nil {
+		tenantErrorResponse(w, err, "Failed to recalculate tenant usage")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tenant)
+}
+
+// TenantEvents handles streaming a tenant's quota warnings as Server-Sent Events
+func (h *AdminHandler) TenantEvents(w http.ResponseWriter, r *http.Request) {
+	tenantID := chi.URLParam(r, "tenantID")
+	if _, err := h.tenantService.GetTenant(r.Context(), tenantID); err != nil {
+		tenantErrorResponse(w, err, "Failed to get tenant")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		errorResponse(w, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	events, unsubscribe := h.contentService.SubscribeTenant(tenantID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event := <-events:
+			if err := writeSSE(w, string(event.Type), event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,213 @@
+package http
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// tenantBucketIdle is how long a tenant's bucket is kept after its last
+// download. An idle bucket refills within a second, so dropping it later
+// changes nothing but the memory held.
+const tenantBucketIdle = time.Minute
+
+// DownloadThrottleConfig limits the bandwidth used by content downloads
+type DownloadThrottleConfig struct {
+	PerRequestBytesPerSec int64 // Limit for a single download, 0 for unlimited
+	PerTenantBytesPerSec  int64 // Limit shared by all downloads of a tenant, 0 for unlimited
+}
+
+// DownloadThrottle hands out token buckets for downloads. One throttle is
+// shared by every handler that streams content data, so that a tenant's
+// limit covers the API, WebDAV, the S3 gateway and short links together.
+type DownloadThrottle struct {
+	config    DownloadThrottleConfig
+	mu        sync.Mutex
+	tenants   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// NewDownloadThrottle creates a throttle enforcing config
+func NewDownloadThrottle(config DownloadThrottleConfig) *DownloadThrottle {
+	return &DownloadThrottle{
+		config:    config,
+		tenants:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// buckets returns the buckets a download of content owned by tenantID must respect
+func (t *DownloadThrottle) buckets(tenantID string) []*tokenBucket {
+	if t == nil {
+		return nil
+	}
+
+	var buckets []*tokenBucket
+	if t.config.PerRequestBytesPerSec > 0 {
+		buckets = append(buckets, newTokenBucket(t.config.PerRequestBytesPerSec))
+	}
+	if t.config.PerTenantBytesPerSec > 0 {
+		buckets = append(buckets, t.tenantBucket(tenantID))
+	}
+	return buckets
+}
+
+// writer wraps w so that writes of content owned by tenantID respect the
+// request and tenant limits
+func (t *DownloadThrottle) writer(ctx context.Context, w io.Writer, tenantID string) io.Writer {
+	buckets := t.buckets(tenantID)
+	if len(buckets) == 0 {
+		return w
+	}
+	return &throttledWriter{ctx: ctx, w: w, buckets: buckets}
+}
+
+// reader wraps r so that reads of content owned by tenantID respect the
+// request and tenant limits, for handlers that don't write the data themselves
+func (t *DownloadThrottle) reader(ctx context.Context, r io.Reader, tenantID string) io.Reader {
+	buckets := t.buckets(tenantID)
+	if len(buckets) == 0 {
+		return r
+	}
+	return &throttledReader{ctx: ctx, r: r, buckets: buckets}
+}
+
+// tenantBucket returns the shared bucket of a tenant, dropping the buckets
+// of tenants that haven't downloaded for a while
+func (t *DownloadThrottle) tenantBucket(tenantID string) *tokenBucket {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(t.lastSweep) > tenantBucketIdle {
+		for id, bucket := range t.tenants {
+			if bucket.idleSince(now) > tenantBucketIdle {
+				delete(t.tenants, id)
+			}
+		}
+		t.lastSweep = now
+	}
+
+	bucket, exists := t.tenants[tenantID]
+	if !exists {
+		bucket = newTokenBucket(t.config.PerTenantBytesPerSec)
+		t.tenants[tenantID] = bucket
+	}
+	return bucket
+}
+
+// tokenBucket allows bytesPerSec bytes per second with a burst of one second
+type tokenBucket struct {
+	mu          sync.Mutex
+	bytesPerSec float64
+	tokens      float64
+	last        time.Time
+}
+
+func newTokenBucket(bytesPerSec int64) *tokenBucket {
+	return &tokenBucket{
+		bytesPerSec: float64(bytesPerSec),
+		tokens:      float64(bytesPerSec),
+		last:        time.Now(),
+	}
+}
+
+// idleSince returns how long ago the bucket was last used
+func (b *tokenBucket) idleSince(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return now.Sub(b.last)
+}
+
+// wait blocks until n bytes may be sent
+func (b *tokenBucket) wait(ctx context.Context, n int) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = min(b.bytesPerSec, b.tokens+now.Sub(b.last).Seconds()*b.bytesPerSec)
+	b.last = now
+	b.tokens -= float64(n)
+	deficit := -b.tokens
+	b.mu.Unlock()
+
+	if deficit <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(time.Duration(deficit / b.bytesPerSec * float64(time.Second)))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// chunkSize returns the largest transfer that fits in the bucket's burst
+func (b *tokenBucket) chunkSize() int {
+	return max(int(b.bytesPerSec), 1)
+}
+
+// chunkSize returns the largest transfer that fits in the burst of every bucket
+func chunkSize(buckets []*tokenBucket, n int) int {
+	for _, bucket := range buckets {
+		n = min(n, bucket.chunkSize())
+	}
+	return n
+}
+
+// waitAll blocks until every bucket allows n bytes
+func waitAll(ctx context.Context, buckets []*tokenBucket, n int) error {
+	for _, bucket := range buckets {
+		if err := bucket.wait(ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// throttledWriter delays writes until every bucket allows them
+type throttledWriter struct {
+	ctx     context.Context
+	w       io.Writer
+	buckets []*tokenBucket
+}
+
+func (t *throttledWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := chunkSize(t.buckets, len(p))
+		if err := waitAll(t.ctx, t.buckets, chunk); err != nil {
+			return written, err
+		}
+
+		n, err := t.w.Write(p[:chunk])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[chunk:]
+	}
+	return written, nil
+}
+
+// throttledReader delays reads until every bucket allows them
+type throttledReader struct {
+	ctx     context.Context
+	r       io.Reader
+	buckets []*tokenBucket
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return t.r.Read(p)
+	}
+	n, err := t.r.Read(p[:chunkSize(t.buckets, len(p))])
+	if n > 0 {
+		if waitErr := waitAll(t.ctx, t.buckets, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDownloadThrottleTenantBuckets(t *testing.T) {
+	throttle := NewDownloadThrottle(DownloadThrottleConfig{PerTenantBytesPerSec: 1024})
+
+	tests := []struct {
+		name       string
+		first      string
+		second     string
+		wantShared bool
+	}{
+		{name: "same tenant", first: "acme", second: "acme", wantShared: true},
+		{name: "other tenant", first: "acme", second: "globex", wantShared: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := throttle.buckets(tt.first)
+			second := throttle.buckets(tt.second)
+			if len(first) != 1 || len(second) != 1 {
+				t.Fatalf("got %d and %d buckets, want 1 each", len(first), len(second))
+			}
+			if shared := first[0] == second[0]; shared != tt.wantShared {
+				t.Errorf("bucket shared = %v, want %v", shared, tt.wantShared)
+			}
+		})
+	}
+}
+
+func TestDownloadThrottleExpiresIdleBuckets(t *testing.T) {
+	throttle := NewDownloadThrottle(DownloadThrottleConfig{PerTenantBytesPerSec: 1024})
+
+	idle := throttle.tenantBucket("idle")
+	idle.last = time.Now().Add(-2 * tenantBucketIdle)
+	throttle.lastSweep = time.Now().Add(-2 * tenantBucketIdle)
+
+	throttle.tenantBucket("active")
+	if _, exists := throttle.tenants["idle"]; exists {
+		t.Error("idle tenant bucket kept after sweep")
+	}
+	if _, exists := throttle.tenants["active"]; !exists {
+		t.Error("active tenant bucket missing")
+	}
+}
+
+func TestNilDownloadThrottle(t *testing.T) {
+	var throttle *DownloadThrottle
+	if buckets := throttle.buckets("acme"); buckets != nil {
+		t.Errorf("nil throttle returned %d buckets", len(buckets))
+	}
+}
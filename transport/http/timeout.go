@@ -0,0 +1,200 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RequestTimeoutConfig bounds how long requests run. A request past its
+// deadline has the contexts of its repository and storage calls cancelled,
+// and is answered with a 504 if nothing was sent yet.
+type RequestTimeoutConfig struct {
+	Metadata time.Duration // Routes reading and writing records, 0 for no deadline
+	Transfer time.Duration // Uploads, downloads and other long operations, 0 for no deadline
+	// Routes overrides the timeout of routes, keyed by chi pattern with an
+	// optional method, e.g. "POST /api/v1/contents/from-url". 0 removes the
+	// deadline of a route.
+	Routes      map[string]time.Duration
+	SlowRequest time.Duration // Requests taking longer are logged, 0 disables the log
+}
+
+// DefaultRequestTimeoutConfig returns a RequestTimeoutConfig letting
+// transfers of large files complete
+func DefaultRequestTimeoutConfig() RequestTimeoutConfig {
+	return RequestTimeoutConfig{
+		Metadata:    30 * time.Second,
+		Transfer:    time.Hour,
+		SlowRequest: 5 * time.Second,
+	}
+}
+
+// transferRoutes are the routes given the transfer timeout: those carrying
+// content data and the admin operations walking every record
+var transferRoutes = []string{
+	"POST /api/v1/contents/",
+	"POST /api/v1/contents/stream",
+	"POST /api/v1/contents/from-url",
+	"GET /api/v1/contents/{id}/data",
+	"HEAD /api/v1/contents/{id}/data",
+	"GET /api/v1/contents/{id}/preview",
+	"GET /api/v1/documents/{documentID}/data",
+	"POST /api/v1/templates/{templateID}/render",
+	"GET /dl/{token}",
+	"GET /s/{token}",
+	"POST /s/{token}",
+	"POST /u/{token}",
+	"POST " + uiPrefix + "/entities/{type}/{entityID}/files",
+	webDAVPrefix,
+	webDAVPrefix + "/*",
+	"GET /admin/v1/export",
+	"POST /admin/v1/import",
+	"GET /admin/v1/backup",
+	"POST /admin/v1/restore",
+	"POST /admin/v1/reconcile",
+	"POST /admin/v1/purge",
+	"POST /admin/v1/reindex",
+	"GET /admin/v1/quarantine/{id}/data",
+	"PUT /{bucket}/*",
+	"GET /{bucket}/*",
+}
+
+// streamingRoutes are the routes of event streams, which stay open as long
+// as their client listens
+var streamingRoutes = []string{
+	"GET /api/v1/contents/{id}/events",
+	"GET /api/v1/uploads/{uploadID}/events",
+	"GET /ws/entities/{type}/{entityID}",
+	"GET /admin/v1/tenants/{tenantID}/events",
+}
+
+// ParseRouteTimeouts parses comma-separated route timeouts given as
+// route=duration, e.g. "POST /api/v1/contents/from-url=2m,GET /dl/{token}=0"
+func ParseRouteTimeouts(s string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	if s == "" {
+		return timeouts, nil
+	}
+	for _, entry := range strings.Split(s, ",") {
+		route, value, ok := strings.Cut(entry, "=")
+		route = strings.TrimSpace(route)
+		if !ok || route == "" {
+			return nil, fmt.Errorf("route timeout %q is not route=duration", entry)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || timeout < 0 {
+			return nil, fmt.Errorf("invalid timeout of route %s: %q", route, value)
+		}
+		timeouts[route] = timeout
+	}
+	return timeouts, nil
+}
+
+// RequestTimeouts returns a middleware applying the timeouts of config to
+// the routes of router. It must be installed on router before its routes,
+// which it looks up when requests arrive.
+func RequestTimeouts(router chi.Routes, config RequestTimeoutConfig) func(http.Handler) http.Handler {
+	timeouts := make(map[string]time.Duration)
+	for _, route := range transferRoutes {
+		timeouts[route] = config.Transfer
+	}
+	for _, route := range streamingRoutes {
+		timeouts[route] = 0
+	}
+	for route, timeout := range config.Routes {
+		timeouts[route] = timeout
+	}
+
+	// The timeout of a route, by method and pattern or pattern alone
+	timeoutOf := func(method, pattern string) time.Duration {
+		if timeout, ok := timeouts[method+" "+pattern]; ok {
+			return timeout
+		}
+		if timeout, ok := timeouts[pattern]; ok {
+			return timeout
+		}
+		return config.Metadata
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			pattern := router.Find(chi.NewRouteContext(), r.Method, r.URL.Path)
+			timeout := timeoutOf(r.Method, pattern)
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			tw := &timeoutResponseWriter{ResponseWriter: w, ctx: ctx}
+			start := time.Now()
+
+			next.ServeHTTP(tw, r.WithContext(ctx))
+
+			// A handler that gave up without answering still gets a response
+			if tw.status == 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				tw.WriteHeader(http.StatusGatewayTimeout)
+			}
+			if elapsed := time.Since(start); config.SlowRequest > 0 && elapsed >= config.SlowRequest {
+				log.Printf("Slow request: %s %s (route %s) took %s, status %d", r.Method, r.URL.Path, pattern, elapsed.Round(time.Millisecond), tw.status)
+			}
+		})
+	}
+}
+
+// timeoutResponseWriter answers with a 504 instead of the server error a
+// handler sends once its deadline has passed, and records the status
+type timeoutResponseWriter struct {
+	http.ResponseWriter
+	ctx      context.Context
+	status   int
+	timedOut bool
+}
+
+func (w *timeoutResponseWriter) WriteHeader(code int) {
+	if w.status != 0 {
+		return
+	}
+	if code >= http.StatusInternalServerError && errors.Is(w.ctx.Err(), context.DeadlineExceeded) {
+		// The handler's body describes its own failure, not the timeout
+		w.timedOut = true
+		w.status = http.StatusGatewayTimeout
+		w.Header().Del("Content-Length")
+		w.Header().Set("Content-Type", "application/json")
+		w.ResponseWriter.WriteHeader(http.StatusGatewayTimeout)
+		json.NewEncoder(w.ResponseWriter).Encode(map[string]string{"error": "Request timed out"})
+		return
+	}
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *timeoutResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.timedOut {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends buffered data, for handlers streaming their response
+func (w *timeoutResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer
+func (w *timeoutResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
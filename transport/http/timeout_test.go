@@ -0,0 +1,131 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRequestTimeouts(t *testing.T) {
+	config := RequestTimeoutConfig{
+		Metadata: time.Minute,
+		Transfer: time.Hour,
+		Routes:   map[string]time.Duration{"GET /api/v1/contents/stats": 2 * time.Minute},
+	}
+
+	router := chi.NewRouter()
+	router.Use(RequestTimeouts(router, config))
+	var remaining time.Duration
+	var hasDeadline bool
+	record := func(w http.ResponseWriter, r *http.Request) {
+		var deadline time.Time
+		deadline, hasDeadline = r.Context().Deadline()
+		remaining = time.Until(deadline)
+	}
+	router.Route("/api/v1/contents", func(r chi.Router) {
+		r.Post("/", record)
+		r.Get("/stats", record)
+		r.Get("/{id}", record)
+		r.Get("/{id}/data", record)
+		r.Get("/{id}/events", record)
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   time.Duration // 0 for no deadline
+	}{
+		{name: "metadata", method: http.MethodGet, path: "/api/v1/contents/42", want: time.Minute},
+		{name: "upload", method: http.MethodPost, path: "/api/v1/contents/", want: time.Hour},
+		{name: "download", method: http.MethodGet, path: "/api/v1/contents/42/data", want: time.Hour},
+		{name: "event stream", method: http.MethodGet, path: "/api/v1/contents/42/events"},
+		{name: "configured route", method: http.MethodGet, path: "/api/v1/contents/stats", want: 2 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasDeadline = false
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+			if tt.want == 0 {
+				if hasDeadline {
+					t.Errorf("deadline in %s, want none", remaining)
+				}
+				return
+			}
+			if !hasDeadline || remaining > tt.want || remaining < tt.want-time.Second {
+				t.Errorf("deadline in %s (set %v), want %s", remaining, hasDeadline, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestTimeoutAnswersGatewayTimeout(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(RequestTimeouts(router, RequestTimeoutConfig{Metadata: 10 * time.Millisecond}))
+	router.Get("/hung", func(w http.ResponseWriter, r *http.Request) {
+		// A storage call giving up when the request's context is cancelled
+		<-r.Context().Done()
+		errorResponse(w, http.StatusInternalServerError, "Failed to retrieve content")
+	})
+	router.Get("/silent", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	router.Get("/fast", func(w http.ResponseWriter, r *http.Request) {
+		errorResponse(w, http.StatusInternalServerError, "Failed to retrieve content")
+	})
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{path: "/hung", want: http.StatusGatewayTimeout},
+		{path: "/silent", want: http.StatusGatewayTimeout},
+		{path: "/fast", want: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d; body %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestParseRouteTimeouts(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    map[string]time.Duration
+		wantErr bool
+	}{
+		{input: "", want: map[string]time.Duration{}},
+		{
+			input: "POST /api/v1/contents/from-url=2m, GET /dl/{token}=0",
+			want:  map[string]time.Duration{"POST /api/v1/contents/from-url": 2 * time.Minute, "GET /dl/{token}": 0},
+		},
+		{input: "/api/v1/contents/{id}", wantErr: true},
+		{input: "/api/v1/contents/{id}=soon", wantErr: true},
+		{input: "/api/v1/contents/{id}=-1s", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseRouteTimeouts(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRouteTimeouts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseRouteTimeouts() = %v, want %v", got, tt.want)
+			}
+			for route, timeout := range tt.want {
+				if got[route] != timeout {
+					t.Errorf("timeout of %s = %s, want %s", route, got[route], timeout)
+				}
+			}
+		})
+	}
+}
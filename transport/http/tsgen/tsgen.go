@@ -0,0 +1,652 @@
+// Package tsgen generates a typed TypeScript client from the OpenAPI
+// description of the content API. Each schema becomes a type and each
+// operation a method of the client, named after its operationId. Only the
+// parts of OpenAPI the spec uses are supported; anything else is an error
+// rather than a silently wrong type.
+package tsgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// object is a JSON object that keeps the order of its keys, so the
+// generated code follows the order of the spec
+type object struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+func (o *object) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return fmt.Errorf("expected a JSON object")
+	}
+	o.values = make(map[string]json.RawMessage)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		if _, seen := o.values[key]; !seen {
+			o.keys = append(o.keys, key)
+		}
+		o.values[key] = value
+	}
+	return nil
+}
+
+// decode decodes the value of a key into v
+func (o object) decode(key string, v interface{}) error {
+	return json.Unmarshal(o.values[key], v)
+}
+
+// typeList is the type of a schema, a single name or a list of them
+type typeList []string
+
+func (t *typeList) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*t = typeList{name}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+type schema struct {
+	Ref                  string          `json:"$ref"`
+	Type                 typeList        `json:"type"`
+	Format               string          `json:"format"`
+	Description          string          `json:"description"`
+	Enum                 []interface{}   `json:"enum"`
+	Items                *schema         `json:"items"`
+	Properties           object          `json:"properties"`
+	Required             []string        `json:"required"`
+	AdditionalProperties json.RawMessage `json:"additionalProperties"`
+	AllOf                []*schema       `json:"allOf"`
+	AnyOf                []*schema       `json:"anyOf"`
+	OneOf                []*schema       `json:"oneOf"`
+	Deprecated           bool            `json:"deprecated"`
+}
+
+type parameter struct {
+	Ref         string  `json:"$ref"`
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required"`
+	Description string  `json:"description"`
+	Deprecated  bool    `json:"deprecated"`
+	Schema      *schema `json:"schema"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool   `json:"required"`
+	Content  object `json:"content"`
+}
+
+type response struct {
+	Ref         string `json:"$ref"`
+	Description string `json:"description"`
+	Content     object `json:"content"`
+}
+
+type operation struct {
+	OperationID string       `json:"operationId"`
+	Summary     string       `json:"summary"`
+	Parameters  []*parameter `json:"parameters"`
+	RequestBody *requestBody `json:"requestBody"`
+	Responses   object       `json:"responses"`
+}
+
+type spec struct {
+	Info struct {
+		Title   string `json:"title"`
+		Version string `json:"version"`
+	} `json:"info"`
+	Paths      object `json:"paths"`
+	Components struct {
+		Schemas    object                `json:"schemas"`
+		Parameters map[string]*parameter `json:"parameters"`
+		Responses  map[string]*response  `json:"responses"`
+	} `json:"components"`
+}
+
+// methods are the HTTP methods of path items, in the order they are generated
+var methods = []string{"get", "put", "post", "delete", "patch"}
+
+// reservedNames are globals of TypeScript a schema type must not shadow;
+// schemas of these names get a Body suffix
+var reservedNames = map[string]bool{"Error": true, "Object": true, "Array": true, "Date": true, "Record": true, "Blob": true, "Response": true, "Request": true}
+
+var (
+	identifier    = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+	pathParameter = regexp.MustCompile(`\{([^}]+)\}`)
+)
+
+// generator writes the client of a spec
+type generator struct {
+	spec *spec
+	out  bytes.Buffer
+}
+
+// Generate returns the TypeScript client of an OpenAPI spec
+func Generate(data []byte) ([]byte, error) {
+	g := &generator{spec: &spec{}}
+	if err := json.Unmarshal(data, g.spec); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+	// The errors the client throws carry the body of error responses
+	if _, ok := g.spec.Components.Schemas.values["Error"]; !ok {
+		return nil, fmt.Errorf("the spec has no Error schema")
+	}
+
+	g.printf("// Code generated by go generate ./transport/http from openapi.json. DO NOT EDIT.\n")
+	g.printf("// %s %s\n\n", g.spec.Info.Title, g.spec.Info.Version)
+	if err := g.types(); err != nil {
+		return nil, err
+	}
+	g.printf("%s", runtime)
+	if err := g.client(); err != nil {
+		return nil, err
+	}
+	return g.out.Bytes(), nil
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.out, format, args...)
+}
+
+// typeName returns the TypeScript name of a schema
+func typeName(name string) string {
+	if reservedNames[name] {
+		return name + "Body"
+	}
+	return name
+}
+
+// refName returns the type of a reference to a component schema
+func refName(ref string) (string, error) {
+	name, ok := strings.CutPrefix(ref, "#/components/schemas/")
+	if !ok {
+		return "", fmt.Errorf("unsupported reference %q", ref)
+	}
+	return typeName(name), nil
+}
+
+// types writes a type per component schema
+func (g *generator) types() error {
+	schemas := g.spec.Components.Schemas
+	for _, name := range schemas.keys {
+		var s schema
+		if err := schemas.decode(name, &s); err != nil {
+			return fmt.Errorf("schema %s: %w", name, err)
+		}
+		tsType, err := g.typeOf(&s, "")
+		if err != nil {
+			return fmt.Errorf("schema %s: %w", name, err)
+		}
+		g.comment("", s.Description, false)
+		if strings.HasPrefix(tsType, "{") {
+			g.printf("export interface %s %s\n\n", typeName(name), tsType)
+		} else {
+			g.printf("export type %s = %s;\n\n", typeName(name), tsType)
+		}
+	}
+	return nil
+}
+
+// comment writes a doc comment, if there is anything to say
+func (g *generator) comment(indent, description string, deprecated bool) {
+	var lines []string
+	if description != "" {
+		lines = append(lines, description)
+	}
+	if deprecated {
+		lines = append(lines, "@deprecated")
+	}
+	switch len(lines) {
+	case 0:
+	case 1:
+		g.printf("%s/** %s */\n", indent, lines[0])
+	default:
+		g.printf("%s/**\n", indent)
+		for _, line := range lines {
+			g.printf("%s * %s\n", indent, line)
+		}
+		g.printf("%s */\n", indent)
+	}
+}
+
+// typeOf returns the TypeScript type of a schema, indenting the lines of
+// object types by indent
+func (g *generator) typeOf(s *schema, indent string) (string, error) {
+	if s == nil {
+		return "unknown", nil
+	}
+	if s.Ref != "" {
+		return refName(s.Ref)
+	}
+	if len(s.AllOf) > 0 {
+		parts, err := g.typesOf(s.AllOf, indent)
+		if err != nil {
+			return "", err
+		}
+		if len(s.Properties.keys) > 0 {
+			own, err := g.objectOf(s, indent)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, own)
+		}
+		return strings.Join(parts, " & "), nil
+	}
+	if alternatives := append(s.AnyOf, s.OneOf...); len(alternatives) > 0 {
+		parts, err := g.typesOf(alternatives, indent)
+		if err != nil {
+			return "", err
+		}
+		return strings.Join(parts, " | "), nil
+	}
+
+	if len(s.Enum) > 0 {
+		values := make([]string, len(s.Enum))
+		for i, value := range s.Enum {
+			literal, err := json.Marshal(value)
+			if err != nil {
+				return "", err
+			}
+			values[i] = string(literal)
+		}
+		return strings.Join(values, " | "), nil
+	}
+
+	types := s.Type
+	if len(types) == 0 {
+		types = typeList{"object"}
+	}
+	var parts []string
+	for _, t := range types {
+		var part string
+		switch t {
+		case "string":
+			part = "string"
+			if s.Format == "binary" {
+				part = "Blob"
+			}
+		case "integer", "number":
+			part = "number"
+		case "boolean":
+			part = "boolean"
+		case "null":
+			part = "null"
+		case "array":
+			items, err := g.typeOf(s.Items, indent)
+			if err != nil {
+				return "", err
+			}
+			if strings.ContainsAny(items, "|&") {
+				items = "(" + items + ")"
+			}
+			part = items + "[]"
+		case "object":
+			var err error
+			if part, err = g.objectOf(s, indent); err != nil {
+				return "", err
+			}
+		default:
+			return "", fmt.Errorf("unsupported type %q", t)
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " | "), nil
+}
+
+// typesOf returns the types of several schemas
+func (g *generator) typesOf(schemas []*schema, indent string) ([]string, error) {
+	types := make([]string, len(schemas))
+	for i, s := range schemas {
+		var err error
+		if types[i], err = g.typeOf(s, indent); err != nil {
+			return nil, err
+		}
+	}
+	return types, nil
+}
+
+// objectOf returns the TypeScript type of an object schema
+func (g *generator) objectOf(s *schema, indent string) (string, error) {
+	if len(s.Properties.keys) == 0 {
+		values := "unknown"
+		var additional schema
+		if len(s.AdditionalProperties) > 0 && json.Unmarshal(s.AdditionalProperties, &additional) == nil {
+			var err error
+			if values, err = g.typeOf(&additional, indent); err != nil {
+				return "", err
+			}
+		}
+		return "Record<string, " + values + ">", nil
+	}
+
+	required := make(map[string]bool, len(s.Required))
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	var b strings.Builder
+	b.WriteString("{\n")
+	inner := indent + "  "
+	for _, name := range s.Properties.keys {
+		var property schema
+		if err := s.Properties.decode(name, &property); err != nil {
+			return "", fmt.Errorf("property %s: %w", name, err)
+		}
+		propertyType, err := g.typeOf(&property, inner)
+		if err != nil {
+			return "", fmt.Errorf("property %s: %w", name, err)
+		}
+		var doc generator
+		doc.comment(inner, property.Description, property.Deprecated)
+		b.Write(doc.out.Bytes())
+		optional := "?"
+		if required[name] {
+			optional = ""
+		}
+		fmt.Fprintf(&b, "%s%s%s: %s;\n", inner, propertyName(name), optional, propertyType)
+	}
+	b.WriteString(indent + "}")
+	return b.String(), nil
+}
+
+// propertyName quotes names that aren't identifiers
+func propertyName(name string) string {
+	if identifier.MatchString(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+// client writes the class with a method per operation
+func (g *generator) client() error {
+	g.printf("/** Client of the %s */\n", g.spec.Info.Title)
+	g.printf("export class ContentsClient {\n")
+	g.printf("%s", clientRuntime)
+	for _, path := range g.spec.Paths.keys {
+		var item object
+		if err := g.spec.Paths.decode(path, &item); err != nil {
+			return fmt.Errorf("path %s: %w", path, err)
+		}
+		var shared []*parameter
+		if _, ok := item.values["parameters"]; ok {
+			if err := item.decode("parameters", &shared); err != nil {
+				return fmt.Errorf("path %s: %w", path, err)
+			}
+		}
+		for _, method := range methods {
+			if _, ok := item.values[method]; !ok {
+				continue
+			}
+			var op operation
+			if err := item.decode(method, &op); err != nil {
+				return fmt.Errorf("%s %s: %w", strings.ToUpper(method), path, err)
+			}
+			g.printf("\n")
+			if err := g.operation(path, method, &op, append(shared[:len(shared):len(shared)], op.Parameters...)); err != nil {
+				return fmt.Errorf("%s %s: %w", strings.ToUpper(method), path, err)
+			}
+		}
+	}
+	g.printf("}\n")
+	return nil
+}
+
+// operation writes the method of an operation
+func (g *generator) operation(path, method string, op *operation, parameters []*parameter) error {
+	if !identifier.MatchString(op.OperationID) {
+		return fmt.Errorf("operationId %q is missing or not an identifier", op.OperationID)
+	}
+
+	var pathParameters, queryParameters []*parameter
+	for _, p := range parameters {
+		if p.Ref != "" {
+			name, ok := strings.CutPrefix(p.Ref, "#/components/parameters/")
+			if !ok || g.spec.Components.Parameters[name] == nil {
+				return fmt.Errorf("unknown parameter %q", p.Ref)
+			}
+			p = g.spec.Components.Parameters[name]
+		}
+		switch p.In {
+		case "path":
+			pathParameters = append(pathParameters, p)
+		case "query":
+			queryParameters = append(queryParameters, p)
+		default:
+			return fmt.Errorf("unsupported parameter location %q of %s", p.In, p.Name)
+		}
+	}
+	// Path parameters are taken in the order the path names them
+	order := map[string]int{}
+	for i, match := range pathParameter.FindAllStringSubmatch(path, -1) {
+		order[match[1]] = i
+	}
+	if len(order) != len(pathParameters) {
+		return fmt.Errorf("path parameters don't match the path")
+	}
+	sort.SliceStable(pathParameters, func(i, j int) bool { return order[pathParameters[i].Name] < order[pathParameters[j].Name] })
+
+	var args []string
+	for _, p := range pathParameters {
+		if !identifier.MatchString(p.Name) {
+			return fmt.Errorf("path parameter %q is not an identifier", p.Name)
+		}
+		args = append(args, p.Name+": string")
+	}
+	if op.RequestBody != nil {
+		bodyType, err := g.bodyType(op.RequestBody)
+		if err != nil {
+			return err
+		}
+		optional := "?"
+		if op.RequestBody.Required {
+			optional = ""
+		}
+		args = append(args, "body"+optional+": "+bodyType)
+	}
+	if len(queryParameters) > 0 {
+		var b strings.Builder
+		b.WriteString("query: {\n")
+		for _, p := range queryParameters {
+			paramType, err := g.typeOf(p.Schema, "    ")
+			if err != nil {
+				return fmt.Errorf("parameter %s: %w", p.Name, err)
+			}
+			var doc generator
+			doc.comment("      ", p.Description, p.Deprecated)
+			b.Write(doc.out.Bytes())
+			fmt.Fprintf(&b, "      %s?: %s;\n", propertyName(p.Name), paramType)
+		}
+		b.WriteString("    } = {}")
+		args = append(args, b.String())
+	}
+	args = append(args, "init: RequestInit = {}")
+
+	resultType, err := g.resultType(op)
+	if err != nil {
+		return err
+	}
+
+	urlPath := "`" + pathParameter.ReplaceAllString(path, "${encodeURIComponent($1)}") + "`"
+	body, query := "undefined", "undefined"
+	if op.RequestBody != nil {
+		body = "body"
+	}
+	if len(queryParameters) > 0 {
+		query = "query"
+	}
+
+	g.comment("  ", op.Summary, false)
+	g.printf("  %s(\n", op.OperationID)
+	for _, arg := range args {
+		g.printf("    %s,\n", arg)
+	}
+	g.printf("  ): Promise<%s> {\n", resultType)
+	g.printf("    return this.request(%q, %s, %s, %s, init);\n", strings.ToUpper(method), urlPath, query, body)
+	g.printf("  }\n")
+	return nil
+}
+
+// bodyType returns the type of the body of a request, a union of the types
+// of its media types
+func (g *generator) bodyType(body *requestBody) (string, error) {
+	var types []string
+	for _, media := range body.Content.keys {
+		var m mediaType
+		if err := body.Content.decode(media, &m); err != nil {
+			return "", err
+		}
+		switch {
+		case media == "application/json":
+			t, err := g.typeOf(m.Schema, "    ")
+			if err != nil {
+				return "", err
+			}
+			types = append(types, t)
+		case media == "multipart/form-data":
+			types = append(types, "FormData")
+		case media == "*/*" || strings.HasPrefix(media, "application/octet-stream"):
+			types = append(types, "Blob | ArrayBuffer")
+		default:
+			return "", fmt.Errorf("unsupported request media type %q", media)
+		}
+	}
+	if len(types) == 0 {
+		return "", fmt.Errorf("request body without content")
+	}
+	return strings.Join(types, " | "), nil
+}
+
+// resultType returns the type of the JSON body of the first successful
+// response of an operation, void if it has none
+func (g *generator) resultType(op *operation) (string, error) {
+	for _, code := range op.Responses.keys {
+		if !strings.HasPrefix(code, "2") {
+			continue
+		}
+		var r response
+		if err := op.Responses.decode(code, &r); err != nil {
+			return "", err
+		}
+		if r.Ref != "" {
+			name, ok := strings.CutPrefix(r.Ref, "#/components/responses/")
+			if !ok || g.spec.Components.Responses[name] == nil {
+				return "", fmt.Errorf("unknown response %q", r.Ref)
+			}
+			r = *g.spec.Components.Responses[name]
+		}
+		if _, ok := r.Content.values["application/json"]; !ok {
+			return "void", nil
+		}
+		var m mediaType
+		if err := r.Content.decode("application/json", &m); err != nil {
+			return "", err
+		}
+		return g.typeOf(m.Schema, "  ")
+	}
+	return "void", nil
+}
+
+// runtime is the part of the client that doesn't depend on the spec,
+// written before the client class
+const runtime = `/** Options of a ContentsClient */
+export interface ClientOptions {
+  /** Root of the service, e.g. "https://contents.example.com" */
+  baseUrl: string;
+  /** Sent as X-Tenant-ID */
+  tenantId?: string;
+  /** Sent as X-Principal-ID */
+  principalId?: string;
+  /** Headers sent with every request, e.g. for a gateway in front of the service */
+  headers?: Record<string, string>;
+  /** The fetch of the global scope if not set */
+  fetch?: typeof fetch;
+}
+
+/** An error response of the API */
+export class ApiError extends Error {
+  constructor(
+    readonly status: number,
+    readonly body: ErrorBody | undefined,
+  ) {
+    super(body?.error ?? "content API returned " + status);
+    this.name = "ApiError";
+  }
+}
+
+type QueryValue = string | number | boolean | undefined;
+
+`
+
+// clientRuntime is the constructor and request method of the client class
+const clientRuntime = `  constructor(private readonly options: ClientOptions) {}
+
+  private async request<T>(
+    method: string,
+    path: string,
+    query: Record<string, QueryValue> | undefined,
+    body: unknown,
+    init: RequestInit,
+  ): Promise<T> {
+    const url = new URL(this.options.baseUrl.replace(/\/+$/, "") + path);
+    for (const [name, value] of Object.entries(query ?? {})) {
+      if (value !== undefined) {
+        url.searchParams.set(name, String(value));
+      }
+    }
+
+    const headers = new Headers(this.options.headers);
+    if (this.options.tenantId) {
+      headers.set("X-Tenant-ID", this.options.tenantId);
+    }
+    if (this.options.principalId) {
+      headers.set("X-Principal-ID", this.options.principalId);
+    }
+    new Headers(init.headers).forEach((value, name) => headers.set(name, value));
+
+    // Forms and raw data are sent as they are, anything else as JSON
+    let payload: BodyInit | undefined;
+    if (body === undefined) {
+      payload = undefined;
+    } else if (body instanceof FormData || body instanceof Blob || body instanceof ArrayBuffer) {
+      payload = body;
+    } else {
+      payload = JSON.stringify(body);
+      headers.set("Content-Type", "application/json");
+    }
+
+    const fetchImpl = this.options.fetch ?? fetch;
+    const response = await fetchImpl(url, { ...init, method, headers, body: payload });
+    const text = await response.text();
+    if (!response.ok) {
+      let error: ErrorBody | undefined;
+      try {
+        error = JSON.parse(text) as ErrorBody;
+      } catch {
+        error = undefined;
+      }
+      throw new ApiError(response.status, error);
+    }
+    return (text ? JSON.parse(text) : undefined) as T;
+  }
+`
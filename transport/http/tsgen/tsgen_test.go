@@ -0,0 +1,123 @@
+package tsgen
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestClientUpToDate checks that the committed client was generated from
+// the current spec
+func TestClientUpToDate(t *testing.T) {
+	spec, err := os.ReadFile("../openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Generate(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("../../../clients/ts/src/client.ts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("clients/ts/src/client.ts is out of date, run make ts-client")
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	const components = `"components": {"schemas": {
+		"Error": {"type": "object", "required": ["error"], "properties": {"error": {"type": "string"}}},
+		"Item": {
+			"description": "An item",
+			"type": "object",
+			"required": ["id", "tags"],
+			"properties": {
+				"id": {"type": "string"},
+				"size": {"type": ["integer", "null"]},
+				"state": {"type": "string", "enum": ["new", "old"]},
+				"tags": {"type": "array", "items": {"type": "string"}},
+				"content-type": {"type": "string", "deprecated": true}
+			}
+		},
+		"Items": {"type": "array", "items": {"anyOf": [{"$ref": "#/components/schemas/Item"}, {"type": "null"}]}}
+	}}`
+
+	tests := []struct {
+		name    string
+		paths   string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:  "types",
+			paths: `{}`,
+			want: []string{
+				"export interface ErrorBody {\n  error: string;\n}",
+				"/** An item */\nexport interface Item {\n  id: string;\n  size?: number | null;\n  state?: \"new\" | \"old\";\n  tags: string[];\n  /** @deprecated */\n  \"content-type\"?: string;\n}",
+				"export type Items = (Item | null)[];",
+			},
+		},
+		{
+			name: "operation",
+			paths: `{"/items/{id}": {
+				"parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+				"put": {
+					"operationId": "putItem",
+					"summary": "Replace an item",
+					"parameters": [{"name": "force", "in": "query", "schema": {"type": "boolean"}}],
+					"requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Item"}}}},
+					"responses": {"200": {"description": "OK", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Item"}}}}}
+				}
+			}}`,
+			want: []string{
+				"  /** Replace an item */\n  putItem(\n    id: string,\n    body: Item,\n    query: {\n      force?: boolean;\n    } = {},\n    init: RequestInit = {},\n  ): Promise<Item> {\n",
+				"return this.request(\"PUT\", `/items/${encodeURIComponent(id)}`, query, body, init);",
+			},
+		},
+		{
+			name:  "no content",
+			paths: `{"/items/{id}": {"delete": {"operationId": "deleteItem", "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}], "responses": {"204": {"description": "Deleted"}}}}}`,
+			want:  []string{"  ): Promise<void> {\n"},
+		},
+		{
+			name:    "missing operationId",
+			paths:   `{"/items": {"get": {"responses": {"200": {"description": "OK"}}}}}`,
+			wantErr: "operationId",
+		},
+		{
+			name:    "unsupported type",
+			paths:   `{"/items": {"get": {"operationId": "getItems", "parameters": [{"name": "at", "in": "query", "schema": {"type": "date"}}], "responses": {"200": {"description": "OK"}}}}}`,
+			wantErr: `unsupported type "date"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := `{"info": {"title": "Items API", "version": "1"}, "paths": ` + tt.paths + `, ` + components + `}`
+			got, err := Generate([]byte(spec))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Generate() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(got), want) {
+					t.Errorf("Generate() is missing\n%s\nin\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateNeedsErrorSchema(t *testing.T) {
+	if _, err := Generate([]byte(`{"paths": {}, "components": {"schemas": {}}}`)); err == nil {
+		t.Error("Generate() of a spec without an Error schema succeeded")
+	}
+}
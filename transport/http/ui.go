@@ -0,0 +1,331 @@
+package http
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+const (
+	// uiPrefix is the path the web UI is served under
+	uiPrefix = "/ui"
+
+	// uiSource is the source of content uploaded through the web UI
+	uiSource = "ui"
+)
+
+//go:embed ui
+var uiFiles embed.FS
+
+// uiPolicy is the Content-Security-Policy of the UI pages: no scripts, the
+// service's stylesheet, and previews framed from the service
+const uiPolicy = "default-src 'none'; style-src 'self'; img-src 'self'; frame-src 'self'; base-uri 'none'; form-action 'self'; frame-ancestors 'none'"
+
+// uiFuncs are the functions of the UI templates
+var uiFuncs = template.FuncMap{
+	"bytes": formatBytes,
+}
+
+// uiTemplates are the UI pages, each with the shared layout
+var uiTemplates = func() map[string]*template.Template {
+	templates := make(map[string]*template.Template)
+	for _, page := range []string{"index", "entities", "entity", "content"} {
+		templates[page] = template.Must(template.New(page).Funcs(uiFuncs).ParseFS(uiFiles, "ui/layout.html", "ui/"+page+".html"))
+	}
+	return templates
+}()
+
+// formatBytes returns a size for people, e.g. "1.5 MB"
+func formatBytes(size int64) string {
+	if size < 0 {
+		return "unknown"
+	}
+	if size < 1000 {
+		return strconv.FormatInt(size, 10) + " B"
+	}
+	value := float64(size)
+	for _, unit := range []string{"kB", "MB", "GB", "TB"} {
+		value /= 1000
+		if value < 1000 {
+			return fmt.Sprintf("%.1f %s", value, unit)
+		}
+	}
+	return fmt.Sprintf("%.1f PB", value/1000)
+}
+
+// UIConfig configures the web UI
+type UIConfig struct {
+	EntityTypes []string // Entity types offered on every page; any type can be opened by name
+	Uploads     bool     // Allow uploading files to entities
+}
+
+// UIHandler serves a minimal web UI for browsing, previewing and uploading
+// the content of entities, for deployments without a front end of their own
+type UIHandler struct {
+	contentService *service.ContentService
+	config         UIConfig
+}
+
+// NewUIHandler creates a new web UI handler
+func NewUIHandler(contentService *service.ContentService, config UIConfig) *UIHandler {
+	return &UIHandler{
+		contentService: contentService,
+		config:         config,
+	}
+}
+
+// RegisterRoutes registers the UI pages under /ui. Downloads and previews
+// link to the content API, which must be registered on the same router.
+func (h *UIHandler) RegisterRoutes(r chi.Router) {
+	static, _ := fs.Sub(uiFiles, "ui")
+	r.Route(uiPrefix, func(r chi.Router) {
+		r.Get("/", h.Index)
+		r.Get("/style.css", func(w http.ResponseWriter, r *http.Request) {
+			http.ServeFileFS(w, r, static, "style.css")
+		})
+		r.Get("/entities", h.Entities)
+		r.Get("/entities/{type}/{entityID}", h.Entity)
+		if h.config.Uploads {
+			r.Post("/entities/{type}/{entityID}/files", h.Upload)
+		}
+		r.Get("/contents/{id}", h.Content)
+	})
+}
+
+// uiPage is the data of a UI page
+type uiPage struct {
+	Prefix      string
+	EntityTypes []string
+	EntityType  string
+	EntityID    string
+	Error       string
+	Uploads     bool
+
+	// Listings
+	Items       interface{}
+	Page        int
+	TotalPages  int
+	PreviousURL string
+	NextURL     string
+
+	// Content pages
+	Content      *model.Content
+	Associations []*model.ContentEntityAssociation
+	Previewable  bool
+}
+
+// newPage returns the data of a page
+func (h *UIHandler) newPage() *uiPage {
+	return &uiPage{
+		Prefix:      uiPrefix,
+		EntityTypes: h.config.EntityTypes,
+		Uploads:     h.config.Uploads,
+	}
+}
+
+// paginate sets the page links of a listing of total items, or of an
+// unknown total if negative. query is the query of the listing, whose page
+// parameter is replaced.
+func (p *uiPage) paginate(query url.Values, page, pageSize int, total int64) {
+	p.Page = page
+	if total > 0 {
+		p.TotalPages = int((total + int64(pageSize) - 1) / int64(pageSize))
+	}
+	link := func(page int) string {
+		query.Set("page", strconv.Itoa(page))
+		return "?" + query.Encode()
+	}
+	if page > 1 {
+		p.PreviousURL = link(page - 1)
+	}
+	if page < p.TotalPages {
+		p.NextURL = link(page + 1)
+	}
+}
+
+// render writes a page
+func (h *UIHandler) render(w http.ResponseWriter, status int, name string, page *uiPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", uiPolicy)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.WriteHeader(status)
+	if err := uiTemplates[name].ExecuteTemplate(w, "layout", page); err != nil {
+		log.Printf("Error rendering UI page %s: %v", name, err)
+	}
+}
+
+// Index handles the start page, listing the latest content of the tenant
+func (h *UIHandler) Index(w http.ResponseWriter, r *http.Request) {
+	options := listOptions(r)
+	page := h.newPage()
+	result, err := h.contentService.ListContent(r.Context(), service.ListContentInput{
+		TenantID: requestTenant(r),
+		Page:     options.Page,
+		PageSize: options.PageSize,
+	})
+	if err != nil {
+		log.Printf("Error listing content for the UI: %v", err)
+		page.Error = "Files could not be listed."
+		h.render(w, http.StatusInternalServerError, "index", page)
+		return
+	}
+
+	page.Items = result.Items
+	page.paginate(r.URL.Query(), options.Page, options.PageSize, int64(result.TotalCount))
+	h.render(w, http.StatusOK, "index", page)
+}
+
+// Entities handles the form opening an entity, and lists the entities of a
+// type that have content when no entity ID is given
+func (h *UIHandler) Entities(w http.ResponseWriter, r *http.Request) {
+	entityType := strings.TrimSpace(r.URL.Query().Get("type"))
+	entityID := strings.TrimSpace(r.URL.Query().Get("id"))
+	if entityType == "" {
+		http.Redirect(w, r, uiPrefix+"/", http.StatusSeeOther)
+		return
+	}
+	if entityID != "" {
+		http.Redirect(w, r, uiPrefix+"/entities/"+url.PathEscape(entityType)+"/"+url.PathEscape(entityID), http.StatusSeeOther)
+		return
+	}
+
+	options := listOptions(r)
+	page := h.newPage()
+	page.EntityType = entityType
+	ids, total, err := h.contentService.ListEntities(r.Context(), entityType, options)
+	if err != nil {
+		log.Printf("Error listing %s entities for the UI: %v", entityType, err)
+		page.Error = "Entities could not be listed."
+		h.render(w, http.StatusInternalServerError, "entities", page)
+		return
+	}
+
+	page.Items = ids
+	page.paginate(r.URL.Query(), options.Page, options.PageSize, total)
+	h.render(w, http.StatusOK, "entities", page)
+}
+
+// Entity handles the page of an entity, listing its content
+func (h *UIHandler) Entity(w http.ResponseWriter, r *http.Request) {
+	h.renderEntity(w, r, http.StatusOK, "")
+}
+
+// renderEntity writes the page of an entity with an error, if any
+func (h *UIHandler) renderEntity(w http.ResponseWriter, r *http.Request, status int, message string) {
+	options := listOptions(r)
+	page := h.newPage()
+	page.EntityType = chi.URLParam(r, "type")
+	page.EntityID = chi.URLParam(r, "entityID")
+	page.Error = message
+
+	contents, total, err := h.contentService.GetContentWithAssociationsForEntity(r.Context(), page.EntityType, page.EntityID, options)
+	if err != nil {
+		log.Printf("Error listing the content of %s %s for the UI: %v", page.EntityType, page.EntityID, err)
+		page.Error = "Files could not be listed."
+		h.render(w, http.StatusInternalServerError, "entity", page)
+		return
+	}
+
+	page.Items = contents
+	page.paginate(r.URL.Query(), options.Page, options.PageSize, total)
+	h.render(w, status, "entity", page)
+}
+
+// Upload handles a file added to an entity from its page, then shows the
+// page again
+func (h *UIHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	// Forms of other sites must not upload with the user's credentials
+	if r.Header.Get("Sec-Fetch-Site") == "cross-site" {
+		errorResponse(w, http.StatusForbidden, "Cross-site uploads are not allowed")
+		return
+	}
+
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		h.renderEntity(w, r, http.StatusBadRequest, "Please choose a file to upload.")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		h.renderEntity(w, r, http.StatusBadRequest, "Please choose a file to upload.")
+		return
+	}
+	defer file.Close()
+
+	_, err = h.contentService.CreateContent(r.Context(), service.CreateContentInput{
+		TenantID:   requestTenant(r),
+		FileName:   header.Filename,
+		MIMEType:   header.Header.Get("Content-Type"),
+		FileSize:   header.Size,
+		Data:       file,
+		CreatedBy:  requestPrincipal(r),
+		EntityType: chi.URLParam(r, "type"),
+		EntityID:   chi.URLParam(r, "entityID"),
+		Source:     uiSource,
+		Metadata:   make(model.Metadata),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput), errors.Is(err, service.ErrTenantNotFound):
+			h.renderEntity(w, r, http.StatusBadRequest, "The file was not accepted: "+err.Error())
+		case errors.Is(err, service.ErrQuotaExceeded):
+			h.renderEntity(w, r, quotaExceededStatus(err), err.Error())
+		case errors.Is(err, service.ErrAttachmentLimit):
+			h.renderEntity(w, r, http.StatusConflict, err.Error())
+		case errors.Is(err, service.ErrStorageUnavailable):
+			setRetryAfter(w, err)
+			h.renderEntity(w, r, http.StatusServiceUnavailable, "Uploads are unavailable at the moment, please try again later.")
+		default:
+			log.Printf("Error uploading from the UI: %v", err)
+			h.renderEntity(w, r, http.StatusInternalServerError, "The file could not be uploaded.")
+		}
+		return
+	}
+
+	// Reloading the page must not upload the file again
+	http.Redirect(w, r, r.URL.Path[:strings.LastIndex(r.URL.Path, "/files")], http.StatusSeeOther)
+}
+
+// Content handles the page of a content item, with its details and preview
+func (h *UIHandler) Content(w http.ResponseWriter, r *http.Request) {
+	page := h.newPage()
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		page.Error = "This file does not exist."
+		h.render(w, http.StatusNotFound, "index", page)
+		return
+	}
+
+	content, err := h.contentService.GetContent(r.Context(), id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		page.Error = "The file could not be loaded."
+		if errors.Is(err, service.ErrContentNotFound) {
+			status = http.StatusNotFound
+			page.Error = "This file does not exist."
+		}
+		h.render(w, status, "index", page)
+		return
+	}
+
+	page.Content = content
+	page.Associations, _, err = h.contentService.ListAssociationsForContent(r.Context(), id, listOptions(r))
+	if err != nil {
+		log.Printf("Error listing the associations of content %s for the UI: %v", id, err)
+	}
+	page.Previewable = previewKind(content.MIMEType) != ""
+	h.render(w, http.StatusOK, "content", page)
+}
@@ -0,0 +1,73 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/livefire2015/simple-contents/repository/memory"
+	"github.com/livefire2015/simple-contents/service"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+)
+
+func TestUIUpload(t *testing.T) {
+	contentService := service.NewContentService(memory.NewMemoryRepository(), memorystorage.NewMemoryStorage())
+	router := chi.NewRouter()
+	NewUIHandler(contentService, UIConfig{EntityTypes: []string{"order"}, Uploads: true}).RegisterRoutes(router)
+
+	upload := func(fetchSite string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, _ := form.CreateFormFile("file", "invoice.pdf")
+		part.Write([]byte("%PDF-1.7"))
+		form.Close()
+
+		r := httptest.NewRequest(http.MethodPost, "/ui/entities/order/42/files", &body)
+		r.Header.Set("Content-Type", form.FormDataContentType())
+		r.Header.Set("Sec-Fetch-Site", fetchSite)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := upload("cross-site"); w.Code != http.StatusForbidden {
+		t.Errorf("cross-site upload status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	w := upload("same-origin")
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/ui/entities/order/42" {
+		t.Fatalf("upload status = %d, location %q, want %d to the entity page", w.Code, w.Header().Get("Location"), http.StatusSeeOther)
+	}
+
+	listed, err := contentService.ListContent(context.Background(), service.ListContentInput{})
+	if err != nil || len(listed.Items) != 1 {
+		t.Fatalf("ListContent() = %v, %v, want the uploaded file", listed, err)
+	}
+	if source := listed.Items[0].Source; source != uiSource {
+		t.Errorf("source = %q, want %q", source, uiSource)
+	}
+
+	pages := []struct {
+		path string
+		want string
+	}{
+		{path: "/ui/", want: "invoice.pdf"},
+		{path: "/ui/entities?type=order", want: `href="/ui/entities/order/42"`},
+		{path: "/ui/entities/order/42", want: "invoice.pdf"},
+		{path: "/ui/contents/" + listed.Items[0].ID.String(), want: `href="/ui/entities/order/42"`},
+	}
+	for _, page := range pages {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, page.path, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("GET %s status = %d, want %d", page.path, w.Code, http.StatusOK)
+		}
+		if !strings.Contains(w.Body.String(), page.want) {
+			t.Errorf("GET %s doesn't contain %s:\n%s", page.path, page.want, w.Body.String())
+		}
+	}
+}
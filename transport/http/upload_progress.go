@@ -0,0 +1,248 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/model"
+)
+
+const (
+	// uploadIDHeader names the client-chosen ID an upload's progress is tracked under
+	uploadIDHeader = "X-Upload-ID"
+	// maxUploadIDLength bounds the IDs clients can choose
+	maxUploadIDLength = 128
+	// uploadProgressRetention is how long the outcome of an upload stays queryable
+	uploadProgressRetention = 10 * time.Minute
+	// uploadProgressInterval is the shortest time between two SSE progress events
+	uploadProgressInterval = 250 * time.Millisecond
+)
+
+// States of a tracked upload
+const (
+	uploadInProgress = "uploading"
+	uploadCompleted  = "completed"
+	uploadFailed     = "failed"
+)
+
+// uploadProgress is the received-bytes progress of an upload
+type uploadProgress struct {
+	UploadID      string     `json:"upload_id"`
+	State         string     `json:"state"`
+	ReceivedBytes int64      `json:"received_bytes"`
+	TotalBytes    int64      `json:"total_bytes"`          // -1 if the request has no Content-Length
+	ContentID     *uuid.UUID `json:"content_id,omitempty"` // Content created by a completed upload
+	StartedAt     time.Time  `json:"started_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// trackedUpload is the progress of an upload and a channel closed on its next change
+type trackedUpload struct {
+	progress uploadProgress
+	changed  chan struct{}
+}
+
+// uploadTracker keeps the progress of the uploads of this instance in memory
+type uploadTracker struct {
+	mu      sync.Mutex
+	uploads map[string]*trackedUpload
+}
+
+func newUploadTracker() *uploadTracker {
+	return &uploadTracker{uploads: make(map[string]*trackedUpload)}
+}
+
+// uploadKey scopes an upload ID to the tenant of a request
+func uploadKey(r *http.Request, uploadID string) string {
+	return requestTenant(r) + "/" + uploadID
+}
+
+// start begins tracking an upload. It returns false if an upload with the
+// same key is still in progress.
+func (t *uploadTracker) start(key, uploadID string, totalBytes int64) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for k, upload := range t.uploads {
+		if upload.progress.State != uploadInProgress && now.Sub(upload.progress.UpdatedAt) > uploadProgressRetention {
+			close(upload.changed)
+			delete(t.uploads, k)
+		}
+	}
+
+	if existing, ok := t.uploads[key]; ok {
+		if existing.progress.State == uploadInProgress {
+			return false
+		}
+		close(existing.changed)
+	}
+	t.uploads[key] = &trackedUpload{
+		progress: uploadProgress{
+			UploadID:   uploadID,
+			State:      uploadInProgress,
+			TotalBytes: totalBytes,
+			StartedAt:  now,
+			UpdatedAt:  now,
+		},
+		changed: make(chan struct{}),
+	}
+	return true
+}
+
+// update changes the progress of an upload and wakes its watchers
+func (t *uploadTracker) update(key string, fn func(*uploadProgress)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	upload, ok := t.uploads[key]
+	if !ok {
+		return
+	}
+	fn(&upload.progress)
+	upload.progress.UpdatedAt = time.Now()
+	close(upload.changed)
+	upload.changed = make(chan struct{})
+}
+
+// get returns the progress of an upload and a channel closed when it changes
+func (t *uploadTracker) get(key string) (uploadProgress, <-chan struct{}, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	upload, ok := t.uploads[key]
+	if !ok {
+		return uploadProgress{}, nil, false
+	}
+	return upload.progress, upload.changed, true
+}
+
+// progressReader counts the bytes read from a request body
+type progressReader struct {
+	io.ReadCloser
+	tracker *uploadTracker
+	key     string
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.ReadCloser.Read(b)
+	if n > 0 {
+		p.tracker.update(p.key, func(progress *uploadProgress) {
+			progress.ReceivedBytes += int64(n)
+		})
+	}
+	return n, err
+}
+
+// trackUpload tracks the body of an upload request carrying an X-Upload-ID.
+// The returned function records the outcome: the created content, or nil if
+// the upload failed. It writes an error response and returns false if the ID
+// is invalid or already in use.
+func (h *ContentHandler) trackUpload(w http.ResponseWriter, r *http.Request) (func(*model.Content), bool) {
+	uploadID := r.Header.Get(uploadIDHeader)
+	if uploadID == "" {
+		return func(*model.Content) {}, true
+	}
+	if len(uploadID) > maxUploadIDLength {
+		errorResponse(w, http.StatusBadRequest, "Upload ID is too long")
+		return nil, false
+	}
+
+	key := uploadKey(r, uploadID)
+	if !h.uploads.start(key, uploadID, r.ContentLength) {
+		errorResponse(w, http.StatusConflict, "Upload ID is already in use")
+		return nil, false
+	}
+	r.Body = &progressReader{ReadCloser: r.Body, tracker: h.uploads, key: key}
+
+	return func(content *model.Content) {
+		h.uploads.update(key, func(progress *uploadProgress) {
+			if content == nil {
+				progress.State = uploadFailed
+				return
+			}
+			progress.State = uploadCompleted
+			progress.ContentID = &content.ID
+		})
+	}, true
+}
+
+// GetUploadProgress handles retrieving the received-bytes progress of an upload
+func (h *ContentHandler) GetUploadProgress(w http.ResponseWriter, r *http.Request) {
+	progress, _, ok := h.uploads.get(uploadKey(r, chi.URLParam(r, "uploadID")))
+	if !ok {
+		errorResponse(w, http.StatusNotFound, "Upload not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(progress)
+}
+
+// UploadProgressEvents streams the progress of an upload as Server-Sent
+// Events until it completes or fails
+func (h *ContentHandler) UploadProgressEvents(w http.ResponseWriter, r *http.Request) {
+	key := uploadKey(r, chi.URLParam(r, "uploadID"))
+	progress, changed, ok := h.uploads.get(key)
+	if !ok {
+		errorResponse(w, http.StatusNotFound, "Upload not found")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		errorResponse(w, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		if err := writeSSE(w, "progress", progress); err != nil {
+			return
+		}
+		flusher.Flush()
+		if progress.State != uploadInProgress {
+			return
+		}
+
+		// Bodies are read in small chunks; coalesce their updates
+		select {
+		case <-time.After(uploadProgressInterval):
+		case <-r.Context().Done():
+			return
+		}
+
+	wait:
+		for {
+			select {
+			case <-changed:
+				break wait
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		if progress, changed, ok = h.uploads.get(key); !ok {
+			return
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/service"
+)
+
+// uploadRequestFormOverhead is the room left in the body of an upload
+// through a request for the multipart framing around the file
+const uploadRequestFormOverhead = 1 << 20
+
+// uploadRequestTemplate is the page an external party uploads files on, or
+// that tells why they can't
+var uploadRequestTemplate = template.Must(template.New("upload-request").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Upload a file</title>
+<style>{{.Style}}</style>
+</head>
+<body>
+{{- if .Open}}
+<form method="post" enctype="multipart/form-data">
+{{- if .Request.Message}}
+<p>{{.Request.Message}}</p>
+{{- end}}
+{{- if .Notice}}
+<em>{{.Notice}}</em>
+{{- end}}
+<label for="file">Choose a file{{if .Request.MaxFileSize}} of at most {{.Request.MaxFileSize}} bytes{{end}}:</label>
+<input type="file" id="file" name="file" required>
+<button type="submit">Upload</button>
+</form>
+{{- else}}
+<p>{{.Notice}}</p>
+{{- end}}
+</body>
+</html>
+`))
+
+// uploadRequestResponse is an upload request with the public URL to send
+type uploadRequestResponse struct {
+	*model.UploadRequest
+	URL string `json:"url"`
+}
+
+// uploadRequestErrorResponse maps upload request service errors to HTTP responses
+func uploadRequestErrorResponse(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		invalidInputResponse(w, err)
+	case errors.Is(err, service.ErrTenantNotFound):
+		errorResponse(w, http.StatusBadRequest, "Unknown tenant")
+	case errors.Is(err, service.ErrUploadRequestNotFound):
+		errorResponse(w, http.StatusNotFound, "Upload request not found")
+	default:
+		errorResponse(w, http.StatusInternalServerError, message)
+	}
+}
+
+// CreateUploadRequest handles creating an upload request for files attached
+// to an entity, owned by the tenant of the request
+func (h *ContentHandler) CreateUploadRequest(w http.ResponseWriter, r *http.Request) {
+	var input service.UploadRequestInput
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	request, err := h.uploadRequestService.CreateUploadRequest(r.Context(), requestTenant(r), chi.URLParam(r, "type"), chi.URLParam(r, "entityID"), requestPrincipal(r), input)
+	if err != nil {
+		uploadRequestErrorResponse(w, err, "Failed to create upload request")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(uploadRequestResponse{UploadRequest: request, URL: h.publicURL(r, "/u/"+request.Token)})
+}
+
+// ListUploadRequests handles listing the upload requests of an entity with their uploads
+func (h *ContentHandler) ListUploadRequests(w http.ResponseWriter, r *http.Request) {
+	options := listOptions(r)
+	requests, total, err := h.uploadRequestService.ListUploadRequests(r.Context(), chi.URLParam(r, "type"), chi.URLParam(r, "entityID"), options)
+	if err != nil {
+		uploadRequestErrorResponse(w, err, "Failed to list upload requests")
+		return
+	}
+
+	items := make([]uploadRequestResponse, len(requests))
+	for i, request := range requests {
+		items[i] = uploadRequestResponse{UploadRequest: request, URL: h.publicURL(r, "/u/"+request.Token)}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items":      items,
+		"totalCount": total,
+		"page":       options.Page,
+		"pageSize":   options.PageSize,
+	})
+}
+
+// RevokeUploadRequest handles revoking an upload request
+func (h *ContentHandler) RevokeUploadRequest(w http.ResponseWriter, r *http.Request) {
+	err := h.uploadRequestService.RevokeUploadRequest(r.Context(), chi.URLParam(r, "type"), chi.URLParam(r, "entityID"), chi.URLParam(r, "token"), requestPrincipal(r))
+	if err != nil {
+		uploadRequestErrorResponse(w, err, "Failed to revoke upload request")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// acceptsJSON reports whether a client asked for JSON rather than a page
+func acceptsJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+// uploadRequestPage answers an external party with the upload page, or with
+// JSON to clients asking for it. request is nil if the upload request
+// doesn't accept files.
+func uploadRequestPage(w http.ResponseWriter, r *http.Request, status int, request *model.UploadRequest, notice string) {
+	w.Header().Set("Cache-Control", "no-store")
+	if acceptsJSON(r) {
+		if status >= http.StatusBadRequest {
+			errorResponse(w, status, notice)
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(map[string]string{"message": notice})
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", publicPagePolicy)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(status)
+
+	err := uploadRequestTemplate.Execute(w, map[string]interface{}{
+		"Open":    request != nil,
+		"Request": request,
+		"Notice":  notice,
+		"Style":   template.CSS(publicPageStyle),
+	})
+	if err != nil {
+		log.Printf("Error rendering upload request page: %v", err)
+	}
+}
+
+// closedUploadRequestPage answers a request for an upload request that
+// doesn't accept files
+func closedUploadRequestPage(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrUploadRequestNotFound):
+		uploadRequestPage(w, r, http.StatusNotFound, nil, "This link does not exist.")
+	case errors.Is(err, service.ErrUploadRequestExpired), errors.Is(err, service.ErrUploadRequestRevoked), errors.Is(err, service.ErrUploadRequestExhausted):
+		uploadRequestPage(w, r, http.StatusGone, nil, "This link no longer accepts files.")
+	default:
+		log.Printf("Error opening upload request: %v", err)
+		uploadRequestPage(w, r, http.StatusInternalServerError, nil, "The upload page could not be opened, please try again later.")
+	}
+}
+
+// UploadRequestForm handles showing the upload page of an upload request
+func (h *ContentHandler) UploadRequestForm(w http.ResponseWriter, r *http.Request) {
+	request, err := h.uploadRequestService.OpenUploadRequest(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		closedUploadRequestPage(w, r, err)
+		return
+	}
+	uploadRequestPage(w, r, http.StatusOK, request, "")
+}
+
+// FulfillUploadRequest handles a file uploaded through an upload request as
+// the file field of a multipart form, with no authentication besides the token
+func (h *ContentHandler) FulfillUploadRequest(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+	request, err := h.uploadRequestService.OpenUploadRequest(r.Context(), token)
+	if err != nil {
+		closedUploadRequestPage(w, r, err)
+		return
+	}
+	if request.MaxFileSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, request.MaxFileSize+uploadRequestFormOverhead)
+	}
+
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			uploadRequestPage(w, r, http.StatusRequestEntityTooLarge, request, "The file is too large.")
+		} else {
+			uploadRequestPage(w, r, http.StatusBadRequest, request, "Please choose a file to upload.")
+		}
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		uploadRequestPage(w, r, http.StatusBadRequest, request, "Please choose a file to upload.")
+		return
+	}
+	defer file.Close()
+
+	content, err := h.uploadRequestService.FulfillUploadRequest(r.Context(), token, service.UploadRequestFile{
+		FileName: header.Filename,
+		MIMEType: header.Header.Get("Content-Type"),
+		FileSize: header.Size,
+		Data:     file,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUploadRequestNotFound), errors.Is(err, service.ErrUploadRequestExpired),
+			errors.Is(err, service.ErrUploadRequestRevoked), errors.Is(err, service.ErrUploadRequestExhausted):
+			closedUploadRequestPage(w, r, err)
+		case errors.Is(err, service.ErrUploadRequestTooLarge):
+			uploadRequestPage(w, r, http.StatusRequestEntityTooLarge, request, "The file is too large.")
+		case errors.Is(err, service.ErrInvalidInput):
+			uploadRequestPage(w, r, http.StatusBadRequest, request, "This file can't be accepted, please choose another one.")
+		case errors.Is(err, service.ErrAttachmentLimit), errors.Is(err, service.ErrQuotaExceeded):
+			uploadRequestPage(w, r, http.StatusConflict, nil, "No more files can be accepted.")
+		case errors.Is(err, service.ErrStorageUnavailable):
+			setRetryAfter(w, err)
+			uploadRequestPage(w, r, http.StatusServiceUnavailable, request, "Uploads are unavailable at the moment, please try again later.")
+		default:
+			log.Printf("Error uploading through upload request for %s %s: %v", request.EntityType, request.EntityID, err)
+			uploadRequestPage(w, r, http.StatusInternalServerError, request, "The file could not be uploaded, please try again later.")
+		}
+		return
+	}
+
+	// Offer to upload another file while the request accepts more
+	request.Uploads++
+	notice := "Thank you, " + content.FileName + " was received."
+	if request.MaxUploads > 0 && request.Uploads >= request.MaxUploads {
+		request = nil
+	}
+	uploadRequestPage(w, r, http.StatusCreated, request, notice)
+}
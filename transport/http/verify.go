@@ -0,0 +1,61 @@
+package http
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"regexp"
+)
+
+// checksumErrorTrailer is the trailer that reports a download whose data
+// didn't match the stored checksum
+const checksumErrorTrailer = "X-Checksum-Error"
+
+var errChecksumMismatch = errors.New("checksum mismatch")
+
+// md5ETag matches ETags that are the MD5 of the data. Multipart ETags
+// ("<md5>-<parts>") can't be verified from the data alone.
+var md5ETag = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// checksumReader computes the MD5 of the data it reads and compares it with
+// the expected ETag. The final bytes are withheld on a mismatch, so that
+// corrupt data is never delivered whole.
+type checksumReader struct {
+	r        io.Reader
+	hash     hash.Hash
+	expected string
+	size     int64
+	read     int64
+	verified bool
+	err      error
+}
+
+// newChecksumReader verifies r against an MD5 ETag, or returns nil if the ETag is not an MD5
+func newChecksumReader(r io.Reader, etag string, size int64) *checksumReader {
+	if !md5ETag.MatchString(etag) {
+		return nil
+	}
+	return &checksumReader{r: r, hash: md5.New(), expected: etag, size: size}
+}
+
+func (c *checksumReader) Read(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	n, err := c.r.Read(p)
+	c.hash.Write(p[:n])
+	c.read += int64(n)
+
+	if !c.verified && (c.read >= c.size || err == io.EOF) {
+		if sum := hex.EncodeToString(c.hash.Sum(nil)); sum != c.expected || c.read != c.size {
+			c.err = fmt.Errorf("%w: read %d bytes with MD5 %s, expected %d bytes with MD5 %s", errChecksumMismatch, c.read, sum, c.size, c.expected)
+			return 0, c.err
+		}
+		c.verified = true
+	}
+	return n, err
+}
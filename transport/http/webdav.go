@@ -0,0 +1,516 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/livefire2015/simple-contents/model"
+	"github.com/livefire2015/simple-contents/repository"
+	"github.com/livefire2015/simple-contents/service"
+	"github.com/livefire2015/simple-contents/storage"
+	"golang.org/x/net/webdav"
+)
+
+const (
+	// webDAVPrefix is the path the WebDAV tree is served under
+	webDAVPrefix = "/dav"
+
+	// webDAVSource is the source of content uploaded through WebDAV
+	webDAVSource = "webdav"
+
+	// webDAVPageSize is the number of entities read per repository call when listing a type
+	webDAVPageSize = 500
+)
+
+// WebDAVConfig configures the WebDAV facade
+type WebDAVConfig struct {
+	EntityTypes []string // Entity types listed at the root of the tree
+	Writable    bool     // Allow uploading, renaming and deleting files
+
+	Throttle *DownloadThrottle // Optional bandwidth limits for reading files
+}
+
+// NewWebDAVHandler serves content as a WebDAV tree of entity folders,
+// /dav/{type}/{entityID}/{file name}, so the documents of an entity can be
+// mounted in Finder or Explorer
+func NewWebDAVHandler(contentService *service.ContentService, config WebDAVConfig) http.Handler {
+	fsys := &contentFS{contentService: contentService, config: config}
+	handler := &webdav.Handler{
+		Prefix:     webDAVPrefix,
+		FileSystem: fsys,
+		LockSystem: webdav.NewMemLS(),
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Files are served from the origin of the API, see ContentType
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			fsys.setContentType(w, r)
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// setContentType sets the Content-Type of a file read with GET or HEAD,
+// which the handler would otherwise take from the extension of its name
+func (fsys *contentFS) setContentType(w http.ResponseWriter, r *http.Request) {
+	info, err := fsys.Stat(r.Context(), strings.TrimPrefix(r.URL.Path, webDAVPrefix))
+	if err != nil {
+		return
+	}
+	if file, ok := info.(*davFileInfo); ok && !file.dir {
+		if contentType, err := file.ContentType(r.Context()); err == nil {
+			w.Header().Set("Content-Type", contentType)
+		}
+	}
+}
+
+// RegisterWebDAV mounts the WebDAV facade on a router
+func RegisterWebDAV(r chi.Router, handler http.Handler) {
+	// chi only routes the methods it knows about
+	for _, method := range []string{"PROPFIND", "PROPPATCH", "MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK"} {
+		chi.RegisterMethod(method)
+	}
+	r.Handle(webDAVPrefix, handler)
+	r.Handle(webDAVPrefix+"/*", handler)
+}
+
+// contentFS implements webdav.FileSystem over the content catalogue
+type contentFS struct {
+	contentService *service.ContentService
+	config         WebDAVConfig
+}
+
+// davPath is a parsed path of the tree
+type davPath struct {
+	entityType string
+	entityID   string
+	fileName   string
+}
+
+// depth returns 0 for the root, 1 for a type, 2 for an entity and 3 for a file
+func (p davPath) depth() int {
+	switch {
+	case p.fileName != "":
+		return 3
+	case p.entityID != "":
+		return 2
+	case p.entityType != "":
+		return 1
+	}
+	return 0
+}
+
+// parse splits a path of the tree, rejecting unknown types and deeper paths
+func (fsys *contentFS) parse(name string) (davPath, error) {
+	segments := strings.Split(strings.Trim(path.Clean("/"+name), "/"), "/")
+	if segments[0] == "" {
+		return davPath{}, nil
+	}
+	if len(segments) > 3 || !slices.Contains(fsys.config.EntityTypes, segments[0]) {
+		return davPath{}, os.ErrNotExist
+	}
+	var p davPath
+	p.entityType = segments[0]
+	if len(segments) > 1 {
+		p.entityID = segments[1]
+	}
+	if len(segments) > 2 {
+		p.fileName = segments[2]
+	}
+	return p, nil
+}
+
+// entityFiles lists the content of an entity by the file names shown in its folder
+func (fsys *contentFS) entityFiles(ctx context.Context, p davPath) (map[string]*model.Content, []string, error) {
+	return fsys.contentService.EntityFiles(ctx, p.entityType, p.entityID)
+}
+
+// lookup finds the content shown under a file path
+func (fsys *contentFS) lookup(ctx context.Context, p davPath) (*model.Content, error) {
+	files, _, err := fsys.entityFiles(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	content, ok := files[p.fileName]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return content, nil
+}
+
+// Mkdir creates an entity folder, which always exists. Other folders cannot be created.
+func (fsys *contentFS) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	p, err := fsys.parse(name)
+	if err != nil {
+		return err
+	}
+	if !fsys.config.Writable || p.depth() != 2 {
+		return os.ErrPermission
+	}
+	return nil
+}
+
+// OpenFile opens a folder or a file for reading, or a file in an entity folder for writing
+func (fsys *contentFS) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	p, err := fsys.parse(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC) != 0 {
+		if !fsys.config.Writable || p.depth() != 3 || strings.HasPrefix(p.fileName, ".") {
+			// Hidden files, such as .DS_Store, are not worth cataloguing
+			return nil, os.ErrPermission
+		}
+		previous, err := fsys.lookup(ctx, p)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		return newUploadFile(ctx, fsys.contentService, p, previous), nil
+	}
+
+	if p.depth() < 3 {
+		return &dirFile{fsys: fsys, ctx: ctx, path: p}, nil
+	}
+	content, err := fsys.lookup(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	return &contentFile{ctx: ctx, contentService: fsys.contentService, content: content, info: contentInfo(p.fileName, content), throttle: fsys.config.Throttle}, nil
+}
+
+// RemoveAll detaches a file from its entity, deleting the content once no
+// other entity uses it. Folders cannot be removed.
+func (fsys *contentFS) RemoveAll(ctx context.Context, name string) error {
+	p, err := fsys.parse(name)
+	if err != nil {
+		return err
+	}
+	if !fsys.config.Writable || p.depth() != 3 {
+		return os.ErrPermission
+	}
+	content, err := fsys.lookup(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	_, err = fsys.contentService.DeleteContent(ctx, content.ID, service.DeleteContentOptions{
+		Policy:     service.DeleteLastReference,
+		EntityType: p.entityType,
+		EntityID:   p.entityID,
+	})
+	if errors.Is(err, service.ErrContentRetained) {
+		return os.ErrPermission
+	}
+	return err
+}
+
+// Rename renames a file within its entity folder
+func (fsys *contentFS) Rename(ctx context.Context, oldName, newName string) error {
+	from, err := fsys.parse(oldName)
+	if err != nil {
+		return err
+	}
+	to, err := fsys.parse(newName)
+	if err != nil {
+		return err
+	}
+	if !fsys.config.Writable || from.depth() != 3 || to.depth() != 3 ||
+		from.entityType != to.entityType || from.entityID != to.entityID {
+		return os.ErrPermission
+	}
+
+	content, err := fsys.lookup(ctx, from)
+	if err != nil {
+		return err
+	}
+	_, err = fsys.contentService.UpdateContent(ctx, service.UpdateContentInput{ID: content.ID, FileName: to.fileName})
+	return err
+}
+
+// Stat describes a folder or a file
+func (fsys *contentFS) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	p, err := fsys.parse(name)
+	if err != nil {
+		return nil, err
+	}
+	if p.depth() < 3 {
+		return dirInfo(p), nil
+	}
+	content, err := fsys.lookup(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	return contentInfo(p.fileName, content), nil
+}
+
+// davFileInfo describes a folder or a content item of the tree
+type davFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+	content *model.Content
+}
+
+func dirInfo(p davPath) *davFileInfo {
+	name := p.entityID
+	if name == "" {
+		name = p.entityType
+	}
+	if name == "" {
+		name = "/"
+	}
+	return &davFileInfo{name: name, dir: true}
+}
+
+func contentInfo(name string, content *model.Content) *davFileInfo {
+	return &davFileInfo{name: name, size: content.FileSize, modTime: content.UpdatedAt, content: content}
+}
+
+func (i *davFileInfo) Name() string       { return i.name }
+func (i *davFileInfo) Size() int64        { return i.size }
+func (i *davFileInfo) ModTime() time.Time { return i.modTime }
+func (i *davFileInfo) IsDir() bool        { return i.dir }
+func (i *davFileInfo) Sys() interface{}   { return i.content }
+
+func (i *davFileInfo) Mode() os.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o755
+	}
+	return 0o644
+}
+
+// ContentType returns the stored MIME type, saving the handler from sniffing
+// the data. Active content is served as application/octet-stream, so a
+// browser opening an uploaded HTML or SVG file downloads it rather than run
+// its scripts from the service's origin.
+func (i *davFileInfo) ContentType(ctx context.Context) (string, error) {
+	if i.content == nil {
+		return "", webdav.ErrNotImplemented
+	}
+	if activeContent(i.content.MIMEType) {
+		return "application/octet-stream", nil
+	}
+	return i.content.MIMEType, nil
+}
+
+// ETag returns the stored ETag of the content
+func (i *davFileInfo) ETag(ctx context.Context) (string, error) {
+	if i.content == nil || i.content.ETag == "" {
+		return "", webdav.ErrNotImplemented
+	}
+	return `"` + i.content.ETag + `"`, nil
+}
+
+// dirFile is an open folder
+type dirFile struct {
+	fsys    *contentFS
+	ctx     context.Context
+	path    davPath
+	entries []os.FileInfo
+	read    bool
+}
+
+func (d *dirFile) Close() error                                 { return nil }
+func (d *dirFile) Read(p []byte) (int, error)                   { return 0, fs.ErrInvalid }
+func (d *dirFile) Write(p []byte) (int, error)                  { return 0, os.ErrPermission }
+func (d *dirFile) Seek(offset int64, whence int) (int64, error) { return 0, nil }
+func (d *dirFile) Stat() (os.FileInfo, error)                   { return dirInfo(d.path), nil }
+
+// Readdir lists the folder, reading it from the catalogue on the first call
+func (d *dirFile) Readdir(count int) ([]os.FileInfo, error) {
+	if !d.read {
+		entries, err := d.list()
+		if err != nil {
+			return nil, err
+		}
+		d.entries, d.read = entries, true
+	}
+
+	if count <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	n := min(count, len(d.entries))
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
+
+// list returns the entries of the folder
+func (d *dirFile) list() ([]os.FileInfo, error) {
+	var entries []os.FileInfo
+	switch d.path.depth() {
+	case 0:
+		for _, entityType := range d.fsys.config.EntityTypes {
+			entries = append(entries, dirInfo(davPath{entityType: entityType}))
+		}
+	case 1:
+		for page := 1; ; page++ {
+			entityIDs, _, err := d.fsys.contentService.ListEntities(d.ctx, d.path.entityType, repository.ListOptions{Page: page, PageSize: webDAVPageSize})
+			if err != nil {
+				return nil, err
+			}
+			for _, entityID := range entityIDs {
+				entries = append(entries, dirInfo(davPath{entityType: d.path.entityType, entityID: entityID}))
+			}
+			if len(entityIDs) < webDAVPageSize {
+				break
+			}
+		}
+	case 2:
+		files, names, err := d.fsys.entityFiles(d.ctx, d.path)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			entries = append(entries, contentInfo(name, files[name]))
+		}
+	}
+	return entries, nil
+}
+
+// contentFile is a content item open for reading. Its data is downloaded
+// on the first read, and seeking reopens the download at the new offset.
+type contentFile struct {
+	ctx            context.Context
+	contentService *service.ContentService
+	content        *model.Content
+	info           *davFileInfo
+	throttle       *DownloadThrottle
+	data           io.ReadCloser
+	reader         io.Reader // data, throttled
+	offset         int64
+}
+
+func (f *contentFile) Readdir(count int) ([]os.FileInfo, error) { return nil, fs.ErrInvalid }
+func (f *contentFile) Write(p []byte) (int, error)              { return 0, os.ErrPermission }
+func (f *contentFile) Stat() (os.FileInfo, error)               { return f.info, nil }
+
+func (f *contentFile) Read(p []byte) (int, error) {
+	if f.data == nil {
+		data, _, err := f.contentService.GetContentData(f.ctx, f.content.ID)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := io.CopyN(io.Discard, data, f.offset); err != nil {
+			data.Close()
+			return 0, err
+		}
+		f.data = data
+		f.reader = f.throttle.reader(f.ctx, data, f.content.TenantID)
+	}
+	n, err := f.reader.Read(p)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *contentFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.content.FileSize
+	}
+	if offset < 0 {
+		return 0, fs.ErrInvalid
+	}
+	if offset != f.offset && f.data != nil {
+		f.data.Close()
+		f.data = nil
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *contentFile) Close() error {
+	if f.data != nil {
+		return f.data.Close()
+	}
+	return nil
+}
+
+// uploadFile streams the data written to it into a new content item linked
+// to the entity of its folder. The content it replaces is detached on Close.
+type uploadFile struct {
+	path     davPath
+	previous *model.Content
+	service  *service.ContentService
+	ctx      context.Context
+	pipe     *io.PipeWriter
+	written  int64
+	done     chan error
+}
+
+func newUploadFile(ctx context.Context, contentService *service.ContentService, p davPath, previous *model.Content) *uploadFile {
+	mimeType := mime.TypeByExtension(path.Ext(p.fileName))
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+
+	reader, writer := io.Pipe()
+	f := &uploadFile{path: p, previous: previous, service: contentService, ctx: ctx, pipe: writer, done: make(chan error, 1)}
+	go func() {
+		_, err := contentService.CreateContent(ctx, service.CreateContentInput{
+			FileName:   p.fileName,
+			MIMEType:   mimeType,
+			FileSize:   storage.UnknownSize,
+			Data:       reader,
+			EntityType: p.entityType,
+			EntityID:   p.entityID,
+			Source:     webDAVSource,
+		})
+		reader.CloseWithError(err)
+		f.done <- err
+	}()
+	return f
+}
+
+func (f *uploadFile) Readdir(count int) ([]os.FileInfo, error)     { return nil, fs.ErrInvalid }
+func (f *uploadFile) Read(p []byte) (int, error)                   { return 0, fs.ErrInvalid }
+func (f *uploadFile) Seek(offset int64, whence int) (int64, error) { return f.written, nil }
+
+func (f *uploadFile) Stat() (os.FileInfo, error) {
+	return &davFileInfo{name: f.path.fileName, size: f.written, modTime: time.Now()}, nil
+}
+
+func (f *uploadFile) Write(p []byte) (int, error) {
+	n, err := f.pipe.Write(p)
+	f.written += int64(n)
+	return n, err
+}
+
+// Close finishes the upload and detaches the content it replaces
+func (f *uploadFile) Close() error {
+	f.pipe.Close()
+	if err := <-f.done; err != nil {
+		return err
+	}
+	if f.previous == nil {
+		return nil
+	}
+	_, err := f.service.DeleteContent(f.ctx, f.previous.ID, service.DeleteContentOptions{
+		Policy:     service.DeleteLastReference,
+		EntityType: f.path.entityType,
+		EntityID:   f.path.entityID,
+	})
+	if errors.Is(err, service.ErrContentNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/livefire2015/simple-contents/repository/memory"
+	"github.com/livefire2015/simple-contents/service"
+	"github.com/livefire2015/simple-contents/storage/memorystorage"
+)
+
+func TestWebDAVServesActiveContentAsData(t *testing.T) {
+	ctx := context.Background()
+	contentService := service.NewContentService(memory.NewMemoryRepository(), memorystorage.NewMemoryStorage())
+	handler := NewWebDAVHandler(contentService, WebDAVConfig{EntityTypes: []string{"customer"}})
+
+	tests := []struct {
+		fileName string
+		mimeType string
+		want     string
+	}{
+		{fileName: "page.html", mimeType: "text/html", want: "application/octet-stream"},
+		{fileName: "logo.svg", mimeType: "image/svg+xml", want: "application/octet-stream"},
+		{fileName: "feed.xml", mimeType: "application/xml; charset=utf-8", want: "application/octet-stream"},
+		{fileName: "scan.png", mimeType: "image/png", want: "image/png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			data := "<svg onload=alert(1)>"
+			_, err := contentService.CreateContent(ctx, service.CreateContentInput{
+				FileName: tt.fileName, MIMEType: tt.mimeType, FileSize: int64(len(data)), Data: strings.NewReader(data),
+				EntityType: "customer", EntityID: "42",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/dav/customer/42/"+tt.fileName, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.want {
+				t.Errorf("Content-Type = %q, want %q", got, tt.want)
+			}
+			if got := w.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+				t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+			}
+			if body, _ := io.ReadAll(w.Body); string(body) != data {
+				t.Errorf("body = %q, want %q", body, data)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// wsMaxClientPayload bounds frames accepted from clients
+	wsMaxClientPayload = 4096
+	// wsWriteTimeout bounds a single frame write to a slow client
+	wsWriteTimeout = 10 * time.Second
+)
+
+var errForbiddenOrigin = errors.New("websocket origin not allowed")
+
+// WebSocketConfig controls which pages may open WebSocket connections
+type WebSocketConfig struct {
+	// AllowedOrigins are the origins, e.g. https://app.example.com, whose
+	// pages may connect besides the service itself; "*" allows any origin.
+	// Clients that send no Origin, i.e. that aren't browsers, are always allowed.
+	AllowedOrigins []string
+}
+
+// ConfigureWebSocket sets the origins allowed to open WebSocket connections
+func (h *ContentHandler) ConfigureWebSocket(config WebSocketConfig) {
+	h.webSocket = config
+}
+
+// checkOrigin rejects handshakes from browser pages of other origins, so a
+// malicious page can't subscribe to events with the visitor's credentials
+func (c WebSocketConfig) checkOrigin(config *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return errForbiddenOrigin
+	}
+	if strings.EqualFold(u.Host, r.Host) ||
+		slices.Contains(c.AllowedOrigins, "*") ||
+		slices.ContainsFunc(c.AllowedOrigins, func(allowed string) bool { return strings.EqualFold(allowed, origin) }) {
+		config.Origin = u
+		return nil
+	}
+	return errForbiddenOrigin
+}
+
+// serveWebSocket upgrades the request and calls handler with the connection.
+// Handshakes that aren't valid or come from a forbidden origin are rejected
+// by the websocket package with 400 Bad Request or 403 Forbidden.
+func (h *ContentHandler) serveWebSocket(w http.ResponseWriter, r *http.Request, handler func(*wsConn)) {
+	server := websocket.Server{
+		Handshake: h.webSocket.checkOrigin,
+		Handler: func(ws *websocket.Conn) {
+			ws.MaxPayloadBytes = wsMaxClientPayload
+			handler(&wsConn{ws: ws})
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+// wsConn is an upgraded WebSocket connection. The websocket package answers
+// pings and close frames while the connection is read.
+type wsConn struct {
+	ws *websocket.Conn
+}
+
+// WriteText sends a text message
+func (c *wsConn) WriteText(payload []byte) error {
+	c.ws.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return websocket.Message.Send(c.ws, string(payload))
+}
+
+// Ping sends a ping to keep the connection alive. It must not be called
+// concurrently with WriteText.
+func (c *wsConn) Ping() error {
+	c.ws.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	c.ws.PayloadType = websocket.PingFrame
+	defer func() { c.ws.PayloadType = websocket.TextFrame }()
+	_, err := c.ws.Write(nil)
+	return err
+}
+
+// readLoop discards client messages and returns when the client closes the
+// connection, a read fails or a frame is too large
+func (c *wsConn) readLoop() {
+	for {
+		var message []byte
+		if err := websocket.Message.Receive(c.ws, &message); err != nil {
+			return
+		}
+	}
+}
+
+// Close closes the connection
+func (c *wsConn) Close() error {
+	return c.ws.Close()
+}
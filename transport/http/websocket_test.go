@@ -0,0 +1,191 @@
+package http
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/simple-contents/service"
+	"golang.org/x/net/websocket"
+)
+
+// newEventServer serves entity events from the returned channel over WebSocket
+func newEventServer(t *testing.T, config WebSocketConfig) (*httptest.Server, chan service.Event) {
+	t.Helper()
+	events := make(chan service.Event, 1)
+	h := &ContentHandler{webSocket: config}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.serveWebSocket(w, r, func(conn *wsConn) {
+			defer conn.Close()
+			streamEntityEvents(conn, events)
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, events
+}
+
+// rawHandshake opens a WebSocket without a client library, so the test
+// controls the Origin header and the frames sent
+func rawHandshake(t *testing.T, addr, origin string) (net.Conn, *bufio.Reader, int) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	request := "GET /ws HTTP/1.1\r\n" +
+		"Host: " + addr + "\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n" +
+		"Sec-WebSocket-Version: 13\r\n"
+	if origin != "" {
+		request += "Origin: " + origin + "\r\n"
+	}
+	if _, err := io.WriteString(conn, request+"\r\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode == http.StatusSwitchingProtocols {
+		// RFC 6455 section 1.3 example key and accept value
+		if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+			t.Errorf("Sec-WebSocket-Accept = %q", got)
+		}
+	}
+	return conn, reader, resp.StatusCode
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+	server, _ := newEventServer(t, WebSocketConfig{AllowedOrigins: []string{"https://app.example.com"}})
+	addr := strings.TrimPrefix(server.URL, "http://")
+
+	tests := []struct {
+		name       string
+		origin     string
+		wantStatus int
+	}{
+		{name: "no origin", origin: "", wantStatus: http.StatusSwitchingProtocols},
+		{name: "same origin", origin: "http://" + addr, wantStatus: http.StatusSwitchingProtocols},
+		{name: "allowed origin", origin: "https://app.example.com", wantStatus: http.StatusSwitchingProtocols},
+		{name: "other origin", origin: "https://evil.example.com", wantStatus: http.StatusForbidden},
+		{name: "null origin", origin: "null", wantStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, status := rawHandshake(t, addr, tt.origin); status != tt.wantStatus {
+				t.Errorf("handshake status = %d, want %d", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestWebSocketStreamsEvents(t *testing.T) {
+	server, events := newEventServer(t, WebSocketConfig{})
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	ws, err := websocket.Dial(wsURL, "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	// Client messages are masked by the client library and discarded by the server
+	if err := websocket.Message.Send(ws, "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := service.Event{Type: service.EventContentAdded, ContentID: uuid.New(), EntityType: "order", EntityID: "1"}
+	events <- want
+
+	ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var got service.Event
+	if err := websocket.JSON.Receive(ws, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ContentID != want.ContentID || got.EntityID != want.EntityID {
+		t.Errorf("received event %+v, want %+v", got, want)
+	}
+}
+
+func TestWebSocketFraming(t *testing.T) {
+	tests := []struct {
+		name string
+		// frame is written by the client after the handshake
+		frame     []byte
+		wantClose bool
+	}{
+		{
+			name: "masked text",
+			// "Hello" masked with 37 fa 21 3d, from RFC 6455 section 5.7
+			frame:     []byte{0x81, 0x85, 0x37, 0xfa, 0x21, 0x3d, 0x7f, 0x9f, 0x4d, 0x51, 0x58},
+			wantClose: false,
+		},
+		{
+			name:      "unmasked text",
+			frame:     []byte{0x81, 0x05, 'H', 'e', 'l', 'l', 'o'},
+			wantClose: true,
+		},
+		{
+			name:      "oversized frame",
+			frame:     append([]byte{0x82, 0xFE, 0x20, 0x00, 0, 0, 0, 0}, make([]byte, 0x2000)...),
+			wantClose: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newEventServer(t, WebSocketConfig{})
+			conn, reader, status := rawHandshake(t, strings.TrimPrefix(server.URL, "http://"), "")
+			if status != http.StatusSwitchingProtocols {
+				t.Fatalf("handshake status = %d", status)
+			}
+			if _, err := conn.Write(tt.frame); err != nil {
+				t.Fatal(err)
+			}
+
+			conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+			closed, err := readUntilClosed(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if closed != tt.wantClose {
+				t.Errorf("connection closed = %v, want %v", closed, tt.wantClose)
+			}
+		})
+	}
+}
+
+// readUntilClosed reads server frames, which must be unmasked, and reports
+// whether the server closed the connection before the read deadline
+func readUntilClosed(reader *bufio.Reader) (bool, error) {
+	for {
+		header := make([]byte, 2)
+		if _, err := io.ReadFull(reader, header); err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return false, nil
+			}
+			return true, nil
+		}
+		if header[1]&0x80 != 0 {
+			return false, fmt.Errorf("server sent a masked frame")
+		}
+		if _, err := io.CopyN(io.Discard, reader, int64(header[1]&0x7F)); err != nil {
+			return true, nil
+		}
+	}
+}
@@ -0,0 +1,291 @@
+// Package validate checks structs against the rules of their validate tags,
+// returning an error per failing field. The tags follow the syntax of
+// go-playground/validator:
+//
+//	FileName string `validate:"required,max=255"`
+//
+// Rules are separated by commas and checked in order, stopping at the first
+// failure of a field:
+//
+//	required   not the zero value, e.g. a non-empty string or a non-nil map
+//	omitempty  skip the other rules when the value is zero
+//	min=N      at least N characters of a string, entries of a slice or
+//	           map, or the value N of a number
+//	max=N      at most N, counted as min
+//	oneof=a b  one of the values separated by spaces
+//	uuid       a UUID string
+//	url        an absolute URL with a host
+//
+// Rules other than required and omitempty check the value a pointer points
+// to, so optional fields of updates can be limited too. Fields are named
+// after their JSON name, or their Go name in snake_case.
+// Nested structs are checked too, their fields named with a dot.
+package validate
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// FieldError is a field that breaks a rule
+type FieldError struct {
+	Field   string `json:"field"`   // e.g. "file_name" or "retention.days"
+	Message string `json:"message"` // e.g. "must be at most 255 characters"
+}
+
+// Errors is the failing fields of a struct, in the order of the fields
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	reasons := make([]string, len(e))
+	for i, field := range e {
+		reasons[i] = field.Field + " " + field.Message
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// rule is a parsed validate tag rule
+type rule struct {
+	name  string
+	param string
+}
+
+// field is a validated field of a struct type
+type field struct {
+	index  int
+	name   string
+	rules  []rule
+	nested bool // A struct whose fields are checked too
+}
+
+// fields caches the validated fields of struct types
+var fields sync.Map // reflect.Type -> []field
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Struct checks the fields of a struct, or of the struct a pointer points
+// to, and returns Errors if any fails. It panics on malformed tags.
+func Struct(s interface{}) error {
+	value := reflect.ValueOf(s)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validate: %T is not a struct", s))
+	}
+
+	var errs Errors
+	check(value, "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// check appends the failing fields of a struct value to errs
+func check(value reflect.Value, prefix string, errs *Errors) {
+	for _, f := range fieldsOf(value.Type()) {
+		fieldValue := value.Field(f.index)
+		name := prefix + f.name
+		if message := apply(f.rules, fieldValue); message != "" {
+			*errs = append(*errs, FieldError{Field: name, Message: message})
+			continue
+		}
+		if f.nested {
+			for fieldValue.Kind() == reflect.Ptr {
+				if fieldValue.IsNil() {
+					break
+				}
+				fieldValue = fieldValue.Elem()
+			}
+			if fieldValue.Kind() == reflect.Struct {
+				check(fieldValue, name+".", errs)
+			}
+		}
+	}
+}
+
+// fieldsOf returns the validated fields of a struct type
+func fieldsOf(t reflect.Type) []field {
+	if cached, ok := fields.Load(t); ok {
+		return cached.([]field)
+	}
+
+	var result []field
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		tag, hasTag := structField.Tag.Lookup("validate")
+		if !structField.IsExported() || tag == "-" {
+			continue
+		}
+		fieldType := structField.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		nested := fieldType.Kind() == reflect.Struct && fieldType != timeType
+		if !hasTag && !nested {
+			continue
+		}
+		result = append(result, field{
+			index:  i,
+			name:   fieldName(structField),
+			rules:  parseRules(t, structField.Name, tag),
+			nested: nested,
+		})
+	}
+
+	fields.Store(t, result)
+	return result
+}
+
+// parseRules parses a validate tag
+func parseRules(t reflect.Type, fieldName, tag string) []rule {
+	if tag == "" {
+		return nil
+	}
+	var rules []rule
+	for _, part := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(part, "=")
+		switch name {
+		case "required", "omitempty", "uuid", "url":
+		case "min", "max":
+			if _, err := strconv.ParseInt(param, 10, 64); err != nil {
+				panic(fmt.Sprintf("validate: %s.%s: %s needs an integer", t, fieldName, name))
+			}
+		case "oneof":
+			if param == "" {
+				panic(fmt.Sprintf("validate: %s.%s: oneof needs values", t, fieldName))
+			}
+		default:
+			panic(fmt.Sprintf("validate: %s.%s: unknown rule %q", t, fieldName, name))
+		}
+		rules = append(rules, rule{name: name, param: param})
+	}
+	return rules
+}
+
+// fieldName returns the name of a field in errors
+func fieldName(structField reflect.StructField) string {
+	if name, _, _ := strings.Cut(structField.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	return snakeCase(structField.Name)
+}
+
+// snakeCase converts a Go name to snake_case, keeping initialisms
+// together: MIMEType is mime_type and EntityID entity_id
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			previousLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if previousLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// apply returns the message of the first rule a value breaks, or "" if it
+// follows them all
+func apply(rules []rule, value reflect.Value) string {
+	target := value
+	for target.Kind() == reflect.Ptr && !target.IsNil() {
+		target = target.Elem()
+	}
+	for _, r := range rules {
+		switch r.name {
+		case "required":
+			if value.IsZero() {
+				return "is required"
+			}
+		case "omitempty":
+			if value.IsZero() {
+				return ""
+			}
+		case "min", "max":
+			if message := checkBound(r, target); message != "" {
+				return message
+			}
+		case "oneof":
+			if target.Kind() == reflect.String && !target.IsZero() && !contains(strings.Fields(r.param), target.String()) {
+				return "must be one of " + strings.Join(strings.Fields(r.param), ", ")
+			}
+		case "uuid":
+			if target.Kind() == reflect.String && !target.IsZero() {
+				if _, err := uuid.Parse(target.String()); err != nil {
+					return "must be a UUID"
+				}
+			}
+		case "url":
+			if target.Kind() == reflect.String && !target.IsZero() {
+				if u, err := url.Parse(target.String()); err != nil || !u.IsAbs() || u.Host == "" {
+					return "must be an absolute URL"
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// checkBound returns the message of a value outside a min or max bound
+func checkBound(r rule, value reflect.Value) string {
+	bound, _ := strconv.ParseInt(r.param, 10, 64)
+	tooSmall := r.name == "min"
+	outside := func(n int64) bool {
+		if tooSmall {
+			return n < bound
+		}
+		return n > bound
+	}
+	limit := "at most"
+	if tooSmall {
+		limit = "at least"
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		if outside(int64(utf8.RuneCountInString(value.String()))) {
+			return fmt.Sprintf("must be %s %d characters", limit, bound)
+		}
+	case reflect.Slice, reflect.Map, reflect.Array:
+		if outside(int64(value.Len())) {
+			return fmt.Sprintf("must have %s %d entries", limit, bound)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if outside(value.Int()) {
+			return fmt.Sprintf("must be %s %d", limit, bound)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if value.Uint() > uint64(1<<63-1) || outside(int64(value.Uint())) {
+			return fmt.Sprintf("must be %s %d", limit, bound)
+		}
+	}
+	return ""
+}
+
+// contains reports whether values holds value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package validate
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type testRetention struct {
+	MaxAgeDays int `json:"max_age_days" validate:"min=0"`
+}
+
+type testInput struct {
+	ID          uuid.UUID              `validate:"required"`
+	FileName    string                 `json:"file_name" validate:"required,max=8"`
+	MIMEType    string                 `validate:"omitempty,max=5"`
+	Mode        string                 `json:"mode" validate:"omitempty,oneof=put post"`
+	ParentID    string                 `json:"parent_id" validate:"omitempty,uuid"`
+	CallbackURL string                 `validate:"omitempty,url"`
+	Metadata    map[string]interface{} `json:"metadata" validate:"max=2"`
+	Note        *string                `json:"note" validate:"max=4"`
+	Size        int64                  `json:"size" validate:"min=1"`
+	Retention   testRetention          `json:"retention"`
+	Ignored     string
+}
+
+func TestStruct(t *testing.T) {
+	valid := func() testInput {
+		return testInput{ID: uuid.New(), FileName: "a.pdf", Size: 1}
+	}
+	tests := []struct {
+		name   string
+		modify func(*testInput)
+		want   Errors
+	}{
+		{name: "valid", modify: func(*testInput) {}},
+		{name: "valid optional fields", modify: func(in *testInput) {
+			in.MIMEType = "a/b"
+			in.Mode = "post"
+			in.ParentID = uuid.NewString()
+			in.CallbackURL = "https://example.com/done"
+			in.Metadata = map[string]interface{}{"a": 1}
+		}},
+		{name: "missing fields", modify: func(in *testInput) { *in = testInput{Size: 1} }, want: Errors{
+			{Field: "id", Message: "is required"},
+			{Field: "file_name", Message: "is required"},
+		}},
+		{name: "too long counts characters", modify: func(in *testInput) { in.FileName = "ééééééééé" }, want: Errors{
+			{Field: "file_name", Message: "must be at most 8 characters"},
+		}},
+		{name: "go names in snake case", modify: func(in *testInput) { in.MIMEType = "application/pdf" }, want: Errors{
+			{Field: "mime_type", Message: "must be at most 5 characters"},
+		}},
+		{name: "oneof", modify: func(in *testInput) { in.Mode = "patch" }, want: Errors{
+			{Field: "mode", Message: "must be one of put, post"},
+		}},
+		{name: "uuid", modify: func(in *testInput) { in.ParentID = "42" }, want: Errors{
+			{Field: "parent_id", Message: "must be a UUID"},
+		}},
+		{name: "url", modify: func(in *testInput) { in.CallbackURL = "/relative" }, want: Errors{
+			{Field: "callback_url", Message: "must be an absolute URL"},
+		}},
+		{name: "map entries", modify: func(in *testInput) { in.Metadata = map[string]interface{}{"a": 1, "b": 2, "c": 3} }, want: Errors{
+			{Field: "metadata", Message: "must have at most 2 entries"},
+		}},
+		{name: "pointer", modify: func(in *testInput) { note := "too long"; in.Note = &note }, want: Errors{
+			{Field: "note", Message: "must be at most 4 characters"},
+		}},
+		{name: "number", modify: func(in *testInput) { in.Size = 0 }, want: Errors{
+			{Field: "size", Message: "must be at least 1"},
+		}},
+		{name: "nested", modify: func(in *testInput) { in.Retention.MaxAgeDays = -1 }, want: Errors{
+			{Field: "retention.max_age_days", Message: "must be at least 0"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := valid()
+			tt.modify(&input)
+			err := Struct(&input)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Struct() error = %v, want nil", err)
+				}
+				return
+			}
+			var errs Errors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Struct() error = %v, want Errors", err)
+			}
+			if !reflect.DeepEqual(errs, tt.want) {
+				t.Errorf("Struct() = %v, want %v", errs, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructPanicsOnUnknownRule(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(string), "unknown rule") {
+			t.Errorf("recover() = %v, want an unknown rule panic", r)
+		}
+	}()
+	Struct(struct {
+		Name string `validate:"requried"`
+	}{})
+}
+
+func TestSnakeCase(t *testing.T) {
+	for name, want := range map[string]string{
+		"FileName":    "file_name",
+		"MIMEType":    "mime_type",
+		"EntityID":    "entity_id",
+		"CallbackURL": "callback_url",
+		"SHA256":      "sha256",
+		"ID":          "id",
+	} {
+		if got := snakeCase(name); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", name, got, want)
+		}
+	}
+}